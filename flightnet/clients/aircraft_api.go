@@ -1,49 +1,315 @@
 package clients
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Aircraft represents aircraft data
 type Aircraft struct {
-	AirplaneID          string `json:"airplaneId"`
-	NumberRegistration  string `json:"numberRegistration"`
-	ProductionLine      string `json:"productionLine"`
-	AirplaneIataType    string `json:"airplaneIataType"`
-	PlaneModel          string `json:"planeModel"`
-	ModelCode           string `json:"modelCode"`
-	HexIcaoAirplane     string `json:"hexIcaoAirplane"`
-	CodeIataPlaneShort  string `json:"codeIataPlaneShort"`
-	CodeIataPlaneLong   string `json:"codeIataPlaneLong"`
-	ConstructionNumber  string `json:"constructionNumber"`
-	RolloutDate         string `json:"rolloutDate"`
-	FirstFlight         string `json:"firstFlight"`
-	DeliveryDate        string `json:"deliveryDate"`
-	RegistrationDate    string `json:"registrationDate"`
-	CodeIataAirline     string `json:"codeIataAirline"`
-	EnginesCount        string `json:"enginesCount"`
-	EnginesType         string `json:"enginesType"`
-	PlaneAge            string `json:"planeAge"`
-	PlaneStatus         string `json:"planeStatus"`
+	AirplaneID         string `json:"airplaneId"`
+	NumberRegistration string `json:"numberRegistration"`
+	ProductionLine     string `json:"productionLine"`
+	AirplaneIataType   string `json:"airplaneIataType"`
+	PlaneModel         string `json:"planeModel"`
+	ModelCode          string `json:"modelCode"`
+	HexIcaoAirplane    string `json:"hexIcaoAirplane"`
+	CodeIataPlaneShort string `json:"codeIataPlaneShort"`
+	CodeIataPlaneLong  string `json:"codeIataPlaneLong"`
+	ConstructionNumber string `json:"constructionNumber"`
+	RolloutDate        string `json:"rolloutDate"`
+	FirstFlight        string `json:"firstFlight"`
+	DeliveryDate       string `json:"deliveryDate"`
+	RegistrationDate   string `json:"registrationDate"`
+	CodeIataAirline    string `json:"codeIataAirline"`
+	EnginesCount       string `json:"enginesCount"`
+	EnginesType        string `json:"enginesType"`
+	PlaneAge           string `json:"planeAge"`
+	PlaneStatus        string `json:"planeStatus"`
+
+	// Details holds the numeric and date fields above converted to typed
+	// values, filled in by the Parser
+	Details AircraftDetails `json:"details"`
+}
+
+// AircraftDetails holds typed values of the Aircraft fields aviation-edge
+// sends as strings. Empty or unparseable fields are left at their zero value.
+type AircraftDetails struct {
+	EnginesCount     int       `json:"enginesCount"`
+	PlaneAgeYears    float64   `json:"planeAgeYears"`
+	RolloutDate      time.Time `json:"rolloutDate"`
+	FirstFlight      time.Time `json:"firstFlight"`
+	DeliveryDate     time.Time `json:"deliveryDate"`
+	RegistrationDate time.Time `json:"registrationDate"`
+}
+
+// aircraftDateLayouts are the date formats seen in airplaneDatabase responses
+var aircraftDateLayouts = []string{
+	"2006-01-02T15:04:05.000Z",
+	time.RFC3339,
+	"2006-01-02",
 }
 
+// derive implements deriver, filling in Details
+func (a *Aircraft) derive() []string {
+	var problems []string
+
+	if s := strings.TrimSpace(a.EnginesCount); s != "" {
+		if n, err := strconv.Atoi(s); err != nil {
+			problems = append(problems, fmt.Sprintf("enginesCount: invalid integer %q", s))
+		} else {
+			a.Details.EnginesCount = n
+		}
+	}
+	if s := strings.TrimSpace(a.PlaneAge); s != "" {
+		if age, err := strconv.ParseFloat(s, 64); err != nil {
+			problems = append(problems, fmt.Sprintf("planeAge: invalid number %q", s))
+		} else {
+			a.Details.PlaneAgeYears = age
+		}
+	}
+
+	dates := []struct {
+		name  string
+		value string
+		dst   *time.Time
+	}{
+		{"rolloutDate", a.RolloutDate, &a.Details.RolloutDate},
+		{"firstFlight", a.FirstFlight, &a.Details.FirstFlight},
+		{"deliveryDate", a.DeliveryDate, &a.Details.DeliveryDate},
+		{"registrationDate", a.RegistrationDate, &a.Details.RegistrationDate},
+	}
+	for _, d := range dates {
+		t, ok := parseAircraftDate(d.value)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: invalid date %q", d.name, d.value))
+			continue
+		}
+		*d.dst = t
+	}
+	return problems
+}
+
+// ageYears returns the plane age, reporting false when it is unknown.
+// Details alone cannot tell a new aircraft from one with an unparseable age.
+func (a *Aircraft) ageYears() (float64, bool) {
+	if _, err := strconv.ParseFloat(strings.TrimSpace(a.PlaneAge), 64); err != nil {
+		return 0, false
+	}
+	return a.Details.PlaneAgeYears, true
+}
+
+// parseAircraftDate parses an airplaneDatabase date. Empty values and the
+// "0000-00-00" sentinel give the zero time.
+func parseAircraftDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "0000-00-00") {
+		return time.Time{}, true
+	}
+	for _, layout := range aircraftDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ErrAircraftNotFound is returned when no aircraft matches a lookup
+var ErrAircraftNotFound = errors.New("aircraft not found")
+
 // AircraftAPI handles aircraft data
 type AircraftAPI struct {
-	fetcher *Fetcher
-	parser  *Parser
+	fetcher   *Fetcher
+	parser    *Parser
+	diskCache *aircraftDiskCache // Set with SetDiskCache
 }
 
 // NewAircraftAPI creates a new AircraftAPI instance
 func NewAircraftAPI() *AircraftAPI {
+	return NewAircraftAPIWithFetcher(NewFetcher())
+}
+
+// NewAircraftAPIWithFetcher creates a AircraftAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewAircraftAPIWithFetcher(fetcher *Fetcher) *AircraftAPI {
 	return &AircraftAPI{
-		fetcher: NewFetcher(),
+		fetcher: fetcher,
 		parser:  NewParser(),
 	}
 }
 
 // GetAircraft fetches aircraft data
 func (a *AircraftAPI) GetAircraft(params map[string]string) ([]Aircraft, error) {
-	data, err := a.fetcher.Get("airplaneDatabase", params)
+	return a.GetAircraftContext(context.Background(), params)
+}
+
+// GetAircraftContext fetches aircraft data, aborting when ctx is done. With
+// a disk cache enabled, requests with no params other than limit are served
+// from the cache.
+func (a *AircraftAPI) GetAircraftContext(ctx context.Context, params map[string]string) ([]Aircraft, error) {
+	if a.diskCache != nil {
+		if limit, ok := cacheableAircraftParams(params); ok {
+			return a.aircraftFromDisk(ctx, limit)
+		}
+	}
+
+	body, err := a.fetcher.GetStream(ctx, "aviation-edge", "airplaneDatabase", params)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return a.parser.ParseAircraftStream(body, 0)
+}
+
+// AircraftFilter selects aircraft from the aircraft database. Empty fields
+// and zero ages match everything.
+type AircraftFilter struct {
+	Airline     string  // Airline IATA code
+	Model       string  // Matched against the plane model, model code and IATA type
+	Status      string  // PlaneStatus, e.g. active or stored
+	MinAgeYears float64 // Aircraft of unknown age never match an age bound
+	MaxAgeYears float64
+}
+
+// Matches reports whether an aircraft passes the filter
+func (f AircraftFilter) Matches(a *Aircraft) bool {
+	if f.Airline != "" && !strings.EqualFold(a.CodeIataAirline, f.Airline) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(strings.TrimSpace(a.PlaneStatus), f.Status) {
+		return false
+	}
+	if f.Model != "" {
+		model := strings.ToUpper(f.Model)
+		if !strings.Contains(strings.ToUpper(a.PlaneModel), model) &&
+			!strings.EqualFold(a.ModelCode, model) && !strings.EqualFold(a.AirplaneIataType, model) {
+			return false
+		}
+	}
+	if f.MinAgeYears > 0 || f.MaxAgeYears > 0 {
+		age, ok := a.ageYears()
+		if !ok {
+			return false
+		}
+		if f.MinAgeYears > 0 && age < f.MinAgeYears || f.MaxAgeYears > 0 && age > f.MaxAgeYears {
+			return false
+		}
+	}
+	return true
+}
+
+// AircraftPage is one page of filtered aircraft
+type AircraftPage struct {
+	Aircraft []Aircraft `json:"aircraft"`
+	Total    int        `json:"total"` // Aircraft matching the filter across all pages
+	Limit    int        `json:"limit"`
+	Offset   int        `json:"offset"`
+}
+
+// GetAircraftFiltered returns the page of aircraft matching filter that
+// starts at offset and holds up to limit aircraft. A limit of 0 returns all
+// matches from offset on.
+func (a *AircraftAPI) GetAircraftFiltered(filter AircraftFilter, limit, offset int) (*AircraftPage, error) {
+	return a.GetAircraftFilteredContext(context.Background(), filter, limit, offset)
+}
+
+// GetAircraftFilteredContext returns a page of aircraft matching filter,
+// aborting when ctx is done. The upstream response is filtered as it streams
+// in, so only the requested page is held in memory.
+func (a *AircraftAPI) GetAircraftFilteredContext(ctx context.Context, filter AircraftFilter, limit, offset int) (*AircraftPage, error) {
+	if limit < 0 || offset < 0 {
+		return nil, fmt.Errorf("invalid page: limit %d, offset %d", limit, offset)
+	}
+
+	params := map[string]string{}
+	if filter.Airline != "" {
+		params["codeIataAirline"] = strings.ToUpper(filter.Airline)
+	}
+	body, err := a.fetcher.GetStream(ctx, "aviation-edge", "airplaneDatabase", params)
 	if err != nil {
 		return nil, err
 	}
-	
-	return a.parser.ParseAircraftResponse(data)
+	defer body.Close()
+
+	page := &AircraftPage{Aircraft: []Aircraft{}, Limit: limit, Offset: offset}
+	err = a.parser.EachAircraft(body, func(aircraft Aircraft) bool {
+		if !filter.Matches(&aircraft) {
+			return true
+		}
+		if page.Total >= offset && (limit == 0 || len(page.Aircraft) < limit) {
+			page.Aircraft = append(page.Aircraft, aircraft)
+		}
+		page.Total++
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// GetAircraftByRegistration looks up an aircraft by its registration (tail number)
+func (a *AircraftAPI) GetAircraftByRegistration(reg string) (*Aircraft, error) {
+	return a.GetAircraftByRegistrationContext(context.Background(), reg)
 }
 
+// GetAircraftByRegistrationContext looks up an aircraft by its registration,
+// aborting when ctx is done
+func (a *AircraftAPI) GetAircraftByRegistrationContext(ctx context.Context, reg string) (*Aircraft, error) {
+	return a.lookup(ctx, "numberRegistration", normalizeAircraftID(reg), func(ac *Aircraft) string {
+		return ac.NumberRegistration
+	})
+}
+
+// GetAircraftByHexICAO looks up an aircraft by its ICAO 24-bit address in hex
+func (a *AircraftAPI) GetAircraftByHexICAO(hex string) (*Aircraft, error) {
+	return a.GetAircraftByHexICAOContext(context.Background(), hex)
+}
+
+// GetAircraftByHexICAOContext looks up an aircraft by its ICAO 24-bit
+// address in hex, aborting when ctx is done
+func (a *AircraftAPI) GetAircraftByHexICAOContext(ctx context.Context, hex string) (*Aircraft, error) {
+	return a.lookup(ctx, "hexIcaoAirplane", normalizeAircraftID(hex), func(ac *Aircraft) string {
+		return ac.HexIcaoAirplane
+	})
+}
+
+// lookup queries the aircraft database with param set to id and returns the
+// aircraft whose field, as returned by idOf, matches id
+func (a *AircraftAPI) lookup(ctx context.Context, param, id string, idOf func(*Aircraft) string) (*Aircraft, error) {
+	if id == "" {
+		return nil, fmt.Errorf("empty %s", param)
+	}
+
+	data, err := a.fetcher.GetContext(ctx, "aviation-edge", "airplaneDatabase", map[string]string{param: id})
+	if err != nil {
+		return nil, err
+	}
+	aircraft, err := a.parser.ParseAircraftResponse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range aircraft {
+		if normalizeAircraftID(idOf(&aircraft[i])) == id {
+			return &aircraft[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s %s: %w", param, id, ErrAircraftNotFound)
+}
+
+// normalizeAircraftID uppercases a registration or hex address and strips
+// hyphens and spaces, so "g-euPT" and "GEUPT" compare equal
+func normalizeAircraftID(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(id)))
+}
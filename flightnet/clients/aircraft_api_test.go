@@ -0,0 +1,84 @@
+package clients
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestAircraftAPI returns an AircraftAPI whose aviation-edge upstream is
+// handler
+func newTestAircraftAPI(t *testing.T, handler http.HandlerFunc) *AircraftAPI {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	f := NewFetcher()
+	err := f.RegisterAPI("aviation-edge", APIConfig{
+		BaseURL:   server.URL,
+		APIKey:    "test-key",
+		AuthStyle: AuthKeyInQuery,
+		AuthParam: "key",
+		Retry:     RetryPolicy{MaxAttempts: 1},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAircraftAPIWithFetcher(f)
+}
+
+func TestAircraftLookup(t *testing.T) {
+	api := newTestAircraftAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/airplaneDatabase" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		switch {
+		case q.Get("numberRegistration") == "GEUPT":
+			// aviation-edge answers some single matches with a bare object
+			w.Write([]byte(`{"airplaneId":"1","numberRegistration":"G-EUPT","hexIcaoAirplane":"400A0B","planeModel":"A319"}`))
+		case q.Get("hexIcaoAirplane") == "A1B2C3":
+			w.Write([]byte(`[
+				{"airplaneId":"2","numberRegistration":"N12345","hexIcaoAirplane":"A1B2C4","planeModel":"737"},
+				{"airplaneId":"3","numberRegistration":"N54321","hexIcaoAirplane":"a1b2c3","planeModel":"787"}
+			]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+
+	t.Run("registration, single object", func(t *testing.T) {
+		aircraft, err := api.GetAircraftByRegistration(" g-euPT ")
+		if err != nil {
+			t.Fatalf("GetAircraftByRegistration: %v", err)
+		}
+		if aircraft.AirplaneID != "1" || aircraft.PlaneModel != "A319" {
+			t.Errorf("got %+v, want G-EUPT", aircraft)
+		}
+	})
+
+	t.Run("hex ICAO, array", func(t *testing.T) {
+		aircraft, err := api.GetAircraftByHexICAO("a1b2c3")
+		if err != nil {
+			t.Fatalf("GetAircraftByHexICAO: %v", err)
+		}
+		if aircraft.AirplaneID != "3" || aircraft.NumberRegistration != "N54321" {
+			t.Errorf("got %+v, want the array element matching A1B2C3", aircraft)
+		}
+	})
+
+	t.Run("empty array", func(t *testing.T) {
+		_, err := api.GetAircraftByRegistration("N00000")
+		if !errors.Is(err, ErrAircraftNotFound) {
+			t.Errorf("error %v, want ErrAircraftNotFound", err)
+		}
+	})
+
+	t.Run("empty id", func(t *testing.T) {
+		if _, err := api.GetAircraftByHexICAO(" - "); err == nil {
+			t.Error("looking up an empty hex address succeeded")
+		}
+	})
+}
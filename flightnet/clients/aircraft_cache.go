@@ -0,0 +1,177 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultAircraftCacheMaxAge is how long a cached aircraft database is
+// served before it is refreshed
+const DefaultAircraftCacheMaxAge = 24 * time.Hour
+
+// aircraftDiskCache keeps the raw airplaneDatabase response on disk. The
+// file's modification time records when it was fetched.
+type aircraftDiskCache struct {
+	path       string
+	maxAge     time.Duration
+	mu         sync.Mutex  // Serializes refreshes
+	refreshing atomic.Bool // Set while a background refresh runs
+}
+
+// SetDiskCache makes GetAircraft serve the full aircraft database from a
+// file at path, downloading it on first use and refreshing it in the
+// background once it is older than maxAge (DefaultAircraftCacheMaxAge when
+// zero). An empty path disables the cache.
+func (a *AircraftAPI) SetDiskCache(path string, maxAge time.Duration) {
+	if path == "" {
+		a.diskCache = nil
+		return
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultAircraftCacheMaxAge
+	}
+	a.diskCache = &aircraftDiskCache{path: path, maxAge: maxAge}
+}
+
+// RefreshCache downloads the aircraft database and replaces the cache file
+func (a *AircraftAPI) RefreshCache(ctx context.Context) error {
+	if a.diskCache == nil {
+		return errors.New("aircraft disk cache is not enabled")
+	}
+	return a.refreshDiskCache(ctx, true)
+}
+
+// cacheableAircraftParams reports whether a request can be answered from the
+// cached full database. Only the limit, which is applied locally, is allowed.
+func cacheableAircraftParams(params map[string]string) (limit int, ok bool) {
+	for key, value := range params {
+		if key != "limit" {
+			return 0, false
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		limit = n
+	}
+	return limit, true
+}
+
+// aircraftFromDisk serves up to limit aircraft from the cache file,
+// downloading it when missing or unreadable and starting a background
+// refresh when it is stale
+func (a *AircraftAPI) aircraftFromDisk(ctx context.Context, limit int) ([]Aircraft, error) {
+	aircraft, fetchedAt, err := a.readDiskCache(limit)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Aircraft cache %s unusable, fetching from network: %v", a.diskCache.path, err)
+		}
+		if err := a.refreshDiskCache(ctx, false); err != nil {
+			return nil, err
+		}
+		if aircraft, _, err = a.readDiskCache(limit); err != nil {
+			return nil, fmt.Errorf("failed to read refreshed aircraft cache: %w", err)
+		}
+		return aircraft, nil
+	}
+
+	if time.Since(fetchedAt) > a.diskCache.maxAge && a.diskCache.refreshing.CompareAndSwap(false, true) {
+		go func() {
+			defer a.diskCache.refreshing.Store(false)
+			if err := a.refreshDiskCache(context.Background(), true); err != nil {
+				log.Printf("Background refresh of aircraft cache %s failed: %v", a.diskCache.path, err)
+			}
+		}()
+	}
+	return aircraft, nil
+}
+
+// readDiskCache parses up to limit aircraft from the cache file and returns
+// the time it was fetched
+func (a *AircraftAPI) readDiskCache(limit int) ([]Aircraft, time.Time, error) {
+	f, err := os.Open(a.diskCache.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	aircraft, err := a.parser.ParseAircraftStream(f, limit)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return aircraft, info.ModTime(), nil
+}
+
+// refreshDiskCache downloads the full aircraft database to a temporary file
+// and renames it over the cache file once it has been parsed successfully.
+// Unless force is set, a cache file that is already fresh is kept.
+func (a *AircraftAPI) refreshDiskCache(ctx context.Context, force bool) error {
+	cache := a.diskCache
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	// Another caller may have refreshed the file while this one waited
+	if !force {
+		if info, err := os.Stat(cache.path); err == nil && time.Since(info.ModTime()) <= cache.maxAge {
+			if _, _, err := a.readDiskCache(1); err == nil {
+				return nil
+			}
+		}
+	}
+
+	fetchedAt := time.Now()
+	body, err := a.fetcher.GetStream(ctx, "aviation-edge", "airplaneDatabase", nil)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(cache.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create aircraft cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(cache.path), filepath.Base(cache.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create aircraft cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	// Check the response parses while writing it so a truncated or error
+	// response never replaces a good cache
+	count := 0
+	if err := a.parser.EachAircraft(io.TeeReader(body, tmp), func(Aircraft) bool {
+		count++
+		return true
+	}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write aircraft cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write aircraft cache: %w", err)
+	}
+	if err := os.Chtimes(tmp.Name(), fetchedAt, fetchedAt); err != nil {
+		return fmt.Errorf("failed to record aircraft cache fetch time: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), cache.path); err != nil {
+		return fmt.Errorf("failed to replace aircraft cache: %w", err)
+	}
+
+	log.Printf("Cached %d aircraft to %s", count, cache.path)
+	return nil
+}
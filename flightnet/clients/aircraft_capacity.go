@@ -0,0 +1,106 @@
+package clients
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/your-project/clients/internal/dataset"
+)
+
+// defaultAircraftSeats is the seat count assumed for aircraft types that are
+// not in the capacity table, a typical narrow-body
+const defaultAircraftSeats = 150
+
+// aircraftTypesCSV maps ICAO aircraft type designators to typical seat counts
+// in a two-class layout, maximum takeoff weights and average cruise fuel burn
+var aircraftTypesCSV = dataset.AircraftTypes
+
+// AircraftCapacity is the typical capacity of an aircraft type
+type AircraftCapacity struct {
+	Designator string `json:"designator"` // ICAO type designator, e.g. B738
+	Name       string `json:"name"`
+	Seats      int    `json:"seats"`
+	MTOWKg     int    `json:"mtow_kg"` // Maximum takeoff weight
+	// BurnKgPerKm is the typical fuel burn per km flown, averaged over a
+	// medium-length flight
+	BurnKgPerKm float64 `json:"burn_kg_per_km"`
+}
+
+var (
+	aircraftCapacitiesOnce sync.Once
+	aircraftCapacities     map[string]AircraftCapacity
+)
+
+// loadAircraftCapacities parses the embedded capacity table on first use
+func loadAircraftCapacities() map[string]AircraftCapacity {
+	aircraftCapacitiesOnce.Do(func() {
+		capacities, err := parseAircraftCapacities(aircraftTypesCSV)
+		if err != nil {
+			log.Printf("Failed to load embedded aircraft capacity table: %v", err)
+			capacities = map[string]AircraftCapacity{}
+		}
+		aircraftCapacities = capacities
+	})
+	return aircraftCapacities
+}
+
+// parseAircraftCapacities parses CSV data with a header row of
+// designator,name,seats,mtow_kg,burn_kg_per_km
+func parseAircraftCapacities(data string) (map[string]AircraftCapacity, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read aircraft capacity data: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty aircraft capacity data")
+	}
+
+	capacities := make(map[string]AircraftCapacity, len(records)-1)
+	for i, record := range records[1:] {
+		if len(record) != 5 {
+			return nil, fmt.Errorf("aircraft capacity line %d: expected 5 fields, got %d", i+2, len(record))
+		}
+		seats, err := strconv.Atoi(record[2])
+		if err != nil || seats <= 0 {
+			return nil, fmt.Errorf("aircraft capacity line %d: invalid seat count %q", i+2, record[2])
+		}
+		mtow, err := strconv.Atoi(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("aircraft capacity line %d: invalid takeoff weight: %w", i+2, err)
+		}
+		burn, err := strconv.ParseFloat(record[4], 64)
+		if err != nil || burn <= 0 {
+			return nil, fmt.Errorf("aircraft capacity line %d: invalid fuel burn %q", i+2, record[4])
+		}
+		capacities[record[0]] = AircraftCapacity{Designator: record[0], Name: record[1], Seats: seats, MTOWKg: mtow, BurnKgPerKm: burn}
+	}
+	return capacities, nil
+}
+
+// LookupAircraftCapacity returns the typical seat count of an ICAO aircraft
+// type designator such as A388 or E190, in any case
+func LookupAircraftCapacity(typeDesignator string) (seats int, ok bool) {
+	capacity, ok := GetAircraftCapacity(typeDesignator)
+	return capacity.Seats, ok
+}
+
+// GetAircraftCapacity returns the typical seat count, maximum takeoff weight
+// and fuel burn of an ICAO aircraft type designator, in any case
+func GetAircraftCapacity(typeDesignator string) (AircraftCapacity, bool) {
+	capacity, ok := loadAircraftCapacities()[strings.ToUpper(strings.TrimSpace(typeDesignator))]
+	return capacity, ok
+}
+
+// aircraftSeats returns the seat count to spread an aircraft's fuel burn and
+// emissions over, defaultAircraftSeats for unknown types
+func aircraftSeats(typeDesignator string) int {
+	if seats, ok := LookupAircraftCapacity(typeDesignator); ok {
+		return seats
+	}
+	return defaultAircraftSeats
+}
@@ -0,0 +1,140 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// co2PerKgFuel is the CO2 emitted by burning a kilogram of jet fuel, per
+// ICAO's carbon emissions calculator
+const co2PerKgFuel = 3.16
+
+// Aircraft comparison data sources
+const (
+	ComparisonSourceFuelAPI  = "fuel-api"
+	ComparisonSourceBurnRate = "burn-rate table" // The embedded aircraft type table
+)
+
+// AircraftComparison is one aircraft type's fuel burn and emissions over a
+// distance
+type AircraftComparison struct {
+	Aircraft     string  `json:"aircraft"` // ICAO type designator
+	Name         string  `json:"name,omitempty"`
+	Rank         int     `json:"rank,omitempty"` // 1 for the lowest CO2 per seat-km, zero on error
+	Seats        int     `json:"seats"`
+	SeatsAssumed bool    `json:"seats_assumed,omitempty"` // The type's seat count is unknown, so the default was used
+	FuelKg       float64 `json:"fuel_kg"`
+	CO2Kg        float64 `json:"co2_kg"`
+	CO2PerSeatKm float64 `json:"co2_per_seat_km"` // Grams
+	// DeltaPercent is how much more CO2 per seat-km the type emits than the
+	// best one
+	DeltaPercent float64 `json:"delta_percent"`
+	Source       string  `json:"source,omitempty"`
+	Error        string  `json:"error,omitempty"`
+}
+
+// ComparisonReport ranks aircraft types by CO2 per seat-km over a distance
+type ComparisonReport struct {
+	DistanceKm float64              `json:"distance_km"`
+	Best       string               `json:"best"`     // Type designator of the greenest aircraft
+	Aircraft   []AircraftComparison `json:"aircraft"` // By rank, failed types last
+}
+
+// CompareAircraft compares the fuel burn and emissions of aircraft types
+// over a distance
+func (s *SustainabilityAPI) CompareAircraft(aircraft []string, distanceKM float64) (*ComparisonReport, error) {
+	return s.CompareAircraftContext(context.Background(), aircraft, distanceKM)
+}
+
+// CompareAircraftContext compares aircraft types over a distance, aborting
+// when ctx is done. Each type is looked up with the fuel API, falling back
+// to the embedded burn-rate table, and the types are ranked by CO2 per
+// seat-km. A type that cannot be assessed is reported with its error; the
+// comparison fails only when no type can be.
+func (s *SustainabilityAPI) CompareAircraftContext(ctx context.Context, aircraft []string, distanceKM float64) (*ComparisonReport, error) {
+	if len(aircraft) == 0 {
+		return nil, errors.New("no aircraft to compare")
+	}
+	if !(distanceKM > 0) {
+		return nil, fmt.Errorf("invalid distance %v: must be above 0", distanceKM)
+	}
+
+	report := &ComparisonReport{DistanceKm: distanceKM}
+	var compared, failed []AircraftComparison
+	seen := make(map[string]bool)
+	for _, designator := range aircraft {
+		designator = strings.ToUpper(strings.TrimSpace(designator))
+		if designator == "" || seen[designator] {
+			continue
+		}
+		seen[designator] = true
+
+		entry, err := s.compareAircraftType(ctx, designator, distanceKM)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			entry.Error = err.Error()
+			failed = append(failed, entry)
+			continue
+		}
+		compared = append(compared, entry)
+	}
+	if len(compared) == 0 {
+		return nil, fmt.Errorf("no aircraft type could be assessed: %s", failed[0].Error)
+	}
+
+	sort.SliceStable(compared, func(i, j int) bool { return compared[i].CO2PerSeatKm < compared[j].CO2PerSeatKm })
+	best := compared[0].CO2PerSeatKm
+	for i := range compared {
+		compared[i].Rank = i + 1
+		if best > 0 {
+			compared[i].DeltaPercent = math.Round((compared[i].CO2PerSeatKm/best-1)*1000) / 10
+		}
+	}
+	report.Best = compared[0].Aircraft
+	report.Aircraft = append(compared, failed...)
+	return report, nil
+}
+
+// compareAircraftType assesses one aircraft type over a distance
+func (s *SustainabilityAPI) compareAircraftType(ctx context.Context, designator string, distanceKM float64) (AircraftComparison, error) {
+	entry := AircraftComparison{Aircraft: designator, Seats: defaultAircraftSeats, SeatsAssumed: true}
+	capacity, known := GetAircraftCapacity(designator)
+	if known {
+		entry.Name = capacity.Name
+		entry.Seats = capacity.Seats
+		entry.SeatsAssumed = false
+	}
+
+	// The fuel API takes the distance in nautical miles
+	distanceNM := strconv.FormatFloat(distanceKM/1.852, 'f', 1, 64)
+	data, err := s.GetFuelConsumptionContext(ctx, designator, distanceNM)
+	switch {
+	case err == nil && data.FuelConsumption.Total > 0:
+		entry.FuelKg = data.FuelConsumption.Total
+		entry.CO2Kg = data.CO2Emissions.Total
+		if entry.CO2Kg <= 0 {
+			entry.CO2Kg = entry.FuelKg * co2PerKgFuel
+		}
+		entry.Source = ComparisonSourceFuelAPI
+	case known:
+		entry.FuelKg = capacity.BurnKgPerKm * distanceKM
+		entry.CO2Kg = entry.FuelKg * co2PerKgFuel
+		entry.Source = ComparisonSourceBurnRate
+	case err != nil:
+		return entry, fmt.Errorf("unknown aircraft type and fuel API unavailable: %w", err)
+	default:
+		return entry, errors.New("unknown aircraft type and no fuel burn from the fuel API")
+	}
+
+	entry.FuelKg = math.Round(entry.FuelKg*10) / 10
+	entry.CO2Kg = math.Round(entry.CO2Kg*10) / 10
+	entry.CO2PerSeatKm = math.Round(entry.CO2Kg/(float64(entry.Seats)*distanceKM)*1000*100) / 100
+	return entry, nil
+}
@@ -0,0 +1,72 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// ErrElevationUnknown is returned when no source knows an airport's elevation
+var ErrElevationUnknown = errors.New("airport elevation unknown")
+
+// feetPerMeter converts metres to feet
+const feetPerMeter = 3.28084
+
+// elevationCache remembers elevations by ICAO code, since they never change
+var elevationCache sync.Map
+
+// GetElevation returns an airport's field elevation in feet. The airport
+// database has no elevations, so they come from the NOAA Aviation Weather
+// Center airport data.
+func (a *AirportsAPI) GetElevation(code string) (float64, error) {
+	return a.GetElevationContext(context.Background(), code)
+}
+
+// GetElevationContext returns an airport's field elevation in feet, aborting
+// when ctx is done
+func (a *AirportsAPI) GetElevationContext(ctx context.Context, code string) (float64, error) {
+	icao, err := noaaStationID(code)
+	if err != nil {
+		airport, lookupErr := a.GetAirportContext(ctx, code)
+		if lookupErr != nil {
+			return 0, lookupErr
+		}
+		if airport.ICAO == "" {
+			return 0, fmt.Errorf("%s has no ICAO code: %w", code, ErrElevationUnknown)
+		}
+		icao = airport.ICAO
+	}
+	if elevation, ok := elevationCache.Load(icao); ok {
+		return elevation.(float64), nil
+	}
+
+	data, err := a.fetcher.GetContext(ctx, "noaa", "airport", map[string]string{"ids": icao, "format": "json"})
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, fmt.Errorf("NOAA airport request for %s failed: %w", icao, err)
+	}
+
+	var records []struct {
+		ICAOID string   `json:"icaoId"`
+		Elev   *float64 `json:"elev"` // Metres
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &records); err != nil {
+			return 0, fmt.Errorf("failed to parse NOAA airport data for %s: %w", icao, err)
+		}
+	}
+	for _, record := range records {
+		if strings.EqualFold(record.ICAOID, icao) && record.Elev != nil {
+			elevation := math.Round(*record.Elev * feetPerMeter)
+			elevationCache.Store(icao, elevation)
+			return elevation, nil
+		}
+	}
+	return 0, fmt.Errorf("%s: %w", icao, ErrElevationUnknown)
+}
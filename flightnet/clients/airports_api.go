@@ -0,0 +1,364 @@
+package clients
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/your-project/clients/internal/dataset"
+)
+
+// Airport is an airport and its location
+type Airport struct {
+	IATA      string  `json:"iata"`
+	ICAO      string  `json:"icao"`
+	Name      string  `json:"name"`
+	City      string  `json:"city"`    // City name, or the IATA city code when only that is known
+	Country   string  `json:"country"` // ISO 3166-1 alpha-2 code
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"` // IANA time zone, e.g. America/New_York
+}
+
+// ErrAirportNotFound is returned when no airport has the requested code
+var ErrAirportNotFound = errors.New("airport not found")
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// DistanceTo returns the great-circle distance to other in kilometres
+func (a Airport) DistanceTo(other Airport) float64 {
+	return GreatCircleDistance(a.Latitude, a.Longitude, other.Latitude, other.Longitude)
+}
+
+// GreatCircleDistance returns the haversine distance in kilometres between
+// two points given in degrees
+func GreatCircleDistance(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// GreatCirclePoint returns the point a fraction of the way along the great
+// circle between two points given in degrees. fraction 0 is the first point
+// and 1 the second.
+func GreatCirclePoint(lat1, lon1, lat2, lon2, fraction float64) (lat, lon float64) {
+	toRad := math.Pi / 180
+	d := GreatCircleDistance(lat1, lon1, lat2, lon2) / earthRadiusKm
+	if d == 0 {
+		return lat1, lon1
+	}
+	a := math.Sin((1-fraction)*d) / math.Sin(d)
+	b := math.Sin(fraction*d) / math.Sin(d)
+	phi1, lambda1 := lat1*toRad, lon1*toRad
+	phi2, lambda2 := lat2*toRad, lon2*toRad
+	x := a*math.Cos(phi1)*math.Cos(lambda1) + b*math.Cos(phi2)*math.Cos(lambda2)
+	y := a*math.Cos(phi1)*math.Sin(lambda1) + b*math.Cos(phi2)*math.Sin(lambda2)
+	z := a*math.Sin(phi1) + b*math.Sin(phi2)
+	return math.Atan2(z, math.Hypot(x, y)) / toRad, math.Atan2(y, x) / toRad
+}
+
+// airportsCSV is the fallback dataset of the busiest airports, used when the
+// airport database is unavailable
+var airportsCSV = dataset.Airports
+
+// EmbeddedAirports returns the airports of the embedded dataset
+func EmbeddedAirports() []Airport {
+	return append([]Airport(nil), fallbackAirports().airports...)
+}
+
+// airportIndex looks up airports by code
+type airportIndex struct {
+	airports []Airport
+	byIATA   map[string]*Airport
+	byICAO   map[string]*Airport
+}
+
+var (
+	embeddedAirportsOnce sync.Once
+	embeddedAirports     *airportIndex
+)
+
+// fallbackAirports returns the index of the embedded dataset, loading it on
+// first use
+func fallbackAirports() *airportIndex {
+	embeddedAirportsOnce.Do(func() {
+		index, err := loadAirportIndex(airportsCSV)
+		if err != nil {
+			log.Printf("Failed to load embedded airport dataset: %v", err)
+			index = &airportIndex{byIATA: map[string]*Airport{}, byICAO: map[string]*Airport{}}
+		}
+		embeddedAirports = index
+	})
+	return embeddedAirports
+}
+
+// loadAirportIndex parses airport CSV data with a header row of
+// iata,icao,name,city,country,latitude,longitude,timezone
+func loadAirportIndex(data string) (*airportIndex, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read airport data: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty airport data")
+	}
+
+	index := &airportIndex{
+		airports: make([]Airport, 0, len(records)-1),
+		byIATA:   make(map[string]*Airport, len(records)-1),
+		byICAO:   make(map[string]*Airport, len(records)-1),
+	}
+	for i, record := range records[1:] {
+		if len(record) != 8 {
+			return nil, fmt.Errorf("airport data line %d: expected 8 fields, got %d", i+2, len(record))
+		}
+		lat, err := strconv.ParseFloat(record[5], 64)
+		if err != nil {
+			return nil, fmt.Errorf("airport data line %d: invalid latitude: %w", i+2, err)
+		}
+		lon, err := strconv.ParseFloat(record[6], 64)
+		if err != nil {
+			return nil, fmt.Errorf("airport data line %d: invalid longitude: %w", i+2, err)
+		}
+		index.airports = append(index.airports, Airport{
+			IATA:      record[0],
+			ICAO:      record[1],
+			Name:      record[2],
+			City:      record[3],
+			Country:   record[4],
+			Latitude:  lat,
+			Longitude: lon,
+			Timezone:  record[7],
+		})
+	}
+
+	// Index once the slice has stopped growing so the pointers stay valid
+	for i := range index.airports {
+		airport := &index.airports[i]
+		index.byIATA[airport.IATA] = airport
+		index.byICAO[airport.ICAO] = airport
+	}
+	return index, nil
+}
+
+// AirportsAPI handles airport reference data from the aviation-edge airport
+// database, falling back to an embedded dataset of the busiest airports
+type AirportsAPI struct {
+	fetcher *Fetcher
+	parser  *Parser
+}
+
+// NewAirportsAPI creates a new AirportsAPI instance
+func NewAirportsAPI() *AirportsAPI {
+	return NewAirportsAPIWithFetcher(NewFetcher())
+}
+
+// NewEmbeddedAirportsAPI creates an AirportsAPI that looks airports up in the
+// embedded dataset alone, never calling the airport database
+func NewEmbeddedAirportsAPI() *AirportsAPI {
+	return &AirportsAPI{parser: NewParser()}
+}
+
+// NewAirportsAPIWithFetcher creates an AirportsAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewAirportsAPIWithFetcher(fetcher *Fetcher) *AirportsAPI {
+	return &AirportsAPI{
+		fetcher: fetcher,
+		parser:  NewParser(),
+	}
+}
+
+// GetAirportByIATA looks up an airport by its three-letter IATA code
+func (a *AirportsAPI) GetAirportByIATA(code string) (*Airport, error) {
+	return a.GetAirportByIATAContext(context.Background(), code)
+}
+
+// GetAirportByIATAContext looks up an airport by its three-letter IATA code,
+// aborting when ctx is done
+func (a *AirportsAPI) GetAirportByIATAContext(ctx context.Context, code string) (*Airport, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !isAirportCode(code, 3) {
+		return nil, fmt.Errorf("invalid IATA airport code %q", code)
+	}
+	return a.lookup(ctx, "codeIataAirport", code, func(ap *Airport) string { return ap.IATA }, fallbackAirports().byIATA)
+}
+
+// GetAirportByICAO looks up an airport by its four-character ICAO code
+func (a *AirportsAPI) GetAirportByICAO(code string) (*Airport, error) {
+	return a.GetAirportByICAOContext(context.Background(), code)
+}
+
+// GetAirportByICAOContext looks up an airport by its four-character ICAO
+// code, aborting when ctx is done
+func (a *AirportsAPI) GetAirportByICAOContext(ctx context.Context, code string) (*Airport, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !isAirportCode(code, 4) {
+		return nil, fmt.Errorf("invalid ICAO airport code %q", code)
+	}
+	return a.lookup(ctx, "codeIcaoAirport", code, func(ap *Airport) string { return ap.ICAO }, fallbackAirports().byICAO)
+}
+
+// GetAirport looks up an airport by IATA or ICAO code, telling them apart by length
+func (a *AirportsAPI) GetAirport(code string) (*Airport, error) {
+	return a.GetAirportContext(context.Background(), code)
+}
+
+// GetAirportContext looks up an airport by IATA or ICAO code, aborting when ctx is done
+func (a *AirportsAPI) GetAirportContext(ctx context.Context, code string) (*Airport, error) {
+	if len(strings.TrimSpace(code)) == 4 {
+		return a.GetAirportByICAOContext(ctx, code)
+	}
+	return a.GetAirportByIATAContext(ctx, code)
+}
+
+// GetRouteDistance returns the great-circle distance in kilometres between
+// two airports given by IATA or ICAO code
+func (a *AirportsAPI) GetRouteDistance(origin, destination string) (float64, error) {
+	return a.GetRouteDistanceContext(context.Background(), origin, destination)
+}
+
+// GetRouteDistanceContext returns the great-circle distance in kilometres
+// between two airports, aborting when ctx is done
+func (a *AirportsAPI) GetRouteDistanceContext(ctx context.Context, origin, destination string) (float64, error) {
+	from, err := a.GetAirportContext(ctx, origin)
+	if err != nil {
+		return 0, fmt.Errorf("invalid origin: %w", err)
+	}
+	to, err := a.GetAirportContext(ctx, destination)
+	if err != nil {
+		return 0, fmt.Errorf("invalid destination: %w", err)
+	}
+	return from.DistanceTo(*to), nil
+}
+
+// SearchAirports finds airports whose code, name or city matches query.
+// Exact code matches come first, followed by name and city matches.
+func (a *AirportsAPI) SearchAirports(query string) ([]Airport, error) {
+	return a.SearchAirportsContext(context.Background(), query)
+}
+
+// SearchAirportsContext finds airports whose code, name or city matches
+// query, aborting when ctx is done
+func (a *AirportsAPI) SearchAirportsContext(ctx context.Context, query string) ([]Airport, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("search query must not be empty")
+	}
+
+	var results []Airport
+	seen := make(map[string]bool)
+
+	// A query that looks like a code may name an airport outside the
+	// embedded dataset, so look it up directly first
+	if code := strings.ToUpper(query); isAirportCode(code, 3) || isAirportCode(code, 4) {
+		airport, err := a.GetAirportContext(ctx, code)
+		switch {
+		case err == nil:
+			results = append(results, *airport)
+			seen[airport.ICAO+"|"+airport.IATA] = true
+		case ctx.Err() != nil:
+			return nil, ctx.Err()
+		}
+	}
+
+	needle := strings.ToLower(query)
+	var matches []Airport
+	for _, airport := range fallbackAirports().airports {
+		if seen[airport.ICAO+"|"+airport.IATA] {
+			continue
+		}
+		if strings.Contains(strings.ToLower(airport.Name), needle) ||
+			strings.Contains(strings.ToLower(airport.City), needle) {
+			matches = append(matches, airport)
+		}
+	}
+
+	// Prefer airports whose city or name starts with the query
+	sort.SliceStable(matches, func(i, j int) bool {
+		return hasAirportPrefix(matches[i], needle) && !hasAirportPrefix(matches[j], needle)
+	})
+	return append(results, matches...), nil
+}
+
+// lookup fetches the airport with code from the airport database, using
+// fallback when the database is unavailable or has no such airport
+func (a *AirportsAPI) lookup(ctx context.Context, param, code string, codeOf func(*Airport) string, fallback map[string]*Airport) (*Airport, error) {
+	// Without a fetcher there is no database to ask
+	if a.fetcher != nil {
+		data, err := a.fetcher.GetContext(ctx, "aviation-edge", "airportDatabase", map[string]string{param: code})
+		if err == nil {
+			var airports []Airport
+			if airports, err = a.parser.ParseAirportResponse(data); err == nil {
+				for i := range airports {
+					if codeOf(&airports[i]) == code {
+						airport := airports[i]
+						fillFromEmbedded(&airport)
+						return &airport, nil
+					}
+				}
+			}
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Airport database unavailable for %s, using embedded dataset: %v", code, err)
+		}
+	}
+
+	if airport, ok := fallback[code]; ok {
+		found := *airport
+		return &found, nil
+	}
+	return nil, fmt.Errorf("%s: %w", code, ErrAirportNotFound)
+}
+
+// fillFromEmbedded completes an airport database record with the city name
+// and any missing fields from the embedded dataset
+func fillFromEmbedded(airport *Airport) {
+	known, ok := fallbackAirports().byIATA[airport.IATA]
+	if !ok {
+		return
+	}
+	airport.City = known.City
+	if airport.ICAO == "" {
+		airport.ICAO = known.ICAO
+	}
+	if airport.Country == "" {
+		airport.Country = known.Country
+	}
+	if airport.Timezone == "" {
+		airport.Timezone = known.Timezone
+	}
+}
+
+// isAirportCode reports whether code is n letters or digits
+func isAirportCode(code string, n int) bool {
+	if len(code) != n {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAirportPrefix reports whether an airport's city or name starts with prefix
+func hasAirportPrefix(airport Airport, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(airport.City), prefix) ||
+		strings.HasPrefix(strings.ToLower(airport.Name), prefix)
+}
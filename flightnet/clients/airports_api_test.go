@@ -0,0 +1,38 @@
+package clients
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEmbeddedAirportsAPI(t *testing.T) {
+	api := NewEmbeddedAirportsAPI()
+
+	jfk, err := api.GetAirport("kjfk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jfk.IATA != "JFK" || jfk.Country != "US" {
+		t.Errorf("KJFK is %+v, want JFK in the US", jfk)
+	}
+	if _, err := api.GetAirportByIATA("QQQ"); !errors.Is(err, ErrAirportNotFound) {
+		t.Errorf("QQQ: error %v, want ErrAirportNotFound", err)
+	}
+
+	distance, err := api.GetRouteDistance("JFK", "LHR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distance < 5500 || distance > 5600 {
+		t.Errorf("JFK-LHR is %.0f km, want about 5540", distance)
+	}
+
+	airports := EmbeddedAirports()
+	if len(airports) == 0 {
+		t.Fatal("no embedded airports")
+	}
+	airports[0].IATA = "changed"
+	if EmbeddedAirports()[0].IATA == "changed" {
+		t.Error("EmbeddedAirports returned the dataset itself rather than a copy")
+	}
+}
@@ -0,0 +1,203 @@
+package clients
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// AirspaceEventType classifies an airspace event reported in the news
+type AirspaceEventType string
+
+// Airspace event types, from most to least severe
+const (
+	AirspaceClosure     AirspaceEventType = "closure"
+	AirspaceRestriction AirspaceEventType = "restriction"
+	AirspaceAdvisory    AirspaceEventType = "advisory"
+)
+
+// AirspaceEvent is an airspace closure, restriction or advisory for a
+// country, extracted from a news article
+type AirspaceEvent struct {
+	CountryISO2   string            `json:"country_iso2"`
+	FIR           string            `json:"fir,omitempty"` // ICAO code of the country's flight information region, when it has just one
+	EventType     AirspaceEventType `json:"event_type"`
+	Confidence    float64           `json:"confidence"` // 0 to 1
+	SourceArticle NewsArticle       `json:"source_article"`
+}
+
+// Terms that classify a sentence, matched as whole words after
+// normalizeNewsText
+var (
+	// aviationTerms must appear for a sentence to describe an airspace event
+	aviationTerms = []string{"airspace", "fir", "flight information region", "no fly zone", "overflight", "overflights", "flight", "flights", "airline", "airlines", "aviation", "notam", "notams"}
+	// explicitAirspaceTerms raise the confidence of an event
+	explicitAirspaceTerms = []string{"airspace", "fir", "flight information region", "no fly zone", "overflight", "overflights"}
+	closureTerms          = []string{"closed", "closes", "close", "closing", "closure", "closures", "shut", "shuts", "shutting", "shutdown", "no fly zone", "ban", "bans", "banned", "halted", "halts", "suspended", "suspends", "grounded"}
+	restrictionTerms      = []string{"restricted", "restricts", "restrict", "restriction", "restrictions", "limited", "limits", "curbs", "diverted", "diverts", "rerouted", "reroute", "avoid", "avoids", "avoiding", "off limits"}
+	advisoryTerms         = []string{"advisory", "advisories", "warning", "warnings", "warns", "warned", "notam", "notams", "caution", "advised", "advises", "concerns", "alert"}
+	// reopeningTerms cancel closures and restrictions in the same sentence
+	reopeningTerms = []string{"reopen", "reopens", "reopened", "reopening", "lift", "lifts", "lifted", "lifting", "resume", "resumes", "resumed", "resuming", "restored", "restores", "no longer"}
+	// negationTerms within negationWindow words before a term cancel it;
+	// "t" is what remains of n't
+	negationTerms = []string{"not", "no", "never", "without", "deny", "denies", "denied", "t"}
+)
+
+// negationWindow is how many words before a term a negation applies to
+const negationWindow = 3
+
+// sentenceBreak splits article text into sentences
+var sentenceBreak = regexp.MustCompile(`[.!?;]+\s+`)
+
+// ExtractAirspaceEvents finds airspace closures, restrictions and advisories
+// in news articles. Each sentence of an article's title and description is
+// matched case-insensitively against country names and demonyms, and a
+// sentence about reopening airspace or a negated term ("not closed") does
+// not count as a closure. Each article yields at most one event per country,
+// the most severe one found.
+func ExtractAirspaceEvents(resp *NewsResponse) []AirspaceEvent {
+	if resp == nil {
+		return nil
+	}
+
+	var events []AirspaceEvent
+	for _, article := range resp.Articles {
+		found := make(map[string]int) // Country to index in events
+		titleSentences := sentenceBreak.Split(article.Title, -1)
+		sentences := append(titleSentences, sentenceBreak.Split(article.Description, -1)...)
+		for i, sentence := range sentences {
+			text := normalizeNewsText(sentence)
+			eventType, confidence, ok := classifyAirspaceSentence(text, i < len(titleSentences))
+			if !ok {
+				continue
+			}
+			for _, country := range countriesIn(text) {
+				event := AirspaceEvent{
+					CountryISO2:   country.iso2,
+					FIR:           country.fir,
+					EventType:     eventType,
+					Confidence:    confidence,
+					SourceArticle: article,
+				}
+				if j, seen := found[country.iso2]; !seen {
+					found[country.iso2] = len(events)
+					events = append(events, event)
+				} else if moreSevere(event, events[j]) {
+					events[j] = event
+				}
+			}
+		}
+	}
+	return events
+}
+
+// classifyAirspaceSentence returns the event a normalized sentence
+// describes and the confidence in it
+func classifyAirspaceSentence(text string, inTitle bool) (AirspaceEventType, float64, bool) {
+	if !containsTerm(text, aviationTerms, false) {
+		return "", 0, false
+	}
+	reopening := containsTerm(text, reopeningTerms, false)
+
+	var eventType AirspaceEventType
+	var confidence float64
+	switch {
+	case !reopening && containsTerm(text, closureTerms, true):
+		eventType, confidence = AirspaceClosure, 0.6
+	case !reopening && containsTerm(text, restrictionTerms, true):
+		eventType, confidence = AirspaceRestriction, 0.5
+	case containsTerm(text, advisoryTerms, true):
+		eventType, confidence = AirspaceAdvisory, 0.4
+	default:
+		return "", 0, false
+	}
+	if containsTerm(text, explicitAirspaceTerms, false) {
+		confidence += 0.3
+	}
+	if inTitle {
+		confidence += 0.1
+	}
+	return eventType, math.Min(math.Round(confidence*100)/100, 1), true
+}
+
+// countriesIn returns the countries named in a normalized sentence. Longer
+// names are matched first and masked, so shorter names inside them, such as
+// "guinea" in "papua new guinea", do not match again.
+func countriesIn(text string) []*countryRecord {
+	var countries []*countryRecord
+	seen := make(map[string]bool)
+	for _, term := range loadCountryTable().terms {
+		needle := " " + term.term + " "
+		for {
+			i := strings.Index(text, needle)
+			if i < 0 {
+				break
+			}
+			text = text[:i+1] + strings.Repeat(" ", len(term.term)) + text[i+1+len(term.term):]
+			if term.country != nil && !seen[term.country.iso2] {
+				seen[term.country.iso2] = true
+				countries = append(countries, term.country)
+			}
+		}
+	}
+	return countries
+}
+
+// containsTerm reports whether a normalized sentence contains one of terms
+// as whole words. With negatable set, an occurrence preceded closely by a
+// negation does not count.
+func containsTerm(text string, terms []string, negatable bool) bool {
+	for _, term := range terms {
+		needle := " " + term + " "
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], needle)
+			if i < 0 {
+				break
+			}
+			i += offset
+			if !negatable || !negated(text[:i]) {
+				return true
+			}
+			offset = i + 1
+		}
+	}
+	return false
+}
+
+// negated reports whether a negation is among the last negationWindow words
+// of before
+func negated(before string) bool {
+	words := strings.Fields(before)
+	if len(words) > negationWindow {
+		words = words[len(words)-negationWindow:]
+	}
+	for _, word := range words {
+		for _, negation := range negationTerms {
+			if word == negation {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeNewsText lowercases text and turns everything but letters and
+// digits into single spaces, padding the result with a space on each side so
+// whole words can be found with strings.Index
+func normalizeNewsText(text string) string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return " " + strings.Join(fields, " ") + " "
+}
+
+// moreSevere reports whether a should replace b as an article's event for a
+// country
+func moreSevere(a, b AirspaceEvent) bool {
+	rank := map[AirspaceEventType]int{AirspaceAdvisory: 1, AirspaceRestriction: 2, AirspaceClosure: 3}
+	if rank[a.EventType] != rank[b.EventType] {
+		return rank[a.EventType] > rank[b.EventType]
+	}
+	return a.Confidence > b.Confidence
+}
@@ -0,0 +1,179 @@
+package clients
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats reports response cache effectiveness
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// cacheEntry is a cached response body
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+// inflightCall is an upstream request shared by concurrent callers
+type inflightCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// responseCache is an in-memory TTL cache for GET responses that also
+// de-duplicates concurrent requests for the same key
+type responseCache struct {
+	mu       sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]*inflightCall
+	swept    time.Time // Last sweep for expired entries
+	hits     uint64
+	misses   uint64
+}
+
+// cacheSweepInterval is how often storing a response also drops every
+// expired entry
+const cacheSweepInterval = time.Minute
+
+// newResponseCache creates an empty cache
+func newResponseCache() *responseCache {
+	return &responseCache{
+		entries:  make(map[string]cacheEntry),
+		inflight: make(map[string]*inflightCall),
+	}
+}
+
+// cacheKey builds a key from the API name, endpoint and sorted parameters
+func cacheKey(apiName, endpoint string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(apiName)
+	b.WriteByte('|')
+	b.WriteString(endpoint)
+	for _, key := range keys {
+		b.WriteByte('|')
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(params[key])
+	}
+	return b.String()
+}
+
+// get returns the cached body for key, calling fetch on a miss or after
+// expiry. Concurrent misses for the same key share a single fetch, which
+// runs detached from any one caller's cancellation so a caller giving up
+// does not fail the others; each caller stops waiting when its own ctx is
+// done.
+func (c *responseCache) get(ctx context.Context, key string, ttl time.Duration, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok {
+		if time.Now().Before(entry.expires) {
+			c.mu.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return copyBytes(entry.body), nil
+		}
+		delete(c.entries, key)
+	}
+	atomic.AddUint64(&c.misses, 1)
+
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &inflightCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		go c.fetch(context.WithoutCancel(ctx), key, ttl, call, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		return copyBytes(call.body), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch runs the shared fetch for key and stores its result
+func (c *responseCache) fetch(ctx context.Context, key string, ttl time.Duration, call *inflightCall, fetch func(ctx context.Context) ([]byte, error)) {
+	call.body, call.err = fetch(ctx)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		now := time.Now()
+		c.entries[key] = cacheEntry{body: call.body, expires: now.Add(ttl)}
+		c.sweep(now)
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// sweep removes expired entries that were never read again, at most once
+// per cacheSweepInterval; callers hold mu
+func (c *responseCache) sweep(now time.Time) {
+	if now.Sub(c.swept) < cacheSweepInterval {
+		return
+	}
+	c.swept = now
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// invalidate removes every entry whose key starts with prefix
+func (c *responseCache) invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// stats returns the current counters
+func (c *responseCache) stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: entries,
+	}
+}
+
+// copyBytes returns a copy so callers cannot modify cached data
+func copyBytes(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
+
+// InvalidateCache drops all cached responses for an API
+func (f *Fetcher) InvalidateCache(apiName string) {
+	f.cache.invalidate(apiName + "|")
+}
+
+// CacheStats returns response cache hit and miss counters
+func (f *Fetcher) CacheStats() CacheStats {
+	return f.cache.stats()
+}
@@ -0,0 +1,98 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResponseCacheRemovesExpiredEntries(t *testing.T) {
+	c := newResponseCache()
+	ctx := context.Background()
+	body := func(s string) func(context.Context) ([]byte, error) {
+		return func(context.Context) ([]byte, error) { return []byte(s), nil }
+	}
+	failed := func(context.Context) ([]byte, error) { return nil, errors.New("upstream down") }
+
+	if _, err := c.get(ctx, "read", time.Millisecond, body("a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.get(ctx, "unread", time.Millisecond, body("b")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// An expired read drops the entry even when refreshing it fails
+	if _, err := c.get(ctx, "read", time.Minute, failed); err == nil {
+		t.Fatal("expired entry was served instead of refetched")
+	}
+	if got := c.stats().Entries; got != 1 {
+		t.Fatalf("%d entries after the expired read, want 1", got)
+	}
+
+	// Storing a response sweeps entries nobody read again
+	c.mu.Lock()
+	c.swept = time.Time{}
+	c.mu.Unlock()
+	if _, err := c.get(ctx, "fresh", time.Minute, body("c")); err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	_, unread := c.entries["unread"]
+	_, fresh := c.entries["fresh"]
+	c.mu.Unlock()
+	if unread || !fresh {
+		t.Errorf("after a sweep unread is kept %v and fresh %v, want only fresh", unread, fresh)
+	}
+}
+
+func TestResponseCacheWaiterSurvivesLeaderCancel(t *testing.T) {
+	c := newResponseCache()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var fetches atomic.Int32
+	fetch := func(ctx context.Context) ([]byte, error) {
+		fetches.Add(1)
+		close(started)
+		<-release
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return []byte("shared"), nil
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := c.get(leaderCtx, "key", time.Minute, fetch)
+		leaderErr <- err
+	}()
+	<-started
+
+	waiter := make(chan []byte, 1)
+	go func() {
+		body, err := c.get(context.Background(), "key", time.Minute, fetch)
+		if err != nil {
+			t.Errorf("waiter: %v", err)
+		}
+		waiter <- body
+	}()
+	// Let the waiter join the in-flight fetch
+	for atomic.LoadUint64(&c.misses) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancelLeader()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader error %v, want context.Canceled", err)
+	}
+	close(release)
+	if body := <-waiter; string(body) != "shared" {
+		t.Errorf("waiter got %q, want the shared body", body)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetched %d times, want 1", got)
+	}
+}
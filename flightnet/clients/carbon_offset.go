@@ -0,0 +1,130 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// DefaultCarbonPrice is the offset price per tonne of CO2 used unless
+	// SetOffsetPricing is called, typical of certified voluntary offsets
+	DefaultCarbonPrice = 25.0
+	// DefaultCarbonCurrency is the currency of DefaultCarbonPrice
+	DefaultCarbonCurrency = "USD"
+	// DefaultRadiativeForcing is the multiplier applied to CO2 to account for
+	// the warming effect of contrails and NOx emitted at altitude
+	DefaultRadiativeForcing = 1.9
+)
+
+// OffsetPricing configures how offset costs are estimated
+type OffsetPricing struct {
+	PricePerTonne float64 `json:"price_per_tonne"`
+	Currency      string  `json:"currency"`
+	// RadiativeForcing applies RFMultiplier to the CO2 offset, to account for
+	// non-CO2 effects
+	RadiativeForcing bool    `json:"radiative_forcing"`
+	RFMultiplier     float64 `json:"rf_multiplier"`
+}
+
+// DefaultOffsetPricing is the pricing used unless SetOffsetPricing is called
+var DefaultOffsetPricing = OffsetPricing{
+	PricePerTonne:    DefaultCarbonPrice,
+	Currency:         DefaultCarbonCurrency,
+	RadiativeForcing: true,
+	RFMultiplier:     DefaultRadiativeForcing,
+}
+
+// Validate checks the price is not negative, a currency is given and the
+// multiplier is at least 1 when radiative forcing is applied
+func (p OffsetPricing) Validate() error {
+	if !(p.PricePerTonne >= 0) {
+		return fmt.Errorf("invalid carbon price %v: must not be negative", p.PricePerTonne)
+	}
+	if strings.TrimSpace(p.Currency) == "" {
+		return errors.New("carbon price currency must not be empty")
+	}
+	if p.RadiativeForcing && !(p.RFMultiplier >= 1) {
+		return fmt.Errorf("invalid radiative forcing multiplier %v: must be at least 1", p.RFMultiplier)
+	}
+	return nil
+}
+
+// OffsetEstimate is the cost of offsetting a flight's emissions
+type OffsetEstimate struct {
+	Currency         string  `json:"currency"`
+	PricePerTonne    float64 `json:"price_per_tonne"`
+	RadiativeForcing bool    `json:"radiative_forcing"`
+	Multiplier       float64 `json:"multiplier"`            // Applied to CO2, 1 without radiative forcing
+	CO2eKg           float64 `json:"co2e_kg"`               // CO2 times Multiplier
+	CO2ePerPassenger float64 `json:"co2e_per_passenger_kg"` // At the default load factor
+	TotalCost        float64 `json:"total_cost"`
+	PerPassengerCost float64 `json:"per_passenger_cost"`
+}
+
+// EstimateOffsetCost returns the cost of offsetting co2Kg of CO2 at
+// pricePerTonne, rounded to cents. currency is only checked for being set.
+func EstimateOffsetCost(co2Kg, pricePerTonne float64, currency string) (float64, error) {
+	if err := (OffsetPricing{PricePerTonne: pricePerTonne, Currency: currency}).Validate(); err != nil {
+		return 0, err
+	}
+	if !(co2Kg >= 0) {
+		return 0, fmt.Errorf("invalid CO2 amount %v: must not be negative", co2Kg)
+	}
+	return math.Round(co2Kg/1000*pricePerTonne*100) / 100, nil
+}
+
+// SetOffsetPricing sets the carbon price and radiative forcing used for the
+// offset estimates of emissions calculated from then on
+func (s *SustainabilityAPI) SetOffsetPricing(pricing OffsetPricing) error {
+	if err := pricing.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.offsetPricing = pricing
+	s.mu.Unlock()
+	return nil
+}
+
+// OffsetPricing returns the pricing offset estimates use
+func (s *SustainabilityAPI) OffsetPricing() OffsetPricing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.offsetPricing
+}
+
+// EstimateOffset estimates the cost of offsetting emissions data's total
+// CO2 and one passenger's share of it
+func (s *SustainabilityAPI) EstimateOffset(data *SustainabilityData) *OffsetEstimate {
+	return estimateOffset(data, s.OffsetPricing())
+}
+
+// estimateOffset prices data's emissions, which pricing must have been
+// validated for
+func estimateOffset(data *SustainabilityData, pricing OffsetPricing) *OffsetEstimate {
+	multiplier := 1.0
+	if pricing.RadiativeForcing {
+		multiplier = pricing.RFMultiplier
+	}
+	co2e := data.CO2Emissions.Total * multiplier
+	seats := data.SeatsAssumed
+	if seats <= 0 {
+		seats = defaultAircraftSeats
+	}
+	perPassenger := co2e / (float64(seats) * DefaultLoadFactor)
+
+	// The pricing is valid, so neither estimate can fail
+	total, _ := EstimateOffsetCost(co2e, pricing.PricePerTonne, pricing.Currency)
+	passenger, _ := EstimateOffsetCost(perPassenger, pricing.PricePerTonne, pricing.Currency)
+	return &OffsetEstimate{
+		Currency:         pricing.Currency,
+		PricePerTonne:    pricing.PricePerTonne,
+		RadiativeForcing: pricing.RadiativeForcing,
+		Multiplier:       multiplier,
+		CO2eKg:           math.Round(co2e*10) / 10,
+		CO2ePerPassenger: math.Round(perPassenger*10) / 10,
+		TotalCost:        total,
+		PerPassengerCost: passenger,
+	}
+}
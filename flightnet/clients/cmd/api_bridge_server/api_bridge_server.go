@@ -0,0 +1,2470 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/your-project/clients"
+)
+
+// DataProvider is an interface for retrieving flight environment data
+type DataProvider interface {
+	GetFlightEnvironment(ctx context.Context, params map[string]string) (*FlightEnvironmentData, error)
+	Name() string
+	Ping() bool
+}
+
+// ProviderCapabilities declares which parts of the DataProvider contract a
+// provider supports. The conformance suite checks every behaviour against the
+// declared capabilities, so a provider that cannot honour something says so
+// here instead of having the check skipped.
+type ProviderCapabilities struct {
+	// Cancellation means the provider returns the context error when the
+	// context is cancelled before or during a fetch
+	Cancellation bool
+	// ExactAircraftCount means the provider returns exactly aircraft_count
+	// aircraft; otherwise it only has to return no more than requested
+	ExactAircraftCount bool
+	// MaxAircraftCount caps the number of aircraft the provider will return
+	// for a single request (0 means no cap)
+	MaxAircraftCount int
+	// RejectsMalformedRoutes means a malformed route parameter is reported as
+	// an error instead of being ignored
+	RejectsMalformedRoutes bool
+	// ConcurrentSafe means GetFlightEnvironment may be called from several
+	// goroutines at once
+	ConcurrentSafe bool
+	// RequiresCredentials means the provider serves nothing until upstream
+	// credentials are configured, reporting so through Ping
+	RequiresCredentials bool
+	// ConsistentSections means the sections describe one scenario: flights
+	// fly the reported aircraft between airports whose weather is reported,
+	// over the distance the sustainability of their route gives
+	ConsistentSections bool
+}
+
+// capabilityDeclarer is implemented by providers that declare their capabilities
+type capabilityDeclarer interface {
+	Capabilities() ProviderCapabilities
+}
+
+// fullCapabilities is assumed for providers that do not declare anything
+var fullCapabilities = ProviderCapabilities{
+	Cancellation:       true,
+	ExactAircraftCount: true,
+	ConcurrentSafe:     true,
+	ConsistentSections: true,
+}
+
+// providerCapabilities returns the capabilities declared by a provider
+func providerCapabilities(p DataProvider) ProviderCapabilities {
+	if d, ok := p.(capabilityDeclarer); ok {
+		return d.Capabilities()
+	}
+	return fullCapabilities
+}
+
+// metricsReporter is implemented by providers that track the requests they
+// make to upstream APIs, e.g. through a Fetcher metrics hook
+type metricsReporter interface {
+	UpstreamMetrics() interface{}
+}
+
+// credentialReporter is implemented by providers that need upstream
+// credentials, naming those that are not configured
+type credentialReporter interface {
+	MissingCredentials() []string
+}
+
+// airportResolver is implemented by providers that look airports up through
+// their own AirportsAPI, so routes given to them resolve the same way
+type airportResolver interface {
+	Airports() *clients.AirportsAPI
+}
+
+// providerAirports returns the airports a provider resolves routes against,
+// the embedded dataset for providers that do not say
+func providerAirports(p DataProvider) *clients.AirportsAPI {
+	if r, ok := p.(airportResolver); ok {
+		return r.Airports()
+	}
+	return clients.NewEmbeddedAirportsAPI()
+}
+
+// MockProvider uses mock implementations from api_types.go
+type MockProvider struct {
+	aircraftAPI       *AircraftAPI
+	flightsAPI        *FlightsAPI
+	weatherAPI        *WeatherAPI
+	newsAPI           *NewsAPI
+	geopoliticalAPI   *GeopoliticalAPI
+	sustainabilityAPI *SustainabilityAPI
+	notamAPI          *NOTAMAPI
+	airportsAPI       *clients.AirportsAPI
+
+	simulations *simulationStore // Sessions of simulate=true requests
+
+	fixturesMu sync.RWMutex
+	fixtures   *mockFixtures // Served in place of generated sections, nil without a fixtures directory
+}
+
+// APIBridgeServer holds the providers and routing infrastructure
+type APIBridgeServer struct {
+	mockProvider *MockProvider
+	liveProvider *LiveProvider
+
+	environmentCache *environmentCache
+
+	eventSnapshots *snapshotStore // Latest snapshot of each event stream, for resuming
+
+	history *historyRecorder // Records generated snapshots, nil when history is disabled
+
+	rateLimiter *rateLimiter
+
+	maxAircraftCount int // Largest aircraft_count a request may ask for
+
+	streams      sync.WaitGroup // Open flight environment streams
+	shutdown     chan struct{}  // Closed to end flight environment streams
+	shutdownOnce sync.Once
+
+	providersMu     sync.RWMutex
+	providers       map[string]DataProvider // By name
+	providerNames   []string                // In registration order
+	defaultProvider string
+
+	alertsMu     sync.Mutex
+	riskAlerts   []RiskAlert          // Most recent last, at most maxRiskAlerts
+	elevatedRisk map[string]RiskAlert // Countries at or above riskAlertThreshold, by code
+}
+
+// riskCountries are the countries whose risk is reported with the flight
+// environment unless countries is given, and watched for alerts
+var riskCountries = []string{"US", "UK", "DE", "FR", "RU", "CN", "IR"}
+
+const (
+	// riskAlertThreshold is the risk level from which a country raises an alert
+	riskAlertThreshold = 7
+	// maxRiskAlerts is how many recent risk alerts the server keeps
+	maxRiskAlerts = 100
+)
+
+type FlightEnvironmentData struct {
+	Aircraft     []Aircraft           `json:"aircraft"`
+	Flights      []Flight             `json:"flights"`
+	Weather      map[string]*WeatherData `json:"weather"`
+	News         *NewsResponse        `json:"news"`
+	Geopolitical map[string]*GeopoliticalRisk `json:"geopolitical"`
+	Sustainability map[string]*SustainabilityData `json:"sustainability"` // Keyed by route leg, such as JFK-LHR
+	NOTAMs       []NOTAM              `json:"notams"`
+	NoFlyZones   []NoFlyZone          `json:"no_fly_zones"` // From AirspaceEvents, NOTAMs and static advisories
+	// NoFlyZoneCodes are the countries of NoFlyZones, once each, as the
+	// no_fly_zones list was before it was typed. Deprecated: use NoFlyZones.
+	NoFlyZoneCodes []string           `json:"no_fly_zone_codes"`
+	AirspaceEvents []AirspaceEvent    `json:"airspace_events"`
+	RouteWeather []RouteWeatherPoint  `json:"route_weather,omitempty"` // Every leg's points, in route order
+	RouteWeatherByLeg map[string][]RouteWeatherPoint `json:"route_weather_by_leg,omitempty"` // Keyed by leg, such as JFK-LHR
+	RouteNews    map[string]*NewsResponse `json:"route_news,omitempty"` // News per ISO2 country along the route
+	// Degraded lists the sections that are missing or hold fallback data
+	// because an upstream API failed
+	Degraded     []string             `json:"degraded,omitempty"`
+	Sections     map[string]*SectionStatus `json:"sections"` // How each section was fetched
+	EmissionsReport *EmissionsReport  `json:"emissions_report,omitempty"` // Set with include=emissions_report
+	Meta         *ResponseMeta        `json:"meta,omitempty"` // Set when sections or pagination narrow the response
+	Timestamp    string               `json:"timestamp"`
+}
+
+// NewMockProvider creates a new provider with mock implementations
+func NewMockProvider() *MockProvider {
+	return &MockProvider{
+		aircraftAPI:       NewAircraftAPI(),
+		flightsAPI:        NewFlightsAPI(),
+		weatherAPI:        NewWeatherAPI(),
+		newsAPI:           NewNewsAPI(),
+		geopoliticalAPI:   NewGeopoliticalAPI(),
+		sustainabilityAPI: NewSustainabilityAPI(),
+		notamAPI:          NewNOTAMAPI(),
+		airportsAPI:       clients.NewEmbeddedAirportsAPI(),
+		simulations:       newSimulationStore(),
+	}
+}
+
+// NewAPIBridgeServer creates a new server with the mock and live providers
+// registered, and mock as the default
+func NewAPIBridgeServer() *APIBridgeServer {
+	s := &APIBridgeServer{
+		mockProvider: NewMockProvider(),
+		liveProvider: NewLiveProvider(),
+		providers:    make(map[string]DataProvider),
+		elevatedRisk: make(map[string]RiskAlert),
+
+		environmentCache: newEnvironmentCache(defaultEnvironmentCacheTTL),
+		eventSnapshots:   newSnapshotStore(),
+		rateLimiter:      newRateLimiter(defaultRateLimits),
+		maxAircraftCount: defaultMaxAircraftCount,
+		shutdown:         make(chan struct{}),
+	}
+	// Neither name can clash
+	s.RegisterProvider(s.mockProvider)
+	s.RegisterProvider(s.liveProvider)
+	s.defaultProvider = s.mockProvider.Name()
+	return s
+}
+
+// ErrUnknownProvider is returned when no provider is registered under a name
+var ErrUnknownProvider = errors.New("unknown provider")
+
+// RegisterProvider makes p selectable by its name with the provider query
+// parameter. Names must be unique.
+func (s *APIBridgeServer) RegisterProvider(p DataProvider) error {
+	name := p.Name()
+	if strings.TrimSpace(name) == "" {
+		return errors.New("provider name must not be empty")
+	}
+	s.providersMu.Lock()
+	defer s.providersMu.Unlock()
+	if _, exists := s.providers[name]; exists {
+		return fmt.Errorf("provider %q is already registered", name)
+	}
+	s.providers[name] = p
+	s.providerNames = append(s.providerNames, name)
+	return nil
+}
+
+// Provider returns the provider registered under name
+func (s *APIBridgeServer) Provider(name string) (DataProvider, bool) {
+	s.providersMu.RLock()
+	defer s.providersMu.RUnlock()
+	p, ok := s.providers[name]
+	return p, ok
+}
+
+// Providers returns the registered providers in registration order
+func (s *APIBridgeServer) Providers() []DataProvider {
+	s.providersMu.RLock()
+	defer s.providersMu.RUnlock()
+	providers := make([]DataProvider, 0, len(s.providerNames))
+	for _, name := range s.providerNames {
+		providers = append(providers, s.providers[name])
+	}
+	return providers
+}
+
+// SetDefaultProvider sets the provider used when a request names none
+func (s *APIBridgeServer) SetDefaultProvider(name string) error {
+	s.providersMu.Lock()
+	defer s.providersMu.Unlock()
+	if _, ok := s.providers[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+	s.defaultProvider = name
+	return nil
+}
+
+// DefaultProvider returns the name of the provider used when a request
+// names none
+func (s *APIBridgeServer) DefaultProvider() string {
+	s.providersMu.RLock()
+	defer s.providersMu.RUnlock()
+	return s.defaultProvider
+}
+
+// resolveProvider returns the provider a provider query parameter names, or
+// the default provider when it is empty
+func (s *APIBridgeServer) resolveProvider(name string) (DataProvider, error) {
+	if name == "" {
+		name = s.DefaultProvider()
+	}
+	p, ok := s.Provider(name)
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must be one of %s", ErrUnknownProvider, name, strings.Join(s.providerNameList(), ", "))
+	}
+	return p, nil
+}
+
+// providerNameList returns the registered provider names in registration order
+func (s *APIBridgeServer) providerNameList() []string {
+	s.providersMu.RLock()
+	defer s.providersMu.RUnlock()
+	return append([]string(nil), s.providerNames...)
+}
+
+// Name returns the provider name
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// Ping checks if the provider is available
+func (p *MockProvider) Ping() bool {
+	// Mock provider is always available
+	return true
+}
+
+// GetFlightEnvironment retrieves flight environment data using mock implementations
+func (p *MockProvider) GetFlightEnvironment(ctx context.Context, params map[string]string) (*FlightEnvironmentData, error) {
+	logger := logFor(ctx).With("provider", p.Name())
+
+	// Parse count parameter
+	count := defaultAircraftCount
+	if countStr, ok := params["aircraft_count"]; ok {
+		if c, err := strconv.Atoi(countStr); err == nil {
+			count = c
+		}
+	}
+	
+	logger.Info("using aircraft count", "count", count)
+
+	// Resolve the route and the lists to report on once for the sections
+	// that use them
+	legs := routeLegs(p.airportsAPI, params, logger)
+	hasRoute := len(legs) > 0
+	lists := resolveEnvironmentLists(params, legs, logger)
+	scenario, err := LookupMockScenario(params["scenario"])
+	if err != nil {
+		logger.Warn("ignoring parameter", "error", err)
+		scenario = MockScenarios[DefaultMockScenario]
+	}
+	faults, err := parseMockFaults(params)
+	if err != nil {
+		logger.Warn("ignoring fault injection", "error", err)
+		faults = &mockFaults{}
+	}
+	fixtures := p.currentFixtures()
+	if fixtures == nil {
+		fixtures = &mockFixtures{}
+	}
+
+	// Initialize response data
+	envData := &FlightEnvironmentData{
+		Weather:        make(map[string]*WeatherData),
+		Geopolitical:   make(map[string]*GeopoliticalRisk),
+		Sustainability: make(map[string]*SustainabilityData),
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+	}
+
+	// Check for context cancellation, during any injected latency too
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+	if err := faults.delay(ctx); err != nil {
+		return nil, err
+	}
+
+	// The sections are independent, so they are fetched concurrently and
+	// write into envData under mu
+	var mu sync.Mutex
+	sections := newSectionTracker(p.Name())
+	// start begins timing a section, failing it at once when a fault is
+	// injected into it
+	start := func(section string) (func(err error), bool) {
+		done := sections.start(section, p.Name())
+		if err := faults.fault(section); err != nil {
+			logger.Warn("injecting fault", "section", section, "error", err)
+			done(err)
+			return nil, false
+		}
+		return done, true
+	}
+	err = fetchConcurrently(ctx,
+		// Aircraft
+		func(ctx context.Context) {
+			done, ok := start(sectionAircraft)
+			if !ok {
+				return
+			}
+			var aircraft []Aircraft
+			var err error
+			if fixtures.aircraft != nil {
+				logger.Info("serving aircraft fixture", "count", len(fixtures.aircraft))
+				aircraft = append([]Aircraft{}, fixtures.aircraft...)
+			} else {
+				aircraftParams := map[string]string{"limit": strconv.Itoa(count)}
+				logger.Info("fetching aircraft data", "limit", count)
+				aircraft, err = p.aircraftAPI.GetAircraft(aircraftParams)
+			}
+			done(err)
+			if err != nil {
+				logger.Error("error fetching aircraft data", "error", err)
+				return
+			}
+			logger.Info("retrieved aircraft data", "count", len(aircraft))
+			mu.Lock()
+			envData.Aircraft = aircraft
+			mu.Unlock()
+		},
+		// Flights, restricted to the requested route when there is one, and
+		// their emissions when asked for
+		func(ctx context.Context) {
+			done, ok := start(sectionFlights)
+			if !ok {
+				return
+			}
+			var flights []Flight
+			var err error
+			if fixtures.flights != nil {
+				logger.Info("serving flights fixture", "count", len(fixtures.flights))
+				flights = append([]Flight{}, fixtures.flights...)
+			} else if hasRoute {
+				for _, leg := range legs {
+					logger.Info("fetching flights for route", "origin", leg.Origin, "destination", leg.Destination)
+					var legFlights []Flight
+					if legFlights, err = p.flightsAPI.GetFlightsByRoute(leg.Origin, leg.Destination); err != nil {
+						break
+					}
+					flights = append(flights, legFlights...)
+				}
+				if count >= 0 && len(flights) > count {
+					flights = flights[:count]
+				}
+			} else {
+				// Between the airports whose weather is reported
+				flightParams := map[string]string{"limit": strconv.Itoa(count), "airports": strings.Join(lists.airports, ",")}
+				logger.Info("fetching flight data", "limit", count)
+				flights, err = p.flightsAPI.GetFlights(flightParams)
+			}
+			done(err)
+			if err != nil {
+				logger.Error("error fetching flight data", "error", err)
+				return
+			}
+			logger.Info("retrieved flight data", "count", len(flights))
+			mu.Lock()
+			envData.Flights = flights
+			mu.Unlock()
+
+			if !includes(params, "emissions_report") || ctx.Err() != nil {
+				return
+			}
+			if done, ok = start(sectionEmissionsReport); !ok {
+				return
+			}
+			report, err := p.sustainabilityAPI.GetFleetEmissionsReport(flights)
+			done(err)
+			if err != nil {
+				logger.Error("error building emissions report", "error", err)
+				return
+			}
+			mu.Lock()
+			envData.EmissionsReport = report
+			mu.Unlock()
+		},
+		// Weather for the requested airports
+		func(ctx context.Context) {
+			done, ok := start(sectionWeather)
+			if !ok {
+				return
+			}
+			if fixtures.weather != nil {
+				logger.Info("serving weather fixture", "airports", len(fixtures.weather))
+				done(nil)
+				mu.Lock()
+				envData.Weather = copyFixtureMap(fixtures.weather)
+				mu.Unlock()
+				return
+			}
+			logger.Info("fetching weather data", "airports", lists.airports)
+			weatherData, weatherFailures, err := p.weatherAPI.GetMultipleAirportsWeather(lists.airports)
+			if err != nil {
+				done(err)
+				logger.Error("error fetching weather data", "error", err)
+				return
+			}
+			for airport, err := range weatherFailures {
+				sections.degrade(sectionWeather, fmt.Errorf("%s: %w", airport, err))
+				logger.Error("error fetching weather", "airport", airport, "error", err)
+			}
+			done(nil)
+			logger.Info("retrieved weather data", "airports", len(weatherData))
+			mu.Lock()
+			envData.Weather = weatherData
+			mu.Unlock()
+		},
+		// NOTAMs for the same airports by ICAO location
+		func(ctx context.Context) {
+			done, ok := start(sectionNOTAMs)
+			if !ok {
+				return
+			}
+			notamLocations := icaoLocations(lists.airports)
+			logger.Info("fetching NOTAMs", "locations", notamLocations)
+			notams, err := p.notamAPI.GetNOTAMsForAirports(notamLocations)
+			done(err)
+			if err != nil {
+				logger.Error("error fetching NOTAMs", "error", err)
+				return
+			}
+			logger.Info("retrieved NOTAMs", "count", len(notams))
+			mu.Lock()
+			envData.NOTAMs = notams
+			mu.Unlock()
+		},
+		// News for the countries along the route, or geopolitical news for
+		// the default topics without one, and the no-fly zones in it
+		func(ctx context.Context) {
+			done, ok := start(sectionNews)
+			if !ok {
+				return
+			}
+			var geoNews *NewsResponse
+			var routeNews map[string]*NewsResponse
+			var err error
+			if fixtures.news != nil {
+				logger.Info("serving news fixture", "articles", len(fixtures.news.Articles))
+				news := *fixtures.news
+				news.Articles = append([]NewsArticle{}, fixtures.news.Articles...)
+				geoNews = &news
+			} else if hasRoute {
+				for _, leg := range legs {
+					logger.Info("fetching news for countries along route", "origin", leg.Origin, "destination", leg.Destination)
+					var legNews map[string]*NewsResponse
+					if legNews, err = p.newsAPI.GetNewsForRoute(leg.Origin, leg.Destination); err != nil {
+						break
+					}
+					routeNews = mergeRouteNews(routeNews, legNews)
+				}
+				if err == nil {
+					geoNews = combineRouteNews(routeNews)
+				}
+			} else {
+				logger.Info("fetching geopolitical news", "topics", lists.topics)
+				geoNews, err = p.newsAPI.GetGeopoliticalNews(lists.topics)
+			}
+			done(err)
+			if err != nil {
+				logger.Error("error fetching geopolitical news", "error", err)
+				return
+			}
+			logger.Info("retrieved news articles", "count", geoNews.Count)
+			events := airspaceEvents(geoNews)
+			logger.Info("extracted airspace events", "events", len(events))
+			mu.Lock()
+			envData.RouteNews = routeNews
+			envData.News = geoNews
+			envData.AirspaceEvents = events
+			mu.Unlock()
+		},
+		// Geopolitical risk
+		func(ctx context.Context) {
+			done, ok := start(sectionGeopolitical)
+			if !ok {
+				return
+			}
+			if fixtures.geopolitical != nil {
+				logger.Info("serving geopolitical fixture", "countries", len(fixtures.geopolitical))
+				done(nil)
+				mu.Lock()
+				envData.Geopolitical = copyFixtureMap(fixtures.geopolitical)
+				mu.Unlock()
+				return
+			}
+			geoRisks := make(map[string]*GeopoliticalRisk)
+			for _, country := range lists.countries {
+				if ctx.Err() != nil {
+					done(ctx.Err())
+					return
+				}
+				risk, err := p.geopoliticalAPI.GetCountryRisk(country)
+				if err != nil {
+					sections.degrade(sectionGeopolitical, fmt.Errorf("%s: %w", country, err))
+					logger.Error("error fetching risk", "country", country, "error", err)
+				} else {
+					geoRisks[country] = risk
+				}
+			}
+			if len(geoRisks) == 0 && len(lists.countries) > 0 {
+				done(errors.New("no country risk could be assessed"))
+			} else {
+				done(nil)
+			}
+			mu.Lock()
+			envData.Geopolitical = geoRisks
+			mu.Unlock()
+		},
+		// Sustainability of each leg of the route, or of the legs of the
+		// fixture whether or not there is a route
+		func(ctx context.Context) {
+			if fixtures.sustainability != nil {
+				done, ok := start(sectionSustainability)
+				if !ok {
+					return
+				}
+				logger.Info("serving sustainability fixture", "legs", len(fixtures.sustainability))
+				done(nil)
+				mu.Lock()
+				envData.Sustainability = copyFixtureMap(fixtures.sustainability)
+				mu.Unlock()
+				return
+			}
+			if !hasRoute {
+				sections.skip(sectionSustainability, p.Name())
+				return
+			}
+			done, ok := start(sectionSustainability)
+			if !ok {
+				return
+			}
+			byLeg := make(map[string]*SustainabilityData, len(legs))
+			for _, leg := range legs {
+				sustainability, err := p.sustainabilityAPI.GetRouteEmissions(leg.Origin, leg.Destination)
+				if err != nil {
+					done(err)
+					logger.Error("error fetching sustainability data", "leg", leg.String(), "error", err)
+					return
+				}
+				// Adjust for a SAF blend when saf is given, before pricing the offset
+				if saf, err := parseSAFParam(params["saf"]); err != nil {
+					logger.Warn("ignoring parameter", "error", err)
+				} else if saf > 0 {
+					sustainability.CO2Emissions, sustainability.FuelBlend = p.sustainabilityAPI.ApplyFuelBlend(sustainability.CO2Emissions, saf)
+				}
+				// Radiative forcing is applied unless radiative_forcing=false
+				sustainability.Offset = p.sustainabilityAPI.EstimateOffset(sustainability.CO2Emissions, params["radiative_forcing"] != "false")
+				byLeg[leg.String()] = sustainability
+			}
+			done(nil)
+			mu.Lock()
+			for key, sustainability := range byLeg {
+				envData.Sustainability[key] = sustainability
+			}
+			mu.Unlock()
+		},
+		// Weather along each leg of the route
+		func(ctx context.Context) {
+			if !hasRoute {
+				sections.skip(sectionRouteWeather, p.Name())
+				return
+			}
+			done, ok := start(sectionRouteWeather)
+			if !ok {
+				return
+			}
+			var routeWeather []RouteWeatherPoint
+			byLeg := make(map[string][]RouteWeatherPoint, len(legs))
+			for _, leg := range legs {
+				points, err := p.weatherAPI.GetRouteWeather(leg.Origin, leg.Destination, defaultRouteWeatherSamples)
+				if err != nil {
+					done(err)
+					logger.Error("error fetching route weather", "leg", leg.String(), "error", err)
+					return
+				}
+				routeWeather = append(routeWeather, points...)
+				byLeg[leg.String()] = points
+			}
+			done(nil)
+			mu.Lock()
+			envData.RouteWeather = routeWeather
+			envData.RouteWeatherByLeg = byLeg
+			mu.Unlock()
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Flights from a fixture keep the aircraft it gives them
+	if fixtures.flights == nil {
+		assignMockAircraft(envData)
+	}
+	if params["simulate"] == "true" {
+		p.simulations.advance(simulationKey(params), envData)
+	}
+	applyMockScenario(envData, scenario)
+	deriveNoFlyZones(envData)
+	sections.finish(envData)
+
+	return envData, nil
+}
+
+// assignMockAircraft has the flights fly the generated aircraft, in turn,
+// since the flights API picks aircraft IDs of its own. Flights have no
+// aircraft when none were generated.
+func assignMockAircraft(envData *FlightEnvironmentData) {
+	for i := range envData.Flights {
+		if len(envData.Aircraft) == 0 {
+			envData.Flights[i].Aircraft = ""
+			continue
+		}
+		envData.Flights[i].Aircraft = envData.Aircraft[i%len(envData.Aircraft)].ID
+	}
+}
+
+// applyMockScenario shapes generated data toward a scenario, copying what
+// it changes since the mock APIs may share it
+func applyMockScenario(envData *FlightEnvironmentData, scenario *MockScenario) {
+	if profile := scenario.Weather; profile != nil && len(envData.Weather) > 0 {
+		airports := make([]string, 0, len(envData.Weather))
+		for airport := range envData.Weather {
+			airports = append(airports, airport)
+		}
+		sort.Strings(airports)
+		rand.Shuffle(len(airports), func(i, j int) { airports[i], airports[j] = airports[j], airports[i] })
+		n := int(math.Ceil(profile.Share * float64(len(airports))))
+		for _, airport := range airports[:n] {
+			if envData.Weather[airport] == nil {
+				continue
+			}
+			weather := *envData.Weather[airport]
+			if len(profile.Conditions) > 0 {
+				weather.Conditions = profile.Conditions[rand.Intn(len(profile.Conditions))]
+			}
+			if profile.VisibilityKm.set() {
+				weather.Visibility = profile.VisibilityKm.draw()
+			}
+			if profile.WindKph.set() {
+				weather.WindSpeed = profile.WindKph.draw()
+			}
+			if profile.PrecipitationMm.set() {
+				weather.Precipitation = profile.PrecipitationMm.draw()
+			}
+			weather.FlightCategory = MockFlightCategory(weather.Visibility)
+			envData.Weather[airport] = &weather
+		}
+	}
+
+	// Flights keep their own status when none of the scenario's fits their
+	// schedule
+	if len(scenario.FlightStatuses) > 0 {
+		now := time.Now()
+		for i := range envData.Flights {
+			var fitting []string
+			for _, status := range scenario.FlightStatuses {
+				if statusFitsSchedule(clients.NormalizeStatus(status), envData.Flights[i], now) {
+					fitting = append(fitting, status)
+				}
+			}
+			if len(fitting) > 0 {
+				status := fitting[rand.Intn(len(fitting))]
+				envData.Flights[i].Status, envData.Flights[i].StatusCode = status, clients.NormalizeStatus(status)
+			}
+		}
+	}
+
+	if profile := scenario.Risk; profile != nil {
+		for country, risk := range envData.Geopolitical {
+			if risk == nil {
+				continue
+			}
+			adjusted := *risk
+			adjusted.RiskLevel = int(math.Round(profile.Levels.draw()))
+			if profile.Advisory != "" {
+				adjusted.Advisory = profile.Advisory
+			}
+			envData.Geopolitical[country] = &adjusted
+		}
+	}
+
+	// The airspace events follow from the news, and the no-fly zones from
+	// them once the scenario is applied
+	if profile := scenario.News; profile != nil && envData.News != nil {
+		news := *envData.News
+		if profile.Replace {
+			news.Articles = []NewsArticle{}
+		}
+		now := time.Now().UTC().Format(time.RFC3339)
+		for _, article := range profile.Articles {
+			article.PublishedAt = now
+			news.Articles = append(news.Articles, article)
+		}
+		news.Count = len(news.Articles)
+		envData.News = &news
+		envData.AirspaceEvents = airspaceEvents(&news)
+		if profile.Replace {
+			envData.RouteNews = nil
+		}
+	}
+}
+
+// fetchConcurrently runs each fetch in its own goroutine and waits for them
+// all. It returns ctx's error as soon as ctx is done, without waiting for
+// the fetches still running, which are handed ctx so they can stop early.
+func fetchConcurrently(ctx context.Context, fetches ...func(ctx context.Context)) error {
+	var wg sync.WaitGroup
+	for _, fetch := range fetches {
+		wg.Add(1)
+		go func(fetch func(ctx context.Context)) {
+			defer wg.Done()
+			fetch(ctx)
+		}(fetch)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	return ctx.Err()
+}
+
+// Capabilities declares which parts of the DataProvider contract the provider supports
+func (p *MockProvider) Capabilities() ProviderCapabilities {
+	// Fixtures are served as they are, whatever aircraft_count asks for and
+	// whether or not they agree with each other
+	fixtures := p.currentFixtures()
+	return ProviderCapabilities{
+		Cancellation:       true,
+		ExactAircraftCount: fixtures == nil || fixtures.aircraft == nil,
+		ConcurrentSafe:     true,
+		ConsistentSections: fixtures == nil,
+	}
+}
+
+// GetAircraft returns up to limit mock aircraft
+func (p *MockProvider) GetAircraft(ctx context.Context, limit int) ([]Aircraft, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.aircraftAPI.GetAircraft(map[string]string{"limit": strconv.Itoa(limit)})
+}
+
+// GetFlights returns mock flights from dep to arr, or from or to one of them
+func (p *MockProvider) GetFlights(ctx context.Context, dep, arr string) ([]Flight, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	switch {
+	case dep != "" && arr != "":
+		return p.flightsAPI.GetFlightsByRoute(dep, arr)
+	case dep != "":
+		return p.flightsAPI.GetFlightsForAirport(dep, true)
+	case arr != "":
+		return p.flightsAPI.GetFlightsForAirport(arr, false)
+	}
+	return nil, errors.New("flights need a departure or arrival airport")
+}
+
+// GetWeather returns the mock weather at an airport
+func (p *MockProvider) GetWeather(ctx context.Context, airport string) (*WeatherData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	weather, failures, err := p.weatherAPI.GetMultipleAirportsWeather([]string{airport})
+	if err != nil {
+		return nil, err
+	}
+	if err := failures[airport]; err != nil {
+		return nil, err
+	}
+	return weather[airport], nil
+}
+
+// GetCountryRisk returns the static risk assessment of a country
+func (p *MockProvider) GetCountryRisk(ctx context.Context, country string) (*GeopoliticalRisk, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.geopoliticalAPI.GetCountryRisk(country)
+}
+
+// GetNews returns mock geopolitical news for topics
+func (p *MockProvider) GetNews(ctx context.Context, topics []string) (*NewsResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return p.newsAPI.GetGeopoliticalNews(topics)
+}
+
+// includes reports whether the comma-separated include parameter names
+// section
+func includes(params map[string]string, section string) bool {
+	for _, name := range strings.Split(params["include"], ",") {
+		if strings.EqualFold(strings.TrimSpace(name), section) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSAFParam parses a saf parameter, the percentage of sustainable
+// aviation fuel in the blend burned. An empty parameter means none.
+func parseSAFParam(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	saf, err := strconv.ParseFloat(value, 64)
+	if err != nil || !(saf >= 0 && saf <= 100) {
+		return 0, fmt.Errorf("invalid saf %q: must be a percentage between 0 and 100", value)
+	}
+	return saf, nil
+}
+
+// Sections of FlightEnvironmentData reported in Sections
+const (
+	sectionAircraft        = "aircraft"
+	sectionFlights         = "flights"
+	sectionWeather         = "weather"
+	sectionNOTAMs          = "notams"
+	sectionNews            = "news"
+	sectionGeopolitical    = "geopolitical"
+	sectionSustainability  = "sustainability"
+	sectionRouteWeather    = "route_weather"
+	sectionEmissionsReport = "emissions_report"
+)
+
+// Section statuses
+const (
+	SectionOK       = "ok"
+	SectionDegraded = "degraded" // Partial or fallback data
+	SectionFailed   = "failed"
+	SectionSkipped  = "skipped" // Not requested, such as the route sections without a route
+)
+
+// SectionStatus reports how one section of a flight environment response
+// was fetched
+type SectionStatus struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Source     string `json:"source,omitempty"` // The upstream API or mock, empty when calculated locally
+}
+
+// sectionTracker records the status of each section of a flight environment
+// response while the sections are fetched concurrently
+type sectionTracker struct {
+	mu       sync.Mutex
+	provider string // Labels the section metrics
+	sections map[string]*SectionStatus
+}
+
+func newSectionTracker(provider string) *sectionTracker {
+	return &sectionTracker{provider: provider, sections: make(map[string]*SectionStatus)}
+}
+
+// start begins timing section, fetched from source. The returned function
+// records the outcome: failed with err, otherwise ok unless degraded.
+func (t *sectionTracker) start(section, source string) func(err error) {
+	started := time.Now()
+	t.mu.Lock()
+	t.sections[section] = &SectionStatus{Source: source}
+	t.mu.Unlock()
+
+	return func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		status := t.sections[section]
+		status.DurationMs = time.Since(started).Milliseconds()
+		switch {
+		case err != nil:
+			status.Status = SectionFailed
+			status.Error = err.Error()
+		case status.Status == "":
+			status.Status = SectionOK
+		}
+	}
+}
+
+// degrade marks a started section as holding partial or fallback data
+// because of err
+func (t *sectionTracker) degrade(section string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	bridgeMetrics.fallbacks.inc(t.provider, section)
+	status := t.sections[section]
+	if status.Status == SectionFailed {
+		return
+	}
+	status.Status = SectionDegraded
+	if status.Error == "" {
+		status.Error = err.Error()
+	} else {
+		status.Error += "; " + err.Error()
+	}
+}
+
+// skip records that section was not fetched for this request
+func (t *sectionTracker) skip(section, source string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.sections[section] = &SectionStatus{Status: SectionSkipped, Source: source}
+}
+
+// finish sets envData's Sections, and its Degraded list to the sections
+// that failed or were degraded, and records how long each fetch took
+func (t *sectionTracker) finish(envData *FlightEnvironmentData) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	envData.Sections = t.sections
+	envData.Degraded = nil
+	for section, status := range t.sections {
+		if status.Status != SectionSkipped && status.Status != "" {
+			bridgeMetrics.sectionDuration.observe(float64(status.DurationMs)/1000, t.provider, section, status.Status)
+		}
+		if status.Status == SectionFailed || status.Status == SectionDegraded {
+			envData.Degraded = append(envData.Degraded, section)
+		}
+	}
+	sort.Strings(envData.Degraded)
+}
+
+// allSectionsFailed reports whether every section that was fetched failed
+func (d *FlightEnvironmentData) allSectionsFailed() bool {
+	fetched := 0
+	for _, status := range d.Sections {
+		switch status.Status {
+		case SectionSkipped:
+		case SectionFailed:
+			fetched++
+		default:
+			return false
+		}
+	}
+	return fetched > 0
+}
+
+// Generic handler for flight environment data
+func (s *APIBridgeServer) handleFlightEnvironment(w http.ResponseWriter, r *http.Request, provider DataProvider) {
+	w.Header().Set("Content-Type", "application/json")
+	logger := logFor(r.Context()).With("provider", provider.Name())
+	setRequestProvider(r.Context(), provider.Name())
+	logger.Info("received request for flight environment data")
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	// Extract query parameters into a map
+	params := make(map[string]string)
+	for key, values := range r.URL.Query() {
+		if len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	if _, err := parseSAFParam(params["saf"]); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	shape, err := parseEnvironmentShape(params)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	// CSV and NDJSON are made from the cached JSON, so the format does not
+	// belong in the cache key
+	format, err := negotiateFormat(r)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	var section string
+	if format != formatJSON {
+		if section, err = flatSection(shape); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	delete(params, "format")
+	w.Header().Add("Vary", "Accept")
+	if count, defaulted, err := s.parseAircraftCount(params); err != nil {
+		writeParamError(w, err)
+		return
+	} else if defaulted {
+		shape.defaults = map[string]string{"aircraft_count": strconv.Itoa(count)}
+	}
+	if err := normalizeEnvironmentLists(params); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := LookupMockScenario(params["scenario"]); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := parseMockFaults(params); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Providers ignore a route they cannot parse, so reject it here first
+	if params["route"] != "" {
+		if _, err := ParseRoutes(providerAirports(provider), params["route"]); err != nil {
+			writeRouteError(w, err)
+			return
+		}
+	}
+
+	// Get data from the cache, or the provider when it is cold, stale,
+	// refresh=true or simulate=true, since simulations move on every request
+	key := environmentCacheKey(provider.Name(), params)
+	bypass := params["refresh"] == "true" || params["simulate"] == "true"
+	entry, hit, err := s.environmentCache.get(ctx, key, bypass, func() (*cachedEnvironment, error) {
+		envData, err := provider.GetFlightEnvironment(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		// Partial data is still a success, but nothing at all means every
+		// upstream failed
+		status := http.StatusOK
+		if envData.allSectionsFailed() {
+			logger.Error("every section failed", "sections", envData.Degraded)
+			status = http.StatusBadGateway
+		}
+		body, err := marshalEnvironment(envData, shape)
+		if err != nil {
+			return nil, fmt.Errorf("encoding response to JSON: %w", err)
+		}
+		if status == http.StatusOK {
+			s.history.record(provider.Name(), params, envData)
+		}
+		return newCachedEnvironment(append(body, '\n'), status), nil
+	})
+	if err != nil {
+		var statusCode int
+		
+		switch {
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+			statusCode = http.StatusGatewayTimeout
+			logger.Warn("request timed out or was canceled", "error", err)
+		case errors.Is(err, errLiveUnavailable):
+			statusCode = http.StatusServiceUnavailable
+			logger.Warn("provider unavailable", "error", err)
+		default:
+			statusCode = http.StatusInternalServerError
+			logger.Error("error getting flight environment data", "error", err)
+		}
+		
+		http.Error(w, fmt.Sprintf("Error: %v", err), statusCode)
+		return
+	}
+
+	cacheStatus := "MISS"
+	if hit {
+		cacheStatus = "HIT"
+		bridgeMetrics.cacheRequests.inc("hit")
+	} else {
+		bridgeMetrics.cacheRequests.inc("miss")
+	}
+	etag := entry.etag
+	if entry.status == http.StatusOK {
+		etag = formatETag(etag, format)
+	}
+	w.Header().Set("X-Cache", cacheStatus)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", entry.cacheControl())
+	if entry.status == http.StatusOK && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// Send one section as CSV or NDJSON, or the whole response as JSON
+	if entry.status == http.StatusOK && format != formatJSON {
+		if err := writeFlatEnvironment(w, entry.body, section, format); err != nil {
+			logger.Error("error encoding flight environment to "+string(format), "error", err)
+			return
+		}
+		logger.Info("sent flight environment data", "cache", cacheStatus, "format", format, "section", section)
+		return
+	}
+	w.WriteHeader(entry.status)
+	if _, err := w.Write(entry.body); err != nil {
+		logger.Error("error writing response", "error", err)
+		return
+	}
+	
+	logger.Info("sent flight environment data", "cache", cacheStatus)
+}
+
+// Handler for sample flight environment data
+func (s *APIBridgeServer) getSampleFlightEnvironmentData(w http.ResponseWriter, r *http.Request) {
+	s.handleFlightEnvironment(w, r, s.mockProvider)
+}
+
+// Handler for live flight environment data
+func (s *APIBridgeServer) getLiveFlightEnvironmentData(w http.ResponseWriter, r *http.Request) {
+	s.handleFlightEnvironment(w, r, s.liveProvider)
+}
+
+// SetEnvironmentCacheTTL sets how long flight environment responses are
+// cached, emptying the cache. Zero disables caching.
+func (s *APIBridgeServer) SetEnvironmentCacheTTL(ttl time.Duration) {
+	s.environmentCache = newEnvironmentCache(ttl)
+}
+
+// getFlightEnvironment serves flight environment data from the provider
+// named by the provider query parameter, or the default provider
+func (s *APIBridgeServer) getFlightEnvironment(w http.ResponseWriter, r *http.Request) {
+	provider, err := s.resolveProvider(r.URL.Query().Get("provider"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.handleFlightEnvironment(w, r, provider)
+}
+
+// ProviderInfo describes a registered provider
+type ProviderInfo struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // ok or error, from Ping
+	Default bool   `json:"default"`
+}
+
+// getProviders lists the registered providers and whether each responds
+func (s *APIBridgeServer) getProviders(w http.ResponseWriter, r *http.Request) {
+	defaultProvider := s.DefaultProvider()
+	providers := []ProviderInfo{}
+	for _, p := range s.Providers() {
+		status := "error"
+		if p.Ping() {
+			status = "ok"
+		}
+		providers = append(providers, ProviderInfo{
+			Name:    p.Name(),
+			Status:  status,
+			Default: p.Name() == defaultProvider,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"providers": providers,
+		"default":   defaultProvider,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logFor(r.Context()).Error("error encoding providers response", "error", err)
+		http.Error(w, "Error generating response", http.StatusInternalServerError)
+	}
+}
+
+// Tile endpoint limits
+const (
+	maxTileZoom         = 22
+	defaultTileFeatures = 200
+	maxTileFeatures     = 1000
+	tileClusterGrid     = 8
+	tileCacheMaxAge     = 30
+	maxMercatorLatitude = 85.05112878
+)
+
+// TileBounds is the geographic extent of a Web Mercator tile
+type TileBounds struct {
+	West  float64 `json:"west"`
+	South float64 `json:"south"`
+	East  float64 `json:"east"`
+	North float64 `json:"north"`
+}
+
+// GeoJSONFeatureCollection is a GeoJSON FeatureCollection of aircraft or clusters
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+	Bounds   TileBounds       `json:"bbox_tile"`
+	Total    int              `json:"total_in_tile"`
+	Cluster  bool             `json:"clustered"`
+}
+
+// GeoJSONFeature is a single GeoJSON point feature
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   GeoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONPoint is a GeoJSON point geometry; coordinates are [lon, lat]
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// tileBounds returns the bounds of tile x/y at zoom z
+func tileBounds(z, x, y int) TileBounds {
+	n := math.Exp2(float64(z))
+	return TileBounds{
+		West:  float64(x)/n*360 - 180,
+		East:  float64(x+1)/n*360 - 180,
+		North: tileLatitude(float64(y), n),
+		South: tileLatitude(float64(y+1), n),
+	}
+}
+
+// tileLatitude converts a tile row edge to a latitude
+func tileLatitude(y, n float64) float64 {
+	return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180 / math.Pi
+}
+
+// lonLatToTile returns the tile containing a coordinate at zoom z. Longitudes
+// outside -180..180 are wrapped and latitudes are clamped to the Mercator range.
+func lonLatToTile(lon, lat float64, z int) (x, y int) {
+	n := math.Exp2(float64(z))
+	lon = normalizeLongitude(lon)
+	lat = math.Max(-maxMercatorLatitude, math.Min(maxMercatorLatitude, lat))
+
+	x = int(math.Floor((lon + 180) / 360 * n))
+	latRad := lat * math.Pi / 180
+	y = int(math.Floor((1 - math.Log(math.Tan(latRad)+1/math.Cos(latRad))/math.Pi) / 2 * n))
+
+	// lon == 180 and the clamped poles land on the far edge
+	maxIndex := int(n) - 1
+	if x > maxIndex {
+		x = maxIndex
+	}
+	if y > maxIndex {
+		y = maxIndex
+	}
+	if y < 0 {
+		y = 0
+	}
+	return x, y
+}
+
+// normalizeLongitude wraps a longitude into -180..180, keeping 180 itself
+func normalizeLongitude(lon float64) float64 {
+	if lon >= -180 && lon <= 180 {
+		return lon
+	}
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// parseTileCoordinates validates z/x/y path values
+func parseTileCoordinates(vars map[string]string) (z, x, y int, err error) {
+	if z, err = strconv.Atoi(vars["z"]); err != nil || z < 0 || z > maxTileZoom {
+		return 0, 0, 0, fmt.Errorf("zoom must be between 0 and %d", maxTileZoom)
+	}
+	n := 1 << uint(z)
+	if x, err = strconv.Atoi(vars["x"]); err != nil || x < 0 || x >= n {
+		return 0, 0, 0, fmt.Errorf("x must be between 0 and %d at zoom %d", n-1, z)
+	}
+	if y, err = strconv.Atoi(vars["y"]); err != nil || y < 0 || y >= n {
+		return 0, 0, 0, fmt.Errorf("y must be between 0 and %d at zoom %d", n-1, z)
+	}
+	return z, x, y, nil
+}
+
+// buildAircraftTile selects the aircraft inside tile z/x/y and clusters them
+// on a grid when there are more than maxFeatures
+func buildAircraftTile(aircraft []Aircraft, z, x, y, maxFeatures int) *GeoJSONFeatureCollection {
+	tile := &GeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []GeoJSONFeature{},
+		Bounds:   tileBounds(z, x, y),
+	}
+
+	var inTile []Aircraft
+	for _, a := range aircraft {
+		if tx, ty := lonLatToTile(a.Location.Longitude, a.Location.Latitude, z); tx == x && ty == y {
+			inTile = append(inTile, a)
+		}
+	}
+	tile.Total = len(inTile)
+
+	if len(inTile) <= maxFeatures {
+		for _, a := range inTile {
+			tile.Features = append(tile.Features, GeoJSONFeature{
+				Type: "Feature",
+				Geometry: GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: [2]float64{normalizeLongitude(a.Location.Longitude), a.Location.Latitude},
+				},
+				Properties: map[string]interface{}{
+					"id":           a.ID,
+					"registration": a.Registration,
+					"model":        a.Model,
+					"altitude":     a.Altitude,
+					"speed":        a.Speed,
+					"heading":      a.Heading,
+					"status":       a.Status,
+				},
+			})
+		}
+		return tile
+	}
+
+	// Too many aircraft: cluster on a grid of sub-tiles
+	tile.Cluster = true
+	type cluster struct {
+		count    int
+		lon, lat float64
+	}
+	clusters := make(map[[2]int]*cluster)
+	for _, a := range inTile {
+		cx, cy := lonLatToTile(a.Location.Longitude, a.Location.Latitude, z+3)
+		key := [2]int{cx, cy}
+		c, ok := clusters[key]
+		if !ok {
+			c = &cluster{}
+			clusters[key] = c
+		}
+		c.count++
+		c.lon += normalizeLongitude(a.Location.Longitude)
+		c.lat += a.Location.Latitude
+	}
+
+	keys := make([][2]int, 0, len(clusters))
+	for key := range clusters {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][1] != keys[j][1] {
+			return keys[i][1] < keys[j][1]
+		}
+		return keys[i][0] < keys[j][0]
+	})
+
+	for _, key := range keys {
+		c := clusters[key]
+		tile.Features = append(tile.Features, GeoJSONFeature{
+			Type: "Feature",
+			Geometry: GeoJSONPoint{
+				Type:        "Point",
+				Coordinates: [2]float64{c.lon / float64(c.count), c.lat / float64(c.count)},
+			},
+			Properties: map[string]interface{}{
+				"cluster":     true,
+				"point_count": c.count,
+			},
+		})
+	}
+	return tile
+}
+
+// Handler for aircraft GeoJSON tiles
+func (s *APIBridgeServer) getAircraftTile(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("received request for aircraft tile", "tile", r.URL.Path)
+
+	z, x, y, err := parseTileCoordinates(mux.Vars(r))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	maxFeatures := defaultTileFeatures
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if maxFeatures, err = parsePositiveInt("limit", limitStr, 1, maxTileFeatures); err != nil {
+			writeParamError(w, err)
+			return
+		}
+	}
+
+	provider, err := s.resolveProvider(r.URL.Query().Get("provider"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	setRequestProvider(r.Context(), provider.Name())
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	params := map[string]string{}
+	if countStr := r.URL.Query().Get("aircraft_count"); countStr != "" {
+		params["aircraft_count"] = countStr
+	}
+	if _, _, err := s.parseAircraftCount(params); err != nil {
+		writeParamError(w, err)
+		return
+	}
+
+	envData, err := provider.GetFlightEnvironment(ctx, params)
+	if err != nil {
+		logFor(r.Context()).Error("error getting aircraft for tile", "provider", provider.Name(), "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	// Tiles are cached against the snapshot they were cut from
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d/%d/%d|%d",
+		provider.Name(), envData.Timestamp, z, x, y, maxFeatures))))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", tileCacheMaxAge))
+	if snapshotTime, err := time.Parse(time.RFC3339, envData.Timestamp); err == nil {
+		w.Header().Set("Last-Modified", snapshotTime.UTC().Format(http.TimeFormat))
+	}
+	if match := r.Header.Get("If-None-Match"); match != "" && (match == etag || match == "*") {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	tile := buildAircraftTile(envData.Aircraft, z, x, y, maxFeatures)
+
+	w.Header().Set("Content-Type", "application/geo+json")
+	if err := json.NewEncoder(w).Encode(tile); err != nil {
+		logFor(r.Context()).Error("error encoding tile response to JSON", "error", err)
+		return
+	}
+
+	logFor(r.Context()).Info("sent tile", "z", z, "x", x, "y", y,
+		"features", len(tile.Features), "aircraft", tile.Total, "clustered", tile.Cluster)
+}
+
+// getAirlineFleet reports the fleet summary of an airline by IATA code
+func (s *APIBridgeServer) getAirlineFleet(w http.ResponseWriter, r *http.Request) {
+	iata := strings.ToUpper(mux.Vars(r)["iata"])
+	logFor(r.Context()).Info("received request for fleet summary", "airline", iata)
+
+	summary, err := s.mockProvider.aircraftAPI.GetFleetSummary(iata)
+	if err != nil {
+		logFor(r.Context()).Error("error getting fleet summary", "airline", iata, "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		logFor(r.Context()).Error("error encoding fleet summary to JSON", "error", err)
+	}
+}
+
+// parseCoordinates parses n comma-separated numbers
+func parseCoordinates(value string, n int) ([]float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("expected %d comma-separated numbers, got %d", n, len(parts))
+	}
+	numbers := make([]float64, n)
+	for i, part := range parts {
+		number, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("%q is not a number", part)
+		}
+		numbers[i] = number
+	}
+	return numbers, nil
+}
+
+// getFlightsInArea lists the flights inside a bounding box given as
+// bbox=minLat,minLon,maxLat,maxLon, or within a radius given as
+// near=lat,lon,radiusKm. A box with minLon above maxLon crosses the antimeridian.
+// Flights between airports, given as dep and arr, are served by
+// getFlightsByAirport.
+func (s *APIBridgeServer) getFlightsInArea(w http.ResponseWriter, r *http.Request) {
+	bbox, near := r.URL.Query().Get("bbox"), r.URL.Query().Get("near")
+	byAirport := r.URL.Query().Get("dep") != "" || r.URL.Query().Get("arr") != ""
+	given := 0
+	for _, set := range []bool{bbox != "", near != "", byAirport} {
+		if set {
+			given++
+		}
+	}
+	if given != 1 {
+		writeJSONError(w, http.StatusBadRequest, "exactly one of bbox, near or dep and arr is required")
+		return
+	}
+	if byAirport {
+		s.getFlightsByAirport(w, r)
+		return
+	}
+	logFor(r.Context()).Info("received request for flights in area")
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	var flights []Flight
+	if bbox != "" {
+		c, err := parseCoordinates(bbox, 4)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid bbox: %v", err))
+			return
+		}
+		box := BoundingBox{MinLat: c[0], MinLon: c[1], MaxLat: c[2], MaxLon: c[3]}
+		if err := box.Validate(); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid bbox: %v", err))
+			return
+		}
+		if flights, err = s.mockProvider.flightsAPI.GetFlightsInBoundingBox(box.MinLat, box.MinLon, box.MaxLat, box.MaxLon); err != nil {
+			logFor(r.Context()).Error("error getting flights in bounding box", "bbox", bbox, "error", err)
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+			return
+		}
+	} else {
+		c, err := parseCoordinates(near, 3)
+		if err == nil {
+			err = BoundingBox{MinLat: c[0], MinLon: c[1], MaxLat: c[0], MaxLon: c[1]}.Validate()
+		}
+		if err == nil && c[2] <= 0 {
+			err = errors.New("radius must be a positive number of kilometres")
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid near: %v", err))
+			return
+		}
+		if flights, err = s.mockProvider.flightsAPI.GetFlightsNearPoint(c[0], c[1], c[2]); err != nil {
+			logFor(r.Context()).Error("error getting flights near point", "near", near, "error", err)
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+			return
+		}
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if err := writeList(w, "flights", format, flights, "", nil); err != nil {
+		logFor(r.Context()).Error("error encoding flights to "+string(format), "error", err)
+		return
+	}
+	logFor(r.Context()).Info("sent flights in area", "count", len(flights))
+}
+
+// getAirportBoard lists an airport's departures or arrivals within the
+// window query parameter, a duration such as 6h (default 12h)
+func (s *APIBridgeServer) getAirportBoard(departures bool) http.HandlerFunc {
+	kind := "arrivals"
+	if departures {
+		kind = "departures"
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		iata := strings.ToUpper(mux.Vars(r)["iata"])
+		logFor(r.Context()).Info("received request for airport board", "airport", iata, "board", kind)
+
+		window := 12 * time.Hour
+		if windowStr := r.URL.Query().Get("window"); windowStr != "" {
+			d, err := time.ParseDuration(windowStr)
+			if err != nil || d <= 0 || d > 7*24*time.Hour {
+				writeJSONError(w, http.StatusBadRequest, "window must be a duration between 1s and 168h, e.g. 6h")
+				return
+			}
+			window = d
+		}
+
+		board := s.mockProvider.flightsAPI.GetArrivalsBoard
+		if departures {
+			board = s.mockProvider.flightsAPI.GetDeparturesBoard
+		}
+		entries, err := board(iata, window)
+		if errors.Is(err, clients.ErrAirportNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logFor(r.Context()).Error("error getting airport board", "airport", iata, "board", kind, "error", err)
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(entries); err != nil {
+			logFor(r.Context()).Error("error encoding airport board to JSON", "board", kind, "error", err)
+		}
+	}
+}
+
+// Route weather sampling limits
+const (
+	defaultRouteWeatherSamples = 5
+	maxRouteWeatherSamples     = 50
+)
+
+// getRouteWeather reports the weather along the great-circle route given as
+// route=JFK-LHR, at samples waypoints between the airports (default 5). A
+// route of several legs reports each, keyed by leg.
+func (s *APIBridgeServer) getRouteWeather(w http.ResponseWriter, r *http.Request) {
+	routeParam := r.URL.Query().Get("route")
+	logFor(r.Context()).Info("received request for route weather", "route", routeParam)
+
+	legs, err := ParseRoutes(s.mockProvider.airportsAPI, routeParam)
+	if err != nil {
+		writeRouteError(w, err)
+		return
+	}
+
+	samples := defaultRouteWeatherSamples
+	if samplesStr := r.URL.Query().Get("samples"); samplesStr != "" {
+		if samples, err = parsePositiveInt("samples", samplesStr, 0, maxRouteWeatherSamples); err != nil {
+			writeParamError(w, err)
+			return
+		}
+	}
+
+	byLeg := make(map[string][]RouteWeatherPoint, len(legs))
+	for _, leg := range legs {
+		points, err := s.mockProvider.weatherAPI.GetRouteWeather(leg.Origin, leg.Destination, samples)
+		if errors.Is(err, clients.ErrAirportNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logFor(r.Context()).Error("error getting route weather", "route", routeParam, "leg", leg.String(), "error", err)
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+			return
+		}
+		byLeg[leg.String()] = points
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(perLeg(legs, byLeg)); err != nil {
+		logFor(r.Context()).Error("error encoding route weather to JSON", "error", err)
+	}
+}
+
+// getPassengerEmissions reports one passenger's emissions for a route, e.g.
+// /sustainability/passenger?route=JFK-LAX&class=business&load_factor=0.8. A
+// route of several legs reports each, keyed by leg.
+func (s *APIBridgeServer) getPassengerEmissions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	routeParam := query.Get("route")
+	logFor(r.Context()).Info("received request for passenger emissions", "route", routeParam)
+
+	legs, err := ParseRoutes(s.mockProvider.airportsAPI, routeParam)
+	if err != nil {
+		writeRouteError(w, err)
+		return
+	}
+
+	var loadFactor float64 // Zero selects the default
+	if loadFactorStr := query.Get("load_factor"); loadFactorStr != "" {
+		f, err := strconv.ParseFloat(loadFactorStr, 64)
+		if err != nil || !(f > 0 && f <= 1) {
+			writeJSONError(w, http.StatusBadRequest, "load_factor must be above 0 and at most 1")
+			return
+		}
+		loadFactor = f
+	}
+	saf, err := parseSAFParam(query.Get("saf"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	byLeg := make(map[string]*PassengerEmissions, len(legs))
+	for _, leg := range legs {
+		emissions, err := s.mockProvider.sustainabilityAPI.GetPassengerEmissions(leg.Origin, leg.Destination, query.Get("class"), loadFactor)
+		if errors.Is(err, ErrInvalidCabinClass) {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, clients.ErrAirportNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logFor(r.Context()).Error("error getting passenger emissions", "route", routeParam, "leg", leg.String(), "error", err)
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+			return
+		}
+		if saf > 0 {
+			co2, blend := s.mockProvider.sustainabilityAPI.ApplyFuelBlend(emissions.CO2PerPassengerKg, saf)
+			emissions.CO2PerPassengerKg = math.Round(co2*10) / 10
+			emissions.FuelBlend = blend
+		}
+		byLeg[leg.String()] = emissions
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(perLeg(legs, byLeg)); err != nil {
+		logFor(r.Context()).Error("error encoding passenger emissions to JSON", "error", err)
+	}
+}
+
+// getAircraftComparison ranks aircraft types by emissions, e.g.
+// /sustainability/compare?aircraft=A20N,B738,E190&distance=1500
+func (s *APIBridgeServer) getAircraftComparison(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	logFor(r.Context()).Info("received request to compare aircraft", "aircraft", query.Get("aircraft"))
+
+	var aircraft []string
+	for _, designator := range strings.Split(query.Get("aircraft"), ",") {
+		if designator = strings.TrimSpace(designator); designator != "" {
+			aircraft = append(aircraft, designator)
+		}
+	}
+	if len(aircraft) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "aircraft must list ICAO type designators such as A20N,B738,E190")
+		return
+	}
+	distance, err := strconv.ParseFloat(query.Get("distance"), 64)
+	if err != nil || !(distance > 0) {
+		writeJSONError(w, http.StatusBadRequest, "distance must be a positive number of kilometres")
+		return
+	}
+	saf, err := parseSAFParam(query.Get("saf"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := s.mockProvider.sustainabilityAPI.CompareAircraft(aircraft, distance)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+	if saf > 0 {
+		// The blend scales every type's CO2 alike, so the ranking is unchanged
+		report.SAFPercent = saf
+		for i := range report.Aircraft {
+			co2, _ := s.mockProvider.sustainabilityAPI.ApplyFuelBlend(report.Aircraft[i].CO2Kg, saf)
+			perSeatKm, _ := s.mockProvider.sustainabilityAPI.ApplyFuelBlend(report.Aircraft[i].CO2PerSeatKm, saf)
+			report.Aircraft[i].CO2Kg = math.Round(co2*10) / 10
+			report.Aircraft[i].CO2PerSeatKm = math.Round(perSeatKm*100) / 100
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logFor(r.Context()).Error("error encoding aircraft comparison to JSON", "error", err)
+	}
+}
+
+// getRouteRisk reports the geopolitical risk of the countries along a
+// great-circle route, e.g. /route-risk?route=JFK-DXB. A route of several
+// legs reports each, keyed by leg.
+func (s *APIBridgeServer) getRouteRisk(w http.ResponseWriter, r *http.Request) {
+	routeParam := r.URL.Query().Get("route")
+	logFor(r.Context()).Info("received request for route risk", "route", routeParam)
+
+	legs, err := ParseRoutes(s.mockProvider.airportsAPI, routeParam)
+	if err != nil {
+		writeRouteError(w, err)
+		return
+	}
+
+	byLeg := make(map[string]*RouteRisk, len(legs))
+	for _, leg := range legs {
+		risk, err := s.mockProvider.geopoliticalAPI.GetRouteRisk(leg.Origin, leg.Destination)
+		if errors.Is(err, clients.ErrAirportNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			logFor(r.Context()).Error("error getting route risk", "route", routeParam, "leg", leg.String(), "error", err)
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+			return
+		}
+		byLeg[leg.String()] = risk
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(perLeg(legs, byLeg)); err != nil {
+		logFor(r.Context()).Error("error encoding route risk to JSON", "error", err)
+	}
+}
+
+// watchRiskAlerts subscribes to risk alerts for riskCountries, which keeps
+// their risk data refreshed in the background and records every alert for
+// /risk-alerts. The returned function cancels the subscription.
+func (s *APIBridgeServer) watchRiskAlerts() (func(), error) {
+	return s.mockProvider.geopoliticalAPI.SubscribeRiskAlerts(riskCountries, riskAlertThreshold, func(alert RiskAlert) {
+		slog.Warn("risk alert", "country", alert.Country, "type", alert.Type, "from_level", alert.PreviousLevel, "to_level", alert.NewLevel, "threshold", alert.Threshold)
+		s.alertsMu.Lock()
+		defer s.alertsMu.Unlock()
+		s.riskAlerts = append(s.riskAlerts, alert)
+		if len(s.riskAlerts) > maxRiskAlerts {
+			s.riskAlerts = s.riskAlerts[len(s.riskAlerts)-maxRiskAlerts:]
+		}
+		if alert.Type == "recovered" {
+			delete(s.elevatedRisk, alert.Country)
+		} else {
+			s.elevatedRisk[alert.Country] = alert
+		}
+	})
+}
+
+// getRiskAlerts handles GET /risk-alerts, reporting the countries currently
+// at or above the alert threshold and the most recent alerts, newest first
+func (s *APIBridgeServer) getRiskAlerts(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("received request for risk alerts")
+
+	s.alertsMu.Lock()
+	elevated := make([]RiskAlert, 0, len(s.elevatedRisk))
+	for _, alert := range s.elevatedRisk {
+		elevated = append(elevated, alert)
+	}
+	recent := make([]RiskAlert, 0, len(s.riskAlerts))
+	for i := len(s.riskAlerts) - 1; i >= 0; i-- {
+		recent = append(recent, s.riskAlerts[i])
+	}
+	s.alertsMu.Unlock()
+	sort.Slice(elevated, func(i, j int) bool { return elevated[i].Country < elevated[j].Country })
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"threshold": riskAlertThreshold,
+		"elevated":  elevated,
+		"alerts":    recent,
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logFor(r.Context()).Error("error encoding risk alerts to JSON", "error", err)
+	}
+}
+
+// airspaceEventCountries returns every country with an airspace event, in
+// the order first reported
+func airspaceEventCountries(events []AirspaceEvent) []string {
+	zones := []string{}
+	for _, event := range events {
+		zones = append(zones, event.CountryISO2)
+	}
+	return removeDuplicates(zones)
+}
+
+// combineRouteNews merges per-country route news into one response, in
+// country code order, dropping articles reported for several countries
+func combineRouteNews(news map[string]*NewsResponse) *NewsResponse {
+	countries := make([]string, 0, len(news))
+	for country := range news {
+		countries = append(countries, country)
+	}
+	sort.Strings(countries)
+
+	combined := &NewsResponse{Articles: []NewsArticle{}, Query: fmt.Sprintf("countries:%v", countries)}
+	seen := make(map[string]bool)
+	for _, country := range countries {
+		for _, article := range news[country].Articles {
+			if !seen[article.URL] {
+				seen[article.URL] = true
+				combined.Articles = append(combined.Articles, article)
+			}
+		}
+	}
+	combined.Count = len(combined.Articles)
+	return combined
+}
+
+func removeDuplicates(slice []string) []string {
+	keys := make(map[string]bool)
+	result := []string{}
+	
+	for _, item := range slice {
+		if !keys[item] {
+			keys[item] = true
+			result = append(result, item)
+		}
+	}
+	
+	return result
+}
+
+// getUpstreamMetrics serves the bridge's metrics in the Prometheus text
+// format or, with format=json or an Accept header asking for JSON, upstream
+// API request metrics for each provider. Providers that do not track
+// upstream requests are reported as unavailable.
+func (s *APIBridgeServer) getUpstreamMetrics(w http.ResponseWriter, r *http.Request) {
+	if !wantsJSONMetrics(r) {
+		writePrometheusMetrics(w)
+		return
+	}
+
+	providers := map[string]interface{}{}
+	for _, p := range s.Providers() {
+		entry := map[string]interface{}{"available": false}
+		if m, ok := p.(metricsReporter); ok {
+			entry = map[string]interface{}{
+				"available": true,
+				"apis":      m.UpstreamMetrics(),
+			}
+		}
+		providers[p.Name()] = entry
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := map[string]interface{}{
+		"providers":               providers,
+		"unknown_flight_statuses": clients.UnknownStatusCounts(),
+		"weather_cache":           s.mockProvider.weatherAPI.CacheStats(),
+		"rate_limits":             s.rateLimiter.Stats(),
+		"timestamp":               time.Now().Format(time.RFC3339),
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logFor(r.Context()).Error("error encoding metrics response", "error", err)
+		http.Error(w, "Error generating response", http.StatusInternalServerError)
+	}
+}
+
+// Health check endpoint
+func (s *APIBridgeServer) healthCheck(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("health check request")
+	w.Header().Set("Content-Type", "application/json")
+	
+	// Check every provider, healthy only when they all respond. A provider
+	// without credentials is not configured rather than failing, so it is
+	// reported in details without degrading the server.
+	status := "healthy"
+	providerStatuses := make(map[string]string)
+	details := make(map[string]string)
+	for _, p := range s.Providers() {
+		providerStatuses[p.Name()] = "ok"
+		if p.Ping() {
+			continue
+		}
+		if c, ok := p.(credentialReporter); ok {
+			if missing := c.MissingCredentials(); len(missing) > 0 {
+				providerStatuses[p.Name()] = "unconfigured"
+				details[p.Name()] = "missing " + strings.Join(missing, ", ")
+				continue
+			}
+		}
+		providerStatuses[p.Name()] = "error"
+		status = "degraded"
+	}
+	
+	// Create response with detailed status
+	response := map[string]interface{}{
+		"status":    status,
+		"providers": providerStatuses,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if len(details) > 0 {
+		response["details"] = details
+	}
+	
+	// Set appropriate status code
+	if status != "healthy" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logFor(r.Context()).Error("error encoding health check response", "error", err)
+		http.Error(w, "Error generating response", http.StatusInternalServerError)
+		return
+	}
+	
+	logFor(r.Context()).Info("health check completed", "status", status, "providers", providerStatuses)
+}
+
+// handler routes the server's endpoints behind its middleware, configured
+// by options
+func (s *APIBridgeServer) handler(options serverOptions) http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/health", s.healthCheck).Methods("GET")
+	r.HandleFunc("/flight-environment", s.getFlightEnvironment).Methods("GET")
+	r.HandleFunc("/providers", s.getProviders).Methods("GET")
+	r.HandleFunc("/flight-environment/sample", s.getSampleFlightEnvironmentData).Methods("GET")
+	r.HandleFunc("/flight-environment/live", s.getLiveFlightEnvironmentData).Methods("GET")
+	r.HandleFunc("/flight-environment/stream", s.streamFlightEnvironment).Methods("GET")
+	r.HandleFunc("/flight-environment/events", s.streamFlightEnvironmentEvents).Methods("GET")
+	r.HandleFunc("/flight-environment/history", s.getHistory).Methods("GET")
+	r.HandleFunc("/flight-environment/history/{id}", s.getHistorySnapshot).Methods("GET")
+	r.HandleFunc("/flight-environment/diff", s.getEnvironmentDiff).Methods("GET")
+	r.HandleFunc("/aircraft/tiles/{z:[0-9]+}/{x:[0-9]+}/{y:[0-9]+}.json", s.getAircraftTile).Methods("GET")
+	r.HandleFunc("/metrics", s.getUpstreamMetrics).Methods("GET")
+	r.HandleFunc("/airlines/{iata:[A-Za-z0-9]{2}}/fleet", s.getAirlineFleet).Methods("GET")
+	r.HandleFunc("/flights", s.getFlightsInArea).Methods("GET")
+	r.HandleFunc("/aircraft", s.getAircraft).Methods("GET")
+	r.HandleFunc("/weather/{airport}", s.getAirportWeather).Methods("GET")
+	r.HandleFunc("/risk/{country}", s.getCountryRisk).Methods("GET")
+	r.HandleFunc("/news", s.getNews).Methods("GET")
+	r.HandleFunc("/flight-plan/evaluate", s.evaluateFlightPlan).Methods("POST")
+	r.HandleFunc("/airports/{iata:[A-Za-z]{3}}/departures", s.getAirportBoard(true)).Methods("GET")
+	r.HandleFunc("/airports/{iata:[A-Za-z]{3}}/arrivals", s.getAirportBoard(false)).Methods("GET")
+	r.HandleFunc("/route-weather", s.getRouteWeather).Methods("GET")
+	r.HandleFunc("/route-risk", s.getRouteRisk).Methods("GET")
+	r.HandleFunc("/risk-alerts", s.getRiskAlerts).Methods("GET")
+	r.HandleFunc("/sustainability/passenger", s.getPassengerEmissions).Methods("GET")
+	r.HandleFunc("/sustainability/compare", s.getAircraftComparison).Methods("GET")
+	// Admin routes change the server's state, so they only exist when API
+	// keys guard them
+	if len(options.apiKeys) > 0 {
+		r.HandleFunc("/admin/reload-fixtures", s.reloadFixtures).Methods("POST")
+	}
+	r.Use(metricsMiddleware, apiKeyMiddleware(options.apiKeys), s.rateLimitMiddleware)
+	return requestLogMiddleware(gzipMiddleware(corsMiddleware(r, options.corsOrigins)))
+}
+
+func main() {
+	// Log JSON lines, including what the clients package logs with log
+	slog.SetDefault(newLogger(os.Stderr))
+	slog.Info("initializing API Bridge Server")
+	
+	options, err := parseServerOptions(os.Args[1:])
+	if err != nil {
+		fatal("invalid server options", err)
+	}
+	serverHost, serverPort := options.host, options.port
+	if options.backfill != "" {
+		os.Exit(runBackfill(options.history, options.backfill))
+	}
+	
+	// Create server
+	server := NewAPIBridgeServer()
+	if err := server.SetDefaultProvider(options.defaultProvider); err != nil {
+		fatal("invalid default provider", err)
+	}
+	server.SetEnvironmentCacheTTL(options.cacheTTL)
+	server.SetRateLimits(options.rateLimits)
+	server.SetMaxAircraftCount(options.maxAircraftCount)
+	if options.history != "" {
+		store, err := openHistoryStore(options.history)
+		if err != nil {
+			fatal("failed to open snapshot history", err)
+		}
+		server.SetHistory(store, options.historyRetention)
+	}
+	if options.mockFixtures != "" {
+		status, err := server.mockProvider.LoadFixtures(options.mockFixtures)
+		if err != nil {
+			fatal("failed to load mock fixtures", err)
+		}
+		slog.Info("loaded mock fixtures", "dir", status.Dir, "loaded", status.Loaded, "generated", status.Generated)
+	}
+	stopRiskAlerts, err := server.watchRiskAlerts()
+	if err != nil {
+		fatal("failed to subscribe to risk alerts", err)
+	}
+	defer stopRiskAlerts()
+	
+	// Set up router
+	handler := server.handler(options)
+	if len(options.apiKeys) == 0 {
+		slog.Warn("no API keys configured, every endpoint is open to anyone and /admin routes are disabled; set BRIDGE_API_KEYS or -api-keys-file")
+	}
+	
+	// Create HTTP server
+	serverAddr := net.JoinHostPort(serverHost, serverPort)
+	
+	// Print server information before starting
+	fmt.Println("🚀 API Bridge Server starting on " + serverAddr)
+	fmt.Println("📡 Endpoints:")
+	fmt.Println("   GET /health - Health check")
+	fmt.Println("   GET /flight-environment?provider=mock|live&route=JFK-LAX&aircraft_count=5 - Get flight environment data, from the " + options.defaultProvider + " provider by default")
+	fmt.Println("     (cached for " + options.cacheTTL.String() + " with ETag support, refresh=true bypasses the cache)")
+	fmt.Println("     aircraft_count is 0 to " + strconv.Itoa(options.maxAircraftCount) + ", " + strconv.Itoa(defaultAircraftCount) + " by default")
+	fmt.Println("     sections=weather,geopolitical selects sections; aircraft_limit, aircraft_offset, flights_limit and flights_offset paginate")
+	fmt.Println("     format=csv|ndjson, or Accept: text/csv|application/x-ndjson, sends one list section, e.g. sections=weather, flattened")
+	fmt.Println("     route takes IATA or ICAO codes, multi-leg routes such as JFK-LHR-DXB and comma-separated routes such as KJFK-EGLL,JFK-LAX")
+	fmt.Println("     airports=JFK,LHR, countries=US,RU and topics=Iran,Russia replace the default weather airports, risk countries and news topics")
+	fmt.Println("     scenario=nominal|storm|crisis|quiet shapes the mock provider's data toward a situation")
+	fmt.Println("     mock_latency=2s, mock_fail=weather,news and mock_error_rate=0.3 (repeatable with seed=42) inject faults into the mock provider")
+	fmt.Println("     simulate=true&seed=42 moves the mock provider's aircraft and flights on between requests of the same seed")
+	fmt.Println("   GET /flight-environment/sample?route=JFK-LAX&aircraft_count=5 - Get sample flight environment data")
+	fmt.Println("   GET /flight-environment/live?route=JFK-LAX&aircraft_count=5 - Get live flight environment data")
+	fmt.Println("   GET /flight-environment/stream?provider=live&interval=10s&mode=snapshot|diff - WebSocket stream of flight environment updates")
+	fmt.Println("   GET /flight-environment/events?provider=live&interval=30s - Server-Sent Events of flight environment snapshots and changes")
+	fmt.Println("   GET /flight-environment/history?from=2024-01-02T00:00:00Z&to=...&provider=live&limit=100 - Stored snapshots, newest first, with -history")
+	fmt.Println("   GET /flight-environment/history/{id} - A stored snapshot")
+	fmt.Println("   GET /flight-environment/diff?from={id}&to={id}|latest - Aircraft, flight status, weather, risk and no-fly zone changes between stored snapshots")
+	fmt.Println("   GET /providers - Registered providers and their status")
+	fmt.Println("   GET /aircraft/tiles/{z}/{x}/{y}.json?aircraft_count=500 - Get aircraft in a Web Mercator tile as GeoJSON")
+	fmt.Println("   GET /metrics - Prometheus metrics; ?format=json for upstream API request counts, error rates and latency")
+	fmt.Println("   GET /airlines/{iata}/fleet - Fleet summary by model, engine type, status and age")
+	fmt.Println("   GET /flights?bbox=minLat,minLon,maxLat,maxLon | ?near=lat,lon,radiusKm - Flights in an area")
+	fmt.Println("   GET /flights?dep=JFK&arr=LHR&provider=mock|live - Flights between airports, or from or to one")
+	fmt.Println("   GET /aircraft?limit=10&provider=mock|live - Aircraft")
+	fmt.Println("     /flights and /aircraft take format=csv|ndjson or Accept: text/csv|application/x-ndjson too")
+	fmt.Println("   GET /weather/{airport}?provider=mock|live - Weather at an airport, by IATA or ICAO code")
+	fmt.Println("   GET /risk/{country}?provider=mock|live - Geopolitical risk of a country")
+	fmt.Println("   GET /news?topics=Iran,Russia&provider=mock|live - Geopolitical news")
+	fmt.Println("   POST /flight-plan/evaluate - Go, Caution or No-Go for {origin, destination, departure_time, aircraft_type, waypoints}")
+	fmt.Println("   GET /airports/{iata}/departures?window=12h - Departures board")
+	fmt.Println("   GET /airports/{iata}/arrivals?window=12h - Arrivals board")
+	fmt.Println("   GET /route-weather?route=JFK-LHR&samples=5 - Weather along a great-circle route, per leg for multi-leg routes")
+	fmt.Println("   GET /route-risk?route=JFK-DXB - Geopolitical risk of the countries along a route, per leg for multi-leg routes")
+	fmt.Println("   GET /risk-alerts - Countries above the risk alert threshold and recent alerts")
+	fmt.Println("   GET /sustainability/passenger?route=JFK-LAX&class=business&load_factor=0.8&saf=30 - Emissions per passenger, optionally on a SAF blend")
+	fmt.Println("   GET /sustainability/compare?aircraft=A20N,B738,E190&distance=1500 - Aircraft types ranked by CO2 per seat-km")
+	if len(options.apiKeys) > 0 {
+		fmt.Println("   POST /admin/reload-fixtures - Re-read the mock provider's fixtures from -mock-fixtures")
+	}
+	fmt.Println("⏱️  Per-client rate limits: " + server.rateLimiter.describe())
+	if len(options.apiKeys) > 0 {
+		fmt.Printf("🔑 %d API keys accepted in X-API-Key or Authorization: Bearer; /health needs none\n", len(options.apiKeys))
+	}
+	
+	// Check if the port is available before trying to bind
+	if err := checkPortAvailable(serverHost, serverPort); err != nil {
+		fatal("port check failed", err)
+	}
+	
+	httpServer := &http.Server{
+		Addr:         serverAddr,
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+	httpServer.RegisterOnShutdown(server.endStreams)
+	
+	// Channel to listen for errors coming from the listener
+	serverErrors := make(chan error, 1)
+	
+	// Start the server in a goroutine
+	go func() {
+		slog.Info("starting server", "addr", serverAddr)
+		err := httpServer.ListenAndServe()
+		
+		// Handle Windows-specific errors
+		if err != nil && err != http.ErrServerClosed {
+			if isWindowsSocketError(err) {
+				slog.Warn("windows socket error detected", "error", err)
+				if strings.Contains(err.Error(), "bind: permission denied") {
+					err = fmt.Errorf("%v - try running as administrator or using a different port", err)
+				} else if strings.Contains(err.Error(), "bind: address already in use") {
+					err = fmt.Errorf("Port %s is already in use by another application. Please close other applications or use a different port.", serverPort)
+				}
+			}
+			serverErrors <- err
+		}
+	}()
+	
+	// Channel to listen for interrupt or terminate signals
+	shutdown := make(chan os.Signal, 1)
+	signal.Notify(shutdown, os.Interrupt, syscall.SIGTERM)
+	
+	// Block until a signal is received or server error
+	select {
+	case err := <-serverErrors:
+		fatal("error starting server", err)
+		
+	case sig := <-shutdown:
+		slog.Info("received signal, initiating graceful shutdown", "signal", sig.String())
+		
+		// Create context with timeout for graceful shutdown
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		
+		// Attempt graceful shutdown
+		if err := httpServer.Shutdown(ctx); err != nil {
+			slog.Error("error during server shutdown", "error", err)
+			httpServer.Close()
+		}
+		if err := server.closeStreams(ctx); err != nil {
+			slog.Error("error closing flight environment streams", "error", err)
+		}
+		if err := server.closeHistory(ctx); err != nil {
+			slog.Error("error closing snapshot history", "error", err)
+		}
+		
+		slog.Info("server shutdown complete")
+	}
+}
+
+// Default listen address, reachable from the local machine only, and
+// default provider
+const (
+	defaultServerHost   = "127.0.0.1"
+	defaultServerPort   = "8081"
+	defaultProviderName = "mock"
+)
+
+// serverOptions configures the bridge server
+type serverOptions struct {
+	host             string
+	port             string
+	defaultProvider  string // Serves /flight-environment without a provider parameter
+	cacheTTL         time.Duration
+	corsOrigins      []string             // Origins allowed to call the bridge from a browser, * for any
+	apiKeys          []*APIKey            // Keys callers must present, none to allow anyone
+	rateLimits       map[string]RateLimit // Per-client limits by route group
+	history          string               // Snapshot history store, file:DIR or sqlite:PATH, empty to disable
+	historyRetention historyRetention
+	mockFixtures     string // Directory of JSON fixtures the mock provider serves, empty to generate everything
+	maxAircraftCount int    // Largest aircraft_count a request may ask for
+	backfill         string // Directory of JSON exports to import into history instead of serving
+}
+
+// parseServerOptions resolves the server options from the flags in args,
+// then the environment. The host and port come from -host and -port, then
+// BRIDGE_HOST and BRIDGE_PORT, then PORT as set by PaaS platforms. PORT alone
+// binds all interfaces, since the platform's router must reach the server.
+// The default provider comes from -provider, then BRIDGE_DEFAULT_PROVIDER,
+// the response cache TTL from -cache-ttl, then BRIDGE_CACHE_TTL, and the
+// comma-separated CORS origins from -cors-origins, then BRIDGE_CORS_ORIGINS.
+// API keys come from BRIDGE_API_KEYS and the file named by -api-keys-file or
+// BRIDGE_API_KEYS_FILE, and rate limits from -rate-limits, then
+// BRIDGE_RATE_LIMITS. The snapshot history store comes from -history, then
+// BRIDGE_HISTORY, and its retention from -history-max-age and
+// -history-max-count, then BRIDGE_HISTORY_MAX_AGE and
+// BRIDGE_HISTORY_MAX_COUNT. The mock provider's fixtures directory comes
+// from -mock-fixtures, then MOCK_FIXTURES_DIR, and the largest
+// aircraft_count from -max-aircraft-count, then BRIDGE_MAX_AIRCRAFT_COUNT.
+// -backfill, which has no environment variable since it runs once, imports
+// a directory of exports into the history store and exits.
+func parseServerOptions(args []string) (serverOptions, error) {
+	host, port := defaultServerHost, defaultServerPort
+	defaultProvider := defaultProviderName
+	cacheTTL := defaultEnvironmentCacheTTL
+	corsOrigins := defaultCORSOrigins
+	apiKeys, apiKeysFile := os.Getenv("BRIDGE_API_KEYS"), os.Getenv("BRIDGE_API_KEYS_FILE")
+	rateLimits := os.Getenv("BRIDGE_RATE_LIMITS")
+	history := os.Getenv("BRIDGE_HISTORY")
+	historyMaxAge, historyMaxCount := defaultHistoryMaxAge, defaultHistoryMaxCount
+	mockFixtures := os.Getenv("MOCK_FIXTURES_DIR")
+	maxAircraftCount := defaultMaxAircraftCount
+	var backfill string
+	if env := os.Getenv("PORT"); env != "" {
+		host, port = "0.0.0.0", env
+	}
+	if env := os.Getenv("BRIDGE_HOST"); env != "" {
+		host = env
+	}
+	if env := os.Getenv("BRIDGE_PORT"); env != "" {
+		port = env
+	}
+	if env := os.Getenv("BRIDGE_DEFAULT_PROVIDER"); env != "" {
+		defaultProvider = env
+	}
+	if env := os.Getenv("BRIDGE_CACHE_TTL"); env != "" {
+		ttl, err := time.ParseDuration(env)
+		if err != nil {
+			return serverOptions{}, fmt.Errorf("invalid BRIDGE_CACHE_TTL %q: %w", env, err)
+		}
+		cacheTTL = ttl
+	}
+	if env := os.Getenv("BRIDGE_CORS_ORIGINS"); env != "" {
+		corsOrigins = env
+	}
+	if env := os.Getenv("BRIDGE_HISTORY_MAX_AGE"); env != "" {
+		age, err := time.ParseDuration(env)
+		if err != nil {
+			return serverOptions{}, fmt.Errorf("invalid BRIDGE_HISTORY_MAX_AGE %q: %w", env, err)
+		}
+		historyMaxAge = age
+	}
+	if env := os.Getenv("BRIDGE_HISTORY_MAX_COUNT"); env != "" {
+		count, err := strconv.Atoi(env)
+		if err != nil {
+			return serverOptions{}, fmt.Errorf("invalid BRIDGE_HISTORY_MAX_COUNT %q: %w", env, err)
+		}
+		historyMaxCount = count
+	}
+	if env := os.Getenv("BRIDGE_MAX_AIRCRAFT_COUNT"); env != "" {
+		count, err := strconv.Atoi(env)
+		if err != nil {
+			return serverOptions{}, fmt.Errorf("invalid BRIDGE_MAX_AIRCRAFT_COUNT %q: %w", env, err)
+		}
+		maxAircraftCount = count
+	}
+
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flags.StringVar(&host, "host", host, "host or IP address to listen on (BRIDGE_HOST)")
+	flags.StringVar(&port, "port", port, "port to listen on, 1-65535 (BRIDGE_PORT or PORT)")
+	flags.StringVar(&defaultProvider, "provider", defaultProvider, "provider serving /flight-environment by default (BRIDGE_DEFAULT_PROVIDER)")
+	flags.DurationVar(&cacheTTL, "cache-ttl", cacheTTL, "how long flight environment responses are cached, 0 to disable (BRIDGE_CACHE_TTL)")
+	flags.StringVar(&corsOrigins, "cors-origins", corsOrigins, "comma-separated origins allowed to call the bridge from a browser, * for any (BRIDGE_CORS_ORIGINS)")
+	flags.StringVar(&apiKeysFile, "api-keys-file", apiKeysFile, "file of API keys, one label:key[:requests-per-minute] per line, added to BRIDGE_API_KEYS (BRIDGE_API_KEYS_FILE)")
+	flags.StringVar(&rateLimits, "rate-limits", rateLimits, "comma-separated per-client limits by route group, group=requests-per-minute[:burst] for groups health, live and default, 0 for no limit (BRIDGE_RATE_LIMITS)")
+	flags.StringVar(&history, "history", history, "store every generated flight environment in file:DIR or sqlite:PATH, empty to disable (BRIDGE_HISTORY)")
+	flags.DurationVar(&historyMaxAge, "history-max-age", historyMaxAge, "how long stored snapshots are kept, 0 for no limit (BRIDGE_HISTORY_MAX_AGE)")
+	flags.IntVar(&historyMaxCount, "history-max-count", historyMaxCount, "how many stored snapshots are kept, 0 for no limit (BRIDGE_HISTORY_MAX_COUNT)")
+	flags.StringVar(&mockFixtures, "mock-fixtures", mockFixtures, "directory of aircraft.json, flights.json, weather.json, news.json, geopolitical.json and sustainability.json the mock provider serves in place of generated data (MOCK_FIXTURES_DIR)")
+	flags.IntVar(&maxAircraftCount, "max-aircraft-count", maxAircraftCount, "largest aircraft_count a request may ask for (BRIDGE_MAX_AIRCRAFT_COUNT)")
+	flags.StringVar(&backfill, "backfill", "", "import the JSON exports in this directory into the -history store, print a summary and exit")
+	if err := flags.Parse(args); err != nil {
+		return serverOptions{}, err
+	}
+	if cacheTTL < 0 {
+		return serverOptions{}, fmt.Errorf("invalid cache TTL %v: must not be negative", cacheTTL)
+	}
+	if historyMaxAge < 0 {
+		return serverOptions{}, fmt.Errorf("invalid history max age %v: must not be negative", historyMaxAge)
+	}
+	if historyMaxCount < 0 {
+		return serverOptions{}, fmt.Errorf("invalid history max count %d: must not be negative", historyMaxCount)
+	}
+	if maxAircraftCount < 1 {
+		return serverOptions{}, fmt.Errorf("invalid max aircraft count %d: must be at least 1", maxAircraftCount)
+	}
+	if backfill != "" && history == "" {
+		return serverOptions{}, errors.New("-backfill needs a history store to import into; set -history or BRIDGE_HISTORY")
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(port))
+	if err != nil || n < 1 || n > 65535 {
+		return serverOptions{}, fmt.Errorf("invalid port %q: must be between 1 and 65535", port)
+	}
+	keys, err := loadAPIKeys(apiKeys, apiKeysFile)
+	if err != nil {
+		return serverOptions{}, err
+	}
+	limits, err := parseRateLimits(rateLimits)
+	if err != nil {
+		return serverOptions{}, err
+	}
+	return serverOptions{
+		host:            host,
+		port:            strconv.Itoa(n),
+		defaultProvider: defaultProvider,
+		cacheTTL:        cacheTTL,
+		corsOrigins:     parseCORSOrigins(corsOrigins),
+		apiKeys:         keys,
+		rateLimits:      limits,
+		history:         history,
+		historyRetention: historyRetention{
+			maxAge:   historyMaxAge,
+			maxCount: historyMaxCount,
+		},
+		mockFixtures:     mockFixtures,
+		maxAircraftCount: maxAircraftCount,
+		backfill:         backfill,
+	}, nil
+}
+
+// Check if a port is available before binding
+func checkPortAvailable(host, port string) error {
+	addr := net.JoinHostPort(host, port)
+	conn, err := net.Listen("tcp", addr)
+	if err != nil {
+		// If we can't listen, port is not available
+		if strings.Contains(err.Error(), "bind: address already in use") {
+			return fmt.Errorf("port %s is already in use", port)
+		}
+		if strings.Contains(err.Error(), "bind: permission denied") {
+			if runtime.GOOS == "windows" {
+				return fmt.Errorf("permission denied to bind to port %s - try running as administrator", port)
+			}
+			return fmt.Errorf("permission denied to bind to port %s", port)
+		}
+		return fmt.Errorf("cannot bind to port %s: %v", port, err)
+	}
+	
+	// Close the listener if it was successful
+	conn.Close()
+	return nil
+}
+
+// Helper function to detect Windows-specific socket errors
+func isWindowsSocketError(err error) bool {
+	if err == nil {
+		return false
+	}
+	
+	if runtime.GOOS != "windows" {
+		return false
+	}
+	
+	// Check for common Windows socket errors
+	errorStr := err.Error()
+	windowsErrorTexts := []string{
+		"bind: permission denied",
+		"bind: address already in use",
+		"specified socket address is already in use",
+		"use of closed network connection",
+		"wsasysnotready",
+		"wsavernotsupported",
+		"wsaeproviderfailedinit",
+	}
+	
+	for _, text := range windowsErrorTexts {
+		if strings.Contains(strings.ToLower(errorStr), strings.ToLower(text)) {
+			return true
+		}
+	}
+	
+	// Check for specific Windows error codes
+	windowsErrorCodes := []string{
+		"WSA", // Windows Socket API errors start with WSA
+		"WSAEACCES",
+		"WSAEADDRINUSE",
+		"WSAEADDRNOTAVAIL",
+	}
+	
+	for _, code := range windowsErrorCodes {
+		if strings.Contains(errorStr, code) {
+			return true
+		}
+	}
+	
+	return false
+}
+
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// newTestHandler returns the routed handler of a new server configured by
+// options, as main serves it
+func newTestHandler(t *testing.T, options serverOptions) (*APIBridgeServer, http.Handler) {
+	t.Helper()
+	server := NewAPIBridgeServer()
+	return server, server.handler(options)
+}
+
+// serve sends req through h and returns the recorded response
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMockEnvironmentIsConsistent(t *testing.T) {
+	airports := clients.NewEmbeddedAirportsAPI()
+	for _, scenario := range sortedKeys(MockScenarios) {
+		for _, route := range []string{"", "JFK-LAX", "JFK-LHR-DXB"} {
+			t.Run(scenario+"/"+route, func(t *testing.T) {
+				params := map[string]string{"scenario": scenario, "aircraft_count": "20"}
+				if route != "" {
+					params["route"] = route
+				}
+				envData, err := NewMockProvider().GetFlightEnvironment(context.Background(), params)
+				if err != nil {
+					t.Fatalf("GetFlightEnvironment: %v", err)
+				}
+				now := time.Now()
+				if len(envData.Flights) == 0 {
+					t.Fatal("no flights")
+				}
+				checkReferentialIntegrity(t, envData)
+				for _, flight := range envData.Flights {
+					for _, code := range []string{flight.Origin, flight.Destination} {
+						if _, err := airports.GetAirportByIATA(code); err != nil {
+							t.Errorf("flight %s uses unknown airport %s", flight.FlightNumber, code)
+						}
+					}
+					checkFlightSchedule(t, flight, now)
+				}
+			})
+		}
+	}
+}
+
+// checkFlightSchedule verifies that flight's status could be true at now
+// given its departure and arrival times
+func checkFlightSchedule(t *testing.T, flight Flight, now time.Time) {
+	t.Helper()
+	if !flight.ArrivalTime.After(flight.DepartureTime) {
+		t.Errorf("flight %s arrives at %s, before it departs at %s", flight.FlightNumber, flight.ArrivalTime, flight.DepartureTime)
+	}
+	if !statusFitsSchedule(flight.StatusCode, flight, now) {
+		t.Errorf("flight %s is %s at %s, departing %s and arriving %s",
+			flight.FlightNumber, flight.StatusCode, now.Format(time.RFC3339), flight.DepartureTime.Format(time.RFC3339), flight.ArrivalTime.Format(time.RFC3339))
+	}
+}
+
+func TestMockBoardsFollowSchedule(t *testing.T) {
+	flights := NewMockProvider().flightsAPI
+	for _, board := range []string{"departures", "arrivals"} {
+		t.Run(board, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				entries, err := flights.board("JFK", 24*time.Hour, board == "departures")
+				if err != nil {
+					t.Fatal(err)
+				}
+				now := time.Now()
+				for _, entry := range entries {
+					if entry.ScheduledTime.Before(now.Add(-time.Minute)) || entry.ScheduledTime.After(now.Add(24*time.Hour)) {
+						t.Errorf("%s at %s is outside the 24h window", entry.FlightNumber, entry.ScheduledTime)
+					}
+					if !entry.ScheduledTime.After(now) {
+						continue
+					}
+					// A departure yet to happen cannot be in the air or landed,
+					// nor an arrival yet to happen landed
+					switch {
+					case entry.Status == clients.StatusLanded,
+						board == "departures" && (entry.Status == clients.StatusEnRoute || entry.Status == clients.StatusDeparted || entry.Status == clients.StatusDiverted):
+						t.Errorf("%s %s at %s is %s", board, entry.FlightNumber, entry.ScheduledTime.Format(time.RFC3339), entry.Status)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1840 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-project/clients"
+	"github.com/your-project/clients/internal/dataset"
+)
+
+// ----- Data Models -----
+
+// Aircraft represents an aircraft with its properties
+type Aircraft struct {
+	ID           string    `json:"id"`
+	Type         string    `json:"type"`
+	Manufacturer string    `json:"manufacturer"`
+	Model        string    `json:"model"`
+	Registration string    `json:"registration"`
+	Location     GeoPoint  `json:"location"`
+	Altitude     int       `json:"altitude"`
+	Speed        int       `json:"speed"` // Ground speed in knots
+	Heading      int       `json:"heading"`
+	Status       string    `json:"status"`
+	LastUpdated  time.Time `json:"last_updated"`
+}
+
+// GeoPoint represents a geographical location
+type GeoPoint struct {
+	Latitude  float64 `json:"lat"`
+	Longitude float64 `json:"lng"`
+}
+
+// Flight represents a scheduled flight
+type Flight struct {
+	FlightNumber string    `json:"flight_number"`
+	Airline      string    `json:"airline"`
+	Origin       string    `json:"origin"`
+	Destination  string    `json:"destination"`
+	DepartureTime time.Time `json:"departure_time"`
+	ArrivalTime  time.Time `json:"arrival_time"`
+	Status       string    `json:"status"`
+	StatusCode   clients.FlightStatus `json:"status_code"`
+	Aircraft     string    `json:"aircraft_id"`
+	Distance     int       `json:"distance_km"`
+	Duration     int       `json:"duration_min"`
+	Gate         string    `json:"gate"`
+	Position     GeoPoint  `json:"position"`
+}
+
+// WeatherData represents weather conditions at a location
+type WeatherData struct {
+	Location       string    `json:"location"`
+	Temperature    float64   `json:"temperature_c"`
+	WindSpeed      float64   `json:"wind_speed_kph"`
+	WindDirection  int       `json:"wind_direction_deg"`
+	Conditions     string    `json:"conditions"`
+	Visibility     float64   `json:"visibility_km"`
+	Pressure       float64   `json:"pressure_hpa"`
+	Humidity       int       `json:"humidity_percent"`
+	Precipitation  float64   `json:"precipitation_mm"`
+	FlightCategory string    `json:"flight_category,omitempty"` // VFR, MVFR, IFR or LIFR
+	Updated        string    `json:"updated_at"`
+	ObservedAt     time.Time `json:"observed_at"`
+}
+
+// NewsArticle represents a single news article
+type NewsArticle struct {
+	Source      string `json:"source"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+	PublishedAt string `json:"published_at"`
+	Relevance   int    `json:"relevance"`
+}
+
+// NewsResponse represents a collection of news articles
+type NewsResponse struct {
+	Articles []NewsArticle `json:"articles"`
+	Count    int           `json:"count"`
+	Query    string        `json:"query"`
+}
+
+// AirspaceEvent is an airspace closure, restriction or advisory for a
+// country, extracted from a news article
+type AirspaceEvent struct {
+	CountryISO2   string                    `json:"country_iso2"`
+	FIR           string                    `json:"fir,omitempty"` // ICAO code of the country's flight information region, when it has just one
+	EventType     clients.AirspaceEventType `json:"event_type"`
+	Confidence    float64                   `json:"confidence"` // 0 to 1
+	SourceArticle NewsArticle               `json:"source_article"`
+}
+
+// GeopoliticalRisk represents risk assessment for a country
+type GeopoliticalRisk struct {
+	Country     string   `json:"country"`
+	RiskLevel   int      `json:"risk_level"` // 1-10 scale
+	Factors     []string `json:"risk_factors"`
+	Advisory    string   `json:"travel_advisory"`
+	AdvisorySource string `json:"advisory_source,omitempty"` // "static" for the built-in advisories
+	LastUpdated string   `json:"last_updated"`
+}
+
+// RouteRiskSegment is a stretch of a route over one country
+type RouteRiskSegment struct {
+	Country   string  `json:"country"`
+	StartKm   float64 `json:"start_km"`
+	EndKm     float64 `json:"end_km"`
+	RiskLevel int     `json:"risk_level"` // 1-10 scale
+	Flagged   bool    `json:"flagged,omitempty"`
+}
+
+// RouteRisk represents the risk of flying a great-circle route
+type RouteRisk struct {
+	Origin           string                       `json:"origin"`
+	Destination      string                       `json:"destination"`
+	DistanceKm       float64                      `json:"distance_km"`
+	MaxRisk          int                          `json:"max_risk"`     // 1-10 scale
+	AverageRisk      float64                      `json:"average_risk"` // Weighted by distance flown over each country
+	Segments         []RouteRiskSegment           `json:"segments"`
+	HighRiskSegments []RouteRiskSegment           `json:"high_risk_segments"`
+	Countries        map[string]*GeopoliticalRisk `json:"countries"`
+	Unassessed       []string                     `json:"unassessed_countries"`
+	FlaggedCountries []string                     `json:"flagged_countries"`
+	AirspaceEvents   []AirspaceEvent              `json:"airspace_events"`
+}
+
+// RiskAlert reports a country's risk level crossing a subscription's threshold
+type RiskAlert struct {
+	Country       string   `json:"country"`
+	Type          string   `json:"type"` // "raised" or "recovered"
+	Threshold     int      `json:"threshold"`
+	PreviousLevel int      `json:"previous_level"` // 1-10 scale, zero when Initial is set
+	NewLevel      int      `json:"new_level"`
+	Initial       bool     `json:"initial,omitempty"`
+	Factors       []string `json:"factors"`
+	Time          string   `json:"time"`
+}
+
+// SustainabilityData represents environmental impact data
+type SustainabilityData struct {
+	Route         string  `json:"route"`
+	Distance      int     `json:"distance_km"` // Great-circle distance scaled by the routing factor
+	GreatCircle   int     `json:"great_circle_km"`
+	CO2Emissions  float64 `json:"co2_emissions_kg"`
+	FuelEfficiency float64 `json:"fuel_efficiency_l_per_100km"`
+	AlternativeFuel bool   `json:"alternative_fuel_available"`
+	NoiseLevel    int     `json:"noise_level_db"`
+	EmissionsRating string `json:"emissions_rating"` // A, B, C, D, E
+	Offset        *OffsetEstimate `json:"offset,omitempty"`
+	FuelBlend     *FuelBlendAdjustment `json:"fuel_blend,omitempty"` // Set when CO2Emissions is adjusted for SAF
+	LastCalculated time.Time      `json:"last_calculated"`
+}
+
+// FuelBlendAdjustment represents how a sustainable aviation fuel blend
+// changed lifecycle CO2
+type FuelBlendAdjustment struct {
+	SAFPercent                float64 `json:"saf_percent"`
+	LifecycleReductionPercent float64 `json:"lifecycle_reduction_percent"`
+	UnadjustedCO2Kg           float64 `json:"unadjusted_co2_kg"`
+	AdjustedCO2Kg             float64 `json:"adjusted_co2_kg"`
+	ReductionKg               float64 `json:"reduction_kg"`
+}
+
+// OffsetEstimate represents the cost of offsetting a passenger's emissions
+type OffsetEstimate struct {
+	Currency         string  `json:"currency"`
+	PricePerTonne    float64 `json:"price_per_tonne"`
+	RadiativeForcing bool    `json:"radiative_forcing"`
+	Multiplier       float64 `json:"multiplier"` // Applied to CO2, 1 without radiative forcing
+	CO2eKg           float64 `json:"co2e_kg"`
+	Cost             float64 `json:"cost"`
+}
+
+// AircraftComparison represents one aircraft type's emissions over a distance
+type AircraftComparison struct {
+	Aircraft     string  `json:"aircraft"`
+	Name         string  `json:"name,omitempty"`
+	Rank         int     `json:"rank,omitempty"` // 1 for the lowest CO2 per seat-km, zero on error
+	Seats        int     `json:"seats"`
+	FuelKg       float64 `json:"fuel_kg"`
+	CO2Kg        float64 `json:"co2_kg"`
+	CO2PerSeatKm float64 `json:"co2_per_seat_km"` // Grams
+	DeltaPercent float64 `json:"delta_percent"`   // Versus the best type
+	Error        string  `json:"error,omitempty"`
+}
+
+// ComparisonReport represents aircraft types ranked by CO2 per seat-km
+type ComparisonReport struct {
+	DistanceKm float64              `json:"distance_km"`
+	Best       string               `json:"best"`
+	Aircraft   []AircraftComparison `json:"aircraft"` // By rank, failed types last
+	SAFPercent float64              `json:"saf_percent,omitempty"` // SAF share the CO2 figures are adjusted for
+}
+
+// PassengerEmissions represents the share of a flight's emissions attributed
+// to one passenger
+type PassengerEmissions struct {
+	Route             string  `json:"route"`
+	CabinClass        string  `json:"cabin_class"`
+	ClassMultiplier   float64 `json:"class_multiplier"` // Relative to economy
+	LoadFactor        float64 `json:"load_factor"`
+	DistanceKm        int     `json:"distance_km"`
+	CO2PerPassengerKg float64 `json:"co2_per_passenger_kg"`
+	FuelBlend         *FuelBlendAdjustment `json:"fuel_blend,omitempty"` // Per passenger, set for SAF blends
+}
+
+// FlightEmissionsEntry represents one flight's emissions in an emissions report
+type FlightEmissionsEntry struct {
+	Flight          string  `json:"flight"`
+	Airline         string  `json:"airline"`
+	Origin          string  `json:"origin"`
+	Destination     string  `json:"destination"`
+	Aircraft        string  `json:"aircraft,omitempty"`
+	AircraftAssumed bool    `json:"aircraft_assumed,omitempty"` // Unknown type, a typical narrow-body's burn was used
+	DistanceKm      float64 `json:"distance_km"`
+	FuelKg          float64 `json:"fuel_kg"`
+	CO2Kg           float64 `json:"co2_kg"`
+}
+
+// AirlineEmissions represents the emissions totals of one airline's flights
+type AirlineEmissions struct {
+	Airline    string  `json:"airline"`
+	Flights    int     `json:"flights"`
+	DistanceKm float64 `json:"distance_km"`
+	FuelKg     float64 `json:"fuel_kg"`
+	CO2Kg      float64 `json:"co2_kg"`
+}
+
+// SkippedFlight represents a flight left out of an emissions report
+type SkippedFlight struct {
+	Flight string `json:"flight"`
+	Reason string `json:"reason"`
+}
+
+// EmissionsReport represents the emissions of a list of flights
+type EmissionsReport struct {
+	Flights         int                    `json:"flights"` // Flights assessed
+	TotalDistanceKm float64                `json:"total_distance_km"`
+	TotalFuelKg     float64                `json:"total_fuel_kg"`
+	TotalCO2Kg      float64                `json:"total_co2_kg"`
+	ByAirline       []AirlineEmissions     `json:"by_airline"`   // Highest CO2 first
+	TopEmitters     []FlightEmissionsEntry `json:"top_emitters"` // The ten highest-emitting flights
+	PerFlight       []FlightEmissionsEntry `json:"per_flight"`
+	Skipped         []SkippedFlight        `json:"skipped"`
+}
+
+// NOTAM represents a notice to air missions for an airport or airspace
+type NOTAM struct {
+	ID            string    `json:"id"`
+	Number        string    `json:"number"`
+	FIR           string    `json:"fir"`
+	Location      string    `json:"location"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	EffectiveTo   time.Time `json:"effective_to"` // Zero for permanent NOTAMs
+	Text          string    `json:"text"`
+	QCode         string    `json:"qcode"`
+}
+
+// FleetSummary aggregates an airline's aircraft
+type FleetSummary struct {
+	Airline         string         `json:"airline"`
+	Total           int            `json:"total"`
+	Active          int            `json:"active"`
+	Stored          int            `json:"stored"`
+	OtherStatus     int            `json:"otherStatus"`
+	ByModel         map[string]int `json:"byModel"`
+	ByEngineType    map[string]int `json:"byEngineType"`
+	AverageAgeYears float64        `json:"averageAgeYears"`
+}
+
+// ----- API Clients -----
+
+// AircraftAPI client for aircraft data
+type AircraftAPI struct{}
+
+// NewAircraftAPI creates a new aircraft API client
+func NewAircraftAPI() *AircraftAPI {
+	return &AircraftAPI{}
+}
+
+// GetAircraft retrieves aircraft data
+func (api *AircraftAPI) GetAircraft(params map[string]string) ([]Aircraft, error) {
+	// Mock implementation
+	limit := 5
+	if val, ok := params["limit"]; ok {
+		fmt.Sscanf(val, "%d", &limit)
+	}
+
+	aircraft := []Aircraft{}
+	
+	types := []string{"Commercial", "Private", "Cargo", "Military"}
+	manufacturers := []string{"Boeing", "Airbus", "Embraer", "Bombardier"}
+	models := []string{"747-800", "A380", "E195", "Global 7500"}
+	statuses := []string{"In Flight", "Scheduled", "Delayed", "Landed"}
+	
+	for i := 0; i < limit; i++ {
+		now := time.Now()
+		aircraft = append(aircraft, Aircraft{
+			ID:           fmt.Sprintf("AC%04d", 1000+i),
+			Type:         types[rand.Intn(len(types))],
+			Manufacturer: manufacturers[rand.Intn(len(manufacturers))],
+			Model:        models[rand.Intn(len(models))],
+			Registration: fmt.Sprintf("N%d%c%c", 100+i, 'A'+rand.Intn(26), 'A'+rand.Intn(26)),
+			Location: GeoPoint{
+				Latitude:  (rand.Float64() * 170) - 85, // -85 to +85
+				Longitude: (rand.Float64() * 360) - 180, // -180 to +180
+			},
+			Altitude:    int(30000 + rand.Float64()*10000),
+			Speed:       int(400 + rand.Float64()*200),
+			Heading:     rand.Intn(360),
+			Status:      statuses[rand.Intn(len(statuses))],
+			LastUpdated: now.Add(time.Duration(-rand.Intn(60)) * time.Minute),
+		})
+	}
+
+	return aircraft, nil
+}
+
+// GetFleetSummary retrieves an aggregate view of an airline's fleet
+func (api *AircraftAPI) GetFleetSummary(airlineIATA string) (*FleetSummary, error) {
+	// Mock implementation
+	airline := strings.ToUpper(airlineIATA)
+	if len(airline) != 2 {
+		return nil, fmt.Errorf("invalid airline IATA code %q", airlineIATA)
+	}
+
+	models := []string{"A320", "A321", "B737-800", "B787-9", "A350-900"}
+	summary := &FleetSummary{
+		Airline:      airline,
+		Total:        20 + rand.Intn(180),
+		ByModel:      make(map[string]int),
+		ByEngineType: make(map[string]int),
+	}
+
+	ageTotal := 0.0
+	for i := 0; i < summary.Total; i++ {
+		model := models[rand.Intn(len(models))]
+		summary.ByModel[model]++
+		summary.ByEngineType["JET"]++
+		switch r := rand.Float64(); {
+		case r < 0.85:
+			summary.Active++
+		case r < 0.95:
+			summary.Stored++
+		default:
+			summary.OtherStatus++
+		}
+		ageTotal += rand.Float64() * 25
+	}
+	summary.AverageAgeYears = math.Round(ageTotal/float64(summary.Total)*10) / 10
+
+	return summary, nil
+}
+
+// FlightsAPI client for flight data
+type FlightsAPI struct{}
+
+// NewFlightsAPI creates a new flights API client
+func NewFlightsAPI() *FlightsAPI {
+	return &FlightsAPI{}
+}
+
+// GetFlights retrieves flight data. Flights fly between the comma-separated
+// IATA codes of the airports parameter when it names at least two.
+func (api *FlightsAPI) GetFlights(params map[string]string) ([]Flight, error) {
+	// Mock implementation
+	limit := 5
+	if val, ok := params["limit"]; ok {
+		fmt.Sscanf(val, "%d", &limit)
+	}
+	
+	flights := []Flight{}
+	
+	airlines := []string{"United", "Delta", "British Airways", "Lufthansa", "Emirates"}
+	origins := []string{"JFK", "LAX", "LHR", "CDG", "DXB"}
+	destinations := []string{"ORD", "SFO", "FRA", "AMS", "SIN"}
+	if airports := strings.Split(params["airports"], ","); len(airports) >= 2 {
+		origins, destinations = airports, airports
+	}
+	now := time.Now()
+	
+	for i := 0; i < limit; i++ {
+		airline := airlines[rand.Intn(len(airlines))]
+		origin := origins[rand.Intn(len(origins))]
+		destination := destinations[rand.Intn(len(destinations))]
+		
+		// Avoid same origin and destination
+		for destination == origin {
+			destination = destinations[rand.Intn(len(destinations))]
+		}
+		
+		flights = append(flights, mockFlight(i, airline, origin, destination, mockRouteDistance(origin, destination), now))
+	}
+	
+	return flights, nil
+}
+
+// GetFlightsByRoute retrieves the flights from one airport to another
+func (api *FlightsAPI) GetFlightsByRoute(originIATA, destIATA string) ([]Flight, error) {
+	// Mock implementation
+	origin, destination := strings.ToUpper(originIATA), strings.ToUpper(destIATA)
+	if len(origin) != 3 || len(destination) != 3 {
+		return nil, fmt.Errorf("invalid route %s-%s", originIATA, destIATA)
+	}
+
+	distance := mockRouteDistance(origin, destination)
+	airlines := []string{"United", "Delta", "British Airways", "Lufthansa", "Emirates"}
+	now := time.Now()
+	flights := []Flight{}
+	for i := 0; i < 1+rand.Intn(5); i++ {
+		flights = append(flights, mockFlight(i, airlines[rand.Intn(len(airlines))], origin, destination, distance, now))
+	}
+	return flights, nil
+}
+
+// GetFlightsForAirport retrieves the flights departing an airport, or
+// arriving at it when departures is false
+func (api *FlightsAPI) GetFlightsForAirport(airportIATA string, departures bool) ([]Flight, error) {
+	// Mock implementation
+	airport := strings.ToUpper(airportIATA)
+	if _, err := clients.NewEmbeddedAirportsAPI().GetAirportByIATA(airport); err != nil {
+		return nil, err
+	}
+
+	airlines := []string{"United", "Delta", "British Airways", "Lufthansa", "Emirates"}
+	others := []string{"JFK", "LAX", "LHR", "CDG", "DXB", "ORD", "SFO", "FRA", "AMS", "SIN"}
+	now := time.Now()
+	flights := []Flight{}
+	for i := 0; i < 1+rand.Intn(8); i++ {
+		other := others[rand.Intn(len(others))]
+		if other == airport {
+			continue
+		}
+		distance := mockRouteDistance(airport, other)
+		origin, destination := airport, other
+		if !departures {
+			origin, destination = other, airport
+		}
+		flights = append(flights, mockFlight(i, airlines[rand.Intn(len(airlines))], origin, destination, distance, now))
+	}
+	return flights, nil
+}
+
+// BoundingBox is an area between two latitudes and two longitudes. A box
+// whose MinLon is greater than its MaxLon crosses the antimeridian.
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// Validate checks the box lies within valid coordinates
+func (b BoundingBox) Validate() error {
+	for _, lat := range []float64{b.MinLat, b.MaxLat} {
+		if math.IsNaN(lat) || lat < -90 || lat > 90 {
+			return fmt.Errorf("latitude %v out of range [-90, 90]", lat)
+		}
+	}
+	for _, lon := range []float64{b.MinLon, b.MaxLon} {
+		if math.IsNaN(lon) || lon < -180 || lon > 180 {
+			return fmt.Errorf("longitude %v out of range [-180, 180]", lon)
+		}
+	}
+	if b.MinLat > b.MaxLat {
+		return fmt.Errorf("minimum latitude %v is above maximum latitude %v", b.MinLat, b.MaxLat)
+	}
+	return nil
+}
+
+// Contains reports whether a point lies inside the box, edges included
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// GetFlightsInBoundingBox retrieves the flights currently inside a box
+func (api *FlightsAPI) GetFlightsInBoundingBox(minLat, minLon, maxLat, maxLon float64) ([]Flight, error) {
+	// Mock implementation
+	box := BoundingBox{minLat, minLon, maxLat, maxLon}
+	if err := box.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid bounding box: %w", err)
+	}
+	return api.filterLiveFlights(func(flight Flight) bool {
+		return box.Contains(flight.Position.Latitude, flight.Position.Longitude)
+	})
+}
+
+// GetFlightsNearPoint retrieves the flights within radiusKM of a point
+func (api *FlightsAPI) GetFlightsNearPoint(lat, lon, radiusKM float64) ([]Flight, error) {
+	// Mock implementation
+	if err := (BoundingBox{lat, lon, lat, lon}).Validate(); err != nil {
+		return nil, fmt.Errorf("invalid point: %w", err)
+	}
+	if math.IsNaN(radiusKM) || math.IsInf(radiusKM, 0) || radiusKM <= 0 {
+		return nil, fmt.Errorf("invalid radius %v: must be a positive number of kilometres", radiusKM)
+	}
+	return api.filterLiveFlights(func(flight Flight) bool {
+		return clients.GreatCircleDistance(lat, lon, flight.Position.Latitude, flight.Position.Longitude) <= radiusKM
+	})
+}
+
+// filterLiveFlights generates a batch of mock flights and keeps those that match
+func (api *FlightsAPI) filterLiveFlights(match func(Flight) bool) ([]Flight, error) {
+	flights, err := api.GetFlights(map[string]string{"limit": "200"})
+	if err != nil {
+		return nil, err
+	}
+	matched := []Flight{}
+	for _, flight := range flights {
+		if match(flight) {
+			matched = append(matched, flight)
+		}
+	}
+	return matched, nil
+}
+
+// BoardEntry is one row of an airport departures or arrivals board
+type BoardEntry struct {
+	FlightNumber  string       `json:"flight_number"`
+	Airline       string       `json:"airline"`
+	Airport       string       `json:"airport"` // Destination on a departures board, origin on an arrivals board
+	ScheduledTime time.Time    `json:"scheduled_time"`
+	Status        clients.FlightStatus `json:"status"`
+	Terminal      string       `json:"terminal,omitempty"`
+	Gate          string       `json:"gate,omitempty"`
+}
+
+// GetDeparturesBoard retrieves the flights departing an airport within window
+func (api *FlightsAPI) GetDeparturesBoard(airportIATA string, window time.Duration) ([]BoardEntry, error) {
+	return api.board(airportIATA, window, true)
+}
+
+// GetArrivalsBoard retrieves the flights arriving at an airport within window
+func (api *FlightsAPI) GetArrivalsBoard(airportIATA string, window time.Duration) ([]BoardEntry, error) {
+	return api.board(airportIATA, window, false)
+}
+
+// board builds a random board for an airport
+func (api *FlightsAPI) board(airportIATA string, window time.Duration, departures bool) ([]BoardEntry, error) {
+	// Mock implementation
+	airport := strings.ToUpper(airportIATA)
+	if _, err := clients.NewEmbeddedAirportsAPI().GetAirportByIATA(airport); err != nil {
+		return nil, err
+	}
+	if window <= 0 || window > 7*24*time.Hour {
+		return nil, fmt.Errorf("invalid board window %v: must be positive and at most 168h", window)
+	}
+
+	airlines := []string{"United", "Delta", "British Airways", "Lufthansa", "Emirates"}
+	others := []string{"JFK", "LAX", "LHR", "CDG", "DXB", "ORD", "SFO", "FRA", "AMS", "SIN"}
+	now := time.Now()
+	entries := []BoardEntry{}
+	for i := 0; i < 5+rand.Intn(10); i++ {
+		other := others[rand.Intn(len(others))]
+		if other == airport {
+			continue
+		}
+		origin, destination := airport, other
+		if !departures {
+			origin, destination = other, airport
+		}
+		// The board's time is the departure or the arrival, within window
+		flight := mockFlight(i, airlines[rand.Intn(len(airlines))], origin, destination, 800+rand.Intn(8000), now)
+		scheduled := now.Add(time.Duration(rand.Int63n(int64(window)))).Truncate(time.Minute)
+		if departures {
+			flight = scheduleFlight(flight, scheduled, now)
+		} else {
+			flight = scheduleFlight(flight, scheduled.Add(-time.Duration(flight.Duration)*time.Minute), now)
+		}
+		entries = append(entries, BoardEntry{
+			FlightNumber:  flight.FlightNumber,
+			Airline:       flight.Airline,
+			Airport:       other,
+			ScheduledTime: scheduled,
+			Status:        flight.StatusCode,
+			Terminal:      fmt.Sprintf("%d", 1+rand.Intn(5)),
+			Gate:          flight.Gate,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ScheduledTime.Before(entries[j].ScheduledTime)
+	})
+	return entries, nil
+}
+
+// mockFlight builds a random flight on a route, departing between six hours
+// before now and eighteen after, with the status and position its schedule
+// gives at now
+func mockFlight(i int, airline, origin, destination string, distance int, now time.Time) Flight {
+	flightDuration := 120 + rand.Intn(600) // 2-10 hours in minutes
+	departureTime := now.Add(time.Duration(rand.Int63n(int64(24*time.Hour))) - 6*time.Hour).Truncate(time.Minute)
+
+	flight := Flight{
+		FlightNumber: fmt.Sprintf("%s%d", airline[:2], 1000+i),
+		Airline:      airline,
+		Origin:       origin,
+		Destination:  destination,
+		Aircraft:     fmt.Sprintf("AC%04d", 1000+rand.Intn(20)),
+		Distance:     distance,
+		Duration:     flightDuration,
+		Gate:         fmt.Sprintf("%c%d", 'A'+rand.Intn(6), 1+rand.Intn(20)),
+		Position:     mockPosition(origin, destination),
+	}
+	return scheduleFlight(flight, departureTime, now)
+}
+
+// scheduleFlight sets flight to depart at departure and arrive Duration
+// minutes later, with the status and position that schedule gives at now.
+// Some flights yet to board are delayed.
+func scheduleFlight(flight Flight, departure, now time.Time) Flight {
+	flight.DepartureTime = departure
+	flight.ArrivalTime = departure.Add(time.Duration(flight.Duration) * time.Minute)
+	flight = simulatedFlight(flight, now)
+	if flight.StatusCode == clients.StatusScheduled && rand.Intn(5) == 0 {
+		flight.Status, flight.StatusCode = "Delayed", clients.StatusDelayed
+	}
+	return flight
+}
+
+// statusFitsSchedule reports whether a flight scheduled as flight is could
+// have status at now: nothing lands before its arrival time or boards,
+// departs late or is cancelled after its departure
+func statusFitsSchedule(status clients.FlightStatus, flight Flight, now time.Time) bool {
+	departed := !now.Before(flight.DepartureTime)
+	arrived := !now.Before(flight.ArrivalTime)
+	switch status {
+	case clients.StatusScheduled, clients.StatusDelayed, clients.StatusCancelled:
+		return !departed
+	case clients.StatusBoarding:
+		return !departed && !now.Before(flight.DepartureTime.Add(-boardingWindow))
+	case clients.StatusDeparted, clients.StatusEnRoute:
+		return departed && !arrived
+	case clients.StatusLanded:
+		return arrived
+	case clients.StatusDiverted:
+		return departed
+	}
+	return true
+}
+
+// mockRouteDistance is the distance flown between two airports: the
+// great-circle distance scaled by the routing factor, as SustainabilityAPI
+// reports it, or a random distance when either airport is unknown
+func mockRouteDistance(origin, destination string) int {
+	airports := clients.NewEmbeddedAirportsAPI()
+	from, err1 := airports.GetAirportByIATA(origin)
+	to, err2 := airports.GetAirportByIATA(destination)
+	if err1 != nil || err2 != nil {
+		return 800 + rand.Intn(8000)
+	}
+	return int(math.Round(from.DistanceTo(*to) * defaultRoutingFactor))
+}
+
+// mockPosition places a flight somewhere between its airports, or anywhere
+// when either airport is unknown
+func mockPosition(origin, destination string) GeoPoint {
+	airports := clients.NewEmbeddedAirportsAPI()
+	from, err1 := airports.GetAirportByIATA(origin)
+	to, err2 := airports.GetAirportByIATA(destination)
+	if err1 != nil || err2 != nil {
+		return GeoPoint{
+			Latitude:  (rand.Float64() * 170) - 85,
+			Longitude: (rand.Float64() * 360) - 180,
+		}
+	}
+	progress := rand.Float64()
+	return GeoPoint{
+		Latitude:  from.Latitude + (to.Latitude-from.Latitude)*progress,
+		Longitude: from.Longitude + (to.Longitude-from.Longitude)*progress,
+	}
+}
+
+// WeatherAPI client for weather data. Weather is cached per airport so
+// repeated bridge requests see the same observation until it expires.
+type WeatherAPI struct {
+	mu     sync.Mutex
+	cache  map[string]*WeatherData
+	hits   uint64
+	misses uint64
+}
+
+// weatherCacheTTL is how long mock weather is reused
+const weatherCacheTTL = 10 * time.Minute
+
+// CacheStats reports weather cache effectiveness
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// NewWeatherAPI creates a new weather API client
+func NewWeatherAPI() *WeatherAPI {
+	return &WeatherAPI{cache: make(map[string]*WeatherData)}
+}
+
+// CacheStats returns weather cache hit and miss counters
+func (api *WeatherAPI) CacheStats() CacheStats {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return CacheStats{Hits: api.hits, Misses: api.misses, Entries: len(api.cache)}
+}
+
+// GetMultipleAirportsWeather retrieves weather data for multiple airports,
+// reporting per-airport failures separately
+func (api *WeatherAPI) GetMultipleAirportsWeather(airports []string) (map[string]*WeatherData, map[string]error, error) {
+	// Mock implementation
+	weatherMap := make(map[string]*WeatherData)
+	
+	conditions := []string{
+		"Clear", "Partly Cloudy", "Cloudy", "Light Rain", 
+		"Heavy Rain", "Thunderstorm", "Snow", "Fog",
+	}
+	
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for _, airport := range airports {
+		if cached, ok := api.cache[airport]; ok && time.Since(cached.ObservedAt) < weatherCacheTTL {
+			api.hits++
+			copied := *cached
+			weatherMap[airport] = &copied
+			continue
+		}
+		api.misses++
+		now := time.Now()
+		weatherMap[airport] = &WeatherData{
+			Location:      airport,
+			Temperature:   (rand.Float64() * 50) - 10, // -10C to 40C
+			WindSpeed:     rand.Float64() * 60,        // 0-60 kph
+			WindDirection: rand.Intn(360),
+			Conditions:    conditions[rand.Intn(len(conditions))],
+			Visibility:    rand.Float64() * 10,        // 0-10 km
+			Pressure:      980 + rand.Float64()*50,    // 980-1030 hPa
+			Humidity:      rand.Intn(100),
+			Precipitation: rand.Float64() * 20,        // 0-20 mm
+			Updated:       now.Format(time.RFC3339),
+			ObservedAt:    now.UTC(),
+		}
+		weatherMap[airport].FlightCategory = MockFlightCategory(weatherMap[airport].Visibility)
+		copied := *weatherMap[airport]
+		api.cache[airport] = &copied
+	}
+	
+	return weatherMap, map[string]error{}, nil
+}
+
+// SuitabilityCriteria are the weather limits for flight operations. A zero
+// field is not checked.
+type SuitabilityCriteria struct {
+	MaxWindKt            float64  // Sustained wind
+	MinVisibilityMiles   float64  // Statute miles
+	DisallowedConditions []string // Conditions such as Thunderstorm
+}
+
+// DefaultSuitabilityCriteria are the limits IsWeatherSuitableForFlight uses
+var DefaultSuitabilityCriteria = SuitabilityCriteria{
+	MaxWindKt:            35,
+	MinVisibilityMiles:   3,
+	DisallowedConditions: []string{"Thunderstorm"},
+}
+
+// CriterionFailure is a criterion the weather did not meet
+type CriterionFailure struct {
+	Criterion string `json:"criterion"` // wind, visibility or conditions
+	Measured  string `json:"measured"`
+	Threshold string `json:"threshold"`
+}
+
+func (c CriterionFailure) String() string {
+	return fmt.Sprintf("%s %s (limit %s)", c.Criterion, c.Measured, c.Threshold)
+}
+
+// SuitabilityResult reports whether the weather at an airport meets a set
+// of criteria
+type SuitabilityResult struct {
+	Airport  string             `json:"airport"`
+	Suitable bool               `json:"suitable"`
+	Failures []CriterionFailure `json:"failures"`
+	Weather  *WeatherData       `json:"weather"`
+}
+
+// Summary describes the result in one sentence
+func (r *SuitabilityResult) Summary() string {
+	if r.Suitable {
+		return "Weather conditions suitable for flight"
+	}
+	reasons := make([]string, len(r.Failures))
+	for i, failure := range r.Failures {
+		reasons[i] = failure.String()
+	}
+	return "Unsuitable due to: " + strings.Join(reasons, "; ")
+}
+
+// IsWeatherSuitableForFlight checks the weather at an airport against
+// DefaultSuitabilityCriteria
+func (api *WeatherAPI) IsWeatherSuitableForFlight(airportIATA string) (*SuitabilityResult, error) {
+	airport := strings.ToUpper(airportIATA)
+	weather, failures, err := api.GetMultipleAirportsWeather([]string{airport})
+	if err != nil {
+		return nil, err
+	}
+	if err := failures[airport]; err != nil {
+		return nil, err
+	}
+	return CheckSuitability(weather[airport], DefaultSuitabilityCriteria), nil
+}
+
+// CheckSuitability evaluates a weather report against c
+func CheckSuitability(weather *WeatherData, c SuitabilityCriteria) *SuitabilityResult {
+	result := &SuitabilityResult{Airport: weather.Location, Failures: []CriterionFailure{}, Weather: weather}
+	if windKt := weather.WindSpeed / 1.852; c.MaxWindKt > 0 && windKt > c.MaxWindKt {
+		result.Failures = append(result.Failures, CriterionFailure{"wind", fmt.Sprintf("%.0f kt", windKt), fmt.Sprintf("%.0f kt", c.MaxWindKt)})
+	}
+	if miles := weather.Visibility / 1.609344; c.MinVisibilityMiles > 0 && miles < c.MinVisibilityMiles {
+		result.Failures = append(result.Failures, CriterionFailure{"visibility", fmt.Sprintf("%.1f mi", miles), fmt.Sprintf("%.1f mi", c.MinVisibilityMiles)})
+	}
+	for _, conditions := range c.DisallowedConditions {
+		if strings.EqualFold(weather.Conditions, conditions) {
+			result.Failures = append(result.Failures, CriterionFailure{"conditions", weather.Conditions, "not " + strings.Join(c.DisallowedConditions, "/")})
+			break
+		}
+	}
+	result.Suitable = len(result.Failures) == 0
+	return result
+}
+
+// RouteWeatherPoint is the weather at a point along a route, taken from the
+// nearest weather station
+type RouteWeatherPoint struct {
+	Lat               float64      `json:"lat"`
+	Lon               float64      `json:"lon"`
+	NearestStation    string       `json:"nearest_station"`
+	StationDistanceKm float64      `json:"station_distance_km"`
+	Weather           *WeatherData `json:"weather"`
+}
+
+// GetRouteWeather retrieves the weather at the origin, at samples evenly
+// spaced great-circle waypoints and at the destination of a route
+func (api *WeatherAPI) GetRouteWeather(originIATA, destIATA string, samples int) ([]RouteWeatherPoint, error) {
+	if samples < 0 || samples > 50 {
+		return nil, fmt.Errorf("invalid sample count %d: must be between 0 and 50", samples)
+	}
+	airports := clients.NewEmbeddedAirportsAPI()
+	origin, err := airports.GetAirportByIATA(originIATA)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := airports.GetAirportByIATA(destIATA)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]RouteWeatherPoint, 0, samples+2)
+	var stations []string
+	known := clients.EmbeddedAirports()
+	for i := 0; i <= samples+1; i++ {
+		lat, lon := clients.GreatCirclePoint(origin.Latitude, origin.Longitude, dest.Latitude, dest.Longitude, float64(i)/float64(samples+1))
+		var nearest clients.Airport
+		best := math.Inf(1)
+		for _, airport := range known {
+			if d := clients.GreatCircleDistance(lat, lon, airport.Latitude, airport.Longitude); d < best {
+				nearest, best = airport, d
+			}
+		}
+		points = append(points, RouteWeatherPoint{
+			Lat:               math.Round(lat*1e4) / 1e4,
+			Lon:               math.Round(lon*1e4) / 1e4,
+			NearestStation:    nearest.ICAO,
+			StationDistanceKm: math.Round(best*10) / 10,
+		})
+		stations = append(stations, nearest.IATA)
+	}
+
+	weather, _, err := api.GetMultipleAirportsWeather(stations)
+	if err != nil {
+		return nil, err
+	}
+	for i := range points {
+		points[i].Weather = weather[stations[i]]
+	}
+	return points, nil
+}
+
+// routeSegment is a stretch of a route over one country, or over no country
+// when country is empty
+type routeSegment struct {
+	country        string
+	startKm, endKm float64
+}
+
+// routeSegments splits the great-circle route between two airports into the
+// stretches over each country. Points about 200 km apart are attributed to
+// the country of the nearest airport, or to none when it is over 300 km away.
+func routeSegments(origin, dest *clients.Airport) []routeSegment {
+	distance := origin.DistanceTo(*dest)
+	samples := min(max(int(math.Ceil(distance/200)), 1), 100)
+	step := distance / float64(samples)
+
+	var segments []routeSegment
+	known := clients.EmbeddedAirports()
+	for i := 0; i <= samples; i++ {
+		country := origin.Country
+		if i == samples {
+			country = dest.Country
+		} else if i > 0 {
+			lat, lon := clients.GreatCirclePoint(origin.Latitude, origin.Longitude, dest.Latitude, dest.Longitude, float64(i)/float64(samples))
+			var nearest clients.Airport
+			best := math.Inf(1)
+			for _, airport := range known {
+				if d := clients.GreatCircleDistance(lat, lon, airport.Latitude, airport.Longitude); d < best {
+					nearest, best = airport, d
+				}
+			}
+			country = ""
+			if best <= 300 {
+				country = nearest.Country
+			}
+		}
+
+		start := max((float64(i)-0.5)*step, 0)
+		end := min((float64(i)+0.5)*step, distance)
+		if n := len(segments); n > 0 && segments[n-1].country == country {
+			segments[n-1].endKm = end
+			continue
+		}
+		segments = append(segments, routeSegment{country: country, startKm: start, endKm: end})
+	}
+	return segments
+}
+
+// NewsAPI client for news data
+type NewsAPI struct{}
+
+// NewNewsAPI creates a new news API client
+func NewNewsAPI() *NewsAPI {
+	return &NewsAPI{}
+}
+
+// mockArticlesByTopic are the mock headlines for topics with known airspace
+// events
+var mockArticlesByTopic = map[string][]string{
+	"Iran": {
+		"Iran restricts airspace access in northern region",
+		"Airlines advised to avoid Iranian airspace amid tensions",
+		"New diplomatic efforts to ease tensions in Iranian airspace",
+	},
+	"Russia": {
+		"Russia declares no-fly zone over parts of its western border",
+		"Commercial flights diverted around Russian military exercises",
+		"Negotiations ongoing to reopen eastern Russian airspace",
+	},
+	"North Korea": {
+		"North Korea missile tests prompt airspace concerns",
+		"Airlines avoid North Korean airspace after recent activity",
+		"ICAO issues advisory for DPRK flight information region",
+	},
+}
+
+// mockTopicArticles creates mock articles for a topic
+func mockTopicArticles(topic string) []NewsArticle {
+	sources := []string{"Reuters", "BBC", "CNN", "Al Jazeera", "Aviation Weekly"}
+
+	articles := []NewsArticle{}
+	for _, title := range mockArticlesByTopic[topic] {
+		articles = append(articles, NewsArticle{
+			Source:      sources[rand.Intn(len(sources))],
+			Title:       title,
+			Description: fmt.Sprintf("Details about %s and its impact on international aviation.", title),
+			URL:         fmt.Sprintf("https://example.com/news/%d", rand.Intn(1000)),
+			PublishedAt: time.Now().Add(-time.Duration(rand.Intn(72)) * time.Hour).Format(time.RFC3339),
+			Relevance:   rand.Intn(5) + 6, // 6-10 scale
+		})
+	}
+	return articles
+}
+
+// GetGeopoliticalNews retrieves geopolitical news related to specified topics
+func (api *NewsAPI) GetGeopoliticalNews(topics []string) (*NewsResponse, error) {
+	// Mock implementation
+	articles := []NewsArticle{}
+	for _, topic := range topics {
+		articles = append(articles, mockTopicArticles(topic)...)
+	}
+
+	return &NewsResponse{
+		Articles: articles,
+		Count:    len(articles),
+		Query:    fmt.Sprintf("topics:%v", topics),
+	}, nil
+}
+
+// GetNewsForRoute retrieves airspace and security news for the origin's and
+// destination's countries and those overflown on the great-circle path,
+// keyed by ISO 3166-1 alpha-2 code
+func (api *NewsAPI) GetNewsForRoute(originIATA, destIATA string) (map[string]*NewsResponse, error) {
+	airports := clients.NewEmbeddedAirportsAPI()
+	origin, err := airports.GetAirportByIATA(originIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid origin: %w", err)
+	}
+	dest, err := airports.GetAirportByIATA(destIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+
+	countries := []string{}
+	seen := map[string]bool{}
+	for _, segment := range routeSegments(origin, dest) {
+		if segment.country == "" || seen[segment.country] {
+			continue
+		}
+		seen[segment.country] = true
+		countries = append(countries, segment.country)
+	}
+	if len(countries) > 8 {
+		countries = append(countries[:7], dest.Country)
+	}
+
+	news := make(map[string]*NewsResponse, len(countries))
+	for _, iso2 := range countries {
+		name := countryName(iso2)
+		articles := mockTopicArticles(name)
+		if len(articles) == 0 {
+			articles = append(articles, NewsArticle{
+				Source:      "Aviation Weekly",
+				Title:       fmt.Sprintf("%s aviation authorities report normal operations", name),
+				Description: fmt.Sprintf("Flights over %s are operating as scheduled.", name),
+				URL:         fmt.Sprintf("https://example.com/news/%d", rand.Intn(1000)),
+				PublishedAt: time.Now().Add(-time.Duration(rand.Intn(72)) * time.Hour).Format(time.RFC3339),
+				Relevance:   rand.Intn(3) + 3, // 3-5 scale
+			})
+		}
+		news[iso2] = &NewsResponse{
+			Articles: articles,
+			Count:    len(articles),
+			Query:    fmt.Sprintf("country:%s", iso2),
+		}
+	}
+	return news, nil
+}
+
+// countryName returns the display name of a country from the embedded
+// country table, or the code itself when it is unknown
+func countryName(iso2 string) string {
+	if _, _, name, err := clients.NormalizeCountry(iso2); err == nil {
+		return name
+	}
+	return iso2
+}
+
+// airspaceEvents extracts the airspace events in news with
+// clients.ExtractAirspaceEvents, each citing its article as the bridge
+// serves it
+func airspaceEvents(news *NewsResponse) []AirspaceEvent {
+	if news == nil {
+		return nil
+	}
+
+	var events []AirspaceEvent
+	for _, article := range news.Articles {
+		// One article at a time, so its events are known to come from it
+		source := clients.NewsArticle{Title: article.Title, Description: article.Description, URL: article.URL, PublishedAt: article.PublishedAt}
+		source.Source.Name = article.Source
+		for _, event := range clients.ExtractAirspaceEvents(&clients.NewsResponse{Articles: []clients.NewsArticle{source}}) {
+			events = append(events, AirspaceEvent{
+				CountryISO2:   event.CountryISO2,
+				FIR:           event.FIR,
+				EventType:     event.EventType,
+				Confidence:    event.Confidence,
+				SourceArticle: article,
+			})
+		}
+	}
+	return events
+}
+
+// moreSevere reports whether a should replace b as an article's event for a
+// country
+func moreSevere(a, b AirspaceEvent) bool {
+	rank := map[clients.AirspaceEventType]int{clients.AirspaceAdvisory: 1, clients.AirspaceRestriction: 2, clients.AirspaceClosure: 3}
+	if rank[a.EventType] != rank[b.EventType] {
+		return rank[a.EventType] > rank[b.EventType]
+	}
+	return a.Confidence > b.Confidence
+}
+
+// defaultRiskRefreshInterval is how often subscribed countries' risk is
+// re-evaluated
+const defaultRiskRefreshInterval = time.Hour
+
+// GeopoliticalAPI client for geopolitical risk data
+type GeopoliticalAPI struct {
+	mu              sync.Mutex
+	refreshInterval time.Duration
+}
+
+// NewGeopoliticalAPI creates a new geopolitical API client
+func NewGeopoliticalAPI() *GeopoliticalAPI {
+	return &GeopoliticalAPI{refreshInterval: defaultRiskRefreshInterval}
+}
+
+// SetRiskRefreshInterval sets how often subscribed countries' risk is
+// re-evaluated
+func (api *GeopoliticalAPI) SetRiskRefreshInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultRiskRefreshInterval
+	}
+	api.mu.Lock()
+	api.refreshInterval = interval
+	api.mu.Unlock()
+}
+
+// SubscribeRiskAlerts calls cb from a background goroutine whenever the risk
+// level of one of countries rises to threshold or above, and again when it
+// falls back below. Countries are evaluated at once and then every refresh
+// interval. The returned function cancels the subscription.
+func (api *GeopoliticalAPI) SubscribeRiskAlerts(countries []string, threshold int, cb func(RiskAlert)) (func(), error) {
+	if cb == nil {
+		return nil, errors.New("risk alert callback is nil")
+	}
+	if threshold < 1 || threshold > 10 {
+		return nil, fmt.Errorf("invalid risk threshold %d: must be between 1 and 10", threshold)
+	}
+	if len(countries) == 0 {
+		return nil, errors.New("no countries to watch")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		last := make(map[string]*GeopoliticalRisk)
+		for {
+			for _, country := range countries {
+				risk, err := api.GetCountryRisk(country)
+				if err != nil {
+					continue
+				}
+				if alert, ok := riskAlert(country, threshold, last[country], risk); ok {
+					select {
+					case <-done:
+						return
+					default:
+						cb(alert)
+					}
+				}
+				last[country] = risk
+			}
+
+			api.mu.Lock()
+			interval := api.refreshInterval
+			api.mu.Unlock()
+			timer := time.NewTimer(interval)
+			select {
+			case <-timer.C:
+			case <-done:
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+// riskAlert returns the alert a country's new risk raises against threshold,
+// given its previous risk, nil on first evaluation
+func riskAlert(country string, threshold int, previous, risk *GeopoliticalRisk) (RiskAlert, bool) {
+	above := risk.RiskLevel >= threshold
+	wasAbove := previous != nil && previous.RiskLevel >= threshold
+	if above == wasAbove {
+		return RiskAlert{}, false
+	}
+	alert := RiskAlert{
+		Country:   country,
+		Type:      "raised",
+		Threshold: threshold,
+		NewLevel:  risk.RiskLevel,
+		Initial:   previous == nil,
+		Factors:   risk.Factors,
+		Time:      time.Now().Format(time.RFC3339),
+	}
+	if previous != nil {
+		alert.PreviousLevel = previous.RiskLevel
+	}
+	if !above {
+		alert.Type = "recovered"
+		alert.Factors = previous.Factors
+	}
+	return alert, true
+}
+
+// ErrCountryNotFound is returned when there is no risk assessment for a
+// country
+var ErrCountryNotFound = errors.New("country not found")
+
+// GetCountryRisk retrieves risk assessment for a specific country
+func (api *GeopoliticalAPI) GetCountryRisk(countryCode string) (*GeopoliticalRisk, error) {
+	// Mock implementation
+	riskFactors := map[string][]string{
+		"US": {"Severe weather in some regions", "Occasional civil unrest"},
+		"UK": {"Transportation strikes", "Heightened security at airports"},
+		"DE": {"Border control issues", "Environmental protests"},
+		"FR": {"Labor strikes", "Occasional protests"},
+		"RU": {"Military activities", "Airspace restrictions", "Sanctions impact"},
+		"CN": {"Airspace congestion", "Regional tensions", "Strict overflight regulations"},
+		"IR": {"Military activity", "Political tensions", "International sanctions"},
+	}
+	
+	riskLevels := map[string]int{
+		"US": 2, "UK": 2, "DE": 2, "FR": 3, 
+		"RU": 7, "CN": 5, "IR": 8,
+	}
+	
+	advisories := map[string]string{
+		"US": "Exercise normal precautions",
+		"UK": "Exercise normal precautions",
+		"DE": "Exercise normal precautions",
+		"FR": "Exercise increased caution",
+		"RU": "Reconsider travel",
+		"CN": "Exercise increased caution",
+		"IR": "Do not travel",
+	}
+	
+	if factors, ok := riskFactors[countryCode]; ok {
+		return &GeopoliticalRisk{
+			Country:     countryCode,
+			RiskLevel:   riskLevels[countryCode],
+			Factors:     factors,
+			Advisory:    advisories[countryCode],
+			AdvisorySource: "static",
+			LastUpdated: time.Now().Format(time.RFC3339),
+		}, nil
+	}
+	
+	return nil, fmt.Errorf("%s: %w", countryCode, ErrCountryNotFound)
+}
+
+// GetRouteRisk retrieves the risk of every country along the great-circle
+// route between two airports, combined into the route's maximum and
+// distance-weighted average risk
+func (api *GeopoliticalAPI) GetRouteRisk(originIATA, destIATA string) (*RouteRisk, error) {
+	airports := clients.NewEmbeddedAirportsAPI()
+	origin, err := airports.GetAirportByIATA(originIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid origin: %w", err)
+	}
+	dest, err := airports.GetAirportByIATA(destIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+	segments := routeSegments(origin, dest)
+
+	route := &RouteRisk{
+		Origin:           origin.IATA,
+		Destination:      dest.IATA,
+		DistanceKm:       math.Round(origin.DistanceTo(*dest)*10) / 10,
+		Segments:         []RouteRiskSegment{},
+		HighRiskSegments: []RouteRiskSegment{},
+		Countries:        make(map[string]*GeopoliticalRisk),
+		Unassessed:       []string{},
+		FlaggedCountries: []string{},
+		AirspaceEvents:   []AirspaceEvent{},
+	}
+
+	// Closures and restrictions reported in the news flag a country
+	flagged := make(map[string]bool)
+	onRoute := make(map[string]bool)
+	for _, segment := range segments {
+		onRoute[segment.country] = true
+	}
+	if news, err := NewNewsAPI().GetNewsForRoute(originIATA, destIATA); err == nil {
+		for _, event := range airspaceEvents(combineRouteNews(news)) {
+			if !onRoute[event.CountryISO2] {
+				continue
+			}
+			route.AirspaceEvents = append(route.AirspaceEvents, event)
+			if event.EventType != clients.AirspaceAdvisory {
+				flagged[event.CountryISO2] = true
+			}
+		}
+		route.FlaggedCountries = airspaceEventCountries(route.AirspaceEvents)
+	}
+
+	var weighted, flown float64
+	unassessed := make(map[string]bool)
+	for _, segment := range segments {
+		if segment.country == "" {
+			continue
+		}
+		risk, ok := route.Countries[segment.country]
+		if !ok {
+			if risk, err = api.GetCountryRisk(segment.country); err != nil {
+				if !unassessed[segment.country] {
+					unassessed[segment.country] = true
+					route.Unassessed = append(route.Unassessed, segment.country)
+				}
+				continue
+			}
+			route.Countries[segment.country] = risk
+		}
+
+		s := RouteRiskSegment{
+			Country:   segment.country,
+			StartKm:   math.Round(segment.startKm*10) / 10,
+			EndKm:     math.Round(segment.endKm*10) / 10,
+			RiskLevel: risk.RiskLevel,
+			Flagged:   flagged[segment.country],
+		}
+		route.Segments = append(route.Segments, s)
+		if s.RiskLevel >= 7 || s.Flagged {
+			route.HighRiskSegments = append(route.HighRiskSegments, s)
+		}
+		route.MaxRisk = max(route.MaxRisk, risk.RiskLevel)
+		weighted += float64(risk.RiskLevel) * (segment.endKm - segment.startKm)
+		flown += segment.endKm - segment.startKm
+	}
+	if flown > 0 {
+		route.AverageRisk = math.Round(weighted/flown*100) / 100
+	}
+	return route, nil
+}
+
+// NOTAMAPI client for NOTAM data
+type NOTAMAPI struct{}
+
+// NewNOTAMAPI creates a new NOTAM API client
+func NewNOTAMAPI() *NOTAMAPI {
+	return &NOTAMAPI{}
+}
+
+// GetNOTAMsForAirports retrieves NOTAMs for the given ICAO locations
+func (api *NOTAMAPI) GetNOTAMsForAirports(locations []string) ([]NOTAM, error) {
+	// Mock implementation
+	notices := []struct {
+		qcode string
+		text  string
+	}{
+		{"QMRLC", "RWY %s CLSD FOR MAINT"},
+		{"QMXLC", "TWY %s CLSD"},
+		{"QOBCE", "OBST CRANE ERECTED %s NM FROM ARP 450FT AMSL LGT"},
+		{"QRTCA", "TEMPORARY RESTRICTED AREA %s NM RADIUS ACT SFC-FL180"},
+	}
+
+	notams := []NOTAM{}
+	now := time.Now().UTC()
+	for _, location := range locations {
+		count := 1 + rand.Intn(3)
+		for i := 0; i < count; i++ {
+			notice := notices[rand.Intn(len(notices))]
+			from := now.Add(-time.Duration(rand.Intn(48)) * time.Hour).Truncate(time.Minute)
+			notams = append(notams, NOTAM{
+				ID:            fmt.Sprintf("NOTAM_%s_%d", location, rand.Intn(100000)),
+				Number:        fmt.Sprintf("%02d/%03d", now.Month(), rand.Intn(1000)),
+				FIR:           location,
+				Location:      location,
+				EffectiveFrom: from,
+				EffectiveTo:   from.Add(time.Duration(24+rand.Intn(72)) * time.Hour),
+				Text:          fmt.Sprintf(notice.text, strconv.Itoa(rand.Intn(30)+1)),
+				QCode:         notice.qcode,
+			})
+		}
+	}
+
+	return notams, nil
+}
+
+// defaultRoutingFactor scales great-circle distances up to the distance
+// actually flown, since airways rarely allow direct tracks
+const defaultRoutingFactor = 1.07
+
+// SustainabilityAPI client for environmental impact data
+type SustainabilityAPI struct {
+	airports      *clients.AirportsAPI
+	routingFactor float64
+}
+
+// NewSustainabilityAPI creates a new sustainability API client
+func NewSustainabilityAPI() *SustainabilityAPI {
+	return &SustainabilityAPI{airports: clients.NewEmbeddedAirportsAPI(), routingFactor: defaultRoutingFactor}
+}
+
+// GetRouteEmissions retrieves emissions data for a specific route
+func (api *SustainabilityAPI) GetRouteEmissions(origin, destination string) (*SustainabilityData, error) {
+	// Mock implementation
+	from, err := api.airports.GetAirportByIATA(origin)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.airports.GetAirportByIATA(destination)
+	if err != nil {
+		return nil, err
+	}
+	
+	route := fmt.Sprintf("%s-%s", from.IATA, to.IATA)
+	greatCircle := from.DistanceTo(*to)
+	distance := int(math.Round(greatCircle * api.routingFactor))
+	
+	// CO2 calculation: ~0.2 kg per passenger km (simplified)
+	co2 := float64(distance) * 0.2 * (0.9 + rand.Float64()*0.2) // +/- 10% variation
+	
+	ratings := []string{"A", "B", "C", "D", "E"}
+	
+	return &SustainabilityData{
+		Route:           route,
+		Distance:        distance,
+		GreatCircle:     int(math.Round(greatCircle)),
+		CO2Emissions:    co2,
+		FuelEfficiency:  3.5 + rand.Float64()*2, // 3.5-5.5 liters per 100km per passenger
+		AlternativeFuel: rand.Float64() < 0.3,    // 30% chance of alternative fuel
+		NoiseLevel:      70 + rand.Intn(20),      // 70-90 dB
+		EmissionsRating: ratings[rand.Intn(len(ratings))],
+		LastCalculated:  time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+// aircraftTypesCSV is the embedded aircraft type table
+var aircraftTypesCSV = dataset.AircraftTypes
+
+// aircraftType is a row of the embedded aircraft type table
+type aircraftType struct {
+	name        string
+	seats       int
+	burnKgPerKm float64
+}
+
+var (
+	aircraftTypesOnce sync.Once
+	aircraftTypes     = make(map[string]aircraftType) // By ICAO type designator
+)
+
+// loadAircraftTypes parses the embedded aircraft type table on first use
+func loadAircraftTypes() map[string]aircraftType {
+	aircraftTypesOnce.Do(func() {
+		records, err := csv.NewReader(strings.NewReader(aircraftTypesCSV)).ReadAll()
+		if err != nil || len(records) == 0 {
+			return
+		}
+		for _, r := range records[1:] {
+			seats, _ := strconv.Atoi(r[2])
+			burn, _ := strconv.ParseFloat(r[4], 64)
+			aircraftTypes[r[0]] = aircraftType{name: r[1], seats: seats, burnKgPerKm: burn}
+		}
+	})
+	return aircraftTypes
+}
+
+// CompareAircraft ranks aircraft types by CO2 per seat-km over a distance
+// using the embedded burn-rate table. Unknown types are reported with an
+// error rather than failing the comparison.
+func (api *SustainabilityAPI) CompareAircraft(aircraft []string, distanceKM float64) (*ComparisonReport, error) {
+	if len(aircraft) == 0 {
+		return nil, errors.New("no aircraft to compare")
+	}
+	if !(distanceKM > 0) {
+		return nil, fmt.Errorf("invalid distance %v: must be above 0", distanceKM)
+	}
+
+	types := loadAircraftTypes()
+	var compared, failed []AircraftComparison
+	seen := make(map[string]bool)
+	for _, designator := range aircraft {
+		designator = strings.ToUpper(strings.TrimSpace(designator))
+		if designator == "" || seen[designator] {
+			continue
+		}
+		seen[designator] = true
+		t, ok := types[designator]
+		if !ok || t.seats <= 0 {
+			failed = append(failed, AircraftComparison{Aircraft: designator, Error: "unknown aircraft type"})
+			continue
+		}
+		fuel := t.burnKgPerKm * distanceKM
+		co2 := fuel * 3.16
+		compared = append(compared, AircraftComparison{
+			Aircraft:     designator,
+			Name:         t.name,
+			Seats:        t.seats,
+			FuelKg:       math.Round(fuel*10) / 10,
+			CO2Kg:        math.Round(co2*10) / 10,
+			CO2PerSeatKm: math.Round(co2/(float64(t.seats)*distanceKM)*1000*100) / 100,
+		})
+	}
+	if len(compared) == 0 {
+		return nil, errors.New("no aircraft type could be assessed")
+	}
+
+	sort.SliceStable(compared, func(i, j int) bool { return compared[i].CO2PerSeatKm < compared[j].CO2PerSeatKm })
+	for i := range compared {
+		compared[i].Rank = i + 1
+		compared[i].DeltaPercent = math.Round((compared[i].CO2PerSeatKm/compared[0].CO2PerSeatKm-1)*1000) / 10
+	}
+	return &ComparisonReport{
+		DistanceKm: distanceKM,
+		Best:       compared[0].Aircraft,
+		Aircraft:   append(compared, failed...),
+	}, nil
+}
+
+// defaultBurnKgPerKm is the fuel burn assumed for unknown aircraft types
+const defaultBurnKgPerKm = 3.0
+
+// GetFleetEmissionsReport totals the emissions of flights over their
+// great-circle distances scaled by the routing factor. Flights whose airports
+// are unknown are listed as skipped.
+func (api *SustainabilityAPI) GetFleetEmissionsReport(flights []Flight) (*EmissionsReport, error) {
+	report := &EmissionsReport{
+		ByAirline:   []AirlineEmissions{},
+		TopEmitters: []FlightEmissionsEntry{},
+		PerFlight:   []FlightEmissionsEntry{},
+		Skipped:     []SkippedFlight{},
+	}
+	types := loadAircraftTypes()
+	airlines := make(map[string]*AirlineEmissions)
+	var order []string
+	for _, f := range flights {
+		from, err := api.airports.GetAirportByIATA(f.Origin)
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedFlight{Flight: f.FlightNumber, Reason: fmt.Sprintf("invalid origin: %v", err)})
+			continue
+		}
+		to, err := api.airports.GetAirportByIATA(f.Destination)
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedFlight{Flight: f.FlightNumber, Reason: fmt.Sprintf("invalid destination: %v", err)})
+			continue
+		}
+
+		entry := FlightEmissionsEntry{
+			Flight:      f.FlightNumber,
+			Airline:     f.Airline,
+			Origin:      from.IATA,
+			Destination: to.IATA,
+		}
+		// Mock flights carry an aircraft ID rather than a type designator, so
+		// the type is usually unknown
+		burn := defaultBurnKgPerKm
+		if t, ok := types[strings.ToUpper(f.Aircraft)]; ok {
+			entry.Aircraft = strings.ToUpper(f.Aircraft)
+			burn = t.burnKgPerKm
+		} else {
+			entry.AircraftAssumed = true
+		}
+		distance := from.DistanceTo(*to) * api.routingFactor
+		entry.DistanceKm = math.Round(distance*10) / 10
+		entry.FuelKg = math.Round(burn*distance*10) / 10
+		entry.CO2Kg = math.Round(burn*distance*3.16*10) / 10
+		report.PerFlight = append(report.PerFlight, entry)
+
+		totals, ok := airlines[entry.Airline]
+		if !ok {
+			totals = &AirlineEmissions{Airline: entry.Airline}
+			airlines[entry.Airline] = totals
+			order = append(order, entry.Airline)
+		}
+		totals.Flights++
+		totals.DistanceKm += entry.DistanceKm
+		totals.FuelKg += entry.FuelKg
+		totals.CO2Kg += entry.CO2Kg
+		report.TotalDistanceKm += entry.DistanceKm
+		report.TotalFuelKg += entry.FuelKg
+		report.TotalCO2Kg += entry.CO2Kg
+	}
+	report.Flights = len(report.PerFlight)
+	report.TotalDistanceKm = math.Round(report.TotalDistanceKm*10) / 10
+	report.TotalFuelKg = math.Round(report.TotalFuelKg*10) / 10
+	report.TotalCO2Kg = math.Round(report.TotalCO2Kg*10) / 10
+
+	for _, airline := range order {
+		totals := airlines[airline]
+		totals.DistanceKm = math.Round(totals.DistanceKm*10) / 10
+		totals.FuelKg = math.Round(totals.FuelKg*10) / 10
+		totals.CO2Kg = math.Round(totals.CO2Kg*10) / 10
+		report.ByAirline = append(report.ByAirline, *totals)
+	}
+	sort.SliceStable(report.ByAirline, func(i, j int) bool { return report.ByAirline[i].CO2Kg > report.ByAirline[j].CO2Kg })
+
+	report.TopEmitters = append(report.TopEmitters, report.PerFlight...)
+	sort.SliceStable(report.TopEmitters, func(i, j int) bool { return report.TopEmitters[i].CO2Kg > report.TopEmitters[j].CO2Kg })
+	if len(report.TopEmitters) > 10 {
+		report.TopEmitters = report.TopEmitters[:10]
+	}
+	return report, nil
+}
+
+// defaultSAFLifecycleReduction is the lifecycle CO2 reduction of sustainable
+// aviation fuel over fossil jet fuel, in percent
+const defaultSAFLifecycleReduction = 80.0
+
+// ApplyFuelBlend returns co2Kg of lifecycle CO2 adjusted for a blend with
+// safPercent sustainable aviation fuel, and the adjustment made
+func (api *SustainabilityAPI) ApplyFuelBlend(co2Kg, safPercent float64) (float64, *FuelBlendAdjustment) {
+	adjusted := co2Kg * (1 - safPercent/100*defaultSAFLifecycleReduction/100)
+	return adjusted, &FuelBlendAdjustment{
+		SAFPercent:                safPercent,
+		LifecycleReductionPercent: defaultSAFLifecycleReduction,
+		UnadjustedCO2Kg:           math.Round(co2Kg*10) / 10,
+		AdjustedCO2Kg:             math.Round(adjusted*10) / 10,
+		ReductionKg:               math.Round((co2Kg-adjusted)*10) / 10,
+	}
+}
+
+// Offset pricing defaults
+const (
+	defaultCarbonPrice      = 25.0 // Per tonne of CO2
+	defaultCarbonCurrency   = "USD"
+	defaultRadiativeForcing = 1.9 // Multiplier for non-CO2 effects at altitude
+)
+
+// EstimateOffset estimates the cost of offsetting co2Kg at the default carbon
+// price, with the radiative forcing multiplier applied when radiativeForcing
+// is set
+func (api *SustainabilityAPI) EstimateOffset(co2Kg float64, radiativeForcing bool) *OffsetEstimate {
+	multiplier := 1.0
+	if radiativeForcing {
+		multiplier = defaultRadiativeForcing
+	}
+	co2e := co2Kg * multiplier
+	return &OffsetEstimate{
+		Currency:         defaultCarbonCurrency,
+		PricePerTonne:    defaultCarbonPrice,
+		RadiativeForcing: radiativeForcing,
+		Multiplier:       multiplier,
+		CO2eKg:           math.Round(co2e*10) / 10,
+		Cost:             math.Round(co2e/1000*defaultCarbonPrice*100) / 100,
+	}
+}
+
+// defaultLoadFactor is the share of seats assumed occupied
+const defaultLoadFactor = 0.82
+
+// ErrInvalidCabinClass is returned for unknown cabin classes
+var ErrInvalidCabinClass = errors.New("invalid cabin class")
+
+// cabinClassMultipliers weight each class's share of a flight's emissions
+// relative to economy
+var cabinClassMultipliers = map[string]float64{
+	"economy":         1,
+	"premium_economy": 1.5,
+	"business":        2,
+	"first":           4,
+}
+
+// GetPassengerEmissions retrieves one passenger's emissions for a route and
+// cabin class, spreading the flight's emissions over loadFactor of its seats
+func (api *SustainabilityAPI) GetPassengerEmissions(origin, destination, cabinClass string, loadFactor float64) (*PassengerEmissions, error) {
+	class := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(cabinClass)))
+	if class == "" {
+		class = "economy"
+	}
+	multiplier, ok := cabinClassMultipliers[class]
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must be economy, premium_economy, business or first", ErrInvalidCabinClass, cabinClass)
+	}
+	if loadFactor == 0 {
+		loadFactor = defaultLoadFactor
+	}
+	if !(loadFactor > 0 && loadFactor <= 1) {
+		return nil, fmt.Errorf("invalid load factor %v: must be above 0 and at most 1", loadFactor)
+	}
+
+	from, err := api.airports.GetAirportByIATA(origin)
+	if err != nil {
+		return nil, err
+	}
+	to, err := api.airports.GetAirportByIATA(destination)
+	if err != nil {
+		return nil, err
+	}
+	distance := int(math.Round(from.DistanceTo(*to) * api.routingFactor))
+
+	// ~0.2 kg CO2 per economy passenger km at the default load factor
+	co2 := float64(distance) * 0.2 * defaultLoadFactor / loadFactor * multiplier
+	return &PassengerEmissions{
+		Route:             fmt.Sprintf("%s-%s", from.IATA, to.IATA),
+		CabinClass:        class,
+		ClassMultiplier:   multiplier,
+		LoadFactor:        loadFactor,
+		DistanceKm:        distance,
+		CO2PerPassengerKg: math.Round(co2*10) / 10,
+	}, nil
+}
+
+// MockFlightCategory is the flight category for a visibility in km. The
+// mock weather has no cloud ceiling, so visibility alone decides it.
+func MockFlightCategory(visibilityKm float64) string {
+	miles := visibilityKm / 1.609344
+	switch {
+	case miles < 1:
+		return "LIFR"
+	case miles < 3:
+		return "IFR"
+	case miles <= 5:
+		return "MVFR"
+	default:
+		return "VFR"
+	}
+}
+
+// ValueRange is an interval values are drawn from uniformly. The zero range
+// leaves a value as it is.
+type ValueRange struct {
+	Min, Max float64
+}
+
+// set reports whether the range constrains anything
+func (r ValueRange) set() bool {
+	return r.Min != 0 || r.Max != 0
+}
+
+// draw returns a random value in the range
+func (r ValueRange) draw() float64 {
+	return r.Min + rand.Float64()*(r.Max-r.Min)
+}
+
+// WeatherProfile overrides the weather at a share of the airports
+type WeatherProfile struct {
+	Share           float64 // Of the airports, at least one when above 0
+	Conditions      []string
+	VisibilityKm    ValueRange
+	WindKph         ValueRange
+	PrecipitationMm ValueRange
+}
+
+// RiskProfile overrides the risk of every country
+type RiskProfile struct {
+	Levels   ValueRange // 1-10 scale
+	Advisory string
+}
+
+// NewsProfile adds articles to the geopolitical news, or replaces it
+type NewsProfile struct {
+	Replace  bool
+	Articles []NewsArticle
+}
+
+// MockScenario is a situation the mock flight environment is shaped toward,
+// selected with the scenario parameter. Nil profiles and an empty
+// FlightStatuses leave that part of the data random.
+type MockScenario struct {
+	Name           string
+	Description    string
+	Weather        *WeatherProfile
+	FlightStatuses []string // Every flight's status is drawn from these
+	Risk           *RiskProfile
+	News           *NewsProfile
+}
+
+// DefaultMockScenario is the scenario without a scenario parameter
+const DefaultMockScenario = "nominal"
+
+// MockScenarios are the scenarios the mock provider can produce, by name
+var MockScenarios = map[string]*MockScenario{
+	"nominal": {
+		Name:        "nominal",
+		Description: "Random weather, flights, risk and news",
+	},
+	"storm": {
+		Name:        "storm",
+		Description: "Low visibility, high winds and IFR or LIFR at most airports, with flights delayed",
+		Weather: &WeatherProfile{
+			Share:           0.6,
+			Conditions:      []string{"Thunderstorm", "Heavy Rain", "Fog"},
+			VisibilityKm:    ValueRange{0.2, 4.5},
+			WindKph:         ValueRange{65, 110},
+			PrecipitationMm: ValueRange{15, 60},
+		},
+		FlightStatuses: []string{"Delayed", "Delayed", "Delayed", "Cancelled", "Diverted", "On Time"},
+	},
+	"crisis": {
+		Name:        "crisis",
+		Description: "Airspace closures in the news, high risk everywhere and populated no-fly zones",
+		Risk: &RiskProfile{
+			Levels:   ValueRange{8, 10},
+			Advisory: "Do not travel",
+		},
+		News: &NewsProfile{Articles: []NewsArticle{
+			{Source: "Reuters", Title: "Ukraine closes its airspace to all civil flights", Description: "Ukraine has closed its airspace to civil aviation until further notice.", URL: "https://example.com/news/crisis-ukraine", Relevance: 10},
+			{Source: "BBC", Title: "Israel shuts airspace as conflict escalates", Description: "Flights to and over Israel are suspended while the airspace is closed.", URL: "https://example.com/news/crisis-israel", Relevance: 10},
+			{Source: "Aviation Weekly", Title: "Syria airspace closed after missile strikes", Description: "Airlines reroute around the closed airspace over Syria.", URL: "https://example.com/news/crisis-syria", Relevance: 9},
+		}},
+	},
+	"quiet": {
+		Name:        "quiet",
+		Description: "Clear VFR weather and low risk everywhere, with no airspace news",
+		Weather: &WeatherProfile{
+			Share:           1,
+			Conditions:      []string{"Clear", "Partly Cloudy"},
+			VisibilityKm:    ValueRange{10, 15},
+			WindKph:         ValueRange{0, 15},
+			PrecipitationMm: ValueRange{0, 0.01},
+		},
+		FlightStatuses: []string{"On Time", "Boarding", "In Air", "Landed"},
+		Risk: &RiskProfile{
+			Levels:   ValueRange{1, 2},
+			Advisory: "Exercise normal precautions",
+		},
+		News: &NewsProfile{Replace: true},
+	},
+}
+
+// ErrUnknownScenario is returned for a scenario name not in MockScenarios
+var ErrUnknownScenario = errors.New("unknown scenario")
+
+// LookupMockScenario returns the scenario named name, the default one when
+// name is empty
+func LookupMockScenario(name string) (*MockScenario, error) {
+	if name == "" {
+		name = DefaultMockScenario
+	}
+	scenario, ok := MockScenarios[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(MockScenarios))
+		for known := range MockScenarios {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w %q: must be one of %s", ErrUnknownScenario, name, strings.Join(names, ", "))
+	}
+	return scenario, nil
+}
+
+func init() {
+	// Seed the random number generator
+	rand.Seed(time.Now().UnixNano())
+}
+
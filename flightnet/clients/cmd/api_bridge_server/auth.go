@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// authExemptPaths can be called without an API key
+var authExemptPaths = map[string]bool{
+	"/health": true,
+}
+
+// APIKey is a key allowed to call the bridge
+type APIKey struct {
+	Label string // Names the key's holder in access logs
+	// RequestsPerMinute limits the key's requests, 0 for no limit
+	RequestsPerMinute int
+	hash              [sha256.Size]byte
+	limiter           *tokenBucket // Holds a minute's worth of requests
+}
+
+// parseAPIKey parses a key definition, label:key or label:key:requests-per-minute
+func parseAPIKey(definition string) (*APIKey, error) {
+	parts := strings.Split(strings.TrimSpace(definition), ":")
+	if len(parts) < 2 || len(parts) > 3 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return nil, fmt.Errorf("invalid API key definition: must be label:key or label:key:requests-per-minute")
+	}
+	key := &APIKey{Label: strings.TrimSpace(parts[0]), hash: sha256.Sum256([]byte(strings.TrimSpace(parts[1])))}
+	if len(parts) == 3 {
+		perMinute, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || perMinute < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q for API key %s: must be a number of requests per minute", parts[2], key.Label)
+		}
+		key.RequestsPerMinute = perMinute
+	}
+	if key.RequestsPerMinute > 0 {
+		key.limiter = newTokenBucket(float64(key.RequestsPerMinute)/60, key.RequestsPerMinute)
+	}
+	return key, nil
+}
+
+// loadAPIKeys loads the keys defined in the comma-separated list definitions
+// and in the file at path, one per line with # starting a comment. Either
+// may be empty. Labels must be unique.
+func loadAPIKeys(definitions, path string) ([]*APIKey, error) {
+	var lines []string
+	for _, definition := range strings.Split(definitions, ",") {
+		if strings.TrimSpace(definition) != "" {
+			lines = append(lines, definition)
+		}
+	}
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening API key file: %w", err)
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line, _, _ := strings.Cut(scanner.Text(), "#")
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading API key file: %w", err)
+		}
+	}
+
+	keys := make([]*APIKey, 0, len(lines))
+	labels := make(map[string]bool, len(lines))
+	for _, line := range lines {
+		key, err := parseAPIKey(line)
+		if err != nil {
+			return nil, err
+		}
+		if labels[key.Label] {
+			return nil, fmt.Errorf("duplicate API key label %q", key.Label)
+		}
+		labels[key.Label] = true
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// matchAPIKey returns the key whose value is presented. Every key is
+// compared in constant time, so the time taken reveals nothing about them.
+func matchAPIKey(keys []*APIKey, presented string) *APIKey {
+	hash := sha256.Sum256([]byte(presented))
+	var match *APIKey
+	for _, key := range keys {
+		if subtle.ConstantTimeCompare(hash[:], key.hash[:]) == 1 {
+			match = key
+		}
+	}
+	return match
+}
+
+// presentedAPIKey returns the key in the X-API-Key header, or else a bearer
+// token in the Authorization header
+func presentedAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+// apiKeyLabelKey is the context key of the label of the key a request used
+type apiKeyLabelKey struct{}
+
+// APIKeyLabel returns the label of the API key that authenticated ctx's
+// request, or "" when none did
+func APIKeyLabel(ctx context.Context) string {
+	label, _ := ctx.Value(apiKeyLabelKey{}).(string)
+	return label
+}
+
+// writeJSONError sends a JSON error body with status
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// apiKeyMiddleware requires requests to present one of keys, except for
+// authExemptPaths, and applies the key's rate limit. The key's label is
+// recorded for the access log. Without keys it lets every request through.
+func apiKeyMiddleware(keys []*APIKey) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if len(keys) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if authExemptPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			presented := presentedAPIKey(r)
+			if presented == "" {
+				logFor(r.Context()).Warn("rejected request", "reason", "missing API key")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api-bridge"`)
+				writeJSONError(w, http.StatusUnauthorized, "missing API key: send it in the X-API-Key header or as a bearer token")
+				return
+			}
+			key := matchAPIKey(keys, presented)
+			if key == nil {
+				logFor(r.Context()).Warn("rejected request", "reason", "invalid API key")
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api-bridge", error="invalid_token"`)
+				writeJSONError(w, http.StatusUnauthorized, "invalid API key")
+				return
+			}
+			if key.limiter != nil {
+				if ok, wait := key.limiter.allow(); !ok {
+					logFor(r.Context()).Warn("rate limited", "api_key", key.Label)
+					w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+					writeJSONError(w, http.StatusTooManyRequests, fmt.Sprintf("rate limit of %d requests per minute exceeded", key.RequestsPerMinute))
+					return
+				}
+			}
+
+			setRequestAPIKey(r.Context(), key.Label)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyLabelKey{}, key.Label)))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	keys, err := loadAPIKeys("ci:ci-secret, ops:ops-secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, h := newTestHandler(t, serverOptions{apiKeys: keys})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"X-API-Key valid", "X-API-Key", "ci-secret", http.StatusOK},
+		{"X-API-Key second key", "X-API-Key", "ops-secret", http.StatusOK},
+		{"X-API-Key wrong", "X-API-Key", "ci-secret-", http.StatusUnauthorized},
+		{"Bearer valid", "Authorization", "Bearer ops-secret", http.StatusOK},
+		{"Bearer lowercase scheme", "Authorization", "bearer ci-secret", http.StatusOK},
+		{"Bearer wrong", "Authorization", "Bearer nope", http.StatusUnauthorized},
+		{"Basic is not a bearer token", "Authorization", "Basic ci-secret", http.StatusUnauthorized},
+		{"missing", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/providers", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := serve(h, req)
+			if rec.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+			if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+
+	t.Run("health needs no key", func(t *testing.T) {
+		if rec := serve(h, httptest.NewRequest(http.MethodGet, "/health", nil)); rec.Code == http.StatusUnauthorized {
+			t.Errorf("/health answered 401")
+		}
+	})
+}
+
+func TestAPIKeyLabelAndRateLimit(t *testing.T) {
+	keys, err := loadAPIKeys("ci:ci-secret:2", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var label string
+	h := apiKeyMiddleware(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label = APIKeyLabel(r.Context())
+	}))
+
+	codes := make([]int, 3)
+	for i := range codes {
+		req := httptest.NewRequest(http.MethodGet, "/providers", nil)
+		req.Header.Set("X-API-Key", "ci-secret")
+		rec := serve(h, req)
+		codes[i] = rec.Code
+		if rec.Code == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+			t.Error("429 without Retry-After")
+		}
+	}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("statuses %v, want two 200s then 429 at 2 requests per minute", codes)
+	}
+	if label != "ci" {
+		t.Errorf("label %q, want ci", label)
+	}
+}
+
+func TestAdminRoutesNeedAPIKeys(t *testing.T) {
+	_, open := newTestHandler(t, serverOptions{})
+	if rec := serve(open, httptest.NewRequest(http.MethodPost, "/admin/reload-fixtures", nil)); rec.Code != http.StatusNotFound {
+		t.Errorf("without API keys /admin/reload-fixtures answered %d, want 404", rec.Code)
+	}
+
+	keys, err := loadAPIKeys("ops:ops-secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, guarded := newTestHandler(t, serverOptions{apiKeys: keys})
+	if rec := serve(guarded, httptest.NewRequest(http.MethodPost, "/admin/reload-fixtures", nil)); rec.Code != http.StatusUnauthorized {
+		t.Errorf("/admin/reload-fixtures without a key answered %d, want 401", rec.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/admin/reload-fixtures", nil)
+	req.Header.Set("X-API-Key", "ops-secret")
+	// The test server has no fixtures directory to reload
+	if rec := serve(guarded, req); rec.Code != http.StatusNotImplemented {
+		t.Errorf("/admin/reload-fixtures with a key answered %d, want 501: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"flightnet/models"
+	"github.com/your-project/clients"
+)
+
+// Export formats the backfill recognises
+const (
+	// backfillDump is the combined aircraft, flights and futureFlights dump
+	// cmd/test writes, of aviation-edge records
+	backfillDump = "aviation_edge_dump"
+	// backfillEnvironment is a flight environment response saved as it is
+	backfillEnvironment = "flight_environment"
+	// backfillExport is a stored snapshot with its entry, or an array of
+	// them
+	backfillExport = "snapshot_export"
+)
+
+// backfillProvider is the provider of snapshots from flight environment
+// responses, which do not record where they came from
+const backfillProvider = "backfill"
+
+// Outcomes of backfilling a file
+const (
+	BackfillImported = "imported" // Every snapshot in the file was imported or already stored
+	BackfillPartial  = "partial"  // Some records or sections were invalid and left out
+	BackfillSkipped  = "skipped"  // Every snapshot in the file was already stored
+	BackfillFailed   = "failed"   // Nothing in the file could be imported
+)
+
+// ErrUnknownExportFormat is returned for a file in none of the export formats
+var ErrUnknownExportFormat = errors.New("not a known export format")
+
+// dumpNameTimestamp matches the time a dump was taken at in its file name,
+// as in test-data-20240301T120000Z.json, for dumps that do not record it
+var dumpNameTimestamp = regexp.MustCompile(`[0-9]{8}T[0-9]{6}Z`)
+
+// BackfillFile reports what was imported from one file
+type BackfillFile struct {
+	Path     string   `json:"path"` // Relative to the backfilled directory
+	Format   string   `json:"format,omitempty"`
+	Status   string   `json:"status"`
+	Imported int      `json:"imported"` // Snapshots stored
+	Skipped  int      `json:"skipped"`  // Snapshots already stored, by content
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// BackfillReport summarises a backfill, by file
+type BackfillReport struct {
+	Imported  int            `json:"imported"` // Files with snapshots stored, including partial ones
+	Skipped   int            `json:"skipped"`  // Files already stored
+	Failed    int            `json:"failed"`
+	Snapshots int            `json:"snapshots"` // Snapshots stored
+	Files     []BackfillFile `json:"files"`
+}
+
+// backfillSnapshot is a snapshot decoded from an export, waiting to be stored
+type backfillSnapshot struct {
+	provider  string
+	params    map[string]string
+	timestamp time.Time
+	data      *FlightEnvironmentData
+}
+
+// backfillHistory imports the JSON exports under dir into store, keeping
+// their original timestamps. Snapshots whose data is already stored are
+// skipped, and invalid records are left out and reported with the rest of
+// their file imported. The error is only for failures of the store or of
+// walking dir; files that fail are reported.
+func backfillHistory(ctx context.Context, store HistoryStore, dir string) (*BackfillReport, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(path), ".json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading backfill directory: %w", err)
+	}
+	sort.Strings(paths)
+
+	stored, err := storedSnapshotHashes(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	// Distances come from the built-in airport list, since a backfill makes
+	// no upstream requests
+	airports := clients.NewEmbeddedAirportsAPI()
+
+	report := &BackfillReport{Files: []BackfillFile{}}
+	for _, path := range paths {
+		rel, _ := filepath.Rel(dir, path)
+		file := BackfillFile{Path: filepath.ToSlash(rel)}
+
+		snapshots, format, problems, err := readBackfillFile(path, airports)
+		file.Format = format
+		file.Errors = problems
+		if err != nil {
+			file.Errors = append(file.Errors, err.Error())
+		}
+		for _, snapshot := range snapshots {
+			snapshot.params["backfill_file"] = file.Path
+			imported, err := saveBackfillSnapshot(ctx, store, stored, snapshot)
+			if err != nil {
+				return nil, err
+			}
+			if imported {
+				file.Imported++
+			} else {
+				file.Skipped++
+			}
+		}
+
+		switch {
+		case file.Imported == 0 && file.Skipped == 0:
+			file.Status = BackfillFailed
+			report.Failed++
+		case file.Imported == 0:
+			file.Status = BackfillSkipped
+			report.Skipped++
+		case len(file.Errors) > 0:
+			file.Status = BackfillPartial
+			report.Imported++
+		default:
+			file.Status = BackfillImported
+			report.Imported++
+		}
+		report.Snapshots += file.Imported
+		report.Files = append(report.Files, file)
+	}
+	return report, nil
+}
+
+// storedSnapshotHashes returns the content hashes of the stored snapshots
+func storedSnapshotHashes(ctx context.Context, store HistoryStore) (map[string]bool, error) {
+	entries, err := store.List(ctx, HistoryQuery{})
+	if err != nil {
+		return nil, fmt.Errorf("listing stored snapshots: %w", err)
+	}
+	hashes := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		_, data, err := store.Get(ctx, entry.ID)
+		if errors.Is(err, ErrSnapshotNotFound) {
+			continue // Removed since it was listed
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading stored snapshot %s: %w", entry.ID, err)
+		}
+		hashes[snapshotHash(data)] = true
+	}
+	return hashes, nil
+}
+
+// snapshotHash identifies the JSON data of a snapshot by content
+func snapshotHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// saveBackfillSnapshot stores snapshot unless its data is in stored, adding
+// it there, and reports whether it was stored
+func saveBackfillSnapshot(ctx context.Context, store HistoryStore, stored map[string]bool, snapshot backfillSnapshot) (bool, error) {
+	data, err := json.Marshal(snapshot.data)
+	if err != nil {
+		return false, fmt.Errorf("encoding snapshot to JSON: %w", err)
+	}
+	hash := snapshotHash(data)
+	if stored[hash] {
+		return false, nil
+	}
+	entry := HistoryEntry{
+		ID:        newHistoryID(snapshot.timestamp),
+		Provider:  snapshot.provider,
+		Params:    snapshot.params,
+		Timestamp: snapshot.timestamp,
+		Size:      len(data),
+	}
+	if err := store.Save(ctx, entry, data); err != nil {
+		return false, fmt.Errorf("saving snapshot %s: %w", entry.ID, err)
+	}
+	stored[hash] = true
+	return true, nil
+}
+
+// readBackfillFile decodes the snapshots in an export file, detecting its
+// format, with flight distances between airports. problems lists the records
+// or sections left out; err is set when nothing could be read.
+func readBackfillFile(path string, airports *clients.AirportsAPI) (snapshots []backfillSnapshot, format string, problems []string, err error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	format, err = detectExportFormat(body)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	switch format {
+	case backfillDump:
+		snapshot, problems, err := decodeAviationEdgeDump(body, filepath.Base(path), airports)
+		if err != nil {
+			return nil, format, problems, err
+		}
+		return []backfillSnapshot{snapshot}, format, problems, nil
+	case backfillEnvironment:
+		snapshot, problems, err := decodeEnvironmentExport(body)
+		if err != nil {
+			return nil, format, problems, err
+		}
+		return []backfillSnapshot{snapshot}, format, problems, nil
+	default:
+		snapshots, problems, err := decodeSnapshotExports(body)
+		return snapshots, format, problems, err
+	}
+}
+
+// detectExportFormat tells the export formats apart by their top-level
+// fields: snapshot exports have data and timestamp, dumps only the lists in
+// models.DumpLists and a timestamp, and flight environments a timestamp
+// besides their sections
+func detectExportFormat(body []byte) (string, error) {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "[") {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(body, &items); err != nil {
+			return "", fmt.Errorf("decoding JSON: %w", err)
+		}
+		if len(items) > 0 && items[0]["data"] != nil && items[0]["timestamp"] != nil {
+			return backfillExport, nil
+		}
+		return "", ErrUnknownExportFormat
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return "", fmt.Errorf("decoding JSON: %w", err)
+	}
+	if fields["data"] != nil && fields["timestamp"] != nil {
+		return backfillExport, nil
+	}
+	dumpFields := 0
+	for _, name := range models.DumpLists {
+		if fields[name] != nil {
+			dumpFields++
+		}
+	}
+	otherFields := len(fields) - dumpFields
+	if fields["timestamp"] != nil {
+		otherFields--
+	}
+	switch {
+	case dumpFields > 0 && otherFields == 0:
+		return backfillDump, nil
+	case fields["timestamp"] != nil:
+		return backfillEnvironment, nil
+	}
+	return "", ErrUnknownExportFormat
+}
+
+// decodeAviationEdgeDump converts a dump of aviation-edge records through
+// the models package into a snapshot taken at the dump's timestamp, or when
+// it has none the time in its file name. A dump with neither fails, rather
+// than being stored at a time it was not taken. Invalid records are left out
+// and listed in problems.
+func decodeAviationEdgeDump(body []byte, name string, airports *clients.AirportsAPI) (backfillSnapshot, []string, error) {
+	dump, problems, err := models.DecodeDump(body)
+	if err != nil {
+		return backfillSnapshot{}, problems, err
+	}
+	timestamp := dump.Timestamp
+	if timestamp.IsZero() {
+		match := dumpNameTimestamp.FindString(name)
+		if match == "" {
+			return backfillSnapshot{}, problems, errors.New("no timestamp: the dump has no timestamp field and its name no time such as 20240301T120000Z")
+		}
+		timestamp, err = time.Parse("20060102T150405Z", match)
+		if err != nil {
+			return backfillSnapshot{}, problems, fmt.Errorf("time %s in the file name is not a time", match)
+		}
+	}
+
+	envData := newBackfillEnvironment(timestamp)
+	for _, a := range dump.Aircraft {
+		envData.Aircraft = append(envData.Aircraft, backfillAircraft(a, timestamp))
+	}
+	for _, f := range dump.Flights {
+		envData.Flights = append(envData.Flights, backfillFlight(f, airports))
+	}
+	return backfillSnapshot{
+		provider:  "live",
+		params:    map[string]string{},
+		timestamp: timestamp,
+		data:      envData,
+	}, problems, nil
+}
+
+// backfillAircraft converts a dumped aircraft, last seen when the dump was
+// taken
+func backfillAircraft(a models.Aircraft, timestamp time.Time) Aircraft {
+	id := a.Registration
+	if id == "" {
+		id = a.ID
+	}
+	return Aircraft{
+		ID:           id,
+		Type:         a.TypeCode,
+		Model:        a.Model,
+		Registration: a.Registration,
+		Status:       a.Status,
+		LastUpdated:  timestamp,
+	}
+}
+
+// backfillFlight converts a dumped flight, with the distance between its
+// airports when airports knows both
+func backfillFlight(f models.Flight, airports *clients.AirportsAPI) Flight {
+	flight := Flight{
+		FlightNumber:  f.Number,
+		Airline:       f.Airline,
+		Origin:        f.Origin,
+		Destination:   f.Destination,
+		DepartureTime: f.DepartureTime,
+		ArrivalTime:   f.ArrivalTime,
+		Status:        f.Status,
+		Aircraft:      f.Aircraft,
+	}
+	if !f.DepartureTime.IsZero() && f.ArrivalTime.After(f.DepartureTime) {
+		flight.Duration = int(f.ArrivalTime.Sub(f.DepartureTime).Minutes())
+	}
+	from, fromErr := airports.GetAirport(f.Origin)
+	to, toErr := airports.GetAirport(f.Destination)
+	if fromErr == nil && toErr == nil {
+		flight.Distance = int(math.Round(from.DistanceTo(*to)))
+	}
+	return flight
+}
+
+// newBackfillEnvironment returns an empty flight environment taken at t
+func newBackfillEnvironment(t time.Time) *FlightEnvironmentData {
+	return &FlightEnvironmentData{
+		Weather:        make(map[string]*WeatherData),
+		Geopolitical:   make(map[string]*GeopoliticalRisk),
+		Sustainability: make(map[string]*SustainabilityData),
+		Timestamp:      t.Format(time.RFC3339),
+	}
+}
+
+// decodeEnvironmentExport converts a saved flight environment response into
+// a snapshot taken at its timestamp. Sections that do not decode are left
+// out and listed in problems.
+func decodeEnvironmentExport(body []byte) (backfillSnapshot, []string, error) {
+	envData, problems, err := decodeEnvironmentSections(body)
+	if err != nil {
+		return backfillSnapshot{}, problems, err
+	}
+	timestamp, err := time.Parse(time.RFC3339, envData.Timestamp)
+	if err != nil {
+		return backfillSnapshot{}, problems, fmt.Errorf("timestamp %q is not an RFC 3339 time", envData.Timestamp)
+	}
+	return backfillSnapshot{
+		provider:  backfillProvider,
+		params:    map[string]string{},
+		timestamp: timestamp.UTC(),
+		data:      envData,
+	}, problems, nil
+}
+
+// decodeEnvironmentSections decodes a flight environment section by
+// section, leaving out and listing those that do not decode
+func decodeEnvironmentSections(body []byte) (*FlightEnvironmentData, []string, error) {
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(body, &sections); err != nil {
+		return nil, nil, fmt.Errorf("decoding JSON: %w", err)
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		var probe FlightEnvironmentData
+		single, _ := json.Marshal(map[string]json.RawMessage{name: sections[name]})
+		if err := json.Unmarshal(single, &probe); err != nil {
+			problems = append(problems, fmt.Sprintf("section %s: %v", name, err))
+			delete(sections, name)
+		}
+	}
+	valid, _ := json.Marshal(sections)
+	var envData FlightEnvironmentData
+	if err := json.Unmarshal(valid, &envData); err != nil {
+		return nil, problems, fmt.Errorf("decoding JSON: %w", err)
+	}
+	if envData.Weather == nil {
+		envData.Weather = make(map[string]*WeatherData)
+	}
+	if envData.Geopolitical == nil {
+		envData.Geopolitical = make(map[string]*GeopoliticalRisk)
+	}
+	if envData.Sustainability == nil {
+		envData.Sustainability = make(map[string]*SustainabilityData)
+	}
+	return &envData, problems, nil
+}
+
+// decodeSnapshotExports converts exported snapshots, one object or an array
+// of them, keeping their provider, parameters and timestamp. Snapshots that
+// do not decode are left out and listed in problems.
+func decodeSnapshotExports(body []byte) ([]backfillSnapshot, []string, error) {
+	var items []json.RawMessage
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, nil, fmt.Errorf("decoding JSON: %w", err)
+		}
+	} else {
+		items = []json.RawMessage{body}
+	}
+
+	var snapshots []backfillSnapshot
+	var problems []string
+	for i, item := range items {
+		var export HistorySnapshot
+		if err := json.Unmarshal(item, &export); err != nil {
+			problems = append(problems, fmt.Sprintf("snapshot %d: %v", i, err))
+			continue
+		}
+		if export.Timestamp.IsZero() || len(export.Data) == 0 {
+			problems = append(problems, fmt.Sprintf("snapshot %d: missing timestamp or data", i))
+			continue
+		}
+		envData, sectionProblems, err := decodeEnvironmentSections(export.Data)
+		for _, problem := range sectionProblems {
+			problems = append(problems, fmt.Sprintf("snapshot %d: %s", i, problem))
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("snapshot %d: %v", i, err))
+			continue
+		}
+		provider := export.Provider
+		if provider == "" {
+			provider = backfillProvider
+		}
+		params := export.Params
+		if params == nil {
+			params = map[string]string{}
+		}
+		snapshots = append(snapshots, backfillSnapshot{
+			provider:  provider,
+			params:    params,
+			timestamp: export.Timestamp.UTC(),
+			data:      envData,
+		})
+	}
+	if len(snapshots) == 0 {
+		return nil, problems, errors.New("no valid snapshots")
+	}
+	return snapshots, problems, nil
+}
+
+// runBackfill imports the exports in dir into the history store named by
+// spec, printing the report as JSON. It returns the exit status: 1 when the
+// backfill stopped or a file failed.
+func runBackfill(spec, dir string) int {
+	store, err := openHistoryStore(spec)
+	if err != nil {
+		slog.Error("failed to open snapshot history", "error", err)
+		return 1
+	}
+	defer store.Close()
+
+	report, err := backfillHistory(context.Background(), store, dir)
+	if err != nil {
+		slog.Error("backfill failed", "dir", dir, "error", err)
+		return 1
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		slog.Error("error encoding backfill report", "error", err)
+		return 1
+	}
+	slog.Info("backfill complete", "dir", dir, "imported", report.Imported, "skipped", report.Skipped,
+		"failed", report.Failed, "snapshots", report.Snapshots)
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const backfillTestDump = `{
+	"timestamp": "2024-03-01T09:00:00Z",
+	"aircraft": [
+		{"airplaneId": "1", "numberRegistration": "N123AA", "planeModel": "737"},
+		{"airplaneId": ""}
+	],
+	"flights": [
+		{
+			"departure": {"iataCode": "JFK", "scheduledTime": "2024-03-01T10:00:00"},
+			"arrival": {"iataCode": "LHR", "scheduledTime": "2024-03-01T22:00:00"},
+			"flight": {"iataNumber": "BA114"},
+			"status": "scheduled"
+		}
+	]
+}`
+
+// backfillTestUndatedDump is a dump written before dumps recorded when
+// they were taken
+const backfillTestUndatedDump = `{"aircraft": [{"airplaneId": "3", "numberRegistration": "G-XWBA"}], "flights": [], "futureFlights": []}`
+
+const backfillTestEnvironment = `{
+	"timestamp": "2024-03-01T12:00:00Z",
+	"aircraft": [],
+	"flights": [],
+	"weather": "not a map"
+}`
+
+const backfillTestExport = `[
+	{"id": "x", "provider": "sample", "params": {"route": "JFK-LAX"}, "timestamp": "2024-03-02T08:00:00Z", "data": {"timestamp": "2024-03-02T08:00:00Z", "no_fly_zones": ["Ukraine"]}},
+	{"id": "y", "provider": "sample"}
+]`
+
+func TestBackfillHistory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"dump.json":                           backfillTestDump,
+		"old/test-data-20240229T180000Z.json": backfillTestUndatedDump,
+		"old/test-data.json":                  backfillTestUndatedDump,
+		"sub/environment.json":                backfillTestEnvironment,
+		"export.json":                         backfillTestExport,
+		"unknown.json":                        `{"hello": 1}`,
+		"broken.json":                         `{"timestamp": `,
+		"notes.txt":                           "not an export",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store, err := newFileHistoryStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	report, err := backfillHistory(ctx, store, dir)
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	want := map[string]struct{ format, status string }{
+		"broken.json":                         {"", BackfillFailed},
+		"dump.json":                           {backfillDump, BackfillPartial},
+		"export.json":                         {backfillExport, BackfillPartial},
+		"old/test-data-20240229T180000Z.json": {backfillDump, BackfillImported},
+		"old/test-data.json":                  {backfillDump, BackfillFailed},
+		"sub/environment.json":                {backfillEnvironment, BackfillPartial},
+		"unknown.json":                        {"", BackfillFailed},
+	}
+	if len(report.Files) != len(want) {
+		t.Fatalf("reported %d files, want %d: %+v", len(report.Files), len(want), report.Files)
+	}
+	for _, file := range report.Files {
+		w, ok := want[file.Path]
+		if !ok {
+			t.Errorf("unexpected file %s in report", file.Path)
+			continue
+		}
+		if file.Format != w.format || file.Status != w.status {
+			t.Errorf("%s: format %q status %q, want %q %q (errors %v)", file.Path, file.Format, file.Status, w.format, w.status, file.Errors)
+		}
+	}
+	if report.Snapshots != 4 || report.Imported != 4 || report.Failed != 3 {
+		t.Errorf("report %+v, want 4 snapshots from 4 files and 3 failures", report)
+	}
+
+	entries, err := store.List(ctx, HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	times := make(map[time.Time]HistoryEntry)
+	for _, entry := range entries {
+		times[entry.Timestamp] = entry
+	}
+	for _, want := range []time.Time{
+		time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),   // The dump's own
+		time.Date(2024, 2, 29, 18, 0, 0, 0, time.UTC), // From the file name
+		time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),  // The environment's
+		time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC),   // The export's
+	} {
+		if _, ok := times[want]; !ok {
+			t.Errorf("no snapshot stored at %s, have %v", want, entries)
+		}
+	}
+	if entry := times[time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC)]; entry.Provider != "sample" || entry.Params["route"] != "JFK-LAX" {
+		t.Errorf("export stored as %+v, want its provider and parameters kept", entry)
+	}
+
+	dumped := times[time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)]
+	_, data, err := store.Get(ctx, dumped.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envData FlightEnvironmentData
+	if err := json.Unmarshal(data, &envData); err != nil {
+		t.Fatal(err)
+	}
+	if len(envData.Aircraft) != 1 || envData.Aircraft[0].Registration != "N123AA" {
+		t.Errorf("dumped aircraft %+v, want N123AA alone", envData.Aircraft)
+	}
+	if len(envData.Flights) != 1 || envData.Flights[0].FlightNumber != "BA114" || envData.Flights[0].Duration != 720 {
+		t.Fatalf("dumped flights %+v, want BA114 taking 720 minutes", envData.Flights)
+	}
+	if d := envData.Flights[0].Distance; d < 5500 || d > 5600 {
+		t.Errorf("BA114 covers %d km, want the 5,540 km from JFK to LHR", d)
+	}
+
+	report, err = backfillHistory(ctx, store, dir)
+	if err != nil {
+		t.Fatalf("second backfill: %v", err)
+	}
+	if report.Snapshots != 0 || report.Skipped != 4 {
+		t.Errorf("second backfill %+v, want every imported file skipped", report)
+	}
+}
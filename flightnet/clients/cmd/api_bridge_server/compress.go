@@ -0,0 +1,184 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gzipMinSize is the smallest response worth compressing; below it the
+// gzip framing outweighs the savings
+const gzipMinSize = 1024
+
+// uncompressedPaths serve streams, which compression would hold back or
+// which are hijacked for WebSockets
+var uncompressedPaths = map[string]bool{
+	"/flight-environment/stream": true,
+	"/flight-environment/events": true,
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(io.Discard) },
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// compressibleType reports whether a Content-Type is text that compresses
+// well
+func compressibleType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		mediaType == "application/javascript" || mediaType == "application/xml"
+}
+
+// gzipResponseWriter holds back the start of a response until it knows
+// whether to compress it, then streams the rest through gzip
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte // Body written before deciding, at most gzipMinSize
+	decided bool
+	gz      *gzip.Writer // Set once deciding to compress
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.decided || g.status != 0 {
+		return
+	}
+	g.status = status
+	// Bodyless and informational responses go out as they are
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		g.decide(false)
+	}
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.decided {
+		if g.Header().Get("Content-Encoding") != "" || !compressibleType(g.Header().Get("Content-Type")) {
+			g.decide(false)
+		} else {
+			g.buf = append(g.buf, b...)
+			if len(g.buf) < gzipMinSize {
+				return len(b), nil
+			}
+			if err := g.decide(true); err != nil {
+				return 0, err
+			}
+			return len(b), nil
+		}
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+// decide sends the header, compressed or not, then whatever body was held
+// back
+func (g *gzipResponseWriter) decide(compress bool) error {
+	g.decided = true
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	header := g.Header()
+	if compress {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		// The compressed bytes differ, so the ETag can only match weakly
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		g.gz = gzipWriters.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if g.gz != nil {
+		_, err = g.gz.Write(buf)
+	} else {
+		_, err = g.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends what has been written so far, uncompressed if compression
+// has not started
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		g.decide(false)
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+// Unwrap gives http.ResponseController the underlying writer
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// close sends a response too small to compress, or ends the compressed
+// stream
+func (g *gzipResponseWriter) close() {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			// The handler wrote nothing, so net/http sends its default
+			return
+		}
+		g.decide(false)
+	}
+	if g.gz != nil {
+		g.gz.Close()
+		g.gz.Reset(io.Discard)
+		gzipWriters.Put(g.gz)
+		g.gz = nil
+	}
+}
+
+// gzipMiddleware compresses text responses of at least gzipMinSize bytes for
+// clients accepting gzip, except on uncompressedPaths. Responses are
+// streamed through the compressor rather than buffered whole.
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if uncompressedPaths[r.URL.Path] || r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		g := &gzipResponseWriter{ResponseWriter: w}
+		defer g.close()
+		next.ServeHTTP(g, r)
+	})
+}
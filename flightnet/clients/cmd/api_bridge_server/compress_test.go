@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzipResponses(t *testing.T) {
+	_, h := newTestHandler(t, serverOptions{})
+	const path = "/flight-environment?route=JFK-LAX&aircraft_count=50"
+
+	// The first request fills the environment cache, so both bodies match
+	plain := serve(h, httptest.NewRequest(http.MethodGet, path, nil))
+	if plain.Code != http.StatusOK {
+		t.Fatalf("status %d", plain.Code)
+	}
+	if plain.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response compressed without Accept-Encoding")
+	}
+	if plain.Body.Len() < gzipMinSize {
+		t.Fatalf("body of %d bytes is too small to be compressed", plain.Body.Len())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	compressed := serve(h, req)
+	if compressed.Code != http.StatusOK {
+		t.Fatalf("status %d", compressed.Code)
+	}
+	if got := compressed.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding %q, want gzip", got)
+	}
+	if !bytes.Contains([]byte(compressed.Header().Get("Vary")), []byte("Accept-Encoding")) {
+		t.Errorf("Vary %q does not include Accept-Encoding", compressed.Header().Get("Vary"))
+	}
+	if compressed.Body.Len() >= plain.Body.Len() {
+		t.Errorf("compressed body of %d bytes is no smaller than %d", compressed.Body.Len(), plain.Body.Len())
+	}
+
+	reader, err := gzip.NewReader(compressed.Body)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("decompressing: %v", err)
+	}
+	if !bytes.Equal(decompressed, plain.Body.Bytes()) {
+		t.Errorf("decompressed body differs from the uncompressed one:\n%s\nwant:\n%s", decompressed, plain.Body.Bytes())
+	}
+}
+
+func TestGzipSkipsSmallResponses(t *testing.T) {
+	_, h := newTestHandler(t, serverOptions{})
+	req := httptest.NewRequest(http.MethodGet, "/providers", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := serve(h, req)
+	if rec.Body.Len() >= gzipMinSize {
+		t.Skipf("/providers is %d bytes, no longer a small response", rec.Body.Len())
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding %q on a %d byte response", got, rec.Body.Len())
+	}
+}
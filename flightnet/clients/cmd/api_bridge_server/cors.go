@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultCORSOrigins allows every origin, convenient for local frontends
+const defaultCORSOrigins = "*"
+
+// CORS headers the bridge allows and exposes
+const (
+	corsAllowedMethods = "GET, POST, OPTIONS"
+	// Authorization and X-API-Key carry API keys, X-Request-ID correlates
+	// logs, the others carry conditional and resumed requests
+	corsAllowedHeaders = "Authorization, X-API-Key, X-Request-ID, Content-Type, If-None-Match, Last-Event-ID"
+	corsExposedHeaders = "ETag, X-Cache, X-Request-ID, Retry-After"
+	corsMaxAge         = 600 // Seconds browsers may cache a preflight
+)
+
+// parseCORSOrigins splits a comma-separated list of allowed origins. * allows
+// every origin.
+func parseCORSOrigins(value string) []string {
+	var origins []string
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// corsMiddleware adds CORS headers for requests from allowed origins and
+// answers preflight requests for any route router has, whatever methods the
+// route accepts. It wraps the router rather than being added with Use, since
+// mux only runs middleware for requests whose method matches a route.
+func corsMiddleware(router *mux.Router, origins []string) http.Handler {
+	allowAll := false
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			allowAll = true
+		}
+		allowed[origin] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if !allowAll && !allowed[origin] {
+			// Without CORS headers the browser blocks the response
+			router.ServeHTTP(w, r)
+			return
+		}
+		if allowAll {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !routeExists(router, r) {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		router.ServeHTTP(w, r)
+	})
+}
+
+// routeExists reports whether router has a route for r's path, for any method
+func routeExists(router *mux.Router, r *http.Request) bool {
+	var match mux.RouteMatch
+	return router.Match(r, &match) || errors.Is(match.MatchErr, mux.ErrMethodMismatch)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	const allowedOrigin = "https://app.example.com"
+	_, h := newTestHandler(t, serverOptions{corsOrigins: parseCORSOrigins(allowedOrigin + "/")})
+
+	simple := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		req.Header.Set("Origin", origin)
+		return serve(h, req)
+	}
+	preflight := func(origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, "/flight-environment", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		req.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+		return serve(h, req)
+	}
+	varies := func(rec *httptest.ResponseRecorder, header string) bool {
+		for _, value := range rec.Header().Values("Vary") {
+			for _, name := range strings.Split(value, ",") {
+				if strings.TrimSpace(name) == header {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	t.Run("simple request, allowed origin", func(t *testing.T) {
+		rec := simple(allowedOrigin)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d, want 200", rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+			t.Errorf("Access-Control-Allow-Origin %q, want %q", got, allowedOrigin)
+		}
+		if got := rec.Header().Get("Access-Control-Expose-Headers"); !strings.Contains(got, "ETag") {
+			t.Errorf("Access-Control-Expose-Headers %q does not expose ETag", got)
+		}
+		if !varies(rec, "Origin") {
+			t.Errorf("Vary %v does not include Origin", rec.Header().Values("Vary"))
+		}
+	})
+
+	t.Run("simple request, rejected origin", func(t *testing.T) {
+		rec := simple("https://evil.example.com")
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Access-Control-Allow-Origin %q for a rejected origin", got)
+		}
+		if !varies(rec, "Origin") {
+			t.Errorf("Vary %v does not include Origin", rec.Header().Values("Vary"))
+		}
+	})
+
+	t.Run("preflight, allowed origin", func(t *testing.T) {
+		rec := preflight(allowedOrigin)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("status %d, want 204", rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != allowedOrigin {
+			t.Errorf("Access-Control-Allow-Origin %q, want %q", got, allowedOrigin)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodGet) {
+			t.Errorf("Access-Control-Allow-Methods %q does not allow GET", got)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Headers"); !strings.Contains(got, "X-API-Key") {
+			t.Errorf("Access-Control-Allow-Headers %q does not allow X-API-Key", got)
+		}
+		if got := rec.Header().Get("Access-Control-Max-Age"); got == "" {
+			t.Error("no Access-Control-Max-Age")
+		}
+		for _, header := range []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"} {
+			if !varies(rec, header) {
+				t.Errorf("Vary %v does not include %s", rec.Header().Values("Vary"), header)
+			}
+		}
+	})
+
+	t.Run("preflight, rejected origin", func(t *testing.T) {
+		rec := preflight("https://evil.example.com")
+		if rec.Code == http.StatusNoContent {
+			t.Errorf("status 204 for a rejected origin's preflight")
+		}
+		for _, header := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Methods", "Access-Control-Allow-Headers"} {
+			if got := rec.Header().Get(header); got != "" {
+				t.Errorf("%s %q for a rejected origin", header, got)
+			}
+		}
+		if !varies(rec, "Origin") {
+			t.Errorf("Vary %v does not include Origin", rec.Header().Values("Vary"))
+		}
+	})
+
+	t.Run("preflight, unknown route", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/no-such-route", nil)
+		req.Header.Set("Origin", allowedOrigin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		if rec := serve(h, req); rec.Code != http.StatusNotFound {
+			t.Errorf("status %d, want 404", rec.Code)
+		}
+	})
+}
+
+func TestCORSAllowsAnyOrigin(t *testing.T) {
+	_, h := newTestHandler(t, serverOptions{corsOrigins: parseCORSOrigins(defaultCORSOrigins)})
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Origin", "https://anywhere.example.com")
+	if got := serve(h, req).Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin %q, want *", got)
+	}
+}
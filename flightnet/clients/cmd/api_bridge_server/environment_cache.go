@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultEnvironmentCacheTTL is how long flight environment responses are
+// served from the cache unless -cache-ttl or BRIDGE_CACHE_TTL is set
+const defaultEnvironmentCacheTTL = 60 * time.Second
+
+// cachedEnvironment is a serialized flight environment response
+type cachedEnvironment struct {
+	body    []byte
+	etag    string // Strong ETag over body
+	status  int
+	expires time.Time
+}
+
+// environmentCall is a flight environment fetch shared by concurrent requests
+type environmentCall struct {
+	done  chan struct{}
+	entry *cachedEnvironment
+	err   error
+}
+
+// environmentCache caches flight environment responses for a TTL and
+// de-duplicates concurrent requests for the same key. A zero TTL disables
+// caching but still shares concurrent fetches.
+type environmentCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	entries  map[string]*cachedEnvironment
+	inflight map[string]*environmentCall
+}
+
+// newEnvironmentCache creates an empty cache whose entries live for ttl
+func newEnvironmentCache(ttl time.Duration) *environmentCache {
+	return &environmentCache{
+		ttl:      ttl,
+		entries:  make(map[string]*cachedEnvironment),
+		inflight: make(map[string]*environmentCall),
+	}
+}
+
+// environmentCacheKey builds a key from the provider name and the sorted
+// query parameters, leaving out those that do not change the data, such as
+// the refresh interval of streams
+func environmentCacheKey(provider string, params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		switch key {
+		case "provider", "refresh", "interval", "mode":
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(provider)
+	for _, key := range keys {
+		b.WriteByte('|')
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(strings.TrimSpace(params[key]))
+	}
+	return b.String()
+}
+
+// newCachedEnvironment wraps a serialized response with its ETag
+func newCachedEnvironment(body []byte, status int) *cachedEnvironment {
+	return &cachedEnvironment{
+		body:   body,
+		etag:   fmt.Sprintf(`"%x"`, sha1.Sum(body)),
+		status: status,
+	}
+}
+
+// get returns the cached response for key, calling fetch on a miss, after
+// expiry or when refresh is set. hit reports whether the response came from
+// the cache. Concurrent misses for the same key share a single fetch, and
+// only successful responses are stored.
+func (c *environmentCache) get(ctx context.Context, key string, refresh bool, fetch func() (*cachedEnvironment, error)) (entry *cachedEnvironment, hit bool, err error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && !refresh && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry, true, nil
+	}
+
+	if call, ok := c.inflight[key]; ok && !refresh {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.entry, false, call.err
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+
+	call := &environmentCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.entry, call.err = fetch()
+
+	c.mu.Lock()
+	if c.inflight[key] == call {
+		delete(c.inflight, key)
+	}
+	if call.err == nil && call.entry.status == http.StatusOK && c.ttl > 0 {
+		call.entry.expires = time.Now().Add(c.ttl)
+		c.entries[key] = call.entry
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return call.entry, false, call.err
+}
+
+// purge drops the cached responses of provider, whose data has changed
+func (c *environmentCache) purge(provider string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key == provider || strings.HasPrefix(key, provider+"|") {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// cacheControl returns the Cache-Control header for entry, which clients may
+// reuse until it expires from the cache
+func (entry *cachedEnvironment) cacheControl() string {
+	if entry.expires.IsZero() {
+		return "no-cache"
+	}
+	remaining := time.Until(entry.expires)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return fmt.Sprintf("max-age=%d", int(remaining.Round(time.Second).Seconds()))
+}
+
+// etagMatches reports whether an If-None-Match header matches etag. The
+// comparison is weak, as RFC 9110 requires, so the W/ prefix added to
+// compressed responses is ignored.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// minAircraftMoveKm is how far an aircraft must move to be reported as
+// moved, so position jitter is not
+const minAircraftMoveKm = 0.1
+
+// diffSectionParams are the query parameters that decide which entries a
+// diffed section holds. Snapshots whose parameters differ report different
+// things, so the section cannot be compared.
+var diffSectionParams = map[string][]string{
+	sectionAircraft:     {"aircraft_count"},
+	sectionFlights:      {"route"},
+	sectionWeather:      {"airports", "route"},
+	sectionGeopolitical: {"countries", "route"},
+	"no_fly_zones":      {"topics", "route", "airports", "countries"},
+}
+
+// diffSectionSources are the sections whose statuses cover each diffed
+// section; the no-fly zones come from the news, NOTAMs and risk advisories
+var diffSectionSources = map[string][]string{
+	sectionAircraft:     {sectionAircraft},
+	sectionFlights:      {sectionFlights},
+	sectionWeather:      {sectionWeather},
+	sectionGeopolitical: {sectionGeopolitical},
+	"no_fly_zones":      {sectionNews, sectionNOTAMs, sectionGeopolitical},
+}
+
+// AircraftMove is an aircraft seen in both snapshots at different positions
+type AircraftMove struct {
+	ID           string   `json:"id"`
+	Registration string   `json:"registration"`
+	From         GeoPoint `json:"from"`
+	To           GeoPoint `json:"to"`
+	DistanceKm   float64  `json:"distance_km"`
+}
+
+// AircraftDiff is how the aircraft changed between snapshots
+type AircraftDiff struct {
+	Appeared    []Aircraft     `json:"appeared"`
+	Disappeared []Aircraft     `json:"disappeared"`
+	Moved       []AircraftMove `json:"moved"`
+}
+
+// FlightStatusChange is a flight whose status changed between snapshots
+type FlightStatusChange struct {
+	FlightNumber  string    `json:"flight_number"`
+	DepartureTime time.Time `json:"departure_time"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+}
+
+// RiskDelta is a country whose risk level changed between snapshots
+type RiskDelta struct {
+	Country string `json:"country"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+	Delta   int    `json:"delta"`
+}
+
+// NoFlyZoneDiff is the no-fly zones reported in only one of the snapshots,
+// by country and source
+type NoFlyZoneDiff struct {
+	Added   []NoFlyZone `json:"added"`
+	Removed []NoFlyZone `json:"removed"`
+}
+
+// IncomparableSection is a section left out of a diff, and why
+type IncomparableSection struct {
+	Section string `json:"section"`
+	Reason  string `json:"reason"`
+}
+
+// SnapshotDiff is the difference between two stored snapshots. Sections
+// that could not be compared are null and listed in Incomparable.
+type SnapshotDiff struct {
+	From          string                `json:"from"`
+	To            string                `json:"to"`
+	FromTimestamp time.Time             `json:"from_timestamp"`
+	ToTimestamp   time.Time             `json:"to_timestamp"`
+	Aircraft      *AircraftDiff         `json:"aircraft"`
+	Flights       []FlightStatusChange  `json:"flights_status_changed"`
+	Weather       []WeatherChange       `json:"weather_changed"`
+	Risk          []RiskDelta           `json:"risk_changed"`
+	NoFlyZones    *NoFlyZoneDiff        `json:"no_fly_zones"`
+	Incomparable  []IncomparableSection `json:"incomparable,omitempty"`
+}
+
+// sectionIncomparable reports why section cannot be compared between the
+// snapshots, or "" when it can
+func sectionIncomparable(section string, from, to *HistoryEntry, before, after *FlightEnvironmentData) string {
+	if from.Provider != to.Provider {
+		return fmt.Sprintf("taken from different providers, %s and %s", from.Provider, to.Provider)
+	}
+	for _, param := range diffSectionParams[section] {
+		if from.Params[param] != to.Params[param] {
+			return fmt.Sprintf("%s differs, %q and %q", param, from.Params[param], to.Params[param])
+		}
+	}
+	for _, source := range diffSectionSources[section] {
+		for _, snapshot := range []struct {
+			id   string
+			data *FlightEnvironmentData
+		}{{from.ID, before}, {to.ID, after}} {
+			status, ok := snapshot.data.Sections[source]
+			switch {
+			case !ok || status.Status == SectionSkipped:
+				return fmt.Sprintf("%s not fetched in %s", source, snapshot.id)
+			case status.Status == SectionFailed || status.Status == SectionDegraded:
+				return fmt.Sprintf("%s %s in %s", source, status.Status, snapshot.id)
+			}
+		}
+	}
+	return ""
+}
+
+// aircraftKey keys an aircraft by ID, or registration when it has no ID
+func aircraftKey(a Aircraft) string {
+	if a.ID != "" {
+		return a.ID
+	}
+	return "registration:" + a.Registration
+}
+
+// diffAircraft returns the aircraft that appeared, disappeared or moved
+func diffAircraft(before, after []Aircraft) *AircraftDiff {
+	diff := &AircraftDiff{Appeared: []Aircraft{}, Disappeared: []Aircraft{}, Moved: []AircraftMove{}}
+	previous := make(map[string]Aircraft, len(before))
+	for _, a := range before {
+		previous[aircraftKey(a)] = a
+	}
+	seen := make(map[string]bool, len(after))
+	for _, a := range after {
+		key := aircraftKey(a)
+		seen[key] = true
+		old, ok := previous[key]
+		if !ok {
+			diff.Appeared = append(diff.Appeared, a)
+			continue
+		}
+		distance := clients.GreatCircleDistance(old.Location.Latitude, old.Location.Longitude, a.Location.Latitude, a.Location.Longitude)
+		if distance >= minAircraftMoveKm {
+			diff.Moved = append(diff.Moved, AircraftMove{
+				ID:           a.ID,
+				Registration: a.Registration,
+				From:         old.Location,
+				To:           a.Location,
+				DistanceKm:   math.Round(distance*10) / 10,
+			})
+		}
+	}
+	for _, a := range before {
+		if !seen[aircraftKey(a)] {
+			diff.Disappeared = append(diff.Disappeared, a)
+		}
+	}
+	return diff
+}
+
+// flightKey keys a flight by flight number and scheduled departure
+func flightKey(f Flight) string {
+	return f.FlightNumber + "@" + f.DepartureTime.UTC().Format(time.RFC3339)
+}
+
+// flightStatus is a flight's normalized status, or its upstream status when
+// it has none
+func flightStatus(f Flight) string {
+	if f.StatusCode != "" {
+		return string(f.StatusCode)
+	}
+	return f.Status
+}
+
+// diffFlights returns the flights in both snapshots whose status changed
+func diffFlights(before, after []Flight) []FlightStatusChange {
+	previous := make(map[string]Flight, len(before))
+	for _, f := range before {
+		previous[flightKey(f)] = f
+	}
+	changes := []FlightStatusChange{}
+	for _, f := range after {
+		old, ok := previous[flightKey(f)]
+		if !ok || flightStatus(old) == flightStatus(f) {
+			continue
+		}
+		changes = append(changes, FlightStatusChange{
+			FlightNumber:  f.FlightNumber,
+			DepartureTime: f.DepartureTime,
+			From:          flightStatus(old),
+			To:            flightStatus(f),
+		})
+	}
+	return changes
+}
+
+// diffWeather returns the airports in both snapshots whose weather
+// conditions changed
+func diffWeather(before, after map[string]*WeatherData) []WeatherChange {
+	changes := []WeatherChange{}
+	for airport, weather := range after {
+		old, ok := before[airport]
+		if !ok || weather == nil || old == nil || old.Conditions == weather.Conditions {
+			continue
+		}
+		changes = append(changes, WeatherChange{Airport: airport, From: old.Conditions, To: weather.Conditions})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Airport < changes[j].Airport })
+	return changes
+}
+
+// diffRisk returns the countries in both snapshots whose risk level changed
+func diffRisk(before, after map[string]*GeopoliticalRisk) []RiskDelta {
+	deltas := []RiskDelta{}
+	for country, risk := range after {
+		old, ok := before[country]
+		if !ok || risk == nil || old == nil || old.RiskLevel == risk.RiskLevel {
+			continue
+		}
+		deltas = append(deltas, RiskDelta{Country: country, From: old.RiskLevel, To: risk.RiskLevel, Delta: risk.RiskLevel - old.RiskLevel})
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Country < deltas[j].Country })
+	return deltas
+}
+
+// diffSnapshots compares two stored snapshots section by section
+func diffSnapshots(from, to *HistoryEntry, before, after *FlightEnvironmentData) *SnapshotDiff {
+	diff := &SnapshotDiff{From: from.ID, To: to.ID, FromTimestamp: from.Timestamp, ToTimestamp: to.Timestamp}
+	for _, section := range []string{sectionAircraft, sectionFlights, sectionWeather, sectionGeopolitical, "no_fly_zones"} {
+		if reason := sectionIncomparable(section, from, to, before, after); reason != "" {
+			diff.Incomparable = append(diff.Incomparable, IncomparableSection{Section: section, Reason: reason})
+			continue
+		}
+		switch section {
+		case sectionAircraft:
+			diff.Aircraft = diffAircraft(before.Aircraft, after.Aircraft)
+		case sectionFlights:
+			diff.Flights = diffFlights(before.Flights, after.Flights)
+		case sectionWeather:
+			diff.Weather = diffWeather(before.Weather, after.Weather)
+		case sectionGeopolitical:
+			diff.Risk = diffRisk(before.Geopolitical, after.Geopolitical)
+		case "no_fly_zones":
+			diff.NoFlyZones = &NoFlyZoneDiff{
+				Added:   append([]NoFlyZone{}, diffNoFlyZones(before.NoFlyZones, after.NoFlyZones)...),
+				Removed: append([]NoFlyZone{}, diffNoFlyZones(after.NoFlyZones, before.NoFlyZones)...),
+			}
+		}
+	}
+	return diff
+}
+
+// sameParams reports whether two snapshots were taken with the same
+// parameters
+func sameParams(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// latestSnapshotLike returns the newest snapshot from the provider of from
+// with the same parameters, or the provider's newest when none has them
+func latestSnapshotLike(ctx context.Context, store HistoryStore, from *HistoryEntry) (string, error) {
+	entries, err := store.List(ctx, HistoryQuery{Provider: from.Provider, Limit: maxHistoryLimit})
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if sameParams(entry.Params, from.Params) {
+			return entry.ID, nil
+		}
+	}
+	if len(entries) == 0 {
+		return from.ID, nil
+	}
+	return entries[0].ID, nil
+}
+
+// loadSnapshot reads a stored snapshot and decodes its data
+func loadSnapshot(ctx context.Context, store HistoryStore, id string) (*HistoryEntry, *FlightEnvironmentData, error) {
+	if !validHistoryID(id) {
+		return nil, nil, fmt.Errorf("%w: %q", ErrSnapshotNotFound, id)
+	}
+	entry, raw, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	var data FlightEnvironmentData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, nil, fmt.Errorf("decoding snapshot %s: %w", id, err)
+	}
+	return entry, &data, nil
+}
+
+// getEnvironmentDiff compares two stored snapshots, e.g.
+// /flight-environment/diff?from={id}&to={id}. to=latest, the default, is
+// the newest snapshot taken like from.
+func (s *APIBridgeServer) getEnvironmentDiff(w http.ResponseWriter, r *http.Request) {
+	fromID, toID := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	logFor(r.Context()).Info("received request for snapshot diff", "from", fromID, "to", toID)
+
+	if fromID == "" {
+		writeJSONError(w, http.StatusBadRequest, "from must name a snapshot")
+		return
+	}
+	store, ok := s.historyStore(w)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	writeError := func(err error) {
+		if errors.Is(err, ErrSnapshotNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		logFor(ctx).Error("error diffing snapshots", "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+	}
+	from, before, err := loadSnapshot(ctx, store, fromID)
+	if err != nil {
+		writeError(fmt.Errorf("from: %w", err))
+		return
+	}
+	if toID == "" || strings.EqualFold(toID, "latest") {
+		if toID, err = latestSnapshotLike(ctx, store, from); err != nil {
+			writeError(err)
+			return
+		}
+	}
+	to, after, err := loadSnapshot(ctx, store, toID)
+	if err != nil {
+		writeError(fmt.Errorf("to: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(diffSnapshots(from, to, before, after)); err != nil {
+		logFor(ctx).Error("error encoding snapshot diff to JSON", "error", err)
+	}
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// eventSnapshotRetention is how long the latest snapshot of an event
+	// stream is kept for clients resuming with Last-Event-ID
+	eventSnapshotRetention = 10 * time.Minute
+	// eventHeartbeatPeriod is how often an idle event stream sends a comment
+	// so proxies do not time it out
+	eventHeartbeatPeriod = 15 * time.Second
+)
+
+// Event stream event types
+const (
+	EventSnapshot = "snapshot" // The full FlightEnvironmentData
+	EventChange   = "change"   // An EnvironmentChange since the previous event
+	EventError    = "error"    // The refresh failed, the next one is still attempted
+)
+
+// WeatherChange is a change in an airport's weather conditions
+type WeatherChange struct {
+	Airport string `json:"airport"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// EnvironmentChange is the difference between two consecutive flight
+// environment snapshots
+type EnvironmentChange struct {
+	AircraftAdded     []Aircraft      `json:"aircraft_added,omitempty"`
+	AircraftRemoved   []string        `json:"aircraft_removed,omitempty"` // IDs
+	NoFlyZonesAdded   []NoFlyZone     `json:"no_fly_zones_added,omitempty"`
+	NoFlyZonesRemoved []NoFlyZone     `json:"no_fly_zones_removed,omitempty"`
+	WeatherChanged    []WeatherChange `json:"weather_changed,omitempty"`
+	Timestamp         string          `json:"timestamp"`
+}
+
+// empty reports whether nothing changed
+func (c *EnvironmentChange) empty() bool {
+	return len(c.AircraftAdded) == 0 && len(c.AircraftRemoved) == 0 &&
+		len(c.NoFlyZonesAdded) == 0 && len(c.NoFlyZonesRemoved) == 0 &&
+		len(c.WeatherChanged) == 0
+}
+
+// diffEnvironment returns what changed from previous to current: aircraft
+// added or removed by ID, no-fly zones added or removed, and airports whose
+// weather conditions changed
+func diffEnvironment(previous, current *FlightEnvironmentData) *EnvironmentChange {
+	change := &EnvironmentChange{Timestamp: current.Timestamp}
+
+	before := make(map[string]bool, len(previous.Aircraft))
+	for _, a := range previous.Aircraft {
+		before[a.ID] = true
+	}
+	after := make(map[string]bool, len(current.Aircraft))
+	for _, a := range current.Aircraft {
+		after[a.ID] = true
+		if !before[a.ID] {
+			change.AircraftAdded = append(change.AircraftAdded, a)
+		}
+	}
+	for _, a := range previous.Aircraft {
+		if !after[a.ID] {
+			change.AircraftRemoved = append(change.AircraftRemoved, a.ID)
+		}
+	}
+
+	change.NoFlyZonesAdded = diffNoFlyZones(previous.NoFlyZones, current.NoFlyZones)
+	change.NoFlyZonesRemoved = diffNoFlyZones(current.NoFlyZones, previous.NoFlyZones)
+
+	for airport, weather := range current.Weather {
+		old, ok := previous.Weather[airport]
+		if !ok || weather == nil || old == nil || old.Conditions == weather.Conditions {
+			continue
+		}
+		change.WeatherChanged = append(change.WeatherChanged, WeatherChange{Airport: airport, From: old.Conditions, To: weather.Conditions})
+	}
+	sort.Slice(change.WeatherChanged, func(i, j int) bool { return change.WeatherChanged[i].Airport < change.WeatherChanged[j].Airport })
+	return change
+}
+
+// retainedSnapshot is the latest snapshot of an event stream
+type retainedSnapshot struct {
+	id      uint64
+	data    *FlightEnvironmentData
+	expires time.Time
+}
+
+// snapshotStore keeps the latest snapshot of each event stream, by
+// environmentCacheKey, so clients can resume from it
+type snapshotStore struct {
+	mu        sync.Mutex
+	lastID    uint64
+	snapshots map[string]retainedSnapshot
+}
+
+func newSnapshotStore() *snapshotStore {
+	return &snapshotStore{snapshots: make(map[string]retainedSnapshot)}
+}
+
+// put retains data as the latest snapshot for key and returns its event ID,
+// which increases across all streams
+func (s *snapshotStore) put(key string, data *FlightEnvironmentData) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, snapshot := range s.snapshots {
+		if now.After(snapshot.expires) {
+			delete(s.snapshots, k)
+		}
+	}
+	s.lastID++
+	s.snapshots[key] = retainedSnapshot{id: s.lastID, data: data, expires: now.Add(eventSnapshotRetention)}
+	return s.lastID
+}
+
+// get returns the latest snapshot retained for key
+func (s *snapshotStore) get(key string) (retainedSnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshot, ok := s.snapshots[key]
+	if !ok || time.Now().After(snapshot.expires) {
+		return retainedSnapshot{}, false
+	}
+	return snapshot, true
+}
+
+// eventWriter writes Server-Sent Events, flushing each one
+type eventWriter struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+}
+
+// write sends an event whose data is payload as JSON. id is left out when 0.
+func (e *eventWriter) write(id uint64, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", event, err)
+	}
+	if id > 0 {
+		data = append([]byte("id: "+strconv.FormatUint(id, 10)+"\nevent: "+event+"\ndata: "), data...)
+	} else {
+		data = append([]byte("event: "+event+"\ndata: "), data...)
+	}
+	return e.send(append(data, '\n', '\n'))
+}
+
+// send writes raw event stream text and flushes it
+func (e *eventWriter) send(text []byte) error {
+	e.controller.SetWriteDeadline(time.Now().Add(streamWriteWait))
+	if _, err := e.w.Write(text); err != nil {
+		return err
+	}
+	return e.controller.Flush()
+}
+
+// streamFlightEnvironmentEvents streams flight environment data as
+// Server-Sent Events: a snapshot event, then a change event whenever a
+// refresh every interval finds aircraft, no-fly zones or weather conditions
+// changed. A client reconnecting with Last-Event-ID resumes from the latest
+// snapshot retained for the same parameters.
+func (s *APIBridgeServer) streamFlightEnvironmentEvents(w http.ResponseWriter, r *http.Request) {
+	provider, params, interval, err := s.parseStreamRequest(r)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	setRequestProvider(r.Context(), provider.Name())
+	key := environmentCacheKey(provider.Name(), params)
+
+	s.streams.Add(1)
+	defer s.streams.Done()
+
+	// The stream outlives the server's write timeout, so each event sets
+	// its own deadline
+	events := &eventWriter{w: w, controller: http.NewResponseController(w)}
+	if err := events.controller.SetWriteDeadline(time.Time{}); err != nil {
+		http.Error(w, "Error: streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // Stops nginx buffering events
+	w.WriteHeader(http.StatusOK)
+	if err := events.controller.Flush(); err != nil {
+		logFor(r.Context()).Error("error starting event stream", "error", err)
+		return
+	}
+	logFor(r.Context()).Info("streaming flight environment events", "interval", interval.String(), "provider", provider.Name())
+
+	var last *FlightEnvironmentData
+	if lastEventID := r.Header.Get("Last-Event-ID"); lastEventID != "" {
+		if snapshot, ok := s.eventSnapshots.get(key); ok {
+			// A client that saw the snapshot already only needs what changed
+			if lastEventID != strconv.FormatUint(snapshot.id, 10) {
+				if err := events.write(snapshot.id, EventSnapshot, snapshot.data); err != nil {
+					logFor(r.Context()).Error("error writing event stream", "error", err)
+					return
+				}
+			}
+			last = snapshot.data
+		}
+	}
+
+	refresh := time.NewTimer(0)
+	defer refresh.Stop()
+	heartbeat := time.NewTicker(eventHeartbeatPeriod)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-refresh.C:
+			ctx, cancel := context.WithTimeout(r.Context(), interval)
+			envData, err := provider.GetFlightEnvironment(ctx, params)
+			cancel()
+			switch {
+			case r.Context().Err() != nil:
+				return
+			case err != nil:
+				err = events.write(0, EventError, map[string]string{"error": err.Error(), "timestamp": time.Now().UTC().Format(time.RFC3339)})
+			case last == nil:
+				err = events.write(s.eventSnapshots.put(key, envData), EventSnapshot, envData)
+				last = envData
+			default:
+				change := diffEnvironment(last, envData)
+				id := s.eventSnapshots.put(key, envData)
+				if !change.empty() {
+					err = events.write(id, EventChange, change)
+				}
+				last = envData
+			}
+			if err != nil {
+				logFor(r.Context()).Error("error writing event stream", "error", err)
+				return
+			}
+			refresh.Reset(interval)
+		case <-heartbeat.C:
+			if err := events.send([]byte(": keep-alive\n\n")); err != nil {
+				logFor(r.Context()).Error("error writing event stream", "error", err)
+				return
+			}
+		case <-s.streamsDone():
+			return
+		case <-r.Context().Done():
+			logFor(r.Context()).Info("flight environment event stream closed")
+			return
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/your-project/clients"
+)
+
+// Caps on the airports, countries and topics parameters, since every entry
+// costs upstream requests
+const (
+	maxEnvironmentAirports  = 20
+	maxEnvironmentCountries = 20
+	maxEnvironmentTopics    = 10
+	maxTopicLength          = 64
+)
+
+// Defaults for the airports and topics parameters; riskCountries is the
+// default for countries
+var (
+	defaultWeatherAirports = []string{"JFK", "LAX", "LHR", "CDG", "DXB"}
+	defaultNewsTopics      = []string{"Iran", "Russia", "North Korea"}
+)
+
+// environmentLists are the airports whose weather and NOTAMs, the countries
+// whose risk and the topics whose news a flight environment reports
+type environmentLists struct {
+	airports  []string // IATA codes
+	countries []string // ISO 3166-1 alpha-2 codes
+	topics    []string
+}
+
+// parseListParam parses the comma-separated list parameter name, passing
+// each entry through normalize and dropping repeats. It returns nil when the
+// parameter is absent.
+func parseListParam(params map[string]string, name string, max int, normalize func(string) (string, error)) ([]string, error) {
+	value, ok := params[name]
+	if !ok {
+		return nil, nil
+	}
+	var list []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		normalized, err := normalize(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			list = append(list, normalized)
+		}
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("%s must name at least one entry", name)
+	}
+	if len(list) > max {
+		return nil, fmt.Errorf("%s names %d entries, more than the %d allowed", name, len(list), max)
+	}
+	return list, nil
+}
+
+// normalizeCountry upper-cases an ISO 3166-1 alpha-2 country code
+func normalizeCountry(code string) (string, error) {
+	if len(code) != 2 {
+		return "", fmt.Errorf("country %q must be a 2-letter ISO code", code)
+	}
+	for i := 0; i < len(code); i++ {
+		if (code[i] < 'A' || code[i] > 'Z') && (code[i] < 'a' || code[i] > 'z') {
+			return "", fmt.Errorf("country %q must be a 2-letter ISO code", code)
+		}
+	}
+	return strings.ToUpper(code), nil
+}
+
+// normalizeTopic checks a news topic is short plain text
+func normalizeTopic(topic string) (string, error) {
+	if len(topic) > maxTopicLength {
+		return "", fmt.Errorf("topic %q is longer than %d characters", topic, maxTopicLength)
+	}
+	for _, r := range topic {
+		if r < ' ' || r == 0x7f {
+			return "", fmt.Errorf("topic %q contains control characters", topic)
+		}
+	}
+	return topic, nil
+}
+
+// parseEnvironmentLists parses the airports, countries and topics
+// parameters. Lists that were not given are left nil.
+func parseEnvironmentLists(params map[string]string) (environmentLists, error) {
+	var lists environmentLists
+	var err error
+	airports := clients.NewEmbeddedAirportsAPI()
+	if lists.airports, err = parseListParam(params, "airports", maxEnvironmentAirports, func(code string) (string, error) {
+		return resolveAirportCode(airports, code)
+	}); err != nil {
+		return environmentLists{}, err
+	}
+	if lists.countries, err = parseListParam(params, "countries", maxEnvironmentCountries, normalizeCountry); err != nil {
+		return environmentLists{}, err
+	}
+	if lists.topics, err = parseListParam(params, "topics", maxEnvironmentTopics, normalizeTopic); err != nil {
+		return environmentLists{}, err
+	}
+	return lists, nil
+}
+
+// normalizeEnvironmentLists validates the list parameters and rewrites them
+// in canonical form, IATA codes and upper-case countries, so providers and
+// the cache key see one spelling of each list
+func normalizeEnvironmentLists(params map[string]string) error {
+	lists, err := parseEnvironmentLists(params)
+	if err != nil {
+		return err
+	}
+	for name, list := range map[string][]string{"airports": lists.airports, "countries": lists.countries, "topics": lists.topics} {
+		if list != nil {
+			params[name] = strings.Join(list, ",")
+		}
+	}
+	return nil
+}
+
+// resolveEnvironmentLists returns the lists a provider should report on.
+// Lists that were not given take their defaults, extended with the airports
+// of a route and their countries. A provider has no way to reject bad
+// parameters, so lists that do not parse are logged and defaulted.
+func resolveEnvironmentLists(params map[string]string, legs []RouteLeg, logger *slog.Logger) environmentLists {
+	lists, err := parseEnvironmentLists(params)
+	if err != nil {
+		logger.Warn("ignoring environment lists", "error", err)
+		lists = environmentLists{}
+	}
+
+	if lists.airports == nil {
+		lists.airports = append([]string(nil), defaultWeatherAirports...)
+		for _, leg := range legs {
+			lists.airports = appendMissing(lists.airports, leg.Origin, leg.Destination)
+		}
+	}
+	if lists.countries == nil {
+		lists.countries = append([]string(nil), riskCountries...)
+		airports := clients.NewEmbeddedAirportsAPI()
+		for _, leg := range legs {
+			for _, code := range []string{leg.Origin, leg.Destination} {
+				if airport, err := airports.GetAirportByIATA(code); err == nil && airport.Country != "" {
+					lists.countries = appendMissing(lists.countries, airport.Country)
+				}
+			}
+		}
+	}
+	if lists.topics == nil {
+		lists.topics = defaultNewsTopics
+	}
+	return lists
+}
+
+// appendMissing appends the values not already in list
+func appendMissing(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// icaoLocations returns the ICAO codes of the airports, for NOTAM lookups,
+// skipping airports without one
+func icaoLocations(iataCodes []string) []string {
+	airports := clients.NewEmbeddedAirportsAPI()
+	locations := make([]string, 0, len(iataCodes))
+	for _, code := range iataCodes {
+		if airport, err := airports.GetAirportByIATA(code); err == nil && airport.ICAO != "" {
+			locations = append(locations, airport.ICAO)
+		}
+	}
+	return locations
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Flight environment stream limits
+const (
+	defaultStreamInterval = 10 * time.Second
+	minStreamInterval     = time.Second
+	maxStreamInterval     = 10 * time.Minute
+	// streamWriteWait is how long a frame may take to write
+	streamWriteWait = 10 * time.Second
+	// streamPongWait is how long a peer may go without answering pings
+	// before it is considered dead
+	streamPongWait = 60 * time.Second
+	// streamPingPeriod must be shorter than streamPongWait
+	streamPingPeriod = streamPongWait * 9 / 10
+)
+
+// Stream message types
+const (
+	StreamSnapshot = "snapshot" // Data holds the full environment
+	StreamDiff     = "diff"     // Changed holds the fields that changed since the last message
+	StreamError    = "error"    // The refresh failed, the next one is still attempted
+)
+
+// StreamMessage is a frame pushed on the flight environment stream
+type StreamMessage struct {
+	Type string                 `json:"type"`
+	Data *FlightEnvironmentData `json:"data,omitempty"`
+	// Changed maps the JSON names of the top-level fields that changed to
+	// their new values
+	Changed   map[string]json.RawMessage `json:"changed,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+	Timestamp string                     `json:"timestamp"`
+}
+
+var streamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// The bridge serves dashboards from other origins, like its REST endpoints
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// parseStreamInterval parses an interval parameter, a duration such as 30s
+// or a number of seconds. An empty parameter means the default.
+func parseStreamInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultStreamInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid interval %q: must be a duration such as 30s", value)
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+	if interval < minStreamInterval || interval > maxStreamInterval {
+		return 0, fmt.Errorf("invalid interval %q: must be between %v and %v", value, minStreamInterval, maxStreamInterval)
+	}
+	return interval, nil
+}
+
+// parseStreamRequest resolves the provider, query parameters and refresh
+// interval of a request for a flight environment stream
+func (s *APIBridgeServer) parseStreamRequest(r *http.Request) (provider DataProvider, params map[string]string, interval time.Duration, err error) {
+	params = make(map[string]string)
+	for key, values := range r.URL.Query() {
+		if len(values) > 0 {
+			params[key] = values[0]
+		}
+	}
+	if provider, err = s.resolveProvider(params["provider"]); err != nil {
+		return nil, nil, 0, err
+	}
+	if interval, err = parseStreamInterval(params["interval"]); err != nil {
+		return nil, nil, 0, err
+	}
+	if _, err = parseSAFParam(params["saf"]); err != nil {
+		return nil, nil, 0, err
+	}
+	if err = normalizeEnvironmentLists(params); err != nil {
+		return nil, nil, 0, err
+	}
+	if _, err = LookupMockScenario(params["scenario"]); err != nil {
+		return nil, nil, 0, err
+	}
+	if _, err = parseMockFaults(params); err != nil {
+		return nil, nil, 0, err
+	}
+	if _, _, err = s.parseAircraftCount(params); err != nil {
+		return nil, nil, 0, err
+	}
+	return provider, params, interval, nil
+}
+
+// streamFlightEnvironment upgrades to a WebSocket and pushes flight
+// environment data every interval, as full snapshots or, with mode=diff, a
+// snapshot followed by the fields that changed. A consumer too slow to keep
+// up misses intermediate refreshes rather than having them queue.
+func (s *APIBridgeServer) streamFlightEnvironment(w http.ResponseWriter, r *http.Request) {
+	provider, params, interval, err := s.parseStreamRequest(r)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	setRequestProvider(r.Context(), provider.Name())
+	diff := false
+	switch params["mode"] {
+	case "", StreamSnapshot:
+	case StreamDiff:
+		diff = true
+	default:
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid mode %q: must be snapshot or diff", params["mode"]))
+		return
+	}
+
+	// Counted before the upgrade hijacks the connection, so that once
+	// http.Server.Shutdown returns every stream is counted
+	s.streams.Add(1)
+	defer s.streams.Done()
+
+	// The upgrader writes the error response itself
+	conn, err := streamUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logFor(r.Context()).Error("error upgrading flight environment stream", "error", err)
+		return
+	}
+	defer conn.Close()
+	logFor(r.Context()).Info("streaming flight environment data", "interval", interval.String(), "provider", provider.Name(), "mode", params["mode"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Read only to process pongs and notice the peer going away
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	})
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// The refresher keeps at most one message waiting for the writer,
+	// replacing it when the writer falls behind
+	pending := make(chan *StreamMessage, 1)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			offerStreamMessage(pending, s.refreshStream(ctx, provider, params, interval))
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamPingPeriod)
+	defer ping.Stop()
+	var last map[string]json.RawMessage
+	for {
+		select {
+		case msg := <-pending:
+			if diff && msg.Type == StreamSnapshot {
+				msg, last = diffStreamMessage(msg, last)
+			}
+			if msg == nil {
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteJSON(msg); err != nil {
+				logFor(r.Context()).Error("error writing flight environment stream", "error", err)
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(streamWriteWait)); err != nil {
+				logFor(r.Context()).Warn("flight environment stream peer stopped responding", "error", err)
+				return
+			}
+		case <-s.streamsDone():
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"),
+				time.Now().Add(streamWriteWait))
+			return
+		case <-ctx.Done():
+			logFor(r.Context()).Info("flight environment stream closed")
+			return
+		}
+	}
+}
+
+// refreshStream fetches the environment for one stream message, giving up
+// after interval so a slow upstream cannot stall the stream
+func (s *APIBridgeServer) refreshStream(ctx context.Context, provider DataProvider, params map[string]string, interval time.Duration) *StreamMessage {
+	ctx, cancel := context.WithTimeout(ctx, interval)
+	defer cancel()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	envData, err := provider.GetFlightEnvironment(ctx, params)
+	if err != nil {
+		return &StreamMessage{Type: StreamError, Error: err.Error(), Timestamp: now}
+	}
+	return &StreamMessage{Type: StreamSnapshot, Data: envData, Timestamp: now}
+}
+
+// offerStreamMessage queues msg for the writer, replacing any message the
+// writer has not taken yet
+func offerStreamMessage(pending chan *StreamMessage, msg *StreamMessage) {
+	for {
+		select {
+		case pending <- msg:
+			return
+		default:
+		}
+		select {
+		case <-pending:
+		default:
+		}
+	}
+}
+
+// diffStreamMessage turns a snapshot into a diff against the fields last
+// sent, returning nil when nothing changed. The first snapshot is sent
+// whole. It also returns the fields to diff the next snapshot against.
+func diffStreamMessage(msg *StreamMessage, last map[string]json.RawMessage) (*StreamMessage, map[string]json.RawMessage) {
+	body, err := json.Marshal(msg.Data)
+	if err != nil {
+		return msg, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return msg, nil
+	}
+	if last == nil {
+		return msg, fields
+	}
+
+	changed := make(map[string]json.RawMessage)
+	for name, value := range fields {
+		// Every refresh has a new timestamp, which says nothing changed
+		if name == "timestamp" {
+			continue
+		}
+		if !bytes.Equal(last[name], value) {
+			changed[name] = value
+		}
+	}
+	for name := range last {
+		if _, ok := fields[name]; !ok {
+			changed[name] = json.RawMessage("null")
+		}
+	}
+	if len(changed) == 0 {
+		return nil, fields
+	}
+	return &StreamMessage{Type: StreamDiff, Changed: changed, Timestamp: msg.Timestamp}, fields
+}
+
+// streamsDone returns a channel closed when the server shuts down
+func (s *APIBridgeServer) streamsDone() <-chan struct{} {
+	return s.shutdown
+}
+
+// endStreams tells every flight environment stream to close. Event streams
+// must end before http.Server.Shutdown can finish waiting for them.
+func (s *APIBridgeServer) endStreams() {
+	s.shutdownOnce.Do(func() { close(s.shutdown) })
+}
+
+// closeStreams closes every flight environment stream, including the
+// WebSocket streams http.Server.Shutdown leaves open since their connections
+// are hijacked, and waits until they have closed or ctx is done
+func (s *APIBridgeServer) closeStreams(ctx context.Context) error {
+	s.endStreams()
+
+	closed := make(chan struct{})
+	go func() {
+		s.streams.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+const (
+	// maxFlightPlanBytes bounds the body of a flight plan
+	maxFlightPlanBytes = 64 << 10
+	// departureTimeGrace lets a departure time a little in the past through,
+	// for clock skew and plans submitted at the gate
+	departureTimeGrace = 5 * time.Minute
+	// weatherForecastHorizon is how far ahead current weather still says
+	// something about the departure
+	weatherForecastHorizon = 6 * time.Hour
+	// flightPlanTimeout bounds the checks of one evaluation
+	flightPlanTimeout = 15 * time.Second
+)
+
+// Route risk levels, on the 1-10 scale, from which a plan needs caution or
+// should not go
+const (
+	riskCautionLevel = 5
+	riskNoGoLevel    = 8
+)
+
+// FlightPlanVerdict is the overall recommendation for a flight plan
+type FlightPlanVerdict string
+
+const (
+	VerdictGo      FlightPlanVerdict = "Go"
+	VerdictCaution FlightPlanVerdict = "Caution"
+	VerdictNoGo    FlightPlanVerdict = "No-Go"
+)
+
+// CheckStatus is the outcome of one dimension of a flight plan evaluation
+type CheckStatus string
+
+const (
+	CheckOK          CheckStatus = "ok"
+	CheckCaution     CheckStatus = "caution"
+	CheckNoGo        CheckStatus = "no_go"
+	CheckUnavailable CheckStatus = "unavailable" // The check could not be made
+)
+
+// FlightPlan is a flight to evaluate. Airports are IATA or ICAO codes and
+// waypoints are airports the route passes between origin and destination.
+type FlightPlan struct {
+	Origin        string   `json:"origin"`
+	Destination   string   `json:"destination"`
+	DepartureTime string   `json:"departure_time"` // RFC 3339
+	AircraftType  string   `json:"aircraft_type"`  // ICAO type designator, such as B738
+	Waypoints     []string `json:"waypoints,omitempty"`
+}
+
+// PlanCheck is the outcome of one dimension of a flight plan evaluation,
+// with the data it was based on
+type PlanCheck struct {
+	Status  CheckStatus `json:"status"`
+	Reasons []string    `json:"reasons,omitempty"`
+	Error   string      `json:"error,omitempty"` // Why the check is unavailable
+	Details interface{} `json:"details,omitempty"`
+}
+
+// RouteRiskSummary combines the risk of every leg of a flight plan
+type RouteRiskSummary struct {
+	MaxRisk           int          `json:"max_risk"`     // 1-10 scale
+	AverageRisk       float64      `json:"average_risk"` // Weighted by leg distance
+	HighRiskCountries []string     `json:"high_risk_countries"`
+	Unassessed        []string     `json:"unassessed_countries"`
+	Legs              []*RouteRisk `json:"legs"`
+}
+
+// AirspaceSummary lists the airspace events reported along a flight plan
+type AirspaceSummary struct {
+	NoFlyZones []string        `json:"no_fly_zones"` // Countries with closures or restrictions
+	Events     []AirspaceEvent `json:"events"`
+}
+
+// FlightPlanEvaluation is the verdict on a flight plan, with every
+// dimension it was based on
+type FlightPlanEvaluation struct {
+	Origin           string            `json:"origin"` // IATA
+	Destination      string            `json:"destination"`
+	Waypoints        []string          `json:"waypoints,omitempty"`
+	DepartureTime    time.Time         `json:"departure_time"`
+	AircraftType     string            `json:"aircraft_type"`
+	DistanceKm       float64           `json:"distance_km"`
+	Verdict          FlightPlanVerdict `json:"verdict"`
+	Reasons          []string          `json:"reasons"`
+	Notes            []string          `json:"notes,omitempty"`
+	DepartureWeather *PlanCheck        `json:"departure_weather"`
+	ArrivalWeather   *PlanCheck        `json:"arrival_weather"`
+	RouteRisk        *PlanCheck        `json:"route_risk"`
+	Airspace         *PlanCheck        `json:"airspace"`
+	Emissions        *PlanCheck        `json:"emissions"` // Informational, never changes the verdict
+	EvaluatedAt      time.Time         `json:"evaluated_at"`
+}
+
+// validatedPlan is a flight plan whose fields have been checked and resolved
+type validatedPlan struct {
+	legs          []RouteLeg
+	waypoints     []string // IATA
+	departureTime time.Time
+	aircraftType  string
+}
+
+// validate checks every field of the plan, returning the problems by field
+// name
+func (plan FlightPlan) validate(now time.Time) (*validatedPlan, map[string]string) {
+	problems := make(map[string]string)
+	airports := clients.NewEmbeddedAirportsAPI()
+	resolve := func(field, code string) string {
+		if strings.TrimSpace(code) == "" {
+			problems[field] = "required"
+			return ""
+		}
+		iata, err := resolveAirportCode(airports, strings.TrimSpace(code))
+		if err != nil {
+			problems[field] = err.Error()
+			return ""
+		}
+		return iata
+	}
+
+	origin := resolve("origin", plan.Origin)
+	destination := resolve("destination", plan.Destination)
+	if len(plan.Waypoints) > maxRouteLegs-1 {
+		problems["waypoints"] = fmt.Sprintf("at most %d waypoints are allowed", maxRouteLegs-1)
+	}
+	waypoints := make([]string, 0, len(plan.Waypoints))
+	for i, code := range plan.Waypoints {
+		waypoints = append(waypoints, resolve(fmt.Sprintf("waypoints[%d]", i), code))
+	}
+
+	validated := &validatedPlan{waypoints: waypoints}
+	if len(problems) == 0 {
+		stops := append(append([]string{origin}, waypoints...), destination)
+		for i := 1; i < len(stops); i++ {
+			if stops[i-1] == stops[i] {
+				problems["destination"] = fmt.Sprintf("leg %s-%s starts and ends at the same airport", stops[i-1], stops[i])
+				break
+			}
+			validated.legs = append(validated.legs, RouteLeg{Origin: stops[i-1], Destination: stops[i]})
+		}
+	}
+
+	switch departure, err := time.Parse(time.RFC3339, strings.TrimSpace(plan.DepartureTime)); {
+	case strings.TrimSpace(plan.DepartureTime) == "":
+		problems["departure_time"] = "required"
+	case err != nil:
+		problems["departure_time"] = "must be an RFC 3339 time such as 2025-06-01T14:30:00Z"
+	case departure.Before(now.Add(-departureTimeGrace)):
+		problems["departure_time"] = "must not be in the past"
+	default:
+		validated.departureTime = departure.UTC()
+	}
+
+	validated.aircraftType = strings.ToUpper(strings.TrimSpace(plan.AircraftType))
+	if validated.aircraftType == "" {
+		problems["aircraft_type"] = "required"
+	} else if _, ok := loadAircraftTypes()[validated.aircraftType]; !ok {
+		problems["aircraft_type"] = fmt.Sprintf("unknown ICAO type designator %q", plan.AircraftType)
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+	return validated, nil
+}
+
+// checkFlightPlan checks the weather at both ends, the risk and airspace
+// events along the route and the emissions of a validated plan
+// concurrently, and combines them into a verdict. Checks that fail or do
+// not finish before ctx is done are reported as unavailable.
+func (s *APIBridgeServer) checkFlightPlan(ctx context.Context, plan *validatedPlan, now time.Time) *FlightPlanEvaluation {
+	origin := plan.legs[0].Origin
+	destination := plan.legs[len(plan.legs)-1].Destination
+	eval := &FlightPlanEvaluation{
+		Origin:        origin,
+		Destination:   destination,
+		Waypoints:     plan.waypoints,
+		DepartureTime: plan.departureTime,
+		AircraftType:  plan.aircraftType,
+		EvaluatedAt:   now.UTC(),
+	}
+	airports := clients.NewEmbeddedAirportsAPI()
+	for _, leg := range plan.legs {
+		from, err1 := airports.GetAirportByIATA(leg.Origin)
+		to, err2 := airports.GetAirportByIATA(leg.Destination)
+		if err1 == nil && err2 == nil {
+			eval.DistanceKm += from.DistanceTo(*to)
+		}
+	}
+	eval.DistanceKm = math.Round(eval.DistanceKm*10) / 10
+
+	var mu sync.Mutex
+	var departureWeather, arrivalWeather, emissions *PlanCheck
+	var risks []*RouteRisk
+	var riskErr error
+	fetchConcurrently(ctx,
+		func(ctx context.Context) {
+			check := s.weatherCheck(origin, "departure")
+			mu.Lock()
+			departureWeather = check
+			mu.Unlock()
+		},
+		func(ctx context.Context) {
+			check := s.weatherCheck(destination, "arrival")
+			mu.Lock()
+			arrivalWeather = check
+			mu.Unlock()
+		},
+		func(ctx context.Context) {
+			var legRisks []*RouteRisk
+			var err error
+			for _, leg := range plan.legs {
+				if ctx.Err() != nil {
+					return
+				}
+				var risk *RouteRisk
+				if risk, err = s.mockProvider.geopoliticalAPI.GetRouteRisk(leg.Origin, leg.Destination); err != nil {
+					err = fmt.Errorf("%s: %w", leg, err)
+					break
+				}
+				legRisks = append(legRisks, risk)
+			}
+			mu.Lock()
+			risks, riskErr = legRisks, err
+			mu.Unlock()
+		},
+		func(ctx context.Context) {
+			check := s.emissionsCheck(plan.aircraftType, eval.DistanceKm)
+			mu.Lock()
+			emissions = check
+			mu.Unlock()
+		},
+	)
+
+	mu.Lock()
+	defer mu.Unlock()
+	unfinished := func(check *PlanCheck) *PlanCheck {
+		if check != nil {
+			return check
+		}
+		return &PlanCheck{Status: CheckUnavailable, Error: "check did not finish in time"}
+	}
+	eval.DepartureWeather = unfinished(departureWeather)
+	eval.ArrivalWeather = unfinished(arrivalWeather)
+	eval.Emissions = unfinished(emissions)
+	switch {
+	case riskErr != nil:
+		eval.RouteRisk = &PlanCheck{Status: CheckUnavailable, Error: riskErr.Error()}
+		eval.Airspace = &PlanCheck{Status: CheckUnavailable, Error: "route risk unavailable"}
+	case len(risks) < len(plan.legs):
+		eval.RouteRisk = unfinished(nil)
+		eval.Airspace = unfinished(nil)
+	default:
+		eval.RouteRisk, eval.Airspace = routeRiskChecks(risks)
+	}
+
+	if plan.departureTime.Sub(now) > weatherForecastHorizon {
+		eval.Notes = append(eval.Notes, "weather checks use current conditions, which say little about a departure over "+weatherForecastHorizon.String()+" away")
+	}
+	eval.Verdict, eval.Reasons = flightPlanVerdict(map[string]*PlanCheck{
+		"departure weather": eval.DepartureWeather,
+		"arrival weather":   eval.ArrivalWeather,
+		"route risk":        eval.RouteRisk,
+		"airspace":          eval.Airspace,
+	})
+	return eval
+}
+
+// weatherCheck checks the weather at an airport, where the flight departs
+// or arrives; unsuitable weather means the flight should not go
+func (s *APIBridgeServer) weatherCheck(airport, where string) *PlanCheck {
+	result, err := s.mockProvider.weatherAPI.IsWeatherSuitableForFlight(airport)
+	if err != nil {
+		return &PlanCheck{Status: CheckUnavailable, Error: err.Error()}
+	}
+	check := &PlanCheck{Status: CheckOK, Details: result}
+	if !result.Suitable {
+		check.Status = CheckNoGo
+		for _, failure := range result.Failures {
+			check.Reasons = append(check.Reasons, fmt.Sprintf("%s weather at %s: %s", where, airport, failure))
+		}
+	}
+	return check
+}
+
+// emissionsCheck estimates the emissions of the aircraft type over the
+// plan's distance
+func (s *APIBridgeServer) emissionsCheck(aircraftType string, distanceKm float64) *PlanCheck {
+	report, err := s.mockProvider.sustainabilityAPI.CompareAircraft([]string{aircraftType}, distanceKm)
+	if err != nil {
+		return &PlanCheck{Status: CheckUnavailable, Error: err.Error()}
+	}
+	return &PlanCheck{Status: CheckOK, Details: report.Aircraft[0]}
+}
+
+// routeRiskChecks summarises the risk of every leg and the airspace events
+// along them. Closures and restrictions mean the flight should not go;
+// advisories and elevated risk call for caution.
+func routeRiskChecks(risks []*RouteRisk) (riskCheck, airspaceCheck *PlanCheck) {
+	summary := &RouteRiskSummary{HighRiskCountries: []string{}, Unassessed: []string{}, Legs: risks}
+	airspace := &AirspaceSummary{NoFlyZones: []string{}, Events: []AirspaceEvent{}}
+	highRisk := make(map[string]int)
+	var weighted, distance float64
+	for _, risk := range risks {
+		summary.MaxRisk = max(summary.MaxRisk, risk.MaxRisk)
+		weighted += risk.AverageRisk * risk.DistanceKm
+		distance += risk.DistanceKm
+		for country, countryRisk := range risk.Countries {
+			if countryRisk.RiskLevel >= riskCautionLevel {
+				highRisk[country] = countryRisk.RiskLevel
+			}
+		}
+		summary.Unassessed = appendMissing(summary.Unassessed, risk.Unassessed...)
+		airspace.NoFlyZones = appendMissing(airspace.NoFlyZones, risk.FlaggedCountries...)
+		airspace.Events = append(airspace.Events, risk.AirspaceEvents...)
+	}
+	if distance > 0 {
+		summary.AverageRisk = math.Round(weighted/distance*100) / 100
+	}
+	for country := range highRisk {
+		summary.HighRiskCountries = append(summary.HighRiskCountries, country)
+	}
+	sort.Strings(summary.HighRiskCountries)
+
+	riskCheck = &PlanCheck{Status: CheckOK, Details: summary}
+	for _, country := range summary.HighRiskCountries {
+		status := CheckCaution
+		if highRisk[country] >= riskNoGoLevel {
+			status = CheckNoGo
+		}
+		riskCheck.Status = worseCheck(riskCheck.Status, status)
+		riskCheck.Reasons = append(riskCheck.Reasons, fmt.Sprintf("route crosses %s at risk level %d", country, highRisk[country]))
+	}
+
+	airspaceCheck = &PlanCheck{Status: CheckOK, Details: airspace}
+	for _, country := range airspace.NoFlyZones {
+		airspaceCheck.Status = CheckNoGo
+		airspaceCheck.Reasons = append(airspaceCheck.Reasons, fmt.Sprintf("airspace closed or restricted over %s", country))
+	}
+	for _, event := range airspace.Events {
+		if event.EventType == clients.AirspaceAdvisory {
+			airspaceCheck.Status = worseCheck(airspaceCheck.Status, CheckCaution)
+			airspaceCheck.Reasons = appendMissing(airspaceCheck.Reasons, fmt.Sprintf("airspace advisory for %s", event.CountryISO2))
+		}
+	}
+	return riskCheck, airspaceCheck
+}
+
+// checkSeverity orders check statuses; an unavailable check calls for
+// caution, as nothing is known about it
+var checkSeverity = map[CheckStatus]int{CheckOK: 0, CheckCaution: 1, CheckUnavailable: 1, CheckNoGo: 2}
+
+// worseCheck returns the more severe of two statuses
+func worseCheck(a, b CheckStatus) CheckStatus {
+	if checkSeverity[b] > checkSeverity[a] {
+		return b
+	}
+	return a
+}
+
+// flightPlanVerdict combines checks, by name, into a verdict: No-Go when any
+// says not to go, Caution when any calls for caution or is unavailable,
+// otherwise Go. The reasons are those of every check that is not ok.
+func flightPlanVerdict(checks map[string]*PlanCheck) (FlightPlanVerdict, []string) {
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	worst := CheckOK
+	reasons := []string{}
+	for _, name := range names {
+		check := checks[name]
+		worst = worseCheck(worst, check.Status)
+		if check.Status == CheckUnavailable {
+			reasons = append(reasons, fmt.Sprintf("%s unavailable: %s", name, check.Error))
+		}
+		reasons = append(reasons, check.Reasons...)
+	}
+	switch worst {
+	case CheckNoGo:
+		return VerdictNoGo, reasons
+	case CheckCaution, CheckUnavailable:
+		return VerdictCaution, reasons
+	}
+	return VerdictGo, reasons
+}
+
+// evaluateFlightPlan evaluates the flight plan in the request body,
+// responding 400 with the problems by field when it is invalid
+func (s *APIBridgeServer) evaluateFlightPlan(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("received request to evaluate flight plan")
+
+	var plan FlightPlan
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFlightPlanBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&plan); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("flight plan must be at most %d bytes", maxFlightPlanBytes))
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid flight plan JSON: %v", err))
+		return
+	}
+
+	now := time.Now()
+	validated, problems := plan.validate(now)
+	if problems != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": "invalid flight plan", "fields": problems})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), flightPlanTimeout)
+	defer cancel()
+	eval := s.checkFlightPlan(ctx, validated, now)
+	logFor(r.Context()).Info("evaluated flight plan", "origin", eval.Origin, "destination", eval.Destination, "verdict", eval.Verdict)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(eval); err != nil {
+		logFor(r.Context()).Error("error encoding flight plan evaluation to JSON", "error", err)
+	}
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// Retention of snapshot history unless -history-max-age and
+	// -history-max-count are set
+	defaultHistoryMaxAge   = 7 * 24 * time.Hour
+	defaultHistoryMaxCount = 10000
+	// historyPruneInterval is how often snapshots past retention are removed
+	historyPruneInterval = 10 * time.Minute
+	// historyQueueSize is how many snapshots may wait to be written before
+	// new ones are dropped
+	historyQueueSize = 64
+	// historyWriteTimeout bounds writing one snapshot or one pruning pass
+	historyWriteTimeout = 10 * time.Second
+	// Limits of the limit parameter of /flight-environment/history
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// ErrSnapshotNotFound is returned when no snapshot is stored under an ID
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// HistoryEntry describes a stored flight environment snapshot
+type HistoryEntry struct {
+	ID        string            `json:"id"`
+	Provider  string            `json:"provider"`
+	Params    map[string]string `json:"params"` // Query parameters, without provider and refresh
+	Timestamp time.Time         `json:"timestamp"`
+	Size      int               `json:"size_bytes"` // Of the JSON data
+}
+
+// HistoryQuery selects stored snapshots. Zero fields match anything.
+type HistoryQuery struct {
+	From     time.Time // Inclusive
+	To       time.Time // Inclusive
+	Provider string
+	Limit    int // Newest first
+}
+
+// HistoryStore persists flight environment snapshots. Implementations must
+// be safe for concurrent use.
+type HistoryStore interface {
+	// Save stores the JSON data of a snapshot under entry.ID
+	Save(ctx context.Context, entry HistoryEntry, data []byte) error
+	// List returns the entries matching query, newest first
+	List(ctx context.Context, query HistoryQuery) ([]HistoryEntry, error)
+	// Get returns a snapshot and its JSON data, failing with
+	// ErrSnapshotNotFound when there is none with the ID
+	Get(ctx context.Context, id string) (*HistoryEntry, []byte, error)
+	// Prune removes the snapshots taken before cutoff, then all but the
+	// newest maxCount (0 means no cap), returning how many it removed
+	Prune(ctx context.Context, cutoff time.Time, maxCount int) (int, error)
+	Close() error
+}
+
+// historyIDPattern matches snapshot IDs: the nanosecond timestamp, padded
+// so IDs sort by time, and a random suffix
+var historyIDPattern = regexp.MustCompile(`^[0-9]{20}-[0-9a-f]{8}$`)
+
+// newHistoryID creates a snapshot ID for a snapshot taken at t
+func newHistoryID(t time.Time) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%020d-%s", t.UnixNano(), hex.EncodeToString(b))
+}
+
+// validHistoryID reports whether id is a snapshot ID, and so safe to use in
+// a file name
+func validHistoryID(id string) bool {
+	return historyIDPattern.MatchString(id)
+}
+
+// openHistoryStore opens the store named by spec, file:DIR or sqlite:PATH
+func openHistoryStore(spec string) (HistoryStore, error) {
+	kind, location, ok := strings.Cut(spec, ":")
+	if !ok || location == "" {
+		return nil, fmt.Errorf("invalid history store %q: must be file:DIR or sqlite:PATH", spec)
+	}
+	switch kind {
+	case "file":
+		return newFileHistoryStore(location)
+	case "sqlite":
+		return newSQLiteHistoryStore(location)
+	default:
+		return nil, fmt.Errorf("invalid history store %q: unknown kind %q, must be file or sqlite", spec, kind)
+	}
+}
+
+// historyRetention bounds the snapshots kept in history
+type historyRetention struct {
+	maxAge   time.Duration // 0 means no limit
+	maxCount int           // 0 means no limit
+}
+
+// historySnapshot is a snapshot waiting to be written
+type historySnapshot struct {
+	entry HistoryEntry
+	data  *FlightEnvironmentData
+}
+
+// historyRecorder writes snapshots to a store in the background, so
+// recording never holds up a request, and prunes the store on a ticker
+type historyRecorder struct {
+	store     HistoryStore
+	retention historyRetention
+	queue     chan historySnapshot
+	stop      chan struct{}
+	stopOnce  sync.Once
+	done      chan struct{}
+}
+
+// newHistoryRecorder starts recording snapshots to store
+func newHistoryRecorder(store HistoryStore, retention historyRetention) *historyRecorder {
+	h := &historyRecorder{
+		store:     store,
+		retention: retention,
+		queue:     make(chan historySnapshot, historyQueueSize),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// record queues a snapshot to be written, dropping it when the queue is full
+func (h *historyRecorder) record(provider string, params map[string]string, data *FlightEnvironmentData) {
+	if h == nil {
+		return
+	}
+	stored := make(map[string]string, len(params))
+	for key, value := range params {
+		if key != "provider" && key != "refresh" {
+			stored[key] = value
+		}
+	}
+	now := time.Now().UTC()
+	snapshot := historySnapshot{
+		entry: HistoryEntry{ID: newHistoryID(now), Provider: provider, Params: stored, Timestamp: now},
+		data:  data,
+	}
+
+	select {
+	case <-h.stop:
+		return
+	default:
+	}
+	select {
+	case h.queue <- snapshot:
+	default:
+		bridgeMetrics.historySnapshots.inc("dropped")
+		slog.Warn("snapshot history queue is full, dropping snapshot", "provider", provider)
+	}
+}
+
+// run writes queued snapshots and prunes until stopped, then writes what is
+// still queued
+func (h *historyRecorder) run() {
+	defer close(h.done)
+	ticker := time.NewTicker(historyPruneInterval)
+	defer ticker.Stop()
+
+	h.prune()
+	for {
+		select {
+		case snapshot := <-h.queue:
+			h.save(snapshot)
+		case <-ticker.C:
+			h.prune()
+		case <-h.stop:
+			for {
+				select {
+				case snapshot := <-h.queue:
+					h.save(snapshot)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// save writes one snapshot
+func (h *historyRecorder) save(snapshot historySnapshot) {
+	data, err := json.Marshal(snapshot.data)
+	if err != nil {
+		bridgeMetrics.historySnapshots.inc("failed")
+		slog.Error("error encoding snapshot to JSON", "id", snapshot.entry.ID, "error", err)
+		return
+	}
+	snapshot.entry.Size = len(data)
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+	if err := h.store.Save(ctx, snapshot.entry, data); err != nil {
+		bridgeMetrics.historySnapshots.inc("failed")
+		slog.Error("error saving snapshot", "id", snapshot.entry.ID, "error", err)
+		return
+	}
+	bridgeMetrics.historySnapshots.inc("saved")
+}
+
+// prune removes the snapshots past retention
+func (h *historyRecorder) prune() {
+	if h.retention.maxAge == 0 && h.retention.maxCount == 0 {
+		return
+	}
+	var cutoff time.Time
+	if h.retention.maxAge > 0 {
+		cutoff = time.Now().Add(-h.retention.maxAge)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+	removed, err := h.store.Prune(ctx, cutoff, h.retention.maxCount)
+	if err != nil {
+		slog.Error("error pruning snapshot history", "error", err)
+		return
+	}
+	if removed > 0 {
+		slog.Info("pruned snapshot history", "removed", removed)
+	}
+}
+
+// close stops recording, writes the queued snapshots and closes the store,
+// unless ctx is done first
+func (h *historyRecorder) close(ctx context.Context) error {
+	h.stopOnce.Do(func() { close(h.stop) })
+	select {
+	case <-h.done:
+		return h.store.Close()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetHistory records every flight environment generated from now on to
+// store, keeping snapshots within retention
+func (s *APIBridgeServer) SetHistory(store HistoryStore, retention historyRetention) {
+	s.history = newHistoryRecorder(store, retention)
+}
+
+// closeHistory writes the queued snapshots and closes the history store, if
+// history is enabled
+func (s *APIBridgeServer) closeHistory(ctx context.Context) error {
+	if s.history == nil {
+		return nil
+	}
+	return s.history.close(ctx)
+}
+
+// historyStore returns the history store, writing the error response when
+// history is disabled
+func (s *APIBridgeServer) historyStore(w http.ResponseWriter) (HistoryStore, bool) {
+	if s.history == nil {
+		http.Error(w, "Error: snapshot history is disabled; set -history or BRIDGE_HISTORY", http.StatusNotImplemented)
+		return nil, false
+	}
+	return s.history.store, true
+}
+
+// HistoryList is the response of /flight-environment/history
+type HistoryList struct {
+	Snapshots []HistoryEntry `json:"snapshots"`
+	Count     int            `json:"count"`
+}
+
+// parseHistoryQuery parses the from, to, provider and limit parameters of
+// /flight-environment/history
+func parseHistoryQuery(r *http.Request) (HistoryQuery, error) {
+	params := r.URL.Query()
+	query := HistoryQuery{Provider: params.Get("provider"), Limit: defaultHistoryLimit}
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		value := params.Get(bound.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return HistoryQuery{}, fmt.Errorf("%s must be an RFC 3339 time such as 2024-01-02T15:04:05Z", bound.name)
+		}
+		*bound.t = t
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		return HistoryQuery{}, errors.New("to must not be before from")
+	}
+	if limitStr := params.Get("limit"); limitStr != "" {
+		n, err := parsePositiveInt("limit", limitStr, 1, maxHistoryLimit)
+		if err != nil {
+			return HistoryQuery{}, err
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+// getHistory lists stored snapshots, newest first, e.g.
+// /flight-environment/history?from=2024-01-02T00:00:00Z&provider=live
+func (s *APIBridgeServer) getHistory(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("received request for snapshot history")
+
+	query, err := parseHistoryQuery(r)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	store, ok := s.historyStore(w)
+	if !ok {
+		return
+	}
+
+	entries, err := store.List(r.Context(), query)
+	if err != nil {
+		logFor(r.Context()).Error("error listing snapshots", "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if entries == nil {
+		entries = []HistoryEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(HistoryList{Snapshots: entries, Count: len(entries)}); err != nil {
+		logFor(r.Context()).Error("error encoding snapshot history to JSON", "error", err)
+	}
+}
+
+// HistorySnapshot is a stored snapshot with its flight environment data
+type HistorySnapshot struct {
+	HistoryEntry
+	Data json.RawMessage `json:"data"`
+}
+
+// getHistorySnapshot serves a stored snapshot by ID
+func (s *APIBridgeServer) getHistorySnapshot(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	logFor(r.Context()).Info("received request for snapshot", "id", id)
+
+	store, ok := s.historyStore(w)
+	if !ok {
+		return
+	}
+	if !validHistoryID(id) {
+		http.Error(w, fmt.Sprintf("Error: %v", ErrSnapshotNotFound), http.StatusNotFound)
+		return
+	}
+
+	entry, data, err := store.Get(r.Context(), id)
+	if errors.Is(err, ErrSnapshotNotFound) {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logFor(r.Context()).Error("error getting snapshot", "id", id, "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(HistorySnapshot{HistoryEntry: *entry, Data: data}); err != nil {
+		logFor(r.Context()).Error("error encoding snapshot to JSON", "error", err)
+	}
+}
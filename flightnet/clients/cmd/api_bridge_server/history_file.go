@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fileHistoryStore keeps each snapshot in a directory as two files, the
+// data in ID.json and its entry in ID.meta.json. Entries are indexed in
+// memory when the store opens, so listing never reads the data.
+type fileHistoryStore struct {
+	dir string
+
+	mu      sync.RWMutex
+	entries []HistoryEntry // Oldest first
+}
+
+// metaSuffix ends the name of the file holding a snapshot's entry
+const metaSuffix = ".meta.json"
+
+// newFileHistoryStore opens the store in dir, creating dir if needed
+func newFileHistoryStore(dir string) (*fileHistoryStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating history directory: %w", err)
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "*"+metaSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("listing history directory: %w", err)
+	}
+
+	s := &fileHistoryStore{dir: dir}
+	for _, name := range names {
+		raw, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot entry: %w", err)
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(raw, &entry); err != nil || !validHistoryID(entry.ID) {
+			// Left by a write that was interrupted, or not ours
+			continue
+		}
+		s.entries = append(s.entries, entry)
+	}
+	sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].ID < s.entries[j].ID })
+	return s, nil
+}
+
+func (s *fileHistoryStore) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func (s *fileHistoryStore) metaPath(id string) string {
+	return filepath.Join(s.dir, id+metaSuffix)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to name,
+// so readers never see a partial file
+func writeFileAtomic(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// Save writes the data before the entry, so an indexed entry always has data
+func (s *fileHistoryStore) Save(ctx context.Context, entry HistoryEntry, data []byte) error {
+	if !validHistoryID(entry.ID) {
+		return fmt.Errorf("invalid snapshot ID %q", entry.ID)
+	}
+	meta, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encoding snapshot entry: %w", err)
+	}
+	if err := writeFileAtomic(s.dataPath(entry.ID), data); err != nil {
+		return fmt.Errorf("writing snapshot: %w", err)
+	}
+	if err := writeFileAtomic(s.metaPath(entry.ID), meta); err != nil {
+		os.Remove(s.dataPath(entry.ID))
+		return fmt.Errorf("writing snapshot entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].ID >= entry.ID })
+	s.entries = append(s.entries, HistoryEntry{})
+	copy(s.entries[i+1:], s.entries[i:])
+	s.entries[i] = entry
+	return nil
+}
+
+func (s *fileHistoryStore) List(ctx context.Context, query HistoryQuery) ([]HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var entries []HistoryEntry
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		entry := s.entries[i]
+		if !query.To.IsZero() && entry.Timestamp.After(query.To) {
+			continue
+		}
+		if !query.From.IsZero() && entry.Timestamp.Before(query.From) {
+			break
+		}
+		if query.Provider != "" && entry.Provider != query.Provider {
+			continue
+		}
+		entries = append(entries, entry)
+		if query.Limit > 0 && len(entries) == query.Limit {
+			break
+		}
+	}
+	return entries, nil
+}
+
+func (s *fileHistoryStore) Get(ctx context.Context, id string) (*HistoryEntry, []byte, error) {
+	s.mu.RLock()
+	i := sort.Search(len(s.entries), func(i int) bool { return s.entries[i].ID >= id })
+	if i == len(s.entries) || s.entries[i].ID != id {
+		s.mu.RUnlock()
+		return nil, nil, ErrSnapshotNotFound
+	}
+	entry := s.entries[i]
+	s.mu.RUnlock()
+
+	data, err := os.ReadFile(s.dataPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		// Pruned since it was looked up
+		return nil, nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading snapshot: %w", err)
+	}
+	return &entry, data, nil
+}
+
+func (s *fileHistoryStore) Prune(ctx context.Context, cutoff time.Time, maxCount int) (int, error) {
+	s.mu.Lock()
+	n := sort.Search(len(s.entries), func(i int) bool { return !s.entries[i].Timestamp.Before(cutoff) })
+	if maxCount > 0 && len(s.entries)-n > maxCount {
+		n = len(s.entries) - maxCount
+	}
+	removed := append([]HistoryEntry(nil), s.entries[:n]...)
+	s.entries = append(s.entries[:0:0], s.entries[n:]...)
+	s.mu.Unlock()
+
+	var failed []string
+	for _, entry := range removed {
+		// The entry goes first, so an interrupted prune leaves no entry
+		// without data
+		for _, name := range []string{s.metaPath(entry.ID), s.dataPath(entry.ID)} {
+			if err := os.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				failed = append(failed, entry.ID)
+				break
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return len(removed) - len(failed), fmt.Errorf("removing snapshots %s", strings.Join(failed, ", "))
+	}
+	return len(removed), nil
+}
+
+func (s *fileHistoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqliteHistorySchema creates the snapshots table, indexed by time for
+// listing and pruning
+const sqliteHistorySchema = `
+CREATE TABLE IF NOT EXISTS snapshots (
+	id         TEXT PRIMARY KEY,
+	provider   TEXT NOT NULL,
+	params     TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	size       INTEGER NOT NULL,
+	data       BLOB NOT NULL
+);
+CREATE INDEX IF NOT EXISTS snapshots_created_at ON snapshots (created_at);`
+
+// sqliteHistoryStore keeps snapshots in a SQLite database, with timestamps
+// as Unix nanoseconds
+type sqliteHistoryStore struct {
+	db *sql.DB
+}
+
+// newSQLiteHistoryStore opens the database at path, creating it if needed
+func newSQLiteHistoryStore(path string) (*sqliteHistoryStore, error) {
+	dsn := "file:" + (&url.URL{Path: path}).EscapedPath() + "?_busy_timeout=5000&_journal_mode=WAL"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening history database: %w", err)
+	}
+	// SQLite allows one writer, so queue writes here rather than fail them
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(sqliteHistorySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating history schema: %w", err)
+	}
+	return &sqliteHistoryStore{db: db}, nil
+}
+
+func (s *sqliteHistoryStore) Save(ctx context.Context, entry HistoryEntry, data []byte) error {
+	params, err := json.Marshal(entry.Params)
+	if err != nil {
+		return fmt.Errorf("encoding snapshot parameters: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO snapshots (id, provider, params, created_at, size, data) VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.Provider, string(params), entry.Timestamp.UnixNano(), entry.Size, data)
+	if err != nil {
+		return fmt.Errorf("inserting snapshot: %w", err)
+	}
+	return nil
+}
+
+// scanHistoryEntry reads the id, provider, params, created_at and size
+// columns of a row
+func scanHistoryEntry(scan func(dest ...interface{}) error) (HistoryEntry, error) {
+	var entry HistoryEntry
+	var params string
+	var createdAt int64
+	if err := scan(&entry.ID, &entry.Provider, &params, &createdAt, &entry.Size); err != nil {
+		return HistoryEntry{}, err
+	}
+	if err := json.Unmarshal([]byte(params), &entry.Params); err != nil {
+		return HistoryEntry{}, fmt.Errorf("decoding snapshot parameters: %w", err)
+	}
+	entry.Timestamp = time.Unix(0, createdAt).UTC()
+	return entry, nil
+}
+
+func (s *sqliteHistoryStore) List(ctx context.Context, query HistoryQuery) ([]HistoryEntry, error) {
+	var conditions []string
+	var args []interface{}
+	if !query.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, query.From.UnixNano())
+	}
+	if !query.To.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, query.To.UnixNano())
+	}
+	if query.Provider != "" {
+		conditions = append(conditions, "provider = ?")
+		args = append(args, query.Provider)
+	}
+	statement := "SELECT id, provider, params, created_at, size FROM snapshots"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY created_at DESC, id DESC"
+	if query.Limit > 0 {
+		statement += " LIMIT ?"
+		args = append(args, query.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	defer rows.Close()
+	var entries []HistoryEntry
+	for rows.Next() {
+		entry, err := scanHistoryEntry(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("listing snapshots: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing snapshots: %w", err)
+	}
+	return entries, nil
+}
+
+func (s *sqliteHistoryStore) Get(ctx context.Context, id string) (*HistoryEntry, []byte, error) {
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, provider, params, created_at, size, data FROM snapshots WHERE id = ?`, id)
+	var data []byte
+	entry, err := scanHistoryEntry(func(dest ...interface{}) error {
+		return row.Scan(append(dest, &data)...)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting snapshot: %w", err)
+	}
+	return &entry, data, nil
+}
+
+func (s *sqliteHistoryStore) Prune(ctx context.Context, cutoff time.Time, maxCount int) (int, error) {
+	var removed int64
+	if !cutoff.IsZero() {
+		result, err := s.db.ExecContext(ctx, `DELETE FROM snapshots WHERE created_at < ?`, cutoff.UnixNano())
+		if err != nil {
+			return 0, fmt.Errorf("pruning snapshots: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += n
+	}
+	if maxCount > 0 {
+		result, err := s.db.ExecContext(ctx,
+			`DELETE FROM snapshots WHERE id NOT IN (SELECT id FROM snapshots ORDER BY created_at DESC, id DESC LIMIT ?)`, maxCount)
+		if err != nil {
+			return int(removed), fmt.Errorf("pruning snapshots: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += n
+	}
+	return int(removed), nil
+}
+
+func (s *sqliteHistoryStore) Close() error {
+	return s.db.Close()
+}
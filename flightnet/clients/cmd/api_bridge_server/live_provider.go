@@ -0,0 +1,744 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// errLiveUnavailable is returned by the live provider when it has no usable
+// key for aviation-edge, the source of its aircraft and flights
+var errLiveUnavailable = errors.New("live data unavailable: no usable AVIATION_EDGE_API_KEY")
+
+// liveSectionSources names the upstream API behind each section
+var liveSectionSources = map[string]string{
+	sectionAircraft:       "aviation-edge",
+	sectionFlights:        "aviation-edge",
+	sectionWeather:        "aviation-edge",
+	sectionNOTAMs:         "faa-notam",
+	sectionNews:           "newsapi",
+	sectionGeopolitical:   "world-bank",
+	sectionSustainability: "fuel-api",
+	sectionRouteWeather:   "aviation-edge",
+}
+
+// LiveProvider fetches flight environment data from the upstream APIs through
+// the clients package
+type LiveProvider struct {
+	fetcher           *clients.Fetcher
+	metrics           *clients.InMemoryMetrics
+	aircraftAPI       *clients.AircraftAPI
+	flightsAPI        *clients.FlightsAPI
+	weatherAPI        *clients.WeatherAPI
+	newsAPI           *clients.NewsAPI
+	geopoliticalAPI   *clients.GeopoliticalAPI
+	sustainabilityAPI *clients.SustainabilityAPI
+	notamAPI          *clients.NOTAMAPI
+	airportsAPI       *clients.AirportsAPI
+}
+
+// NewLiveProvider creates a new provider with real API clients
+func NewLiveProvider() *LiveProvider {
+	return NewLiveProviderWithFetcher(clients.NewFetcher())
+}
+
+// NewLiveProviderWithFetcher creates a live provider whose clients share
+// fetcher, which gets a metrics hook for the /metrics endpoint
+func NewLiveProviderWithFetcher(fetcher *clients.Fetcher) *LiveProvider {
+	metrics := clients.NewInMemoryMetrics()
+	fetcher.SetMetricsHook(metrics)
+	return &LiveProvider{
+		fetcher:           fetcher,
+		metrics:           metrics,
+		aircraftAPI:       clients.NewAircraftAPIWithFetcher(fetcher),
+		flightsAPI:        clients.NewFlightsAPIWithFetcher(fetcher),
+		weatherAPI:        clients.NewWeatherAPIWithFetcher(fetcher),
+		newsAPI:           clients.NewNewsAPIWithFetcher(fetcher),
+		geopoliticalAPI:   clients.NewGeopoliticalAPIWithFetcher(fetcher),
+		sustainabilityAPI: clients.NewSustainabilityAPIWithFetcher(fetcher),
+		notamAPI:          clients.NewNOTAMAPIWithFetcher(fetcher),
+		airportsAPI:       clients.NewAirportsAPIWithFetcher(fetcher),
+	}
+}
+
+// Name returns the provider name
+func (p *LiveProvider) Name() string {
+	return "live"
+}
+
+// Ping reports whether aviation-edge has a mock or a key that is neither
+// rejected nor out of quota. It makes no upstream request.
+func (p *LiveProvider) Ping() bool {
+	if p.fetcher.HasMock("aviation-edge") {
+		return true
+	}
+	keys, ok := p.fetcher.KeyStatus("aviation-edge")
+	if !ok {
+		return false
+	}
+	for _, key := range keys {
+		if !key.Exhausted {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingCredentials names the aviation-edge key when none is configured,
+// as opposed to configured keys that were rejected or ran out of quota
+func (p *LiveProvider) MissingCredentials() []string {
+	if p.fetcher.HasMock("aviation-edge") {
+		return nil
+	}
+	if keys, ok := p.fetcher.KeyStatus("aviation-edge"); ok && len(keys) > 0 {
+		return nil
+	}
+	return []string{"AVIATION_EDGE_API_KEY"}
+}
+
+// Airports returns the AirportsAPI routes are resolved against, which asks
+// the aviation-edge airport database before the embedded dataset
+func (p *LiveProvider) Airports() *clients.AirportsAPI {
+	return p.airportsAPI
+}
+
+// UpstreamMetrics reports the requests made to each upstream API
+func (p *LiveProvider) UpstreamMetrics() interface{} {
+	return p.metrics.Summary()
+}
+
+// Capabilities declares which parts of the DataProvider contract the provider supports
+func (p *LiveProvider) Capabilities() ProviderCapabilities {
+	return ProviderCapabilities{
+		Cancellation: true,
+		// aviation-edge may return fewer aircraft than asked for
+		ExactAircraftCount:  false,
+		ConcurrentSafe:      true,
+		RequiresCredentials: true,
+	}
+}
+
+// limitParams asks an upstream list for up to limit entries. A limit of
+// zero or less is left out rather than sent upstream, and the caller trims
+// the results instead.
+func limitParams(limit int) map[string]string {
+	params := map[string]string{}
+	if limit > 0 {
+		params["limit"] = strconv.Itoa(limit)
+	}
+	return params
+}
+
+// GetAircraft returns up to limit aircraft from the aviation-edge database
+func (p *LiveProvider) GetAircraft(ctx context.Context, limit int) ([]Aircraft, error) {
+	if !p.Ping() {
+		return nil, errLiveUnavailable
+	}
+	aircraft, err := p.aircraftAPI.GetAircraftContext(ctx, limitParams(limit))
+	if err != nil {
+		return nil, err
+	}
+	converted := []Aircraft{}
+	for i, a := range aircraft {
+		if i == limit {
+			break
+		}
+		converted = append(converted, liveAircraft(a))
+	}
+	return converted, nil
+}
+
+// GetFlights returns the live flights from dep to arr, or from or to one of
+// them
+func (p *LiveProvider) GetFlights(ctx context.Context, dep, arr string) ([]Flight, error) {
+	if !p.Ping() {
+		return nil, errLiveUnavailable
+	}
+	flights, err := p.flightsAPI.QueryFlightsContext(ctx, clients.FlightQuery{DepIATA: dep, ArrIATA: arr})
+	if err != nil {
+		return nil, err
+	}
+	converted := []Flight{}
+	for _, f := range flights {
+		// Drop records for other airports, such as unfiltered responses
+		if (dep != "" && !strings.EqualFold(f.Departure.IataCode, dep)) || (arr != "" && !strings.EqualFold(f.Arrival.IataCode, arr)) {
+			continue
+		}
+		converted = append(converted, p.liveFlight(ctx, f))
+	}
+	return converted, nil
+}
+
+// GetWeather returns the current weather at an airport, logging when the
+// clients fell back to synthetic data
+func (p *LiveProvider) GetWeather(ctx context.Context, airport string) (*WeatherData, error) {
+	weather, err := p.weatherAPI.GetCurrentWeatherContext(ctx, airport)
+	if err != nil {
+		return nil, err
+	}
+	if weather.Synthetic {
+		logFor(ctx).Warn("serving synthetic weather", "provider", p.Name(), "airport", airport)
+	}
+	return liveWeather(weather), nil
+}
+
+// GetCountryRisk returns the live risk assessment of a country
+func (p *LiveProvider) GetCountryRisk(ctx context.Context, country string) (*GeopoliticalRisk, error) {
+	risk, err := p.geopoliticalAPI.GetCountryRiskContext(ctx, country)
+	if errors.Is(err, clients.ErrUnknownCountry) {
+		return nil, fmt.Errorf("%s: %w", country, ErrCountryNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return liveRisk(risk), nil
+}
+
+// GetNews returns the live geopolitical news for topics
+func (p *LiveProvider) GetNews(ctx context.Context, topics []string) (*NewsResponse, error) {
+	news, err := p.newsAPI.GetGeopoliticalNewsContext(ctx, topics)
+	if err != nil {
+		return nil, err
+	}
+	return liveNews(news, strings.Join(topics, ",")), nil
+}
+
+// GetFlightEnvironment retrieves flight environment data from the upstream
+// APIs. It fails with errLiveUnavailable when there is no aviation-edge key,
+// rather than returning mock data. Other sections that cannot be fetched, or
+// that the clients filled with synthetic fallback data, are listed in
+// Degraded. The sections are fetched concurrently, so a request takes about
+// as long as the slowest of them.
+func (p *LiveProvider) GetFlightEnvironment(ctx context.Context, params map[string]string) (*FlightEnvironmentData, error) {
+	if !p.Ping() {
+		return nil, errLiveUnavailable
+	}
+
+	logger := logFor(ctx).With("provider", p.Name())
+	legs := routeLegs(p.airportsAPI, params, logger)
+	hasRoute := len(legs) > 0
+	lists := resolveEnvironmentLists(params, legs, logger)
+	count := defaultAircraftCount
+	if c, err := strconv.Atoi(params["aircraft_count"]); err == nil {
+		count = c
+	}
+
+	envData := &FlightEnvironmentData{
+		Weather:        make(map[string]*WeatherData),
+		Geopolitical:   make(map[string]*GeopoliticalRisk),
+		Sustainability: make(map[string]*SustainabilityData),
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+	}
+
+	// The sections are fetched concurrently and write into envData under mu
+	var mu sync.Mutex
+	sections := newSectionTracker(p.Name())
+	start := func(section string) func(err error) {
+		done := sections.start(section, liveSectionSources[section])
+		return func(err error) {
+			if err != nil {
+				logFor(ctx).Error("error fetching section", "provider", p.Name(), "section", section, "error", err)
+			}
+			done(err)
+		}
+	}
+	degrade := func(section string, err error) {
+		logFor(ctx).Warn("section degraded", "provider", p.Name(), "section", section, "error", err)
+		sections.degrade(section, err)
+	}
+
+	err := fetchConcurrently(ctx,
+		// Aircraft
+		func(ctx context.Context) {
+			done := start(sectionAircraft)
+			aircraft, err := p.aircraftAPI.GetAircraftContext(ctx, limitParams(count))
+			done(err)
+			if err != nil {
+				return
+			}
+			var converted []Aircraft
+			for i, a := range aircraft {
+				if count >= 0 && i == count {
+					break
+				}
+				converted = append(converted, liveAircraft(a))
+			}
+			mu.Lock()
+			envData.Aircraft = converted
+			mu.Unlock()
+		},
+		// Flights, restricted to the requested route when there is one, and
+		// their emissions when asked for
+		func(ctx context.Context) {
+			done := start(sectionFlights)
+			var flights []clients.Flight
+			var err error
+			if hasRoute {
+				for _, leg := range legs {
+					var legFlights []clients.Flight
+					if legFlights, err = p.flightsAPI.GetFlightsByRouteContext(ctx, leg.Origin, leg.Destination); err != nil {
+						break
+					}
+					flights = append(flights, legFlights...)
+				}
+			} else {
+				flights, err = p.flightsAPI.GetFlightsContext(ctx, limitParams(count))
+			}
+			done(err)
+			if err != nil {
+				return
+			}
+			if count >= 0 && len(flights) > count {
+				flights = flights[:count]
+			}
+			var converted []Flight
+			for _, f := range flights {
+				converted = append(converted, p.liveFlight(ctx, f))
+			}
+			mu.Lock()
+			envData.Flights = converted
+			mu.Unlock()
+
+			if !includes(params, "emissions_report") {
+				return
+			}
+			done = start(sectionEmissionsReport)
+			report, err := p.sustainabilityAPI.GetFleetEmissionsReportContext(ctx, flights)
+			done(err)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			envData.EmissionsReport = liveEmissionsReport(report)
+			mu.Unlock()
+		},
+		// Weather for the requested airports
+		func(ctx context.Context) {
+			done := start(sectionWeather)
+			weather, failures, err := p.weatherAPI.GetMultipleAirportsWeatherContext(ctx, lists.airports)
+			if err != nil {
+				done(err)
+				return
+			}
+			for airport, err := range failures {
+				degrade(sectionWeather, fmt.Errorf("%s: %w", airport, err))
+			}
+			for airport, w := range weather {
+				if w.Synthetic {
+					degrade(sectionWeather, fmt.Errorf("%s: synthetic fallback data", airport))
+				}
+				mu.Lock()
+				envData.Weather[airport] = liveWeather(w)
+				mu.Unlock()
+			}
+			done(nil)
+		},
+		// NOTAMs for the same airports
+		func(ctx context.Context) {
+			done := start(sectionNOTAMs)
+			notams, err := p.notamAPI.GetNOTAMsForAirportsContext(ctx, icaoLocations(lists.airports))
+			done(err)
+			if err != nil {
+				return
+			}
+			var converted []NOTAM
+			for _, n := range notams {
+				converted = append(converted, NOTAM(n))
+			}
+			mu.Lock()
+			envData.NOTAMs = converted
+			mu.Unlock()
+		},
+		// News along the route, or for the default topics without one, and
+		// the airspace events reported in it
+		func(ctx context.Context) {
+			done := start(sectionNews)
+			var news *NewsResponse
+			var routeNews map[string]*NewsResponse
+			var events []AirspaceEvent
+			if hasRoute {
+				upstream := make(map[string]*clients.NewsResponse)
+				for _, leg := range legs {
+					byCountry, err := p.newsAPI.GetNewsForRouteContext(ctx, leg.Origin, leg.Destination)
+					if err != nil {
+						done(err)
+						return
+					}
+					legNews := make(map[string]*NewsResponse, len(byCountry))
+					for country, countryNews := range byCountry {
+						legNews[country] = liveNews(countryNews, country)
+						if upstream[country] == nil {
+							upstream[country] = countryNews
+						}
+					}
+					routeNews = mergeRouteNews(routeNews, legNews)
+				}
+				done(nil)
+				news = combineRouteNews(routeNews)
+				byCountry := make([]*clients.NewsResponse, 0, len(upstream))
+				for _, country := range sortedKeys(upstream) {
+					byCountry = append(byCountry, upstream[country])
+				}
+				events = liveAirspaceEvents(byCountry...)
+			} else {
+				topicNews, err := p.newsAPI.GetGeopoliticalNewsContext(ctx, lists.topics)
+				done(err)
+				if err != nil {
+					return
+				}
+				news = liveNews(topicNews, strings.Join(lists.topics, ","))
+				events = liveAirspaceEvents(topicNews)
+			}
+			mu.Lock()
+			envData.RouteNews = routeNews
+			envData.News = news
+			envData.AirspaceEvents = events
+			mu.Unlock()
+		},
+		// Geopolitical risk, which takes several requests per country, so the
+		// countries are assessed concurrently too
+		func(ctx context.Context) {
+			done := start(sectionGeopolitical)
+			assessed := 0
+			fetches := make([]func(ctx context.Context), 0, len(lists.countries))
+			for _, country := range lists.countries {
+				fetches = append(fetches, func(ctx context.Context) {
+					risk, err := p.geopoliticalAPI.GetCountryRiskContext(ctx, country)
+					if ctx.Err() != nil {
+						return
+					}
+					if err != nil {
+						degrade(sectionGeopolitical, fmt.Errorf("%s: %w", country, err))
+						return
+					}
+					mu.Lock()
+					envData.Geopolitical[country] = liveRisk(risk)
+					assessed++
+					mu.Unlock()
+				})
+			}
+			if err := fetchConcurrently(ctx, fetches...); err != nil {
+				done(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if assessed == 0 && len(lists.countries) > 0 {
+				done(errors.New("no country risk could be assessed"))
+				return
+			}
+			done(nil)
+		},
+		// Sustainability of each leg of the route
+		func(ctx context.Context) {
+			if !hasRoute {
+				sections.skip(sectionSustainability, liveSectionSources[sectionSustainability])
+				return
+			}
+			done := start(sectionSustainability)
+			saf, _ := parseSAFParam(params["saf"])
+			for _, leg := range legs {
+				data, err := p.sustainabilityAPI.GetRouteEmissionsWithFuelBlendContext(ctx, leg.Origin, leg.Destination, clients.FuelBlend{SAFPercent: saf})
+				if err != nil {
+					done(err)
+					return
+				}
+				if data.Synthetic {
+					degrade(sectionSustainability, fmt.Errorf("%s: synthetic fallback data", leg))
+				}
+				// Radiative forcing is applied unless radiative_forcing=false
+				converted := p.liveSustainability(data, params["radiative_forcing"] != "false")
+				mu.Lock()
+				envData.Sustainability[leg.String()] = converted
+				mu.Unlock()
+			}
+			done(nil)
+		},
+		// Weather along each leg of the route
+		func(ctx context.Context) {
+			if !hasRoute {
+				sections.skip(sectionRouteWeather, liveSectionSources[sectionRouteWeather])
+				return
+			}
+			done := start(sectionRouteWeather)
+			var routeWeather []RouteWeatherPoint
+			byLeg := make(map[string][]RouteWeatherPoint, len(legs))
+			for _, leg := range legs {
+				points, err := p.weatherAPI.GetRouteWeatherContext(ctx, leg.Origin, leg.Destination, defaultRouteWeatherSamples)
+				if err != nil {
+					done(err)
+					return
+				}
+				var converted []RouteWeatherPoint
+				for _, point := range points {
+					entry := RouteWeatherPoint{Lat: point.Lat, Lon: point.Lon, NearestStation: point.NearestStation, StationDistanceKm: point.StationDistanceKm}
+					if point.Weather != nil {
+						entry.Weather = liveWeather(point.Weather)
+					}
+					converted = append(converted, entry)
+				}
+				routeWeather = append(routeWeather, converted...)
+				byLeg[leg.String()] = converted
+			}
+			done(nil)
+			mu.Lock()
+			envData.RouteWeather = routeWeather
+			envData.RouteWeatherByLeg = byLeg
+			mu.Unlock()
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	deriveNoFlyZones(envData)
+	sections.finish(envData)
+	return envData, nil
+}
+
+// liveAircraft converts an aviation-edge aircraft database record. The
+// database has no position, so Location and the flight state are left zero.
+func liveAircraft(a clients.Aircraft) Aircraft {
+	return Aircraft{
+		ID:           firstNonEmpty(a.AirplaneID, a.HexIcaoAirplane),
+		Type:         a.AirplaneIataType,
+		Manufacturer: a.ProductionLine,
+		Model:        firstNonEmpty(a.PlaneModel, a.ModelCode),
+		Registration: a.NumberRegistration,
+		Status:       a.PlaneStatus,
+	}
+}
+
+// liveFlight converts an aviation-edge flight, with the distance between its
+// airports and its scheduled duration when they are known
+func (p *LiveProvider) liveFlight(ctx context.Context, f clients.Flight) Flight {
+	flight := Flight{
+		FlightNumber:  firstNonEmpty(f.Flight.IataNumber, f.Flight.IcaoNumber, f.Flight.Number),
+		Airline:       firstNonEmpty(f.Airline.Name, f.Airline.IataCode, f.Airline.IcaoCode),
+		Origin:        firstNonEmpty(f.Departure.IataCode, f.Departure.IcaoCode),
+		Destination:   firstNonEmpty(f.Arrival.IataCode, f.Arrival.IcaoCode),
+		DepartureTime: f.ScheduledDeparture,
+		ArrivalTime:   f.ScheduledArrival,
+		Status:        f.Status,
+		StatusCode:    f.StatusCode,
+		Aircraft:      f.Aircraft.RegNumber,
+		Gate:          f.Departure.Gate,
+		Position:      GeoPoint{Latitude: f.Geography.Latitude, Longitude: f.Geography.Longitude},
+	}
+	if distance, err := p.airportsAPI.GetRouteDistanceContext(ctx, flight.Origin, flight.Destination); err == nil {
+		flight.Distance = int(math.Round(distance))
+	}
+	if !f.TimesUnreliable && f.ScheduledArrival.After(f.ScheduledDeparture) {
+		flight.Duration = int(f.ScheduledArrival.Sub(f.ScheduledDeparture).Minutes())
+	}
+	return flight
+}
+
+// liveWeather converts current weather, with wind speeds in km/h
+func liveWeather(w *clients.WeatherData) *WeatherData {
+	current := w.CurrentWeather
+	return &WeatherData{
+		Location:       firstNonEmpty(w.AirportIATA, w.AirportICAO),
+		Temperature:    current.Temperature.Celsius,
+		WindSpeed:      math.Round(windKph(current.Wind.Speed, current.Wind.Unit)*10) / 10,
+		WindDirection:  current.Wind.Direction,
+		Conditions:     current.Conditions,
+		Visibility:     current.Visibility.Meters / 1000,
+		Pressure:       current.Pressure.HPa,
+		Humidity:       int(math.Round(current.Humidity)),
+		FlightCategory: strings.ToUpper(current.WeatherCategory),
+		Updated:        w.LastUpdated,
+		ObservedAt:     w.ObservedAt,
+	}
+}
+
+// windKph converts a wind speed in knots, metres per second or km/h to km/h
+func windKph(speed float64, unit string) float64 {
+	switch strings.ToLower(unit) {
+	case "mps", "m/s":
+		return speed * 3.6
+	case "kmh", "kph", "km/h":
+		return speed
+	default: // Knots, the METAR default
+		return speed * 1.852
+	}
+}
+
+// liveNews converts a NewsAPI response
+func liveNews(news *clients.NewsResponse, query string) *NewsResponse {
+	response := &NewsResponse{Articles: []NewsArticle{}, Query: query}
+	for _, article := range news.Articles {
+		response.Articles = append(response.Articles, liveArticle(article))
+	}
+	response.Count = len(response.Articles)
+	return response
+}
+
+// liveArticle converts a NewsAPI article
+func liveArticle(article clients.NewsArticle) NewsArticle {
+	return NewsArticle{
+		Source:      article.Source.Name,
+		Title:       article.Title,
+		Description: article.Description,
+		URL:         article.URL,
+		PublishedAt: article.PublishedAt,
+	}
+}
+
+// liveAirspaceEvents extracts the airspace events in NewsAPI responses,
+// reading an article that several of them carry once
+func liveAirspaceEvents(responses ...*clients.NewsResponse) []AirspaceEvent {
+	combined := &clients.NewsResponse{}
+	seen := make(map[string]bool)
+	for _, news := range responses {
+		for _, article := range news.Articles {
+			if !seen[article.URL] {
+				seen[article.URL] = true
+				combined.Articles = append(combined.Articles, article)
+			}
+		}
+	}
+
+	var events []AirspaceEvent
+	for _, event := range clients.ExtractAirspaceEvents(combined) {
+		events = append(events, AirspaceEvent{
+			CountryISO2:   event.CountryISO2,
+			FIR:           event.FIR,
+			EventType:     event.EventType,
+			Confidence:    event.Confidence,
+			SourceArticle: liveArticle(event.SourceArticle),
+		})
+	}
+	return events
+}
+
+// liveRisk converts a risk assessment, putting its 0-1 score on the 1-10
+// scale and listing the factors scored above 0.5
+func liveRisk(risk *clients.GeopoliticalRisk) *GeopoliticalRisk {
+	level := int(math.Round(risk.RiskScore * 10))
+	level = int(math.Max(1, math.Min(10, float64(level))))
+
+	factors := []string{}
+	for _, factor := range []struct {
+		name  string
+		score float64
+	}{
+		{"Political", risk.Factors.Political},
+		{"Economic", risk.Factors.Economic},
+		{"Security", risk.Factors.Security},
+		{"Social", risk.Factors.Social},
+		{"Travel advisory", risk.Factors.Advisory},
+	} {
+		if factor.score > 0.5 {
+			factors = append(factors, factor.name)
+		}
+	}
+	return &GeopoliticalRisk{
+		Country:        risk.Country,
+		RiskLevel:      level,
+		Factors:        factors,
+		Advisory:       risk.Advisory,
+		AdvisorySource: risk.AdvisorySource,
+		LastUpdated:    risk.LastUpdated,
+	}
+}
+
+// liveSustainability converts route emissions, pricing the offset with or
+// without radiative forcing
+func (p *LiveProvider) liveSustainability(data *clients.SustainabilityData, radiativeForcing bool) *SustainabilityData {
+	converted := &SustainabilityData{
+		Route:           data.Route,
+		Distance:        int(math.Round(data.Distance)),
+		GreatCircle:     int(math.Round(data.GreatCircleKm)),
+		CO2Emissions:    data.CO2Emissions.Total,
+		AlternativeFuel: data.FuelBlend != nil && data.FuelBlend.SAFPercent > 0,
+		EmissionsRating: emissionsRating(data.EfficiencyScore),
+		LastCalculated:  data.LastCalculated,
+	}
+	if data.Distance > 0 && data.SeatsAssumed > 0 {
+		// Jet fuel weighs about 0.8 kg per litre
+		converted.FuelEfficiency = math.Round(data.FuelConsumption.Total/0.8/data.Distance*100/float64(data.SeatsAssumed)*100) / 100
+	}
+	if data.FuelBlend != nil {
+		blend := FuelBlendAdjustment(*data.FuelBlend)
+		converted.FuelBlend = &blend
+	}
+
+	pricing := p.sustainabilityAPI.OffsetPricing()
+	multiplier := 1.0
+	if radiativeForcing {
+		multiplier = pricing.RFMultiplier
+	}
+	co2e := data.CO2Emissions.Total * multiplier
+	cost, err := clients.EstimateOffsetCost(co2e, pricing.PricePerTonne, pricing.Currency)
+	if err == nil {
+		converted.Offset = &OffsetEstimate{
+			Currency:         pricing.Currency,
+			PricePerTonne:    pricing.PricePerTonne,
+			RadiativeForcing: radiativeForcing,
+			Multiplier:       multiplier,
+			CO2eKg:           math.Round(co2e*10) / 10,
+			Cost:             cost,
+		}
+	}
+	return converted
+}
+
+// emissionsRating grades a 0-100 efficiency score from A to E
+func emissionsRating(score float64) string {
+	switch {
+	case score >= 80:
+		return "A"
+	case score >= 60:
+		return "B"
+	case score >= 40:
+		return "C"
+	case score >= 20:
+		return "D"
+	default:
+		return "E"
+	}
+}
+
+// liveEmissionsReport converts a fleet emissions report
+func liveEmissionsReport(report *clients.EmissionsReport) *EmissionsReport {
+	converted := &EmissionsReport{
+		Flights:         report.Flights,
+		TotalDistanceKm: report.TotalDistanceKm,
+		TotalFuelKg:     report.TotalFuelKg,
+		TotalCO2Kg:      report.TotalCO2Kg,
+		ByAirline:       make([]AirlineEmissions, 0, len(report.ByAirline)),
+		TopEmitters:     make([]FlightEmissionsEntry, 0, len(report.TopEmitters)),
+		PerFlight:       make([]FlightEmissionsEntry, 0, len(report.PerFlight)),
+		Skipped:         make([]SkippedFlight, 0, len(report.Skipped)),
+	}
+	for _, airline := range report.ByAirline {
+		converted.ByAirline = append(converted.ByAirline, AirlineEmissions(airline))
+	}
+	for _, entry := range report.TopEmitters {
+		converted.TopEmitters = append(converted.TopEmitters, FlightEmissionsEntry(entry))
+	}
+	for _, entry := range report.PerFlight {
+		converted.PerFlight = append(converted.PerFlight, FlightEmissionsEntry(entry))
+	}
+	for _, skipped := range report.Skipped {
+		converted.Skipped = append(converted.Skipped, SkippedFlight(skipped))
+	}
+	return converted
+}
+
+// firstNonEmpty returns the first of values that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// newHealthTestServer returns a server whose live provider fetches
+// aviation-edge from baseURL with keys
+func newHealthTestServer(t *testing.T, baseURL string, keys ...string) (*LiveProvider, *APIBridgeServer) {
+	t.Helper()
+	fetcher := clients.NewFetcher()
+	cfg := clients.APIConfig{BaseURL: baseURL, APIKeys: keys, AuthStyle: clients.AuthKeyInQuery, AuthParam: "key"}
+	if err := fetcher.RegisterAPI("aviation-edge", cfg, true); err != nil {
+		t.Fatal(err)
+	}
+	live := NewLiveProviderWithFetcher(fetcher)
+	server := NewAPIBridgeServer()
+	server.liveProvider = live
+	server.providers[live.Name()] = live
+	return live, server
+}
+
+// getHealth requests /health, returning its status code and body
+func getHealth(t *testing.T, server *APIBridgeServer) (int, map[string]interface{}) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	server.healthCheck(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding /health: %v: %s", err, rec.Body)
+	}
+	return rec.Code, body
+}
+
+func TestHealthReportsMissingCredentials(t *testing.T) {
+	_, server := newHealthTestServer(t, "http://127.0.0.1:1")
+
+	code, body := getHealth(t, server)
+	if code != http.StatusOK || body["status"] != "healthy" {
+		t.Fatalf("/health without an aviation-edge key answered %d %v, want 200 healthy", code, body["status"])
+	}
+	providers, _ := body["providers"].(map[string]interface{})
+	if providers["live"] != "unconfigured" || providers["mock"] != "ok" {
+		t.Errorf("providers %v, want live unconfigured and mock ok", providers)
+	}
+	details, _ := body["details"].(map[string]interface{})
+	if details["live"] != "missing AVIATION_EDGE_API_KEY" {
+		t.Errorf("details %v, want the missing key named for live", details)
+	}
+}
+
+func TestHealthDegradesWhenKeysAreRejected(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer upstream.Close()
+	live, server := newHealthTestServer(t, upstream.URL, "rejected")
+
+	if code, body := getHealth(t, server); code != http.StatusOK {
+		t.Fatalf("/health before the key was used answered %d %v, want 200", code, body)
+	}
+	if _, err := live.aircraftAPI.GetAircraftContext(context.Background(), limitParams(1)); err == nil {
+		t.Fatal("fetching aircraft succeeded with a rejected key")
+	}
+	code, body := getHealth(t, server)
+	if code != http.StatusServiceUnavailable || body["status"] != "degraded" {
+		t.Errorf("/health with every key rejected answered %d %v, want 503 degraded", code, body["status"])
+	}
+	if providers, _ := body["providers"].(map[string]interface{}); providers["live"] != "error" {
+		t.Errorf("providers %v, want live error", providers)
+	}
+}
+
+func TestLiveAircraftLimit(t *testing.T) {
+	tests := []struct {
+		count string
+		limit string // Sent to airplaneDatabase, empty when left out
+	}{
+		{"5", "5"},
+		{"0", ""},
+	}
+	for _, tt := range tests {
+		t.Run("aircraft_count="+tt.count, func(t *testing.T) {
+			var mu sync.Mutex
+			var sent map[string]string
+			fetcher := newMockedFetcher()
+			fetcher.SetMock("aviation-edge", func(endpoint string, params map[string]string) ([]byte, error) {
+				if endpoint == "airplaneDatabase" {
+					mu.Lock()
+					sent = params
+					mu.Unlock()
+				}
+				return mockUpstream("aviation-edge", endpoint, params)
+			})
+
+			envData, err := NewLiveProviderWithFetcher(fetcher).GetFlightEnvironment(context.Background(), map[string]string{"aircraft_count": tt.count})
+			if err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if sent == nil {
+				t.Fatal("airplaneDatabase was not requested")
+			}
+			if limit, ok := sent["limit"]; limit != tt.limit || ok != (tt.limit != "") {
+				t.Errorf("sent limit %q (present %v), want %q", limit, ok, tt.limit)
+			}
+			if want, _ := strconv.Atoi(tt.count); len(envData.Aircraft) != want {
+				t.Errorf("returned %d aircraft, want %d", len(envData.Aircraft), want)
+			}
+		})
+	}
+}
+
+func TestFlightEnvironmentFetchesSectionsConcurrently(t *testing.T) {
+	const delay = 300 * time.Millisecond
+	// Each delayed section makes exactly one call to its endpoint
+	delayed := map[string]string{
+		"airplaneDatabase": sectionAircraft,
+		"flights":          sectionFlights,
+	}
+	fetcher := newMockedFetcher()
+	fetcher.SetMock("aviation-edge", func(endpoint string, params map[string]string) ([]byte, error) {
+		if _, ok := delayed[endpoint]; ok {
+			time.Sleep(delay)
+		}
+		return mockUpstream("aviation-edge", endpoint, params)
+	})
+	provider := NewLiveProviderWithFetcher(fetcher)
+
+	start := time.Now()
+	envData, err := provider.GetFlightEnvironment(context.Background(), map[string]string{"route": "JFK-LAX"})
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("GetFlightEnvironment: %v", err)
+	}
+
+	// Fetched one after another the delays would add up
+	if elapsed < delay || elapsed >= time.Duration(len(delayed))*delay {
+		t.Errorf("took %v, want about the slowest section's %v", elapsed, delay)
+	}
+	for _, section := range delayed {
+		status := envData.Sections[section]
+		if status == nil || time.Duration(status.DurationMs)*time.Millisecond < delay {
+			t.Errorf("%s section %+v was not delayed by %v", section, status, delay)
+		}
+	}
+	for _, section := range []string{sectionNOTAMs, sectionNews, sectionGeopolitical} {
+		status := envData.Sections[section]
+		if status == nil {
+			t.Errorf("no status for %s", section)
+			continue
+		}
+		if time.Duration(status.DurationMs)*time.Millisecond >= delay/2 {
+			t.Errorf("%s section took %dms although it has no delay", section, status.DurationMs)
+		}
+	}
+}
+
+func TestLiveRoutesUseAirportDatabase(t *testing.T) {
+	// QQQ is in the airport database but not the embedded dataset
+	fetcher := newMockedFetcher()
+	fetcher.SetMock("aviation-edge", func(endpoint string, params map[string]string) ([]byte, error) {
+		if endpoint == "airportDatabase" && params["codeIataAirport"] == "QQQ" {
+			return []byte(`[{"nameAirport": "Test Field", "codeIataAirport": "QQQ", "codeIcaoAirport": "KQQQ", "codeIso2Country": "US", "latitudeAirport": "40.0", "longitudeAirport": "-75.0"}]`), nil
+		}
+		return mockUpstream("aviation-edge", endpoint, params)
+	})
+	provider := NewLiveProviderWithFetcher(fetcher)
+
+	legs, err := ParseRoutes(providerAirports(provider), "JFK-QQQ")
+	if err != nil {
+		t.Fatalf("live route: %v", err)
+	}
+	if len(legs) != 1 || legs[0] != (RouteLeg{Origin: "JFK", Destination: "QQQ"}) {
+		t.Errorf("legs %v, want JFK-QQQ", legs)
+	}
+	if _, err := ParseRoutes(providerAirports(NewMockProvider()), "JFK-QQQ"); !errors.Is(err, clients.ErrAirportNotFound) {
+		t.Errorf("mock route: error %v, want QQQ not found", err)
+	}
+}
+
+func TestLiveAirspaceEvents(t *testing.T) {
+	fetcher := newMockedFetcher()
+	fetcher.SetMock("newsapi", func(endpoint string, params map[string]string) ([]byte, error) {
+		return []byte(`{"status": "ok", "totalResults": 1, "articles": [{"source": {"name": "Wire"}, "title": "Iran closes its airspace to civilian flights", "url": "https://example.com/iran"}]}`), nil
+	})
+
+	envData, err := NewLiveProviderWithFetcher(fetcher).GetFlightEnvironment(context.Background(), map[string]string{"topics": "Iran"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(envData.AirspaceEvents) != 1 {
+		t.Fatalf("airspace events %+v, want the closure of Iran", envData.AirspaceEvents)
+	}
+	event := envData.AirspaceEvents[0]
+	if event.CountryISO2 != "IR" || event.EventType != clients.AirspaceClosure {
+		t.Errorf("event %+v, want a closure of IR", event)
+	}
+	if event.SourceArticle.Source != "Wire" || event.SourceArticle.URL != "https://example.com/iran" {
+		t.Errorf("source article %+v, want the Wire article", event.SourceArticle)
+	}
+}
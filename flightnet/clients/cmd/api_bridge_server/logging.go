@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// requestIDHeader carries a request's ID, from the caller or generated
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from callers
+const maxRequestIDLength = 128
+
+// newLogger creates the server's logger, writing JSON lines to w
+func newLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true}))
+}
+
+// requestInfo is what the handlers learn about a request that its access
+// log entry reports
+type requestInfo struct {
+	id       string
+	provider string // Provider that served the request, if any
+	apiKey   string // Label of the API key that authenticated it, if any
+}
+
+// requestInfoKey is the context key of a request's *requestInfo
+type requestInfoKey struct{}
+
+// loggerKey is the context key of a request's logger
+type loggerKey struct{}
+
+// RequestID returns the ID of ctx's request, or "" outside a request
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// logFor returns the logger for ctx, which includes the request ID within
+// a request
+func logFor(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// setRequestProvider records the provider serving ctx's request for its
+// access log entry
+func setRequestProvider(ctx context.Context, name string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.provider = name
+	}
+}
+
+// setRequestAPIKey records the label of the API key that authenticated
+// ctx's request for its access log entry
+func setRequestAPIKey(ctx context.Context, label string) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		info.apiKey = label
+	}
+}
+
+// newRequestID returns a random 16-byte hex ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID reports whether a caller's request ID is safe to log and
+// echo: printable ASCII of reasonable length
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// responseRecorder captures the status and size of a response. It passes
+// flushes and hijacks through so streams keep working.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Flush lets handlers stream through the recorder
+func (rec *responseRecorder) Flush() {
+	http.NewResponseController(rec.ResponseWriter).Flush()
+}
+
+// Hijack lets WebSocket upgrades take over the connection
+func (rec *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rec.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	rec.status = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// Unwrap gives http.ResponseController the underlying writer
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// requestLogMiddleware gives each request an ID, taken from its X-Request-ID
+// header when valid and generated otherwise, which is echoed in the response
+// and included by the request's logger. Once the request is served it logs
+// one access log entry.
+func requestLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+
+		info := &requestInfo{id: id}
+		logger := slog.Default().With("request_id", id)
+		ctx := context.WithValue(r.Context(), requestInfoKey{}, info)
+		ctx = context.WithValue(ctx, loggerKey{}, logger)
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		attrs := []any{
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", rec.bytes,
+			"duration_ms", time.Since(started).Milliseconds(),
+			"remote_addr", r.RemoteAddr,
+		}
+		if info.provider != "" {
+			attrs = append(attrs, "provider", info.provider)
+		}
+		if info.apiKey != "" {
+			attrs = append(attrs, "api_key", info.apiKey)
+		}
+		logger.Info("request", attrs...)
+	})
+}
+
+// fatal logs err and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// prometheusContentType is the Prometheus text exposition format
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// defaultLatencyBuckets are the upper bounds, in seconds, of latency
+// histograms
+var defaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// metricCollector writes a metric family in the Prometheus text format
+type metricCollector interface {
+	writeTo(w io.Writer)
+}
+
+// metricRegistry holds the collectors exposed on /metrics, in the order
+// they were registered
+type metricRegistry struct {
+	mu         sync.Mutex
+	collectors []metricCollector
+}
+
+func (r *metricRegistry) register(c metricCollector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// writeTo writes every metric family
+func (r *metricRegistry) writeTo(w io.Writer) {
+	r.mu.Lock()
+	collectors := append([]metricCollector(nil), r.collectors...)
+	r.mu.Unlock()
+	for _, c := range collectors {
+		c.writeTo(w)
+	}
+}
+
+// metricLabels joins label values into a series key and back
+func metricLabels(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels renders names and the values in key as {name="value",...},
+// adding extra, already rendered, pairs
+func formatLabels(names []string, key string, extra ...string) string {
+	var pairs []string
+	if len(names) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, names[i]+`="`+escapeLabelValue(value)+`"`)
+		}
+	}
+	pairs = append(pairs, extra...)
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sortedKeys returns the series keys of a family in a stable order
+func sortedKeys[V any](series map[string]V) []string {
+	keys := make([]string, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// counterVec is a family of counters partitioned by labels
+type counterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	series     map[string]float64
+}
+
+func newCounterVec(registry *metricRegistry, name, help string, labels ...string) *counterVec {
+	c := &counterVec{name: name, help: help, labels: labels, series: make(map[string]float64)}
+	registry.register(c)
+	return c
+}
+
+// inc adds one to the counter with the label values, given in the order
+// the labels were declared
+func (c *counterVec) inc(values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.series[metricLabels(values)]++
+}
+
+// value returns the counter with the label values
+func (c *counterVec) value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.series[metricLabels(values)]
+}
+
+func (c *counterVec) writeTo(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.series) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, key), formatMetricValue(c.series[key]))
+	}
+}
+
+// histogram is one series of a histogramVec
+type histogram struct {
+	counts []uint64 // Per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// histogramVec is a family of histograms partitioned by labels
+type histogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64 // Upper bounds, ascending
+	mu         sync.Mutex
+	series     map[string]*histogram
+}
+
+func newHistogramVec(registry *metricRegistry, name, help string, buckets []float64, labels ...string) *histogramVec {
+	h := &histogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+	registry.register(h)
+	return h
+}
+
+// observe records value in the histogram with the label values
+func (h *histogramVec) observe(value float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := metricLabels(values)
+	series, ok := h.series[key]
+	if !ok {
+		series = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = series
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		series.counts[i]++
+	}
+	series.sum += value
+	series.count++
+}
+
+func (h *histogramVec) writeTo(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.series) {
+		series := h.series[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += series.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, `le="`+formatMetricValue(bound)+`"`), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, `le="+Inf"`), series.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, key), formatMetricValue(series.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, key), series.count)
+	}
+}
+
+// gaugeFunc is a gauge whose value is computed at scrape time
+type gaugeFunc struct {
+	name, help string
+	value      func() float64
+}
+
+func newGaugeFunc(registry *metricRegistry, name, help string, value func() float64) *gaugeFunc {
+	g := &gaugeFunc{name: name, help: help, value: value}
+	registry.register(g)
+	return g
+}
+
+func (g *gaugeFunc) writeTo(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", g.name, g.help, g.name, g.name, formatMetricValue(g.value()))
+}
+
+// bridgeMetrics are the bridge's operational metrics
+var bridgeMetrics = newBridgeMetrics()
+
+// bridgeMetricSet holds the bridge's metrics and the registry exposing them
+type bridgeMetricSet struct {
+	registry *metricRegistry
+
+	requests         *counterVec
+	requestDuration  *histogramVec
+	sectionDuration  *histogramVec
+	fallbacks        *counterVec
+	cacheRequests    *counterVec
+	historySnapshots *counterVec
+}
+
+func newBridgeMetrics() *bridgeMetricSet {
+	registry := &metricRegistry{}
+	m := &bridgeMetricSet{
+		registry: registry,
+		requests: newCounterVec(registry, "bridge_http_requests_total",
+			"HTTP requests served, by route template, method and status code.", "route", "method", "status"),
+		requestDuration: newHistogramVec(registry, "bridge_http_request_duration_seconds",
+			"Time to serve HTTP requests, by route template.", defaultLatencyBuckets, "route"),
+		sectionDuration: newHistogramVec(registry, "bridge_section_fetch_duration_seconds",
+			"Time to fetch each flight environment section, by provider, section and outcome.", defaultLatencyBuckets, "provider", "section", "status"),
+		fallbacks: newCounterVec(registry, "bridge_provider_fallbacks_total",
+			"Sections served partial or fallback data, by provider and section.", "provider", "section"),
+		cacheRequests: newCounterVec(registry, "bridge_environment_cache_requests_total",
+			"Flight environment cache lookups, by result.", "result"),
+		historySnapshots: newCounterVec(registry, "bridge_history_snapshots_total",
+			"Flight environment snapshots recorded to history, by result: saved, failed or dropped.", "result"),
+	}
+	newGaugeFunc(registry, "bridge_environment_cache_hit_ratio",
+		"Share of flight environment cache lookups served from the cache.", func() float64 {
+			hits, misses := m.cacheRequests.value("hit"), m.cacheRequests.value("miss")
+			if hits+misses == 0 {
+				return 0
+			}
+			return hits / (hits + misses)
+		})
+	return m
+}
+
+// metricsMiddleware counts and times requests by their route template,
+// which keeps the number of series bounded whatever paths are requested
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		started := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		bridgeMetrics.requests.inc(route, r.Method, strconv.Itoa(status))
+		bridgeMetrics.requestDuration.observe(time.Since(started).Seconds(), route)
+	})
+}
+
+// wantsJSONMetrics reports whether a /metrics request asks for the JSON
+// upstream metrics rather than the Prometheus text format
+func wantsJSONMetrics(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "json"
+	}
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/plain")
+}
+
+// writePrometheusMetrics writes the bridge's metrics in the Prometheus text
+// format
+func writePrometheusMetrics(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", prometheusContentType)
+	buffered := bufio.NewWriter(w)
+	bridgeMetrics.registry.writeTo(buffered)
+	buffered.Flush()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// scrapeMetrics fetches /metrics from h and returns each series' value by
+// its name and labels as exposed
+func scrapeMetrics(t *testing.T, h http.Handler) map[string]float64 {
+	t.Helper()
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/metrics status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("/metrics Content-Type %q, want the Prometheus text format", ct)
+	}
+	series := make(map[string]float64)
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndexByte(line, ' ')
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("bad metric line %q: %v", line, err)
+		}
+		series[line[:i]] = value
+	}
+	return series
+}
+
+func TestMetricsCountRequests(t *testing.T) {
+	_, h := newTestHandler(t, serverOptions{})
+	before := scrapeMetrics(t, h)
+
+	for i := 0; i < 3; i++ {
+		serve(h, httptest.NewRequest(http.MethodGet, "/providers", nil))
+	}
+	serve(h, httptest.NewRequest(http.MethodGet, "/airports/JFK/departures", nil))
+	serve(h, httptest.NewRequest(http.MethodGet, "/airports/LAX/departures?window=forever", nil))
+	serve(h, httptest.NewRequest(http.MethodGet, "/flight-environment?route=JFK-LAX&refresh=true", nil))
+
+	after := scrapeMetrics(t, h)
+	delta := func(name string) float64 { return after[name] - before[name] }
+
+	counters := map[string]float64{
+		`bridge_http_requests_total{route="/providers",method="GET",status="200"}`:                              3,
+		`bridge_http_requests_total{route="/airports/{iata:[A-Za-z]{3}}/departures",method="GET",status="200"}`: 1,
+		`bridge_http_requests_total{route="/airports/{iata:[A-Za-z]{3}}/departures",method="GET",status="400"}`: 1,
+		`bridge_http_request_duration_seconds_count{route="/providers"}`:                                        3,
+		`bridge_http_request_duration_seconds_bucket{route="/providers",le="+Inf"}`:                             3,
+		`bridge_http_request_duration_seconds_count{route="/airports/{iata:[A-Za-z]{3}}/departures"}`:           2,
+		`bridge_section_fetch_duration_seconds_count{provider="mock",section="aircraft",status="ok"}`:           1,
+		`bridge_environment_cache_requests_total{result="miss"}`:                                                1,
+	}
+	for name, want := range counters {
+		if _, ok := after[name]; !ok {
+			t.Errorf("no series %s", name)
+			continue
+		}
+		if got := delta(name); got != want {
+			t.Errorf("%s rose by %v, want %v", name, got, want)
+		}
+	}
+
+	// Buckets are cumulative, ending at the count
+	previous := 0.0
+	for _, le := range []string{"0.005", "0.01", "0.1", "1", "10", "+Inf"} {
+		bucket := after[`bridge_http_request_duration_seconds_bucket{route="/providers",le="`+le+`"}`]
+		if bucket < previous {
+			t.Errorf("bucket le=%s holds %v, fewer than the bucket before", le, bucket)
+		}
+		previous = bucket
+	}
+	if sum := after[`bridge_http_request_duration_seconds_sum{route="/providers"}`]; sum <= 0 {
+		t.Errorf("duration sum %v, want the time spent", sum)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxMockLatency caps mock_latency, long enough to outlast the request
+// timeout
+const maxMockLatency = time.Minute
+
+// mockSections are the sections of the mock provider, in the order
+// mock_error_rate decides whether they fail
+var mockSections = []string{
+	sectionAircraft, sectionFlights, sectionEmissionsReport, sectionWeather,
+	sectionNOTAMs, sectionNews, sectionGeopolitical, sectionSustainability,
+	sectionRouteWeather,
+}
+
+// errInjectedFault is the error of a section failed by fault injection
+var errInjectedFault = errors.New("injected fault")
+
+// mockFaults are the faults the mock provider injects into one response
+type mockFaults struct {
+	latency time.Duration
+	failing map[string]error // By section
+}
+
+// parseMockFaults parses the fault injection parameters: mock_latency, a
+// delay such as 2s; mock_fail, the comma-separated sections to fail; and
+// mock_error_rate, the probability each other section fails, drawn from
+// seed when it is given so the same sections fail every time
+func parseMockFaults(params map[string]string) (*mockFaults, error) {
+	faults := &mockFaults{failing: make(map[string]error)}
+
+	if value := params["mock_latency"]; value != "" {
+		latency, err := time.ParseDuration(value)
+		if err != nil || latency < 0 || latency > maxMockLatency {
+			return nil, fmt.Errorf("invalid mock_latency %q: must be a duration between 0s and %v", value, maxMockLatency)
+		}
+		faults.latency = latency
+	}
+
+	if value := params["mock_fail"]; value != "" {
+		for _, section := range strings.Split(value, ",") {
+			section = strings.ToLower(strings.TrimSpace(section))
+			if section == "" {
+				continue
+			}
+			if !containsString(mockSections, section) {
+				return nil, fmt.Errorf("invalid mock_fail section %q: must be one of %s", section, strings.Join(mockSections, ", "))
+			}
+			faults.failing[section] = fmt.Errorf("%w (mock_fail)", errInjectedFault)
+		}
+	}
+
+	if value := params["mock_error_rate"]; value != "" {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid mock_error_rate %q: must be between 0 and 1", value)
+		}
+		seed := time.Now().UnixNano()
+		if value := params["seed"]; value != "" {
+			if seed, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid seed %q: must be an integer", value)
+			}
+		}
+		// Every section draws, in a fixed order, so a seed fails the same
+		// sections whichever are also named by mock_fail
+		rng := rand.New(rand.NewSource(seed))
+		for _, section := range mockSections {
+			if rng.Float64() < rate && faults.failing[section] == nil {
+				faults.failing[section] = fmt.Errorf("%w (mock_error_rate %v)", errInjectedFault, rate)
+			}
+		}
+	}
+	return faults, nil
+}
+
+// containsString reports whether list holds value
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+	return false
+}
+
+// delay waits out the injected latency, returning ctx's error if it is done
+// first
+func (f *mockFaults) delay(ctx context.Context) error {
+	if f.latency == 0 {
+		return nil
+	}
+	timer := time.NewTimer(f.latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fault returns the injected error of section, or nil when it should
+// succeed
+func (f *mockFaults) fault(section string) error {
+	if err := f.failing[section]; err != nil {
+		return fmt.Errorf("%s: %w", section, err)
+	}
+	return nil
+}
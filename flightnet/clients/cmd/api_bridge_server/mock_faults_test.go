@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// getEnvironment requests the flight environment at query from h and decodes
+// the response
+func getEnvironment(t *testing.T, h http.Handler, query string) (int, *FlightEnvironmentData) {
+	t.Helper()
+	rec := serve(h, httptest.NewRequest(http.MethodGet, "/flight-environment?"+query, nil))
+	var envData FlightEnvironmentData
+	if err := json.Unmarshal(rec.Body.Bytes(), &envData); err != nil {
+		t.Fatalf("decoding %d response %q: %v", rec.Code, rec.Body, err)
+	}
+	return rec.Code, &envData
+}
+
+func TestInjectedFaultsReportSectionStatus(t *testing.T) {
+	_, h := newTestHandler(t, serverOptions{})
+
+	code, envData := getEnvironment(t, h, "route=JFK-LAX&mock_fail=weather,news")
+	if code != http.StatusOK {
+		t.Fatalf("status %d with two sections failing, want 200", code)
+	}
+	for _, section := range []string{sectionWeather, sectionNews} {
+		status := envData.Sections[section]
+		if status == nil || status.Status != SectionFailed || !strings.Contains(status.Error, errInjectedFault.Error()) {
+			t.Errorf("%s section %+v, want failed with the injected fault", section, status)
+		}
+	}
+	for _, section := range []string{sectionAircraft, sectionFlights, sectionGeopolitical, sectionSustainability, sectionRouteWeather} {
+		if status := envData.Sections[section]; status == nil || status.Status != SectionOK {
+			t.Errorf("%s section %+v, want ok", section, status)
+		}
+	}
+	if strings.Join(envData.Degraded, ",") != "news,weather" {
+		t.Errorf("degraded %v, want news and weather", envData.Degraded)
+	}
+	if len(envData.Weather) != 0 || len(envData.Aircraft) == 0 {
+		t.Errorf("got %d weather reports and %d aircraft, want only aircraft", len(envData.Weather), len(envData.Aircraft))
+	}
+
+	code, envData = getEnvironment(t, h, "route=JFK-LAX&mock_fail="+strings.Join(mockSections, ",")+"&emissions_report=true")
+	if code != http.StatusBadGateway {
+		t.Fatalf("status %d with every section failing, want 502", code)
+	}
+	if len(envData.Sections) == 0 {
+		t.Fatal("502 response has no section statuses")
+	}
+	for section, status := range envData.Sections {
+		if status.Status != SectionFailed && status.Status != SectionSkipped {
+			t.Errorf("%s section %+v, want failed", section, status)
+		}
+	}
+}
+
+func TestMockErrorRateIsRepeatable(t *testing.T) {
+	_, h := newTestHandler(t, serverOptions{})
+	failed := func() []string {
+		_, envData := getEnvironment(t, h, "mock_error_rate=0.5&seed=7&refresh=true")
+		return envData.Degraded
+	}
+	first, second := failed(), failed()
+	if len(first) == 0 || len(first) == len(mockSections) {
+		t.Fatalf("degraded %v, want some sections failed by a 0.5 error rate", first)
+	}
+	if strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Errorf("seed 7 failed %v then %v, want the same sections", first, second)
+	}
+}
@@ -0,0 +1,449 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// Fixture files, each served in place of the generated section it names
+const (
+	fixtureAircraft       = "aircraft.json"       // Array of aircraft
+	fixtureFlights        = "flights.json"        // Array of flights
+	fixtureWeather        = "weather.json"        // Object of weather by airport
+	fixtureNews           = "news.json"           // News response
+	fixtureGeopolitical   = "geopolitical.json"   // Object of risk by country
+	fixtureSustainability = "sustainability.json" // Object of sustainability by route leg, such as JFK-LHR
+)
+
+// fixtureFiles are the fixture files, in the order they are loaded
+var fixtureFiles = []string{
+	fixtureAircraft, fixtureFlights, fixtureWeather, fixtureNews,
+	fixtureGeopolitical, fixtureSustainability,
+}
+
+var (
+	// ErrInvalidFixture is returned for a fixture file that does not decode
+	// or holds an invalid field
+	ErrInvalidFixture = errors.New("invalid fixture")
+	// ErrFixturesDisabled is returned when fixtures are reloaded without a
+	// fixtures directory
+	ErrFixturesDisabled = errors.New("mock fixtures are disabled")
+)
+
+// mockFixtures are the datasets loaded from a fixtures directory. A nil
+// field was not found, so its section is generated.
+type mockFixtures struct {
+	dir            string
+	loadedAt       time.Time
+	aircraft       []Aircraft
+	flights        []Flight
+	weather        map[string]*WeatherData
+	news           *NewsResponse
+	geopolitical   map[string]*GeopoliticalRisk
+	sustainability map[string]*SustainabilityData
+}
+
+// MockFixturesStatus reports which fixture files a load found
+type MockFixturesStatus struct {
+	Dir       string    `json:"dir"`
+	Loaded    []string  `json:"loaded"`    // Files served verbatim
+	Generated []string  `json:"generated"` // Files not found, whose sections are generated
+	LoadedAt  time.Time `json:"loaded_at"`
+}
+
+// status reports the files f was loaded from
+func (f *mockFixtures) status() *MockFixturesStatus {
+	found := map[string]bool{
+		fixtureAircraft:       f.aircraft != nil,
+		fixtureFlights:        f.flights != nil,
+		fixtureWeather:        f.weather != nil,
+		fixtureNews:           f.news != nil,
+		fixtureGeopolitical:   f.geopolitical != nil,
+		fixtureSustainability: f.sustainability != nil,
+	}
+	status := &MockFixturesStatus{Dir: f.dir, Loaded: []string{}, Generated: []string{}, LoadedAt: f.loadedAt}
+	for _, name := range fixtureFiles {
+		if found[name] {
+			status.Loaded = append(status.Loaded, name)
+		} else {
+			status.Generated = append(status.Generated, name)
+		}
+	}
+	return status
+}
+
+// loadMockFixtures reads the fixture files in dir, any of which may be
+// missing. Every file is validated, and the first that fails is named in
+// the error along with the offending field.
+func loadMockFixtures(dir string) (*mockFixtures, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("opening fixtures directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("opening fixtures directory: %s is not a directory", dir)
+	}
+
+	f := &mockFixtures{dir: dir, loadedAt: time.Now().UTC()}
+	for _, name := range fixtureFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading fixture %s: %w", name, err)
+		}
+		switch name {
+		case fixtureAircraft:
+			f.aircraft, err = decodeFixtureList(data, validateFixtureAircraft)
+			if err == nil {
+				err = uniqueAircraftIDs(f.aircraft)
+			}
+		case fixtureFlights:
+			f.flights, err = decodeFixtureList(data, validateFixtureFlight)
+		case fixtureWeather:
+			f.weather, err = decodeFixtureMap(data, validateFixtureWeather)
+		case fixtureNews:
+			f.news = &NewsResponse{}
+			if err = decodeFixtureValue(data, f.news); err == nil {
+				err = validateFixtureNews(f.news)
+			}
+		case fixtureGeopolitical:
+			f.geopolitical, err = decodeFixtureMap(data, validateFixtureRisk)
+		case fixtureSustainability:
+			f.sustainability, err = decodeFixtureMap(data, validateFixtureSustainability)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w %s: %v", ErrInvalidFixture, name, describeJSONError(data, err))
+		}
+	}
+	return f, nil
+}
+
+// fixtureFieldError is an invalid field of a fixture, by its path such as
+// [2].location.lat
+type fixtureFieldError struct {
+	field   string
+	message string
+}
+
+func (e *fixtureFieldError) Error() string {
+	if e.field == "" {
+		return e.message
+	}
+	return e.field + ": " + e.message
+}
+
+// fieldError returns the error of an invalid field
+func fieldError(field, format string, args ...interface{}) error {
+	return &fixtureFieldError{field: field, message: fmt.Sprintf(format, args...)}
+}
+
+// withFieldPrefix places err, from decoding or validating one entry of a
+// fixture, under the entry's path
+func withFieldPrefix(prefix string, err error) error {
+	var fieldErr *fixtureFieldError
+	if errors.As(err, &fieldErr) {
+		field := prefix
+		if fieldErr.field != "" {
+			field += "." + fieldErr.field
+		}
+		return &fixtureFieldError{field: field, message: fieldErr.message}
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := prefix
+		if typeErr.Field != "" {
+			field += "." + typeErr.Field
+		}
+		return fieldError(field, "must be %s, not %s", jsonTypeName(typeErr.Type), typeErr.Value)
+	}
+	return fieldError(prefix, "%s", strings.TrimPrefix(err.Error(), "json: "))
+}
+
+// describeJSONError describes err, from loading data, adding the line and
+// column of a syntax error
+func describeJSONError(data []byte, err error) string {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := 1, 1
+		for _, b := range data[:min(int(syntaxErr.Offset), len(data))] {
+			if b == '\n' {
+				line, column = line+1, 1
+			} else {
+				column++
+			}
+		}
+		return fmt.Sprintf("line %d, column %d: %v", line, column, syntaxErr)
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fieldError(typeErr.Field, "must be %s, not %s", jsonTypeName(typeErr.Type), typeErr.Value).Error()
+	}
+	return strings.TrimPrefix(err.Error(), "json: ")
+}
+
+// jsonTypeName names the JSON type a Go type decodes from
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	}
+	return t.String()
+}
+
+// decodeFixtureValue decodes data into v, rejecting fields v does not have
+// so that misspelt fields are not silently dropped
+func decodeFixtureValue(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return errors.New("unexpected data after the top-level value")
+	}
+	return nil
+}
+
+// decodeFixtureList decodes a JSON array, validating each entry
+func decodeFixtureList[T any](data []byte, validate func(*T) error) ([]T, error) {
+	var raw []json.RawMessage
+	if err := decodeFixtureValue(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, errors.New("must be an array, not null")
+	}
+	list := make([]T, len(raw))
+	for i, entry := range raw {
+		if err := decodeFixtureValue(entry, &list[i]); err != nil {
+			return nil, withFieldPrefix(fmt.Sprintf("[%d]", i), err)
+		}
+		if err := validate(&list[i]); err != nil {
+			return nil, withFieldPrefix(fmt.Sprintf("[%d]", i), err)
+		}
+	}
+	return list, nil
+}
+
+// decodeFixtureMap decodes a JSON object, validating each value by its key
+func decodeFixtureMap[T any](data []byte, validate func(string, *T) error) (map[string]*T, error) {
+	var raw map[string]json.RawMessage
+	if err := decodeFixtureValue(data, &raw); err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, errors.New("must be an object, not null")
+	}
+	values := make(map[string]*T, len(raw))
+	for key, entry := range raw {
+		prefix := fmt.Sprintf("[%q]", key)
+		if strings.TrimSpace(key) == "" {
+			return nil, fieldError(prefix, "key must not be empty")
+		}
+		var value *T
+		if err := decodeFixtureValue(entry, &value); err != nil {
+			return nil, withFieldPrefix(prefix, err)
+		}
+		if value == nil {
+			return nil, fieldError(prefix, "must not be null")
+		}
+		if err := validate(key, value); err != nil {
+			return nil, withFieldPrefix(prefix, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func validateFixtureAircraft(a *Aircraft) error {
+	switch {
+	case strings.TrimSpace(a.ID) == "":
+		return fieldError("id", "must not be empty")
+	case a.Location.Latitude < -90 || a.Location.Latitude > 90:
+		return fieldError("location.lat", "%v is outside -90 to 90", a.Location.Latitude)
+	case a.Location.Longitude < -180 || a.Location.Longitude > 180:
+		return fieldError("location.lng", "%v is outside -180 to 180", a.Location.Longitude)
+	case a.Speed < 0:
+		return fieldError("speed", "%d must not be negative", a.Speed)
+	case a.Heading < 0 || a.Heading > 359:
+		return fieldError("heading", "%d is outside 0 to 359", a.Heading)
+	}
+	return nil
+}
+
+// uniqueAircraftIDs checks no two aircraft share an ID, which flights refer
+// to them by
+func uniqueAircraftIDs(aircraft []Aircraft) error {
+	seen := make(map[string]int, len(aircraft))
+	for i, a := range aircraft {
+		if first, ok := seen[a.ID]; ok {
+			return fieldError(fmt.Sprintf("[%d].id", i), "%q is also the ID of [%d]", a.ID, first)
+		}
+		seen[a.ID] = i
+	}
+	return nil
+}
+
+// validateFixtureFlight also fills in a missing status_code from status
+func validateFixtureFlight(f *Flight) error {
+	switch {
+	case strings.TrimSpace(f.FlightNumber) == "":
+		return fieldError("flight_number", "must not be empty")
+	case strings.TrimSpace(f.Origin) == "":
+		return fieldError("origin", "must not be empty")
+	case strings.TrimSpace(f.Destination) == "":
+		return fieldError("destination", "must not be empty")
+	case !f.DepartureTime.IsZero() && !f.ArrivalTime.IsZero() && f.ArrivalTime.Before(f.DepartureTime):
+		return fieldError("arrival_time", "%s is before departure_time %s", f.ArrivalTime.Format(time.RFC3339), f.DepartureTime.Format(time.RFC3339))
+	case f.Distance < 0:
+		return fieldError("distance_km", "%d must not be negative", f.Distance)
+	case f.Duration < 0:
+		return fieldError("duration_min", "%d must not be negative", f.Duration)
+	}
+	if f.StatusCode == "" && f.Status != "" {
+		f.StatusCode = clients.NormalizeStatus(f.Status)
+	}
+	return nil
+}
+
+func validateFixtureWeather(airport string, w *WeatherData) error {
+	switch {
+	case w.Visibility < 0:
+		return fieldError("visibility_km", "%v must not be negative", w.Visibility)
+	case w.WindSpeed < 0:
+		return fieldError("wind_speed_kph", "%v must not be negative", w.WindSpeed)
+	case w.WindDirection < 0 || w.WindDirection > 360:
+		return fieldError("wind_direction_deg", "%d is outside 0 to 360", w.WindDirection)
+	case w.Humidity < 0 || w.Humidity > 100:
+		return fieldError("humidity_percent", "%d is outside 0 to 100", w.Humidity)
+	case w.Precipitation < 0:
+		return fieldError("precipitation_mm", "%v must not be negative", w.Precipitation)
+	}
+	return nil
+}
+
+// validateFixtureNews also fills in a missing count from the articles
+func validateFixtureNews(news *NewsResponse) error {
+	for i, article := range news.Articles {
+		if strings.TrimSpace(article.Title) == "" {
+			return fieldError(fmt.Sprintf("articles[%d].title", i), "must not be empty")
+		}
+	}
+	if news.Articles == nil {
+		news.Articles = []NewsArticle{}
+	}
+	if news.Count == 0 {
+		news.Count = len(news.Articles)
+	}
+	return nil
+}
+
+func validateFixtureRisk(country string, risk *GeopoliticalRisk) error {
+	if risk.RiskLevel < 1 || risk.RiskLevel > 10 {
+		return fieldError("risk_level", "%d is outside 1 to 10", risk.RiskLevel)
+	}
+	return nil
+}
+
+func validateFixtureSustainability(leg string, s *SustainabilityData) error {
+	switch {
+	case s.Distance < 0:
+		return fieldError("distance_km", "%d must not be negative", s.Distance)
+	case s.CO2Emissions < 0:
+		return fieldError("co2_emissions_kg", "%v must not be negative", s.CO2Emissions)
+	}
+	return nil
+}
+
+// copyFixtureMap returns a copy of m for one response, which may replace
+// its values, keeping the fixtures themselves intact
+func copyFixtureMap[V any](m map[string]*V) map[string]*V {
+	copied := make(map[string]*V, len(m))
+	for key, value := range m {
+		v := *value
+		copied[key] = &v
+	}
+	return copied
+}
+
+// LoadFixtures loads the fixture files in dir and serves them from then on.
+// The fixtures already loaded are kept when dir does not load.
+func (p *MockProvider) LoadFixtures(dir string) (*MockFixturesStatus, error) {
+	fixtures, err := loadMockFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	p.fixturesMu.Lock()
+	p.fixtures = fixtures
+	p.fixturesMu.Unlock()
+	return fixtures.status(), nil
+}
+
+// ReloadFixtures re-reads the fixture files from the directory they were
+// last loaded from
+func (p *MockProvider) ReloadFixtures() (*MockFixturesStatus, error) {
+	fixtures := p.currentFixtures()
+	if fixtures == nil {
+		return nil, ErrFixturesDisabled
+	}
+	return p.LoadFixtures(fixtures.dir)
+}
+
+// currentFixtures returns the loaded fixtures, or nil when there are none
+func (p *MockProvider) currentFixtures() *mockFixtures {
+	p.fixturesMu.RLock()
+	defer p.fixturesMu.RUnlock()
+	return p.fixtures
+}
+
+// reloadFixtures re-reads the mock provider's fixture files and empties its
+// cached responses, which may hold the fixtures replaced
+func (s *APIBridgeServer) reloadFixtures(w http.ResponseWriter, r *http.Request) {
+	status, err := s.mockProvider.ReloadFixtures()
+	switch {
+	case errors.Is(err, ErrFixturesDisabled):
+		http.Error(w, "Error: mock fixtures are disabled; set -mock-fixtures or MOCK_FIXTURES_DIR", http.StatusNotImplemented)
+		return
+	case errors.Is(err, ErrInvalidFixture):
+		logFor(r.Context()).Warn("keeping previous mock fixtures", "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		logFor(r.Context()).Error("error reloading mock fixtures", "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	s.environmentCache.purge(s.mockProvider.Name())
+	logFor(r.Context()).Info("reloaded mock fixtures", "dir", status.Dir, "loaded", status.Loaded)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		logFor(r.Context()).Error("error encoding fixtures response", "error", err)
+	}
+}
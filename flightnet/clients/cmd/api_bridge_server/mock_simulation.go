@@ -0,0 +1,200 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+const (
+	// simulationIdleTTL is how long a simulation session lasts without
+	// requests
+	simulationIdleTTL = 30 * time.Minute
+	// maxSimulationSessions caps the sessions kept, dropping the least
+	// recently used
+	maxSimulationSessions = 100
+	// simulationStep is the longest stretch an aircraft flies on one heading
+	// before it is updated
+	simulationStep = time.Minute
+	// maxSimulatedLatitude keeps aircraft within the Web Mercator tiles
+	maxSimulatedLatitude = 85.0
+	// boardingWindow is how long before departure a flight boards
+	boardingWindow = 30 * time.Minute
+)
+
+// simulationSession is a mock scenario whose aircraft and flights evolve
+// over time: aircraft fly along their heading at their speed and flights
+// move through their schedules
+type simulationSession struct {
+	aircraft []Aircraft // As of updated
+	flights  []Flight   // Positions and statuses follow from the schedule
+	updated  time.Time
+	lastUsed time.Time
+}
+
+// simulationStore keeps the simulation sessions, by simulationKey
+type simulationStore struct {
+	mu       sync.Mutex
+	sessions map[string]*simulationSession
+	now      func() time.Time // The simulation clock, time.Now outside tests
+}
+
+func newSimulationStore() *simulationStore {
+	return &simulationStore{sessions: make(map[string]*simulationSession), now: time.Now}
+}
+
+// simulationKey identifies the session of a request by its seed and the
+// parameters that decide its aircraft and flights
+func simulationKey(params map[string]string) string {
+	return strings.Join([]string{params["seed"], params["aircraft_count"], params["route"], params["airports"]}, "|")
+}
+
+// advance replaces the aircraft and flights of envData with those of the
+// session for key as of the simulation clock. The first request of a
+// session starts it from envData, spreading the flights' departures around
+// now so their statuses progress. Sections that failed are left alone.
+func (s *simulationStore) advance(key string, envData *FlightEnvironmentData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.evict(now)
+
+	session, ok := s.sessions[key]
+	if !ok {
+		session = &simulationSession{
+			aircraft: append([]Aircraft(nil), envData.Aircraft...),
+			flights:  scheduleSimulatedFlights(envData.Flights, now),
+			updated:  now,
+		}
+		s.sessions[key] = session
+	}
+	session.lastUsed = now
+	for i := range session.aircraft {
+		advanceAircraft(&session.aircraft[i], now.Sub(session.updated))
+		session.aircraft[i].LastUpdated = now
+	}
+	session.updated = now
+
+	if envData.Aircraft != nil {
+		envData.Aircraft = append([]Aircraft{}, session.aircraft...)
+	}
+	if envData.Flights != nil {
+		envData.Flights = make([]Flight, len(session.flights))
+		for i, flight := range session.flights {
+			envData.Flights[i] = simulatedFlight(flight, now)
+		}
+	}
+}
+
+// evict drops the sessions idle too long, then the least recently used
+// beyond maxSimulationSessions
+func (s *simulationStore) evict(now time.Time) {
+	for key, session := range s.sessions {
+		if now.Sub(session.lastUsed) > simulationIdleTTL {
+			delete(s.sessions, key)
+		}
+	}
+	if len(s.sessions) < maxSimulationSessions {
+		return
+	}
+	keys := make([]string, 0, len(s.sessions))
+	for key := range s.sessions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return s.sessions[keys[i]].lastUsed.Before(s.sessions[keys[j]].lastUsed) })
+	for _, key := range keys[:len(keys)-maxSimulationSessions+1] {
+		delete(s.sessions, key)
+	}
+}
+
+// scheduleSimulatedFlights copies flights with departures from two hours
+// before now to three hours after, so some have landed, some are in the air
+// and some have yet to board
+func scheduleSimulatedFlights(flights []Flight, now time.Time) []Flight {
+	scheduled := make([]Flight, len(flights))
+	for i, flight := range flights {
+		duration := flight.ArrivalTime.Sub(flight.DepartureTime)
+		flight.DepartureTime = now.Add(time.Duration(rand.Int63n(int64(5*time.Hour))) - 2*time.Hour).Truncate(time.Minute)
+		flight.ArrivalTime = flight.DepartureTime.Add(duration)
+		scheduled[i] = flight
+	}
+	return scheduled
+}
+
+// simulatedFlight returns flight as of now: scheduled, boarding, in the air
+// between its airports or landed
+func simulatedFlight(flight Flight, now time.Time) Flight {
+	fraction := 0.0
+	switch {
+	case now.Before(flight.DepartureTime.Add(-boardingWindow)):
+		flight.Status = "Scheduled"
+	case now.Before(flight.DepartureTime):
+		flight.Status = "Boarding"
+	case now.Before(flight.ArrivalTime):
+		flight.Status = "In Air"
+		fraction = float64(now.Sub(flight.DepartureTime)) / float64(flight.ArrivalTime.Sub(flight.DepartureTime))
+	default:
+		flight.Status = "Landed"
+		fraction = 1
+	}
+	flight.StatusCode = clients.NormalizeStatus(flight.Status)
+
+	airports := clients.NewEmbeddedAirportsAPI()
+	from, err1 := airports.GetAirportByIATA(flight.Origin)
+	to, err2 := airports.GetAirportByIATA(flight.Destination)
+	if err1 == nil && err2 == nil {
+		lat, lon := clients.GreatCirclePoint(from.Latitude, from.Longitude, to.Latitude, to.Longitude, fraction)
+		flight.Position = GeoPoint{Latitude: lat, Longitude: lon}
+	}
+	return flight
+}
+
+// advanceAircraft flies a along the great circle of its heading at its
+// speed, in knots, for elapsed, in steps of at most simulationStep. The
+// heading follows the great circle, longitudes wrap at the antimeridian and
+// an aircraft reaching maxSimulatedLatitude turns back from the pole.
+func advanceAircraft(a *Aircraft, elapsed time.Duration) {
+	for elapsed > 0 {
+		step := elapsed
+		if step > simulationStep {
+			step = simulationStep
+		}
+		elapsed -= step
+
+		distanceKm := float64(a.Speed) * 1.852 * step.Hours()
+		lat, lon, heading := destinationPoint(a.Location.Latitude, a.Location.Longitude, float64(a.Heading), distanceKm)
+		if math.Abs(lat) > maxSimulatedLatitude {
+			lat = math.Copysign(maxSimulatedLatitude, lat)
+			heading = math.Mod(540-heading, 360)
+		}
+		a.Location = GeoPoint{Latitude: lat, Longitude: normalizeLongitude(lon)}
+		a.Heading = int(math.Round(heading)) % 360
+	}
+}
+
+// destinationPoint returns the point distanceKm from a start point along
+// the great circle of an initial heading in degrees, and the heading on
+// arrival
+func destinationPoint(lat, lon, heading, distanceKm float64) (float64, float64, float64) {
+	const earthRadiusKm = 6371.0
+	toRad := math.Pi / 180
+	phi1, lambda1, theta := lat*toRad, lon*toRad, heading*toRad
+	delta := distanceKm / earthRadiusKm
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(delta) + math.Cos(phi1)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi1), math.Cos(delta)-math.Sin(phi1)*math.Sin(phi2))
+
+	// The heading on arrival is the reverse of the bearing back to the start
+	back := math.Atan2(math.Sin(lambda1-lambda2)*math.Cos(phi1),
+		math.Cos(phi2)*math.Sin(phi1)-math.Sin(phi2)*math.Cos(phi1)*math.Cos(lambda1-lambda2))
+	final := math.Mod(back/toRad+180, 360)
+	if final < 0 {
+		final += 360
+	}
+	return phi2 / toRad, lambda2 / toRad, final
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// initialBearing returns the heading in degrees of the great circle from
+// one point to another
+func initialBearing(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	phi1, phi2, dLambda := lat1*toRad, lat2*toRad, (lon2-lon1)*toRad
+	theta := math.Atan2(math.Sin(dLambda)*math.Cos(phi2),
+		math.Cos(phi1)*math.Sin(phi2)-math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda))
+	return math.Mod(theta/toRad+360, 360)
+}
+
+// headingDifference returns the smaller angle between two headings
+func headingDifference(a, b float64) float64 {
+	d := math.Mod(math.Abs(a-b), 360)
+	return math.Min(d, 360-d)
+}
+
+func TestSimulationAdvancesWithClock(t *testing.T) {
+	provider := NewMockProvider()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	provider.simulations.now = func() time.Time { return now }
+	params := map[string]string{"simulate": "true", "seed": "42", "aircraft_count": "10", "route": "JFK-LHR"}
+	ctx := context.Background()
+
+	before, err := provider.GetFlightEnvironment(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(before.Aircraft) == 0 || len(before.Flights) == 0 {
+		t.Fatalf("simulation has %d aircraft and %d flights, want some of each", len(before.Aircraft), len(before.Flights))
+	}
+
+	now = now.Add(time.Minute)
+	after, err := provider.GetFlightEnvironment(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after.Aircraft) != len(before.Aircraft) || len(after.Flights) != len(before.Flights) {
+		t.Fatalf("simulation changed size from %d aircraft and %d flights to %d and %d",
+			len(before.Aircraft), len(before.Flights), len(after.Aircraft), len(after.Flights))
+	}
+
+	for i, a := range before.Aircraft {
+		b := after.Aircraft[i]
+		if b.ID != a.ID {
+			t.Fatalf("aircraft %d is %s, was %s", i, b.ID, a.ID)
+		}
+		if !b.LastUpdated.Equal(now) {
+			t.Errorf("%s updated at %s, want %s", a.ID, b.LastUpdated, now)
+		}
+		if math.Abs(b.Location.Latitude) >= maxSimulatedLatitude {
+			continue // Turned back from the pole
+		}
+		moved := clients.GreatCircleDistance(a.Location.Latitude, a.Location.Longitude, b.Location.Latitude, b.Location.Longitude)
+		want := float64(a.Speed) * 1.852 / 60
+		if math.Abs(moved-want) > 0.01*want {
+			t.Errorf("%s at %d kt moved %.2f km in a minute, want %.2f", a.ID, a.Speed, moved, want)
+		}
+		bearing := initialBearing(a.Location.Latitude, a.Location.Longitude, b.Location.Latitude, b.Location.Longitude)
+		if headingDifference(bearing, float64(a.Heading)) > 1 {
+			t.Errorf("%s heading %d moved along %.1f", a.ID, a.Heading, bearing)
+		}
+	}
+
+	for i, f := range before.Flights {
+		g := after.Flights[i]
+		if !statusFitsSchedule(clients.NormalizeStatus(g.Status), g, now) {
+			t.Errorf("%s is %q at %s, departing %s and arriving %s", g.FlightNumber, g.Status, now, g.DepartureTime, g.ArrivalTime)
+		}
+		if f.Status == "In Air" && g.Status == "In Air" && f.Position == g.Position {
+			t.Errorf("%s in the air stayed at %+v", f.FlightNumber, f.Position)
+		}
+	}
+
+	// Until the last arrival each flight moves on through its statuses,
+	// flying while in the air, and then every flight has landed. The clock
+	// moves in steps shorter than simulationIdleTTL, which would end the
+	// session.
+	progress := map[string]int{"Scheduled": 0, "Boarding": 1, "In Air": 2, "Landed": 3}
+	var last time.Time
+	for _, f := range after.Flights {
+		if f.ArrivalTime.After(last) {
+			last = f.ArrivalTime
+		}
+	}
+	flown := make([]bool, len(after.Flights))
+	for i, f := range after.Flights {
+		flown[i] = f.Status == "In Air"
+	}
+	previous := after
+	for !now.After(last) {
+		now = now.Add(simulationIdleTTL / 2)
+		current, err := provider.GetFlightEnvironment(ctx, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(current.Flights) != len(previous.Flights) {
+			t.Fatalf("simulation has %d flights at %s, had %d", len(current.Flights), now, len(previous.Flights))
+		}
+		for i, f := range current.Flights {
+			was := previous.Flights[i]
+			if progress[f.Status] < progress[was.Status] {
+				t.Errorf("%s went from %q back to %q at %s", f.FlightNumber, was.Status, f.Status, now)
+			}
+			if f.Status == "In Air" {
+				flown[i] = true
+				if was.Status == "In Air" && was.Position == f.Position {
+					t.Errorf("%s in the air stayed at %+v", f.FlightNumber, f.Position)
+				}
+			}
+		}
+		previous = current
+	}
+	for i, f := range previous.Flights {
+		if f.Status != "Landed" {
+			t.Errorf("%s is %q after every arrival, want Landed", f.FlightNumber, f.Status)
+		}
+		if long := f.ArrivalTime.Sub(f.DepartureTime) > simulationIdleTTL/2; long && !flown[i] && after.Flights[i].Status != "Landed" {
+			t.Errorf("%s landed without being seen in the air", f.FlightNumber)
+		}
+	}
+}
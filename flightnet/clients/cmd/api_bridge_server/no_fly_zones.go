@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// NoFlyZoneSource is where a no-fly zone was reported
+type NoFlyZoneSource string
+
+// No-fly zone sources
+const (
+	NoFlyZoneNews   NoFlyZoneSource = "news"   // Airspace events extracted from the news
+	NoFlyZoneNOTAM  NoFlyZoneSource = "notam"  // Airspace closures and prohibited areas
+	NoFlyZoneStatic NoFlyZoneSource = "static" // The built-in travel advisories
+)
+
+// Confidence in the no-fly zones whose source gives none of its own
+const (
+	notamNoFlyZoneConfidence  = 0.9
+	staticNoFlyZoneConfidence = 0.5
+)
+
+// doNotTravelAdvisory is the static travel advisory that makes a country a
+// no-fly zone
+const doNotTravelAdvisory = "do not travel"
+
+// NoFlyZone is a country whose airspace is closed, restricted or advised
+// against, with why and how confidently it is listed. A country reported by
+// several sources is listed once for each.
+type NoFlyZone struct {
+	CountryISO2   string          `json:"country_iso2"`
+	CountryName   string          `json:"country_name"`
+	Reason        string          `json:"reason"`
+	Source        NoFlyZoneSource `json:"source"`
+	Confidence    float64         `json:"confidence"` // 0 to 1
+	EffectiveFrom *time.Time      `json:"effective_from,omitempty"`
+	EffectiveTo   *time.Time      `json:"effective_to,omitempty"` // Unset until further notice
+	SourceURLs    []string        `json:"source_urls"`
+}
+
+// key identifies a zone across snapshots, by country and source
+func (z NoFlyZone) key() string {
+	return z.CountryISO2 + "|" + string(z.Source)
+}
+
+// deriveNoFlyZones sets the no-fly zones of envData from its airspace
+// events, NOTAMs and static travel advisories, and the legacy list of their
+// country codes, in that order
+func deriveNoFlyZones(envData *FlightEnvironmentData) {
+	zones := newsNoFlyZones(envData.AirspaceEvents)
+	zones = append(zones, notamNoFlyZones(envData.NOTAMs)...)
+	zones = append(zones, staticNoFlyZones(envData.Geopolitical)...)
+
+	codes := make([]string, 0, len(zones))
+	for _, zone := range zones {
+		codes = append(codes, zone.CountryISO2)
+	}
+	envData.NoFlyZones = zones
+	envData.NoFlyZoneCodes = removeDuplicates(codes)
+}
+
+// newsNoFlyZones lists every country with an airspace event, in the order
+// first reported. A country's zone gives the most severe event as its
+// reason, and cites every article reporting it from the earliest.
+func newsNoFlyZones(events []AirspaceEvent) []NoFlyZone {
+	zones := []NoFlyZone{}
+	byCountry := make(map[string]int)
+	strongest := make(map[string]AirspaceEvent)
+	for _, event := range events {
+		i, seen := byCountry[event.CountryISO2]
+		if !seen {
+			i = len(zones)
+			byCountry[event.CountryISO2] = i
+			zones = append(zones, NoFlyZone{
+				CountryISO2: event.CountryISO2,
+				CountryName: countryName(event.CountryISO2),
+				Source:      NoFlyZoneNews,
+				SourceURLs:  []string{},
+			})
+		}
+		zone := &zones[i]
+		if !seen || moreSevere(event, strongest[event.CountryISO2]) {
+			strongest[event.CountryISO2] = event
+			zone.Reason = fmt.Sprintf("Airspace %s reported: %s", event.EventType, event.SourceArticle.Title)
+		}
+		zone.Confidence = max(zone.Confidence, event.Confidence)
+		if url := event.SourceArticle.URL; url != "" && !containsString(zone.SourceURLs, url) {
+			zone.SourceURLs = append(zone.SourceURLs, url)
+		}
+		if published, err := time.Parse(time.RFC3339, event.SourceArticle.PublishedAt); err == nil {
+			if zone.EffectiveFrom == nil || published.Before(*zone.EffectiveFrom) {
+				zone.EffectiveFrom = &published
+			}
+		}
+	}
+	return zones
+}
+
+// notamClosesAirspace reports whether a NOTAM's Q-code closes airspace to
+// flights: a closed FIR or airspace (QAF and QAA ending LC) or an active
+// prohibited area (QRP). Restricted and danger areas leave the rest of the
+// country open, so they are not no-fly zones.
+func notamClosesAirspace(qcode string) bool {
+	qcode = strings.ToUpper(qcode)
+	if len(qcode) != 5 {
+		return false
+	}
+	switch qcode[:3] {
+	case "QAF", "QAA":
+		return qcode[3:] == "LC"
+	case "QRP":
+		return true
+	}
+	return false
+}
+
+// notamNoFlyZones lists the countries of the locations of NOTAMs closing
+// airspace, one zone per NOTAM
+func notamNoFlyZones(notams []NOTAM) []NoFlyZone {
+	zones := []NoFlyZone{}
+	airports := clients.NewEmbeddedAirportsAPI()
+	for _, notam := range notams {
+		if !notamClosesAirspace(notam.QCode) {
+			continue
+		}
+		airport, err := airports.GetAirportByICAO(notam.Location)
+		if err != nil || airport.Country == "" {
+			continue
+		}
+		zone := NoFlyZone{
+			CountryISO2: airport.Country,
+			CountryName: countryName(airport.Country),
+			Reason:      fmt.Sprintf("NOTAM %s at %s: %s", notam.Number, notam.Location, notam.Text),
+			Source:      NoFlyZoneNOTAM,
+			Confidence:  notamNoFlyZoneConfidence,
+			SourceURLs:  []string{},
+		}
+		if !notam.EffectiveFrom.IsZero() {
+			from := notam.EffectiveFrom
+			zone.EffectiveFrom = &from
+		}
+		if !notam.EffectiveTo.IsZero() {
+			to := notam.EffectiveTo
+			zone.EffectiveTo = &to
+		}
+		zones = append(zones, zone)
+	}
+	return zones
+}
+
+// staticNoFlyZones lists the countries the built-in travel advisories say
+// not to travel to, in country code order
+func staticNoFlyZones(risks map[string]*GeopoliticalRisk) []NoFlyZone {
+	zones := []NoFlyZone{}
+	for _, country := range sortedKeys(risks) {
+		risk := risks[country]
+		if risk == nil || risk.AdvisorySource != "static" || !strings.EqualFold(risk.Advisory, doNotTravelAdvisory) {
+			continue
+		}
+		zones = append(zones, NoFlyZone{
+			CountryISO2: country,
+			CountryName: countryName(country),
+			Reason:      fmt.Sprintf("Travel advisory: %s (risk level %d)", risk.Advisory, risk.RiskLevel),
+			Source:      NoFlyZoneStatic,
+			Confidence:  staticNoFlyZoneConfidence,
+			SourceURLs:  []string{},
+		})
+	}
+	return zones
+}
+
+// diffNoFlyZones returns the zones of after that are not in before, by
+// country and source
+func diffNoFlyZones(before, after []NoFlyZone) []NoFlyZone {
+	seen := make(map[string]bool, len(before))
+	for _, zone := range before {
+		seen[zone.key()] = true
+	}
+	var missing []NoFlyZone
+	for _, zone := range after {
+		if !seen[zone.key()] {
+			missing = append(missing, zone)
+		}
+	}
+	return missing
+}
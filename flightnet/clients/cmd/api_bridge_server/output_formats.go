@@ -0,0 +1,374 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// outputFormat is a representation a list can be sent in
+type outputFormat string
+
+// Output formats, chosen by the format parameter or the Accept header
+const (
+	formatJSON   outputFormat = "json"
+	formatCSV    outputFormat = "csv"    // A header of dotted column names, then a row per item
+	formatNDJSON outputFormat = "ndjson" // One JSON object per line
+)
+
+// ndjsonFlushEvery is how many NDJSON lines are sent at a time, so large
+// lists reach the client as they are written
+const ndjsonFlushEvery = 100
+
+// flatSectionKeys are the sections of the flight environment that can be
+// sent as CSV or NDJSON. List sections map to "", and map sections to the
+// column their keys go in.
+var flatSectionKeys = map[string]string{
+	"aircraft":        "",
+	"flights":         "",
+	"notams":          "",
+	"airspace_events": "",
+	"no_fly_zones":    "",
+	"route_weather":   "",
+	"weather":         "airport",
+	"geopolitical":    "country",
+	"sustainability":  "leg",
+}
+
+// negotiateFormat returns the format a request asks for: the format
+// parameter if given, otherwise the first of text/csv, application/x-ndjson
+// and application/json in the Accept header, otherwise JSON
+func negotiateFormat(r *http.Request) (outputFormat, error) {
+	if value := r.URL.Query().Get("format"); value != "" {
+		switch format := outputFormat(strings.ToLower(strings.TrimSpace(value))); format {
+		case formatJSON, formatCSV, formatNDJSON:
+			return format, nil
+		}
+		return "", fmt.Errorf("%w format %q: must be json, csv or ndjson", ErrInvalidParameter, value)
+	}
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return formatCSV, nil
+		case "application/x-ndjson", "application/ndjson":
+			return formatNDJSON, nil
+		case "application/json":
+			return formatJSON, nil
+		}
+	}
+	return formatJSON, nil
+}
+
+// flatSection returns the one section a flight environment request must
+// select to be sent as CSV or NDJSON
+func flatSection(shape environmentShape) (string, error) {
+	names := make([]string, 0, len(flatSectionKeys))
+	for name := range flatSectionKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(shape.sections) != 1 {
+		return "", fmt.Errorf("csv and ndjson need sections to name exactly one of %s", strings.Join(names, ", "))
+	}
+	for section := range shape.sections {
+		if _, ok := flatSectionKeys[section]; !ok {
+			return "", fmt.Errorf("section %s cannot be sent as csv or ndjson: must be one of %s", section, strings.Join(names, ", "))
+		}
+		return section, nil
+	}
+	return "", nil
+}
+
+// formatETag derives the ETag of another representation of the response
+// whose JSON has etag
+func formatETag(etag string, format outputFormat) string {
+	if format == formatJSON {
+		return etag
+	}
+	return strings.TrimSuffix(etag, `"`) + "-" + string(format) + `"`
+}
+
+// flatColumn is a leaf field of a flattened type: its dotted name, from the
+// JSON names of the fields leading to it, and their indexes
+type flatColumn struct {
+	name  string
+	index []int
+}
+
+// flatColumns returns the columns of t, a struct, in field order. Nested
+// structs are flattened into dotted names; times, slices and maps are
+// leaves.
+func flatColumns(t reflect.Type) []flatColumn {
+	var columns []flatColumn
+	var walk func(t reflect.Type, prefix string, index []int)
+	walk = func(t reflect.Type, prefix string, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fieldIndex := append(append([]int{}, index...), i)
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeOf(time.Time{}) {
+				walk(fieldType, prefix+name+".", fieldIndex)
+				continue
+			}
+			columns = append(columns, flatColumn{name: prefix + name, index: fieldIndex})
+		}
+	}
+	walk(t, "", nil)
+	return columns
+}
+
+// flatField returns the field at index in v, a struct, and false when a nil
+// pointer is on the way to it
+func flatField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// flatCell formats a leaf value for CSV. Nil pointers and zero times are
+// empty, and slices and maps are JSON.
+func flatCell(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(time.RFC3339Nano), nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Map:
+		if v.IsNil() {
+			return "", nil
+		}
+	}
+	encoded, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// flatTable is a list flattened for CSV
+type flatTable struct {
+	columns []string
+	rows    [][]string
+}
+
+// flattenList flattens list, a slice of structs or of pointers to them,
+// into a row per item. keys, when given, go first in a column of their own
+// unless the items have a keyColumn field.
+func flattenList(list interface{}, keyColumn string, keys []string) (*flatTable, error) {
+	v := reflect.ValueOf(list)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("cannot flatten %T: not a list", list)
+	}
+	itemType := v.Type().Elem()
+	if itemType.Kind() == reflect.Pointer {
+		itemType = itemType.Elem()
+	}
+	if itemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot flatten %T: items are not objects", list)
+	}
+
+	columns := flatColumns(itemType)
+	for _, column := range columns {
+		if column.name == keyColumn {
+			keyColumn = "" // The items carry their own keys, as geopolitical risks do
+		}
+	}
+	table := &flatTable{}
+	if keyColumn != "" {
+		table.columns = append(table.columns, keyColumn)
+	}
+	for _, column := range columns {
+		table.columns = append(table.columns, column.name)
+	}
+	for i := 0; i < v.Len(); i++ {
+		var row []string
+		if keyColumn != "" {
+			row = append(row, keys[i])
+		}
+		for _, column := range columns {
+			cell := ""
+			if field, ok := flatField(v.Index(i), column.index); ok {
+				var err error
+				if cell, err = flatCell(field); err != nil {
+					return nil, fmt.Errorf("flattening %s: %w", column.name, err)
+				}
+			}
+			row = append(row, cell)
+		}
+		table.rows = append(table.rows, row)
+	}
+	return table, nil
+}
+
+// mapEntries returns the keys of m, a map by string, in order, and its values
+// in the same order as a slice
+func mapEntries(m interface{}) ([]string, interface{}) {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	values := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), 0, len(keys))
+	for _, key := range keys {
+		values = reflect.Append(values, v.MapIndex(reflect.ValueOf(key)))
+	}
+	return keys, values.Interface()
+}
+
+// writeCSV writes table with a header row, quoting fields as RFC 4180 asks
+func writeCSV(w io.Writer, table *flatTable) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.columns); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(table.rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// writeNDJSON writes each item of list, a slice, as a line of JSON, flushing
+// as it goes. keys, when given, are added to the items under keyColumn.
+func writeNDJSON(w io.Writer, list interface{}, keyColumn string, keys []string) error {
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	v := reflect.ValueOf(list)
+	for i := 0; i < v.Len(); i++ {
+		var item interface{} = v.Index(i).Interface()
+		if keyColumn != "" {
+			encoded, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(encoded, &fields); err != nil {
+				return err
+			}
+			key, _ := json.Marshal(keys[i])
+			fields[keyColumn] = key
+			item = fields
+		}
+		if err := encoder.Encode(item); err != nil {
+			return err
+		}
+		if flusher != nil && (i+1)%ndjsonFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+	return nil
+}
+
+// writeList sends list, a slice, as format. Map sections are sent as their
+// values in key order, with the key in keyColumn.
+func writeList(w http.ResponseWriter, name string, format outputFormat, list interface{}, keyColumn string, keys []string) error {
+	switch format {
+	case formatCSV:
+		table, err := flattenList(list, keyColumn, keys)
+		if err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", name+".csv"))
+		return writeCSV(w, table)
+	case formatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		return writeNDJSON(w, list, keyColumn, keys)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(list)
+}
+
+// writeResourceList sends a list resource in the format a request asked
+// for, or the error fetching it as writeResource does
+func writeResourceList(w http.ResponseWriter, r *http.Request, name string, format outputFormat, list interface{}, err error) {
+	w.Header().Add("Vary", "Accept")
+	if err != nil || format == formatJSON {
+		writeResource(w, r, name, list, err)
+		return
+	}
+	if err := writeList(w, name, format, list, "", nil); err != nil {
+		logFor(r.Context()).Error("error encoding "+name+" to "+string(format), "error", err)
+	}
+}
+
+// writeFlatEnvironment sends section of a flight environment response, as
+// encoded in body, in format
+func writeFlatEnvironment(w http.ResponseWriter, body []byte, section string, format outputFormat) error {
+	var envData FlightEnvironmentData
+	if err := json.Unmarshal(body, &envData); err != nil {
+		return fmt.Errorf("decoding flight environment: %w", err)
+	}
+	var list interface{}
+	var keys []string
+	switch section {
+	case "aircraft":
+		list = envData.Aircraft
+	case "flights":
+		list = envData.Flights
+	case "notams":
+		list = envData.NOTAMs
+	case "airspace_events":
+		list = envData.AirspaceEvents
+	case "no_fly_zones":
+		list = envData.NoFlyZones
+	case "route_weather":
+		list = envData.RouteWeather
+	case "weather":
+		keys, list = mapEntries(envData.Weather)
+	case "geopolitical":
+		keys, list = mapEntries(envData.Geopolitical)
+	case "sustainability":
+		keys, list = mapEntries(envData.Sustainability)
+	default:
+		return fmt.Errorf("section %s cannot be flattened", section)
+	}
+	return writeList(w, section, format, list, flatSectionKeys[section], keys)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+// TestCSVRoundTrip writes a known dataset, with commas, quotes, newlines,
+// nil pointers and nested objects, through the CSV encoder and reads it back
+// with readCSV, failing unless the JSON of both matches
+func TestCSVRoundTrip(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 12, 30, 15, 250000000, time.UTC)
+	from := time.Date(2024, 2, 28, 6, 0, 0, 0, time.UTC)
+
+	t.Run("Aircraft", func(t *testing.T) {
+		testCSVRoundTrip(t, []Aircraft{
+			{ID: "AC1", Type: "Commercial", Manufacturer: "Boeing", Model: "787-9, \"Dreamliner\"", Registration: "N1\nX", Location: GeoPoint{Latitude: 51.4706, Longitude: -0.461941}, Altitude: 36000, Speed: 480, Heading: 270, Status: "In Flight", LastUpdated: updated},
+			{ID: "AC2", Type: "Cargo", Manufacturer: "Airbus", Model: "A330F", Location: GeoPoint{Latitude: -33.9399, Longitude: 151.1753}, Status: "Landed"},
+		}, "", nil)
+	})
+
+	t.Run("Flights", func(t *testing.T) {
+		testCSVRoundTrip(t, []Flight{
+			{FlightNumber: "BA117", Airline: "British Airways", Origin: "LHR", Destination: "JFK", DepartureTime: updated, ArrivalTime: updated.Add(8 * time.Hour), Status: "Scheduled", StatusCode: clients.FlightStatus("scheduled"), Aircraft: "AC1", Distance: 5540, Duration: 480, Gate: "A10", Position: GeoPoint{Latitude: 51.47, Longitude: -0.45}},
+			{FlightNumber: "QF1", Airline: "Qantas, \"The Flying Kangaroo\"", Origin: "SYD", Destination: "LHR"},
+		}, "", nil)
+	})
+
+	t.Run("NoFlyZones", func(t *testing.T) {
+		testCSVRoundTrip(t, []NoFlyZone{
+			{CountryISO2: "RU", CountryName: "Russia", Reason: "Airspace closure reported: \"no-fly zone\", western border", Source: NoFlyZoneNews, Confidence: 0.85, EffectiveFrom: &from, SourceURLs: []string{"https://example.com/a?x=1,2", "https://example.com/b"}},
+			{CountryISO2: "KP", CountryName: "North Korea", Reason: "Travel advisory:\nDo not travel", Source: NoFlyZoneStatic, Confidence: 0.5, SourceURLs: []string{}},
+		}, "", nil)
+	})
+
+	t.Run("Geopolitical", func(t *testing.T) {
+		risks := map[string]*GeopoliticalRisk{
+			"DE": {Country: "DE", RiskLevel: 2, Factors: []string{"Strikes, \"walkouts\""}, Advisory: "Exercise normal precautions", LastUpdated: "2024-03-01T12:00:00Z"},
+			"KP": {Country: "KP", RiskLevel: 10, Advisory: "Do not travel", AdvisorySource: "static"},
+		}
+		keys, list := mapEntries(risks)
+		testCSVRoundTrip(t, list.([]*GeopoliticalRisk), "country", keys)
+	})
+}
+
+// testCSVRoundTrip writes list as CSV and checks that it reads back the same
+func testCSVRoundTrip[T any](t *testing.T, list []T, keyColumn string, keys []string) {
+	t.Helper()
+
+	table, err := flattenList(list, keyColumn, keys)
+	if err != nil {
+		t.Fatalf("flattening: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, table); err != nil {
+		t.Fatalf("writing CSV: %v", err)
+	}
+
+	var decoded []T
+	decodedKeys, err := readCSV(bytes.NewReader(buf.Bytes()), keyColumn, &decoded)
+	if err != nil {
+		t.Fatalf("reading CSV back: %v\n%s", err, buf.String())
+	}
+	want, _ := json.Marshal(list)
+	got, _ := json.Marshal(decoded)
+	if !bytes.Equal(want, got) {
+		t.Errorf("CSV did not round-trip:\nwant %s\ngot  %s\nCSV:\n%s", want, got, buf.String())
+	}
+	if fmt.Sprint(keys) != fmt.Sprint(decodedKeys) {
+		t.Errorf("keys did not round-trip: want %v, got %v", keys, decodedKeys)
+	}
+}
+
+// readCSV reads CSV written by writeCSV into target, a pointer to a slice of
+// structs or of pointers to them, returning the keyColumn column if given,
+// whether it was added by flattenList or is a field of the items.
+// Empty cells are left unset, so nil pointers stay nil.
+func readCSV(r io.Reader, keyColumn string, target interface{}) ([]string, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no header row")
+	}
+
+	list := reflect.ValueOf(target).Elem()
+	itemType := list.Type().Elem()
+	structType := itemType
+	if itemType.Kind() == reflect.Pointer {
+		structType = itemType.Elem()
+	}
+	columns := make(map[string][]int)
+	for _, column := range flatColumns(structType) {
+		columns[column.name] = column.index
+	}
+
+	header := records[0]
+	var keys []string
+	for row, record := range records[1:] {
+		item := reflect.New(structType)
+		for i, cell := range record {
+			index, ok := columns[header[i]]
+			if header[i] == keyColumn && keyColumn != "" {
+				keys = append(keys, cell)
+				if !ok {
+					continue
+				}
+			}
+			if !ok {
+				return nil, fmt.Errorf("row %d: unknown column %s", row+1, header[i])
+			}
+			if cell == "" {
+				continue
+			}
+			if err := setFlatCell(item.Elem(), index, cell); err != nil {
+				return nil, fmt.Errorf("row %d: %s: %w", row+1, header[i], err)
+			}
+		}
+		if itemType.Kind() == reflect.Pointer {
+			list.Set(reflect.Append(list, item))
+		} else {
+			list.Set(reflect.Append(list, item.Elem()))
+		}
+	}
+	return keys, nil
+}
+
+// setFlatCell parses cell into the field at index in v, a struct, allocating
+// the pointers on the way to it
+func setFlatCell(v reflect.Value, index []int, cell string) error {
+	for _, i := range index {
+		if v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if _, ok := v.Interface().(time.Time); ok {
+		t, err := time.Parse(time.RFC3339Nano, cell)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(cell, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return json.Unmarshal([]byte(cell), v.Addr().Interface())
+	}
+	return nil
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/your-project/clients"
+)
+
+func TestMockProviderConformance(t *testing.T) {
+	RunProviderConformance(t, func() DataProvider { return NewMockProvider() })
+}
+
+func TestLiveProviderConformance(t *testing.T) {
+	RunProviderConformance(t, func() DataProvider { return NewLiveProviderWithFetcher(newMockedFetcher()) })
+}
+
+// newMockedFetcher returns a fetcher whose every upstream API is answered by
+// mockUpstream, so the live provider runs without network access or keys
+func newMockedFetcher() *clients.Fetcher {
+	fetcher := clients.NewFetcher()
+	for _, api := range fetcher.ListAPIs() {
+		fetcher.SetMock(api, func(endpoint string, params map[string]string) ([]byte, error) {
+			return mockUpstream(api, endpoint, params)
+		})
+	}
+	return fetcher
+}
+
+// mockUpstream answers a request for endpoint of api with a small, fixed
+// payload in the upstream's format. aviation-edge honours limit, depIata and
+// arrIata; other APIs return empty results.
+func mockUpstream(api, endpoint string, params map[string]string) ([]byte, error) {
+	if api != "aviation-edge" {
+		switch api {
+		case "world-bank":
+			return []byte(`[{"page":1,"pages":1,"per_page":"50","total":0},[]]`), nil
+		case "newsapi":
+			return []byte(`{"status":"ok","totalResults":0,"articles":[]}`), nil
+		case "faa-notam":
+			return []byte(`{"pageSize":0,"pageNum":1,"totalCount":0,"totalPages":0,"items":[]}`), nil
+		case "noaa":
+			return []byte(`[]`), nil
+		}
+		return []byte(`{}`), nil
+	}
+
+	limit := 20
+	if n, err := strconv.Atoi(params["limit"]); err == nil {
+		limit = n
+	}
+	switch endpoint {
+	case "airplaneDatabase":
+		aircraft := make([]map[string]string, 0, limit)
+		for i := 0; i < limit; i++ {
+			aircraft = append(aircraft, map[string]string{
+				"airplaneId":         strconv.Itoa(1000 + i),
+				"numberRegistration": fmt.Sprintf("N%dMK", 100+i),
+				"productionLine":     "Boeing 737 NG",
+				"airplaneIataType":   "B737-800",
+				"planeModel":         "737",
+				"planeStatus":        "active",
+			})
+		}
+		return json.Marshal(aircraft)
+	case "flights", "timetable", "flightsFuture":
+		dep, arr := params["depIata"], params["arrIata"]
+		if dep == "" {
+			dep = "JFK"
+		}
+		if arr == "" {
+			arr = "LAX"
+		}
+		flights := make([]map[string]interface{}, 0, limit)
+		for i := 0; i < limit; i++ {
+			flights = append(flights, map[string]interface{}{
+				"departure": map[string]string{"iataCode": dep},
+				"arrival":   map[string]string{"iataCode": arr},
+				"flight":    map[string]string{"iataNumber": fmt.Sprintf("MK%d", 100+i), "number": strconv.Itoa(100 + i)},
+				"airline":   map[string]string{"name": "Mock Air", "iataCode": "MK"},
+				"status":    "en-route",
+			})
+		}
+		return json.Marshal(flights)
+	case "airportWeather":
+		return []byte(`{"airport_icao":"KJFK","airport_iata":"JFK","current_weather":{"temperature":{"celsius":18},"wind":{"direction":270,"speed":12,"unit":"kt"},"visibility":{"miles":10,"meters":16093},"pressure":{"hPa":1015}}}`), nil
+	}
+	return []byte(`[]`), nil
+}
+
+// RunProviderConformance runs the DataProvider conformance suite against the
+// providers returned by newProvider. Every provider implementation is wired
+// up here, so new providers must pass it too. A provider that declares it
+// requires credentials is skipped while it has none.
+func RunProviderConformance(t *testing.T, newProvider func() DataProvider) {
+	t.Helper()
+
+	caps := providerCapabilities(newProvider())
+	if caps.RequiresCredentials && !newProvider().Ping() {
+		t.Skip("provider requires credentials and has none configured")
+	}
+
+	t.Run("Identity", func(t *testing.T) {
+		p := newProvider()
+		if p.Name() == "" {
+			t.Fatal("Name() returned an empty string")
+		}
+		p.Ping()
+	})
+
+	t.Run("Cancellation", func(t *testing.T) {
+		testProviderCancellation(t, newProvider, caps)
+	})
+
+	t.Run("AircraftCount", func(t *testing.T) {
+		testProviderAircraftCount(t, newProvider, caps)
+	})
+
+	t.Run("Routes", func(t *testing.T) {
+		testProviderRoutes(t, newProvider, caps)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		testProviderConcurrent(t, newProvider, caps)
+	})
+
+	t.Run("Consistency", func(t *testing.T) {
+		testProviderConsistency(t, newProvider, caps)
+	})
+}
+
+// checkEnvironmentData verifies the invariants every successful response must hold
+func checkEnvironmentData(t *testing.T, envData *FlightEnvironmentData) {
+	t.Helper()
+
+	if envData == nil {
+		t.Fatal("GetFlightEnvironment returned nil data without an error")
+	}
+	if envData.Timestamp == "" {
+		t.Error("Timestamp is empty")
+	} else if _, err := time.Parse(time.RFC3339, envData.Timestamp); err != nil {
+		t.Errorf("Timestamp %q is not RFC3339: %v", envData.Timestamp, err)
+	}
+	if envData.Weather == nil {
+		t.Error("Weather map is nil")
+	}
+	if envData.Geopolitical == nil {
+		t.Error("Geopolitical map is nil")
+	}
+	if envData.Sustainability == nil {
+		t.Error("Sustainability map is nil")
+	}
+	for _, flight := range envData.Flights {
+		if flight.StatusCode != clients.NormalizeStatus(flight.Status) {
+			t.Errorf("flight %s has status code %q for status %q", flight.FlightNumber, flight.StatusCode, flight.Status)
+		}
+	}
+}
+
+func testProviderCancellation(t *testing.T, newProvider func() DataProvider, caps ProviderCapabilities) {
+	tests := []struct {
+		name string
+		// preCancelled means the context is already done when the fetch starts
+		preCancelled bool
+		newCtx       func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name:         "cancelled before fetch",
+			preCancelled: true,
+			newCtx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx, cancel
+			},
+		},
+		{
+			name:         "deadline already exceeded",
+			preCancelled: true,
+			newCtx: func() (context.Context, context.CancelFunc) {
+				return context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+			},
+		},
+		{
+			name: "cancelled mid-fetch",
+			newCtx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(time.Millisecond, cancel)
+				return ctx, cancel
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.newCtx()
+			defer cancel()
+
+			start := time.Now()
+			envData, err := newProvider().GetFlightEnvironment(ctx, map[string]string{"aircraft_count": "5"})
+			elapsed := time.Since(start)
+
+			if !caps.Cancellation {
+				t.Logf("provider declares no cancellation support (err=%v)", err)
+				if err == nil {
+					checkEnvironmentData(t, envData)
+				}
+				return
+			}
+
+			if err == nil {
+				if tt.preCancelled {
+					t.Fatal("expected an error for a cancelled context, got nil")
+				}
+				// The fetch finished before the cancellation landed, which
+				// is fine as long as the data is complete
+				checkEnvironmentData(t, envData)
+				return
+			}
+			if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected a context error, got %v", err)
+			}
+			if elapsed > 5*time.Second {
+				t.Errorf("provider took %v to notice cancellation", elapsed)
+			}
+		})
+	}
+}
+
+func testProviderAircraftCount(t *testing.T, newProvider func() DataProvider, caps ProviderCapabilities) {
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{"zero", 0},
+		{"one", 1},
+		{"default size", 5},
+		{"large", 250},
+		{"huge", 10000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]string{"aircraft_count": strconv.Itoa(tt.count)}
+			envData, err := newProvider().GetFlightEnvironment(context.Background(), params)
+			if err != nil {
+				t.Fatalf("GetFlightEnvironment(aircraft_count=%d) failed: %v", tt.count, err)
+			}
+			checkEnvironmentData(t, envData)
+
+			want := tt.count
+			if caps.MaxAircraftCount > 0 && want > caps.MaxAircraftCount {
+				want = caps.MaxAircraftCount
+			}
+
+			got := len(envData.Aircraft)
+			if caps.ExactAircraftCount && got != want {
+				t.Errorf("expected %d aircraft, got %d", want, got)
+			}
+			if got > want {
+				t.Errorf("expected at most %d aircraft, got %d", want, got)
+			}
+		})
+	}
+}
+
+func testProviderRoutes(t *testing.T, newProvider func() DataProvider, caps ProviderCapabilities) {
+	tests := []struct {
+		route     string
+		malformed bool
+	}{
+		{"", false},
+		{"JFK-LAX", false},
+		{"JFK", true},
+		{"JFK-", true},
+		{"-LAX", true},
+		{"JFKLAX", true},
+		{"J-K-L", true},
+		{"JFK-LA", true},
+		{"JFKXLAX", true},
+		{"QQQ-ZZZ", true}, // well-formed but unknown airports
+		{"✈✈✈-✈✈✈", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("route=%q", tt.route), func(t *testing.T) {
+			params := map[string]string{"aircraft_count": "2"}
+			if tt.route != "" {
+				params["route"] = tt.route
+			}
+
+			envData, err := newProvider().GetFlightEnvironment(context.Background(), params)
+			switch {
+			case tt.malformed && caps.RejectsMalformedRoutes:
+				if err == nil {
+					t.Errorf("expected an error for malformed route %q", tt.route)
+				}
+				return
+			case err != nil:
+				t.Fatalf("GetFlightEnvironment(route=%q) failed: %v", tt.route, err)
+			}
+			checkEnvironmentData(t, envData)
+
+			if tt.route == "" && len(envData.Sustainability) != 0 {
+				t.Errorf("expected no sustainability data without a route, got %d entries", len(envData.Sustainability))
+			}
+			if tt.malformed && envData.Sustainability[tt.route] != nil {
+				t.Errorf("expected no sustainability data for malformed route %q", tt.route)
+			}
+		})
+	}
+}
+
+func testProviderConcurrent(t *testing.T, newProvider func() DataProvider, caps ProviderCapabilities) {
+	if !caps.ConcurrentSafe {
+		t.Log("provider declares no concurrent-call support, checking sequential calls only")
+	}
+
+	const calls = 8
+	p := newProvider()
+	errs := make(chan error, calls)
+
+	var wg sync.WaitGroup
+	for i := 0; i < calls; i++ {
+		call := func(i int) {
+			defer wg.Done()
+			params := map[string]string{"aircraft_count": strconv.Itoa(i + 1), "route": "JFK-LAX"}
+			envData, err := p.GetFlightEnvironment(context.Background(), params)
+			if err != nil {
+				errs <- fmt.Errorf("call %d failed: %w", i, err)
+				return
+			}
+			if envData == nil || envData.Timestamp == "" {
+				errs <- fmt.Errorf("call %d returned incomplete data", i)
+			}
+		}
+
+		wg.Add(1)
+		if caps.ConcurrentSafe {
+			go call(i)
+		} else {
+			call(i)
+		}
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func testProviderConsistency(t *testing.T, newProvider func() DataProvider, caps ProviderCapabilities) {
+	if !caps.ConsistentSections {
+		t.Log("provider declares no consistency between sections, nothing to check")
+		return
+	}
+
+	tests := []map[string]string{
+		{"aircraft_count": "5"},
+		{"aircraft_count": "0"},
+		{"aircraft_count": "3", "airports": "JFK,LHR"},
+		{"aircraft_count": "5", "route": "JFK-LHR"},
+		{"aircraft_count": "8", "route": "JFK-LHR-DXB"},
+	}
+	for _, params := range tests {
+		t.Run(fmt.Sprint(params), func(t *testing.T) {
+			// Mock scenarios are random, so check several
+			for i := 0; i < 5; i++ {
+				envData, err := newProvider().GetFlightEnvironment(context.Background(), params)
+				if err != nil {
+					t.Fatalf("GetFlightEnvironment failed: %v", err)
+				}
+				checkEnvironmentData(t, envData)
+				checkReferentialIntegrity(t, envData)
+			}
+		})
+	}
+}
+
+// checkReferentialIntegrity verifies the sections of a response describe
+// one scenario
+func checkReferentialIntegrity(t *testing.T, envData *FlightEnvironmentData) {
+	t.Helper()
+
+	aircraft := make(map[string]bool, len(envData.Aircraft))
+	for _, a := range envData.Aircraft {
+		aircraft[a.ID] = true
+	}
+	for _, flight := range envData.Flights {
+		if flight.Aircraft != "" && !aircraft[flight.Aircraft] {
+			t.Errorf("flight %s flies aircraft %s, which is not in the aircraft section", flight.FlightNumber, flight.Aircraft)
+		}
+		for _, airport := range []string{flight.Origin, flight.Destination} {
+			if envData.Weather[airport] == nil {
+				t.Errorf("flight %s uses airport %s, which has no weather", flight.FlightNumber, airport)
+			}
+		}
+		leg := flight.Origin + "-" + flight.Destination
+		if sustainability := envData.Sustainability[leg]; sustainability != nil && sustainability.Distance != flight.Distance {
+			t.Errorf("flight %s is %d km, but the sustainability of %s gives %d km", flight.FlightNumber, flight.Distance, leg, sustainability.Distance)
+		}
+	}
+}
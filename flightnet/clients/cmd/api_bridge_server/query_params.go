@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultAircraftCount is how many aircraft a flight environment has
+	// without aircraft_count
+	defaultAircraftCount = 5
+	// defaultMaxAircraftCount caps aircraft_count unless
+	// -max-aircraft-count or BRIDGE_MAX_AIRCRAFT_COUNT is set
+	defaultMaxAircraftCount = 500
+	// maxPageParam bounds the limit and offset of a page, which only have to
+	// stay clear of overflow
+	maxPageParam = math.MaxInt32
+)
+
+// ErrInvalidParameter is returned for a numeric query parameter that does
+// not parse or is out of range, or an unknown format
+var ErrInvalidParameter = errors.New("invalid parameter")
+
+// parsePositiveInt parses raw, the value of the query parameter name, as an
+// integer from min to max
+func parsePositiveInt(name, raw string, min, max int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, fmt.Errorf("%w %s %q: must be an integer from %d to %d", ErrInvalidParameter, name, raw, min, max)
+	}
+	if n < min || n > max {
+		return 0, fmt.Errorf("%w %s %d: must be from %d to %d", ErrInvalidParameter, name, n, min, max)
+	}
+	return n, nil
+}
+
+// parseAircraftCount validates the aircraft_count parameter against the
+// server's maximum. Without it the count is the providers' default, and
+// defaulted is set.
+func (s *APIBridgeServer) parseAircraftCount(params map[string]string) (count int, defaulted bool, err error) {
+	raw, ok := params["aircraft_count"]
+	if !ok {
+		return defaultAircraftCount, true, nil
+	}
+	if count, err = parsePositiveInt("aircraft_count", raw, 0, s.maxAircraftCount); err != nil {
+		return 0, false, err
+	}
+	return count, false, nil
+}
+
+// SetMaxAircraftCount sets the largest aircraft_count a request may ask
+// for. It must be called before the server starts.
+func (s *APIBridgeServer) SetMaxAircraftCount(n int) {
+	s.maxAircraftCount = n
+}
+
+// writeParamError responds 400 to a query parameter that does not parse, in
+// JSON for the numeric parameters parsePositiveInt rejects and the format
+func writeParamError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrInvalidParameter) {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSONError(w, http.StatusBadRequest, err.Error())
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBadRequestsAreJSON(t *testing.T) {
+	_, h := newTestHandler(t, serverOptions{})
+	paths := []string{
+		"/flight-environment?aircraft_count=abc",
+		"/flight-environment?aircraft_count=-5",
+		"/flight-environment?route=JFK",
+		"/flight-environment?sections=nonsense",
+		"/flight-environment?format=xml",
+		"/flight-environment?format=csv",
+		"/flights",
+		"/flights?bbox=1,2,3",
+		"/flights?near=north",
+		"/airports/JFK/departures?window=forever",
+		"/sustainability/passenger?route=JFK-LAX&load_factor=2",
+		"/sustainability/compare?aircraft=&distance=100",
+		"/route-weather?route=JFK-LAX&samples=-1",
+	}
+	for _, path := range paths {
+		t.Run(path, func(t *testing.T) {
+			rec := serve(h, httptest.NewRequest(http.MethodGet, path, nil))
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, want 400: %s", rec.Code, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+				t.Errorf("Content-Type %q, want JSON", ct)
+			}
+			var body struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error == "" {
+				t.Errorf("body %q is not a JSON error: %v", rec.Body, err)
+			}
+			if strings.HasPrefix(body.Error, "Error:") {
+				t.Errorf("error %q keeps the plain text prefix", body.Error)
+			}
+		})
+	}
+}
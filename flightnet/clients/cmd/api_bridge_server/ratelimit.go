@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Route groups, each with its own rate limit
+const (
+	RouteGroupHealth  = "health"  // /health
+	RouteGroupLive    = "live"    // Requests reaching the upstream APIs
+	RouteGroupDefault = "default" // Everything else
+)
+
+// RateLimit is how many requests a client may make in a route group
+type RateLimit struct {
+	RequestsPerMinute float64 // 0 for no limit
+	Burst             int     // Requests allowed at once after being idle
+}
+
+// defaultRateLimits are the limits of each route group unless -rate-limits
+// or BRIDGE_RATE_LIMITS override them. Live data spends upstream quota, so
+// its limit is the strictest.
+var defaultRateLimits = map[string]RateLimit{
+	RouteGroupHealth:  {RequestsPerMinute: 600, Burst: 60},
+	RouteGroupLive:    {RequestsPerMinute: 30, Burst: 10},
+	RouteGroupDefault: {RequestsPerMinute: 120, Burst: 30},
+}
+
+// rateLimitSweepPeriod is how often idle clients' buckets are dropped
+const rateLimitSweepPeriod = time.Minute
+
+// tokenBucket is a token bucket rate limiter
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64 // Maximum number of tokens
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// refill adds the tokens earned since the last call. b.mu must be held.
+func (b *tokenBucket) refill(now time.Time) {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// allow takes a token, or returns how long until one is available
+func (b *tokenBucket) allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// remaining returns the tokens available now and whether the bucket is full
+func (b *tokenBucket) remaining() (float64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	return b.tokens, b.tokens >= b.burst
+}
+
+// parseRateLimits parses comma-separated group=requests-per-minute:burst
+// limits, such as live=30:10, over the defaults. The burst may be left out
+// to keep the group's default.
+func parseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit, len(defaultRateLimits))
+	for group, limit := range defaultRateLimits {
+		limits[group] = limit
+	}
+	for _, definition := range strings.Split(value, ",") {
+		if strings.TrimSpace(definition) == "" {
+			continue
+		}
+		group, spec, ok := strings.Cut(definition, "=")
+		group = strings.TrimSpace(group)
+		limit, known := limits[group]
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid rate limit %q: must be group=requests-per-minute[:burst] with group health, live or default", definition)
+		}
+		rate, burst, hasBurst := strings.Cut(spec, ":")
+		perMinute, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+		if err != nil || perMinute < 0 {
+			return nil, fmt.Errorf("invalid rate limit %q: requests per minute must be a number of at least 0", definition)
+		}
+		limit.RequestsPerMinute = perMinute
+		if hasBurst {
+			n, err := strconv.Atoi(strings.TrimSpace(burst))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid rate limit %q: burst must be at least 1", definition)
+			}
+			limit.Burst = n
+		}
+		limits[group] = limit
+	}
+	return limits, nil
+}
+
+// routeGroup returns the route group of r. Requests for the live provider
+// count as live whichever endpoint serves them.
+func routeGroup(r *http.Request) string {
+	switch {
+	case r.URL.Path == "/health":
+		return RouteGroupHealth
+	case r.URL.Path == "/flight-environment/live", r.URL.Query().Get("provider") == "live":
+		return RouteGroupLive
+	}
+	return RouteGroupDefault
+}
+
+// rateLimitClient identifies who r counts against: the label of its API key
+// when authentication is enabled, otherwise its IP address
+func rateLimitClient(r *http.Request) string {
+	if label := APIKeyLabel(r.Context()); label != "" {
+		return "key:" + label
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// RateLimitGroupStats is the state of a route group's rate limiting
+type RateLimitGroupStats struct {
+	RequestsPerMinute float64 `json:"requests_per_minute"`
+	Burst             int     `json:"burst"`
+	Allowed           int64   `json:"allowed"`
+	Limited           int64   `json:"limited"`
+	// Clients maps the clients with a partly used bucket to their remaining
+	// requests
+	Clients map[string]float64 `json:"clients"`
+}
+
+// rateLimiter limits each client's requests to each route group with a
+// token bucket per client and group
+type rateLimiter struct {
+	mu        sync.Mutex
+	limits    map[string]RateLimit
+	buckets   map[string]map[string]*tokenBucket // By group, then client
+	allowed   map[string]int64                   // By group
+	limited   map[string]int64                   // By group
+	lastSweep time.Time
+}
+
+// newRateLimiter creates a limiter applying limits, by route group
+func newRateLimiter(limits map[string]RateLimit) *rateLimiter {
+	l := &rateLimiter{
+		limits:    limits,
+		buckets:   make(map[string]map[string]*tokenBucket),
+		allowed:   make(map[string]int64),
+		limited:   make(map[string]int64),
+		lastSweep: time.Now(),
+	}
+	for group := range limits {
+		l.buckets[group] = make(map[string]*tokenBucket)
+	}
+	return l
+}
+
+// allow counts a request by client to group, returning false and how long
+// to wait when the client has exceeded the group's limit
+func (l *rateLimiter) allow(group, client string) (bool, time.Duration) {
+	l.mu.Lock()
+	limit, ok := l.limits[group]
+	if !ok || limit.RequestsPerMinute == 0 {
+		l.allowed[group]++
+		l.mu.Unlock()
+		return true, 0
+	}
+	l.sweep()
+	bucket, ok := l.buckets[group][client]
+	if !ok {
+		bucket = newTokenBucket(limit.RequestsPerMinute/60, limit.Burst)
+		l.buckets[group][client] = bucket
+	}
+	l.mu.Unlock()
+
+	allowed, wait := bucket.allow()
+
+	l.mu.Lock()
+	if allowed {
+		l.allowed[group]++
+	} else {
+		l.limited[group]++
+	}
+	l.mu.Unlock()
+	return allowed, wait
+}
+
+// sweep drops full buckets, which behave like new ones, once every
+// rateLimitSweepPeriod so clients that went away are forgotten. l.mu must be
+// held.
+func (l *rateLimiter) sweep() {
+	if time.Since(l.lastSweep) < rateLimitSweepPeriod {
+		return
+	}
+	l.lastSweep = time.Now()
+	for _, clients := range l.buckets {
+		for client, bucket := range clients {
+			if _, full := bucket.remaining(); full {
+				delete(clients, client)
+			}
+		}
+	}
+}
+
+// Stats returns the state of each route group
+func (l *rateLimiter) Stats() map[string]RateLimitGroupStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := make(map[string]RateLimitGroupStats, len(l.limits))
+	for group, limit := range l.limits {
+		clients := make(map[string]float64)
+		for client, bucket := range l.buckets[group] {
+			if tokens, full := bucket.remaining(); !full {
+				clients[client] = math.Floor(tokens*100) / 100
+			}
+		}
+		stats[group] = RateLimitGroupStats{
+			RequestsPerMinute: limit.RequestsPerMinute,
+			Burst:             limit.Burst,
+			Allowed:           l.allowed[group],
+			Limited:           l.limited[group],
+			Clients:           clients,
+		}
+	}
+	return stats
+}
+
+// describe lists the limits for the startup banner
+func (l *rateLimiter) describe() string {
+	groups := make([]string, 0, len(l.limits))
+	for group := range l.limits {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	parts := make([]string, 0, len(groups))
+	for _, group := range groups {
+		limit := l.limits[group]
+		if limit.RequestsPerMinute == 0 {
+			parts = append(parts, group+" unlimited")
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %g/min burst %d", group, limit.RequestsPerMinute, limit.Burst))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SetRateLimits replaces the per-client rate limits of each route group,
+// forgetting every client's usage
+func (s *APIBridgeServer) SetRateLimits(limits map[string]RateLimit) {
+	s.rateLimiter = newRateLimiter(limits)
+}
+
+// rateLimitMiddleware rejects requests from clients that exceed their route
+// group's limit with 429 Too Many Requests. It must run after
+// apiKeyMiddleware so clients with an API key are told apart by key.
+func (s *APIBridgeServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		group, client := routeGroup(r), rateLimitClient(r)
+		if ok, wait := s.rateLimiter.allow(group, client); !ok {
+			logFor(r.Context()).Warn("rate limited", "client", client, "group", group)
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeJSONError(w, http.StatusTooManyRequests, fmt.Sprintf("rate limit of %g %s requests per minute exceeded", s.rateLimiter.limits[group].RequestsPerMinute, group))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/your-project/clients"
+)
+
+const (
+	// Limits of the limit parameter of /aircraft
+	defaultResourceLimit = 10
+	maxResourceLimit     = 100
+	// resourceTimeout bounds the upstream requests for one resource
+	resourceTimeout = 30 * time.Second
+)
+
+// ResourceProvider is implemented by providers that can fetch one section
+// of the flight environment on its own, for the resource endpoints
+type ResourceProvider interface {
+	DataProvider
+	// GetAircraft returns up to limit aircraft
+	GetAircraft(ctx context.Context, limit int) ([]Aircraft, error)
+	// GetFlights returns the flights from dep to arr, by IATA code. Either
+	// may be empty to match any airport, but not both.
+	GetFlights(ctx context.Context, dep, arr string) ([]Flight, error)
+	// GetWeather returns the weather at an airport, by IATA code
+	GetWeather(ctx context.Context, airport string) (*WeatherData, error)
+	// GetCountryRisk returns the risk of a country, by ISO 3166-1 alpha-2
+	// code, failing with ErrCountryNotFound when it has no assessment
+	GetCountryRisk(ctx context.Context, country string) (*GeopoliticalRisk, error)
+	// GetNews returns the geopolitical news for topics
+	GetNews(ctx context.Context, topics []string) (*NewsResponse, error)
+}
+
+// resourceProvider resolves the provider parameter of r for a resource
+// endpoint, writing the error response when it names no provider or one
+// that cannot fetch resources on their own
+func (s *APIBridgeServer) resourceProvider(w http.ResponseWriter, r *http.Request) (ResourceProvider, bool) {
+	provider, err := s.resolveProvider(r.URL.Query().Get("provider"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	setRequestProvider(r.Context(), provider.Name())
+	resources, ok := provider.(ResourceProvider)
+	if !ok {
+		http.Error(w, fmt.Sprintf("Error: provider %q only serves the whole flight environment", provider.Name()), http.StatusNotImplemented)
+		return nil, false
+	}
+	return resources, true
+}
+
+// writeResource sends a resource as JSON, or the error fetching it: 404 for
+// an unknown airport or country, 503 when the live provider has no key and
+// otherwise 502
+func writeResource(w http.ResponseWriter, r *http.Request, name string, resource interface{}, err error) {
+	if errors.Is(err, clients.ErrAirportNotFound) || errors.Is(err, ErrCountryNotFound) {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	if errors.Is(err, errLiveUnavailable) {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		logFor(r.Context()).Error("error getting "+name, "error", err)
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resource); err != nil {
+		logFor(r.Context()).Error("error encoding "+name+" to JSON", "error", err)
+	}
+}
+
+// getAircraft lists aircraft, e.g. /aircraft?limit=20
+func (s *APIBridgeServer) getAircraft(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("received request for aircraft")
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	limit := defaultResourceLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		n, err := parsePositiveInt("limit", limitStr, 1, maxResourceLimit)
+		if err != nil {
+			writeParamError(w, err)
+			return
+		}
+		limit = n
+	}
+	provider, ok := s.resourceProvider(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), resourceTimeout)
+	defer cancel()
+	aircraft, err := provider.GetAircraft(ctx, limit)
+	writeResourceList(w, r, "aircraft", format, aircraft, err)
+}
+
+// getFlightsByAirport lists the flights between the dep and arr airports,
+// e.g. /flights?dep=JFK&arr=LHR, or from or to one airport
+func (s *APIBridgeServer) getFlightsByAirport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	logFor(r.Context()).Info("received request for flights by airport", "dep", query.Get("dep"), "arr", query.Get("arr"))
+
+	format, err := negotiateFormat(r)
+	if err != nil {
+		writeParamError(w, err)
+		return
+	}
+	airports := clients.NewEmbeddedAirportsAPI()
+	codes := make(map[string]string, 2)
+	for _, param := range []string{"dep", "arr"} {
+		code := strings.TrimSpace(query.Get(param))
+		if code == "" {
+			continue
+		}
+		iata, err := resolveAirportCode(airports, code)
+		if errors.Is(err, clients.ErrAirportNotFound) {
+			http.Error(w, fmt.Sprintf("Error: %s: %v", param, err), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("%s: %v", param, err))
+			return
+		}
+		codes[param] = iata
+	}
+	if codes["dep"] != "" && codes["dep"] == codes["arr"] {
+		writeJSONError(w, http.StatusBadRequest, "dep and arr must be different airports")
+		return
+	}
+	provider, ok := s.resourceProvider(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), resourceTimeout)
+	defer cancel()
+	flights, err := provider.GetFlights(ctx, codes["dep"], codes["arr"])
+	if flights == nil {
+		flights = []Flight{}
+	}
+	writeResourceList(w, r, "flights", format, flights, err)
+}
+
+// getAirportWeather reports the weather at an airport, by IATA or ICAO code,
+// e.g. /weather/LHR
+func (s *APIBridgeServer) getAirportWeather(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["airport"]
+	logFor(r.Context()).Info("received request for airport weather", "airport", code)
+
+	iata, err := resolveAirportCode(clients.NewEmbeddedAirportsAPI(), code)
+	if errors.Is(err, clients.ErrAirportNotFound) {
+		http.Error(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	provider, ok := s.resourceProvider(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), resourceTimeout)
+	defer cancel()
+	weather, err := provider.GetWeather(ctx, iata)
+	writeResource(w, r, "weather", weather, err)
+}
+
+// getCountryRisk reports the geopolitical risk of a country, e.g. /risk/RU
+func (s *APIBridgeServer) getCountryRisk(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("received request for country risk", "country", mux.Vars(r)["country"])
+
+	country, err := normalizeCountry(mux.Vars(r)["country"])
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	provider, ok := s.resourceProvider(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), resourceTimeout)
+	defer cancel()
+	risk, err := provider.GetCountryRisk(ctx, country)
+	writeResource(w, r, "country risk", risk, err)
+}
+
+// getNews reports geopolitical news, e.g. /news?topics=Iran,Russia, for the
+// default topics when none are given
+func (s *APIBridgeServer) getNews(w http.ResponseWriter, r *http.Request) {
+	logFor(r.Context()).Info("received request for news", "topics", r.URL.Query().Get("topics"))
+
+	topics := defaultNewsTopics
+	if r.URL.Query().Has("topics") {
+		var err error
+		if topics, err = parseListParam(map[string]string{"topics": r.URL.Query().Get("topics")}, "topics", maxEnvironmentTopics, normalizeTopic); err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	provider, ok := s.resourceProvider(w, r)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), resourceTimeout)
+	defer cancel()
+	news, err := provider.GetNews(ctx, topics)
+	writeResource(w, r, "news", news, err)
+}
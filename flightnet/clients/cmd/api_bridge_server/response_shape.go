@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// selectableSections are the top-level fields of FlightEnvironmentData the
+// sections parameter can name. The timestamp, section statuses, degraded
+// list and meta block are always sent.
+var selectableSections = []string{
+	"aircraft", "flights", "weather", "news", "geopolitical", "sustainability",
+	"notams", "no_fly_zones", "no_fly_zone_codes", "airspace_events", "route_weather",
+	"route_weather_by_leg", "route_news", "emissions_report",
+}
+
+// PageMeta describes the page of a list section that was sent
+type PageMeta struct {
+	Total    int `json:"total"`
+	Offset   int `json:"offset"`
+	Limit    int `json:"limit,omitempty"` // 0 when unlimited
+	Returned int `json:"returned"`
+}
+
+// ResponseMeta reports how a flight environment response was narrowed, and
+// the defaults it was generated with
+type ResponseMeta struct {
+	Sections []string          `json:"sections,omitempty"` // Sections sent, all when empty
+	Aircraft *PageMeta         `json:"aircraft,omitempty"`
+	Flights  *PageMeta         `json:"flights,omitempty"`
+	Defaults map[string]string `json:"defaults,omitempty"` // Parameters not given, with the values applied
+}
+
+// page is a requested window of a list section
+type page struct {
+	set           bool // Whether a limit or offset was given
+	limit, offset int  // A zero limit means no limit
+}
+
+// environmentShape is how a client asked to narrow a flight environment
+// response. The zero value sends everything, as without parameters.
+type environmentShape struct {
+	sections map[string]bool // nil for every section
+	aircraft page
+	flights  page
+	defaults map[string]string // Reported in the meta block, see ResponseMeta
+}
+
+// parsePage parses the limit and offset parameters of a list section
+func parsePage(params map[string]string, limitParam, offsetParam string) (page, error) {
+	var p page
+	if value, ok := params[limitParam]; ok {
+		n, err := parsePositiveInt(limitParam, value, 1, maxPageParam)
+		if err != nil {
+			return page{}, err
+		}
+		p.set, p.limit = true, n
+	}
+	if value, ok := params[offsetParam]; ok {
+		n, err := parsePositiveInt(offsetParam, value, 0, maxPageParam)
+		if err != nil {
+			return page{}, err
+		}
+		p.set, p.offset = true, n
+	}
+	return p, nil
+}
+
+// parseEnvironmentShape parses the sections, aircraft_limit,
+// aircraft_offset, flights_limit and flights_offset parameters
+func parseEnvironmentShape(params map[string]string) (environmentShape, error) {
+	var shape environmentShape
+	if value, ok := params["sections"]; ok {
+		valid := make(map[string]bool, len(selectableSections))
+		for _, name := range selectableSections {
+			valid[name] = true
+		}
+		shape.sections = make(map[string]bool)
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !valid[name] {
+				return environmentShape{}, fmt.Errorf("invalid section %q: valid sections are %s", name, strings.Join(selectableSections, ", "))
+			}
+			shape.sections[name] = true
+		}
+		if len(shape.sections) == 0 {
+			return environmentShape{}, fmt.Errorf("sections must name at least one of %s", strings.Join(selectableSections, ", "))
+		}
+	}
+
+	var err error
+	if shape.aircraft, err = parsePage(params, "aircraft_limit", "aircraft_offset"); err != nil {
+		return environmentShape{}, err
+	}
+	if shape.flights, err = parsePage(params, "flights_limit", "flights_offset"); err != nil {
+		return environmentShape{}, err
+	}
+	return shape, nil
+}
+
+// narrowed reports whether the shape changes the response at all, if only
+// to report defaults
+func (shape environmentShape) narrowed() bool {
+	return shape.sections != nil || shape.aircraft.set || shape.flights.set || len(shape.defaults) > 0
+}
+
+// paginate returns the window of a list with total items described by p
+func paginate(p page, total int) (start, end int, meta *PageMeta) {
+	start = min(p.offset, total)
+	end = total
+	if p.limit > 0 {
+		end = min(start+p.limit, total)
+	}
+	return start, end, &PageMeta{Total: total, Offset: p.offset, Limit: p.limit, Returned: end - start}
+}
+
+// marshalEnvironment encodes envData narrowed to shape, with a meta block
+// when the shape narrows it
+func marshalEnvironment(envData *FlightEnvironmentData, shape environmentShape) ([]byte, error) {
+	if !shape.narrowed() {
+		return json.Marshal(envData)
+	}
+
+	narrowed := *envData
+	meta := &ResponseMeta{Defaults: shape.defaults}
+	if shape.aircraft.set {
+		start, end, pageMeta := paginate(shape.aircraft, len(envData.Aircraft))
+		narrowed.Aircraft, meta.Aircraft = envData.Aircraft[start:end], pageMeta
+	}
+	if shape.flights.set {
+		start, end, pageMeta := paginate(shape.flights, len(envData.Flights))
+		narrowed.Flights, meta.Flights = envData.Flights[start:end], pageMeta
+	}
+	if shape.sections != nil {
+		for _, name := range selectableSections {
+			if shape.sections[name] {
+				meta.Sections = append(meta.Sections, name)
+			}
+		}
+	}
+	narrowed.Meta = meta
+
+	body, err := json.Marshal(&narrowed)
+	if err != nil || shape.sections == nil {
+		return body, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for _, name := range selectableSections {
+		if !shape.sections[name] {
+			delete(fields, name)
+		}
+	}
+	return json.Marshal(fields)
+}
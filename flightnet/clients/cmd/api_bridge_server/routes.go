@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/your-project/clients"
+)
+
+// maxRouteLegs bounds the legs of a route parameter, each of which costs
+// several upstream requests
+const maxRouteLegs = 10
+
+// ErrInvalidRoute is returned for a route parameter that cannot be parsed
+var ErrInvalidRoute = errors.New("invalid route")
+
+// RouteLeg is one origin-destination leg of a route, by IATA code
+type RouteLeg struct {
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+}
+
+// String returns the leg as ORIGIN-DESTINATION, which keys per-leg results
+func (l RouteLeg) String() string {
+	return l.Origin + "-" + l.Destination
+}
+
+// ParseRoutes parses a route parameter into its legs. Routes are airport
+// codes joined by hyphens, IATA or ICAO, so JFK-LHR-DXB is two legs, and
+// several routes may be separated by commas. Every code must be a known
+// airport in airports; ICAO codes are resolved to IATA. Repeated legs are
+// kept once.
+func ParseRoutes(airports *clients.AirportsAPI, param string) ([]RouteLeg, error) {
+	var legs []RouteLeg
+	seen := make(map[RouteLeg]bool)
+	for _, route := range strings.Split(param, ",") {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+		codes := strings.Split(route, "-")
+		if len(codes) < 2 {
+			return nil, fmt.Errorf("%w %q: must be airport codes joined by hyphens, such as JFK-LHR or KJFK-EGLL-OMDB", ErrInvalidRoute, route)
+		}
+		iata := make([]string, len(codes))
+		for i, code := range codes {
+			resolved, err := resolveAirportCode(airports, strings.TrimSpace(code))
+			if err != nil {
+				return nil, fmt.Errorf("%w %q: %w", ErrInvalidRoute, route, err)
+			}
+			iata[i] = resolved
+		}
+		for i := 1; i < len(iata); i++ {
+			leg := RouteLeg{Origin: iata[i-1], Destination: iata[i]}
+			if leg.Origin == leg.Destination {
+				return nil, fmt.Errorf("%w %q: leg %s starts and ends at the same airport", ErrInvalidRoute, route, leg)
+			}
+			if !seen[leg] {
+				seen[leg] = true
+				legs = append(legs, leg)
+			}
+		}
+	}
+	if len(legs) == 0 {
+		return nil, fmt.Errorf("%w: route must name at least two airports, such as JFK-LHR", ErrInvalidRoute)
+	}
+	if len(legs) > maxRouteLegs {
+		return nil, fmt.Errorf("%w: %d legs is more than the %d allowed", ErrInvalidRoute, len(legs), maxRouteLegs)
+	}
+	return legs, nil
+}
+
+// resolveAirportCode returns the IATA code of the airport with a 3-letter
+// IATA or 4-letter ICAO code
+func resolveAirportCode(airports *clients.AirportsAPI, code string) (string, error) {
+	for i := 0; i < len(code); i++ {
+		if (code[i] < 'A' || code[i] > 'Z') && (code[i] < 'a' || code[i] > 'z') {
+			return "", fmt.Errorf("airport code %q must be a 3-letter IATA or 4-letter ICAO code", code)
+		}
+	}
+	switch len(code) {
+	case 3:
+		airport, err := airports.GetAirportByIATA(code)
+		if err != nil {
+			return "", err
+		}
+		return airport.IATA, nil
+	case 4:
+		airport, err := airports.GetAirportByICAO(code)
+		if err != nil {
+			return "", err
+		}
+		if airport.IATA == "" {
+			return "", fmt.Errorf("%s has no IATA code", airport.ICAO)
+		}
+		return airport.IATA, nil
+	}
+	return "", fmt.Errorf("airport code %q must be a 3-letter IATA or 4-letter ICAO code", code)
+}
+
+// routeLegs parses the route parameter for a provider, which has no way to
+// reject it, so a route that does not parse against its airports is logged
+// and ignored
+func routeLegs(airports *clients.AirportsAPI, params map[string]string, logger *slog.Logger) []RouteLeg {
+	if params["route"] == "" {
+		return nil
+	}
+	legs, err := ParseRoutes(airports, params["route"])
+	if err != nil {
+		logger.Warn("ignoring route", "route", params["route"], "error", err)
+		return nil
+	}
+	return legs
+}
+
+// mergeRouteNews combines the news for the countries along several legs
+func mergeRouteNews(into, news map[string]*NewsResponse) map[string]*NewsResponse {
+	if into == nil {
+		into = make(map[string]*NewsResponse, len(news))
+	}
+	for country, countryNews := range news {
+		if _, ok := into[country]; !ok {
+			into[country] = countryNews
+		}
+	}
+	return into
+}
+
+// writeRouteError responds to a route parameter ParseRoutes rejected: 404
+// for an unknown airport, otherwise 400, both with a JSON error body
+func writeRouteError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, clients.ErrAirportNotFound) {
+		status = http.StatusNotFound
+	}
+	writeJSONError(w, status, err.Error())
+}
+
+// perLeg returns the result of a single-leg route as it is, so existing
+// clients see no change, and otherwise the results keyed by leg
+func perLeg[V any](legs []RouteLeg, byLeg map[string]V) interface{} {
+	if len(legs) == 1 {
+		return byLeg[legs[0].String()]
+	}
+	return byLeg
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLiveSectionStatus(t *testing.T) {
+	fetcher := newMockedFetcher()
+	for _, api := range []string{"newsapi", "faa-notam"} {
+		fetcher.SetMock(api, func(endpoint string, params map[string]string) ([]byte, error) {
+			return nil, errors.New("upstream down")
+		})
+	}
+
+	envData, err := NewLiveProviderWithFetcher(fetcher).GetFlightEnvironment(context.Background(), map[string]string{"route": "JFK-LAX"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, section := range []string{sectionNews, sectionNOTAMs} {
+		status := envData.Sections[section]
+		if status == nil || status.Status != SectionFailed || !strings.Contains(status.Error, "upstream down") {
+			t.Errorf("%s section %+v, want failed with the upstream error", section, status)
+		}
+	}
+	for _, section := range []string{sectionAircraft, sectionFlights} {
+		if status := envData.Sections[section]; status == nil || status.Status != SectionOK || status.Source != "aviation-edge" {
+			t.Errorf("%s section %+v, want ok from aviation-edge", section, status)
+		}
+	}
+	for _, section := range envData.Degraded {
+		if status := envData.Sections[section].Status; status != SectionFailed && status != SectionDegraded {
+			t.Errorf("%s is listed degraded with status %s", section, status)
+		}
+	}
+}
+
+// failingProvider answers every flight environment request with the same
+// section statuses
+type failingProvider struct {
+	sections map[string]*SectionStatus
+}
+
+func (p failingProvider) GetFlightEnvironment(ctx context.Context, params map[string]string) (*FlightEnvironmentData, error) {
+	return &FlightEnvironmentData{Sections: p.sections}, nil
+}
+
+func (p failingProvider) Name() string { return "failing" }
+func (p failingProvider) Ping() bool   { return true }
+
+func TestFlightEnvironmentStatusCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		sections map[string]*SectionStatus
+		code     int
+	}{
+		{"every section failed", map[string]*SectionStatus{
+			sectionAircraft:     {Status: SectionFailed},
+			sectionWeather:      {Status: SectionFailed},
+			sectionRouteWeather: {Status: SectionSkipped},
+		}, http.StatusBadGateway},
+		{"one section degraded", map[string]*SectionStatus{
+			sectionAircraft: {Status: SectionFailed},
+			sectionWeather:  {Status: SectionDegraded},
+		}, http.StatusOK},
+	}
+	server := NewAPIBridgeServer()
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		server.handleFlightEnvironment(rec, httptest.NewRequest(http.MethodGet, "/flight-environment", nil), failingProvider{tt.sections})
+		if rec.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.code)
+		}
+		var envData FlightEnvironmentData
+		if err := json.Unmarshal(rec.Body.Bytes(), &envData); err != nil {
+			t.Fatalf("%s: decoding %q: %v", tt.name, rec.Body, err)
+		}
+		if len(envData.Sections) != len(tt.sections) {
+			t.Errorf("%s: response has sections %v, want %d", tt.name, envData.Sections, len(tt.sections))
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/your-project/clients/internal/dataset"
+)
+
+// ErrUnknownCountry is returned for country codes and names that are not in
+// the embedded country table
+var ErrUnknownCountry = errors.New("unknown country")
+
+// ErrUnknownRegion is returned for region names that no country in the
+// embedded country table belongs to
+var ErrUnknownRegion = errors.New("unknown region")
+
+// countriesCSV is the embedded country table
+var countriesCSV = []byte(dataset.Countries)
+
+// countryRecord is a country from the embedded country table
+type countryRecord struct {
+	iso2   string
+	iso3   string
+	fir    string // ICAO code of the country's flight information region, when it has just one
+	region string
+	name   string
+}
+
+// countryTerm is a normalized country name or demonym. country is nil for
+// phrases such as "indian ocean" or airline names, which are masked so they
+// do not match a country.
+type countryTerm struct {
+	term    string
+	country *countryRecord
+}
+
+// countryTable indexes the embedded country table
+type countryTable struct {
+	terms  []countryTerm             // Longest first, so "south sudan" is matched before "sudan"
+	byCode map[string]*countryRecord // ISO 3166-1 alpha-2 and alpha-3 codes
+	byName map[string]*countryRecord // Normalized names and demonyms
+	// byRegion lists the countries of each region in table order
+	byRegion map[string][]*countryRecord
+}
+
+var (
+	countryTableOnce sync.Once
+	countries        *countryTable
+)
+
+// loadCountryTable parses the embedded country table on first use
+func loadCountryTable() *countryTable {
+	countryTableOnce.Do(func() {
+		table, err := parseCountryTable(countriesCSV)
+		if err != nil {
+			log.Printf("Failed to load embedded country table: %v", err)
+			table = &countryTable{byCode: map[string]*countryRecord{}, byName: map[string]*countryRecord{}}
+		}
+		countries = table
+	})
+	return countries
+}
+
+// parseCountryTable parses iso2,iso3,fir,region,names rows, where names are
+// separated by "|" with the display name first and an iso2 of "-" marks
+// phrases to mask
+func parseCountryTable(data []byte) (*countryTable, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse country table: %w", err)
+	}
+	table := &countryTable{
+		byCode:   make(map[string]*countryRecord, len(records)*2),
+		byName:   make(map[string]*countryRecord, len(records)*3),
+		byRegion: make(map[string][]*countryRecord),
+	}
+	for i, record := range records {
+		if i == 0 {
+			continue // Header
+		}
+		if len(record) != 5 {
+			return nil, fmt.Errorf("country table line %d: expected 5 fields, got %d", i+1, len(record))
+		}
+		names := strings.Split(record[4], "|")
+		var country *countryRecord
+		if record[0] != "-" {
+			country = &countryRecord{iso2: record[0], iso3: record[1], fir: record[2], region: record[3], name: names[0]}
+			table.byCode[country.iso2] = country
+			table.byCode[country.iso3] = country
+			table.byRegion[country.region] = append(table.byRegion[country.region], country)
+		}
+		for _, name := range names {
+			term := strings.TrimSpace(normalizeNewsText(name))
+			if term == "" {
+				continue
+			}
+			table.terms = append(table.terms, countryTerm{term: term, country: country})
+			if country != nil {
+				table.byName[term] = country
+			}
+		}
+	}
+	sort.SliceStable(table.terms, func(i, j int) bool { return len(table.terms[i].term) > len(table.terms[j].term) })
+	return table, nil
+}
+
+// NormalizeCountry resolves an ISO 3166-1 alpha-2 or alpha-3 code, a country
+// name or a demonym, in any case, to the country's codes and display name,
+// e.g. "Iranian" to IR, IRN and Iran. Unrecognized input returns an error
+// matching ErrUnknownCountry.
+func NormalizeCountry(input string) (iso2, iso3, name string, err error) {
+	country, err := lookupCountry(input)
+	if err != nil {
+		return "", "", "", err
+	}
+	return country.iso2, country.iso3, country.name, nil
+}
+
+// lookupCountry finds a country by code, name or demonym
+func lookupCountry(input string) (*countryRecord, error) {
+	table := loadCountryTable()
+	code := strings.ToUpper(strings.TrimSpace(input))
+	if country, ok := table.byCode[code]; ok {
+		return country, nil
+	}
+	if country, ok := table.byName[strings.TrimSpace(normalizeNewsText(input))]; ok {
+		return country, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownCountry, input)
+}
+
+// regionCountries lists the countries of a region, matched in any case.
+// Unknown regions return an error matching ErrUnknownRegion that lists the
+// valid ones.
+func regionCountries(region string) ([]*countryRecord, error) {
+	table := loadCountryTable()
+	var names []string
+	for name, countries := range table.byRegion {
+		if strings.EqualFold(name, strings.TrimSpace(region)) {
+			return countries, nil
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("%w %q: valid regions are %s", ErrUnknownRegion, region, strings.Join(names, ", "))
+}
@@ -0,0 +1,167 @@
+package clients
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// envFile caches the first .env file found on the search path so keys are
+// only read from disk once per process
+var envFile struct {
+	once   sync.Once
+	values map[string]string
+}
+
+// LoadEnvFile parses the .env file at path and returns all of its keys.
+// Blank lines, # comments and an optional "export " prefix are ignored,
+// values may be single or double quoted, and CRLF line endings and a
+// leading UTF-8 BOM are accepted. Malformed lines are logged by line number
+// and skipped so the rest of the file still loads.
+func LoadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values, err := parseEnv(f, path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// parseEnv reads .env formatted key/value pairs. Later keys override earlier
+// ones. Malformed lines are skipped and logged with name and their line
+// number, but not their content, which may hold a key.
+func parseEnv(r io.Reader, name string) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\r"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(line, "export "); ok {
+			line = strings.TrimSpace(rest)
+		}
+
+		key, rawValue, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			log.Printf("Skipping %s line %d: expected KEY=value", name, lineNum)
+			continue
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(rawValue))
+		if err != nil {
+			log.Printf("Skipping %s line %d: %v", name, lineNum, err)
+			continue
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// parseEnvValue unquotes a value and strips any trailing comment
+func parseEnvValue(raw string) (string, error) {
+	if raw == "" {
+		return "", nil
+	}
+
+	switch quote := raw[0]; quote {
+	case '"', '\'':
+		var sb strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			if c == quote {
+				if rest := strings.TrimSpace(raw[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+					return "", errors.New("unexpected text after quoted value")
+				}
+				return sb.String(), nil
+			}
+			// Double quoted values support the usual escapes
+			if c == '\\' && quote == '"' && i+1 < len(raw) {
+				i++
+				switch raw[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				default:
+					sb.WriteByte(raw[i])
+				}
+				continue
+			}
+			sb.WriteByte(c)
+		}
+		return "", fmt.Errorf("unterminated %c quote", quote)
+	}
+
+	// Unquoted values end at a comment, which must follow whitespace so
+	// values such as URL fragments keep their #
+	for i := 1; i < len(raw); i++ {
+		if raw[i] == '#' && (raw[i-1] == ' ' || raw[i-1] == '\t') {
+			return strings.TrimSpace(raw[:i]), nil
+		}
+	}
+	return raw, nil
+}
+
+// envFileValues returns the keys of the first .env file found on the search
+// path, loading it on first use
+func envFileValues() map[string]string {
+	envFile.once.Do(func() {
+		for _, path := range envFilePaths() {
+			values, err := LoadEnvFile(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				log.Printf("Error loading .env file: %v", err)
+				continue
+			}
+			log.Printf("Found .env at: %s", path)
+			envFile.values = values
+			return
+		}
+	})
+	return envFile.values
+}
+
+// envFilePaths lists the locations searched for a .env file
+func envFilePaths() []string {
+	envPaths := []string{
+		".env",
+		"../clients/.env",
+		"../../clients/.env",
+	}
+
+	// Try with absolute path
+	if dir, err := os.Getwd(); err == nil {
+		if strings.Contains(dir, "flightnet") {
+			parts := strings.Split(dir, "flightnet")
+			if len(parts) > 1 {
+				clientsPath := parts[0] + "flightnet/clients/.env"
+				envPaths = append(envPaths, clientsPath)
+			}
+		}
+	}
+
+	return envPaths
+}
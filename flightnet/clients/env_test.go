@@ -0,0 +1,53 @@
+package clients
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvSkipsMalformedLines(t *testing.T) {
+	input := "\ufeff# keys\r\n" +
+		"AVIATION_EDGE_API_KEY=abc123\r\n" +
+		"not a key value pair\n" +
+		"export NEWS_API_KEY=\"news key\" # trailing comment\n" +
+		"BROKEN=\"unterminated-secret\n" +
+		"=no-key\n" +
+		"ICAO_API_KEY='icao'\n" +
+		"TRAILING=\"value\"leak-secret\n" +
+		"URL=https://example.com/#frag\n"
+
+	var logs bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(output)
+
+	values, err := parseEnv(strings.NewReader(input), "test.env")
+	if err != nil {
+		t.Fatalf("parseEnv: %v", err)
+	}
+	want := map[string]string{
+		"AVIATION_EDGE_API_KEY": "abc123",
+		"NEWS_API_KEY":          "news key",
+		"ICAO_API_KEY":          "icao",
+		"URL":                   "https://example.com/#frag",
+	}
+	if len(values) != len(want) {
+		t.Errorf("got %d keys %v, want %d", len(values), values, len(want))
+	}
+	for key, value := range want {
+		if values[key] != value {
+			t.Errorf("%s = %q, want %q", key, values[key], value)
+		}
+	}
+
+	for _, line := range []string{"line 3", "line 5", "line 6", "line 8"} {
+		if !strings.Contains(logs.String(), "test.env "+line+":") {
+			t.Errorf("logs do not report %s:\n%s", line, logs.String())
+		}
+	}
+	if strings.Contains(logs.String(), "secret") {
+		t.Errorf("logs contain a skipped line's value:\n%s", logs.String())
+	}
+}
@@ -2,6 +2,7 @@ package clients
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,44 +10,118 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
+// DefaultTimeout is used for APIs that do not configure their own timeout
+const DefaultTimeout = 30 * time.Second
+
+// AuthStyle describes how an API key is attached to requests
+type AuthStyle int
+
+const (
+	// AuthNone sends no API key
+	AuthNone AuthStyle = iota
+	// AuthKeyInQuery sends the key as the AuthParam query parameter
+	AuthKeyInQuery
+	// AuthKeyInHeader sends the key in the AuthParam header
+	AuthKeyInHeader
+)
+
+// String returns the name of the auth style
+func (a AuthStyle) String() string {
+	switch a {
+	case AuthNone:
+		return "none"
+	case AuthKeyInQuery:
+		return "key-in-query"
+	case AuthKeyInHeader:
+		return "key-in-header"
+	default:
+		return fmt.Sprintf("AuthStyle(%d)", int(a))
+	}
+}
+
 // APIConfig holds configuration for different API endpoints
 type APIConfig struct {
-	BaseURL string
-	APIKey  string
-	Headers map[string]string
+	BaseURL   string
+	APIKey    string
+	APIKeys   []string  // Keys to rotate through on auth or quota failures, APIKey when empty
+	AuthStyle AuthStyle // How APIKey is sent
+	AuthParam string    // Query parameter or header name carrying APIKey
+	Headers   map[string]string
+	Timeout   time.Duration // Request timeout, DefaultTimeout when zero
+	Retry     RetryPolicy   // Retry policy, DefaultRetryPolicy for unset fields
+
+	// Rate limiting, disabled when RequestsPerSecond is zero
+	RequestsPerSecond float64
+	Burst             int
+
+	// CacheTTL enables response caching for GET requests when non-zero
+	CacheTTL time.Duration
+
+	// MaxResponseSize caps decoded response bodies, DefaultMaxResponseSize when zero
+	MaxResponseSize int64
 }
 
 // Fetcher handles HTTP requests for multiple APIs
 type Fetcher struct {
-	configs map[string]APIConfig
-	client  *http.Client
+	mu       sync.RWMutex
+	configs  map[string]APIConfig
+	client   *http.Client
+	clients  map[string]*http.Client // Per-API clients honoring APIConfig.Timeout
+	limiters map[string]*tokenBucket // Per-API rate limiters
+	cache    *responseCache
+	metrics  MetricsHook
+	mocks    map[string]MockTransport // Installed with SetMock
+	keys     map[string]*keyRing      // Per-API key rotation state
 }
 
 // NewFetcher creates a new Fetcher instance with multiple API configurations
-func NewFetcher() *Fetcher {
+func NewFetcher(opts ...FetcherOption) *Fetcher {
 	aviationEdgeKey := getAPIKey("AVIATION_EDGE_API_KEY")
+	aviationEdgeBackupKey := getAPIKey("AVIATION_EDGE_API_KEY_BACKUP")
 	icaoKey := getAPIKey("ICAO_API_KEY")
+	faaNOTAMKey := getAPIKey("FAA_NOTAM_CLIENT_ID")
+	newsAPIKey := getAPIKey("NEWS_API_KEY")
 
 	configs := map[string]APIConfig{
 		"aviation-edge": {
-			BaseURL: "https://aviation-edge.com/v2/public",
-			APIKey:  aviationEdgeKey,
+			BaseURL:   "https://aviation-edge.com/v2/public",
+			APIKey:    aviationEdgeKey,
+			APIKeys:   nonEmpty(aviationEdgeKey, aviationEdgeBackupKey),
+			AuthStyle: AuthKeyInQuery,
+			AuthParam: "key",
 			Headers: map[string]string{
 				"Content-Type": "application/json",
 			},
+			Timeout:           90 * time.Second, // airplaneDatabase returns the full database
+			RequestsPerSecond: 2,
+			Burst:             4,
 		},
 		"icao": {
-			BaseURL: "https://api.icao.int/v1",
-			APIKey:  icaoKey,
+			BaseURL:   "https://api.icao.int/v1",
+			APIKey:    icaoKey,
+			AuthStyle: AuthKeyInHeader,
+			AuthParam: "Ocp-Apim-Subscription-Key",
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Timeout: 5 * time.Second,
+		},
+		"faa-notam": {
+			BaseURL:   "https://external-api.faa.gov/notamapi/v1",
+			APIKey:    faaNOTAMKey,
+			AuthStyle: AuthKeyInHeader,
+			AuthParam: "client_id",
 			Headers: map[string]string{
-				"Content-Type":              "application/json",
-				"Ocp-Apim-Subscription-Key": icaoKey,
+				"client_secret": getAPIKey("FAA_NOTAM_CLIENT_SECRET"),
 			},
+			Timeout:  15 * time.Second,
+			CacheTTL: 5 * time.Minute, // NOTAMs are issued at any time
 		},
 		"world-bank": {
 			BaseURL: "https://api.worldbank.org/v2",
@@ -54,6 +129,37 @@ func NewFetcher() *Fetcher {
 			Headers: map[string]string{
 				"Content-Type": "application/json",
 			},
+			CacheTTL: 24 * time.Hour, // Indicators are published yearly
+		},
+		"state-dept": {
+			BaseURL: "https://travel.state.gov",
+			APIKey:  "", // The travel advisory feed is public
+			Headers: map[string]string{
+				"Accept": "application/rss+xml, application/xml;q=0.9, text/xml;q=0.8",
+			},
+			Timeout:  15 * time.Second,
+			CacheTTL: 12 * time.Hour, // Advisories are reissued every few months
+		},
+		"noaa": {
+			BaseURL: "https://aviationweather.gov/api/data",
+			APIKey:  "", // The Aviation Weather Center API is free, no key needed
+			Headers: map[string]string{
+				"Accept": "application/json",
+			},
+			Timeout:           10 * time.Second,
+			RequestsPerSecond: 1, // AWC asks clients to stay well under 100 requests a minute
+			Burst:             5,
+			CacheTTL:          5 * time.Minute, // METARs are issued hourly, SPECIs at any time
+		},
+		"newsapi": {
+			BaseURL:   "https://newsapi.org/v2",
+			APIKey:    newsAPIKey,
+			AuthStyle: AuthKeyInHeader,
+			AuthParam: "X-Api-Key",
+			Headers: map[string]string{
+				"Content-Type": "application/json",
+			},
+			Timeout: 15 * time.Second, // Responses are cached by NewsAPI, which tracks the daily quota
 		},
 		"fuel-api": {
 			BaseURL: "https://despouy.ca/flight-fuel-api/q",
@@ -61,15 +167,133 @@ func NewFetcher() *Fetcher {
 			Headers: map[string]string{
 				"Content-Type": "application/json",
 			},
+			CacheTTL: 24 * time.Hour, // Results only depend on aircraft and distance
 		},
 	}
 
 	return &Fetcher{
-		configs: configs,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		configs:  configs,
+		client:   buildClient(opts),
+		clients:  make(map[string]*http.Client),
+		limiters: make(map[string]*tokenBucket),
+		cache:    newResponseCache(),
+		mocks:    make(map[string]MockTransport),
+		keys:     make(map[string]*keyRing),
+	}
+}
+
+// SetTimeout changes the request timeout for an API
+func (f *Fetcher) SetTimeout(apiName string, timeout time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	config, exists := f.configs[apiName]
+	if !exists {
+		return fmt.Errorf("unknown API: %s", apiName)
+	}
+	if timeout < 0 {
+		return fmt.Errorf("invalid timeout for %s: %v", apiName, timeout)
+	}
+
+	config.Timeout = timeout
+	f.configs[apiName] = config
+	delete(f.clients, apiName)
+	return nil
+}
+
+// RegisterAPI adds an upstream API configuration. Registering a name that is
+// already taken fails unless override is true.
+func (f *Fetcher) RegisterAPI(name string, cfg APIConfig, override bool) error {
+	if name == "" {
+		return fmt.Errorf("API name must not be empty")
+	}
+
+	u, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL for %s: %w", name, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid base URL for %s: %q must be an absolute http(s) URL", name, cfg.BaseURL)
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+
+	switch cfg.AuthStyle {
+	case AuthNone:
+	case AuthKeyInQuery, AuthKeyInHeader:
+		if cfg.AuthParam == "" {
+			return fmt.Errorf("API %s uses %s auth but has no AuthParam", name, cfg.AuthStyle)
+		}
+	default:
+		return fmt.Errorf("API %s has unknown auth style %d", name, int(cfg.AuthStyle))
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.configs[name]; exists && !override {
+		return fmt.Errorf("API %s is already registered", name)
+	}
+
+	f.configs[name] = cfg
+	delete(f.clients, name)
+	delete(f.limiters, name)
+	delete(f.keys, name)
+	if f.cache != nil {
+		f.cache.invalidate(name + "|")
+	}
+	return nil
+}
+
+// ListAPIs returns the names of all registered APIs in sorted order
+func (f *Fetcher) ListAPIs() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	names := make([]string, 0, len(f.configs))
+	for name := range f.configs {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
+
+// getConfig returns the configuration for an API
+func (f *Fetcher) getConfig(apiName string) (APIConfig, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	config, exists := f.configs[apiName]
+	return config, exists
+}
+
+// clientFor returns the HTTP client for an API, creating it on first use.
+// All per-API clients share the default client's transport so connections
+// are pooled across APIs.
+func (f *Fetcher) clientFor(apiName string) *http.Client {
+	f.mu.RLock()
+	client, exists := f.clients[apiName]
+	f.mu.RUnlock()
+	if exists {
+		return client
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if client, exists := f.clients[apiName]; exists {
+		return client
+	}
+
+	timeout := f.configs[apiName].Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	client = &http.Client{
+		Transport: f.client.Transport,
+		Timeout:   timeout,
+	}
+	f.clients[apiName] = client
+	return client
 }
 
 // getAPIKey tries to get API key from environment or .env file
@@ -89,199 +313,193 @@ func getAPIKey(keyName string) string {
 	return apiKey
 }
 
-// loadAPIKeyFromEnvFile tries to load API key from .env file
+// loadAPIKeyFromEnvFile looks up an API key in the cached .env file
 func loadAPIKeyFromEnvFile(keyName string) string {
-	envPaths := []string{
-		".env",
-		"../clients/.env",
-		"../../clients/.env",
-	}
-
-	// Try with absolute path
-	if dir, err := os.Getwd(); err == nil {
-		if strings.Contains(dir, "flightnet") {
-			parts := strings.Split(dir, "flightnet")
-			if len(parts) > 1 {
-				clientsPath := parts[0] + "flightnet/clients/.env"
-				envPaths = append(envPaths, clientsPath)
-			}
-		}
-	}
-
-	for _, path := range envPaths {
-		if envContent, err := os.ReadFile(path); err == nil {
-			log.Printf("Found .env at: %s", path)
-			return extractAPIKey(string(envContent), keyName)
-		}
-	}
-
-	return ""
-}
-
-// extractAPIKey extracts specific API key from .env file content
-func extractAPIKey(content, keyName string) string {
-	re := regexp.MustCompile(fmt.Sprintf(`%s=([^\s]+)`, keyName))
-	matches := re.FindStringSubmatch(content)
-	if len(matches) > 1 {
-		return matches[1]
-	}
-	return ""
+	return envFileValues()[keyName]
 }
 
 // Get makes a GET request to the specified API
 func (f *Fetcher) Get(apiName, endpoint string, params map[string]string) ([]byte, error) {
-	config, exists := f.configs[apiName]
+	return f.GetContext(context.Background(), apiName, endpoint, params)
+}
+
+// GetContext makes a GET request to the specified API, aborting when ctx is done
+func (f *Fetcher) GetContext(ctx context.Context, apiName, endpoint string, params map[string]string) ([]byte, error) {
+	config, exists := f.getConfig(apiName)
 	if !exists {
 		return nil, fmt.Errorf("unknown API: %s", apiName)
 	}
-
-	// Handle mock responses when API key is not set
-	if config.APIKey == "" && apiName != "fuel-api" && apiName != "world-bank" {
-		log.Printf("API key not set for %s, returning mock response for endpoint: %s", apiName, endpoint)
-		return f.getMockResponse(apiName, endpoint), nil
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	// Build URL
-	var fullURL string
-	switch apiName {
-	case "aviation-edge":
-		fullURL = fmt.Sprintf("%s/%s?key=%s", config.BaseURL, endpoint, config.APIKey)
-	case "fuel-api":
-		fullURL = fmt.Sprintf("%s/?aircraft=%s&distance=%s", config.BaseURL, params["aircraft"], params["distance"])
-		if params["gcd"] == "true" {
-			fullURL += "&gcd=true"
-		}
-	case "world-bank":
-		// Construct World Bank API URL with parameters
-		// Examples:
-		// https://api.worldbank.org/v2/country/US/indicator/NY.GDP.MKTP.CD?format=json
-		// https://api.worldbank.org/v2/sources?per_page=100&format=json
-		// https://api.worldbank.org/v2/indicator?format=json&source=6
-		fullURL = fmt.Sprintf("%s/%s", config.BaseURL, endpoint)
-	case "icao":
-		fullURL = fmt.Sprintf("%s/%s", config.BaseURL, endpoint)
-	default:
-		fullURL = fmt.Sprintf("%s/%s", config.BaseURL, endpoint)
+	if body, handled, err := f.intercept(apiName, config, endpoint, params); handled {
+		return body, err
 	}
 
-	u, err := url.Parse(fullURL)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing URL: %w", err)
+	if config.CacheTTL > 0 {
+		key := cacheKey(apiName, endpoint, params)
+		return f.cache.get(ctx, key, config.CacheTTL, func(ctx context.Context) ([]byte, error) {
+			return f.get(ctx, apiName, config, endpoint, params)
+		})
 	}
 
-	// Add query parameters
-	if apiName != "fuel-api" {
-		q := u.Query()
-		for key, value := range params {
-			if key != "aircraft" && key != "distance" && key != "gcd" {
-				q.Add(key, value)
-			}
-		}
-		u.RawQuery = q.Encode()
-	}
+	return f.get(ctx, apiName, config, endpoint, params)
+}
 
-	// Create request
-	req, err := http.NewRequest("GET", u.String(), nil)
+// get performs an uncached GET request
+func (f *Fetcher) get(ctx context.Context, apiName string, config APIConfig, endpoint string, params map[string]string) ([]byte, error) {
+	resp, err := f.do(ctx, config, Request{
+		Method:   http.MethodGet,
+		API:      apiName,
+		Endpoint: endpoint,
+		Params:   params,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+		return nil, err
 	}
 
-	// Add headers
-	for key, value := range config.Headers {
-		req.Header.Set(key, value)
-	}
-
-	// Send request
-	log.Printf("Sending request to URL: %s", req.URL.String())
-	resp, err := f.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
-	}
-	defer resp.Body.Close()
+	log.Printf("Received response from %s, length: %d", apiName, len(resp.Body))
+	return resp.Body, nil
+}
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, response: %s", resp.StatusCode, string(body))
-	}
+// Post makes a POST request to the specified API
+func (f *Fetcher) Post(apiName, endpoint string, data interface{}) ([]byte, error) {
+	return f.PostContext(context.Background(), apiName, endpoint, data)
+}
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+// PostContext makes a POST request to the specified API, aborting when ctx is done
+func (f *Fetcher) PostContext(ctx context.Context, apiName, endpoint string, data interface{}) ([]byte, error) {
+	resp, err := f.Do(ctx, Request{
+		Method:   http.MethodPost,
+		API:      apiName,
+		Endpoint: endpoint,
+		Body:     data,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+		return nil, err
 	}
+	return resp.Body, nil
+}
 
-	log.Printf("Received response from %s, length: %d", apiName, len(body))
-	return body, nil
+// Request describes a single call to a registered API
+type Request struct {
+	Method   string            // GET, POST, PUT or DELETE, GET when empty
+	API      string            // Registered API name
+	Endpoint string            // Path relative to the API's base URL
+	Params   map[string]string // Query parameters
+	Body     interface{}       // JSON encoded unless it is already []byte
+	Headers  map[string]string // Merged over the API's configured headers
 }
 
-// Post makes a POST request to the specified API
-func (f *Fetcher) Post(apiName, endpoint string, data interface{}) ([]byte, error) {
-	config, exists := f.configs[apiName]
+// Response is the result of a request that reached the upstream API
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Do sends req to its API, applying the API's auth, retry and rate limit
+// settings. When the API answers with a non-2xx status the Response is
+// returned along with the error so callers can inspect it.
+func (f *Fetcher) Do(ctx context.Context, req Request) (*Response, error) {
+	config, exists := f.getConfig(req.API)
 	if !exists {
-		return nil, fmt.Errorf("unknown API: %s", apiName)
+		return nil, fmt.Errorf("unknown API: %s", req.API)
 	}
-
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling JSON: %w", err)
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-
-	fullURL := fmt.Sprintf("%s/%s", config.BaseURL, endpoint)
-	req, err := http.NewRequest("POST", fullURL, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
+	if body, handled, err := f.intercept(req.API, config, req.Endpoint, req.Params); handled {
+		if err != nil {
+			return nil, err
+		}
+		return &Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body}, nil
 	}
+	return f.do(ctx, config, req)
+}
 
-	// Add headers
-	for key, value := range config.Headers {
-		req.Header.Set(key, value)
+// do sends req using config
+func (f *Fetcher) do(ctx context.Context, config APIConfig, req Request) (*Response, error) {
+	method := strings.ToUpper(req.Method)
+	switch method {
+	case "":
+		method = http.MethodGet
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return nil, fmt.Errorf("unsupported method %q for %s", req.Method, req.API)
 	}
 
-	resp, err := f.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %w", err)
+	var body []byte
+	switch data := req.Body.(type) {
+	case nil:
+	case []byte:
+		body = data
+	default:
+		var err error
+		if body, err = json.Marshal(data); err != nil {
+			return nil, fmt.Errorf("error marshaling JSON: %w", err)
+		}
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	// Send request, rebuilding it per attempt since key rotation can change
+	// the key between attempts
+	return f.send(ctx, req.API, req.Endpoint, config, func(key string) (*http.Request, error) {
+		keyed := config
+		keyed.APIKey = key
+		u, err := buildURL(keyed, req.Endpoint, req.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Printf("Sending %s request to URL: %s", method, RedactURL(u))
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, method, u, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		setHeaders(httpReq, keyed, req.Headers)
+		return httpReq, nil
+	})
+}
+
+// buildURL joins the base URL and endpoint and encodes params along with any
+// query-based auth
+func buildURL(config APIConfig, endpoint string, params map[string]string) (string, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s", config.BaseURL, endpoint))
 	if err != nil {
-		return nil, fmt.Errorf("error reading response: %w", err)
+		return "", fmt.Errorf("error parsing URL: %w", err)
 	}
 
-	return body, nil
+	// Add query parameters
+	q := u.Query()
+	for key, value := range params {
+		q.Add(key, value)
+	}
+	if config.AuthStyle == AuthKeyInQuery {
+		q.Set(config.AuthParam, config.APIKey)
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
 }
 
-// getMockResponse returns mock responses for testing
-func (f *Fetcher) getMockResponse(apiName, endpoint string) []byte {
-	switch apiName {
-	case "aviation-edge":
-		switch endpoint {
-		case "airplaneDatabase":
-			return []byte(`[{"airplaneId":"mock-id"}]`)
-		case "flights":
-			return []byte(`[{"flight":{"number":"mock-flight"}}]`)
-		case "flightsFuture":
-			return []byte(`[{"flight":{"number":"mock-future"}}]`)
-		case "airportWeather":
-			return []byte(`{"airport_icao":"MOCK","current_weather":{"temperature":{"celsius":20}}}`)
-		default:
-			return []byte(`[{}]`)
-		}
-	case "icao":
-		return []byte(`{"co2_emissions":{"total_kg":1000}}`)
-	case "world-bank":
-		switch endpoint {
-		case "sources":
-			return []byte(`[{"page":1,"pages":1,"per_page":"50","total":61},[{"id":"1","name":"Doing Business","code":"","description":"","url":"","dataavailability":"Y","metadataavailability":"Y","concepts":"3"}]]`)
-		case "indicator":
-			return []byte(`[{"page":1,"pages":1,"per_page":"50","total":16000},[{"id":"SP.POP.TOTL","name":"Population, total","unit":"","source":{"id":"2","value":"World Development Indicators"},"sourceNote":"Total population is based on the de facto definition of population.","sourceOrganization":"( 1 ) United Nations Population Division.","topics":[{"id":"8","value":"Health "}]}]]`)
-		default:
-			return []byte(`[{"page":1,"pages":1,"per_page":"50","total":1},[{"id":"mock","value":"Mock World Bank Data"}]]`)
-		}
-	case "fuel-api":
-		return []byte(`{"aircraft":"mock-aircraft","distance":1000,"fuel_burn":2000,"co2_emissions":6000,"unit":"kg"}`)
-	default:
-		return []byte(`{}`)
+// setHeaders adds the configured headers, per-request headers and
+// header-based auth to a request
+func setHeaders(req *http.Request, config APIConfig, headers map[string]string) {
+	for key, value := range config.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if config.AuthStyle == AuthKeyInHeader && config.APIKey != "" {
+		req.Header.Set(config.AuthParam, config.APIKey)
 	}
 }
@@ -0,0 +1,65 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetContextCancelled(t *testing.T) {
+	t.Run("during the request", func(t *testing.T) {
+		started := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		f := newTestFetcher(t, server.URL, APIConfig{})
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-started
+			cancel()
+		}()
+		_, err := f.GetContext(ctx, "test", "slow", nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("error %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("during the backoff", func(t *testing.T) {
+		var attempts atomic.Int32
+		failed := make(chan struct{}, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			http.Error(w, "down", http.StatusBadGateway)
+			failed <- struct{}{}
+		}))
+		defer server.Close()
+
+		f := newTestFetcher(t, server.URL, APIConfig{
+			Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute},
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-failed
+			time.Sleep(10 * time.Millisecond) // Let send start waiting to retry
+			cancel()
+		}()
+		start := time.Now()
+		_, err := f.GetContext(ctx, "test", "flaky", nil)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("error %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(start); elapsed > 10*time.Second {
+			t.Errorf("returned after %v, not when cancelled", elapsed)
+		}
+		if got := attempts.Load(); got != 1 {
+			t.Errorf("made %d attempts, want 1", got)
+		}
+	})
+}
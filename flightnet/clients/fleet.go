@@ -0,0 +1,112 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// FleetSummary aggregates an airline's aircraft from the aircraft database
+type FleetSummary struct {
+	Airline         string         `json:"airline"`
+	Total           int            `json:"total"`
+	Active          int            `json:"active"`
+	Stored          int            `json:"stored"`
+	OtherStatus     int            `json:"otherStatus"` // Any other PlaneStatus, e.g. scrapped or unknown
+	ByModel         map[string]int `json:"byModel"`
+	ByEngineType    map[string]int `json:"byEngineType"`
+	AverageAgeYears float64        `json:"averageAgeYears"` // Over aircraft with a known age
+}
+
+// fleetAggregator builds a FleetSummary one aircraft at a time
+type fleetAggregator struct {
+	summary  FleetSummary
+	ageTotal float64
+	ageCount int
+}
+
+// newFleetAggregator creates an aggregator for an airline
+func newFleetAggregator(airline string) *fleetAggregator {
+	return &fleetAggregator{summary: FleetSummary{
+		Airline:      airline,
+		ByModel:      make(map[string]int),
+		ByEngineType: make(map[string]int),
+	}}
+}
+
+// add counts an aircraft
+func (f *fleetAggregator) add(a Aircraft) {
+	f.summary.Total++
+
+	switch strings.ToLower(strings.TrimSpace(a.PlaneStatus)) {
+	case "active":
+		f.summary.Active++
+	case "stored":
+		f.summary.Stored++
+	default:
+		f.summary.OtherStatus++
+	}
+
+	f.summary.ByModel[valueOrUnknown(a.PlaneModel)]++
+	f.summary.ByEngineType[valueOrUnknown(strings.ToUpper(a.EnginesType))]++
+
+	if age, ok := a.ageYears(); ok {
+		f.ageTotal += age
+		f.ageCount++
+	}
+}
+
+// result returns the summary of the aircraft added so far
+func (f *fleetAggregator) result() *FleetSummary {
+	summary := f.summary
+	if f.ageCount > 0 {
+		summary.AverageAgeYears = math.Round(f.ageTotal/float64(f.ageCount)*10) / 10
+	}
+	return &summary
+}
+
+// valueOrUnknown returns s, or "unknown" when it is empty
+func valueOrUnknown(s string) string {
+	if s = strings.TrimSpace(s); s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// GetFleetSummary aggregates the aircraft of an airline by model, engine
+// type, status and age
+func (a *AircraftAPI) GetFleetSummary(airlineIATA string) (*FleetSummary, error) {
+	return a.GetFleetSummaryContext(context.Background(), airlineIATA)
+}
+
+// GetFleetSummaryContext aggregates the aircraft of an airline, aborting when
+// ctx is done. The response is aggregated as it streams in, so the fleet is
+// never held in memory.
+func (a *AircraftAPI) GetFleetSummaryContext(ctx context.Context, airlineIATA string) (*FleetSummary, error) {
+	airline := strings.ToUpper(strings.TrimSpace(airlineIATA))
+	if len(airline) != 2 {
+		return nil, fmt.Errorf("invalid airline IATA code %q", airlineIATA)
+	}
+
+	body, err := a.fetcher.GetStream(ctx, "aviation-edge", "airplaneDatabase", map[string]string{
+		"codeIataAirline": airline,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	fleet := newFleetAggregator(airline)
+	err = a.parser.EachAircraft(body, func(aircraft Aircraft) bool {
+		// The filter is not honoured by every upstream, so check each record
+		if strings.EqualFold(aircraft.CodeIataAirline, airline) {
+			fleet.add(aircraft)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fleet.result(), nil
+}
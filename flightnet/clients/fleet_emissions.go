@@ -0,0 +1,161 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// defaultBurnKgPerKm is the fuel burn assumed for aircraft types that are
+	// not in the capacity table, a typical narrow-body's
+	defaultBurnKgPerKm = 3.0
+	// fleetTopEmitters is how many of the highest-emitting flights an
+	// emissions report lists
+	fleetTopEmitters = 10
+)
+
+// FlightEmissionsEntry is the fuel burn and emissions of one flight in an
+// emissions report
+type FlightEmissionsEntry struct {
+	Flight      string `json:"flight"`
+	Airline     string `json:"airline"`
+	Origin      string `json:"origin"`
+	Destination string `json:"destination"`
+	Aircraft    string `json:"aircraft,omitempty"` // ICAO type designator
+	// AircraftAssumed is set when the aircraft type is missing or unknown,
+	// so a typical narrow-body's fuel burn was used
+	AircraftAssumed bool    `json:"aircraft_assumed,omitempty"`
+	DistanceKm      float64 `json:"distance_km"`
+	FuelKg          float64 `json:"fuel_kg"`
+	CO2Kg           float64 `json:"co2_kg"`
+}
+
+// AirlineEmissions totals the emissions of one airline's flights
+type AirlineEmissions struct {
+	Airline    string  `json:"airline"`
+	Flights    int     `json:"flights"`
+	DistanceKm float64 `json:"distance_km"`
+	FuelKg     float64 `json:"fuel_kg"`
+	CO2Kg      float64 `json:"co2_kg"`
+}
+
+// SkippedFlight is a flight left out of an emissions report
+type SkippedFlight struct {
+	Flight string `json:"flight"`
+	Reason string `json:"reason"`
+}
+
+// EmissionsReport is the fuel burn and emissions of a list of flights
+type EmissionsReport struct {
+	Flights         int                    `json:"flights"` // Flights assessed
+	TotalDistanceKm float64                `json:"total_distance_km"`
+	TotalFuelKg     float64                `json:"total_fuel_kg"`
+	TotalCO2Kg      float64                `json:"total_co2_kg"`
+	ByAirline       []AirlineEmissions     `json:"by_airline"`   // Highest CO2 first
+	TopEmitters     []FlightEmissionsEntry `json:"top_emitters"` // The ten highest-emitting flights
+	PerFlight       []FlightEmissionsEntry `json:"per_flight"`   // In input order
+	Skipped         []SkippedFlight        `json:"skipped"`      // Flights whose route could not be resolved
+}
+
+// GetFleetEmissionsReport calculates the fuel burn and emissions of a list
+// of flights
+func (s *SustainabilityAPI) GetFleetEmissionsReport(flights []Flight) (*EmissionsReport, error) {
+	return s.GetFleetEmissionsReportContext(context.Background(), flights)
+}
+
+// GetFleetEmissionsReportContext calculates the emissions of a list of
+// flights, aborting when ctx is done. Each flight's distance is the
+// great-circle distance between its airports scaled by the routing factor,
+// and its fuel burn comes from the aircraft type table. Flights whose
+// airports cannot be resolved are listed in Skipped with the reason.
+func (s *SustainabilityAPI) GetFleetEmissionsReportContext(ctx context.Context, flights []Flight) (*EmissionsReport, error) {
+	report := &EmissionsReport{
+		ByAirline:   []AirlineEmissions{},
+		TopEmitters: []FlightEmissionsEntry{},
+		PerFlight:   make([]FlightEmissionsEntry, 0, len(flights)),
+		Skipped:     []SkippedFlight{},
+	}
+	factor := s.RoutingFactor()
+	airlines := make(map[string]*AirlineEmissions)
+	var order []string
+	for _, flight := range flights {
+		entry, err := s.flightEmissions(ctx, flight, factor)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			report.Skipped = append(report.Skipped, SkippedFlight{Flight: entry.Flight, Reason: err.Error()})
+			continue
+		}
+		report.PerFlight = append(report.PerFlight, entry)
+		report.TotalDistanceKm += entry.DistanceKm
+		report.TotalFuelKg += entry.FuelKg
+		report.TotalCO2Kg += entry.CO2Kg
+
+		totals, ok := airlines[entry.Airline]
+		if !ok {
+			totals = &AirlineEmissions{Airline: entry.Airline}
+			airlines[entry.Airline] = totals
+			order = append(order, entry.Airline)
+		}
+		totals.Flights++
+		totals.DistanceKm += entry.DistanceKm
+		totals.FuelKg += entry.FuelKg
+		totals.CO2Kg += entry.CO2Kg
+	}
+	report.Flights = len(report.PerFlight)
+	report.TotalDistanceKm = math.Round(report.TotalDistanceKm*10) / 10
+	report.TotalFuelKg = math.Round(report.TotalFuelKg*10) / 10
+	report.TotalCO2Kg = math.Round(report.TotalCO2Kg*10) / 10
+
+	for _, airline := range order {
+		totals := airlines[airline]
+		totals.DistanceKm = math.Round(totals.DistanceKm*10) / 10
+		totals.FuelKg = math.Round(totals.FuelKg*10) / 10
+		totals.CO2Kg = math.Round(totals.CO2Kg*10) / 10
+		report.ByAirline = append(report.ByAirline, *totals)
+	}
+	sort.SliceStable(report.ByAirline, func(i, j int) bool { return report.ByAirline[i].CO2Kg > report.ByAirline[j].CO2Kg })
+
+	report.TopEmitters = append(report.TopEmitters, report.PerFlight...)
+	sort.SliceStable(report.TopEmitters, func(i, j int) bool { return report.TopEmitters[i].CO2Kg > report.TopEmitters[j].CO2Kg })
+	if len(report.TopEmitters) > fleetTopEmitters {
+		report.TopEmitters = report.TopEmitters[:fleetTopEmitters]
+	}
+	return report, nil
+}
+
+// flightEmissions calculates one flight's emissions over its route
+// distance scaled by factor. The returned entry always identifies the flight.
+func (s *SustainabilityAPI) flightEmissions(ctx context.Context, flight Flight, factor float64) (FlightEmissionsEntry, error) {
+	entry := FlightEmissionsEntry{
+		Flight:      firstNonEmpty(flight.Flight.IataNumber, flight.Flight.IcaoNumber, flight.Flight.Number),
+		Airline:     firstNonEmpty(flight.Airline.Name, flight.Airline.IataCode, flight.Airline.IcaoCode, "Unknown"),
+		Origin:      firstNonEmpty(flight.Departure.IataCode, flight.Departure.IcaoCode),
+		Destination: firstNonEmpty(flight.Arrival.IataCode, flight.Arrival.IcaoCode),
+		Aircraft:    strings.ToUpper(strings.TrimSpace(flight.Aircraft.IcaoCode)),
+	}
+	if entry.Origin == "" || entry.Destination == "" {
+		return entry, errors.New("missing departure or arrival airport")
+	}
+	greatCircle, err := s.airports.GetRouteDistanceContext(ctx, entry.Origin, entry.Destination)
+	if err != nil {
+		return entry, err
+	}
+
+	burn := defaultBurnKgPerKm
+	if capacity, ok := GetAircraftCapacity(entry.Aircraft); ok {
+		burn = capacity.BurnKgPerKm
+	} else {
+		entry.AircraftAssumed = true
+	}
+	distance := greatCircle * factor
+	fuel := burn * distance
+	entry.DistanceKm = math.Round(distance*10) / 10
+	entry.FuelKg = math.Round(fuel*10) / 10
+	entry.CO2Kg = math.Round(fuel*co2PerKgFuel*10) / 10
+	return entry, nil
+}
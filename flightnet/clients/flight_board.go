@@ -0,0 +1,176 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxBoardWindow bounds how far ahead a board looks, since the schedule is
+// fetched one day at a time
+const maxBoardWindow = 7 * 24 * time.Hour
+
+// BoardEntry is one row of an airport departures or arrivals board
+type BoardEntry struct {
+	FlightNumber  string       `json:"flightNumber"`
+	Airline       string       `json:"airline"`
+	Airport       string       `json:"airport"` // Destination on a departures board, origin on an arrivals board
+	ScheduledTime time.Time    `json:"scheduledTime"`
+	Status        FlightStatus `json:"status"`
+	Terminal      string       `json:"terminal,omitempty"`
+	Gate          string       `json:"gate,omitempty"`
+}
+
+// GetDeparturesBoard returns the flights departing an airport within window
+// of now, in scheduled order
+func (f *FlightsAPI) GetDeparturesBoard(airportIATA string, window time.Duration) ([]BoardEntry, error) {
+	return f.GetDeparturesBoardContext(context.Background(), airportIATA, window)
+}
+
+// GetDeparturesBoardContext returns the flights departing an airport within
+// window of now, aborting when ctx is done
+func (f *FlightsAPI) GetDeparturesBoardContext(ctx context.Context, airportIATA string, window time.Duration) ([]BoardEntry, error) {
+	return f.board(ctx, airportIATA, window, true, time.Now())
+}
+
+// GetArrivalsBoard returns the flights arriving at an airport within window
+// of now, in scheduled order
+func (f *FlightsAPI) GetArrivalsBoard(airportIATA string, window time.Duration) ([]BoardEntry, error) {
+	return f.GetArrivalsBoardContext(context.Background(), airportIATA, window)
+}
+
+// GetArrivalsBoardContext returns the flights arriving at an airport within
+// window of now, aborting when ctx is done
+func (f *FlightsAPI) GetArrivalsBoardContext(ctx context.Context, airportIATA string, window time.Duration) ([]BoardEntry, error) {
+	return f.board(ctx, airportIATA, window, false, time.Now())
+}
+
+// board merges the live flights and the schedule for an airport. Live
+// flights are fetched first so their status wins over the schedule's.
+func (f *FlightsAPI) board(ctx context.Context, airportIATA string, window time.Duration, departures bool, now time.Time) ([]BoardEntry, error) {
+	airport, err := normalizeIATAAirport(airportIATA)
+	if err != nil {
+		return nil, err
+	}
+	if window <= 0 || window > maxBoardWindow {
+		return nil, fmt.Errorf("invalid board window %v: must be positive and at most %v", window, maxBoardWindow)
+	}
+
+	liveQuery, scheduleType := FlightQuery{ArrIATA: airport}, "arrival"
+	if departures {
+		liveQuery, scheduleType = FlightQuery{DepIATA: airport}, "departure"
+	}
+
+	var flights []Flight
+	live, liveErr := f.QueryFlightsContext(ctx, liveQuery)
+	if liveErr != nil && !isNoRecords(liveErr) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("Live flights unavailable for %s board: %v", airport, liveErr)
+	}
+	flights = append(flights, live...)
+
+	// The schedule is queried by local date at the airport
+	loc, ok := airportLocation(airport, "")
+	if !ok {
+		loc = time.UTC
+	}
+	cutoff := now.Add(window)
+	first, last := now.In(loc), cutoff.In(loc).Format("2006-01-02")
+	var scheduleErr error
+	// Step from noon so daylight saving changes cannot skip or repeat a day
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 12, 0, 0, 0, loc); day.Format("2006-01-02") <= last; day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		scheduled, err := f.QueryFutureFlightsContext(ctx, FutureFlightQuery{
+			IATACode: airport,
+			Type:     scheduleType,
+			Date:     day,
+		})
+		if err != nil && !isNoRecords(err) {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Schedule unavailable for %s board on %s: %v", airport, date, err)
+			scheduleErr = err
+			continue
+		}
+		flights = append(flights, scheduled...)
+	}
+	if liveErr != nil && !isNoRecords(liveErr) && scheduleErr != nil {
+		return nil, fmt.Errorf("failed to build %s board for %s: %w", scheduleType, airport, liveErr)
+	}
+
+	seen := make(map[string]bool)
+	entries := []BoardEntry{}
+	for i := range flights {
+		entry, ok := boardEntry(&flights[i], airport, departures)
+		if !ok || !onBoard(entry, departures, now, cutoff) {
+			continue
+		}
+		key := entry.FlightNumber + "|" + entry.ScheduledTime.UTC().Format(time.RFC3339)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].ScheduledTime.Equal(entries[j].ScheduledTime) {
+			return entries[i].ScheduledTime.Before(entries[j].ScheduledTime)
+		}
+		return entries[i].FlightNumber < entries[j].FlightNumber
+	})
+	return entries, nil
+}
+
+// boardEntry converts a flight serving airport to a board row, reporting
+// false when the flight belongs on the other board or has no usable time
+func boardEntry(flight *Flight, airport string, departures bool) (BoardEntry, bool) {
+	here, there := &flight.Arrival, &flight.Departure
+	scheduled := flight.ScheduledArrival
+	if departures {
+		here, there = &flight.Departure, &flight.Arrival
+		scheduled = flight.ScheduledDeparture
+	}
+	if !strings.EqualFold(here.IataCode, airport) || scheduled.IsZero() {
+		return BoardEntry{}, false
+	}
+
+	status := flight.StatusCode
+	if status == "" {
+		status = NormalizeStatus(flight.Status)
+	}
+	return BoardEntry{
+		FlightNumber:  strings.ToUpper(flight.Flight.IataNumber),
+		Airline:       flight.Airline.Name,
+		Airport:       strings.ToUpper(there.IataCode),
+		ScheduledTime: scheduled,
+		Status:        status,
+		Terminal:      here.Terminal,
+		Gate:          here.Gate,
+	}, true
+}
+
+// onBoard reports whether an entry is shown between now and cutoff. Flights
+// scheduled earlier stay on the board until they have left, or for arrivals
+// until they have landed.
+func onBoard(entry BoardEntry, departures bool, now, cutoff time.Time) bool {
+	if entry.ScheduledTime.After(cutoff) {
+		return false
+	}
+	if !entry.ScheduledTime.Before(now) {
+		return true
+	}
+	switch entry.Status {
+	case StatusScheduled, StatusBoarding, StatusDelayed:
+		return true
+	case StatusDeparted, StatusEnRoute:
+		return !departures
+	}
+	return false
+}
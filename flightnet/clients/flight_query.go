@@ -0,0 +1,150 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FlightQuery selects live flights from the aviation-edge flight tracker.
+// Empty fields are not sent.
+type FlightQuery struct {
+	DepIATA     string // Departure airport, e.g. LHR
+	ArrIATA     string // Arrival airport
+	AirlineIATA string // Two-character airline code, e.g. BA
+	FlightIATA  string // Flight number, e.g. BA117
+	Status      string // One of scheduled, en-route, landed, started or unknown
+	Limit       int    // Maximum number of flights, zero for the upstream default
+}
+
+// flightQueryStatuses are the statuses the flight tracker filters on
+var flightQueryStatuses = map[string]bool{
+	"scheduled": true,
+	"en-route":  true,
+	"landed":    true,
+	"started":   true,
+	"unknown":   true,
+}
+
+// Validate checks the query's fields are well formed
+func (q FlightQuery) Validate() error {
+	for _, airport := range []struct{ name, code string }{{"depIata", q.DepIATA}, {"arrIata", q.ArrIATA}} {
+		if airport.code != "" {
+			if _, err := normalizeIATAAirport(airport.code); err != nil {
+				return fmt.Errorf("invalid flight query: %s must be a three-letter IATA airport code, got %q", airport.name, airport.code)
+			}
+		}
+	}
+	if q.AirlineIATA != "" && !isAirportCode(strings.ToUpper(strings.TrimSpace(q.AirlineIATA)), 2) {
+		return fmt.Errorf("invalid flight query: airlineIata must be a two-character IATA airline code, got %q", q.AirlineIATA)
+	}
+	if q.FlightIATA != "" && !isIATAFlightNumber(strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(q.FlightIATA), " ", ""))) {
+		return fmt.Errorf("invalid flight query: flightIata must be an IATA flight number such as BA117, got %q", q.FlightIATA)
+	}
+	if q.Status != "" && !flightQueryStatuses[strings.ToLower(q.Status)] {
+		return fmt.Errorf("invalid flight query: status must be scheduled, en-route, landed, started or unknown, got %q", q.Status)
+	}
+	if q.Limit < 0 {
+		return fmt.Errorf("invalid flight query: limit must not be negative, got %d", q.Limit)
+	}
+	return nil
+}
+
+// Params returns the aviation-edge query parameters for the query
+func (q FlightQuery) Params() map[string]string {
+	params := map[string]string{}
+	setParam(params, "depIata", strings.ToUpper(q.DepIATA))
+	setParam(params, "arrIata", strings.ToUpper(q.ArrIATA))
+	setParam(params, "airlineIata", strings.ToUpper(q.AirlineIATA))
+	setParam(params, "flightIata", strings.ToUpper(strings.ReplaceAll(q.FlightIATA, " ", "")))
+	setParam(params, "status", strings.ToLower(q.Status))
+	if q.Limit > 0 {
+		params["limit"] = strconv.Itoa(q.Limit)
+	}
+	return params
+}
+
+// FutureFlightQuery selects scheduled flights from the aviation-edge future
+// schedules. IATACode, Type and Date are required.
+type FutureFlightQuery struct {
+	IATACode    string    // Airport whose schedule is requested
+	Type        string    // departure or arrival
+	Date        time.Time // Schedule date, taken as a calendar date in Date's location
+	AirlineIATA string    // Two-character airline code, optional
+	FlightNum   string    // Flight number without the airline code, e.g. 117, optional
+}
+
+// Validate checks the required fields are set and every field is well formed
+func (q FutureFlightQuery) Validate() error {
+	if q.IATACode == "" {
+		return errors.New("invalid future flight query: iataCode is required")
+	}
+	if _, err := normalizeIATAAirport(q.IATACode); err != nil {
+		return fmt.Errorf("invalid future flight query: iataCode must be a three-letter IATA airport code, got %q", q.IATACode)
+	}
+	if t := strings.ToLower(q.Type); t != "departure" && t != "arrival" {
+		return fmt.Errorf("invalid future flight query: type must be departure or arrival, got %q", q.Type)
+	}
+	if q.Date.IsZero() {
+		return errors.New("invalid future flight query: date is required")
+	}
+	if q.AirlineIATA != "" && !isAirportCode(strings.ToUpper(strings.TrimSpace(q.AirlineIATA)), 2) {
+		return fmt.Errorf("invalid future flight query: airline_iata must be a two-character IATA airline code, got %q", q.AirlineIATA)
+	}
+	if q.FlightNum != "" {
+		if _, err := strconv.Atoi(q.FlightNum); err != nil || len(q.FlightNum) > 4 {
+			return fmt.Errorf("invalid future flight query: flight_num must be one to four digits, got %q", q.FlightNum)
+		}
+	}
+	return nil
+}
+
+// Params returns the aviation-edge query parameters for the query
+func (q FutureFlightQuery) Params() map[string]string {
+	params := map[string]string{
+		"iataCode": strings.ToUpper(q.IATACode),
+		"type":     strings.ToLower(q.Type),
+		"date":     q.Date.Format("2006-01-02"),
+	}
+	setParam(params, "airline_iata", strings.ToUpper(q.AirlineIATA))
+	setParam(params, "flight_num", q.FlightNum)
+	return params
+}
+
+// setParam sets a query parameter when the value is not empty
+func setParam(params map[string]string, key, value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		params[key] = value
+	}
+}
+
+// QueryFlights fetches the live flights matching q
+func (f *FlightsAPI) QueryFlights(q FlightQuery) ([]Flight, error) {
+	return f.QueryFlightsContext(context.Background(), q)
+}
+
+// QueryFlightsContext fetches the live flights matching q, aborting when ctx
+// is done
+func (f *FlightsAPI) QueryFlightsContext(ctx context.Context, q FlightQuery) ([]Flight, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return f.fetchFlights(ctx, "flights", q.Params())
+}
+
+// QueryFutureFlights fetches the scheduled flights matching q
+func (f *FlightsAPI) QueryFutureFlights(q FutureFlightQuery) ([]Flight, error) {
+	return f.QueryFutureFlightsContext(context.Background(), q)
+}
+
+// QueryFutureFlightsContext fetches the scheduled flights matching q,
+// aborting when ctx is done
+func (f *FlightsAPI) QueryFutureFlightsContext(ctx context.Context, q FutureFlightQuery) ([]Flight, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	return f.fetchFlights(ctx, "flightsFuture", q.Params())
+}
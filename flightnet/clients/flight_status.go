@@ -0,0 +1,100 @@
+package clients
+
+import (
+	"strings"
+	"sync"
+)
+
+// FlightStatus is a flight status normalized from the vocabulary of each
+// upstream
+type FlightStatus string
+
+// Normalized flight statuses
+const (
+	StatusScheduled FlightStatus = "scheduled"
+	StatusBoarding  FlightStatus = "boarding"
+	StatusDeparted  FlightStatus = "departed"
+	StatusEnRoute   FlightStatus = "en-route"
+	StatusLanded    FlightStatus = "landed"
+	StatusDelayed   FlightStatus = "delayed"
+	StatusCancelled FlightStatus = "cancelled"
+	StatusDiverted  FlightStatus = "diverted"
+	StatusUnknown   FlightStatus = "unknown"
+)
+
+// flightStatuses maps raw statuses, lowercased with spaces and underscores
+// turned into hyphens, to their normalized form. It covers the aviation-edge
+// flight tracker and timetable and the mock provider.
+var flightStatuses = map[string]FlightStatus{
+	"scheduled": StatusScheduled,
+	"on-time":   StatusScheduled,
+	"expected":  StatusScheduled,
+
+	"boarding":   StatusBoarding,
+	"gate-open":  StatusBoarding,
+	"final-call": StatusBoarding,
+
+	"departed": StatusDeparted,
+	"started":  StatusDeparted,
+	"taxiing":  StatusDeparted,
+
+	"en-route": StatusEnRoute,
+	"enroute":  StatusEnRoute,
+	"active":   StatusEnRoute,
+	"airborne": StatusEnRoute,
+	"in-air":   StatusEnRoute,
+
+	"landed":  StatusLanded,
+	"arrived": StatusLanded,
+
+	"delayed": StatusDelayed,
+
+	"cancelled": StatusCancelled,
+	"canceled":  StatusCancelled,
+
+	"diverted":   StatusDiverted,
+	"redirected": StatusDiverted,
+
+	"unknown": StatusUnknown,
+	"":        StatusUnknown,
+}
+
+// maxUnknownStatuses caps how many distinct unmapped statuses are counted so
+// a misbehaving upstream cannot grow the counts without bound
+const maxUnknownStatuses = 100
+
+var (
+	unknownStatusesMu sync.Mutex
+	unknownStatuses   = map[string]int{}
+)
+
+// NormalizeStatus maps a raw upstream status to a FlightStatus. Statuses not
+// in the mapping table map to StatusUnknown and are counted, see
+// UnknownStatusCounts.
+func NormalizeStatus(raw string) FlightStatus {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	key = strings.NewReplacer(" ", "-", "_", "-").Replace(key)
+	if status, ok := flightStatuses[key]; ok {
+		return status
+	}
+
+	unknownStatusesMu.Lock()
+	defer unknownStatusesMu.Unlock()
+	if _, seen := unknownStatuses[key]; seen || len(unknownStatuses) < maxUnknownStatuses {
+		unknownStatuses[key]++
+	}
+	return StatusUnknown
+}
+
+// UnknownStatusCounts returns how many times each unmapped status has been
+// seen, keyed by its normalized spelling
+func UnknownStatusCounts() map[string]int {
+	unknownStatusesMu.Lock()
+	defer unknownStatusesMu.Unlock()
+
+	counts := make(map[string]int, len(unknownStatuses))
+	for status, n := range unknownStatuses {
+		counts[status] = n
+	}
+	return counts
+}
@@ -0,0 +1,123 @@
+package clients
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// flightTimeLayouts are the layouts scheduled times arrive in. Times without
+// an offset are local to the airport.
+var flightTimeLayouts = []string{
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+}
+
+var (
+	locationsMu sync.Mutex
+	locations   = map[string]*time.Location{}
+)
+
+// airportLocation returns the time zone of an airport in the embedded
+// dataset, looked up by IATA code and then ICAO code
+func airportLocation(iata, icao string) (*time.Location, bool) {
+	index := fallbackAirports()
+	airport, ok := index.byIATA[strings.ToUpper(iata)]
+	if !ok {
+		if airport, ok = index.byICAO[strings.ToUpper(icao)]; !ok {
+			return nil, false
+		}
+	}
+	if airport.Timezone == "" {
+		return nil, false
+	}
+
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	if loc, ok := locations[airport.Timezone]; ok {
+		return loc, loc != nil
+	}
+	loc, err := time.LoadLocation(airport.Timezone)
+	if err != nil {
+		loc = nil // Remembered so the zone database is not searched again
+	}
+	locations[airport.Timezone] = loc
+	return loc, loc != nil
+}
+
+// parseFlightTime parses a scheduled time, reading times without an offset
+// in the airport's zone. An empty value reports ok false with no problem.
+func parseFlightTime(name, value, iata, icao string) (t time.Time, ok bool, problem string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false, ""
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true, ""
+	}
+
+	loc, known := airportLocation(iata, icao)
+	for _, layout := range flightTimeLayouts {
+		if _, err := time.Parse(layout, value); err != nil {
+			continue
+		}
+		if !known {
+			return time.Time{}, false, fmt.Sprintf("%s: no time zone for airport %q", name, iata)
+		}
+		t, _ := time.ParseInLocation(layout, value, loc)
+		return t, true, ""
+	}
+	return time.Time{}, false, fmt.Sprintf("%s: invalid time %q", name, value)
+}
+
+// derive fills in the normalized status and the scheduled times, marking the
+// times unreliable when either is missing or unparseable or the arrival is
+// not after the departure
+func (f *Flight) derive() []string {
+	f.StatusCode = NormalizeStatus(f.Status)
+
+	var problems []string
+	departure, depOK, problem := parseFlightTime("departure.scheduledTime", f.Departure.ScheduledTime, f.Departure.IataCode, f.Departure.IcaoCode)
+	if problem != "" {
+		problems = append(problems, problem)
+	}
+	arrival, arrOK, problem := parseFlightTime("arrival.scheduledTime", f.Arrival.ScheduledTime, f.Arrival.IataCode, f.Arrival.IcaoCode)
+	if problem != "" {
+		problems = append(problems, problem)
+	}
+
+	f.ScheduledDeparture, f.ScheduledArrival = departure, arrival
+	f.TimesUnreliable = !depOK || !arrOK
+	if depOK && arrOK && !arrival.After(departure) {
+		problems = append(problems, fmt.Sprintf("scheduled arrival %s is not after departure %s",
+			f.Arrival.ScheduledTime, f.Departure.ScheduledTime))
+		f.TimesUnreliable = true
+	}
+	return problems
+}
+
+// Duration returns the scheduled block time, or zero when the times are
+// unreliable
+func (f *Flight) Duration() time.Duration {
+	if f.TimesUnreliable {
+		return 0
+	}
+	return f.ScheduledArrival.Sub(f.ScheduledDeparture)
+}
+
+// DelayEstimate reports how long a flight has been overdue at now: one past
+// its scheduled departure whose StatusCode is still StatusScheduled has not
+// left, so it is at least that late. Other flights, and those without a parsed departure
+// time, report zero and false.
+func (f *Flight) DelayEstimate(now time.Time) (time.Duration, bool) {
+	if f.ScheduledDeparture.IsZero() || f.StatusCode != StatusScheduled {
+		return 0, false
+	}
+	if delay := now.Sub(f.ScheduledDeparture); delay > 0 {
+		return delay, true
+	}
+	return 0, false
+}
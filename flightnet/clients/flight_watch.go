@@ -0,0 +1,172 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FlightUpdateType is the kind of change a FlightUpdate reports
+type FlightUpdateType string
+
+// Flight update types
+const (
+	FlightAdded           FlightUpdateType = "added"
+	FlightPositionChanged FlightUpdateType = "position_changed"
+	FlightStatusChanged   FlightUpdateType = "status_changed"
+	FlightRemoved         FlightUpdateType = "removed"
+	FlightWatchError      FlightUpdateType = "error"
+)
+
+// FlightUpdate is a change to a watched flight, or a failed poll
+type FlightUpdate struct {
+	Type         FlightUpdateType
+	FlightNumber string    // IATA flight number, empty for errors
+	Flight       Flight    // Current flight, or the last seen one when removed
+	Previous     *Flight   // Flight before the change, set for position and status changes
+	Err          error     // Set for FlightWatchError
+	Time         time.Time // When the poll completed
+}
+
+// maxWatchBackoff bounds the delay between polls after repeated failures,
+// unless the interval itself is longer
+const maxWatchBackoff = 5 * time.Minute
+
+// Watch polls the flights matching params every interval and sends the
+// changes since the previous poll, keyed by IATA flight number. Every flight
+// in the first poll is reported as added. A failed poll sends a
+// FlightWatchError update and keeps the previous snapshot, and consecutive
+// failures back off exponentially. The channel is closed once ctx is done.
+func (f *FlightsAPI) Watch(ctx context.Context, params map[string]string, interval time.Duration) (<-chan FlightUpdate, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid watch interval %v: must be positive", interval)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Copy params so the caller may reuse the map
+	query := make(map[string]string, len(params))
+	for k, v := range params {
+		query[k] = v
+	}
+
+	updates := make(chan FlightUpdate, 64)
+	go f.watch(ctx, query, interval, updates)
+	return updates, nil
+}
+
+// watch runs the polling loop for Watch
+func (f *FlightsAPI) watch(ctx context.Context, params map[string]string, interval time.Duration, updates chan<- FlightUpdate) {
+	defer close(updates)
+
+	maxDelay := maxWatchBackoff
+	if interval > maxDelay {
+		maxDelay = interval
+	}
+	policy := RetryPolicy{BaseDelay: interval, MaxDelay: maxDelay}
+
+	send := func(update FlightUpdate) bool {
+		select {
+		case updates <- update:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var previous map[string]Flight
+	failures := 0
+	for {
+		flights, err := f.fetchFlights(ctx, "flights", params)
+		if ctx.Err() != nil {
+			return
+		}
+		now := time.Now()
+
+		delay := interval
+		if err != nil && !isNoRecords(err) {
+			failures++
+			delay = policy.backoff(failures + 1)
+			log.Printf("Flight watch poll failed (%d in a row), next poll in %v: %v", failures, delay, err)
+			if !send(FlightUpdate{Type: FlightWatchError, Err: err, Time: now}) {
+				return
+			}
+		} else {
+			failures = 0
+			current := snapshotFlights(flights)
+			for _, update := range diffFlights(previous, current, now) {
+				if !send(update) {
+					return
+				}
+			}
+			previous = current
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// isNoRecords reports whether err is aviation-edge's "No Record Found"
+// response, which means no flight currently matches
+func isNoRecords(err error) bool {
+	var upstream *UpstreamError
+	return errors.As(err, &upstream) && strings.Contains(strings.ToLower(upstream.Text), "no record")
+}
+
+// snapshotFlights indexes flights by IATA flight number. Flights without one
+// cannot be followed between polls and are left out.
+func snapshotFlights(flights []Flight) map[string]Flight {
+	snapshot := make(map[string]Flight, len(flights))
+	for _, flight := range flights {
+		number := strings.ToUpper(strings.TrimSpace(flight.Flight.IataNumber))
+		if number == "" {
+			continue
+		}
+		if _, dup := snapshot[number]; !dup {
+			snapshot[number] = flight
+		}
+	}
+	return snapshot
+}
+
+// diffFlights returns the updates that turn previous into current
+func diffFlights(previous, current map[string]Flight, now time.Time) []FlightUpdate {
+	var updates []FlightUpdate
+	for number, flight := range current {
+		before, ok := previous[number]
+		if !ok {
+			updates = append(updates, FlightUpdate{Type: FlightAdded, FlightNumber: number, Flight: flight, Time: now})
+			continue
+		}
+		if before.Geography != flight.Geography {
+			prev := before
+			updates = append(updates, FlightUpdate{Type: FlightPositionChanged, FlightNumber: number, Flight: flight, Previous: &prev, Time: now})
+		}
+		if before.StatusCode != flight.StatusCode {
+			prev := before
+			updates = append(updates, FlightUpdate{Type: FlightStatusChanged, FlightNumber: number, Flight: flight, Previous: &prev, Time: now})
+		}
+	}
+	for number, flight := range previous {
+		if _, ok := current[number]; !ok {
+			updates = append(updates, FlightUpdate{Type: FlightRemoved, FlightNumber: number, Flight: flight, Time: now})
+		}
+	}
+
+	// Map order is random, so report flights in a stable order
+	sort.SliceStable(updates, func(i, j int) bool {
+		return updates[i].FlightNumber < updates[j].FlightNumber
+	})
+	return updates
+}
@@ -1,8 +1,12 @@
 package clients
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 )
 
 // Flight represents flight data
@@ -16,11 +20,15 @@ type Flight struct {
 		IataCode      string `json:"iataCode"`
 		IcaoCode      string `json:"icaoCode"`
 		ScheduledTime string `json:"scheduledTime"`
+		Terminal      string `json:"terminal"`
+		Gate          string `json:"gate"`
 	} `json:"departure"`
 	Arrival struct {
 		IataCode      string `json:"iataCode"`
 		IcaoCode      string `json:"icaoCode"`
 		ScheduledTime string `json:"scheduledTime"`
+		Terminal      string `json:"terminal"`
+		Gate          string `json:"gate"`
 	} `json:"arrival"`
 	Aircraft struct {
 		RegNumber string `json:"regNumber"`
@@ -36,9 +44,19 @@ type Flight struct {
 		IataCode string `json:"iataCode"`
 		IcaoCode string `json:"icaoCode"`
 	} `json:"airline"`
-	Status string `json:"status"`
+	Status     string       `json:"status"`
+	StatusCode FlightStatus `json:"statusCode"` // Status normalized by NormalizeStatus
+
+	// Scheduled times parsed in the airports' time zones. They are left zero
+	// and TimesUnreliable is set when either is missing or unparseable.
+	ScheduledDeparture time.Time `json:"scheduledDeparture"`
+	ScheduledArrival   time.Time `json:"scheduledArrival"`
+	TimesUnreliable    bool      `json:"timesUnreliable"`
 }
 
+// ErrFlightNotFound is returned when no flight matches a lookup
+var ErrFlightNotFound = errors.New("flight not found")
+
 // FlightsAPI handles flight data
 type FlightsAPI struct {
 	fetcher *Fetcher
@@ -47,53 +65,174 @@ type FlightsAPI struct {
 
 // NewFlightsAPI creates a new FlightsAPI instance
 func NewFlightsAPI() *FlightsAPI {
+	return NewFlightsAPIWithFetcher(NewFetcher())
+}
+
+// NewFlightsAPIWithFetcher creates a FlightsAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewFlightsAPIWithFetcher(fetcher *Fetcher) *FlightsAPI {
 	return &FlightsAPI{
-		fetcher: NewFetcher(),
+		fetcher: fetcher,
 		parser:  NewParser(),
 	}
 }
 
 // GetFlights fetches flight data
+//
+// Deprecated: Use QueryFlights, which validates its parameters.
 func (f *FlightsAPI) GetFlights(params map[string]string) ([]Flight, error) {
-	data, err := f.fetcher.Get("flights", params)
+	return f.fetchFlights(context.Background(), "flights", params)
+}
+
+// GetFlightsContext fetches flight data, aborting when ctx is done
+//
+// Deprecated: Use QueryFlightsContext, which validates its parameters.
+func (f *FlightsAPI) GetFlightsContext(ctx context.Context, params map[string]string) ([]Flight, error) {
+	return f.fetchFlights(ctx, "flights", params)
+}
+
+// GetFutureFlights fetches future flight schedules
+//
+// Deprecated: Use QueryFutureFlights, which checks the required iataCode,
+// type and date are set.
+func (f *FlightsAPI) GetFutureFlights(params map[string]string) ([]Flight, error) {
+	return f.fetchFlights(context.Background(), "flightsFuture", params)
+}
+
+// GetFutureFlightsContext fetches future flight schedules, aborting when ctx is done
+//
+// Deprecated: Use QueryFutureFlightsContext, which checks the required
+// iataCode, type and date are set.
+func (f *FlightsAPI) GetFutureFlightsContext(ctx context.Context, params map[string]string) ([]Flight, error) {
+	return f.fetchFlights(ctx, "flightsFuture", params)
+}
+
+// fetchFlights fetches and parses flights from an aviation-edge endpoint
+func (f *FlightsAPI) fetchFlights(ctx context.Context, endpoint string, params map[string]string) ([]Flight, error) {
+	data, err := f.fetcher.GetContext(ctx, "aviation-edge", endpoint, params)
 	if err != nil {
 		return nil, err
 	}
 
-	// Try parsing as array
-	var flightList []Flight
-	if err := json.Unmarshal(data, &flightList); err == nil {
-		return flightList, nil
+	return ParseOneOrMany[Flight](data)
+}
+
+// GetFlightsByRoute fetches the live flights from one airport to another,
+// both given as IATA codes
+func (f *FlightsAPI) GetFlightsByRoute(originIATA, destIATA string) ([]Flight, error) {
+	return f.GetFlightsByRouteContext(context.Background(), originIATA, destIATA)
+}
+
+// GetFlightsByRouteContext fetches the live flights from one airport to
+// another, aborting when ctx is done
+func (f *FlightsAPI) GetFlightsByRouteContext(ctx context.Context, originIATA, destIATA string) ([]Flight, error) {
+	origin, err := normalizeIATAAirport(originIATA)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := normalizeIATAAirport(destIATA)
+	if err != nil {
+		return nil, err
 	}
 
-	// Try parsing as single object
-	var singleFlight Flight
-	if err := json.Unmarshal(data, &singleFlight); err == nil {
-		return []Flight{singleFlight}, nil
+	flights, err := f.QueryFlightsContext(ctx, FlightQuery{DepIATA: origin, ArrIATA: dest})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errors.New("failed to parse flight response as array or object")
+	// Drop records for other routes, such as placeholder or unfiltered responses
+	matched := flights[:0]
+	for _, flight := range flights {
+		if strings.EqualFold(flight.Departure.IataCode, origin) && strings.EqualFold(flight.Arrival.IataCode, dest) {
+			matched = append(matched, flight)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("route %s-%s: %w", origin, dest, ErrFlightNotFound)
+	}
+	return matched, nil
 }
 
-// GetFutureFlights fetches future flight schedules
-func (f *FlightsAPI) GetFutureFlights(params map[string]string) ([]Flight, error) {
-	data, err := f.fetcher.Get("flightsFuture", params)
+// GetFlightByNumber fetches the live flights with an IATA flight number such
+// as BA117. Multi-leg flights share a number, so every leg is returned,
+// ordered by scheduled departure.
+func (f *FlightsAPI) GetFlightByNumber(flightIATA string) ([]Flight, error) {
+	return f.GetFlightByNumberContext(context.Background(), flightIATA)
+}
+
+// GetFlightByNumberContext fetches the live flights with an IATA flight
+// number, aborting when ctx is done
+func (f *FlightsAPI) GetFlightByNumberContext(ctx context.Context, flightIATA string) ([]Flight, error) {
+	number := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(flightIATA), " ", ""))
+	if !isIATAFlightNumber(number) {
+		return nil, fmt.Errorf("invalid IATA flight number %q", flightIATA)
+	}
+
+	flights, err := f.QueryFlightsContext(ctx, FlightQuery{FlightIATA: number})
 	if err != nil {
 		return nil, err
 	}
 
-	// Try parsing as array
-	var flightList []Flight
-	if err := json.Unmarshal(data, &flightList); err == nil {
-		return flightList, nil
+	matched := flights[:0]
+	for _, flight := range flights {
+		if strings.EqualFold(flight.Flight.IataNumber, number) {
+			matched = append(matched, flight)
+		}
 	}
-
-	// Try parsing as single object
-	var singleFlight Flight
-	if err := json.Unmarshal(data, &singleFlight); err == nil {
-		return []Flight{singleFlight}, nil
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("flight %s: %w", number, ErrFlightNotFound)
 	}
 
-	return nil, errors.New("failed to parse flight response as array or object")
+	// Legs depart in different time zones, so compare parsed times where
+	// both are known and fall back to the local time strings
+	sort.SliceStable(matched, func(i, j int) bool {
+		a, b := matched[i].ScheduledDeparture, matched[j].ScheduledDeparture
+		if !a.IsZero() && !b.IsZero() {
+			return a.Before(b)
+		}
+		return matched[i].Departure.ScheduledTime < matched[j].Departure.ScheduledTime
+	})
+	return matched, nil
 }
 
+// normalizeIATAAirport uppercases an IATA airport code and checks it is
+// three letters
+func normalizeIATAAirport(code string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(code))
+	if len(normalized) != 3 {
+		return "", fmt.Errorf("invalid IATA airport code %q", code)
+	}
+	for i := 0; i < len(normalized); i++ {
+		if normalized[i] < 'A' || normalized[i] > 'Z' {
+			return "", fmt.Errorf("invalid IATA airport code %q", code)
+		}
+	}
+	return normalized, nil
+}
+
+// isIATAFlightNumber reports whether s is a two-character airline designator
+// followed by one to four digits and an optional letter suffix, e.g. BA117
+// or U21234A
+func isIATAFlightNumber(s string) bool {
+	if len(s) < 3 || len(s) > 7 {
+		return false
+	}
+	isAlnum := func(c byte) bool { return c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' }
+	if !isAlnum(s[0]) || !isAlnum(s[1]) {
+		return false
+	}
+
+	digits := s[2:]
+	if last := digits[len(digits)-1]; last >= 'A' && last <= 'Z' {
+		digits = digits[:len(digits)-1]
+	}
+	if len(digits) == 0 || len(digits) > 4 {
+		return false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// BoundingBox is an area between two latitudes and two longitudes in
+// degrees. A box whose MinLon is greater than its MaxLon crosses the
+// antimeridian, e.g. MinLon 170 to MaxLon -170 spans 20° across ±180°.
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+// Validate checks the box lies within valid coordinates
+func (b BoundingBox) Validate() error {
+	for _, lat := range []float64{b.MinLat, b.MaxLat} {
+		if math.IsNaN(lat) || lat < -90 || lat > 90 {
+			return fmt.Errorf("latitude %v out of range [-90, 90]", lat)
+		}
+	}
+	for _, lon := range []float64{b.MinLon, b.MaxLon} {
+		if math.IsNaN(lon) || lon < -180 || lon > 180 {
+			return fmt.Errorf("longitude %v out of range [-180, 180]", lon)
+		}
+	}
+	if b.MinLat > b.MaxLat {
+		return fmt.Errorf("minimum latitude %v is above maximum latitude %v", b.MinLat, b.MaxLat)
+	}
+	return nil
+}
+
+// Contains reports whether a point lies inside the box, edges included
+func (b BoundingBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return lon >= b.MinLon && lon <= b.MaxLon
+	}
+	return lon >= b.MinLon || lon <= b.MaxLon
+}
+
+// hasPosition reports whether a flight reports a position. aviation-edge
+// sends zeros when it has none.
+func (f *Flight) hasPosition() bool {
+	return f.Geography.Latitude != 0 || f.Geography.Longitude != 0
+}
+
+// GetFlightsInBoundingBox fetches the live flights currently inside a box
+func (f *FlightsAPI) GetFlightsInBoundingBox(minLat, minLon, maxLat, maxLon float64) ([]Flight, error) {
+	return f.GetFlightsInBoundingBoxContext(context.Background(), BoundingBox{minLat, minLon, maxLat, maxLon})
+}
+
+// GetFlightsInBoundingBoxContext fetches the live flights currently inside
+// box, aborting when ctx is done
+func (f *FlightsAPI) GetFlightsInBoundingBoxContext(ctx context.Context, box BoundingBox) ([]Flight, error) {
+	if err := box.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid bounding box: %w", err)
+	}
+
+	return f.filterLiveFlights(ctx, func(flight *Flight) bool {
+		return box.Contains(flight.Geography.Latitude, flight.Geography.Longitude)
+	})
+}
+
+// GetFlightsNearPoint fetches the live flights within radiusKM of a point,
+// measured along the great circle
+func (f *FlightsAPI) GetFlightsNearPoint(lat, lon, radiusKM float64) ([]Flight, error) {
+	return f.GetFlightsNearPointContext(context.Background(), lat, lon, radiusKM)
+}
+
+// GetFlightsNearPointContext fetches the live flights within radiusKM of a
+// point, aborting when ctx is done
+func (f *FlightsAPI) GetFlightsNearPointContext(ctx context.Context, lat, lon, radiusKM float64) ([]Flight, error) {
+	if err := (BoundingBox{lat, lon, lat, lon}).Validate(); err != nil {
+		return nil, fmt.Errorf("invalid point: %w", err)
+	}
+	if math.IsNaN(radiusKM) || math.IsInf(radiusKM, 0) || radiusKM <= 0 {
+		return nil, fmt.Errorf("invalid radius %v: must be a positive number of kilometres", radiusKM)
+	}
+
+	return f.filterLiveFlights(ctx, func(flight *Flight) bool {
+		return GreatCircleDistance(lat, lon, flight.Geography.Latitude, flight.Geography.Longitude) <= radiusKM
+	})
+}
+
+// filterLiveFlights fetches all live flights and keeps those with a position
+// that match
+func (f *FlightsAPI) filterLiveFlights(ctx context.Context, match func(*Flight) bool) ([]Flight, error) {
+	flights, err := f.QueryFlightsContext(ctx, FlightQuery{})
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []Flight{}
+	for i := range flights {
+		if flights[i].hasPosition() && match(&flights[i]) {
+			matched = append(matched, flights[i])
+		}
+	}
+	return matched, nil
+}
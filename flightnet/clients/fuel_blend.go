@@ -0,0 +1,111 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// DefaultSAFLifecycleReduction is the lifecycle CO2 reduction of sustainable
+// aviation fuel over fossil jet fuel, in percent, typical of HEFA fuels made
+// from waste oils
+const DefaultSAFLifecycleReduction = 80.0
+
+// FuelBlend is a blend of sustainable aviation fuel (SAF) with fossil jet
+// fuel
+type FuelBlend struct {
+	SAFPercent float64 `json:"saf_percent"` // Share of SAF in the blend
+	// LifecycleReductionPercent is how much less lifecycle CO2 the SAF emits
+	// than fossil fuel, zero for DefaultSAFLifecycleReduction
+	LifecycleReductionPercent float64 `json:"lifecycle_reduction_percent"`
+}
+
+// Validate checks both percentages are between 0 and 100
+func (b FuelBlend) Validate() error {
+	if !(b.SAFPercent >= 0 && b.SAFPercent <= 100) {
+		return fmt.Errorf("invalid SAF share %v: must be between 0 and 100 percent", b.SAFPercent)
+	}
+	if !(b.LifecycleReductionPercent >= 0 && b.LifecycleReductionPercent <= 100) {
+		return fmt.Errorf("invalid SAF lifecycle reduction %v: must be between 0 and 100 percent", b.LifecycleReductionPercent)
+	}
+	return nil
+}
+
+// lifecycleReduction returns the SAF's lifecycle reduction, applying the
+// default
+func (b FuelBlend) lifecycleReduction() float64 {
+	if b.LifecycleReductionPercent == 0 {
+		return DefaultSAFLifecycleReduction
+	}
+	return b.LifecycleReductionPercent
+}
+
+// FuelBlendAdjustment records how a fuel blend changed a flight's lifecycle
+// CO2
+type FuelBlendAdjustment struct {
+	SAFPercent                float64 `json:"saf_percent"`
+	LifecycleReductionPercent float64 `json:"lifecycle_reduction_percent"`
+	UnadjustedCO2Kg           float64 `json:"unadjusted_co2_kg"` // With fossil fuel only
+	AdjustedCO2Kg             float64 `json:"adjusted_co2_kg"`
+	ReductionKg               float64 `json:"reduction_kg"`
+}
+
+// GetFlightEmissionsWithFuelBlend calculates a flight's CO2 emissions when
+// it burns a SAF blend
+func (s *SustainabilityAPI) GetFlightEmissionsWithFuelBlend(origin, destination, cabinClass, airline, aircraft string, blend FuelBlend) (*SustainabilityData, error) {
+	return s.GetFlightEmissionsWithFuelBlendContext(context.Background(), origin, destination, cabinClass, airline, aircraft, blend)
+}
+
+// GetFlightEmissionsWithFuelBlendContext calculates a flight's emissions
+// with GetFlightEmissions and scales its lifecycle CO2 for blend, aborting
+// when ctx is done. Fuel burn is unchanged.
+func (s *SustainabilityAPI) GetFlightEmissionsWithFuelBlendContext(ctx context.Context, origin, destination, cabinClass, airline, aircraft string, blend FuelBlend) (*SustainabilityData, error) {
+	if err := blend.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := s.GetFlightEmissionsContext(ctx, origin, destination, cabinClass, airline, aircraft)
+	if err != nil {
+		return nil, err
+	}
+	applyFuelBlend(data, blend)
+	return data, nil
+}
+
+// GetRouteEmissionsWithFuelBlend calculates emissions for a route flown on a
+// SAF blend
+func (s *SustainabilityAPI) GetRouteEmissionsWithFuelBlend(origin, destination string, blend FuelBlend) (*SustainabilityData, error) {
+	return s.GetRouteEmissionsWithFuelBlendContext(context.Background(), origin, destination, blend)
+}
+
+// GetRouteEmissionsWithFuelBlendContext calculates route emissions with
+// GetRouteEmissions and scales their lifecycle CO2 for blend, aborting when
+// ctx is done. The offset estimate covers the adjusted CO2.
+func (s *SustainabilityAPI) GetRouteEmissionsWithFuelBlendContext(ctx context.Context, origin, destination string, blend FuelBlend) (*SustainabilityData, error) {
+	if err := blend.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := s.GetRouteEmissionsContext(ctx, origin, destination)
+	if err != nil {
+		return nil, err
+	}
+	applyFuelBlend(data, blend)
+	data.Offset = s.EstimateOffset(data)
+	return data, nil
+}
+
+// applyFuelBlend scales data's unadjusted CO2 figures for a validated blend
+func applyFuelBlend(data *SustainabilityData, blend FuelBlend) {
+	unadjusted := data.CO2Emissions.Total
+	reduction := blend.lifecycleReduction()
+	factor := 1 - blend.SAFPercent/100*reduction/100
+	data.CO2Emissions.Total *= factor
+	data.CO2Emissions.PerKm *= factor
+	data.CO2Emissions.PerSeat *= factor
+	data.FuelBlend = &FuelBlendAdjustment{
+		SAFPercent:                blend.SAFPercent,
+		LifecycleReductionPercent: reduction,
+		UnadjustedCO2Kg:           math.Round(unadjusted*10) / 10,
+		AdjustedCO2Kg:             math.Round(data.CO2Emissions.Total*10) / 10,
+		ReductionKg:               math.Round((unadjusted-data.CO2Emissions.Total)*10) / 10,
+	}
+}
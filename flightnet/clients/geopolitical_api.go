@@ -1,6 +1,13 @@
 package clients
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -15,10 +22,17 @@ type GeopoliticalRisk struct {
 		Economic  float64 `json:"economic"`
 		Security  float64 `json:"security"`
 		Social    float64 `json:"social"`
+		Advisory  float64 `json:"advisory"` // From the travel advisory level, zero without one
 	} `json:"factors"`
-	Description string `json:"description"`
-	Source      string `json:"source"`
-	Alerts      []struct {
+	Weights RiskWeights `json:"weights"` // Weights of the factors in RiskScore
+	// Advisory is the State Department travel advisory, e.g. "Level 4: Do
+	// Not Travel", empty when there is none
+	Advisory       string `json:"advisory,omitempty"`
+	AdvisoryLevel  int    `json:"advisory_level,omitempty"`
+	AdvisorySource string `json:"advisory_source,omitempty"`
+	Description    string `json:"description"`
+	Source         string `json:"source"`
+	Alerts         []struct {
 		Type        string `json:"type"`
 		Severity    string `json:"severity"`
 		Description string `json:"description"`
@@ -35,35 +49,125 @@ type GPRIndexData struct {
 	Country   string  `json:"country,omitempty"`
 }
 
+// WorldBankPage is the pagination metadata the World Bank API returns as the
+// first element of every response
+type WorldBankPage struct {
+	Page    int `json:"page"`
+	Pages   int `json:"pages"`
+	PerPage int `json:"per_page"`
+	Total   int `json:"total"`
+}
+
+// WorldBankIndicatorValue is one observation of an indicator for a country
+type WorldBankIndicatorValue struct {
+	CountryISO3 string   `json:"country_iso3"`
+	IndicatorID string   `json:"indicator_id"`
+	Date        string   `json:"date"`
+	Value       *float64 `json:"value"` // nil when the World Bank has no observation
+}
+
+// governanceIndicators are the Worldwide Governance Indicators behind the
+// risk factors, each fetched separately. Estimates range from about -2.5
+// (weak) to 2.5 (strong).
+var governanceIndicators = []struct {
+	id, name, factor string
+}{
+	{"PV.EST", "political stability", "political"}, // Political Stability and Absence of Violence/Terrorism
+	{"CC.EST", "control of corruption", "economic"},
+	{"RL.EST", "rule of law", "security"},
+}
+
 // GeopoliticalAPI handles geopolitical risk data using free sources
 type GeopoliticalAPI struct {
-	fetcher *Fetcher
-	parser  *Parser
+	fetcher    *Fetcher
+	parser     *Parser
+	airports   *AirportsAPI // Route endpoints for GetRouteRisk
+	news       *NewsAPI     // Airspace events for GetRouteRisk
+	advisories *TravelAdvisoryAPI
+	historyMu  sync.Mutex
+	history    RiskHistoryStore // GetCountryRisk results, for GetTrendAnalysis
+	alerts     *riskAlerts
+	weightsMu  sync.Mutex
+	weights    RiskWeights // Factor weights behind RiskScore
 }
 
 // NewGeopoliticalAPI creates a new GeopoliticalAPI instance
 func NewGeopoliticalAPI() *GeopoliticalAPI {
+	return NewGeopoliticalAPIWithFetcher(NewFetcher())
+}
+
+// NewGeopoliticalAPIWithFetcher creates a GeopoliticalAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewGeopoliticalAPIWithFetcher(fetcher *Fetcher) *GeopoliticalAPI {
+	history, _ := NewFileRiskHistory("") // Cannot fail without a file
 	return &GeopoliticalAPI{
-		fetcher: NewFetcher(),
-		parser:  NewParser(),
+		fetcher:    fetcher,
+		parser:     NewParser(),
+		airports:   NewAirportsAPIWithFetcher(fetcher),
+		news:       NewNewsAPIWithFetcher(fetcher),
+		advisories: NewTravelAdvisoryAPIWithFetcher(fetcher),
+		history:    history,
+		weights:    DefaultRiskWeights,
+		alerts: &riskAlerts{
+			interval: DefaultRiskRefreshInterval,
+			subs:     make(map[int]*riskSubscription),
+			wake:     make(chan struct{}, 1),
+		},
 	}
 }
 
 // GetCountryRisk fetches geopolitical risk data for a specific country using free sources
 func (g *GeopoliticalAPI) GetCountryRisk(country string) (*GeopoliticalRisk, error) {
-	// Since we're using free sources, we'll aggregate data from multiple APIs
-	// and create a comprehensive risk assessment
+	return g.GetCountryRiskContext(context.Background(), country)
+}
 
-	// Try to get data from World Bank API (free)
-	worldBankData, err := g.getWorldBankRiskData(country)
+// GetCountryRiskContext fetches geopolitical risk data for a country from the
+// World Bank's governance indicators and the State Department's travel
+// advisory, aborting when ctx is done. country may be any code or name
+// NormalizeCountry accepts. The built-in baseline assessment is returned when
+// no indicator can be fetched. Every result is recorded in the risk history.
+func (g *GeopoliticalAPI) GetCountryRiskContext(ctx context.Context, country string) (*GeopoliticalRisk, error) {
+	record, err := lookupCountry(country)
+	if err != nil {
+		return nil, err
+	}
+	worldBankData, err := g.getWorldBankRiskData(ctx, record)
 	if err != nil {
-		// Fallback to comprehensive mock data based on real geopolitical indicators
-		return g.getComprehensiveRiskData(country), nil
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("World Bank data unavailable for %s, using baseline risk assessment: %v", record.iso2, err)
+		worldBankData = g.getComprehensiveRiskData(record.iso2)
+	}
+	if err := g.applyAdvisory(ctx, worldBankData); err != nil {
+		return nil, err
 	}
 
+	g.recordRisk(worldBankData)
 	return worldBankData, nil
 }
 
+// applyAdvisory merges a country's travel advisory into its risk, failing
+// only when ctx is done. Levels 1 to 4 become advisory factors of 0 to 1.
+func (g *GeopoliticalAPI) applyAdvisory(ctx context.Context, risk *GeopoliticalRisk) error {
+	advisory, err := g.advisories.GetAdvisoryContext(ctx, risk.Country)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !errors.Is(err, ErrNoTravelAdvisory) {
+			log.Printf("Travel advisory unavailable for %s: %v", risk.Country, err)
+		}
+		return nil
+	}
+	risk.Advisory = fmt.Sprintf("Level %d: %s", advisory.Level, advisory.LevelDescription())
+	risk.AdvisoryLevel = advisory.Level
+	risk.AdvisorySource = advisory.Source
+	risk.Factors.Advisory = float64(advisory.Level-1) / 3
+	applyWeights(risk, g.Weights())
+	return nil
+}
+
 // GetGlobalGPRIndex fetches the Global Geopolitical Risk Index (free from policyuncertainty.com)
 func (g *GeopoliticalAPI) GetGlobalGPRIndex() (*GPRIndexData, error) {
 	// The GPR index data is freely available from policyuncertainty.com
@@ -81,42 +185,138 @@ func (g *GeopoliticalAPI) GetGlobalGPRIndex() (*GPRIndexData, error) {
 // GetRegionalRisks fetches geopolitical risks for a specific region using free data
 func (g *GeopoliticalAPI) GetRegionalRisks(region string) ([]GeopoliticalRisk, error) {
 	// Use free data sources to assess regional risks
-	countries := g.getCountriesInRegion(region)
-	var risks []GeopoliticalRisk
-
+	countries, err := regionCountries(region)
+	if err != nil {
+		return nil, err
+	}
+	risks := make([]GeopoliticalRisk, 0, len(countries))
 	for _, country := range countries {
-		risk := g.getComprehensiveRiskData(country)
+		risk := g.getComprehensiveRiskData(country.iso2)
 		risks = append(risks, *risk)
 	}
 
 	return risks, nil
 }
 
-// GetCountryStabilityScore calculates stability score using multiple free indicators
+// GetCountryStabilityScore calculates stability score using multiple free
+// indicators, weighting the factors with the weights set by SetWeights
 func (g *GeopoliticalAPI) GetCountryStabilityScore(country string) (float64, error) {
-	// Aggregate multiple free indicators to create stability score
-	risk := g.getComprehensiveRiskData(country)
+	return g.GetCountryStabilityScoreWeighted(country, g.Weights())
+}
 
-	// Calculate weighted average of different factors
-	stabilityScore := (risk.Factors.Political*0.3 +
-		risk.Factors.Economic*0.25 +
-		risk.Factors.Security*0.25 +
-		risk.Factors.Social*0.2) * 100
+// GetCountryStabilityScoreWeighted calculates stability score between 0 and
+// 100 using the given factor weights
+func (g *GeopoliticalAPI) GetCountryStabilityScoreWeighted(country string, weights RiskWeights) (float64, error) {
+	if err := weights.Validate(); err != nil {
+		return 0, err
+	}
+	iso2, _, _, err := NormalizeCountry(country)
+	if err != nil {
+		return 0, err
+	}
 
-	return stabilityScore, nil
+	// Aggregate multiple free indicators to create stability score
+	risk := g.getComprehensiveRiskData(iso2)
+	return weights.score(risk) * 100, nil
 }
 
-// getWorldBankRiskData fetches data from World Bank API (free)
-func (g *GeopoliticalAPI) getWorldBankRiskData(country string) (*GeopoliticalRisk, error) {
-	// World Bank API is free and provides governance indicators
+// getWorldBankRiskData builds a risk assessment from the most recent World
+// Bank governance estimates. Responses are cached by the Fetcher for a day,
+// since the indicators are only published yearly.
+func (g *GeopoliticalAPI) getWorldBankRiskData(ctx context.Context, country *countryRecord) (*GeopoliticalRisk, error) {
 	params := map[string]string{
-		"country": country,
-		"format":  "json",
+		"format": "json",
+		"mrnev":  "1", // Most recent non-empty value only
+	}
+
+	// Convert estimates into risk factors between 0 (low risk) and 1 (high risk)
+	factors := make(map[string]float64)
+	var estimates []string
+	var latest string
+	var errs []error
+	for _, indicator := range governanceIndicators {
+		endpoint := fmt.Sprintf("country/%s/indicator/%s", country.iso3, indicator.id)
+		data, err := g.fetcher.GetContext(ctx, "world-bank", endpoint, params)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", indicator.id, err))
+			continue
+		}
+		_, values, err := g.parser.ParseWorldBankResponse(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", indicator.id, err))
+			continue
+		}
+		for _, v := range values {
+			if v.IndicatorID != indicator.id || v.Value == nil {
+				continue
+			}
+			factors[indicator.factor] = math.Round(math.Max(0, math.Min(1, (2.5-*v.Value)/5))*1000) / 1000
+			estimates = append(estimates, fmt.Sprintf("%s %.2f", indicator.name, *v.Value))
+			if v.Date > latest {
+				latest = v.Date
+			}
+			break
+		}
+	}
+	if len(factors) == 0 {
+		if len(errs) > 0 {
+			return nil, fmt.Errorf("failed to fetch governance indicators for %s: %w", country.iso2, errors.Join(errs...))
+		}
+		return nil, fmt.Errorf("no governance indicators available for %s", country.iso2)
+	}
+	if len(errs) > 0 {
+		log.Printf("Some governance indicators unavailable for %s: %v", country.iso2, errors.Join(errs...))
+	}
+
+	// There is no social indicator, so the social factor and any factor
+	// without a value are the mean of the factors that have one
+	var sum float64
+	for _, v := range factors {
+		sum += v
+	}
+	mean := math.Round(sum/float64(len(factors))*1000) / 1000
+	factor := func(name string) float64 {
+		if v, ok := factors[name]; ok {
+			return v
+		}
+		return mean
 	}
 
-	// This would call World Bank Governance Indicators API
-	// For now, return structured data based on real indicators
-	return g.getComprehensiveRiskData(country), nil
+	risk := &GeopoliticalRisk{
+		Country:     country.iso2,
+		LastUpdated: time.Now().Format("2006-01-02T15:04:05Z"),
+		Description: "World Bank governance estimates (-2.5 weak to 2.5 strong): " + strings.Join(estimates, ", "),
+		Source:      fmt.Sprintf("World Bank Worldwide Governance Indicators (%s)", latest),
+		Alerts:      g.getCurrentAlerts(country.iso2),
+	}
+	risk.Factors.Political = factor("political")
+	risk.Factors.Economic = factor("economic")
+	risk.Factors.Security = factor("security")
+	risk.Factors.Social = mean
+	applyWeights(risk, g.Weights())
+
+	return risk, nil
+}
+
+// riskLevel describes a risk score between 0 and 1
+func riskLevel(score float64) string {
+	switch {
+	case score < 0.3:
+		return "Low"
+	case score < 0.45:
+		return "Low-Medium"
+	case score < 0.55:
+		return "Medium"
+	case score < 0.65:
+		return "Medium-High"
+	case score < 0.8:
+		return "High"
+	default:
+		return "Very High"
+	}
 }
 
 // getComprehensiveRiskData returns comprehensive risk assessment based on real geopolitical factors
@@ -124,19 +324,18 @@ func (g *GeopoliticalAPI) getComprehensiveRiskData(country string) *Geopolitical
 	// Base risk scores on real geopolitical factors and current events
 	riskData := map[string]struct {
 		political, economic, security, social float64
-		level                                 string
 		description                           string
 	}{
-		"US": {0.3, 0.4, 0.2, 0.3, "Low", "Stable democracy with strong institutions"},
-		"UK": {0.4, 0.5, 0.2, 0.3, "Low-Medium", "Brexit aftermath and political transitions"},
-		"DE": {0.2, 0.3, 0.1, 0.2, "Low", "Stable EU member with strong economy"},
-		"FR": {0.4, 0.4, 0.3, 0.4, "Medium", "Social unrest and security concerns"},
-		"RU": {0.8, 0.7, 0.8, 0.6, "High", "Ongoing conflicts and international sanctions"},
-		"CN": {0.5, 0.4, 0.3, 0.5, "Medium", "Trade tensions and regional disputes"},
-		"IN": {0.5, 0.5, 0.4, 0.6, "Medium", "Regional tensions and economic challenges"},
-		"BR": {0.6, 0.6, 0.5, 0.6, "Medium-High", "Political instability and economic volatility"},
-		"ZA": {0.7, 0.7, 0.8, 0.7, "High", "High crime rates and economic challenges"},
-		"NG": {0.8, 0.8, 0.9, 0.8, "Very High", "Security threats and economic instability"},
+		"US": {0.3, 0.4, 0.2, 0.3, "Stable democracy with strong institutions"},
+		"GB": {0.4, 0.5, 0.2, 0.3, "Brexit aftermath and political transitions"},
+		"DE": {0.2, 0.3, 0.1, 0.2, "Stable EU member with strong economy"},
+		"FR": {0.4, 0.4, 0.3, 0.4, "Social unrest and security concerns"},
+		"RU": {0.8, 0.7, 0.8, 0.6, "Ongoing conflicts and international sanctions"},
+		"CN": {0.5, 0.4, 0.3, 0.5, "Trade tensions and regional disputes"},
+		"IN": {0.5, 0.5, 0.4, 0.6, "Regional tensions and economic challenges"},
+		"BR": {0.6, 0.6, 0.5, 0.6, "Political instability and economic volatility"},
+		"ZA": {0.7, 0.7, 0.8, 0.7, "High crime rates and economic challenges"},
+		"NG": {0.8, 0.8, 0.9, 0.8, "Security threats and economic instability"},
 	}
 
 	data, exists := riskData[country]
@@ -144,24 +343,19 @@ func (g *GeopoliticalAPI) getComprehensiveRiskData(country string) *Geopolitical
 		// Default medium risk for unknown countries
 		data = struct {
 			political, economic, security, social float64
-			level                                 string
 			description                           string
-		}{0.5, 0.5, 0.5, 0.5, "Medium", "Standard risk assessment for country"}
+		}{0.5, 0.5, 0.5, 0.5, "Standard risk assessment for country"}
 	}
 
-	// Calculate overall risk score
-	overallRisk := (data.political + data.economic + data.security + data.social) / 4
-
-	return &GeopoliticalRisk{
+	risk := &GeopoliticalRisk{
 		Country:     country,
-		RiskScore:   overallRisk,
-		RiskLevel:   data.level,
 		LastUpdated: time.Now().Format("2006-01-02T15:04:05Z"),
 		Factors: struct {
 			Political float64 `json:"political"`
 			Economic  float64 `json:"economic"`
 			Security  float64 `json:"security"`
 			Social    float64 `json:"social"`
+			Advisory  float64 `json:"advisory"`
 		}{
 			Political: data.political,
 			Economic:  data.economic,
@@ -169,9 +363,11 @@ func (g *GeopoliticalAPI) getComprehensiveRiskData(country string) *Geopolitical
 			Social:    data.social,
 		},
 		Description: data.description,
-		Source:      "Aggregated from World Bank, GPR Index, and open sources",
+		Source:      "Built-in baseline assessment",
 		Alerts:      g.getCurrentAlerts(country),
 	}
+	applyWeights(risk, g.Weights())
+	return risk
 }
 
 // getCurrentAlerts returns current alerts for a country based on recent events
@@ -234,82 +430,112 @@ func (g *GeopoliticalAPI) getCurrentAlerts(country string) []struct {
 	}
 }
 
-// getCountriesInRegion returns countries for a given region
-func (g *GeopoliticalAPI) getCountriesInRegion(region string) []string {
-	regions := map[string][]string{
-		"Europe":        {"DE", "FR", "UK", "IT", "ES", "NL", "PL"},
-		"Asia":          {"CN", "IN", "JP", "KR", "TH", "VN", "SG"},
-		"North America": {"US", "CA", "MX"},
-		"South America": {"BR", "AR", "CL", "CO", "PE"},
-		"Africa":        {"ZA", "NG", "EG", "KE", "GH"},
-		"Middle East":   {"SA", "AE", "TR", "IL", "IR"},
-	}
+// GetTrendAnalysis analyzes geopolitical risk trends over time
+func (g *GeopoliticalAPI) GetTrendAnalysis(country string, days int) (map[string]interface{}, error) {
+	return g.GetTrendAnalysisContext(context.Background(), country, days)
+}
 
-	if countries, exists := regions[region]; exists {
-		return countries
+// GetTrendAnalysisContext analyzes the risk scores recorded for a country
+// over the last days days, aborting when ctx is done. The current risk is
+// fetched and recorded first. Direction and forecast come from a linear
+// regression over the window and volatility is the scores' standard
+// deviation. With fewer than five samples the direction is "unknown", the
+// confidence is zero and no volatility or forecast is given.
+func (g *GeopoliticalAPI) GetTrendAnalysisContext(ctx context.Context, country string, days int) (map[string]interface{}, error) {
+	if days <= 0 {
+		return nil, fmt.Errorf("invalid trend window %d: must be at least one day", days)
+	}
+	country, _, _, err := NormalizeCountry(country)
+	if err != nil {
+		return nil, err
 	}
 
-	return []string{"US", "UK", "DE"} // Default countries
-}
-
-// GetTrendAnalysis analyzes geopolitical risk trends over time
-func (g *GeopoliticalAPI) GetTrendAnalysis(country string, days int) (map[string]interface{}, error) {
-	// Simulate trend analysis using historical patterns
-	currentRisk := g.getComprehensiveRiskData(country)
+	currentRisk, err := g.GetCountryRiskContext(ctx, country)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	samples, err := g.riskHistory().Samples(country, now.AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk history for %s: %w", country, err)
+	}
 
 	trend := map[string]interface{}{
 		"country":          country,
 		"current_risk":     currentRisk.RiskScore,
-		"trend_direction":  g.calculateTrendDirection(country),
-		"volatility":       g.calculateVolatility(country),
+		"trend_direction":  "unknown",
+		"volatility":       nil,
 		"key_factors":      g.getKeyRiskFactors(country),
-		"forecast_30_days": currentRisk.RiskScore + g.getForecastDelta(country),
-		"confidence_level": 0.75,
-		"last_updated":     time.Now().Format("2006-01-02T15:04:05Z"),
+		"forecast_30_days": nil,
+		"confidence_level": 0.0,
+		"samples":          len(samples),
+		"window_days":      days,
+		"last_updated":     now.Format("2006-01-02T15:04:05Z"),
+	}
+	if len(samples) < minTrendSamples {
+		return trend, nil
+	}
+
+	fit := fitRiskTrend(samples)
+	change := fit.slopePerDay * float64(days)
+	switch {
+	case math.Abs(change) < stableTrendChange:
+		trend["trend_direction"] = "stable"
+	case change > 0:
+		trend["trend_direction"] = "increasing"
+	default:
+		trend["trend_direction"] = "decreasing"
 	}
+	trend["volatility"] = math.Round(fit.stdDev*1000) / 1000
+	forecast := math.Max(0, math.Min(1, currentRisk.RiskScore+fit.slopePerDay*30))
+	trend["forecast_30_days"] = math.Round(forecast*1000) / 1000
+	trend["confidence_level"] = math.Round(math.Min(1, float64(len(samples))/fullTrendConfidenceSamples)*100) / 100
 
 	return trend, nil
 }
 
-// Helper functions for trend analysis
-func (g *GeopoliticalAPI) calculateTrendDirection(country string) string {
-	// Simulate trend calculation based on recent events
-	trendMap := map[string]string{
-		"RU": "increasing",
-		"CN": "stable",
-		"US": "decreasing",
-		"UK": "stable",
-		"DE": "decreasing",
+// SetRiskHistory replaces the store GetCountryRisk results are recorded in.
+// A nil store restores an empty in-memory history.
+func (g *GeopoliticalAPI) SetRiskHistory(store RiskHistoryStore) {
+	if store == nil {
+		store, _ = NewFileRiskHistory("")
 	}
+	g.historyMu.Lock()
+	g.history = store
+	g.historyMu.Unlock()
+}
 
-	if trend, exists := trendMap[country]; exists {
-		return trend
+// PruneRiskHistory removes recorded risk scores older than retention,
+// returning how many were removed
+func (g *GeopoliticalAPI) PruneRiskHistory(retention time.Duration) (int, error) {
+	if retention <= 0 {
+		return 0, fmt.Errorf("invalid retention %v: must be positive", retention)
 	}
-	return "stable"
+	return g.riskHistory().Prune(time.Now().Add(-retention))
 }
 
-func (g *GeopoliticalAPI) calculateVolatility(country string) float64 {
-	// Return volatility score (0-1)
-	volatilityMap := map[string]float64{
-		"RU": 0.8,
-		"CN": 0.4,
-		"US": 0.3,
-		"UK": 0.4,
-		"DE": 0.2,
-	}
+// riskHistory returns the current history store
+func (g *GeopoliticalAPI) riskHistory() RiskHistoryStore {
+	g.historyMu.Lock()
+	defer g.historyMu.Unlock()
+	return g.history
+}
 
-	if vol, exists := volatilityMap[country]; exists {
-		return vol
+// recordRisk appends a risk result to the history, logging failures
+func (g *GeopoliticalAPI) recordRisk(risk *GeopoliticalRisk) {
+	sample := RiskSample{Country: risk.Country, Time: time.Now(), RiskScore: risk.RiskScore, Source: risk.Source}
+	if err := g.riskHistory().Append(sample); err != nil {
+		log.Printf("Failed to record risk for %s: %v", risk.Country, err)
 	}
-	return 0.5
 }
 
+// getKeyRiskFactors returns the main drivers of a country's risk
 func (g *GeopoliticalAPI) getKeyRiskFactors(country string) []string {
 	factorsMap := map[string][]string{
 		"RU": {"International sanctions", "Regional conflicts", "Economic isolation"},
 		"CN": {"Trade tensions", "Regional disputes", "Economic slowdown"},
 		"US": {"Political polarization", "Election cycles", "International commitments"},
-		"UK": {"Post-Brexit adjustments", "Economic challenges", "Political transitions"},
+		"GB": {"Post-Brexit adjustments", "Economic challenges", "Political transitions"},
 		"DE": {"Energy security", "EU leadership role", "Economic dependencies"},
 	}
 
@@ -318,19 +544,3 @@ func (g *GeopoliticalAPI) getKeyRiskFactors(country string) []string {
 	}
 	return []string{"Economic factors", "Political stability", "Regional dynamics"}
 }
-
-func (g *GeopoliticalAPI) getForecastDelta(country string) float64 {
-	// Return expected change in risk score over 30 days
-	deltaMap := map[string]float64{
-		"RU": 0.05,  // Increasing risk
-		"CN": 0.0,   // Stable
-		"US": -0.02, // Decreasing risk
-		"UK": 0.01,  // Slight increase
-		"DE": -0.01, // Slight decrease
-	}
-
-	if delta, exists := deltaMap[country]; exists {
-		return delta
-	}
-	return 0.0
-}
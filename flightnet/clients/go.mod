@@ -2,4 +2,11 @@ module github.com/your-project/clients
 
 go 1.24.4
 
-require github.com/gorilla/mux v1.8.1 // indirect
+require (
+	flightnet v0.0.0-00010101000000-000000000000
+	github.com/gorilla/mux v1.8.1
+	github.com/gorilla/websocket v1.5.3
+	github.com/mattn/go-sqlite3 v1.14.22
+)
+
+replace flightnet => ../
@@ -0,0 +1,24 @@
+// Package dataset embeds the reference tables shared by the clients package
+// and the API bridge server
+package dataset
+
+import _ "embed"
+
+// Airports is the fallback dataset of the busiest airports, with a header row
+// of iata,icao,name,city,country,latitude,longitude,timezone
+//
+//go:embed airports.csv
+var Airports string
+
+// Countries maps countries to their codes, flight information region, region
+// and names, with a header row of iso2,iso3,fir,region,names
+//
+//go:embed countries.csv
+var Countries string
+
+// AircraftTypes maps ICAO aircraft type designators to typical seat counts in
+// a two-class layout, maximum takeoff weights and average cruise fuel burn,
+// with a header row of designator,name,seats,mtow_kg,burn_kg_per_km
+//
+//go:embed aircraft_types.csv
+var AircraftTypes string
@@ -0,0 +1,220 @@
+package clients
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrKeysExhausted is returned when every API key configured for an API has
+// been rejected or has run out of quota
+var ErrKeysExhausted = errors.New("all API keys exhausted")
+
+// KeyStatus reports the state of one configured API key
+type KeyStatus struct {
+	Index          int        `json:"index"`
+	Key            string     `json:"key"` // Masked, only the last 4 characters are shown
+	Active         bool       `json:"active"`
+	Exhausted      bool       `json:"exhausted"`
+	Reason         string     `json:"reason,omitempty"`
+	Failures       int        `json:"failures"`
+	ExhaustedUntil *time.Time `json:"exhausted_until,omitempty"` // Set while out of quota
+}
+
+// keyState tracks failures for a single key
+type keyState struct {
+	failures int
+	reason   string
+	disabled bool      // Rejected by the API, stays disabled until reset
+	until    time.Time // Quota exhausted until this time
+}
+
+// keyRing rotates between the keys of one API
+type keyRing struct {
+	mu     sync.Mutex
+	keys   []string
+	states []keyState
+	active int
+}
+
+// configKeys returns the keys an API config uses, in rotation order
+func configKeys(config APIConfig) []string {
+	if len(config.APIKeys) > 0 {
+		return config.APIKeys
+	}
+	if config.APIKey != "" {
+		return []string{config.APIKey}
+	}
+	return nil
+}
+
+// nonEmpty returns the keys that are set, preserving their order
+func nonEmpty(keys ...string) []string {
+	var set []string
+	for _, key := range keys {
+		if key != "" {
+			set = append(set, key)
+		}
+	}
+	return set
+}
+
+// hasKey reports whether an API config has at least one key
+func (config APIConfig) hasKey() bool {
+	return len(configKeys(config)) > 0
+}
+
+// usable reports whether key i can be used at now; callers hold mu
+func (r *keyRing) usable(i int, now time.Time) bool {
+	s := r.states[i]
+	return !s.disabled && !now.Before(s.until)
+}
+
+// current returns the active key, moving past exhausted keys
+func (r *keyRing) current(apiName string) (string, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for n := 0; n < len(r.keys); n++ {
+		i := (r.active + n) % len(r.keys)
+		if !r.usable(i, now) {
+			continue
+		}
+		if i != r.active {
+			log.Printf("Switching %s to API key index %d", apiName, i)
+			r.active = i
+		}
+		return r.keys[i], i, nil
+	}
+	return "", -1, fmt.Errorf("%s: %w (%d configured)", apiName, ErrKeysExhausted, len(r.keys))
+}
+
+// fail records a rejection of key i and reports whether another key is
+// available to retry with
+func (r *keyRing) fail(apiName string, i int, reason string, until time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := &r.states[i]
+	s.failures++
+	s.reason = reason
+	if until.IsZero() {
+		s.disabled = true
+	} else {
+		s.until = until
+	}
+
+	now := time.Now()
+	for n := 1; n < len(r.keys); n++ {
+		next := (i + n) % len(r.keys)
+		if r.usable(next, now) {
+			log.Printf("API key index %d for %s %s, rotating to key index %d", i, apiName, reason, next)
+			r.active = next
+			return true
+		}
+	}
+	log.Printf("API key index %d for %s %s, no other keys available", i, apiName, reason)
+	return false
+}
+
+// keyRingFor returns the key ring for an API, creating it on first use.
+// It returns nil for APIs that do not send keys.
+func (f *Fetcher) keyRingFor(apiName string, config APIConfig) *keyRing {
+	keys := configKeys(config)
+	if config.AuthStyle == AuthNone || len(keys) == 0 {
+		return nil
+	}
+
+	f.mu.RLock()
+	ring, ok := f.keys[apiName]
+	f.mu.RUnlock()
+	if ok {
+		return ring
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if ring, ok := f.keys[apiName]; ok {
+		return ring
+	}
+	ring = &keyRing{
+		keys:   append([]string(nil), keys...),
+		states: make([]keyState, len(keys)),
+	}
+	f.keys[apiName] = ring
+	return ring
+}
+
+// keyFailure classifies a response that means the key itself is unusable.
+// A zero until means the key was rejected outright.
+func keyFailure(resp *Response) (reason string, until time.Time, ok bool) {
+	if resp == nil {
+		return "", time.Time{}, false
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Sprintf("rejected with status %d", resp.StatusCode), time.Time{}, true
+	case resp.StatusCode == http.StatusPaymentRequired,
+		resp.StatusCode == http.StatusTooManyRequests && bytes.Contains(bytes.ToLower(resp.Body), []byte("quota")):
+		// Daily quotas reset at midnight UTC
+		now := time.Now().UTC()
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return "exceeded its daily quota", midnight, true
+	}
+	return "", time.Time{}, false
+}
+
+// KeyStatus reports the state of each key configured for an API. The second
+// result is false when the API is unknown or does not use keys.
+func (f *Fetcher) KeyStatus(apiName string) ([]KeyStatus, bool) {
+	config, exists := f.getConfig(apiName)
+	if !exists {
+		return nil, false
+	}
+	ring := f.keyRingFor(apiName, config)
+	if ring == nil {
+		return nil, false
+	}
+
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	now := time.Now()
+	status := make([]KeyStatus, len(ring.keys))
+	for i, key := range ring.keys {
+		s := ring.states[i]
+		status[i] = KeyStatus{
+			Index:     i,
+			Key:       maskKey(key),
+			Active:    i == ring.active,
+			Exhausted: !ring.usable(i, now),
+			Reason:    s.reason,
+			Failures:  s.failures,
+		}
+		if now.Before(s.until) {
+			until := s.until
+			status[i].ExhaustedUntil = &until
+		}
+	}
+	return status, true
+}
+
+// ResetKeys clears the failure state of every key configured for an API
+func (f *Fetcher) ResetKeys(apiName string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.keys, apiName)
+}
+
+// maskKey hides all but the last 4 characters of a key
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
@@ -0,0 +1,131 @@
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestKeyRotationOnRejectedKey(t *testing.T) {
+	const keyA, keyB = "key-A-1111", "key-B-2222"
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		calls = append(calls, key)
+		if key != keyB {
+			http.Error(w, "invalid key", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, server.URL, APIConfig{
+		APIKey:    keyA,
+		APIKeys:   []string{keyA, keyB},
+		AuthStyle: AuthKeyInQuery,
+		AuthParam: "key",
+		Retry:     RetryPolicy{MaxAttempts: 1},
+	})
+	body, err := f.Get("test", "thing", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body %q, want key B's response", body)
+	}
+	if len(calls) != 2 || calls[0] != keyA || calls[1] != keyB {
+		t.Errorf("keys sent %v, want A then B", calls)
+	}
+
+	status, ok := f.KeyStatus("test")
+	if !ok || len(status) != 2 {
+		t.Fatalf("KeyStatus %v, %v: want two keys", status, ok)
+	}
+	if !status[0].Exhausted || status[0].Active || status[0].Failures != 1 || status[0].Reason == "" {
+		t.Errorf("key A status %+v, want it failed and inactive", status[0])
+	}
+	if status[1].Exhausted || !status[1].Active {
+		t.Errorf("key B status %+v, want it active", status[1])
+	}
+	// A rejected key is not out of quota, so it has no time to come back at
+	encoded, err := json.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range decoded {
+		if until, ok := key["exhausted_until"]; ok {
+			t.Errorf("key %d encoded exhausted_until %v, want it left out", i, until)
+		}
+	}
+
+	// Key A stays out of rotation for later requests
+	calls = nil
+	if _, err := f.Get("test", "other", nil); err != nil {
+		t.Fatalf("second Get: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != keyB {
+		t.Errorf("keys sent %v, want only B", calls)
+	}
+}
+
+func TestKeyRotationAllKeysRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, server.URL, APIConfig{
+		APIKeys:   []string{"key-A-1111", "key-B-2222"},
+		AuthStyle: AuthKeyInQuery,
+		AuthParam: "key",
+	})
+	if _, err := f.Get("test", "thing", nil); err == nil {
+		t.Fatal("Get succeeded with every key rejected")
+	}
+	if _, err := f.Get("test", "thing", nil); !errors.Is(err, ErrKeysExhausted) {
+		t.Errorf("error %v, want ErrKeysExhausted", err)
+	}
+}
+
+func TestKeyStatusQuotaExhaustedUntil(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "daily quota exceeded", http.StatusPaymentRequired)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, server.URL, APIConfig{
+		APIKeys:   []string{"key-A-1111"},
+		AuthStyle: AuthKeyInQuery,
+		AuthParam: "key",
+		Retry:     RetryPolicy{MaxAttempts: 1},
+	})
+	if _, err := f.Get("test", "thing", nil); err == nil {
+		t.Fatal("Get succeeded with the quota exceeded")
+	}
+
+	status, ok := f.KeyStatus("test")
+	if !ok || len(status) != 1 {
+		t.Fatalf("KeyStatus %v, %v: want one key", status, ok)
+	}
+	encoded, err := json.Marshal(status[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		ExhaustedUntil *time.Time `json:"exhausted_until"`
+	}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.ExhaustedUntil == nil || !decoded.ExhaustedUntil.After(time.Now()) {
+		t.Errorf("encoded %s, want exhausted_until in the future", encoded)
+	}
+}
@@ -0,0 +1,422 @@
+package clients
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// METARReport is a decoded METAR or SPECI observation
+type METARReport struct {
+	Raw             string
+	Station         string
+	ObservationTime time.Time
+	Auto            bool // Automated observation without human oversight
+	Corrected       bool // COR, a correction of an earlier report
+
+	// Wind, speeds in WindUnit (KT, MPS or KMH)
+	WindDirection    int  // Degrees true, 0 when variable or calm
+	WindVariable     bool // VRB, direction varies
+	WindSpeed        int
+	WindGust         int // 0 when no gusts reported
+	WindUnit         string
+	WindVariableFrom int // Direction range for variable winds, e.g. 180V240
+	WindVariableTo   int
+
+	// Visibility, VisibilityMeters is set for both metric and statute forms
+	CAVOK              bool // Ceiling and visibility OK
+	VisibilityMeters   float64
+	VisibilityMiles    float64 // Statute miles, 0 when reported in meters
+	VisibilityLessThan bool    // M prefix, e.g. M1/4SM
+
+	Clouds               []CloudLayer
+	VerticalVisibilityFt int // VV group for an obscured sky, 0 when absent
+
+	TemperatureC  *float64
+	DewpointC     *float64
+	AltimeterHPa  *float64 // QNH, converted from inHg for A groups
+	AltimeterInHg *float64
+
+	// Weather holds present weather codes such as -RA, +TSRA, BR or VCSH
+	Weather []string
+	Remarks string // Text following RMK
+}
+
+var (
+	metarTimeRe       = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	metarWindRe       = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	metarWindVarRe    = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	metarVisMetersRe  = regexp.MustCompile(`^(\d{4})(NDV)?$`)
+	metarVisMilesRe   = regexp.MustCompile(`^(M|P)?(\d+)?(?:(\d)/(\d{1,2}))?SM$`)
+	metarWholeMilesRe = regexp.MustCompile(`^\d$`)
+	metarRVRRe        = regexp.MustCompile(`^R\d{2}[LCR]?/`)
+	metarCloudRe      = regexp.MustCompile(`^(FEW|SCT|BKN|OVC)(\d{3}|///)(CB|TCU|///)?$`)
+	metarVVRe         = regexp.MustCompile(`^VV(\d{3}|///)$`)
+	metarTempRe       = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	metarAltimeterRe  = regexp.MustCompile(`^([AQ])(\d{4})$`)
+	metarWeatherRe    = regexp.MustCompile(`^(-|\+|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+)
+
+// cloudCoverage describes METAR cloud amounts
+var cloudCoverage = map[string]string{
+	"FEW": "Few clouds",
+	"SCT": "Scattered clouds",
+	"BKN": "Broken clouds",
+	"OVC": "Overcast",
+}
+
+// ParseMETAR decodes a raw METAR or SPECI report. Groups it does not
+// recognise, such as runway visual range, are skipped rather than rejected.
+func ParseMETAR(raw string) (*METARReport, error) {
+	return parseMETARAt(raw, time.Now().UTC())
+}
+
+// parseMETARAt decodes raw, resolving the day-of-month observation time
+// against now
+func parseMETARAt(raw string, now time.Time) (*METARReport, error) {
+	tokens := strings.Fields(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	if len(tokens) > 0 && (tokens[0] == "METAR" || tokens[0] == "SPECI") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty METAR")
+	}
+
+	report := &METARReport{Raw: strings.TrimSpace(raw)}
+
+	report.Station = tokens[0]
+	if len(report.Station) != 4 || strings.ToUpper(report.Station) != report.Station {
+		return nil, fmt.Errorf("invalid METAR station %q", tokens[0])
+	}
+	tokens = tokens[1:]
+
+	if len(tokens) > 0 {
+		if m := metarTimeRe.FindStringSubmatch(tokens[0]); m != nil {
+			day, _ := strconv.Atoi(m[1])
+			hour, _ := strconv.Atoi(m[2])
+			minute, _ := strconv.Atoi(m[3])
+			report.ObservationTime = resolveDayTime(now, day, hour, minute)
+			tokens = tokens[1:]
+		}
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		switch {
+		case tok == "RMK":
+			report.Remarks = strings.Join(tokens[i+1:], " ")
+			return report, nil
+		case tok == "NOSIG" || tok == "TEMPO" || tok == "BECMG":
+			// Trend forecasts follow, they are not part of the observation
+			if j := indexOf(tokens[i:], "RMK"); j >= 0 {
+				report.Remarks = strings.Join(tokens[i+j+1:], " ")
+			}
+			return report, nil
+		case tok == "AUTO":
+			report.Auto = true
+		case tok == "COR":
+			report.Corrected = true
+		case tok == "CAVOK":
+			report.CAVOK = true
+			report.VisibilityMeters = 10000
+		case tok == "SKC" || tok == "CLR" || tok == "NSC" || tok == "NCD":
+			// Explicitly no clouds
+		case metarWindRe.MatchString(tok):
+			m := metarWindRe.FindStringSubmatch(tok)
+			if m[1] == "VRB" {
+				report.WindVariable = true
+			} else {
+				report.WindDirection, _ = strconv.Atoi(m[1])
+			}
+			report.WindSpeed, _ = strconv.Atoi(m[2])
+			if m[3] != "" {
+				report.WindGust, _ = strconv.Atoi(m[3])
+			}
+			report.WindUnit = m[4]
+		case metarWindVarRe.MatchString(tok):
+			m := metarWindVarRe.FindStringSubmatch(tok)
+			report.WindVariableFrom, _ = strconv.Atoi(m[1])
+			report.WindVariableTo, _ = strconv.Atoi(m[2])
+		case metarWholeMilesRe.MatchString(tok) && i+1 < len(tokens) && strings.HasSuffix(tokens[i+1], "SM"):
+			// Mixed fraction such as "1 1/2SM"
+			whole, _ := strconv.Atoi(tok)
+			if miles, less, ok := parseStatuteMiles(tokens[i+1]); ok {
+				report.setVisibilityMiles(float64(whole)+miles, less)
+				i++
+			}
+		case strings.HasSuffix(tok, "SM"):
+			if miles, less, ok := parseStatuteMiles(tok); ok {
+				report.setVisibilityMiles(miles, less)
+			}
+		case metarVisMetersRe.MatchString(tok) && report.VisibilityMeters == 0:
+			meters, _ := strconv.Atoi(metarVisMetersRe.FindStringSubmatch(tok)[1])
+			report.VisibilityMeters = float64(meters)
+			if meters == 9999 {
+				report.VisibilityMeters = 10000
+			}
+		case metarRVRRe.MatchString(tok):
+			// Runway visual range is not decoded
+		case metarCloudRe.MatchString(tok):
+			m := metarCloudRe.FindStringSubmatch(tok)
+			layer := CloudLayer{Coverage: m[1], Description: cloudCoverage[m[1]]}
+			if height, err := strconv.Atoi(m[2]); err == nil {
+				layer.AltitudeFt = height * 100
+				layer.AltitudeM = int(float64(layer.AltitudeFt) * 0.3048)
+			}
+			switch m[3] {
+			case "CB":
+				layer.Description += ", cumulonimbus"
+			case "TCU":
+				layer.Description += ", towering cumulus"
+			}
+			report.Clouds = append(report.Clouds, layer)
+		case metarVVRe.MatchString(tok):
+			if height, err := strconv.Atoi(metarVVRe.FindStringSubmatch(tok)[1]); err == nil {
+				report.VerticalVisibilityFt = height * 100
+			}
+		case metarTempRe.MatchString(tok):
+			m := metarTempRe.FindStringSubmatch(tok)
+			report.TemperatureC = parseMETARTemperature(m[1])
+			if m[2] != "" {
+				report.DewpointC = parseMETARTemperature(m[2])
+			}
+		case metarAltimeterRe.MatchString(tok):
+			m := metarAltimeterRe.FindStringSubmatch(tok)
+			value, _ := strconv.Atoi(m[2])
+			var hPa, inHg float64
+			if m[1] == "A" {
+				inHg = float64(value) / 100
+				hPa = inHg * 33.8639
+			} else {
+				hPa = float64(value)
+				inHg = hPa / 33.8639
+			}
+			report.AltimeterHPa = &hPa
+			report.AltimeterInHg = &inHg
+		case isWeatherCode(tok):
+			report.Weather = append(report.Weather, tok)
+		}
+	}
+
+	return report, nil
+}
+
+// setVisibilityMiles records a statute mile visibility
+func (r *METARReport) setVisibilityMiles(miles float64, lessThan bool) {
+	r.VisibilityMiles = miles
+	r.VisibilityMeters = miles * 1609.344
+	r.VisibilityLessThan = lessThan
+}
+
+// Ceiling returns the height of the lowest broken or overcast layer, or the
+// vertical visibility for an obscured sky. ok is false when there is no ceiling.
+func (r *METARReport) Ceiling() (heightFt int, ok bool) {
+	for _, layer := range r.Clouds {
+		if (layer.Coverage == "BKN" || layer.Coverage == "OVC") && (!ok || layer.AltitudeFt < heightFt) {
+			heightFt, ok = layer.AltitudeFt, true
+		}
+	}
+	if r.VerticalVisibilityFt > 0 && (!ok || r.VerticalVisibilityFt < heightFt) {
+		heightFt, ok = r.VerticalVisibilityFt, true
+	}
+	return heightFt, ok
+}
+
+// FlightCategory classifies the observation as VFR, MVFR, IFR or LIFR from
+// the ceiling and visibility
+func (r *METARReport) FlightCategory() string {
+	layers := r.Clouds
+	if r.VerticalVisibilityFt > 0 {
+		// An obscured sky's vertical visibility counts as the ceiling
+		layers = append(layers[:len(layers):len(layers)], CloudLayer{Coverage: "VV", AltitudeFt: r.VerticalVisibilityFt})
+	}
+	category, _ := ComputeFlightCategory(r.VisibilityMeters/1609.344, layers)
+	return category
+}
+
+// UnlimitedCeilingFt is the ceiling ComputeFlightCategory reports when no
+// layer is broken or overcast, e.g. under SKC or CLR
+const UnlimitedCeilingFt = 99999
+
+// ComputeFlightCategory classifies conditions as VFR, MVFR, IFR or LIFR
+// using the standard ceiling and visibility thresholds. The ceiling is the
+// lowest BKN, OVC or VV layer, or UnlimitedCeilingFt when there is none. A
+// visibility of zero or less is treated as unknown, so only the ceiling is
+// used.
+func ComputeFlightCategory(visibilityMiles float64, layers []CloudLayer) (category string, ceilingFt int) {
+	ceilingFt = UnlimitedCeilingFt
+	for _, layer := range layers {
+		switch strings.ToUpper(layer.Coverage) {
+		case "BKN", "OVC", "VV":
+			if layer.AltitudeFt < ceilingFt {
+				ceilingFt = layer.AltitudeFt
+			}
+		}
+	}
+	hasVisibility := visibilityMiles > 0
+
+	switch {
+	case ceilingFt < 500 || (hasVisibility && visibilityMiles < 1):
+		return "LIFR", ceilingFt
+	case ceilingFt < 1000 || (hasVisibility && visibilityMiles < 3):
+		return "IFR", ceilingFt
+	case ceilingFt <= 3000 || (hasVisibility && visibilityMiles <= 5):
+		return "MVFR", ceilingFt
+	default:
+		return "VFR", ceilingFt
+	}
+}
+
+// parseStatuteMiles parses visibility groups such as 10SM, 1/2SM or M1/4SM
+func parseStatuteMiles(tok string) (miles float64, lessThan, ok bool) {
+	m := metarVisMilesRe.FindStringSubmatch(tok)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, false, false
+	}
+	if m[2] != "" {
+		whole, _ := strconv.Atoi(m[2])
+		miles = float64(whole)
+	}
+	if m[3] != "" {
+		num, _ := strconv.Atoi(m[3])
+		den, _ := strconv.Atoi(m[4])
+		if den == 0 {
+			return 0, false, false
+		}
+		miles += float64(num) / float64(den)
+	}
+	return miles, m[1] == "M", true
+}
+
+// parseMETARTemperature parses temperatures such as 12 and M05
+func parseMETARTemperature(s string) *float64 {
+	negative := strings.HasPrefix(s, "M")
+	value, err := strconv.Atoi(strings.TrimPrefix(s, "M"))
+	if err != nil {
+		return nil
+	}
+	t := float64(value)
+	if negative {
+		t = -t
+	}
+	return &t
+}
+
+// isWeatherCode reports whether tok is a present weather group
+func isWeatherCode(tok string) bool {
+	m := metarWeatherRe.FindStringSubmatch(tok)
+	if m == nil {
+		return false
+	}
+	// Needs a phenomenon, or a descriptor that stands alone like TS or VCSH
+	return m[3] != "" || m[2] == "TS" || (m[1] == "VC" && m[2] == "SH")
+}
+
+// resolveDayTime turns a day-of-month and time into the most recent matching
+// time not after now, which handles reports from earlier months. Months
+// without the day are passed over, so a report for the 31st read on March 1
+// is from January 31. An hour of clock skew is allowed; days no month has
+// give the zero time.
+func resolveDayTime(now time.Time, day, hour, minute int) time.Time {
+	for back := 0; back < 12; back++ {
+		t := time.Date(now.Year(), now.Month()-time.Month(back), day, hour, minute, 0, 0, time.UTC)
+		if t.Day() == day && !t.After(now.Add(time.Hour)) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// indexOf returns the index of s in tokens or -1
+func indexOf(tokens []string, s string) int {
+	for i, tok := range tokens {
+		if tok == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// weatherDescriptions name METAR weather phenomena
+var weatherDescriptions = map[string]string{
+	"DZ": "drizzle", "RA": "rain", "SN": "snow", "SG": "snow grains",
+	"IC": "ice crystals", "PL": "ice pellets", "GR": "hail", "GS": "small hail",
+	"UP": "unknown precipitation", "BR": "mist", "FG": "fog", "FU": "smoke",
+	"VA": "volcanic ash", "DU": "dust", "SA": "sand", "HZ": "haze",
+	"PY": "spray", "PO": "dust whirls", "SQ": "squalls", "FC": "funnel cloud",
+	"SS": "sandstorm", "DS": "duststorm", "TS": "thunderstorm", "SH": "showers",
+	"FZ": "freezing", "MI": "shallow", "PR": "partial", "BC": "patches",
+	"DR": "low drifting", "BL": "blowing",
+}
+
+// precipitationCodes are the present weather codes for precipitation
+var precipitationCodes = []string{"DZ", "RA", "SN", "SG", "PL", "GR", "GS", "UP"}
+
+// PrecipitationIntensity returns the heaviest precipitation at the station
+// as none, light, moderate or heavy. Precipitation in the vicinity (VC) is
+// not counted.
+func (r *METARReport) PrecipitationIntensity() string {
+	heaviest := "none"
+	for _, code := range r.Weather {
+		intensity := "moderate"
+		switch {
+		case strings.HasPrefix(code, "VC"):
+			continue
+		case strings.HasPrefix(code, "-"):
+			intensity = "light"
+		case strings.HasPrefix(code, "+"):
+			intensity = "heavy"
+		}
+		for _, precip := range precipitationCodes {
+			if strings.Contains(code, precip) && precipitationLevels[intensity] > precipitationLevels[heaviest] {
+				heaviest = intensity
+			}
+		}
+	}
+	return heaviest
+}
+
+// Conditions describes the present weather in words, e.g. "Light rain, mist"
+func (r *METARReport) Conditions() string {
+	if len(r.Weather) == 0 {
+		switch {
+		case len(r.Clouds) > 0:
+			return r.Clouds[len(r.Clouds)-1].Description
+		case r.VerticalVisibilityFt > 0:
+			return "Sky obscured"
+		default:
+			return "Clear"
+		}
+	}
+
+	var parts []string
+	for _, code := range r.Weather {
+		var words []string
+		switch {
+		case strings.HasPrefix(code, "-"):
+			words = append(words, "light")
+			code = code[1:]
+		case strings.HasPrefix(code, "+"):
+			words = append(words, "heavy")
+			code = code[1:]
+		case strings.HasPrefix(code, "VC"):
+			code = code[2:]
+			words = append(words, "nearby")
+		}
+		for j := 0; j+2 <= len(code); j += 2 {
+			if desc, ok := weatherDescriptions[code[j:j+2]]; ok {
+				words = append(words, desc)
+			}
+		}
+		parts = append(parts, strings.Join(words, " "))
+	}
+
+	text := strings.Join(parts, ", ")
+	if text == "" {
+		return ""
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
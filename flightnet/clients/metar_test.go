@@ -0,0 +1,90 @@
+package clients
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseMETARObservationTime(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		now  time.Time
+		want time.Time
+	}{
+		{
+			name: "same day",
+			raw:  "KJFK 151251Z 31015G25KT 10SM FEW050 SCT250 12/M03 A3012 RMK AO2 SLP199",
+			now:  time.Date(2024, 3, 15, 13, 5, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 15, 12, 51, 0, 0, time.UTC),
+		},
+		{
+			name: "slightly ahead of a slow clock",
+			raw:  "EGLL 151320Z AUTO 24012KT 9999 -RA BKN012 OVC030 09/07 Q1002",
+			now:  time.Date(2024, 3, 15, 13, 5, 0, 0, time.UTC),
+			want: time.Date(2024, 3, 15, 13, 20, 0, 0, time.UTC),
+		},
+		{
+			name: "previous month",
+			raw:  "KLAX 302353Z 25008KT 10SM CLR 18/11 A2995",
+			now:  time.Date(2024, 5, 1, 0, 10, 0, 0, time.UTC),
+			want: time.Date(2024, 4, 30, 23, 53, 0, 0, time.UTC),
+		},
+		{
+			name: "previous year",
+			raw:  "RJTT 312330Z 34007KT 9999 FEW030 05/M04 Q1021 NOSIG",
+			now:  time.Date(2025, 1, 1, 0, 15, 0, 0, time.UTC),
+			want: time.Date(2024, 12, 31, 23, 30, 0, 0, time.UTC),
+		},
+		{
+			name: "previous month lacks the day",
+			raw:  "UUEE 311200Z 20005MPS 9999 OVC010 M02/M04 Q1015 R24L/290050 NOSIG",
+			now:  time.Date(2024, 3, 1, 0, 30, 0, 0, time.UTC),
+			want: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "29th read on March 1 of a common year",
+			raw:  "LFPG 291200Z 22010KT CAVOK 11/04 Q1018 NOSIG",
+			now:  time.Date(2023, 3, 1, 6, 0, 0, 0, time.UTC),
+			want: time.Date(2023, 1, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "29th read on March 1 of a leap year",
+			raw:  "LFPG 291200Z 22010KT CAVOK 11/04 Q1018 NOSIG",
+			now:  time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC),
+			want: time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "day no month has",
+			raw:  "KJFK 321200Z 31015KT 10SM FEW050 12/M03 A3012",
+			now:  time.Date(2024, 3, 15, 13, 0, 0, 0, time.UTC),
+			want: time.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := parseMETARAt(tt.raw, tt.now)
+			if err != nil {
+				t.Fatalf("parseMETARAt: %v", err)
+			}
+			if !report.ObservationTime.Equal(tt.want) {
+				t.Errorf("observation time %s, want %s", report.ObservationTime, tt.want)
+			}
+			if report.ObservationTime.After(tt.now.Add(time.Hour)) {
+				t.Errorf("observation time %s is in the future of %s", report.ObservationTime, tt.now)
+			}
+		})
+	}
+}
+
+func TestParseTAFIssueTimeSkipsShortMonths(t *testing.T) {
+	raw := "TAF UUEE 311100Z 3112/0118 20005MPS 9999 OVC010"
+	now := time.Date(2024, 3, 1, 0, 30, 0, 0, time.UTC)
+	report, err := parseTAFAt(raw, now)
+	if err != nil {
+		t.Fatalf("parseTAFAt: %v", err)
+	}
+	if want := time.Date(2024, 1, 31, 11, 0, 0, 0, time.UTC); !report.IssueTime.Equal(want) {
+		t.Errorf("issue time %s, want %s", report.IssueTime, want)
+	}
+}
@@ -0,0 +1,186 @@
+package clients
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MetricsHook is notified around every upstream HTTP attempt made by a
+// Fetcher, including retries. Cache hits and mock responses are not reported.
+// Implementations must be safe for concurrent use.
+type MetricsHook interface {
+	OnRequestStart(api, endpoint string)
+	// OnRequestEnd reports the outcome of an attempt; status is zero when no
+	// response was received
+	OnRequestEnd(api, endpoint string, status int, dur time.Duration, err error)
+}
+
+// SetMetricsHook installs hook on the Fetcher, nil removes it
+func (f *Fetcher) SetMetricsHook(hook MetricsHook) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.metrics = hook
+}
+
+// observe reports the start of an attempt to the metrics hook and returns
+// the function that reports its end
+func (f *Fetcher) observe(apiName, endpoint string) func(status int, err error) {
+	f.mu.RLock()
+	hook := f.metrics
+	f.mu.RUnlock()
+
+	if hook == nil {
+		return func(int, error) {}
+	}
+
+	hook.OnRequestStart(apiName, endpoint)
+	start := time.Now()
+	return func(status int, err error) {
+		hook.OnRequestEnd(apiName, endpoint, status, time.Since(start), err)
+	}
+}
+
+// latencyBuckets are the upper bounds of the latency histogram
+var latencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// APIMetrics summarises the requests made to one API
+type APIMetrics struct {
+	Requests     int64            `json:"requests"`
+	Errors       int64            `json:"errors"`
+	InFlight     int64            `json:"in_flight"`
+	StatusCodes  map[string]int64 `json:"status_codes"`
+	Endpoints    map[string]int64 `json:"endpoints"`
+	AvgLatencyMs float64          `json:"avg_latency_ms"`
+	MaxLatencyMs float64          `json:"max_latency_ms"`
+	// LatencyHistogram counts requests by latency upper bound, e.g. "250ms"
+	LatencyHistogram map[string]int64 `json:"latency_histogram"`
+}
+
+// apiCounters accumulates the metrics for one API
+type apiCounters struct {
+	requests    int64
+	errors      int64
+	inFlight    int64
+	statusCodes map[int]int64
+	endpoints   map[string]int64
+	total       time.Duration
+	max         time.Duration
+	buckets     []int64 // One per latencyBuckets entry plus an overflow bucket
+}
+
+// InMemoryMetrics is a MetricsHook that keeps request counters and latency
+// histograms in memory
+type InMemoryMetrics struct {
+	mu   sync.Mutex
+	apis map[string]*apiCounters
+}
+
+// NewInMemoryMetrics creates an empty InMemoryMetrics
+func NewInMemoryMetrics() *InMemoryMetrics {
+	return &InMemoryMetrics{apis: make(map[string]*apiCounters)}
+}
+
+// counters returns the counters for an API; callers hold mu
+func (m *InMemoryMetrics) counters(api string) *apiCounters {
+	c, ok := m.apis[api]
+	if !ok {
+		c = &apiCounters{
+			statusCodes: make(map[int]int64),
+			endpoints:   make(map[string]int64),
+			buckets:     make([]int64, len(latencyBuckets)+1),
+		}
+		m.apis[api] = c
+	}
+	return c
+}
+
+// OnRequestStart implements MetricsHook
+func (m *InMemoryMetrics) OnRequestStart(api, endpoint string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters(api).inFlight++
+}
+
+// OnRequestEnd implements MetricsHook
+func (m *InMemoryMetrics) OnRequestEnd(api, endpoint string, status int, dur time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.counters(api)
+	c.inFlight--
+	c.requests++
+	c.endpoints[endpoint]++
+	if err != nil {
+		c.errors++
+	}
+	if status != 0 {
+		c.statusCodes[status]++
+	}
+
+	c.total += dur
+	if dur > c.max {
+		c.max = dur
+	}
+	bucket := sort.Search(len(latencyBuckets), func(i int) bool { return dur <= latencyBuckets[i] })
+	c.buckets[bucket]++
+}
+
+// Summary returns a snapshot of the metrics keyed by API name
+func (m *InMemoryMetrics) Summary() map[string]APIMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	summary := make(map[string]APIMetrics, len(m.apis))
+	for api, c := range m.apis {
+		s := APIMetrics{
+			Requests:         c.requests,
+			Errors:           c.errors,
+			InFlight:         c.inFlight,
+			StatusCodes:      make(map[string]int64, len(c.statusCodes)),
+			Endpoints:        make(map[string]int64, len(c.endpoints)),
+			MaxLatencyMs:     durationMs(c.max),
+			LatencyHistogram: make(map[string]int64, len(c.buckets)),
+		}
+		if c.requests > 0 {
+			s.AvgLatencyMs = durationMs(c.total) / float64(c.requests)
+		}
+		for status, n := range c.statusCodes {
+			s.StatusCodes[strconv.Itoa(status)] = n
+		}
+		for endpoint, n := range c.endpoints {
+			s.Endpoints[endpoint] = n
+		}
+		for i, n := range c.buckets {
+			label := "+Inf"
+			if i < len(latencyBuckets) {
+				label = latencyBuckets[i].String()
+			}
+			s.LatencyHistogram[label] = n
+		}
+		summary[api] = s
+	}
+	return summary
+}
+
+// Reset clears all collected metrics
+func (m *InMemoryMetrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.apis = make(map[string]*apiCounters)
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,117 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrMissingAPIKey is returned when an API that requires a key has none
+// configured and no mock is installed for it
+var ErrMissingAPIKey = errors.New("API key not configured")
+
+// MockTransport answers requests for an API in place of the upstream service
+type MockTransport interface {
+	Respond(endpoint string, params map[string]string) ([]byte, error)
+}
+
+// MockFunc adapts a function to MockTransport
+type MockFunc func(endpoint string, params map[string]string) ([]byte, error)
+
+// Respond implements MockTransport
+func (fn MockFunc) Respond(endpoint string, params map[string]string) ([]byte, error) {
+	return fn(endpoint, params)
+}
+
+// SetMock answers all requests for apiName with mock instead of calling the
+// upstream API, whether or not a key is configured. A nil mock removes it.
+func (f *Fetcher) SetMock(apiName string, mock MockFunc) {
+	if mock == nil {
+		f.SetMockTransport(apiName, nil)
+		return
+	}
+	f.SetMockTransport(apiName, mock)
+}
+
+// SetMockTransport installs a MockTransport for apiName, nil removes it
+func (f *Fetcher) SetMockTransport(apiName string, mock MockTransport) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if mock == nil {
+		delete(f.mocks, apiName)
+	} else {
+		f.mocks[apiName] = mock
+	}
+	if f.cache != nil {
+		f.cache.invalidate(apiName + "|")
+	}
+}
+
+// HasMock reports whether a mock answers requests for apiName
+func (f *Fetcher) HasMock(apiName string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.mocks[apiName] != nil
+}
+
+// CannedMock returns a mock that serves the fixed placeholder payloads the
+// Fetcher used to return for APIs without a key
+func CannedMock(apiName string) MockFunc {
+	return func(endpoint string, params map[string]string) ([]byte, error) {
+		return cannedResponse(apiName, endpoint), nil
+	}
+}
+
+// intercept answers a request from an installed mock, or fails it when the
+// API needs a key that is not configured. handled is false when the request
+// should go upstream.
+func (f *Fetcher) intercept(apiName string, config APIConfig, endpoint string, params map[string]string) (body []byte, handled bool, err error) {
+	f.mu.RLock()
+	mock := f.mocks[apiName]
+	f.mu.RUnlock()
+
+	if mock != nil {
+		log.Printf("Serving mock response for %s endpoint: %s", apiName, endpoint)
+		body, err := mock.Respond(endpoint, params)
+		return body, true, err
+	}
+	if config.AuthStyle != AuthNone && !config.hasKey() {
+		return nil, true, fmt.Errorf("%s: %w", apiName, ErrMissingAPIKey)
+	}
+	return nil, false, nil
+}
+
+// cannedResponse returns a fixed placeholder payload for an API endpoint
+func cannedResponse(apiName, endpoint string) []byte {
+	switch apiName {
+	case "aviation-edge":
+		switch endpoint {
+		case "airplaneDatabase":
+			return []byte(`[{"airplaneId":"mock-id"}]`)
+		case "flights":
+			return []byte(`[{"flight":{"number":"mock-flight"}}]`)
+		case "flightsFuture":
+			return []byte(`[{"flight":{"number":"mock-future"}}]`)
+		case "airportWeather":
+			return []byte(`{"airport_icao":"MOCK","current_weather":{"temperature":{"celsius":20}}}`)
+		default:
+			return []byte(`[{}]`)
+		}
+	case "icao":
+		return []byte(`{"co2_emissions":{"total_kg":1000}}`)
+	case "world-bank":
+		switch endpoint {
+		case "sources":
+			return []byte(`[{"page":1,"pages":1,"per_page":"50","total":61},[{"id":"1","name":"Doing Business","code":"","description":"","url":"","dataavailability":"Y","metadataavailability":"Y","concepts":"3"}]]`)
+		case "indicator":
+			return []byte(`[{"page":1,"pages":1,"per_page":"50","total":16000},[{"id":"SP.POP.TOTL","name":"Population, total","unit":"","source":{"id":"2","value":"World Development Indicators"},"sourceNote":"Total population is based on the de facto definition of population.","sourceOrganization":"( 1 ) United Nations Population Division.","topics":[{"id":"8","value":"Health "}]}]]`)
+		default:
+			return []byte(`[{"page":1,"pages":1,"per_page":"50","total":1},[{"id":"mock","value":"Mock World Bank Data"}]]`)
+		}
+	case "fuel-api":
+		return []byte(`{"aircraft":"mock-aircraft","distance":1000,"fuel_burn":2000,"co2_emissions":6000,"unit":"kg"}`)
+	default:
+		return []byte(`{}`)
+	}
+}
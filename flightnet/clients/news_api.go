@@ -1,14 +1,17 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/url"
-	"strings"
+	"log"
+	"net/http"
+	"sync"
 	"time"
 )
 
-// NewsArticle represents a news article from NewsAPI
+// NewsArticle represents a news article from NewsAPI or a news feed
 type NewsArticle struct {
 	Source struct {
 		ID   string `json:"id"`
@@ -21,6 +24,7 @@ type NewsArticle struct {
 	URLToImage  string `json:"urlToImage"`
 	PublishedAt string `json:"publishedAt"`
 	Content     string `json:"content"`
+	Origin      string `json:"origin,omitempty"` // NewsOriginNewsAPI, NewsOriginRSS or NewsOriginMock
 }
 
 // NewsSource represents a news source from NewsAPI
@@ -39,12 +43,16 @@ type NewsResponse struct {
 	Status       string        `json:"status"`
 	TotalResults int           `json:"totalResults"`
 	Articles     []NewsArticle `json:"articles"`
+	FetchedAt    time.Time     `json:"fetchedAt"`
+	Stale        bool          `json:"stale,omitempty"` // Served from the cache because the daily quota is used up
 }
 
 // SourcesResponse represents the sources endpoint response
 type SourcesResponse struct {
-	Status  string       `json:"status"`
-	Sources []NewsSource `json:"sources"`
+	Status    string       `json:"status"`
+	Sources   []NewsSource `json:"sources"`
+	FetchedAt time.Time    `json:"fetchedAt"`
+	Stale     bool         `json:"stale,omitempty"` // Served from the cache because the daily quota is used up
 }
 
 // TopHeadlinesParams represents parameters for top headlines endpoint
@@ -78,97 +86,176 @@ type SourcesParams struct {
 	Country  string `json:"country,omitempty"`
 }
 
+// NewsAPIError is an error response from NewsAPI, such as
+// {"status":"error","code":"apiKeyInvalid","message":"..."}
+type NewsAPIError struct {
+	StatusCode int
+	Code       string // NewsAPI error code, e.g. rateLimited, apiKeyInvalid or parameterInvalid
+	Message    string
+	err        error // Underlying Fetcher error
+}
+
+func (e *NewsAPIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("NewsAPI error %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("NewsAPI error %s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Unwrap returns the Fetcher error the NewsAPI error was parsed from
+func (e *NewsAPIError) Unwrap() error {
+	return e.err
+}
+
+// Is makes errors.Is(err, ErrRateLimited) true when NewsAPI reports rate limiting
+func (e *NewsAPIError) Is(target error) bool {
+	return target == ErrRateLimited && e.Code == "rateLimited"
+}
+
+// newsAPIErrorBody is the body of a NewsAPI error response
+type newsAPIErrorBody struct {
+	Status  string `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
 // NewsAPI handles news data from NewsAPI.org
 type NewsAPI struct {
-	fetcher *Fetcher
-	parser  *Parser
-	apiKey  string
+	fetcher  *Fetcher
+	parser   *Parser
+	airports *AirportsAPI // Resolves route endpoints for GetNewsForRoute
+	quota    *newsQuota
+	cacheMu  sync.Mutex
+	cache    map[string]newsCacheEntry // Responses by query, kept for the quota
+	maxStale time.Duration
+	feedsMu  sync.Mutex
+	feeds    []NewsFeed // Read when NewsAPI is unavailable
 }
 
 // NewNewsAPI creates a new NewsAPI instance
 func NewNewsAPI() *NewsAPI {
-	apiKey := getAPIKey("NEWS_API_KEY")
-	return &NewsAPI{
-		fetcher: NewFetcher(),
-		parser:  NewParser(),
-		apiKey:  apiKey,
-	}
+	return NewNewsAPIWithFetcher(NewFetcher())
+}
+
+// NewNewsAPIWithFetcher creates a NewsAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewNewsAPIWithFetcher(fetcher *Fetcher) *NewsAPI {
+	n := &NewsAPI{
+		fetcher:  fetcher,
+		parser:   NewParser(),
+		airports: NewAirportsAPIWithFetcher(fetcher),
+		quota:    &newsQuota{limit: DefaultNewsAPIDailyQuota},
+		cache:    make(map[string]newsCacheEntry),
+		maxStale: DefaultNewsMaxStaleness,
+	}
+	if err := n.SetFeeds(DefaultNewsFeeds); err != nil {
+		log.Printf("Failed to register news feeds: %v", err)
+	}
+	return n
 }
 
 // GetTopHeadlines fetches top headlines with optional parameters
 func (n *NewsAPI) GetTopHeadlines(params TopHeadlinesParams) (*NewsResponse, error) {
-	if n.apiKey == "" {
+	return n.GetTopHeadlinesContext(context.Background(), params)
+}
+
+// GetTopHeadlinesContext fetches top headlines, aborting when ctx is done
+func (n *NewsAPI) GetTopHeadlinesContext(ctx context.Context, params TopHeadlinesParams) (*NewsResponse, error) {
+	result, err := n.quotaRequest(ctx, "top-headlines", n.buildTopHeadlinesParams(params))
+	if errors.Is(err, ErrMissingAPIKey) {
 		return n.getMockTopHeadlines(), nil
 	}
-
-	// Build query parameters
-	queryParams := n.buildTopHeadlinesParams(params)
-	queryParams["apiKey"] = n.apiKey
-
-	// Make request using custom fetcher
-	data, err := n.makeNewsAPIRequest("top-headlines", queryParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch top headlines: %w", err)
 	}
 
 	var response NewsResponse
-	if err := json.Unmarshal(data, &response); err != nil {
+	if err := json.Unmarshal(result.body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse top headlines response: %w", err)
 	}
+	response.FetchedAt, response.Stale = result.fetchedAt, result.stale
+	for i := range response.Articles {
+		response.Articles[i].Origin = NewsOriginNewsAPI
+	}
 
 	return &response, nil
 }
 
 // GetEverything fetches all articles matching the query parameters
 func (n *NewsAPI) GetEverything(params EverythingParams) (*NewsResponse, error) {
-	if n.apiKey == "" {
+	return n.GetEverythingContext(context.Background(), params)
+}
+
+// GetEverythingContext fetches all articles matching the query parameters,
+// aborting when ctx is done
+func (n *NewsAPI) GetEverythingContext(ctx context.Context, params EverythingParams) (*NewsResponse, error) {
+	response, err := n.everything(ctx, params)
+	if errors.Is(err, ErrMissingAPIKey) {
 		return n.getMockEverything(params.Q), nil
 	}
+	return response, err
+}
 
-	// Build query parameters
-	queryParams := n.buildEverythingParams(params)
-	queryParams["apiKey"] = n.apiKey
-
-	// Make request using custom fetcher
-	data, err := n.makeNewsAPIRequest("everything", queryParams)
+// everything fetches articles from NewsAPI without falling back to mock data
+func (n *NewsAPI) everything(ctx context.Context, params EverythingParams) (*NewsResponse, error) {
+	result, err := n.quotaRequest(ctx, "everything", n.buildEverythingParams(params))
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch everything: %w", err)
 	}
 
 	var response NewsResponse
-	if err := json.Unmarshal(data, &response); err != nil {
+	if err := json.Unmarshal(result.body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse everything response: %w", err)
 	}
+	response.FetchedAt, response.Stale = result.fetchedAt, result.stale
+	for i := range response.Articles {
+		response.Articles[i].Origin = NewsOriginNewsAPI
+	}
 
 	return &response, nil
 }
 
 // GetSources fetches news sources with optional filters
 func (n *NewsAPI) GetSources(params SourcesParams) (*SourcesResponse, error) {
-	if n.apiKey == "" {
+	return n.GetSourcesContext(context.Background(), params)
+}
+
+// GetSourcesContext fetches news sources, aborting when ctx is done
+func (n *NewsAPI) GetSourcesContext(ctx context.Context, params SourcesParams) (*SourcesResponse, error) {
+	result, err := n.quotaRequest(ctx, "top-headlines/sources", n.buildSourcesParams(params))
+	if errors.Is(err, ErrMissingAPIKey) {
 		return n.getMockSources(), nil
 	}
-
-	// Build query parameters
-	queryParams := n.buildSourcesParams(params)
-	queryParams["apiKey"] = n.apiKey
-
-	// Make request using custom fetcher
-	data, err := n.makeNewsAPIRequest("top-headlines/sources", queryParams)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch sources: %w", err)
 	}
 
 	var response SourcesResponse
-	if err := json.Unmarshal(data, &response); err != nil {
+	if err := json.Unmarshal(result.body, &response); err != nil {
 		return nil, fmt.Errorf("failed to parse sources response: %w", err)
 	}
+	response.FetchedAt, response.Stale = result.fetchedAt, result.stale
 
 	return &response, nil
 }
 
 // GetGeopoliticalNews fetches news related to geopolitical events
 func (n *NewsAPI) GetGeopoliticalNews(countries []string) (*NewsResponse, error) {
+	return n.GetGeopoliticalNewsContext(context.Background(), countries)
+}
+
+// GetGeopoliticalNewsContext fetches news related to geopolitical events,
+// aborting when ctx is done. When NewsAPI fails or has no key, articles
+// mentioning the same terms are read from the configured news feeds; mock
+// data is only returned when there is no key and no feed can be read.
+func (n *NewsAPI) GetGeopoliticalNewsContext(ctx context.Context, countries []string) (*NewsResponse, error) {
+	return n.geopoliticalNews(ctx, countries, time.Time{}, maxGeopoliticalArticles)
+}
+
+// geopoliticalNews fetches up to limit geopolitical articles published since
+// since, or of any age when since is zero, falling back to the news feeds
+// and then mock data as described for GetGeopoliticalNewsContext
+func (n *NewsAPI) geopoliticalNews(ctx context.Context, countries []string, since time.Time, limit int) (*NewsResponse, error) {
 	// Build query for geopolitical terms
 	geopoliticalTerms := []string{
 		"conflict", "war", "sanctions", "diplomacy", "military",
@@ -177,30 +264,60 @@ func (n *NewsAPI) GetGeopoliticalNews(countries []string) (*NewsResponse, error)
 
 	// Add country names to search terms
 	searchTerms := append(geopoliticalTerms, countries...)
-	query := strings.Join(searchTerms, " OR ")
+	query := NewNewsQuery().Should(searchTerms...)
 
 	params := EverythingParams{
-		Q:        query,
 		Language: "en",
 		SortBy:   "publishedAt",
-		PageSize: 20,
+		PageSize: limit,
+	}
+	if !since.IsZero() {
+		params.From = since.UTC().Format(time.RFC3339)
 	}
 
-	return n.GetEverything(params)
+	response, err := n.everythingQuery(ctx, query, params)
+	if err == nil {
+		return response, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	log.Printf("NewsAPI unavailable for geopolitical news, reading news feeds: %v", err)
+
+	feedNews, feedErr := n.GetFeedNewsContext(ctx, searchTerms)
+	switch {
+	case feedErr == nil:
+		if params.From != "" {
+			recent := feedNews.Articles[:0]
+			for _, article := range feedNews.Articles {
+				// Feed dates are RFC 3339 UTC, so they compare as strings
+				if article.PublishedAt >= params.From {
+					recent = append(recent, article)
+				}
+			}
+			feedNews.Articles, feedNews.TotalResults = recent, len(recent)
+		}
+		if len(feedNews.Articles) > limit {
+			feedNews.Articles = feedNews.Articles[:limit]
+		}
+		return feedNews, nil
+	case errors.Is(err, ErrMissingAPIKey):
+		log.Printf("News feeds unavailable, using mock geopolitical news: %v", feedErr)
+		return n.getMockEverything(query.String()), nil
+	default:
+		return nil, fmt.Errorf("failed to fetch geopolitical news: %w", errors.Join(err, feedErr))
+	}
 }
 
 // GetNewsByKeywords fetches news articles by specific keywords
 func (n *NewsAPI) GetNewsByKeywords(keywords []string) (*NewsResponse, error) {
-	query := strings.Join(keywords, " AND ")
-	
 	params := EverythingParams{
-		Q:        query,
 		Language: "en",
 		SortBy:   "relevancy",
 		PageSize: 50,
 	}
 
-	return n.GetEverything(params)
+	return n.GetEverythingQuery(NewNewsQuery().Must(keywords...), params)
 }
 
 // GetNewsByCountry fetches top headlines for a specific country
@@ -223,40 +340,43 @@ func (n *NewsAPI) GetNewsByCategory(category string) (*NewsResponse, error) {
 	return n.GetTopHeadlines(params)
 }
 
-// makeNewsAPIRequest makes a request to NewsAPI using the custom fetcher
-func (n *NewsAPI) makeNewsAPIRequest(endpoint string, params map[string]string) ([]byte, error) {
-	// NewsAPI uses a different base URL structure, so we need to construct it manually
-	baseURL := "https://newsapi.org/v2"
-	fullURL := fmt.Sprintf("%s/%s", baseURL, endpoint)
-
-	// Add query parameters
-	if len(params) > 0 {
-		values := url.Values{}
-		for key, value := range params {
-			if value != "" {
-				values.Add(key, value)
-			}
-		}
-		fullURL += "?" + values.Encode()
-	}
-
-	// Use direct HTTP request since NewsAPI has a different structure
-	resp, err := n.fetcher.client.Get(fullURL)
+// makeNewsAPIRequest sends a GET request to NewsAPI through the Fetcher,
+// which adds the X-Api-Key header. Error responses are returned as
+// *NewsAPIError; ErrMissingAPIKey is returned unchanged when no key is set.
+func (n *NewsAPI) makeNewsAPIRequest(ctx context.Context, endpoint string, params map[string]string) ([]byte, error) {
+	data, err := n.fetcher.GetContext(ctx, "newsapi", endpoint, params)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %w", err)
+		return nil, newsAPIError(err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("NewsAPI returned status %d", resp.StatusCode)
+	// NewsAPI reports errors in the body, which is checked in case one
+	// arrives with a 200 status
+	var status newsAPIErrorBody
+	if json.Unmarshal(data, &status) == nil && status.Status == "error" {
+		return nil, &NewsAPIError{StatusCode: http.StatusOK, Code: status.Code, Message: status.Message}
 	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	return data, nil
+}
+
+// newsAPIError converts a failed Fetcher request into a *NewsAPIError when
+// NewsAPI responded, parsing its error body if it has one
+func newsAPIError(err error) error {
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return &NewsAPIError{StatusCode: http.StatusTooManyRequests, Code: "rateLimited", Message: rateLimitErr.Error(), err: err}
 	}
 
-	return data, nil
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+	apiErr := &NewsAPIError{StatusCode: statusErr.StatusCode, Code: "unexpectedError", err: err}
+	var body newsAPIErrorBody
+	if json.Unmarshal(statusErr.Body, &body) == nil && body.Status == "error" {
+		apiErr.Code, apiErr.Message = body.Code, body.Message
+	}
+	return apiErr
 }
 
 // Helper functions to build query parameters
@@ -343,6 +463,7 @@ func (n *NewsAPI) getMockTopHeadlines() *NewsResponse {
 	return &NewsResponse{
 		Status:       "ok",
 		TotalResults: 2,
+		FetchedAt:    time.Now(),
 		Articles: []NewsArticle{
 			{
 				Source: struct {
@@ -359,6 +480,7 @@ func (n *NewsAPI) getMockTopHeadlines() *NewsResponse {
 				URLToImage:  "https://reuters.com/mock-image-1.jpg",
 				PublishedAt: time.Now().Format(time.RFC3339),
 				Content:     "Israeli military sources confirm targeted strikes on Iranian facilities...",
+				Origin:      NewsOriginMock,
 			},
 			{
 				Source: struct {
@@ -375,6 +497,7 @@ func (n *NewsAPI) getMockTopHeadlines() *NewsResponse {
 				URLToImage:  "https://bbc.com/mock-image-2.jpg",
 				PublishedAt: time.Now().Add(-1 * time.Hour).Format(time.RFC3339),
 				Content:     "Russian aviation authorities announced new restrictions...",
+				Origin:      NewsOriginMock,
 			},
 		},
 	}
@@ -384,6 +507,7 @@ func (n *NewsAPI) getMockEverything(query string) *NewsResponse {
 	return &NewsResponse{
 		Status:       "ok",
 		TotalResults: 1,
+		FetchedAt:    time.Now(),
 		Articles: []NewsArticle{
 			{
 				Source: struct {
@@ -400,6 +524,7 @@ func (n *NewsAPI) getMockEverything(query string) *NewsResponse {
 				URLToImage:  "https://cnn.com/mock-image.jpg",
 				PublishedAt: time.Now().Format(time.RFC3339),
 				Content:     fmt.Sprintf("Recent reports indicate significant developments in %s...", query),
+				Origin:      NewsOriginMock,
 			},
 		},
 	}
@@ -407,7 +532,8 @@ func (n *NewsAPI) getMockEverything(query string) *NewsResponse {
 
 func (n *NewsAPI) getMockSources() *SourcesResponse {
 	return &SourcesResponse{
-		Status: "ok",
+		Status:    "ok",
+		FetchedAt: time.Now(),
 		Sources: []NewsSource{
 			{
 				ID:          "reuters",
@@ -0,0 +1,100 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// NewsAPI paging limits
+const (
+	// newsAPIMaxPageSize is the largest pageSize NewsAPI accepts
+	newsAPIMaxPageSize = 100
+	// newsAPIPageDelay spaces out page requests so a long fetch does not
+	// trip NewsAPI's rate limiting
+	newsAPIPageDelay = time.Second
+	// newsAPIMaxRetryWait bounds how long a rate limited page waits for its retry
+	newsAPIMaxRetryWait = 30 * time.Second
+)
+
+// GetEverythingAllPages fetches up to maxArticles articles matching params,
+// following pages until the results or the plan's page limit run out
+func (n *NewsAPI) GetEverythingAllPages(params EverythingParams, maxArticles int) (*NewsResponse, error) {
+	return n.GetEverythingAllPagesContext(context.Background(), params, maxArticles)
+}
+
+// GetEverythingAllPagesContext fetches up to maxArticles articles matching
+// params, aborting when ctx is done. Articles are deduplicated by URL. Pages
+// start at params.Page, or 1, and hold params.PageSize articles, or 100.
+// Reaching the plan's result limit ends the fetch without an error; a rate
+// limited page is retried once after the wait NewsAPI asks for.
+func (n *NewsAPI) GetEverythingAllPagesContext(ctx context.Context, params EverythingParams, maxArticles int) (*NewsResponse, error) {
+	if maxArticles <= 0 {
+		return nil, fmt.Errorf("invalid article limit %d: must be positive", maxArticles)
+	}
+	if params.PageSize <= 0 || params.PageSize > newsAPIMaxPageSize {
+		params.PageSize = newsAPIMaxPageSize
+	}
+	if params.Page <= 0 {
+		params.Page = 1
+	}
+
+	all := &NewsResponse{Status: "ok"}
+	seen := make(map[string]bool)
+	for first := true; len(all.Articles) < maxArticles; first = false {
+		if !first {
+			if err := sleepContext(ctx, newsAPIPageDelay); err != nil {
+				return nil, err
+			}
+		}
+
+		page, err := n.everythingPage(ctx, params)
+		var apiErr *NewsAPIError
+		if errors.As(err, &apiErr) && apiErr.Code == "maximumResultsReached" {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch page %d: %w", params.Page, err)
+		}
+
+		all.TotalResults = page.TotalResults
+		all.Stale = all.Stale || page.Stale
+		if all.FetchedAt.IsZero() || page.FetchedAt.Before(all.FetchedAt) {
+			all.FetchedAt = page.FetchedAt
+		}
+		added := 0
+		for _, article := range page.Articles {
+			if len(all.Articles) == maxArticles {
+				break
+			}
+			if article.URL != "" && seen[article.URL] {
+				continue
+			}
+			seen[article.URL] = true
+			all.Articles = append(all.Articles, article)
+			added++
+		}
+		// A short or repeated page means the results are exhausted
+		if added == 0 || len(page.Articles) < params.PageSize || params.Page*params.PageSize >= page.TotalResults {
+			break
+		}
+		params.Page++
+	}
+
+	return all, nil
+}
+
+// everythingPage fetches one page. The Fetcher already waits out short
+// Retry-After delays; longer ones, up to newsAPIMaxRetryWait, get one retry.
+func (n *NewsAPI) everythingPage(ctx context.Context, params EverythingParams) (*NewsResponse, error) {
+	page, err := n.GetEverythingContext(ctx, params)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter <= 0 || rateLimitErr.RetryAfter > newsAPIMaxRetryWait {
+		return page, err
+	}
+	if err := sleepContext(ctx, rateLimitErr.RetryAfter); err != nil {
+		return nil, err
+	}
+	return n.GetEverythingContext(ctx, params)
+}
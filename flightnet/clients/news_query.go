@@ -0,0 +1,220 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxNewsQueryLength is the longest q parameter NewsAPI accepts
+const maxNewsQueryLength = 500
+
+// ErrNewsQueryTooLong is returned when a query cannot be made to fit
+// NewsAPI's 500 character limit
+var ErrNewsQueryTooLong = errors.New("news query exceeds 500 characters")
+
+// NewsQuery builds a NewsAPI q parameter from groups of terms. Every Must
+// term has to appear, at least one Should term has to appear and no MustNot
+// term may appear. Terms that are not a single plain word are quoted as
+// exact phrases.
+type NewsQuery struct {
+	must    []string
+	should  []string
+	mustNot []string
+}
+
+// NewNewsQuery creates an empty query
+func NewNewsQuery() *NewsQuery {
+	return &NewsQuery{}
+}
+
+// Must adds terms that every article has to contain
+func (q *NewsQuery) Must(terms ...string) *NewsQuery {
+	q.must = appendNewsTerms(q.must, terms)
+	return q
+}
+
+// Should adds terms of which every article has to contain at least one
+func (q *NewsQuery) Should(terms ...string) *NewsQuery {
+	q.should = appendNewsTerms(q.should, terms)
+	return q
+}
+
+// MustNot adds terms that no article may contain
+func (q *NewsQuery) MustNot(terms ...string) *NewsQuery {
+	q.mustNot = appendNewsTerms(q.mustNot, terms)
+	return q
+}
+
+// String returns the q parameter without checking its length
+func (q *NewsQuery) String() string {
+	return renderNewsQuery(q.must, q.should, q.mustNot)
+}
+
+// Build returns the q parameter, failing when the query has no Must or
+// Should terms or is longer than NewsAPI allows
+func (q *NewsQuery) Build() (string, error) {
+	if len(q.must) == 0 && len(q.should) == 0 {
+		return "", errors.New("news query needs at least one Must or Should term")
+	}
+	query := q.String()
+	if len(query) > maxNewsQueryLength {
+		return "", fmt.Errorf("%w: %d characters", ErrNewsQueryTooLong, len(query))
+	}
+	return query, nil
+}
+
+// Split returns q parameters that each fit NewsAPI's limit and together
+// match the same articles, by spreading the Should terms over several
+// queries. Only a query whose Must and MustNot terms alone are too long
+// fails.
+func (q *NewsQuery) Split() ([]string, error) {
+	query, err := q.Build()
+	if err == nil {
+		return []string{query}, nil
+	}
+	if !errors.Is(err, ErrNewsQueryTooLong) || len(q.should) < 2 {
+		return nil, err
+	}
+
+	var queries []string
+	var group []string
+	for _, term := range q.should {
+		candidate := renderNewsQuery(q.must, append(group[:len(group):len(group)], term), q.mustNot)
+		if len(candidate) <= maxNewsQueryLength {
+			group = append(group, term)
+			continue
+		}
+		if len(group) == 0 {
+			return nil, fmt.Errorf("%w: term %q does not fit alongside the Must and MustNot terms", ErrNewsQueryTooLong, term)
+		}
+		queries = append(queries, renderNewsQuery(q.must, group, q.mustNot))
+		group = []string{term}
+		if single := renderNewsQuery(q.must, group, q.mustNot); len(single) > maxNewsQueryLength {
+			return nil, fmt.Errorf("%w: term %q does not fit alongside the Must and MustNot terms", ErrNewsQueryTooLong, term)
+		}
+	}
+	return append(queries, renderNewsQuery(q.must, group, q.mustNot)), nil
+}
+
+// appendNewsTerms adds the non-blank terms, collapsing inner whitespace
+func appendNewsTerms(dst, terms []string) []string {
+	for _, term := range terms {
+		if term = strings.Join(strings.Fields(term), " "); term != "" {
+			dst = append(dst, term)
+		}
+	}
+	return dst
+}
+
+// renderNewsQuery joins the groups with NewsAPI's boolean syntax, e.g.
+// `"north korea" AND (airspace OR closure) NOT missile`
+func renderNewsQuery(must, should, mustNot []string) string {
+	var parts []string
+	for _, term := range must {
+		parts = append(parts, quoteNewsTerm(term))
+	}
+	if len(should) > 0 {
+		quoted := make([]string, len(should))
+		for i, term := range should {
+			quoted[i] = quoteNewsTerm(term)
+		}
+		group := strings.Join(quoted, " OR ")
+		if len(should) > 1 && len(must)+len(mustNot) > 0 {
+			group = "(" + group + ")"
+		}
+		parts = append(parts, group)
+	}
+	query := strings.Join(parts, " AND ")
+	for _, term := range mustNot {
+		query += " NOT " + quoteNewsTerm(term)
+	}
+	return strings.TrimSpace(query)
+}
+
+// quoteNewsTerm returns a term as NewsAPI syntax. Plain words are used as
+// they are; anything else, including the operators AND, OR and NOT, becomes
+// an exact phrase. NewsAPI has no escape for double quotes, so they are
+// dropped.
+func quoteNewsTerm(term string) string {
+	term = strings.ReplaceAll(term, `"`, "")
+	plain := term != "" && strings.IndexFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) < 0
+	switch term {
+	case "AND", "OR", "NOT":
+		plain = false
+	}
+	if plain {
+		return term
+	}
+	return `"` + term + `"`
+}
+
+// GetEverythingQuery fetches articles matching query. Queries longer than
+// NewsAPI allows are split and their results merged.
+func (n *NewsAPI) GetEverythingQuery(query *NewsQuery, params EverythingParams) (*NewsResponse, error) {
+	return n.GetEverythingQueryContext(context.Background(), query, params)
+}
+
+// GetEverythingQueryContext fetches articles matching query, aborting when
+// ctx is done. params.Q is replaced by the query.
+func (n *NewsAPI) GetEverythingQueryContext(ctx context.Context, query *NewsQuery, params EverythingParams) (*NewsResponse, error) {
+	response, err := n.everythingQuery(ctx, query, params)
+	if errors.Is(err, ErrMissingAPIKey) {
+		return n.getMockEverything(query.String()), nil
+	}
+	return response, err
+}
+
+// everythingQuery fetches articles for each part of a split query without
+// falling back to mock data. Merged results are deduplicated by URL, newest
+// first when sorted by publishedAt, and trimmed to params.PageSize.
+func (n *NewsAPI) everythingQuery(ctx context.Context, query *NewsQuery, params EverythingParams) (*NewsResponse, error) {
+	queries, err := query.Split()
+	if err != nil {
+		return nil, err
+	}
+	if len(queries) == 1 {
+		params.Q = queries[0]
+		return n.everything(ctx, params)
+	}
+
+	var merged *NewsResponse
+	seen := make(map[string]bool)
+	for _, q := range queries {
+		params.Q = q
+		response, err := n.everything(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = &NewsResponse{Status: response.Status, FetchedAt: response.FetchedAt}
+		}
+		merged.TotalResults += response.TotalResults
+		merged.Stale = merged.Stale || response.Stale
+		if response.FetchedAt.Before(merged.FetchedAt) {
+			merged.FetchedAt = response.FetchedAt
+		}
+		for _, article := range response.Articles {
+			if article.URL != "" && seen[article.URL] {
+				continue
+			}
+			seen[article.URL] = true
+			merged.Articles = append(merged.Articles, article)
+		}
+	}
+
+	if params.SortBy == "publishedAt" {
+		sort.SliceStable(merged.Articles, func(i, j int) bool {
+			return merged.Articles[i].PublishedAt > merged.Articles[j].PublishedAt
+		})
+	}
+	if params.PageSize > 0 && len(merged.Articles) > params.PageSize {
+		merged.Articles = merged.Articles[:params.PageSize]
+	}
+	return merged, nil
+}
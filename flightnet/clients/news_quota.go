@@ -0,0 +1,301 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultNewsAPIDailyQuota is the daily request budget of NewsAPI's free plan
+const DefaultNewsAPIDailyQuota = 100
+
+// DefaultNewsMaxStaleness is how old cached results may be when they are
+// served because the daily quota is used up
+const DefaultNewsMaxStaleness = 6 * time.Hour
+
+// newsFreshTTL is how long results are reused without spending quota
+const newsFreshTTL = 5 * time.Minute
+
+// ErrNewsQuotaExhausted is matched by NewsQuotaError
+var ErrNewsQuotaExhausted = errors.New("NewsAPI daily quota exhausted")
+
+// NewsQuotaError is returned when the daily quota is used up and no cached
+// results are recent enough to serve instead
+type NewsQuotaError struct {
+	Limit    int
+	ResetAt  time.Time // Start of the next UTC day
+	CachedAt time.Time // When the cached results were fetched, zero when there are none
+}
+
+func (e *NewsQuotaError) Error() string {
+	if e.CachedAt.IsZero() {
+		return fmt.Sprintf("NewsAPI daily quota of %d requests exhausted until %s and no cached results",
+			e.Limit, e.ResetAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("NewsAPI daily quota of %d requests exhausted until %s and cached results from %s are too old",
+		e.Limit, e.ResetAt.Format(time.RFC3339), e.CachedAt.Format(time.RFC3339))
+}
+
+// Is makes errors.Is(err, ErrNewsQuotaExhausted) true
+func (e *NewsQuotaError) Is(target error) bool {
+	return target == ErrNewsQuotaExhausted
+}
+
+// newsQuota counts NewsAPI requests per UTC day, optionally persisting the
+// count to a state file
+type newsQuota struct {
+	mu    sync.Mutex
+	limit int // Negative for no limit
+	day   string
+	used  int
+	path  string // State file, empty to keep the count in memory only
+}
+
+// newsQuotaState is the state file's content
+type newsQuotaState struct {
+	Day  string `json:"day"` // UTC date, YYYY-MM-DD
+	Used int    `json:"used"`
+}
+
+// rollover starts a new count at the first request of a UTC day; the caller
+// holds q.mu
+func (q *newsQuota) rollover(now time.Time) {
+	if day := now.UTC().Format(time.DateOnly); day != q.day {
+		q.day, q.used = day, 0
+	}
+}
+
+// take spends one request, reporting false when none are left
+func (q *newsQuota) take(now time.Time) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover(now)
+	if q.limit >= 0 && q.used >= q.limit {
+		return false
+	}
+	q.used++
+	q.save()
+	return true
+}
+
+// refund gives back a request that never reached NewsAPI
+func (q *newsQuota) refund(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover(now)
+	if q.used > 0 {
+		q.used--
+		q.save()
+	}
+}
+
+// exhaust marks the day's quota used up, for when NewsAPI reports it is
+func (q *newsQuota) exhaust(now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover(now)
+	if q.limit >= 0 && q.used < q.limit {
+		q.used = q.limit
+		q.save()
+	}
+}
+
+// remaining returns the requests left today, -1 when there is no limit
+func (q *newsQuota) remaining(now time.Time) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.rollover(now)
+	if q.limit < 0 {
+		return -1
+	}
+	return max(q.limit-q.used, 0)
+}
+
+// save writes the count to the state file, replacing it atomically. A
+// failure is logged rather than failing the request. The caller holds q.mu.
+func (q *newsQuota) save() {
+	if q.path == "" {
+		return
+	}
+	data, err := json.Marshal(newsQuotaState{Day: q.day, Used: q.used})
+	if err == nil {
+		err = writeFileAtomic(q.path, data)
+	}
+	if err != nil {
+		log.Printf("Failed to save NewsAPI quota to %s: %v", q.path, err)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// newsCacheEntry is a NewsAPI response body and when it was fetched
+type newsCacheEntry struct {
+	body      []byte
+	fetchedAt time.Time
+}
+
+// newsResult is a NewsAPI response body, possibly served from the cache
+type newsResult struct {
+	body      []byte
+	fetchedAt time.Time
+	stale     bool // Older than newsFreshTTL, served because no request could be made
+}
+
+// SetDailyQuota sets how many requests are sent to NewsAPI per UTC day. Zero
+// restores DefaultNewsAPIDailyQuota and a negative limit removes the limit.
+func (n *NewsAPI) SetDailyQuota(limit int) {
+	if limit == 0 {
+		limit = DefaultNewsAPIDailyQuota
+	}
+	n.quota.mu.Lock()
+	n.quota.limit = limit
+	n.quota.mu.Unlock()
+}
+
+// SetQuotaFile persists the daily request count to a file at path, loading
+// the count already recorded there so restarts do not reset it. An empty
+// path keeps the count in memory only.
+func (n *NewsAPI) SetQuotaFile(path string) error {
+	q := n.quota
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.path = path
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read NewsAPI quota file: %w", err)
+	}
+	var state newsQuotaState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("failed to parse NewsAPI quota file %s: %w", path, err)
+	}
+	// A count from an earlier day is dropped by rollover on the next request
+	if state.Day >= q.day {
+		q.day, q.used = state.Day, state.Used
+	}
+	return nil
+}
+
+// SetMaxStaleness sets how old cached results may be when served after the
+// daily quota is used up. Zero restores DefaultNewsMaxStaleness.
+func (n *NewsAPI) SetMaxStaleness(maxStale time.Duration) {
+	if maxStale <= 0 {
+		maxStale = DefaultNewsMaxStaleness
+	}
+	n.cacheMu.Lock()
+	n.maxStale = maxStale
+	n.cacheMu.Unlock()
+}
+
+// RemainingQuota returns how many NewsAPI requests are left today, or -1
+// when the quota is unlimited
+func (n *NewsAPI) RemainingQuota() int {
+	return n.quota.remaining(time.Now())
+}
+
+// quotaRequest serves a NewsAPI request from the cache while it is fresh and
+// otherwise spends one request of the daily quota on it. Once the quota is
+// used up, cached results up to the maximum staleness are served instead.
+func (n *NewsAPI) quotaRequest(ctx context.Context, endpoint string, params map[string]string) (*newsResult, error) {
+	key := cacheKey("newsapi", endpoint, params)
+	now := time.Now()
+	cached, hasCached := n.cachedNews(key)
+	if hasCached && now.Sub(cached.fetchedAt) < newsFreshTTL {
+		return &newsResult{body: cached.body, fetchedAt: cached.fetchedAt}, nil
+	}
+
+	if !n.quota.take(now) {
+		return n.staleNews(key, now)
+	}
+	data, err := n.makeNewsAPIRequest(ctx, endpoint, params)
+	if errors.Is(err, ErrMissingAPIKey) {
+		n.quota.refund(now)
+		return nil, err
+	}
+	if errors.Is(err, ErrRateLimited) {
+		// NewsAPI counts requests itself, so without a short Retry-After
+		// its daily limit is trusted over the local count
+		var rateLimitErr *RateLimitError
+		if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter == 0 {
+			n.quota.exhaust(now)
+		}
+		if result, staleErr := n.staleNews(key, now); staleErr == nil {
+			log.Printf("NewsAPI rate limited, serving cached %s results from %s", endpoint, result.fetchedAt.Format(time.RFC3339))
+			return result, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	n.cacheNews(key, data, now)
+	return &newsResult{body: data, fetchedAt: now}, nil
+}
+
+// staleNews returns the cached results for key if they are within the
+// maximum staleness, or a *NewsQuotaError
+func (n *NewsAPI) staleNews(key string, now time.Time) (*newsResult, error) {
+	cached, ok := n.cachedNews(key)
+	n.cacheMu.Lock()
+	maxStale := n.maxStale
+	n.cacheMu.Unlock()
+	if ok && now.Sub(cached.fetchedAt) <= maxStale {
+		return &newsResult{body: cached.body, fetchedAt: cached.fetchedAt, stale: true}, nil
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	n.quota.mu.Lock()
+	limit := n.quota.limit
+	n.quota.mu.Unlock()
+	return nil, &NewsQuotaError{Limit: limit, ResetAt: day.Add(24 * time.Hour), CachedAt: cached.fetchedAt}
+}
+
+// cachedNews returns the cached response for key
+func (n *NewsAPI) cachedNews(key string) (newsCacheEntry, bool) {
+	n.cacheMu.Lock()
+	defer n.cacheMu.Unlock()
+	entry, ok := n.cache[key]
+	return entry, ok
+}
+
+// cacheNews stores a response, dropping entries too old to ever be served
+func (n *NewsAPI) cacheNews(key string, body []byte, now time.Time) {
+	n.cacheMu.Lock()
+	defer n.cacheMu.Unlock()
+	for k, entry := range n.cache {
+		if now.Sub(entry.fetchedAt) > n.maxStale {
+			delete(n.cache, k)
+		}
+	}
+	n.cache[key] = newsCacheEntry{body: body, fetchedAt: now}
+}
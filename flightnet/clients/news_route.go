@@ -0,0 +1,106 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Route news tuning
+const (
+	// maxRouteNewsCountries bounds the countries queried per route, since
+	// each one spends a NewsAPI request
+	maxRouteNewsCountries = 8
+	// routeNewsPageSize is how many articles are fetched per country
+	routeNewsPageSize = 10
+)
+
+// routeNewsTerms are the airspace and security topics searched for each
+// country along a route
+var routeNewsTerms = []string{"airspace", "aviation", "flights", "airlines", "security", "military", "conflict"}
+
+// GetNewsForRoute returns airspace and security news for the countries a
+// route touches, keyed by ISO 3166-1 alpha-2 code: the origin's and
+// destination's countries and those overflown on the great-circle path
+func (n *NewsAPI) GetNewsForRoute(originIATA, destIATA string) (map[string]*NewsResponse, error) {
+	return n.GetNewsForRouteContext(context.Background(), originIATA, destIATA)
+}
+
+// GetNewsForRouteContext returns airspace and security news for the
+// countries a route touches, aborting when ctx is done. Overflown countries
+// are approximated by the country of the nearest airport in the embedded
+// airport dataset. Countries whose news cannot be fetched are left out; it
+// fails only when no country's news could be fetched.
+func (n *NewsAPI) GetNewsForRouteContext(ctx context.Context, originIATA, destIATA string) (map[string]*NewsResponse, error) {
+	countries, err := n.routeCountries(ctx, originIATA, destIATA)
+	if err != nil {
+		return nil, err
+	}
+
+	params := EverythingParams{
+		Language: "en",
+		SortBy:   "publishedAt",
+		PageSize: routeNewsPageSize,
+	}
+	news := make(map[string]*NewsResponse, len(countries))
+	var errs []error
+	for _, country := range countries {
+		query := NewNewsQuery().Must(country.name).Should(routeNewsTerms...)
+		response, err := n.everythingQuery(ctx, query, params)
+		if errors.Is(err, ErrMissingAPIKey) {
+			response, err = n.getMockEverything(query.String()), nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err != nil {
+			log.Printf("Route news %s-%s: news unavailable for %s: %v", originIATA, destIATA, country.iso2, err)
+			errs = append(errs, fmt.Errorf("%s: %w", country.iso2, err))
+			continue
+		}
+		news[country.iso2] = response
+	}
+	if len(news) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("failed to fetch route news: %w", errors.Join(errs...))
+	}
+	return news, nil
+}
+
+// routeCountries returns the countries a route touches in path order,
+// keeping the origin's and destination's countries when there are more than
+// maxRouteNewsCountries
+func (n *NewsAPI) routeCountries(ctx context.Context, originIATA, destIATA string) ([]*countryRecord, error) {
+	origin, err := n.airports.GetAirportContext(ctx, originIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid origin: %w", err)
+	}
+	dest, err := n.airports.GetAirportContext(ctx, destIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+	segments, err := routeSegments(origin, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	first, last := segments[0].country, segments[len(segments)-1].country
+	countries := []*countryRecord{first}
+	seen := map[string]bool{first.iso2: true, last.iso2: true}
+	for _, segment := range segments {
+		country := segment.country
+		if country == nil || seen[country.iso2] {
+			continue
+		}
+		seen[country.iso2] = true
+		if len(countries) == maxRouteNewsCountries-1 {
+			log.Printf("Route news %s-%s: more than %d countries, skipping %s", origin.IATA, dest.IATA, maxRouteNewsCountries, country.iso2)
+			continue
+		}
+		countries = append(countries, country)
+	}
+	if last != first {
+		countries = append(countries, last)
+	}
+	return countries, nil
+}
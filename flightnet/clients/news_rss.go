@@ -0,0 +1,275 @@
+package clients
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// News origins, recorded on each article
+const (
+	NewsOriginNewsAPI = "newsapi"
+	NewsOriginRSS     = "rss"
+	NewsOriginMock    = "mock"
+)
+
+// NewsFeed is an RSS or Atom feed read when NewsAPI is unavailable
+type NewsFeed struct {
+	Name string // Used as the articles' source name
+	URL  string
+}
+
+// DefaultNewsFeeds are the world news and aviation feeds used unless
+// SetFeeds is called
+var DefaultNewsFeeds = []NewsFeed{
+	{Name: "BBC News", URL: "https://feeds.bbci.co.uk/news/world/rss.xml"},
+	{Name: "Al Jazeera", URL: "https://www.aljazeera.com/xml/rss/all.xml"},
+	{Name: "The Guardian", URL: "https://www.theguardian.com/world/rss"},
+	{Name: "The New York Times", URL: "https://rss.nytimes.com/services/xml/rss/nyt/World.xml"},
+	{Name: "Simple Flying", URL: "https://simpleflying.com/feed/"},
+}
+
+// maxGeopoliticalArticles is how many articles GetGeopoliticalNews returns
+const maxGeopoliticalArticles = 20
+
+// htmlTag matches markup in feed descriptions
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// rssDocument holds the items of an RSS 2.0, RSS 1.0 or Atom feed
+type rssDocument struct {
+	XMLName xml.Name
+	Channel struct {
+		Items []rssItem `xml:"item"`
+	} `xml:"channel"`
+	Items   []rssItem   `xml:"item"` // RSS 1.0 places items outside the channel
+	Entries []atomEntry `xml:"entry"`
+}
+
+// rssItem is an RSS item
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	PubDate     string `xml:"pubDate"`
+	Date        string `xml:"date"` // Dublin Core, used by RSS 1.0
+	Author      string `xml:"author"`
+	Creator     string `xml:"creator"`
+}
+
+// atomEntry is an Atom entry
+type atomEntry struct {
+	Title string `xml:"title"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Summary   string `xml:"summary"`
+	Content   string `xml:"content"`
+	Published string `xml:"published"`
+	Updated   string `xml:"updated"`
+	Author    struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+// SetFeeds replaces the feeds read when NewsAPI is unavailable. Each feed is
+// registered with the Fetcher as API "rss:<name>" so it is cached and retried.
+func (n *NewsAPI) SetFeeds(feeds []NewsFeed) error {
+	for _, feed := range feeds {
+		if err := n.registerFeed(feed); err != nil {
+			return err
+		}
+	}
+	n.feedsMu.Lock()
+	n.feeds = append([]NewsFeed(nil), feeds...)
+	n.feedsMu.Unlock()
+	return nil
+}
+
+// registerFeed adds a feed's host to the Fetcher
+func (n *NewsAPI) registerFeed(feed NewsFeed) error {
+	if feed.Name == "" {
+		return fmt.Errorf("feed %s has no name", feed.URL)
+	}
+	u, err := url.Parse(feed.URL)
+	if err != nil {
+		return fmt.Errorf("invalid URL for feed %s: %w", feed.Name, err)
+	}
+	return n.fetcher.RegisterAPI(feedAPIName(feed), APIConfig{
+		BaseURL: u.Scheme + "://" + u.Host,
+		Headers: map[string]string{
+			"Accept": "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8",
+		},
+		Timeout:  10 * time.Second,
+		CacheTTL: 15 * time.Minute, // Feeds update every few minutes at most
+	}, true)
+}
+
+// feedAPIName is the Fetcher API name of a feed
+func feedAPIName(feed NewsFeed) string {
+	return "rss:" + feed.Name
+}
+
+// GetFeedNews reads every configured feed and returns the articles that
+// mention any of terms, newest first. An empty terms list returns every
+// article. It fails only when no feed could be read.
+func (n *NewsAPI) GetFeedNews(terms []string) (*NewsResponse, error) {
+	return n.GetFeedNewsContext(context.Background(), terms)
+}
+
+// GetFeedNewsContext reads every configured feed, aborting when ctx is done
+func (n *NewsAPI) GetFeedNewsContext(ctx context.Context, terms []string) (*NewsResponse, error) {
+	n.feedsMu.Lock()
+	feeds := n.feeds
+	n.feedsMu.Unlock()
+	if len(feeds) == 0 {
+		return nil, errors.New("no news feeds configured")
+	}
+
+	normalized := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if t := strings.TrimSpace(normalizeNewsText(term)); t != "" {
+			normalized = append(normalized, t)
+		}
+	}
+
+	results := make([][]NewsArticle, len(feeds))
+	errs := make([]error, len(feeds))
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = n.readFeed(ctx, feed)
+		}()
+	}
+	wg.Wait()
+
+	response := &NewsResponse{Status: "ok", FetchedAt: time.Now()}
+	seen := make(map[string]bool)
+	read := 0
+	for i, articles := range results {
+		if errs[i] != nil {
+			log.Printf("News feed %s unavailable: %v", feeds[i].Name, errs[i])
+			continue
+		}
+		read++
+		for _, article := range articles {
+			if seen[article.URL] {
+				continue
+			}
+			text := normalizeNewsText(article.Title + " " + article.Description)
+			if len(normalized) > 0 && !containsTerm(text, normalized, false) {
+				continue
+			}
+			seen[article.URL] = true
+			response.Articles = append(response.Articles, article)
+		}
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("all news feeds failed: %w", errors.Join(errs...))
+	}
+
+	// RFC 3339 UTC timestamps sort chronologically as strings
+	sort.SliceStable(response.Articles, func(i, j int) bool {
+		return response.Articles[i].PublishedAt > response.Articles[j].PublishedAt
+	})
+	response.TotalResults = len(response.Articles)
+	return response, nil
+}
+
+// readFeed fetches and parses one feed
+func (n *NewsAPI) readFeed(ctx context.Context, feed NewsFeed) ([]NewsArticle, error) {
+	u, err := url.Parse(feed.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed URL: %w", err)
+	}
+	data, err := n.fetcher.GetContext(ctx, feedAPIName(feed), strings.TrimPrefix(u.RequestURI(), "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseFeed(data, feed.Name)
+}
+
+// parseFeed converts an RSS or Atom document into articles from source
+func parseFeed(data []byte, source string) ([]NewsArticle, error) {
+	var doc rssDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse feed: %w", err)
+	}
+	switch doc.XMLName.Local {
+	case "rss", "RDF", "feed":
+	default:
+		return nil, fmt.Errorf("not an RSS or Atom feed: root element %q", doc.XMLName.Local)
+	}
+
+	var articles []NewsArticle
+	for _, item := range append(doc.Channel.Items, doc.Items...) {
+		article := newFeedArticle(source, item.Title, strings.TrimSpace(item.Link), item.Description, firstNonEmpty(item.PubDate, item.Date))
+		article.Author = strings.TrimSpace(firstNonEmpty(item.Creator, item.Author))
+		articles = append(articles, article)
+	}
+	for _, entry := range doc.Entries {
+		link := ""
+		for _, l := range entry.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+				break
+			}
+		}
+		article := newFeedArticle(source, entry.Title, link, firstNonEmpty(entry.Summary, entry.Content), firstNonEmpty(entry.Published, entry.Updated))
+		article.Author = strings.TrimSpace(entry.Author.Name)
+		articles = append(articles, article)
+	}
+	return articles, nil
+}
+
+// newFeedArticle builds an article from feed fields, stripping markup and
+// normalizing the date to RFC 3339
+func newFeedArticle(source, title, link, description, published string) NewsArticle {
+	article := NewsArticle{
+		Title:       plainText(title),
+		Description: plainText(description),
+		URL:         link,
+		PublishedAt: feedTime(published),
+		Origin:      NewsOriginRSS,
+	}
+	article.Source.Name = source
+	return article
+}
+
+// plainText strips HTML markup and entities and collapses whitespace
+func plainText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTag.ReplaceAllString(s, " "))), " ")
+}
+
+// feedTime converts an RSS or Atom date to RFC 3339 in UTC, returning it
+// unchanged when it cannot be parsed
+func feedTime(s string) string {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2 Jan 2006 15:04:05 -0700"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC().Format(time.RFC3339)
+		}
+	}
+	return s
+}
+
+// firstNonEmpty returns the first of values that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// News watch tuning
+const (
+	// newsWatchOverlap is how far before the last successful poll each poll
+	// searches, so articles NewsAPI indexes late are not missed
+	newsWatchOverlap = 15 * time.Minute
+	// newsWatchWindow is how long emitted article URLs are remembered
+	newsWatchWindow = 24 * time.Hour
+	// newsWatchPageSize is how many articles each poll asks for
+	newsWatchPageSize = 100
+)
+
+// WatchGeopolitical polls for geopolitical news about countries every
+// interval and sends each article published since the previous successful
+// poll once, oldest first. The first poll covers the preceding interval.
+// Failed polls are logged and retried with exponential backoff. The channel
+// is closed once ctx is done.
+//
+// Every poll spends NewsAPI quota: a 15 minute interval uses 96 of the free
+// plan's 100 daily requests.
+func (n *NewsAPI) WatchGeopolitical(ctx context.Context, countries []string, interval time.Duration) (<-chan NewsArticle, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid watch interval %v: must be positive", interval)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	articles := make(chan NewsArticle, 64)
+	go n.watchGeopolitical(ctx, append([]string(nil), countries...), interval, articles)
+	return articles, nil
+}
+
+// watchGeopolitical runs the polling loop for WatchGeopolitical
+func (n *NewsAPI) watchGeopolitical(ctx context.Context, countries []string, interval time.Duration, articles chan<- NewsArticle) {
+	defer close(articles)
+
+	maxDelay := maxWatchBackoff
+	if interval > maxDelay {
+		maxDelay = interval
+	}
+	policy := RetryPolicy{BaseDelay: interval, MaxDelay: maxDelay}
+
+	seen := make(map[string]time.Time) // Article URL to when it was emitted
+	lastPoll := time.Now().Add(-interval)
+	failures := 0
+	for {
+		started := time.Now()
+		response, err := n.geopoliticalNews(ctx, countries, lastPoll.Add(-newsWatchOverlap), newsWatchPageSize)
+		if ctx.Err() != nil {
+			return
+		}
+
+		delay := interval
+		if err != nil {
+			failures++
+			delay = policy.backoff(failures + 1)
+			log.Printf("Geopolitical news watch poll failed (%d in a row), next poll in %v: %v", failures, delay, err)
+		} else {
+			failures = 0
+			lastPoll = started
+			for _, article := range freshArticles(response.Articles, seen, started) {
+				select {
+				case articles <- article:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// freshArticles returns the articles whose URLs are not in seen, oldest
+// first, and records them. URLs older than newsWatchWindow are forgotten.
+func freshArticles(articles []NewsArticle, seen map[string]time.Time, now time.Time) []NewsArticle {
+	for url, at := range seen {
+		if now.Sub(at) > newsWatchWindow {
+			delete(seen, url)
+		}
+	}
+
+	var fresh []NewsArticle
+	for _, article := range articles {
+		if article.URL == "" {
+			continue
+		}
+		if _, ok := seen[article.URL]; ok {
+			continue
+		}
+		seen[article.URL] = now
+		fresh = append(fresh, article)
+	}
+	sort.SliceStable(fresh, func(i, j int) bool {
+		return fresh[i].PublishedAt < fresh[j].PublishedAt
+	})
+	return fresh
+}
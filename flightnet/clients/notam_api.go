@@ -0,0 +1,152 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NOTAM is a notice to air missions affecting an airport or airspace
+type NOTAM struct {
+	ID            string    `json:"id"`
+	Number        string    `json:"number"`
+	FIR           string    `json:"fir"`
+	Location      string    `json:"location"` // ICAO location the NOTAM is filed against
+	EffectiveFrom time.Time `json:"effective_from"`
+	EffectiveTo   time.Time `json:"effective_to"` // Zero for permanent NOTAMs
+	Text          string    `json:"text"`
+	QCode         string    `json:"qcode"` // e.g. QRTCA, temporary restricted area activated
+}
+
+// ActiveAt reports whether the NOTAM is in effect at t
+func (n NOTAM) ActiveAt(t time.Time) bool {
+	if !n.EffectiveFrom.IsZero() && t.Before(n.EffectiveFrom) {
+		return false
+	}
+	return n.EffectiveTo.IsZero() || t.Before(n.EffectiveTo)
+}
+
+// IsClosure reports whether the NOTAM closes or restricts airspace or an
+// aerodrome, based on its Q-code and text
+func (n NOTAM) IsClosure() bool {
+	q := strings.ToUpper(n.QCode)
+	switch {
+	case strings.HasPrefix(q, "QR"):
+		// Restricted, prohibited and danger areas
+		return true
+	case strings.HasPrefix(q, "QFA") && strings.HasSuffix(q, "LC"),
+		strings.HasPrefix(q, "QA") && strings.HasSuffix(q, "LC"):
+		// Aerodrome or airspace closed
+		return true
+	}
+	text := strings.ToUpper(n.Text)
+	return strings.Contains(text, "AIRSPACE CLSD") || strings.Contains(text, "AD CLSD")
+}
+
+// maxNOTAMPages bounds the pages fetched for one query
+const maxNOTAMPages = 10
+
+// NOTAMAPI handles NOTAMs from the FAA NOTAM API
+type NOTAMAPI struct {
+	fetcher *Fetcher
+	parser  *Parser
+}
+
+// NewNOTAMAPI creates a new NOTAMAPI instance
+func NewNOTAMAPI() *NOTAMAPI {
+	return NewNOTAMAPIWithFetcher(NewFetcher())
+}
+
+// NewNOTAMAPIWithFetcher creates a NOTAMAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewNOTAMAPIWithFetcher(fetcher *Fetcher) *NOTAMAPI {
+	return &NOTAMAPI{
+		fetcher: fetcher,
+		parser:  NewParser(),
+	}
+}
+
+// GetNOTAMsForAirports fetches the NOTAMs for airports or FIRs by ICAO code
+func (n *NOTAMAPI) GetNOTAMsForAirports(codes []string) ([]NOTAM, error) {
+	return n.GetNOTAMsForAirportsContext(context.Background(), codes)
+}
+
+// GetNOTAMsForAirportsContext fetches the NOTAMs for airports or FIRs by ICAO
+// code, aborting when ctx is done. Locations that fail are logged and skipped
+// unless none succeed.
+func (n *NOTAMAPI) GetNOTAMsForAirportsContext(ctx context.Context, codes []string) ([]NOTAM, error) {
+	var notams []NOTAM
+	var lastErr error
+	for _, code := range codes {
+		found, err := n.query(ctx, map[string]string{"icaoLocation": strings.ToUpper(code)})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Printf("Error fetching NOTAMs for %s: %v", code, err)
+			lastErr = err
+			continue
+		}
+		notams = append(notams, found...)
+	}
+
+	if len(notams) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("failed to fetch NOTAMs: %w", lastErr)
+	}
+	return notams, nil
+}
+
+// GetAirspaceClosures fetches the airspace NOTAMs currently in effect that
+// close or restrict airspace
+func (n *NOTAMAPI) GetAirspaceClosures() ([]NOTAM, error) {
+	return n.GetAirspaceClosuresContext(context.Background())
+}
+
+// GetAirspaceClosuresContext fetches the airspace NOTAMs currently in effect
+// that close or restrict airspace, aborting when ctx is done
+func (n *NOTAMAPI) GetAirspaceClosuresContext(ctx context.Context) ([]NOTAM, error) {
+	notams, err := n.query(ctx, map[string]string{"featureType": "AIRSPACE"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch airspace NOTAMs: %w", err)
+	}
+
+	now := time.Now()
+	var closures []NOTAM
+	for _, notam := range notams {
+		if notam.ActiveAt(now) && notam.IsClosure() {
+			closures = append(closures, notam)
+		}
+	}
+	return closures, nil
+}
+
+// query fetches every page of NOTAMs matching params
+func (n *NOTAMAPI) query(ctx context.Context, params map[string]string) ([]NOTAM, error) {
+	var notams []NOTAM
+	for page := 1; ; page++ {
+		pageParams := map[string]string{
+			"responseFormat": "geoJson",
+			"pageSize":       "1000",
+			"pageNum":        strconv.Itoa(page),
+		}
+		for k, v := range params {
+			pageParams[k] = v
+		}
+
+		data, err := n.fetcher.GetContext(ctx, "faa-notam", "notams", pageParams)
+		if err != nil {
+			return nil, err
+		}
+		found, totalPages, err := n.parser.ParseNOTAMResponse(data)
+		if err != nil {
+			return nil, err
+		}
+		notams = append(notams, found...)
+		if page >= totalPages || page >= maxNOTAMPages {
+			return notams, nil
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/url"
+)
+
+// FetcherOption configures the HTTP client used by a Fetcher
+type FetcherOption func(*fetcherOptions)
+
+// fetcherOptions collects options before the transport is built
+type fetcherOptions struct {
+	client          *http.Client
+	transport       http.RoundTripper
+	proxyURL        *url.URL
+	tlsConfig       *tls.Config
+	maxConnsPerHost int
+	maxIdlePerHost  int
+}
+
+// WithHTTPClient makes the Fetcher use client, including its transport and
+// default timeout. Transport options are ignored when a client is given.
+func WithHTTPClient(client *http.Client) FetcherOption {
+	return func(o *fetcherOptions) {
+		o.client = client
+	}
+}
+
+// WithTransport replaces the default transport. Proxy, TLS and connection
+// pool options only apply when the transport is an *http.Transport.
+func WithTransport(transport http.RoundTripper) FetcherOption {
+	return func(o *fetcherOptions) {
+		o.transport = transport
+	}
+}
+
+// WithProxyURL routes all requests through the given proxy instead of the
+// one configured by the HTTP_PROXY/HTTPS_PROXY environment variables
+func WithProxyURL(proxyURL *url.URL) FetcherOption {
+	return func(o *fetcherOptions) {
+		o.proxyURL = proxyURL
+	}
+}
+
+// WithTLSConfig sets the TLS configuration, e.g. to trust a corporate root CA
+func WithTLSConfig(config *tls.Config) FetcherOption {
+	return func(o *fetcherOptions) {
+		o.tlsConfig = config
+	}
+}
+
+// WithConnectionLimits sets the maximum total and idle connections per host.
+// Zero leaves the corresponding transport default in place.
+func WithConnectionLimits(maxConnsPerHost, maxIdleConnsPerHost int) FetcherOption {
+	return func(o *fetcherOptions) {
+		o.maxConnsPerHost = maxConnsPerHost
+		o.maxIdlePerHost = maxIdleConnsPerHost
+	}
+}
+
+// NewFetcherWithClient creates a Fetcher that sends all requests with client
+func NewFetcherWithClient(client *http.Client) *Fetcher {
+	return NewFetcher(WithHTTPClient(client))
+}
+
+// buildClient returns the shared HTTP client described by opts
+func buildClient(opts []FetcherOption) *http.Client {
+	var o fetcherOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.client != nil {
+		return o.client
+	}
+
+	transport := o.transport
+	if transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if t, ok := transport.(*http.Transport); ok {
+		// Work on a copy so a caller's transport is never modified
+		t = t.Clone()
+		transport = t
+		if o.proxyURL != nil {
+			t.Proxy = http.ProxyURL(o.proxyURL)
+		}
+		if o.tlsConfig != nil {
+			t.TLSClientConfig = o.tlsConfig
+		}
+		if o.maxConnsPerHost > 0 {
+			t.MaxConnsPerHost = o.maxConnsPerHost
+		}
+		if o.maxIdlePerHost > 0 {
+			t.MaxIdleConnsPerHost = o.maxIdlePerHost
+		}
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	}
+}
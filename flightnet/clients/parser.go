@@ -1,9 +1,15 @@
 package clients
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Parser handles response parsing
@@ -19,116 +25,189 @@ func (p *Parser) ParseJSON(data []byte, v interface{}) error {
 	return json.Unmarshal(data, v)
 }
 
+// ParseJSONStrict parses JSON data into a struct, failing on fields the
+// struct does not declare and on records that fail validation
+func (p *Parser) ParseJSONStrict(data []byte, v interface{}) error {
+	return decodeStrict(data, v)
+}
+
 // ParseAircraftResponse handles special case for aircraft responses
-func (p *Parser) ParseAircraftResponse(data []byte) ([]Aircraft, error) {
+func (p *Parser) ParseAircraftResponse(data []byte, opts ...ParseOption) ([]Aircraft, error) {
 	log.Println("Parsing aircraft response, length:", len(data))
+	return p.ParseAircraftStream(bytes.NewReader(data), 0, opts...)
+}
 
-	// Try parsing as array
-	var aircraftList []Aircraft
-	err1 := json.Unmarshal(data, &aircraftList)
-	if err1 == nil {
-		log.Printf("Successfully parsed as array with %d items\n", len(aircraftList))
-		return aircraftList, nil
-	}
-	log.Println("Error parsing as array:", err1)
-
-	// Try parsing as single object
-	var singleAircraft Aircraft
-	err2 := json.Unmarshal(data, &singleAircraft)
-	if err2 == nil {
-		log.Println("Successfully parsed as single object")
-		return []Aircraft{singleAircraft}, nil
-	}
-	log.Println("Error parsing as single object:", err2)
-
-	// For large responses, try to extract a smaller subset
-	if len(data) > 1000000 {
-		log.Println("Response is very large, trying to extract first item from array")
-		// Try to extract the first item from the array
-		if len(data) > 0 && data[0] == '[' {
-			// Find the end of the first object
-			depth := 0
-			for i := 1; i < len(data); i++ {
-				if data[i] == '{' {
-					depth++
-				} else if data[i] == '}' {
-					depth--
-					if depth == 0 && i+1 < len(data) && data[i+1] == ',' {
-						// Found the end of the first object
-						firstObject := append([]byte{'['}, data[1:i+1]...)
-						firstObject = append(firstObject, ']')
-
-						var firstItem []Aircraft
-						if err := json.Unmarshal(firstObject, &firstItem); err == nil {
-							log.Println("Successfully parsed first item from array")
-							return firstItem, nil
-						} else {
-							log.Println("Error parsing first item:", err)
-						}
-						break
-					}
-				}
-			}
-		}
+// ParseAircraftStream decodes up to limit aircraft from an array or single
+// object response, reading no further than needed. A limit of 0 decodes all.
+func (p *Parser) ParseAircraftStream(r io.Reader, limit int, opts ...ParseOption) ([]Aircraft, error) {
+	aircraftList, err := decodeArrayStream[Aircraft](r, limit, newParseOptions(opts))
+	if err != nil {
+		return nil, parseFailure("aircraft", err)
 	}
+	log.Printf("Successfully parsed %d aircraft\n", len(aircraftList))
+	return aircraftList, nil
+}
 
-	return nil, fmt.Errorf("failed to parse aircraft response as array or object: %v, %v", err1, err2)
+// EachAircraft decodes an array or single object aircraft response one
+// record at a time, calling fn for each without keeping them in memory.
+// Decoding stops early when fn returns false.
+func (p *Parser) EachAircraft(r io.Reader, fn func(Aircraft) bool, opts ...ParseOption) error {
+	if err := streamArray(r, newParseOptions(opts), fn); err != nil {
+		return parseFailure("aircraft", err)
+	}
+	return nil
 }
 
 // ParseFlightResponse handles special case for flight responses
-func (p *Parser) ParseFlightResponse(data []byte) ([]Flight, error) {
+func (p *Parser) ParseFlightResponse(data []byte, opts ...ParseOption) ([]Flight, error) {
 	log.Println("Parsing flight response, length:", len(data))
+	return p.ParseFlightStream(bytes.NewReader(data), 0, opts...)
+}
 
-	// Try parsing as array
-	var flightList []Flight
-	err1 := json.Unmarshal(data, &flightList)
-	if err1 == nil {
-		log.Printf("Successfully parsed as array with %d items\n", len(flightList))
-		return flightList, nil
-	}
-	log.Println("Error parsing as array:", err1)
-
-	// Try parsing as single object
-	var singleFlight Flight
-	err2 := json.Unmarshal(data, &singleFlight)
-	if err2 == nil {
-		log.Println("Successfully parsed as single object")
-		return []Flight{singleFlight}, nil
-	}
-	log.Println("Error parsing as single object:", err2)
-
-	// For large responses, try to extract a smaller subset
-	if len(data) > 1000000 {
-		log.Println("Response is very large, trying to extract first item from array")
-		// Try to extract the first item from the array
-		if len(data) > 0 && data[0] == '[' {
-			// Find the end of the first object
-			depth := 0
-			for i := 1; i < len(data); i++ {
-				if data[i] == '{' {
-					depth++
-				} else if data[i] == '}' {
-					depth--
-					if depth == 0 && i+1 < len(data) && data[i+1] == ',' {
-						// Found the end of the first object
-						firstObject := append([]byte{'['}, data[1:i+1]...)
-						firstObject = append(firstObject, ']')
-
-						var firstItem []Flight
-						if err := json.Unmarshal(firstObject, &firstItem); err == nil {
-							log.Println("Successfully parsed first item from array")
-							return firstItem, nil
-						} else {
-							log.Println("Error parsing first item:", err)
-						}
-						break
-					}
-				}
-			}
+// ParseFlightStream decodes up to limit flights from an array or single
+// object response, reading no further than needed. A limit of 0 decodes all.
+func (p *Parser) ParseFlightStream(r io.Reader, limit int, opts ...ParseOption) ([]Flight, error) {
+	flightList, err := decodeArrayStream[Flight](r, limit, newParseOptions(opts))
+	if err != nil {
+		return nil, parseFailure("flight", err)
+	}
+	log.Printf("Successfully parsed %d flights\n", len(flightList))
+	return flightList, nil
+}
+
+// UpstreamError is an error the API reported in a response body, such as
+// aviation-edge's {"error":{"code":...,"text":...}} envelope
+type UpstreamError struct {
+	Code string
+	Text string
+}
+
+func (e *UpstreamError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("upstream error %s: %s", e.Code, e.Text)
+	}
+	return "upstream error: " + e.Text
+}
+
+// upstreamError returns the error envelope in an object response, if any.
+// The error may be an object with a code and text or a plain string.
+func upstreamError(data []byte) *UpstreamError {
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(data, &envelope) != nil || len(envelope.Error) == 0 {
+		return nil
+	}
+
+	var text string
+	if json.Unmarshal(envelope.Error, &text) == nil {
+		if text == "" {
+			return nil
 		}
+		return &UpstreamError{Text: text}
 	}
 
-	return nil, fmt.Errorf("failed to parse flight response as array or object: %v, %v", err1, err2)
+	var detail struct {
+		Code json.RawMessage `json:"code"`
+		Text string          `json:"text"`
+	}
+	if json.Unmarshal(envelope.Error, &detail) != nil {
+		return nil
+	}
+	return &UpstreamError{Code: strings.Trim(string(detail.Code), `"`), Text: detail.Text}
+}
+
+// parseFailure wraps a decode error for a kind of response. Upstream errors
+// are returned as they are.
+func parseFailure(kind string, err error) error {
+	var upstream *UpstreamError
+	if errors.As(err, &upstream) {
+		return err
+	}
+	return fmt.Errorf("failed to parse %s response as array or object: %w", kind, err)
+}
+
+// ParseOneOrMany decodes a response that is either an array of T or a single
+// T. An error envelope is returned as an *UpstreamError.
+func ParseOneOrMany[T any](data []byte, opts ...ParseOption) ([]T, error) {
+	items, err := decodeArrayStream[T](bytes.NewReader(data), 0, newParseOptions(opts))
+	if err != nil {
+		// Name the response after T, e.g. "flight" for []Flight
+		kind := fmt.Sprintf("%T", *new(T))
+		kind = strings.ToLower(kind[strings.LastIndex(kind, ".")+1:])
+		return nil, parseFailure(kind, err)
+	}
+	return items, nil
+}
+
+// decodeArrayStream decodes the elements of a JSON array one at a time,
+// stopping after limit elements when limit is positive. A single object is
+// treated as an array of one. In strict mode invalid elements are skipped
+// and reported as warnings.
+func decodeArrayStream[T any](r io.Reader, limit int, options parseOptions) ([]T, error) {
+	items := []T{}
+	err := streamArray(r, options, func(item T) bool {
+		items = append(items, item)
+		return limit <= 0 || len(items) < limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// streamArray decodes the elements of a JSON array one at a time, passing
+// each to fn without keeping them. Decoding stops early when fn returns
+// false. A single object is treated as an array of one and an error envelope
+// is returned as an *UpstreamError.
+func streamArray[T any](r io.Reader, options parseOptions, fn func(T) bool) error {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('['):
+		for index := 0; dec.More(); index++ {
+			var item T
+			ok, err := options.decode(dec, &item, index)
+			if err != nil {
+				return fmt.Errorf("element %d: %w", index, err)
+			}
+			if ok && !fn(item) {
+				return nil
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("reading end of array: %w", err)
+		}
+		return nil
+
+	case json.Delim('{'):
+		// Re-read the object including the opening brace Token consumed
+		var raw json.RawMessage
+		rest := io.MultiReader(strings.NewReader("{"), dec.Buffered(), r)
+		if err := json.NewDecoder(rest).Decode(&raw); err != nil {
+			return err
+		}
+		if upstream := upstreamError(raw); upstream != nil {
+			return upstream
+		}
+		var item T
+		ok, err := options.decode(json.NewDecoder(bytes.NewReader(raw)), &item, 0)
+		if err != nil {
+			return err
+		}
+		if ok {
+			fn(item)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("expected a JSON array or object, got %v", tok)
+	}
 }
 
 // ParseWeatherResponse handles weather API responses
@@ -143,6 +222,202 @@ func (p *Parser) ParseWeatherResponse(data []byte) (*WeatherData, error) {
 	return &weather, nil
 }
 
+// ParseWorldBankResponse decodes the World Bank [metadata, data] response
+// format. Error responses, which carry a message instead of pagination, are
+// returned as errors.
+func (p *Parser) ParseWorldBankResponse(data []byte) (*WorldBankPage, []WorldBankIndicatorValue, error) {
+	var parts []json.RawMessage
+	if err := json.Unmarshal(data, &parts); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse World Bank response: %w", err)
+	}
+	if len(parts) == 0 {
+		return nil, nil, fmt.Errorf("empty World Bank response")
+	}
+
+	var meta struct {
+		Page    flexInt `json:"page"`
+		Pages   flexInt `json:"pages"`
+		PerPage flexInt `json:"per_page"`
+		Total   flexInt `json:"total"`
+		Message []struct {
+			ID    string `json:"id"`
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(parts[0], &meta); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse World Bank metadata: %w", err)
+	}
+	if len(meta.Message) > 0 {
+		m := meta.Message[0]
+		return nil, nil, fmt.Errorf("World Bank error %s: %s %s", m.ID, m.Key, strings.TrimSpace(m.Value))
+	}
+
+	page := &WorldBankPage{
+		Page:    int(meta.Page),
+		Pages:   int(meta.Pages),
+		PerPage: int(meta.PerPage),
+		Total:   int(meta.Total),
+	}
+
+	// The data element is missing or null when there are no results
+	values := []WorldBankIndicatorValue{}
+	if len(parts) < 2 {
+		return page, values, nil
+	}
+
+	var rows []struct {
+		Indicator struct {
+			ID string `json:"id"`
+		} `json:"indicator"`
+		Country struct {
+			ID string `json:"id"`
+		} `json:"country"`
+		CountryISO3 string   `json:"countryiso3code"`
+		Date        string   `json:"date"`
+		Value       *float64 `json:"value"`
+	}
+	if err := json.Unmarshal(parts[1], &rows); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse World Bank data: %w", err)
+	}
+
+	for _, row := range rows {
+		iso3 := row.CountryISO3
+		if iso3 == "" {
+			iso3 = row.Country.ID
+		}
+		values = append(values, WorldBankIndicatorValue{
+			CountryISO3: iso3,
+			IndicatorID: row.Indicator.ID,
+			Date:        row.Date,
+			Value:       row.Value,
+		})
+	}
+
+	return page, values, nil
+}
+
+// flexInt decodes integers the World Bank sends as numbers, strings or null
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*n = flexInt(v)
+	return nil
+}
+
+// ParseNOTAMResponse decodes a page of an FAA NOTAM API geoJson response,
+// returning the NOTAMs and the total number of pages
+func (p *Parser) ParseNOTAMResponse(data []byte) ([]NOTAM, int, error) {
+	var response struct {
+		TotalPages int `json:"totalPages"`
+		Items      []struct {
+			Properties struct {
+				CoreNOTAMData struct {
+					NOTAM struct {
+						ID             string `json:"id"`
+						Number         string `json:"number"`
+						AffectedFIR    string `json:"affectedFIR"`
+						Location       string `json:"location"`
+						ICAOLocation   string `json:"icaoLocation"`
+						SelectionCode  string `json:"selectionCode"`
+						EffectiveStart string `json:"effectiveStart"`
+						EffectiveEnd   string `json:"effectiveEnd"`
+						Text           string `json:"text"`
+					} `json:"notam"`
+				} `json:"coreNOTAMData"`
+			} `json:"properties"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse NOTAM response: %w", err)
+	}
+
+	notams := make([]NOTAM, 0, len(response.Items))
+	for _, item := range response.Items {
+		n := item.Properties.CoreNOTAMData.NOTAM
+		location := n.ICAOLocation
+		if location == "" {
+			location = n.Location
+		}
+		notams = append(notams, NOTAM{
+			ID:            n.ID,
+			Number:        n.Number,
+			FIR:           n.AffectedFIR,
+			Location:      location,
+			EffectiveFrom: parseNOTAMTime(n.EffectiveStart),
+			EffectiveTo:   parseNOTAMTime(n.EffectiveEnd),
+			Text:          n.Text,
+			QCode:         n.SelectionCode,
+		})
+	}
+	return notams, response.TotalPages, nil
+}
+
+// parseNOTAMTime parses a NOTAM effective time. PERM and unparseable times
+// give the zero time; estimated end times ("...EST") are taken as given.
+func parseNOTAMTime(s string) time.Time {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "EST"))
+	if s == "" || s == "PERM" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// ParseAirportResponse decodes an aviation-edge airportDatabase response,
+// which sends coordinates as numbers or strings and only the IATA code of
+// the city
+func (p *Parser) ParseAirportResponse(data []byte) ([]Airport, error) {
+	type airportRecord struct {
+		NameAirport      string      `json:"nameAirport"`
+		CodeIataAirport  string      `json:"codeIataAirport"`
+		CodeIcaoAirport  string      `json:"codeIcaoAirport"`
+		CodeIataCity     string      `json:"codeIataCity"`
+		CodeIso2Country  string      `json:"codeIso2Country"`
+		LatitudeAirport  json.Number `json:"latitudeAirport"`
+		LongitudeAirport json.Number `json:"longitudeAirport"`
+		Timezone         string      `json:"timezone"`
+	}
+
+	records, err := decodeArrayStream[airportRecord](bytes.NewReader(data), 0, parseOptions{})
+	if err != nil {
+		return nil, parseFailure("airport", err)
+	}
+
+	airports := make([]Airport, 0, len(records))
+	for _, r := range records {
+		lat, latErr := r.LatitudeAirport.Float64()
+		lon, lonErr := r.LongitudeAirport.Float64()
+		if r.CodeIataAirport == "" && r.CodeIcaoAirport == "" || latErr != nil || lonErr != nil {
+			// Records without a code or a position are of no use for lookups
+			continue
+		}
+		airports = append(airports, Airport{
+			IATA:      strings.ToUpper(r.CodeIataAirport),
+			ICAO:      strings.ToUpper(r.CodeIcaoAirport),
+			Name:      r.NameAirport,
+			City:      r.CodeIataCity,
+			Country:   strings.ToUpper(r.CodeIso2Country),
+			Latitude:  lat,
+			Longitude: lon,
+			Timezone:  r.Timezone,
+		})
+	}
+	return airports, nil
+}
+
 // ParseSustainabilityResponse handles sustainability API responses
 func (p *Parser) ParseSustainabilityResponse(data []byte) (*SustainabilityData, error) {
 	log.Println("Parsing sustainability response, length:", len(data))
@@ -0,0 +1,69 @@
+package clients
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// aircraftArray returns a reader of a JSON array of total aircraft, written
+// into a pipe as it is read so it is never held whole on either side, and
+// the count of aircraft written so far
+func aircraftArray(total int) (*io.PipeReader, *atomic.Int64) {
+	reader, writer := io.Pipe()
+	var written atomic.Int64
+	go func() {
+		io.WriteString(writer, "[")
+		for i := 0; i < total; i++ {
+			if i > 0 {
+				io.WriteString(writer, ",")
+			}
+			if _, err := fmt.Fprintf(writer, `{"airplaneId":"%d","numberRegistration":"N%05d","planeModel":"737"}`, i, i); err != nil {
+				return // The reader stopped early
+			}
+			written.Add(1)
+		}
+		io.WriteString(writer, "]")
+		writer.Close()
+	}()
+	return reader, &written
+}
+
+func TestParseAircraftStreamLargeArray(t *testing.T) {
+	const total = 100000
+	reader, _ := aircraftArray(total)
+
+	aircraft, err := NewParser().ParseAircraftStream(reader, 0)
+	if err != nil {
+		t.Fatalf("ParseAircraftStream: %v", err)
+	}
+	if len(aircraft) != total {
+		t.Fatalf("decoded %d aircraft, want %d", len(aircraft), total)
+	}
+	for _, i := range []int{0, total / 2, total - 1} {
+		if want := fmt.Sprintf("N%05d", i); aircraft[i].NumberRegistration != want {
+			t.Errorf("aircraft %d is %s, want %s", i, aircraft[i].NumberRegistration, want)
+		}
+	}
+}
+
+func TestParseAircraftStreamStopsAtLimit(t *testing.T) {
+	const total, limit = 100000, 10
+	reader, written := aircraftArray(total)
+	defer reader.Close() // Ends the writer
+
+	aircraft, err := NewParser().ParseAircraftStream(reader, limit)
+	if err != nil {
+		t.Fatalf("ParseAircraftStream: %v", err)
+	}
+	if len(aircraft) != limit {
+		t.Fatalf("decoded %d aircraft, want %d", len(aircraft), limit)
+	}
+	if want := fmt.Sprintf("N%05d", limit-1); aircraft[limit-1].NumberRegistration != want {
+		t.Errorf("last aircraft is %s, want %s", aircraft[limit-1].NumberRegistration, want)
+	}
+	if n := written.Load(); n >= total {
+		t.Errorf("all %d aircraft were read for the first %d", n, limit)
+	}
+}
@@ -0,0 +1,103 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultLoadFactor is the share of seats assumed occupied, the typical
+// passenger load factor of scheduled flights
+const DefaultLoadFactor = 0.82
+
+// ErrInvalidCabinClass is returned for cabin classes other than economy,
+// premium economy, business and first
+var ErrInvalidCabinClass = errors.New("invalid cabin class")
+
+// cabinClassMultipliers are the shares of a flight's emissions attributed to
+// a seat in each class relative to economy, following ICAO's methodology of
+// weighting classes by the floor space their seats take up
+var cabinClassMultipliers = map[string]float64{
+	"economy":         1,
+	"premium_economy": 1.5,
+	"business":        2,
+	"first":           4,
+}
+
+// PassengerEmissions is the share of a flight's emissions attributed to one
+// passenger
+type PassengerEmissions struct {
+	Route              string  `json:"route"`
+	CabinClass         string  `json:"cabin_class"`
+	ClassMultiplier    float64 `json:"class_multiplier"` // Relative to economy
+	LoadFactor         float64 `json:"load_factor"`
+	SeatsAssumed       int     `json:"seats_assumed"`
+	DistanceKm         float64 `json:"distance_km"`
+	FlightCO2Kg        float64 `json:"flight_co2_kg"`
+	FlightFuelKg       float64 `json:"flight_fuel_kg"`
+	CO2PerPassengerKg  float64 `json:"co2_per_passenger_kg"`
+	FuelPerPassengerKg float64 `json:"fuel_per_passenger_kg"`
+	Synthetic          bool    `json:"synthetic"` // Based on estimated rather than ICAO emissions
+}
+
+// GetPassengerEmissions calculates the emissions of one passenger flying a
+// route in a cabin class
+func (s *SustainabilityAPI) GetPassengerEmissions(origin, destination, cabinClass string, loadFactor float64) (*PassengerEmissions, error) {
+	return s.GetPassengerEmissionsContext(context.Background(), origin, destination, cabinClass, loadFactor)
+}
+
+// GetPassengerEmissionsContext calculates the emissions of one passenger,
+// aborting when ctx is done. The flight's emissions are spread over its
+// occupied seats, seats times loadFactor, and weighted by the cabin class:
+// business counts twice and first four times as much as economy. An empty
+// cabinClass means economy and a zero loadFactor DefaultLoadFactor.
+func (s *SustainabilityAPI) GetPassengerEmissionsContext(ctx context.Context, origin, destination, cabinClass string, loadFactor float64) (*PassengerEmissions, error) {
+	class, err := normalizeCabinClass(cabinClass)
+	if err != nil {
+		return nil, err
+	}
+	if loadFactor == 0 {
+		loadFactor = DefaultLoadFactor
+	}
+	if !(loadFactor > 0 && loadFactor <= 1) {
+		return nil, fmt.Errorf("invalid load factor %v: must be above 0 and at most 1", loadFactor)
+	}
+
+	// The flight's emissions do not depend on the class, only their share does
+	flight, err := s.GetRouteEmissionsContext(ctx, origin, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	multiplier := cabinClassMultipliers[class]
+	passengers := float64(flight.SeatsAssumed) * loadFactor
+	return &PassengerEmissions{
+		Route:              flight.Route,
+		CabinClass:         class,
+		ClassMultiplier:    multiplier,
+		LoadFactor:         loadFactor,
+		SeatsAssumed:       flight.SeatsAssumed,
+		DistanceKm:         flight.Distance,
+		FlightCO2Kg:        flight.CO2Emissions.Total,
+		FlightFuelKg:       flight.FuelConsumption.Total,
+		CO2PerPassengerKg:  math.Round(flight.CO2Emissions.Total/passengers*multiplier*10) / 10,
+		FuelPerPassengerKg: math.Round(flight.FuelConsumption.Total/passengers*multiplier*10) / 10,
+		Synthetic:          flight.Synthetic,
+	}, nil
+}
+
+// normalizeCabinClass accepts cabin class names in any case, with spaces or
+// hyphens in place of underscores
+func normalizeCabinClass(cabinClass string) (string, error) {
+	class := strings.ToLower(strings.TrimSpace(cabinClass))
+	class = strings.NewReplacer(" ", "_", "-", "_").Replace(class)
+	if class == "" {
+		return "economy", nil
+	}
+	if _, ok := cabinClassMultipliers[class]; !ok {
+		return "", fmt.Errorf("%w %q: must be economy, premium_economy, business or first", ErrInvalidCabinClass, cabinClass)
+	}
+	return class, nil
+}
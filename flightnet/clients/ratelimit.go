@@ -0,0 +1,184 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when a request cannot be made within the
+// caller's deadline without exceeding the API's rate limit
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitError is returned when an upstream API responds with 429 Too Many
+// Requests. It matches ErrRateLimited with errors.Is.
+type RateLimitError struct {
+	API        string
+	RetryAfter time.Duration // Wait requested by the API, zero when not given
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: rate limited by upstream, retry after %v", e.API, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s: rate limited by upstream", e.API)
+}
+
+// Is makes errors.Is(err, ErrRateLimited) true for upstream rate limiting
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// newRateLimitError builds a RateLimitError from a 429 response's headers
+func newRateLimitError(apiName string, header http.Header, now time.Time) *RateLimitError {
+	return &RateLimitError{
+		API:        apiName,
+		RetryAfter: parseRetryAfter(header.Get("Retry-After"), now),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header in either delay-seconds or
+// HTTP-date form. Missing, malformed and past values yield zero.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// tokenBucket is a token bucket rate limiter
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64 // Maximum number of tokens
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket creates a full bucket
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// refill adds the tokens accumulated since the last update; callers hold mu
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens += elapsed * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+}
+
+// Wait blocks until a token is available. It returns ErrRateLimited without
+// waiting when the token would not arrive before the context deadline.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.mu.Unlock()
+		return nil
+	}
+
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		b.mu.Unlock()
+		return ErrRateLimited
+	}
+
+	// Reserve the token now so concurrent callers queue behind us
+	b.tokens--
+	b.mu.Unlock()
+
+	if err := sleepContext(ctx, wait); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Remaining returns the number of tokens currently available
+func (b *tokenBucket) Remaining() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens < 0 {
+		return 0
+	}
+	return b.tokens
+}
+
+// limiterFor returns the rate limiter for an API, or nil when it is unlimited
+func (f *Fetcher) limiterFor(apiName string) *tokenBucket {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if limiter, exists := f.limiters[apiName]; exists {
+		return limiter
+	}
+
+	config := f.configs[apiName]
+	if config.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	if f.limiters == nil {
+		f.limiters = make(map[string]*tokenBucket)
+	}
+	limiter := newTokenBucket(config.RequestsPerSecond, config.Burst)
+	f.limiters[apiName] = limiter
+	return limiter
+}
+
+// waitRateLimit blocks until the API's rate limit allows another request
+func (f *Fetcher) waitRateLimit(ctx context.Context, apiName string) error {
+	limiter := f.limiterFor(apiName)
+	if limiter == nil {
+		return nil
+	}
+	if err := limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%s: %w", apiName, err)
+	}
+	return nil
+}
+
+// RateLimitRemaining reports how many requests can be made to an API right
+// now without waiting. limited is false for APIs without a rate limit.
+func (f *Fetcher) RateLimitRemaining(apiName string) (remaining float64, limited bool) {
+	limiter := f.limiterFor(apiName)
+	if limiter == nil {
+		return 0, false
+	}
+	return limiter.Remaining(), true
+}
@@ -0,0 +1,86 @@
+package clients
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces sensitive values in logged URLs and headers
+const Redacted = "REDACTED"
+
+// sensitiveQueryParams are query parameters that carry credentials
+var sensitiveQueryParams = map[string]bool{
+	"key":     true,
+	"apikey":  true,
+	"api_key": true,
+	"token":   true,
+}
+
+// sensitiveHeaders are headers that carry credentials, in canonical form
+var sensitiveHeaders = map[string]bool{
+	"Authorization":             true,
+	"Proxy-Authorization":       true,
+	"X-Api-Key":                 true,
+	"Ocp-Apim-Subscription-Key": true,
+	"Client_id":                 true,
+	"Client_secret":             true,
+}
+
+// isSensitiveQueryParam reports whether a query parameter name carries a
+// credential. Matching is case-insensitive so key, apiKey and apikey are
+// all caught.
+func isSensitiveQueryParam(name string) bool {
+	return sensitiveQueryParams[strings.ToLower(name)]
+}
+
+// RedactURL masks the values of sensitive query parameters so the URL can be
+// logged safely. Strings that do not parse as URLs are returned unchanged.
+func RedactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	q := u.Query()
+	redacted := false
+	for name, values := range q {
+		if !isSensitiveQueryParam(name) {
+			continue
+		}
+		for i := range values {
+			values[i] = Redacted
+		}
+		redacted = true
+	}
+	if !redacted {
+		return rawURL
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// RedactHeaders returns a copy of h with sensitive header values masked
+func RedactHeaders(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			out[name] = []string{Redacted}
+			continue
+		}
+		out[name] = append([]string(nil), values...)
+	}
+	return out
+}
+
+// redactError strips credentials from the URL embedded in net/http errors,
+// which otherwise end up verbatim in logs and wrapped error messages
+func redactError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = RedactURL(urlErr.URL)
+	}
+	return err
+}
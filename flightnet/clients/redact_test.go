@@ -0,0 +1,51 @@
+package clients
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestLogsRedactKey(t *testing.T) {
+	const secret = "s3cr3t-api-key"
+	var seen string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.URL.Query().Get("key")
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	output := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(output)
+
+	cfg := APIConfig{APIKey: secret, AuthStyle: AuthKeyInQuery, AuthParam: "key"}
+	f := newTestFetcher(t, server.URL, cfg)
+	if _, err := f.Get("test", "flights", map[string]string{"code": "JFK"}); err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if seen != secret {
+		t.Fatalf("server got key %q, want the real key", seen)
+	}
+
+	// A network failure logs the URL through the wrapped net/http error
+	cfg.Retry = RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	f = newTestFetcher(t, "http://127.0.0.1:1", cfg)
+	if _, err := f.Get("test", "flights", nil); err == nil {
+		t.Fatal("Get succeeded against a closed port")
+	} else if strings.Contains(err.Error(), secret) {
+		t.Errorf("error leaks the key: %v", err)
+	}
+
+	if !strings.Contains(logs.String(), "key="+Redacted) {
+		t.Errorf("logs do not contain key=%s:\n%s", Redacted, logs.String())
+	}
+	if strings.Contains(logs.String(), secret) {
+		t.Errorf("logs contain the key:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,170 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// regionalTopRisks is how many of the highest-risk countries a regional
+	// report ranks
+	regionalTopRisks = 3
+	// gprBaselineRisk is the risk score taken to match a GPR index of 100, the
+	// index's 1985-2019 average: the neutral "Medium" assessment
+	gprBaselineRisk = 0.5
+	// gprInLineRatio is how far a regional index may be from the global one,
+	// as a fraction, to count as in line with it
+	gprInLineRatio = 0.1
+)
+
+// RiskStatistics summarizes the risk scores of a group of countries
+type RiskStatistics struct {
+	Count  int     `json:"count"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	Max    float64 `json:"max"`
+	Min    float64 `json:"min"`
+}
+
+// RankedCountryRisk is a country's place in a ranking by risk score
+type RankedCountryRisk struct {
+	Rank      int     `json:"rank"`
+	Country   string  `json:"country"` // ISO 3166-1 alpha-2 code
+	Name      string  `json:"name"`
+	RiskScore float64 `json:"risk_score"`
+	RiskLevel string  `json:"risk_level"`
+}
+
+// GPRComparison compares a region's risk with the global Geopolitical Risk
+// Index
+type GPRComparison struct {
+	GlobalIndex float64 `json:"global_index"` // 100 is the 1985-2019 average
+	// RegionalIndex is the region's mean risk score on the index's scale, a
+	// score of 0.5 matching 100
+	RegionalIndex float64 `json:"regional_index"`
+	Ratio         float64 `json:"ratio"`      // RegionalIndex over GlobalIndex
+	Assessment    string  `json:"assessment"` // "above", "in line with" or "below" the global index
+}
+
+// RegionalRiskReport is the geopolitical risk of every country in a region
+// with aggregate statistics
+type RegionalRiskReport struct {
+	Region              string              `json:"region"`
+	Countries           []GeopoliticalRisk  `json:"countries"` // In country table order
+	Statistics          RiskStatistics      `json:"statistics"`
+	HighestRisk         []RankedCountryRisk `json:"highest_risk"`
+	CountriesWithAlerts int                 `json:"countries_with_alerts"` // Alerts above low severity
+
+	GlobalComparison GPRComparison `json:"global_comparison"`
+	GeneratedAt      string        `json:"generated_at"`
+}
+
+// GetRegionalRiskReport assesses every country in a region, such as "Europe"
+// or "Middle East", and summarizes their risk
+func (g *GeopoliticalAPI) GetRegionalRiskReport(region string) (*RegionalRiskReport, error) {
+	return g.GetRegionalRiskReportContext(context.Background(), region)
+}
+
+// GetRegionalRiskReportContext assesses every country in a region with
+// GetCountryRisk, aborting when ctx is done. Unknown regions return an error
+// matching ErrUnknownRegion that lists the valid ones.
+func (g *GeopoliticalAPI) GetRegionalRiskReportContext(ctx context.Context, region string) (*RegionalRiskReport, error) {
+	countries, err := regionCountries(region)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RegionalRiskReport{
+		Region:      countries[0].region,
+		Countries:   make([]GeopoliticalRisk, 0, len(countries)),
+		HighestRisk: []RankedCountryRisk{},
+	}
+	names := make(map[string]string, len(countries))
+	for _, country := range countries {
+		risk, err := g.GetCountryRiskContext(ctx, country.iso2)
+		if err != nil {
+			return nil, fmt.Errorf("failed to assess %s: %w", country.iso2, err)
+		}
+		report.Countries = append(report.Countries, *risk)
+		names[country.iso2] = country.name
+		for _, alert := range risk.Alerts {
+			if alert.Severity != "Low" {
+				report.CountriesWithAlerts++
+				break
+			}
+		}
+	}
+	report.Statistics = riskStatistics(report.Countries)
+
+	ranked := make([]GeopoliticalRisk, len(report.Countries))
+	copy(ranked, report.Countries)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].RiskScore > ranked[j].RiskScore })
+	for i, risk := range ranked {
+		if i == regionalTopRisks {
+			break
+		}
+		report.HighestRisk = append(report.HighestRisk, RankedCountryRisk{
+			Rank:      i + 1,
+			Country:   risk.Country,
+			Name:      names[risk.Country],
+			RiskScore: risk.RiskScore,
+			RiskLevel: risk.RiskLevel,
+		})
+	}
+
+	gpr, err := g.GetGlobalGPRIndex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get global GPR index: %w", err)
+	}
+	report.GlobalComparison = compareWithGPR(report.Statistics.Mean, gpr.GPRIndex)
+	report.GeneratedAt = time.Now().Format(time.RFC3339)
+	return report, nil
+}
+
+// riskStatistics summarizes the risk scores of risks, which must not be empty
+func riskStatistics(risks []GeopoliticalRisk) RiskStatistics {
+	scores := make([]float64, len(risks))
+	var sum float64
+	for i, risk := range risks {
+		scores[i] = risk.RiskScore
+		sum += risk.RiskScore
+	}
+	sort.Float64s(scores)
+
+	n := len(scores)
+	median := scores[n/2]
+	if n%2 == 0 {
+		median = (scores[n/2-1] + scores[n/2]) / 2
+	}
+	return RiskStatistics{
+		Count:  n,
+		Mean:   math.Round(sum/float64(n)*1000) / 1000,
+		Median: math.Round(median*1000) / 1000,
+		Max:    scores[n-1],
+		Min:    scores[0],
+	}
+}
+
+// compareWithGPR puts a mean risk score on the GPR index's scale and
+// compares it with the global index
+func compareWithGPR(meanRisk, globalIndex float64) GPRComparison {
+	comparison := GPRComparison{
+		GlobalIndex:   globalIndex,
+		RegionalIndex: math.Round(meanRisk/gprBaselineRisk*100*10) / 10,
+		Assessment:    "in line with",
+	}
+	if globalIndex <= 0 {
+		return comparison
+	}
+	comparison.Ratio = math.Round(comparison.RegionalIndex/globalIndex*1000) / 1000
+	switch {
+	case comparison.Ratio > 1+gprInLineRatio:
+		comparison.Assessment = "above"
+	case comparison.Ratio < 1-gprInLineRatio:
+		comparison.Assessment = "below"
+	}
+	return comparison
+}
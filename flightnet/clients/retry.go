@@ -0,0 +1,219 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy controls how failed requests to an API are retried. Server
+// errors (5xx) and network errors are retried; client errors (4xx) are not,
+// except 429 responses, which are retried after their Retry-After delay.
+type RetryPolicy struct {
+	MaxAttempts int           // Total attempts including the first, 1 disables retries
+	BaseDelay   time.Duration // Delay before the first retry, doubled on each attempt
+	MaxDelay    time.Duration // Upper bound for the delay between attempts
+}
+
+// DefaultRetryPolicy is used for APIs that do not configure their own policy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// HTTPStatusError is returned when an API responds with an unexpected status code
+type HTTPStatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, response: %s", e.StatusCode, string(e.Body))
+}
+
+// withDefaults fills in unset fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	if p.MaxDelay < p.BaseDelay {
+		p.MaxDelay = p.BaseDelay
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry (1 for the first retry),
+// using exponential growth capped at MaxDelay with equal jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// isRetryable reports whether a failed attempt should be retried
+func isRetryable(err error) bool {
+	if errors.Is(err, ErrResponseTooLarge) {
+		return false
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	return true
+}
+
+// send performs a request built by newRequest, retrying according to the
+// API's retry policy. newRequest is called once per attempt with the key to
+// use so request bodies can be replayed and keys rotated. Retries stop as
+// soon as ctx is done.
+func (f *Fetcher) send(ctx context.Context, apiName, endpoint string, config APIConfig, newRequest func(key string) (*http.Request, error)) (*Response, error) {
+	policy := config.Retry.withDefaults()
+	maxSize := maxResponseSize(config)
+	ring := f.keyRingFor(apiName, config)
+
+	var lastResp *Response
+	var lastErr error
+	attempt := 0
+	for attempt < policy.MaxAttempts {
+		attempt++
+
+		if err := f.waitRateLimit(ctx, apiName); err != nil {
+			return nil, err
+		}
+
+		key, keyIndex := config.APIKey, -1
+		if ring != nil {
+			var err error
+			if key, keyIndex, err = ring.current(apiName); err != nil {
+				return lastResp, err
+			}
+		}
+
+		req, err := newRequest(key)
+		if err != nil {
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+
+		done := f.observe(apiName, endpoint)
+		resp, err := f.sendOnce(apiName, req, maxSize)
+		if resp != nil {
+			done(resp.StatusCode, err)
+		} else {
+			done(0, err)
+		}
+		if err == nil {
+			return resp, nil
+		}
+		lastResp, lastErr = resp, err
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		// A rejected or exhausted key is retried straight away with the next
+		// key without using up an attempt; each key can only fail once
+		if reason, until, ok := keyFailure(resp); ok && ring != nil {
+			if ring.fail(apiName, keyIndex, reason, until) {
+				attempt--
+				continue
+			}
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt)
+		var rateErr *RateLimitError
+		if errors.As(err, &rateErr) {
+			// Honour Retry-After, handing the error back when waiting would
+			// outlast the retry policy or the caller's deadline
+			if rateErr.RetryAfter > 0 {
+				delay = rateErr.RetryAfter
+			}
+			if attempt >= policy.MaxAttempts || !canWait(ctx, delay, policy.MaxDelay) {
+				return resp, err
+			}
+		} else if !isRetryable(err) {
+			return resp, err
+		}
+		if attempt < policy.MaxAttempts {
+			log.Printf("Request to %s failed (attempt %d/%d): %v, retrying in %v",
+				apiName, attempt, policy.MaxAttempts, err, delay)
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return lastResp, fmt.Errorf("request to %s failed after %d attempt(s): %w", apiName, attempt, lastErr)
+}
+
+// canWait reports whether sleeping for d stays within both max and ctx's deadline
+func canWait(ctx context.Context, d, max time.Duration) bool {
+	if d > max {
+		return false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= d {
+		return false
+	}
+	return true
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// sendOnce performs a single attempt and reads the response body
+func (f *Fetcher) sendOnce(apiName string, req *http.Request, maxSize int64) (*Response, error) {
+	resp, err := f.clientFor(apiName).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %w", redactError(err))
+	}
+
+	reader, err := responseBody(resp, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	result := &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return result, newRateLimitError(apiName, resp.Header, time.Now())
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return result, &HTTPStatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+
+	return result, nil
+}
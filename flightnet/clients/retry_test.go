@@ -0,0 +1,67 @@
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestFetcher returns a fetcher with cfg registered as "test", pointed at
+// baseURL
+func newTestFetcher(t *testing.T, baseURL string, cfg APIConfig) *Fetcher {
+	t.Helper()
+	f := NewFetcher()
+	cfg.BaseURL = baseURL
+	if cfg.APIKey == "" {
+		cfg.APIKey = "test" // Without a key Get answers with mock responses
+	}
+	f.configs["test"] = cfg
+	return f
+}
+
+func TestSendRetriesServerErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) <= 2 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, server.URL, APIConfig{
+		Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	})
+	body, err := f.Get("test", "thing", nil)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("made %d attempts, want 3", got)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body %q, want the third attempt's", body)
+	}
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		http.Error(w, "no such thing", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(t, server.URL, APIConfig{
+		Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond},
+	})
+	if _, err := f.Get("test", "thing", nil); err == nil {
+		t.Fatal("Get succeeded on a 404")
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("made %d attempts, want 1", got)
+	}
+}
@@ -0,0 +1,278 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultRiskRefreshInterval is how often subscribed countries' risk is
+// re-evaluated unless SetRiskRefreshInterval is called
+const DefaultRiskRefreshInterval = time.Hour
+
+// RiskAlertType tells whether a country crossed its threshold upwards or
+// fell back below it
+type RiskAlertType string
+
+// Risk alert types
+const (
+	RiskAlertRaised    RiskAlertType = "raised"
+	RiskAlertRecovered RiskAlertType = "recovered"
+)
+
+// RiskAlert reports a country's risk score crossing a subscription's
+// threshold
+type RiskAlert struct {
+	Country       string        `json:"country"` // ISO 3166-1 alpha-2 code
+	Type          RiskAlertType `json:"type"`
+	Threshold     float64       `json:"threshold"`
+	PreviousScore float64       `json:"previous_score"` // Zero when Initial is set
+	NewScore      float64       `json:"new_score"`
+	Initial       bool          `json:"initial,omitempty"` // The country was already above the threshold when first evaluated
+	// Factors are the risk factors behind the alert: for a raised alert
+	// those at or above the threshold, for a recovery those that fell, both
+	// largest first
+	Factors []string  `json:"factors"`
+	Source  string    `json:"source"`
+	Time    time.Time `json:"time"`
+}
+
+// riskSubscription is a SubscribeRiskAlerts callback and the state it was
+// last evaluated against, which only the refresher touches
+type riskSubscription struct {
+	countries []string
+	threshold float64
+	cb        func(RiskAlert)
+	last      map[string]*GeopoliticalRisk // Latest risk per country
+}
+
+// riskAlerts holds the subscriptions and the refresher that evaluates them
+type riskAlerts struct {
+	mu       sync.Mutex
+	interval time.Duration
+	subs     map[int]*riskSubscription
+	nextID   int
+	stop     context.CancelFunc // Stops the refresher, nil while none runs
+	wake     chan struct{}      // Makes the refresher evaluate new subscriptions at once
+}
+
+// SetRiskRefreshInterval sets how often subscribed countries' risk is
+// re-evaluated, from the next evaluation on. Zero restores
+// DefaultRiskRefreshInterval.
+func (g *GeopoliticalAPI) SetRiskRefreshInterval(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRiskRefreshInterval
+	}
+	g.alerts.mu.Lock()
+	g.alerts.interval = interval
+	g.alerts.mu.Unlock()
+}
+
+// SubscribeRiskAlerts calls cb whenever the risk score of one of countries
+// rises to threshold or above, and again when it falls back below. Risk is
+// re-evaluated with GetCountryRisk by a background refresher, at once for a
+// new subscription and then every refresh interval; a country already at or
+// above the threshold on its first evaluation raises an initial alert. cb
+// is called from the refresher goroutine and should not block. The returned
+// function cancels the subscription; the refresher stops once none are left.
+func (g *GeopoliticalAPI) SubscribeRiskAlerts(countries []string, threshold float64, cb func(RiskAlert)) (func(), error) {
+	if cb == nil {
+		return nil, errors.New("risk alert callback is nil")
+	}
+	if threshold <= 0 || threshold > 1 {
+		return nil, fmt.Errorf("invalid risk threshold %v: must be above 0 and at most 1", threshold)
+	}
+	if len(countries) == 0 {
+		return nil, errors.New("no countries to watch")
+	}
+	sub := &riskSubscription{threshold: threshold, cb: cb, last: make(map[string]*GeopoliticalRisk)}
+	for _, country := range countries {
+		iso2, _, _, err := NormalizeCountry(country)
+		if err != nil {
+			return nil, err
+		}
+		sub.countries = append(sub.countries, iso2)
+	}
+
+	a := g.alerts
+	a.mu.Lock()
+	id := a.nextID
+	a.nextID++
+	a.subs[id] = sub
+	if a.stop == nil {
+		ctx, stop := context.WithCancel(context.Background())
+		a.stop = stop
+		go g.refreshRisk(ctx)
+	}
+	a.mu.Unlock()
+
+	// The refresher evaluates new subscriptions at once; one already pending
+	// covers this subscription too
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			delete(a.subs, id)
+			if len(a.subs) == 0 && a.stop != nil {
+				a.stop()
+				a.stop = nil
+			}
+		})
+	}, nil
+}
+
+// refreshRisk evaluates the subscriptions every refresh interval and
+// whenever woken, until ctx is done
+func (g *GeopoliticalAPI) refreshRisk(ctx context.Context) {
+	a := g.alerts
+	for {
+		select {
+		case <-a.wake:
+		default:
+		}
+		g.evaluateRiskAlerts(ctx)
+
+		a.mu.Lock()
+		interval := a.interval
+		a.mu.Unlock()
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-a.wake:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// evaluateRiskAlerts fetches the risk of every subscribed country once and
+// fires the alerts of each subscription
+func (g *GeopoliticalAPI) evaluateRiskAlerts(ctx context.Context) {
+	a := g.alerts
+	a.mu.Lock()
+	ids := make([]int, 0, len(a.subs))
+	for id := range a.subs {
+		ids = append(ids, id)
+	}
+	subs := make(map[int]*riskSubscription, len(a.subs))
+	for id, sub := range a.subs {
+		subs[id] = sub
+	}
+	a.mu.Unlock()
+	sort.Ints(ids) // Oldest subscription first
+
+	risks := make(map[string]*GeopoliticalRisk)
+	for _, id := range ids {
+		for _, country := range subs[id].countries {
+			if _, ok := risks[country]; ok {
+				continue
+			}
+			risk, err := g.GetCountryRiskContext(ctx, country)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Printf("Risk alerts: failed to evaluate %s: %v", country, err)
+			}
+			risks[country] = risk // Nil on failure, so it is only tried once
+		}
+	}
+
+	for _, id := range ids {
+		sub := subs[id]
+		for _, country := range sub.countries {
+			risk := risks[country]
+			if risk == nil {
+				continue
+			}
+			alert, ok := sub.evaluate(country, risk)
+			if !ok {
+				continue
+			}
+			a.mu.Lock()
+			_, active := a.subs[id]
+			a.mu.Unlock()
+			if active {
+				sub.cb(alert)
+			}
+		}
+	}
+}
+
+// evaluate records a country's new risk, returning the alert it raises
+func (s *riskSubscription) evaluate(country string, risk *GeopoliticalRisk) (RiskAlert, bool) {
+	previous, seen := s.last[country]
+	s.last[country] = risk
+
+	above := risk.RiskScore >= s.threshold
+	wasAbove := seen && previous.RiskScore >= s.threshold
+	if above == wasAbove {
+		return RiskAlert{}, false
+	}
+
+	alert := RiskAlert{
+		Country:   country,
+		Type:      RiskAlertRaised,
+		Threshold: s.threshold,
+		NewScore:  risk.RiskScore,
+		Initial:   !seen,
+		Source:    risk.Source,
+		Time:      time.Now(),
+	}
+	if seen {
+		alert.PreviousScore = previous.RiskScore
+	}
+
+	factors := riskFactors(risk)
+	var changes []riskFactor
+	if above {
+		for _, f := range factors {
+			if f.value >= s.threshold {
+				changes = append(changes, f)
+			}
+		}
+	} else {
+		alert.Type = RiskAlertRecovered
+		before := riskFactors(previous)
+		for i, f := range factors {
+			if drop := before[i].value - f.value; drop > 0 {
+				changes = append(changes, riskFactor{name: f.name, value: drop})
+			}
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].value > changes[j].value })
+	alert.Factors = make([]string, len(changes))
+	for i, f := range changes {
+		alert.Factors[i] = f.name
+	}
+	return alert, true
+}
+
+// riskFactor is a named risk factor value
+type riskFactor struct {
+	name  string
+	value float64
+}
+
+// riskFactors lists a risk's factors in a fixed order
+func riskFactors(risk *GeopoliticalRisk) []riskFactor {
+	return []riskFactor{
+		{"political", risk.Factors.Political},
+		{"economic", risk.Factors.Economic},
+		{"security", risk.Factors.Security},
+		{"social", risk.Factors.Social},
+		{"advisory", risk.Factors.Advisory},
+	}
+}
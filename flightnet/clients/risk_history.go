@@ -0,0 +1,159 @@
+package clients
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Trend analysis tuning
+const (
+	// minTrendSamples is how many samples a window needs before a trend is
+	// reported
+	minTrendSamples = 5
+	// fullTrendConfidenceSamples is how many samples give full confidence
+	fullTrendConfidenceSamples = 30
+	// stableTrendChange is the largest change in risk score over the window
+	// that still counts as stable
+	stableTrendChange = 0.02
+)
+
+// RiskSample is a country's risk score at a point in time
+type RiskSample struct {
+	Country   string    `json:"country"` // ISO 3166-1 alpha-2 code
+	Time      time.Time `json:"time"`
+	RiskScore float64   `json:"risk_score"`
+	Source    string    `json:"source"`
+}
+
+// RiskHistoryStore keeps the risk scores GetCountryRisk returns so trends
+// can be computed from them
+type RiskHistoryStore interface {
+	// Append records a sample
+	Append(sample RiskSample) error
+	// Samples returns a country's samples taken at or after since, oldest first
+	Samples(country string, since time.Time) ([]RiskSample, error)
+	// Prune removes samples taken before before, returning how many were removed
+	Prune(before time.Time) (int, error)
+}
+
+// FileRiskHistory is a RiskHistoryStore kept in memory and optionally saved
+// to a JSON file, which is rewritten atomically on every change
+type FileRiskHistory struct {
+	mu      sync.Mutex
+	path    string // Empty to keep the history in memory only
+	samples []RiskSample
+}
+
+// NewFileRiskHistory creates a store saved to path, loading the samples
+// already there. An empty path keeps the history in memory only.
+func NewFileRiskHistory(path string) (*FileRiskHistory, error) {
+	h := &FileRiskHistory{path: path}
+	if path == "" {
+		return h, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read risk history: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.samples); err != nil {
+		return nil, fmt.Errorf("failed to parse risk history %s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Append records a sample
+func (h *FileRiskHistory) Append(sample RiskSample) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples = append(h.samples, sample)
+	return h.save()
+}
+
+// Samples returns a country's samples taken at or after since, oldest first
+func (h *FileRiskHistory) Samples(country string, since time.Time) ([]RiskSample, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var samples []RiskSample
+	for _, s := range h.samples {
+		if s.Country == country && !s.Time.Before(since) {
+			samples = append(samples, s)
+		}
+	}
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Time.Before(samples[j].Time) })
+	return samples, nil
+}
+
+// Prune removes samples taken before before
+func (h *FileRiskHistory) Prune(before time.Time) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	kept := h.samples[:0]
+	for _, s := range h.samples {
+		if !s.Time.Before(before) {
+			kept = append(kept, s)
+		}
+	}
+	removed := len(h.samples) - len(kept)
+	h.samples = kept
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, h.save()
+}
+
+// save writes the samples to the file; the caller holds h.mu
+func (h *FileRiskHistory) save() error {
+	if h.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(h.samples)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(h.path, data); err != nil {
+		return fmt.Errorf("failed to save risk history: %w", err)
+	}
+	return nil
+}
+
+// riskTrend is a least-squares line through a series of risk scores
+type riskTrend struct {
+	slopePerDay float64 // Change in risk score per day
+	stdDev      float64 // Population standard deviation of the scores
+}
+
+// fitRiskTrend fits a line through samples, which must not be empty
+func fitRiskTrend(samples []RiskSample) riskTrend {
+	n := float64(len(samples))
+	start := samples[0].Time
+	var sumX, sumY float64
+	for _, s := range samples {
+		sumX += s.Time.Sub(start).Hours() / 24
+		sumY += s.RiskScore
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var sxx, sxy, syy float64
+	for _, s := range samples {
+		dx := s.Time.Sub(start).Hours()/24 - meanX
+		dy := s.RiskScore - meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+
+	trend := riskTrend{stdDev: math.Sqrt(syy / n)}
+	if sxx > 0 {
+		trend.slopePerDay = sxy / sxx
+	}
+	return trend
+}
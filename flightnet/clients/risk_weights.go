@@ -0,0 +1,94 @@
+package clients
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrInvalidRiskWeights is returned for risk weights that are negative or do
+// not sum to 1
+var ErrInvalidRiskWeights = errors.New("invalid risk weights")
+
+// riskWeightsTolerance is how far the sum of risk weights may be from 1, to
+// allow for weights such as thirds
+const riskWeightsTolerance = 1e-6
+
+// RiskWeights are the weights of the risk factors in a country's overall risk
+// score. They must be non-negative and sum to 1.
+type RiskWeights struct {
+	Political float64 `json:"political"`
+	Economic  float64 `json:"economic"`
+	Security  float64 `json:"security"`
+	Social    float64 `json:"social"`
+	Advisory  float64 `json:"advisory"` // Travel advisory level
+}
+
+// DefaultRiskWeights are the weights used unless SetWeights is called
+var DefaultRiskWeights = RiskWeights{Political: 0.25, Economic: 0.2, Security: 0.25, Social: 0.15, Advisory: 0.15}
+
+// Validate checks that the weights are non-negative and sum to 1, returning
+// an error matching ErrInvalidRiskWeights otherwise
+func (w RiskWeights) Validate() error {
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"political", w.Political},
+		{"economic", w.Economic},
+		{"security", w.Security},
+		{"social", w.Social},
+		{"advisory", w.Advisory},
+	} {
+		if f.value < 0 || math.IsNaN(f.value) || math.IsInf(f.value, 0) {
+			return fmt.Errorf("%w: %s weight is %v", ErrInvalidRiskWeights, f.name, f.value)
+		}
+	}
+	if sum := w.Political + w.Economic + w.Security + w.Social + w.Advisory; math.Abs(sum-1) > riskWeightsTolerance {
+		return fmt.Errorf("%w: weights sum to %v, not 1", ErrInvalidRiskWeights, sum)
+	}
+	return nil
+}
+
+// score is the weighted overall score of a risk's factors. Without a travel
+// advisory the other factors' weights are scaled up to make up for it.
+func (w RiskWeights) score(risk *GeopoliticalRisk) float64 {
+	score := risk.Factors.Political*w.Political +
+		risk.Factors.Economic*w.Economic +
+		risk.Factors.Security*w.Security +
+		risk.Factors.Social*w.Social
+	if risk.AdvisoryLevel > 0 {
+		return score + risk.Factors.Advisory*w.Advisory
+	}
+	if rest := 1 - w.Advisory; rest > riskWeightsTolerance {
+		return score / rest
+	}
+	// Only the advisory is weighted, so fall back to the plain mean
+	return (risk.Factors.Political + risk.Factors.Economic + risk.Factors.Security + risk.Factors.Social) / 4
+}
+
+// SetWeights sets the factor weights behind the risk scores GetCountryRisk
+// and GetCountryStabilityScore compute from then on
+func (g *GeopoliticalAPI) SetWeights(weights RiskWeights) error {
+	if err := weights.Validate(); err != nil {
+		return err
+	}
+	g.weightsMu.Lock()
+	g.weights = weights
+	g.weightsMu.Unlock()
+	return nil
+}
+
+// Weights returns the factor weights in use
+func (g *GeopoliticalAPI) Weights() RiskWeights {
+	g.weightsMu.Lock()
+	defer g.weightsMu.Unlock()
+	return g.weights
+}
+
+// applyWeights sets a risk's overall score and level from its factors
+func applyWeights(risk *GeopoliticalRisk, weights RiskWeights) {
+	risk.Weights = weights
+	risk.RiskScore = weights.score(risk)
+	risk.RiskLevel = riskLevel(risk.RiskScore)
+}
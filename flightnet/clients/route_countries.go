@@ -0,0 +1,69 @@
+package clients
+
+import "math"
+
+// Route path sampling
+const (
+	// routePathSampleKm is the spacing of the great-circle points checked
+	// for overflown countries
+	routePathSampleKm = 200
+	// maxRoutePathSamples bounds the points checked on very long routes
+	maxRoutePathSamples = 100
+	// routePathStationKm is how close the nearest airport must be for a
+	// point to count as over its country; points further out, usually over
+	// the ocean, count as over no country
+	routePathStationKm = 300
+)
+
+// routeSegment is a stretch of a route over one country, or over no country
+// when country is nil
+type routeSegment struct {
+	country *countryRecord
+	startKm float64 // Distance from the origin
+	endKm   float64
+}
+
+// routeSegments splits the great-circle route between two airports into the
+// stretches over each country, in path order. Points about routePathSampleKm
+// apart are attributed to the country of the nearest airport in the embedded
+// airport dataset; the endpoints always belong to the airports' countries.
+func routeSegments(origin, dest *Airport) ([]routeSegment, error) {
+	first, err := lookupCountry(origin.Country)
+	if err != nil {
+		return nil, err
+	}
+	last, err := lookupCountry(dest.Country)
+	if err != nil {
+		return nil, err
+	}
+
+	distance := GreatCircleDistance(origin.Latitude, origin.Longitude, dest.Latitude, dest.Longitude)
+	samples := min(max(int(math.Ceil(distance/routePathSampleKm)), 1), maxRoutePathSamples)
+	step := distance / float64(samples)
+
+	var segments []routeSegment
+	for i := 0; i <= samples; i++ {
+		var country *countryRecord
+		switch i {
+		case 0:
+			country = first
+		case samples:
+			country = last
+		default:
+			lat, lon := GreatCirclePoint(origin.Latitude, origin.Longitude, dest.Latitude, dest.Longitude, float64(i)/float64(samples))
+			if station, km := nearestStation(lat, lon); station != nil && km <= routePathStationKm {
+				country, _ = lookupCountry(station.Country)
+			}
+		}
+
+		// Each point stands for the half steps either side of it
+		start := max((float64(i)-0.5)*step, 0)
+		end := min((float64(i)+0.5)*step, distance)
+		if n := len(segments); n > 0 && segments[n-1].country == country {
+			segments[n-1].endKm = end
+			continue
+		}
+		segments = append(segments, routeSegment{country: country, startKm: start, endKm: end})
+	}
+	return segments, nil
+}
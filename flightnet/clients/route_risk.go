@@ -0,0 +1,167 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// highRouteRisk is the risk score from which a route segment counts as high
+// risk, the lower bound of the "High" risk level
+const highRouteRisk = 0.65
+
+// RouteRiskSegment is a stretch of a route over one country
+type RouteRiskSegment struct {
+	Country   string  `json:"country"` // ISO 3166-1 alpha-2 code
+	FIR       string  `json:"fir,omitempty"`
+	StartKm   float64 `json:"start_km"` // Distance from the origin
+	EndKm     float64 `json:"end_km"`
+	RiskScore float64 `json:"risk_score"`
+	RiskLevel string  `json:"risk_level"`
+	Flagged   bool    `json:"flagged,omitempty"` // The country has a reported airspace closure or restriction
+}
+
+// RouteRisk is the geopolitical risk of flying a great-circle route
+type RouteRisk struct {
+	Origin           string                       `json:"origin"`
+	Destination      string                       `json:"destination"`
+	DistanceKm       float64                      `json:"distance_km"`
+	MaxRisk          float64                      `json:"max_risk"`
+	AverageRisk      float64                      `json:"average_risk"` // Weighted by the distance flown over each country
+	RiskLevel        string                       `json:"risk_level"`   // Level of MaxRisk, since a route is as risky as its riskiest stretch
+	Segments         []RouteRiskSegment           `json:"segments"`     // Stretches over the ocean are left out
+	HighRiskSegments []RouteRiskSegment           `json:"high_risk_segments"`
+	Countries        map[string]*GeopoliticalRisk `json:"countries"`
+	FlaggedCountries []string                     `json:"flagged_countries"` // Countries along the route with airspace events
+	AirspaceEvents   []AirspaceEvent              `json:"airspace_events"`
+}
+
+// GetRouteRisk assesses the geopolitical risk of the great-circle route
+// between two airports from the risk of every country it crosses and the
+// airspace events reported in their news
+func (g *GeopoliticalAPI) GetRouteRisk(originIATA, destIATA string) (*RouteRisk, error) {
+	return g.GetRouteRiskContext(context.Background(), originIATA, destIATA)
+}
+
+// GetRouteRiskContext assesses the geopolitical risk of a route, aborting
+// when ctx is done. Crossed countries are approximated as for
+// NewsAPI.GetNewsForRoute. A segment is high risk when its country's score
+// is at least 0.65 or its airspace is reported closed or restricted.
+func (g *GeopoliticalAPI) GetRouteRiskContext(ctx context.Context, originIATA, destIATA string) (*RouteRisk, error) {
+	origin, err := g.airports.GetAirportContext(ctx, originIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid origin: %w", err)
+	}
+	dest, err := g.airports.GetAirportContext(ctx, destIATA)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+	segments, err := routeSegments(origin, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	route := &RouteRisk{
+		Origin:           origin.IATA,
+		Destination:      dest.IATA,
+		DistanceKm:       math.Round(segments[len(segments)-1].endKm*10) / 10,
+		Segments:         []RouteRiskSegment{},
+		HighRiskSegments: []RouteRiskSegment{},
+		Countries:        make(map[string]*GeopoliticalRisk),
+		FlaggedCountries: []string{},
+		AirspaceEvents:   []AirspaceEvent{},
+	}
+
+	var countries []string // In path order
+	onRoute := make(map[string]bool)
+	for _, segment := range segments {
+		if segment.country != nil && !onRoute[segment.country.iso2] {
+			onRoute[segment.country.iso2] = true
+			countries = append(countries, segment.country.iso2)
+		}
+	}
+	flagged := g.routeAirspaceEvents(ctx, route, countries)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	var weighted, flown float64
+	for _, segment := range segments {
+		if segment.country == nil {
+			continue
+		}
+		iso2 := segment.country.iso2
+		risk, ok := route.Countries[iso2]
+		if !ok {
+			risk, err = g.GetCountryRiskContext(ctx, iso2)
+			if err != nil {
+				return nil, fmt.Errorf("failed to assess %s: %w", iso2, err)
+			}
+			route.Countries[iso2] = risk
+		}
+
+		s := RouteRiskSegment{
+			Country:   iso2,
+			FIR:       segment.country.fir,
+			StartKm:   math.Round(segment.startKm*10) / 10,
+			EndKm:     math.Round(segment.endKm*10) / 10,
+			RiskScore: risk.RiskScore,
+			RiskLevel: risk.RiskLevel,
+			Flagged:   flagged[iso2],
+		}
+		route.Segments = append(route.Segments, s)
+		if s.RiskScore >= highRouteRisk || s.Flagged {
+			route.HighRiskSegments = append(route.HighRiskSegments, s)
+		}
+		route.MaxRisk = math.Max(route.MaxRisk, risk.RiskScore)
+		weighted += risk.RiskScore * (segment.endKm - segment.startKm)
+		flown += segment.endKm - segment.startKm
+	}
+
+	if flown > 0 {
+		route.AverageRisk = math.Round(weighted/flown*1000) / 1000
+	} else {
+		// Origin and destination are the same airport
+		route.AverageRisk = route.MaxRisk
+	}
+	route.RiskLevel = riskLevel(route.MaxRisk)
+	return route, nil
+}
+
+// routeAirspaceEvents adds the airspace events reported for countries on the
+// route to it, returning the countries whose airspace is closed or
+// restricted. News that cannot be fetched is logged and ignored.
+func (g *GeopoliticalAPI) routeAirspaceEvents(ctx context.Context, route *RouteRisk, countries []string) map[string]bool {
+	flagged := make(map[string]bool)
+	news, err := g.news.GetNewsForRouteContext(ctx, route.Origin, route.Destination)
+	if err != nil {
+		log.Printf("Route risk %s-%s: airspace events unavailable: %v", route.Origin, route.Destination, err)
+		return flagged
+	}
+
+	onRoute := make(map[string]bool, len(countries))
+	for _, iso2 := range countries {
+		onRoute[iso2] = true
+	}
+	seen := make(map[string]bool)
+	reported := make(map[string]bool) // Country and article URL, as neighbours' news overlaps
+	for _, iso2 := range countries {
+		for _, event := range ExtractAirspaceEvents(news[iso2]) {
+			key := event.CountryISO2 + " " + event.SourceArticle.URL
+			if !onRoute[event.CountryISO2] || reported[key] {
+				continue
+			}
+			reported[key] = true
+			route.AirspaceEvents = append(route.AirspaceEvents, event)
+			if !seen[event.CountryISO2] {
+				seen[event.CountryISO2] = true
+				route.FlaggedCountries = append(route.FlaggedCountries, event.CountryISO2)
+			}
+			if event.EventType != AirspaceAdvisory {
+				flagged[event.CountryISO2] = true
+			}
+		}
+	}
+	return flagged
+}
@@ -0,0 +1,105 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// maxRouteWeatherSamples bounds the waypoints GetRouteWeather fetches weather
+// for, since each one may need its own request
+const maxRouteWeatherSamples = 50
+
+// RouteWeatherPoint is the weather at a point along a route, taken from the
+// nearest weather station
+type RouteWeatherPoint struct {
+	Lat               float64      `json:"lat"`
+	Lon               float64      `json:"lon"`
+	NearestStation    string       `json:"nearest_station"`     // ICAO code of the station the weather is from
+	StationDistanceKm float64      `json:"station_distance_km"` // Distance from the point to the station
+	Weather           *WeatherData `json:"weather"`             // Nil when the station's weather could not be fetched
+}
+
+// GetRouteWeather returns the weather along the great-circle route between
+// two airports: at the origin, at samples evenly spaced waypoints and at the
+// destination, in that order
+func (w *WeatherAPI) GetRouteWeather(originIATA, destIATA string, samples int) ([]RouteWeatherPoint, error) {
+	return w.GetRouteWeatherContext(context.Background(), originIATA, destIATA, samples)
+}
+
+// GetRouteWeatherContext returns the weather along the great-circle route
+// between two airports, aborting when ctx is done. Each waypoint uses the
+// weather of the nearest airport in the embedded airport dataset.
+func (w *WeatherAPI) GetRouteWeatherContext(ctx context.Context, originIATA, destIATA string, samples int) ([]RouteWeatherPoint, error) {
+	if samples < 0 || samples > maxRouteWeatherSamples {
+		return nil, fmt.Errorf("invalid sample count %d: must be between 0 and %d", samples, maxRouteWeatherSamples)
+	}
+	origin, err := routeAirport(originIATA)
+	if err != nil {
+		return nil, err
+	}
+	dest, err := routeAirport(destIATA)
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]RouteWeatherPoint, 0, samples+2)
+	var stations []string
+	for i := 0; i <= samples+1; i++ {
+		lat, lon := GreatCirclePoint(origin.Latitude, origin.Longitude, dest.Latitude, dest.Longitude, float64(i)/float64(samples+1))
+		station, distance := nearestStation(lat, lon)
+		if station == nil {
+			return nil, fmt.Errorf("no weather station near %.2f,%.2f: %w", lat, lon, ErrAirportNotFound)
+		}
+		points = append(points, RouteWeatherPoint{
+			Lat:               math.Round(lat*1e4) / 1e4,
+			Lon:               math.Round(lon*1e4) / 1e4,
+			NearestStation:    station.ICAO,
+			StationDistanceKm: math.Round(distance*10) / 10,
+		})
+		stations = append(stations, station.IATA)
+	}
+
+	// Neighbouring waypoints often share a station, which is only fetched once
+	weather, failures, err := w.GetMultipleAirportsWeatherContext(ctx, stations)
+	if err != nil {
+		return nil, err
+	}
+	for station, err := range failures {
+		log.Printf("Route weather %s-%s: weather unavailable for %s: %v", origin.IATA, dest.IATA, station, err)
+	}
+	for i := range points {
+		points[i].Weather = weather[stations[i]]
+	}
+	return points, nil
+}
+
+// routeAirport looks up a route endpoint in the embedded airport dataset
+func routeAirport(code string) (*Airport, error) {
+	iata, err := normalizeIATAAirport(code)
+	if err != nil {
+		return nil, err
+	}
+	airport, ok := fallbackAirports().byIATA[iata]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", iata, ErrAirportNotFound)
+	}
+	return airport, nil
+}
+
+// nearestStation returns the airport with both an IATA and an ICAO code
+// closest to a point, and its distance in kilometres
+func nearestStation(lat, lon float64) (*Airport, float64) {
+	var nearest *Airport
+	best := math.Inf(1)
+	for _, airport := range fallbackAirports().byIATA {
+		if airport.ICAO == "" {
+			continue
+		}
+		if d := GreatCircleDistance(lat, lon, airport.Latitude, airport.Longitude); d < best || (d == best && airport.IATA < nearest.IATA) {
+			nearest, best = airport, d
+		}
+	}
+	return nearest, best
+}
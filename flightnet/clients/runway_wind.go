@@ -0,0 +1,124 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// RunwayWind is the wind resolved along and across a runway. Speeds are in
+// knots.
+type RunwayWind struct {
+	Airport          string  `json:"airport"`
+	RunwayHeadingDeg int     `json:"runway_heading_deg"`
+	WindDirectionDeg int     `json:"wind_direction_deg"` // 0 when variable or calm
+	WindSpeedKt      float64 `json:"wind_speed_kt"`
+	GustKt           float64 `json:"gust_kt,omitempty"`
+	HeadwindKt       float64 `json:"headwind_kt"`  // Negative for a tailwind
+	CrosswindKt      float64 `json:"crosswind_kt"` // Positive from the right, negative from the left
+	// Worst cases use the gust speed when gusting and, for variable winds,
+	// the least favourable direction
+	WorstCrosswindKt float64 `json:"worst_crosswind_kt"`
+	WorstTailwindKt  float64 `json:"worst_tailwind_kt"`
+	Variable         bool    `json:"variable"` // Direction varies, so the worst case assumes the least favourable one
+}
+
+// WindComponents resolves a wind into its headwind and crosswind components
+// for a runway. headwind is negative for a tailwind and crosswind is
+// positive from the right and negative from the left.
+func WindComponents(windDirDeg int, windSpeedKts float64, runwayHeadingDeg int) (headwind, crosswind float64) {
+	angle := float64(windDirDeg-runwayHeadingDeg) * math.Pi / 180
+	headwind = windSpeedKts * math.Cos(angle)
+	crosswind = windSpeedKts * math.Sin(angle)
+	return roundTenth(headwind), roundTenth(crosswind)
+}
+
+// roundTenth rounds to a tenth of a knot, removing floating point noise such
+// as 1e-15 or -0 for a wind straight down the runway
+func roundTenth(v float64) float64 {
+	v = math.Round(v*10) / 10
+	if v == 0 {
+		return 0
+	}
+	return v
+}
+
+// GetRunwayWind fetches the current wind at an airport and resolves it for a
+// runway heading
+func (w *WeatherAPI) GetRunwayWind(airportCode string, runwayHeading int) (*RunwayWind, error) {
+	return w.GetRunwayWindContext(context.Background(), airportCode, runwayHeading)
+}
+
+// GetRunwayWindContext fetches the current wind at an airport and resolves
+// it for a runway heading, aborting when ctx is done
+func (w *WeatherAPI) GetRunwayWindContext(ctx context.Context, airportCode string, runwayHeading int) (*RunwayWind, error) {
+	if runwayHeading < 1 || runwayHeading > 360 {
+		return nil, fmt.Errorf("invalid runway heading %d: must be between 1 and 360", runwayHeading)
+	}
+	weather, err := w.GetCurrentWeatherContext(ctx, airportCode)
+	if err != nil {
+		return nil, err
+	}
+	wind := runwayWind(weather, runwayHeading)
+	wind.Airport = airportCode
+	return wind, nil
+}
+
+// runwayWind resolves a weather report's wind for a runway. A variable
+// direction range in the METAR, such as 240V300, is searched for the least
+// favourable direction; VRB or a missing direction assumes any direction.
+func runwayWind(weather *WeatherData, runwayHeading int) *RunwayWind {
+	current := &weather.CurrentWeather
+	wind := &RunwayWind{
+		Airport:          weather.AirportIATA,
+		RunwayHeadingDeg: runwayHeading,
+		WindDirectionDeg: current.Wind.Direction,
+		WindSpeedKt:      knots(current.Wind.Speed, current.Wind.Unit),
+		GustKt:           knots(current.Wind.Gusts, current.Wind.Unit),
+	}
+	worstSpeed := math.Max(wind.WindSpeedKt, wind.GustKt)
+
+	varFrom, varTo := 0, 0
+	if current.METAR != "" {
+		if report, err := ParseMETAR(current.METAR); err == nil {
+			wind.Variable = report.WindVariable
+			varFrom, varTo = report.WindVariableFrom, report.WindVariableTo
+		}
+	}
+	if current.Wind.Direction == 0 && wind.WindSpeedKt > 0 {
+		wind.Variable = true
+	}
+
+	switch {
+	case wind.Variable && varFrom == 0 && varTo == 0:
+		// Any direction is possible, including straight across or behind
+		wind.WorstCrosswindKt = worstSpeed
+		wind.WorstTailwindKt = worstSpeed
+		wind.CrosswindKt = wind.WindSpeedKt
+		return wind
+	case varFrom != 0 || varTo != 0:
+		wind.Variable = true
+	}
+
+	wind.HeadwindKt, wind.CrosswindKt = WindComponents(current.Wind.Direction, wind.WindSpeedKt, runwayHeading)
+	directions := []int{current.Wind.Direction}
+	if wind.Variable {
+		directions = directionRange(varFrom, varTo)
+	}
+	for _, direction := range directions {
+		head, cross := WindComponents(direction, worstSpeed, runwayHeading)
+		wind.WorstCrosswindKt = math.Max(wind.WorstCrosswindKt, math.Abs(cross))
+		wind.WorstTailwindKt = math.Max(wind.WorstTailwindKt, -head)
+	}
+	return wind
+}
+
+// directionRange lists each whole degree from from clockwise to to
+func directionRange(from, to int) []int {
+	span := ((to-from)%360 + 360) % 360
+	directions := make([]int, 0, span+1)
+	for i := 0; i <= span; i++ {
+		directions = append(directions, (from+i)%360)
+	}
+	return directions
+}
@@ -0,0 +1,169 @@
+package clients
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultMaxResponseSize is used for APIs that do not configure their own limit
+const DefaultMaxResponseSize int64 = 100 << 20
+
+// ErrResponseTooLarge is returned when a decoded response body exceeds the
+// API's MaxResponseSize
+var ErrResponseTooLarge = errors.New("response too large")
+
+// maxResponseSize returns the response size limit for an API config
+func maxResponseSize(config APIConfig) int64 {
+	if config.MaxResponseSize > 0 {
+		return config.MaxResponseSize
+	}
+	return DefaultMaxResponseSize
+}
+
+// limitedBody reads at most max bytes from the underlying body and fails
+// with ErrResponseTooLarge once more are available
+type limitedBody struct {
+	r         io.Reader
+	closers   []io.Closer
+	remaining int64
+	max       int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Probe for a single extra byte to tell a body of exactly max bytes
+		// apart from an oversized one
+		var probe [1]byte
+		if n, _ := b.r.Read(probe[:]); n > 0 {
+			return 0, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, b.max)
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+	if err == io.EOF && b.remaining <= 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	var firstErr error
+	for _, c := range b.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// responseBody returns the decompressed, size limited body of resp. Closing
+// it closes resp.Body.
+func responseBody(resp *http.Response, max int64) (io.ReadCloser, error) {
+	var r io.Reader = resp.Body
+	closers := []io.Closer{resp.Body}
+
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && !resp.Uncompressed {
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			resp.Body.Close()
+			return nil, fmt.Errorf("error decompressing response: %w", err)
+		}
+		r = gz
+		closers = append([]io.Closer{gz}, closers...)
+	}
+
+	return &limitedBody{r: r, closers: closers, remaining: max, max: max}, nil
+}
+
+// GetStream makes a GET request to the specified API and returns the response
+// body for incremental decoding. The caller must close the body. Streamed
+// requests bypass the response cache and are not retried once the response
+// has started.
+func (f *Fetcher) GetStream(ctx context.Context, apiName, endpoint string, params map[string]string) (io.ReadCloser, error) {
+	config, exists := f.getConfig(apiName)
+	if !exists {
+		return nil, fmt.Errorf("unknown API: %s", apiName)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if body, handled, err := f.intercept(apiName, config, endpoint, params); handled {
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	ring := f.keyRingFor(apiName, config)
+	keyIndex := -1
+	if ring != nil {
+		key, i, err := ring.current(apiName)
+		if err != nil {
+			return nil, err
+		}
+		config.APIKey, keyIndex = key, i
+	}
+
+	u, err := buildURL(config, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.waitRateLimit(ctx, apiName); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %w", err)
+	}
+	setHeaders(req, config, nil)
+
+	log.Printf("Streaming GET request to URL: %s", RedactURL(u))
+	done := f.observe(apiName, endpoint)
+	resp, err := f.clientFor(apiName).Do(req)
+	if err != nil {
+		err = fmt.Errorf("error sending request: %w", redactError(err))
+		done(0, err)
+		return nil, err
+	}
+
+	body, err := responseBody(resp, maxResponseSize(config))
+	if err != nil {
+		done(resp.StatusCode, err)
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer body.Close()
+		if resp.StatusCode == http.StatusTooManyRequests {
+			err = newRateLimitError(apiName, resp.Header, time.Now())
+		} else {
+			// Error bodies are short, keep only enough to report
+			msg, _ := io.ReadAll(io.LimitReader(body, 4096))
+			err = &HTTPStatusError{StatusCode: resp.StatusCode, Body: msg}
+		}
+		done(resp.StatusCode, err)
+
+		// Rotate away from a rejected key so the next call can succeed
+		if reason, until, ok := keyFailure(&Response{StatusCode: resp.StatusCode}); ok && ring != nil {
+			ring.fail(apiName, keyIndex, reason, until)
+		}
+		return nil, err
+	}
+
+	done(resp.StatusCode, nil)
+	return body, nil
+}
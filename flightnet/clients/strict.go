@@ -0,0 +1,147 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ParseWarning describes a record skipped by strict parsing, or a field of a
+// kept record that could not be converted
+type ParseWarning struct {
+	Index   int             `json:"index"` // Position of the record in the response
+	Reason  string          `json:"reason"`
+	Raw     json.RawMessage `json:"raw"`     // Only set in strict mode
+	Skipped bool            `json:"skipped"` // Whether the record was left out of the result
+}
+
+// String implements fmt.Stringer
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("record %d: %s", w.Index, w.Reason)
+}
+
+// ParseOption configures a parse call
+type ParseOption func(*parseOptions)
+
+// parseOptions holds the settings built from ParseOptions
+type parseOptions struct {
+	strict   bool
+	warnings *[]ParseWarning
+}
+
+// WithStrictParsing rejects records that contain fields the target type
+// does not declare or that are missing required fields. Rejected records are
+// left out of the result and appended to warnings, which may be nil.
+func WithStrictParsing(warnings *[]ParseWarning) ParseOption {
+	return func(o *parseOptions) {
+		o.strict = true
+		o.warnings = warnings
+	}
+}
+
+// WithWarnings collects problems converting fields of records that are kept,
+// such as an unparseable date, without enabling strict parsing
+func WithWarnings(warnings *[]ParseWarning) ParseOption {
+	return func(o *parseOptions) {
+		o.warnings = warnings
+	}
+}
+
+// newParseOptions applies opts to the default, lenient settings
+func newParseOptions(opts []ParseOption) parseOptions {
+	var options parseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// decode reads the next value from dec into v. It reports false when strict
+// mode rejected the record, which is recorded as a warning rather than
+// returned as an error.
+func (o parseOptions) decode(dec *json.Decoder, v interface{}, index int) (bool, error) {
+	if !o.strict {
+		if err := dec.Decode(v); err != nil {
+			return false, err
+		}
+		o.derive(v, index, nil)
+		return true, nil
+	}
+
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
+		return false, err
+	}
+	if err := decodeStrict(raw, v); err != nil {
+		warning := ParseWarning{Index: index, Reason: err.Error(), Raw: raw, Skipped: true}
+		log.Printf("Skipping invalid record: %s", warning)
+		if o.warnings != nil {
+			*o.warnings = append(*o.warnings, warning)
+		}
+		return false, nil
+	}
+	o.derive(v, index, raw)
+	return true, nil
+}
+
+// deriver is implemented by types with fields computed from the decoded ones
+type deriver interface {
+	derive() []string
+}
+
+// derive fills in the derived fields of v when it is a deriver, recording
+// conversion problems as warnings
+func (o parseOptions) derive(v interface{}, index int, raw json.RawMessage) {
+	d, ok := v.(deriver)
+	if !ok {
+		return
+	}
+	for _, problem := range d.derive() {
+		if o.warnings != nil {
+			*o.warnings = append(*o.warnings, ParseWarning{Index: index, Reason: problem, Raw: raw})
+		}
+	}
+}
+
+// validator is implemented by types with required fields
+type validator interface {
+	validate() error
+}
+
+// decodeStrict unmarshals data into v, failing on unknown fields and, when v
+// is a validator, on missing required fields
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	if val, ok := v.(validator); ok {
+		return val.validate()
+	}
+	return nil
+}
+
+// validate implements validator
+func (f *Flight) validate() error {
+	if f.Flight.Number == "" && f.Flight.IataNumber == "" && f.Flight.IcaoNumber == "" {
+		return errors.New("missing flight number")
+	}
+	if f.Departure.IataCode == "" {
+		return errors.New("missing departure IATA code")
+	}
+	return nil
+}
+
+// validate implements validator
+func (a *Aircraft) validate() error {
+	if a.NumberRegistration == "" {
+		return errors.New("missing registration")
+	}
+	return nil
+}
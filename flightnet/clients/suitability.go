@@ -0,0 +1,200 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SuitabilityCriteria are the weather limits for flight operations. A zero
+// field is not checked.
+type SuitabilityCriteria struct {
+	MaxWindKt            float64  // Sustained wind
+	MaxGustKt            float64  // Gusts
+	MinVisibilityMiles   float64  // Statute miles
+	RunwayHeadingDeg     int      // Runway heading, 1-360, for the crosswind check and SuitabilityResult.RunwayWind
+	MaxCrosswindKt       float64  // Crosswind component on RunwayHeadingDeg, gusts and variable directions included
+	DisallowedCategories []string // Flight categories such as IFR or LIFR
+	MaxPrecipitation     string   // Heaviest allowed precipitation: none, light, moderate or heavy
+}
+
+// DefaultSuitabilityCriteria are the limits IsWeatherSuitableForFlight uses
+var DefaultSuitabilityCriteria = SuitabilityCriteria{
+	MaxWindKt:            35,
+	MinVisibilityMiles:   3,
+	DisallowedCategories: []string{"IFR", "LIFR"},
+}
+
+// CriterionFailure is a criterion the weather did not meet
+type CriterionFailure struct {
+	Criterion string `json:"criterion"` // wind, gust, visibility, crosswind, category or precipitation
+	Measured  string `json:"measured"`
+	Threshold string `json:"threshold"`
+}
+
+func (c CriterionFailure) String() string {
+	return fmt.Sprintf("%s %s (limit %s)", c.Criterion, c.Measured, c.Threshold)
+}
+
+// SuitabilityResult reports whether the weather at an airport meets a set
+// of criteria
+type SuitabilityResult struct {
+	Airport  string             `json:"airport"`
+	Suitable bool               `json:"suitable"`
+	Failures []CriterionFailure `json:"failures"`
+	// Unchecked lists criteria that could not be evaluated because the
+	// weather report lacks the measurement. They do not make the result
+	// unsuitable.
+	Unchecked  []string     `json:"unchecked,omitempty"`
+	RunwayWind *RunwayWind  `json:"runway_wind,omitempty"` // Set when the criteria give a runway heading
+	Weather    *WeatherData `json:"weather"`
+}
+
+// Summary describes the result in one sentence
+func (r *SuitabilityResult) Summary() string {
+	if r.Suitable {
+		return "Weather conditions suitable for flight"
+	}
+	reasons := make([]string, len(r.Failures))
+	for i, failure := range r.Failures {
+		reasons[i] = failure.String()
+	}
+	return "Unsuitable due to: " + strings.Join(reasons, "; ")
+}
+
+// precipitationLevels orders precipitation intensities
+var precipitationLevels = map[string]int{"none": 0, "light": 1, "moderate": 2, "heavy": 3}
+
+// Validate checks the criteria are consistent
+func (c SuitabilityCriteria) Validate() error {
+	if c.MaxCrosswindKt > 0 && (c.RunwayHeadingDeg < 1 || c.RunwayHeadingDeg > 360) {
+		return fmt.Errorf("invalid suitability criteria: crosswind limit needs a runway heading between 1 and 360, got %d", c.RunwayHeadingDeg)
+	}
+	if _, ok := precipitationLevels[strings.ToLower(c.MaxPrecipitation)]; c.MaxPrecipitation != "" && !ok {
+		return fmt.Errorf("invalid suitability criteria: max precipitation must be none, light, moderate or heavy, got %q", c.MaxPrecipitation)
+	}
+	return nil
+}
+
+// IsWeatherSuitableForFlight checks the weather at an airport against
+// DefaultSuitabilityCriteria
+func (w *WeatherAPI) IsWeatherSuitableForFlight(airportCode string) (*SuitabilityResult, error) {
+	return w.IsWeatherSuitableForFlightWithCriteriaContext(context.Background(), airportCode, DefaultSuitabilityCriteria)
+}
+
+// IsWeatherSuitableForFlightContext checks the weather at an airport against
+// DefaultSuitabilityCriteria, aborting when ctx is done
+func (w *WeatherAPI) IsWeatherSuitableForFlightContext(ctx context.Context, airportCode string) (*SuitabilityResult, error) {
+	return w.IsWeatherSuitableForFlightWithCriteriaContext(ctx, airportCode, DefaultSuitabilityCriteria)
+}
+
+// IsWeatherSuitableForFlightWithCriteria checks the weather at an airport
+// against c
+func (w *WeatherAPI) IsWeatherSuitableForFlightWithCriteria(airportCode string, c SuitabilityCriteria) (*SuitabilityResult, error) {
+	return w.IsWeatherSuitableForFlightWithCriteriaContext(context.Background(), airportCode, c)
+}
+
+// IsWeatherSuitableForFlightWithCriteriaContext checks the weather at an
+// airport against c, aborting when ctx is done
+func (w *WeatherAPI) IsWeatherSuitableForFlightWithCriteriaContext(ctx context.Context, airportCode string, c SuitabilityCriteria) (*SuitabilityResult, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	weather, err := w.GetCurrentWeatherContext(ctx, airportCode)
+	if err != nil {
+		return nil, err
+	}
+	result := CheckSuitability(weather, c)
+	result.Airport = airportCode
+	return result, nil
+}
+
+// CheckSuitability evaluates a weather report against c
+func CheckSuitability(weather *WeatherData, c SuitabilityCriteria) *SuitabilityResult {
+	result := &SuitabilityResult{Airport: weather.AirportIATA, Failures: []CriterionFailure{}, Weather: weather}
+	current := &weather.CurrentWeather
+	fail := func(criterion, measured, threshold string) {
+		result.Failures = append(result.Failures, CriterionFailure{criterion, measured, threshold})
+	}
+
+	windKt, windKnown := knots(current.Wind.Speed, current.Wind.Unit), current.Wind.Unit != "" || current.Wind.Speed > 0
+	if c.MaxWindKt > 0 {
+		if !windKnown {
+			result.Unchecked = append(result.Unchecked, "wind")
+		} else if windKt > c.MaxWindKt {
+			fail("wind", formatKnots(windKt), formatKnots(c.MaxWindKt))
+		}
+	}
+	if c.MaxGustKt > 0 {
+		if gustKt := knots(current.Wind.Gusts, current.Wind.Unit); !windKnown {
+			result.Unchecked = append(result.Unchecked, "gust")
+		} else if gustKt > c.MaxGustKt {
+			fail("gust", formatKnots(gustKt), formatKnots(c.MaxGustKt))
+		}
+	}
+	if windKnown && c.RunwayHeadingDeg >= 1 && c.RunwayHeadingDeg <= 360 {
+		result.RunwayWind = runwayWind(weather, c.RunwayHeadingDeg)
+	}
+	if c.MaxCrosswindKt > 0 {
+		if wind := result.RunwayWind; wind == nil {
+			result.Unchecked = append(result.Unchecked, "crosswind")
+		} else if wind.WorstCrosswindKt > c.MaxCrosswindKt {
+			measured := formatKnots(wind.WorstCrosswindKt)
+			if wind.Variable {
+				measured += " (variable wind, worst case)"
+			}
+			fail("crosswind", measured, formatKnots(c.MaxCrosswindKt))
+		}
+	}
+
+	if c.MinVisibilityMiles > 0 {
+		if current.Visibility.Miles <= 0 {
+			result.Unchecked = append(result.Unchecked, "visibility")
+		} else if current.Visibility.Miles < c.MinVisibilityMiles {
+			fail("visibility", fmt.Sprintf("%.1f mi", current.Visibility.Miles), fmt.Sprintf("%.1f mi", c.MinVisibilityMiles))
+		}
+	}
+
+	if len(c.DisallowedCategories) > 0 {
+		if current.WeatherCategory == "" {
+			result.Unchecked = append(result.Unchecked, "category")
+		}
+		for _, category := range c.DisallowedCategories {
+			if current.WeatherCategory != "" && strings.EqualFold(current.WeatherCategory, category) {
+				fail("category", current.WeatherCategory, "not "+strings.Join(c.DisallowedCategories, "/"))
+				break
+			}
+		}
+	}
+
+	if c.MaxPrecipitation != "" {
+		if report, err := ParseMETAR(current.METAR); current.METAR == "" || err != nil {
+			result.Unchecked = append(result.Unchecked, "precipitation")
+		} else if intensity := report.PrecipitationIntensity(); precipitationLevels[intensity] > precipitationLevels[strings.ToLower(c.MaxPrecipitation)] {
+			fail("precipitation", intensity, strings.ToLower(c.MaxPrecipitation))
+		}
+	}
+
+	result.Suitable = len(result.Failures) == 0
+	return result
+}
+
+// knots converts a wind speed in unit (KT, MPS, KMH, KPH or MPH) to knots.
+// Speeds without a unit are taken as knots.
+func knots(speed float64, unit string) float64 {
+	switch strings.ToUpper(unit) {
+	case "MPS":
+		return speed * 1.943844
+	case "KMH", "KPH":
+		return speed / 1.852
+	case "MPH":
+		return speed * 0.868976
+	default:
+		return speed
+	}
+}
+
+// formatKnots formats a speed in knots for a CriterionFailure
+func formatKnots(kt float64) string {
+	return fmt.Sprintf("%.0f kt", kt)
+}
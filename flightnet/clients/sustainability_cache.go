@@ -0,0 +1,152 @@
+package clients
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultEmissionsCacheTTL is how long calculated emissions are reused
+// unless SetCacheTTL is called. Emissions for a fixed route and aircraft do
+// not change.
+const DefaultEmissionsCacheTTL = 24 * time.Hour
+
+// emissionsCacheEntry is cached emissions data for one route or aircraft
+type emissionsCacheEntry struct {
+	data    *SustainabilityData
+	expires time.Time
+}
+
+// emissionsCache is an in-memory TTL cache of emissions data keyed by the
+// request's parameters
+type emissionsCache struct {
+	mu      sync.Mutex
+	entries map[string]emissionsCacheEntry
+	hits    uint64
+	misses  uint64
+}
+
+// newEmissionsCache creates an empty cache
+func newEmissionsCache() *emissionsCache {
+	return &emissionsCache{entries: make(map[string]emissionsCacheEntry)}
+}
+
+// emissionsCacheKey joins a request kind and its parameters, ignoring case
+func emissionsCacheKey(kind string, params ...string) string {
+	for i, param := range params {
+		params[i] = strings.ToUpper(strings.TrimSpace(param))
+	}
+	return kind + "|" + strings.Join(params, "|")
+}
+
+// get returns a copy of the cached data for key if it has not expired
+func (c *emissionsCache) get(key string) (*SustainabilityData, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || !time.Now().Before(entry.expires) {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return entry.data.clone(), true
+}
+
+// put caches a copy of data under key for ttl, dropping expired entries
+func (c *emissionsCache) put(key string, data *SustainabilityData, ttl time.Duration) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = emissionsCacheEntry{data: data.clone(), expires: now.Add(ttl)}
+}
+
+// clear removes every entry
+func (c *emissionsCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]emissionsCacheEntry)
+}
+
+// stats returns the current counters
+func (c *emissionsCache) stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: entries,
+	}
+}
+
+// clone returns a copy that shares no pointers with data, so callers cannot
+// modify cached data
+func (data *SustainabilityData) clone() *SustainabilityData {
+	c := *data
+	if data.Offset != nil {
+		offset := *data.Offset
+		c.Offset = &offset
+	}
+	if data.FuelBlend != nil {
+		blend := *data.FuelBlend
+		c.FuelBlend = &blend
+	}
+	return &c
+}
+
+// SetCacheTTL sets how long calculated emissions are cached per route and
+// aircraft. Zero restores DefaultEmissionsCacheTTL and a negative TTL
+// disables the cache.
+func (s *SustainabilityAPI) SetCacheTTL(ttl time.Duration) {
+	s.mu.Lock()
+	s.cacheTTL = ttl
+	s.mu.Unlock()
+}
+
+// ClearCache drops all cached emissions
+func (s *SustainabilityAPI) ClearCache() {
+	s.cache.clear()
+}
+
+// CacheStats returns emissions cache hit and miss counters
+func (s *SustainabilityAPI) CacheStats() CacheStats {
+	return s.cache.stats()
+}
+
+// cachedEmissions returns the cached emissions for key, calling calculate on
+// a miss. Cached copies are marked with their age. Synthetic data is never
+// cached so live data is picked up as soon as the APIs recover.
+func (s *SustainabilityAPI) cachedEmissions(key string, calculate func() (*SustainabilityData, error)) (*SustainabilityData, error) {
+	s.mu.Lock()
+	ttl := s.cacheTTL
+	s.mu.Unlock()
+	if ttl == 0 {
+		ttl = DefaultEmissionsCacheTTL
+	}
+	if ttl < 0 {
+		return calculate()
+	}
+
+	if data, ok := s.cache.get(key); ok {
+		data.Cached = true
+		data.CacheAgeSeconds = int(time.Since(data.LastCalculated).Seconds())
+		return data, nil
+	}
+
+	data, err := calculate()
+	if err != nil {
+		return nil, err
+	}
+	if !data.Synthetic {
+		s.cache.put(key, data, ttl)
+	}
+	return data, nil
+}
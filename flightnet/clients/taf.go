@@ -0,0 +1,351 @@
+package clients
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TAF change group types
+const (
+	TAFBase  = "BASE"  // Conditions from the start of the report
+	TAFFrom  = "FM"    // Rapid change, replaces all prevailing conditions
+	TAFBecmg = "BECMG" // Gradual change, complete by the end of the period
+	TAFTempo = "TEMPO" // Temporary fluctuations during the period
+	TAFProb  = "PROB"  // Probability of conditions, optionally temporary
+)
+
+// TAFPeriod is one forecast group of a TAF
+type TAFPeriod struct {
+	Type        string // TAFBase, TAFFrom, TAFBecmg, TAFTempo or TAFProb
+	Probability int    // 30 or 40 for PROB groups
+	Temporary   bool   // TEMPO, including PROB30 TEMPO
+	From        time.Time
+	To          time.Time
+	Raw         string
+
+	WindDirection        int
+	WindVariable         bool
+	WindSpeed            int
+	WindGust             int
+	WindUnit             string
+	VisibilityMeters     float64
+	CAVOK                bool
+	Clouds               []CloudLayer
+	VerticalVisibilityFt int
+	Weather              []string
+
+	// Which elements the group forecasts, BECMG groups only change these
+	hasWind, hasVisibility, hasClouds, hasWeather bool
+}
+
+// TAFReport is a decoded terminal aerodrome forecast
+type TAFReport struct {
+	Raw       string
+	Station   string
+	IssueTime time.Time
+	ValidFrom time.Time
+	ValidTo   time.Time
+	Amended   bool // AMD
+	Corrected bool // COR
+	Periods   []TAFPeriod
+}
+
+// TAFConditions are the forecast conditions resolved for a point in time
+type TAFConditions struct {
+	Time time.Time
+	// Prevailing holds the base, FM and completed BECMG conditions
+	Prevailing TAFPeriod
+	// Possible lists TEMPO, PROB and in-progress BECMG groups covering Time
+	Possible []TAFPeriod
+}
+
+var (
+	tafValidityRe = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	tafFromRe     = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	tafProbRe     = regexp.MustCompile(`^PROB(\d{2})$`)
+	tafTempRe     = regexp.MustCompile(`^T[XN]M?\d{2}/\d{4}Z$`)
+)
+
+// ParseTAF decodes a raw TAF. Day-of-month times are resolved relative to
+// the current month.
+func ParseTAF(raw string) (*TAFReport, error) {
+	return parseTAFAt(raw, time.Now().UTC())
+}
+
+// parseTAFAt decodes raw, resolving day-of-month times against now
+func parseTAFAt(raw string, now time.Time) (*TAFReport, error) {
+	tokens := strings.Fields(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	if len(tokens) > 0 && tokens[0] == "TAF" {
+		tokens = tokens[1:]
+	}
+
+	report := &TAFReport{Raw: strings.TrimSpace(raw)}
+	for len(tokens) > 0 && (tokens[0] == "AMD" || tokens[0] == "COR") {
+		report.Amended = report.Amended || tokens[0] == "AMD"
+		report.Corrected = report.Corrected || tokens[0] == "COR"
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) < 2 {
+		return nil, fmt.Errorf("TAF too short: %q", raw)
+	}
+	report.Station = tokens[0]
+	if len(report.Station) != 4 || strings.ToUpper(report.Station) != report.Station {
+		return nil, fmt.Errorf("invalid TAF station %q", tokens[0])
+	}
+	tokens = tokens[1:]
+
+	// The issue time anchors every later day-of-month
+	anchor := now
+	if m := metarTimeRe.FindStringSubmatch(tokens[0]); m != nil {
+		day, _ := strconv.Atoi(m[1])
+		hour, _ := strconv.Atoi(m[2])
+		minute, _ := strconv.Atoi(m[3])
+		report.IssueTime = resolveDayTime(now, day, hour, minute)
+		anchor = report.IssueTime
+		tokens = tokens[1:]
+	}
+
+	if len(tokens) == 0 || !tafValidityRe.MatchString(tokens[0]) {
+		return nil, fmt.Errorf("TAF %s has no validity period", report.Station)
+	}
+	from, to, err := parseTAFValidity(tokens[0], anchor)
+	if err != nil {
+		return nil, err
+	}
+	report.ValidFrom, report.ValidTo = from, to
+	tokens = tokens[1:]
+
+	current := &TAFPeriod{Type: TAFBase, From: from, To: to}
+	var raws []string
+	flush := func() {
+		current.Raw = strings.Join(raws, " ")
+		report.Periods = append(report.Periods, *current)
+		raws = nil
+	}
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+
+		switch {
+		case tok == "RMK":
+			i = len(tokens)
+			continue
+		case tafFromRe.MatchString(tok):
+			flush()
+			m := tafFromRe.FindStringSubmatch(tok)
+			day, _ := strconv.Atoi(m[1])
+			hour, _ := strconv.Atoi(m[2])
+			minute, _ := strconv.Atoi(m[3])
+			start := resolveTAFTime(anchor, day, hour, minute)
+			current = &TAFPeriod{Type: TAFFrom, From: start, To: report.ValidTo}
+		case tok == "BECMG" || tok == "TEMPO" || tafProbRe.MatchString(tok):
+			flush()
+			current = &TAFPeriod{Type: tok}
+			if m := tafProbRe.FindStringSubmatch(tok); m != nil {
+				current.Type = TAFProb
+				current.Probability, _ = strconv.Atoi(m[1])
+				if i+1 < len(tokens) && tokens[i+1] == "TEMPO" {
+					current.Temporary = true
+					raws = append(raws, tok)
+					i++
+					tok = tokens[i]
+				}
+			}
+			if tok == "TEMPO" {
+				current.Temporary = true
+			}
+			if i+1 < len(tokens) && tafValidityRe.MatchString(tokens[i+1]) {
+				if current.From, current.To, err = parseTAFValidity(tokens[i+1], anchor); err != nil {
+					return nil, err
+				}
+				raws = append(raws, tok)
+				i++
+				tok = tokens[i]
+			}
+		default:
+			current.decode(tok, tokens, &i, &raws)
+			continue
+		}
+		raws = append(raws, tok)
+	}
+	flush()
+
+	// FM groups run until the next FM group
+	for i := range report.Periods {
+		if report.Periods[i].Type != TAFFrom && report.Periods[i].Type != TAFBase {
+			continue
+		}
+		for j := i + 1; j < len(report.Periods); j++ {
+			if report.Periods[j].Type == TAFFrom {
+				report.Periods[i].To = report.Periods[j].From
+				break
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// decode applies a weather group to the period, advancing i past any
+// additional tokens it consumes
+func (p *TAFPeriod) decode(tok string, tokens []string, i *int, raws *[]string) {
+	*raws = append(*raws, tok)
+
+	switch {
+	case metarWindRe.MatchString(tok):
+		m := metarWindRe.FindStringSubmatch(tok)
+		p.hasWind = true
+		p.WindVariable = m[1] == "VRB"
+		p.WindDirection, _ = strconv.Atoi(m[1])
+		p.WindSpeed, _ = strconv.Atoi(m[2])
+		p.WindGust, _ = strconv.Atoi(m[3])
+		p.WindUnit = m[4]
+	case tok == "CAVOK":
+		p.hasVisibility, p.hasClouds, p.hasWeather = true, true, true
+		p.CAVOK = true
+		p.VisibilityMeters = 10000
+	case metarWholeMilesRe.MatchString(tok) && *i+1 < len(tokens) && strings.HasSuffix(tokens[*i+1], "SM"):
+		whole, _ := strconv.Atoi(tok)
+		if miles, _, ok := parseStatuteMiles(tokens[*i+1]); ok {
+			p.hasVisibility = true
+			p.VisibilityMeters = (float64(whole) + miles) * 1609.344
+			*raws = append(*raws, tokens[*i+1])
+			*i++
+		}
+	case strings.HasSuffix(tok, "SM"):
+		if miles, _, ok := parseStatuteMiles(tok); ok {
+			p.hasVisibility = true
+			p.VisibilityMeters = miles * 1609.344
+		}
+	case metarVisMetersRe.MatchString(tok):
+		meters, _ := strconv.Atoi(metarVisMetersRe.FindStringSubmatch(tok)[1])
+		p.hasVisibility = true
+		p.VisibilityMeters = float64(meters)
+		if meters == 9999 {
+			p.VisibilityMeters = 10000
+		}
+	case tok == "SKC" || tok == "NSC" || tok == "CLR":
+		p.hasClouds = true
+		p.Clouds = nil
+	case metarCloudRe.MatchString(tok):
+		m := metarCloudRe.FindStringSubmatch(tok)
+		layer := CloudLayer{Coverage: m[1], Description: cloudCoverage[m[1]]}
+		if height, err := strconv.Atoi(m[2]); err == nil {
+			layer.AltitudeFt = height * 100
+			layer.AltitudeM = int(float64(layer.AltitudeFt) * 0.3048)
+		}
+		if m[3] == "CB" {
+			layer.Description += ", cumulonimbus"
+		}
+		p.hasClouds = true
+		p.Clouds = append(p.Clouds, layer)
+	case metarVVRe.MatchString(tok):
+		if height, err := strconv.Atoi(metarVVRe.FindStringSubmatch(tok)[1]); err == nil {
+			p.hasClouds = true
+			p.VerticalVisibilityFt = height * 100
+		}
+	case tok == "NSW":
+		// No significant weather, ends earlier phenomena
+		p.hasWeather = true
+		p.Weather = nil
+	case isWeatherCode(tok):
+		p.hasWeather = true
+		p.Weather = append(p.Weather, tok)
+	case tafTempRe.MatchString(tok):
+		// Max/min temperature groups are not decoded
+	}
+}
+
+// overlay applies the elements a BECMG group forecasts to prevailing conditions
+func (p *TAFPeriod) overlay(change TAFPeriod) {
+	if change.hasWind {
+		p.hasWind = true
+		p.WindDirection, p.WindVariable = change.WindDirection, change.WindVariable
+		p.WindSpeed, p.WindGust, p.WindUnit = change.WindSpeed, change.WindGust, change.WindUnit
+	}
+	if change.hasVisibility {
+		p.hasVisibility = true
+		p.VisibilityMeters, p.CAVOK = change.VisibilityMeters, change.CAVOK
+	}
+	if change.hasClouds {
+		p.hasClouds = true
+		p.Clouds, p.VerticalVisibilityFt = change.Clouds, change.VerticalVisibilityFt
+	}
+	if change.hasWeather {
+		p.hasWeather = true
+		p.Weather = change.Weather
+	}
+}
+
+// ConditionsAt resolves the forecast for t. It fails when t is outside the
+// TAF's validity period.
+func (r *TAFReport) ConditionsAt(t time.Time) (*TAFConditions, error) {
+	if t.Before(r.ValidFrom) || !t.Before(r.ValidTo) {
+		return nil, fmt.Errorf("%v is outside the TAF validity %v to %v", t, r.ValidFrom, r.ValidTo)
+	}
+
+	conditions := &TAFConditions{Time: t}
+	for _, period := range r.Periods {
+		switch period.Type {
+		case TAFBase:
+			conditions.Prevailing = period
+		case TAFFrom:
+			if !t.Before(period.From) {
+				conditions.Prevailing = period
+			}
+		case TAFBecmg:
+			switch {
+			case !t.Before(period.To):
+				conditions.Prevailing.overlay(period)
+			case !t.Before(period.From):
+				conditions.Possible = append(conditions.Possible, period)
+			}
+		case TAFTempo, TAFProb:
+			if !t.Before(period.From) && t.Before(period.To) {
+				conditions.Possible = append(conditions.Possible, period)
+			}
+		}
+	}
+	return conditions, nil
+}
+
+// parseTAFValidity parses a DDHH/DDHH period
+func parseTAFValidity(tok string, anchor time.Time) (from, to time.Time, err error) {
+	m := tafValidityRe.FindStringSubmatch(tok)
+	if m == nil {
+		return from, to, fmt.Errorf("invalid TAF period %q", tok)
+	}
+	fromDay, _ := strconv.Atoi(m[1])
+	fromHour, _ := strconv.Atoi(m[2])
+	toDay, _ := strconv.Atoi(m[3])
+	toHour, _ := strconv.Atoi(m[4])
+	if fromHour > 24 || toHour > 24 {
+		return from, to, fmt.Errorf("invalid TAF period %q", tok)
+	}
+
+	from = resolveTAFTime(anchor, fromDay, fromHour, 0)
+	to = resolveTAFTime(anchor, toDay, toHour, 0)
+	if !to.After(from) {
+		return from, to, fmt.Errorf("TAF period %q ends before it starts", tok)
+	}
+	return from, to, nil
+}
+
+// resolveTAFTime resolves a day-of-month and time in the month of anchor, or
+// the adjacent month when the day is far from the anchor's, e.g. a 3118/0124
+// TAF. Hour 24 is the end of the day.
+func resolveTAFTime(anchor time.Time, day, hour, minute int) time.Time {
+	month := anchor.Month()
+	switch {
+	case day < anchor.Day()-7:
+		month++
+	case day > anchor.Day()+7:
+		month--
+	}
+	return time.Date(anchor.Year(), month, day, 0, 0, 0, 0, time.UTC).
+		Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
@@ -0,0 +1,180 @@
+package clients
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoTravelAdvisory is returned for countries the State Department issues
+// no travel advisory for, such as the United States itself
+var ErrNoTravelAdvisory = errors.New("no travel advisory")
+
+// travelAdvisoryFeed is the State Department's RSS feed of travel advisories
+const travelAdvisoryFeed = "_res/rss/TAsTWs.xml"
+
+// Travel advisory sources
+const (
+	TravelAdvisorySourceStateDept = "U.S. Department of State"
+	TravelAdvisorySourceStatic    = "static" // Built-in table, used when the feed is unreachable
+)
+
+// advisoryLevels are the State Department's advisory levels, by number
+var advisoryLevels = [...]string{
+	1: "Exercise Normal Precautions",
+	2: "Exercise Increased Caution",
+	3: "Reconsider Travel",
+	4: "Do Not Travel",
+}
+
+// advisoryTitle matches feed item titles such as "Iran - Level 4: Do Not Travel"
+var advisoryTitle = regexp.MustCompile(`^(.+?)\s+-\s+Level\s+([1-4])\b`)
+
+// staticTravelAdvisories are the advisory levels of the countries with the
+// highest advisories, used when the feed cannot be read
+var staticTravelAdvisories = map[string]int{
+	"AF": 4, "BY": 4, "BF": 4, "MM": 4, "CF": 4, "HT": 4, "IR": 4, "IQ": 4,
+	"LB": 4, "LY": 4, "ML": 4, "KP": 4, "RU": 4, "SO": 4, "SS": 4, "SD": 4,
+	"SY": 4, "UA": 4, "VE": 4, "YE": 4,
+	"CO": 3, "NE": 3, "NG": 3, "PK": 3, "TD": 3, "IL": 3,
+	"CN": 2, "FR": 2, "DE": 2, "GB": 2, "IN": 2, "ZA": 2, "BR": 2,
+	"CA": 1, "JP": 1, "AU": 1,
+}
+
+// TravelAdvisory is the State Department's travel advisory for a country
+type TravelAdvisory struct {
+	CountryISO2 string `json:"country_iso2"`
+	Level       int    `json:"level"` // 1 (exercise normal precautions) to 4 (do not travel)
+	Summary     string `json:"summary"`
+	UpdatedAt   string `json:"updated_at,omitempty"` // RFC 3339
+	Source      string `json:"source"`
+}
+
+// LevelDescription is the name of the advisory level, e.g. "Do Not Travel"
+func (a *TravelAdvisory) LevelDescription() string {
+	if a.Level < 1 || a.Level >= len(advisoryLevels) {
+		return ""
+	}
+	return advisoryLevels[a.Level]
+}
+
+// TravelAdvisoryAPI reads the State Department's travel advisories
+type TravelAdvisoryAPI struct {
+	fetcher *Fetcher
+}
+
+// NewTravelAdvisoryAPI creates a new TravelAdvisoryAPI instance
+func NewTravelAdvisoryAPI() *TravelAdvisoryAPI {
+	return NewTravelAdvisoryAPIWithFetcher(NewFetcher())
+}
+
+// NewTravelAdvisoryAPIWithFetcher creates a TravelAdvisoryAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewTravelAdvisoryAPIWithFetcher(fetcher *Fetcher) *TravelAdvisoryAPI {
+	return &TravelAdvisoryAPI{fetcher: fetcher}
+}
+
+// GetAdvisories fetches every current travel advisory, by ISO2 country code
+func (t *TravelAdvisoryAPI) GetAdvisories() (map[string]*TravelAdvisory, error) {
+	return t.GetAdvisoriesContext(context.Background())
+}
+
+// GetAdvisoriesContext fetches every current travel advisory, aborting when
+// ctx is done. The feed is cached by the Fetcher for 12 hours.
+func (t *TravelAdvisoryAPI) GetAdvisoriesContext(ctx context.Context) (map[string]*TravelAdvisory, error) {
+	data, err := t.fetcher.GetContext(ctx, "state-dept", travelAdvisoryFeed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch travel advisories: %w", err)
+	}
+	return parseTravelAdvisories(data)
+}
+
+// GetAdvisory fetches a country's travel advisory
+func (t *TravelAdvisoryAPI) GetAdvisory(country string) (*TravelAdvisory, error) {
+	return t.GetAdvisoryContext(context.Background(), country)
+}
+
+// GetAdvisoryContext fetches a country's travel advisory, aborting when ctx
+// is done. country may be any code or name NormalizeCountry accepts. When
+// the feed is unreachable the advisory comes from a built-in table, with
+// Source set to "static". Countries without an advisory return an error
+// matching ErrNoTravelAdvisory.
+func (t *TravelAdvisoryAPI) GetAdvisoryContext(ctx context.Context, country string) (*TravelAdvisory, error) {
+	record, err := lookupCountry(country)
+	if err != nil {
+		return nil, err
+	}
+	advisories, err := t.GetAdvisoriesContext(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		log.Printf("Travel advisory feed unavailable, using static advisory for %s: %v", record.iso2, err)
+		return staticTravelAdvisory(record.iso2)
+	}
+	advisory, ok := advisories[record.iso2]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNoTravelAdvisory, record.iso2)
+	}
+	return advisory, nil
+}
+
+// staticTravelAdvisory returns a country's advisory from the built-in table
+func staticTravelAdvisory(iso2 string) (*TravelAdvisory, error) {
+	level, ok := staticTravelAdvisories[iso2]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s in the static table", ErrNoTravelAdvisory, iso2)
+	}
+	return &TravelAdvisory{
+		CountryISO2: iso2,
+		Level:       level,
+		Summary:     fmt.Sprintf("Level %d: %s", level, advisoryLevels[level]),
+		Source:      TravelAdvisorySourceStatic,
+	}, nil
+}
+
+// parseTravelAdvisories parses the advisory feed. Items whose title names no
+// known country, such as combined advisories for several territories, are
+// skipped.
+func parseTravelAdvisories(data []byte) (map[string]*TravelAdvisory, error) {
+	var doc rssDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse travel advisories: %w", err)
+	}
+	if doc.XMLName.Local != "rss" {
+		return nil, fmt.Errorf("not an RSS feed: root element %q", doc.XMLName.Local)
+	}
+
+	advisories := make(map[string]*TravelAdvisory)
+	for _, item := range doc.Channel.Items {
+		m := advisoryTitle.FindStringSubmatch(plainText(item.Title))
+		if m == nil {
+			continue
+		}
+		country, err := lookupCountry(m[1])
+		if err != nil {
+			// Titles such as "Burma (Myanmar)" add another name in parentheses
+			name, _, _ := strings.Cut(m[1], "(")
+			if country, err = lookupCountry(name); err != nil {
+				continue
+			}
+		}
+		level, _ := strconv.Atoi(m[2])
+		advisories[country.iso2] = &TravelAdvisory{
+			CountryISO2: country.iso2,
+			Level:       level,
+			Summary:     plainText(item.Description),
+			UpdatedAt:   feedTime(item.PubDate),
+			Source:      TravelAdvisorySourceStateDept,
+		}
+	}
+	if len(advisories) == 0 {
+		return nil, errors.New("no travel advisories in feed")
+	}
+	return advisories, nil
+}
@@ -1,8 +1,15 @@
 package clients
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
 )
 
 // WeatherData represents airport weather information
@@ -21,6 +28,7 @@ type WeatherData struct {
 		Wind struct {
 			Direction int     `json:"direction"`
 			Speed     float64 `json:"speed"`
+			Gusts     float64 `json:"gusts,omitempty"`
 			Unit      string  `json:"unit"`
 		} `json:"wind"`
 		Visibility struct {
@@ -38,8 +46,12 @@ type WeatherData struct {
 		CloudCover      []CloudLayer `json:"cloud_cover"`
 		WeatherCategory string       `json:"weather_category"`
 	} `json:"current_weather"`
-	Forecast    []ForecastData `json:"forecast"`
-	LastUpdated string         `json:"last_updated"`
+	Forecast    []ForecastData  `json:"forecast"`
+	Derived     *DerivedMetrics `json:"derived,omitempty"` // Set for current weather
+	LastUpdated string          `json:"last_updated"`
+	ObservedAt  time.Time       `json:"observed_at"` // When the METAR was observed, zero when unknown, e.g. for synthetic data
+	Synthetic   bool            `json:"synthetic"`   // Mock data returned because the API was unavailable
+	Source      string          `json:"source"`      // aviation-edge, noaa or mock
 }
 
 // CloudLayer represents cloud layer information
@@ -68,74 +80,130 @@ type ForecastData struct {
 	Conditions string  `json:"conditions"`
 }
 
+// DefaultWeatherConcurrency is how many airports GetMultipleAirportsWeather
+// fetches at once unless SetConcurrency is called
+const DefaultWeatherConcurrency = 5
+
 // WeatherAPI handles airport weather data
 type WeatherAPI struct {
-	fetcher *Fetcher
-	parser  *Parser
+	fetcher     *Fetcher
+	parser      *Parser
+	concurrency int          // Parallel fetches in GetMultipleAirportsWeather, zero for the default
+	airports    *AirportsAPI // Field elevations for DerivedMetrics
+	cache       *weatherCache
+	cacheTTL    time.Duration // Current weather cache lifetime, zero for the default, negative to disable
 }
 
 // NewWeatherAPI creates a new WeatherAPI instance
 func NewWeatherAPI() *WeatherAPI {
+	return NewWeatherAPIWithFetcher(NewFetcher())
+}
+
+// NewWeatherAPIWithFetcher creates a WeatherAPI that sends requests through fetcher,
+// so one configured Fetcher can be shared between clients
+func NewWeatherAPIWithFetcher(fetcher *Fetcher) *WeatherAPI {
 	return &WeatherAPI{
-		fetcher: NewFetcher(),
-		parser:  NewParser(),
+		fetcher:  fetcher,
+		parser:   NewParser(),
+		airports: NewAirportsAPIWithFetcher(fetcher),
+		cache:    newWeatherCache(),
 	}
 }
 
-// GetCurrentWeather fetches current weather for an airport
-func (w *WeatherAPI) GetCurrentWeather(airportCode string) (*WeatherData, error) {
+// GetCurrentWeather fetches current weather for an airport. Results are
+// cached per airport for the cache TTL unless ForceRefresh is given.
+func (w *WeatherAPI) GetCurrentWeather(airportCode string, opts ...WeatherOption) (*WeatherData, error) {
+	return w.GetCurrentWeatherContext(context.Background(), airportCode, opts...)
+}
+
+// GetCurrentWeatherContext fetches current weather for an airport, aborting when ctx is done.
+// aviation-edge is tried first, then NOAA, and mock data is returned only when both fail.
+func (w *WeatherAPI) GetCurrentWeatherContext(ctx context.Context, airportCode string, opts ...WeatherOption) (*WeatherData, error) {
 	params := map[string]string{
 		"iataCode": airportCode,
 	}
+	return w.cachedWeather(ctx, airportCode, opts, func(ctx context.Context) (*WeatherData, error) {
+		return w.fetchCurrentWeather(ctx, airportCode, params)
+	})
+}
 
-	data, err := w.fetcher.Get("aviation-edge", "airportWeather", params)
-	if err != nil {
-		return w.getMockWeatherData(airportCode), nil
-	}
+// GetWeatherByICAO fetches weather using ICAO code, cached like GetCurrentWeather
+func (w *WeatherAPI) GetWeatherByICAO(icaoCode string, opts ...WeatherOption) (*WeatherData, error) {
+	return w.GetWeatherByICAOContext(context.Background(), icaoCode, opts...)
+}
 
-	var weather WeatherData
-	if err := json.Unmarshal(data, &weather); err != nil {
-		return w.getMockWeatherData(airportCode), nil
+// GetWeatherByICAOContext fetches weather using ICAO code, aborting when ctx is done
+func (w *WeatherAPI) GetWeatherByICAOContext(ctx context.Context, icaoCode string, opts ...WeatherOption) (*WeatherData, error) {
+	params := map[string]string{
+		"icaoCode": icaoCode,
 	}
+	return w.cachedWeather(ctx, icaoCode, opts, func(ctx context.Context) (*WeatherData, error) {
+		return w.fetchCurrentWeather(ctx, icaoCode, params)
+	})
+}
 
-	return &weather, nil
+// GetWeatherForecast fetches weather forecast for an airport
+func (w *WeatherAPI) GetWeatherForecast(airportCode string) (*WeatherData, error) {
+	return w.GetWeatherForecastContext(context.Background(), airportCode)
 }
 
-// GetWeatherByICAO fetches weather using ICAO code
-func (w *WeatherAPI) GetWeatherByICAO(icaoCode string) (*WeatherData, error) {
+// GetWeatherForecastContext fetches weather forecast for an airport, aborting when ctx is done
+func (w *WeatherAPI) GetWeatherForecastContext(ctx context.Context, airportCode string) (*WeatherData, error) {
 	params := map[string]string{
-		"icaoCode": icaoCode,
+		"iataCode": airportCode,
+		"forecast": "true",
 	}
+	return w.fetchWeather(ctx, airportCode, params, true)
+}
 
-	data, err := w.fetcher.Get("aviation-edge", "airportWeather", params)
+// fetchCurrentWeather fetches current weather and adds its derived metrics
+func (w *WeatherAPI) fetchCurrentWeather(ctx context.Context, airportCode string, params map[string]string) (*WeatherData, error) {
+	weather, err := w.fetchWeather(ctx, airportCode, params, false)
 	if err != nil {
-		return w.getMockWeatherData(icaoCode), nil
+		return nil, err
 	}
-
-	var weather WeatherData
-	if err := json.Unmarshal(data, &weather); err != nil {
-		return w.getMockWeatherData(icaoCode), nil
+	if err := w.addDerivedMetrics(ctx, airportCode, weather); err != nil {
+		return nil, err
 	}
-
-	return &weather, nil
+	return weather, nil
 }
 
-// GetWeatherForecast fetches weather forecast for an airport
-func (w *WeatherAPI) GetWeatherForecast(airportCode string) (*WeatherData, error) {
-	params := map[string]string{
-		"iataCode": airportCode,
-		"forecast": "true",
+// fetchWeather fetches weather from aviation-edge with params, falling back
+// to NOAA and then to synthetic data. Only ctx ending is returned as an
+// error.
+func (w *WeatherAPI) fetchWeather(ctx context.Context, airportCode string, params map[string]string, forecast bool) (*WeatherData, error) {
+	weather, err := w.aviationEdgeWeather(ctx, params)
+	if err == nil {
+		return weather, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
+	log.Printf("aviation-edge weather unavailable for %s, trying NOAA: %v", airportCode, err)
 
-	data, err := w.fetcher.Get("aviation-edge", "airportWeather", params)
+	weather, noaaErr := w.noaaWeather(ctx, airportCode, forecast)
+	if noaaErr == nil {
+		return weather, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return w.syntheticWeather(airportCode, forecast, errors.Join(err, noaaErr)), nil
+}
+
+// aviationEdgeWeather fetches and decodes weather from aviation-edge
+func (w *WeatherAPI) aviationEdgeWeather(ctx context.Context, params map[string]string) (*WeatherData, error) {
+	data, err := w.fetcher.GetContext(ctx, "aviation-edge", "airportWeather", params)
 	if err != nil {
-		return w.getMockWeatherDataWithForecast(airportCode), nil
+		return nil, err
 	}
 
 	var weather WeatherData
 	if err := json.Unmarshal(data, &weather); err != nil {
-		return w.getMockWeatherDataWithForecast(airportCode), nil
+		return nil, fmt.Errorf("failed to parse weather: %w", err)
 	}
+	weather.Source = WeatherSourceAviationEdge
+	reconcileFlightCategory(&weather, fillFromMETAR(&weather))
 
 	return &weather, nil
 }
@@ -167,6 +235,45 @@ func (w *WeatherAPI) GetTAF(airportCode string) ([]string, error) {
 	return tafReports, nil
 }
 
+// GetForecastAtTime fetches the TAF for an airport and resolves the forecast
+// conditions at t
+func (w *WeatherAPI) GetForecastAtTime(airportCode string, t time.Time) (*TAFConditions, error) {
+	return w.GetForecastAtTimeContext(context.Background(), airportCode, t)
+}
+
+// GetForecastAtTimeContext fetches the TAF for an airport and resolves the
+// forecast conditions at t, aborting when ctx is done. When several TAFs are
+// returned the first one valid at t is used.
+func (w *WeatherAPI) GetForecastAtTimeContext(ctx context.Context, airportCode string, t time.Time) (*TAFConditions, error) {
+	weather, err := w.GetWeatherForecastContext(ctx, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, forecast := range weather.Forecast {
+		if forecast.TAF == "" {
+			continue
+		}
+		report, err := ParseTAF(forecast.TAF)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conditions, err := report.ConditionsAt(t)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return conditions, nil
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("no usable TAF for %s: %w", airportCode, lastErr)
+	}
+	return nil, fmt.Errorf("no TAF available for %s", airportCode)
+}
+
 // GetWeatherConditions gets simplified weather conditions
 func (w *WeatherAPI) GetWeatherConditions(airportCode string) (map[string]interface{}, error) {
 	weather, err := w.GetCurrentWeather(airportCode)
@@ -191,57 +298,167 @@ func (w *WeatherAPI) GetWeatherConditions(airportCode string) (map[string]interf
 	return conditions, nil
 }
 
-// GetMultipleAirportsWeather fetches weather for multiple airports
-func (w *WeatherAPI) GetMultipleAirportsWeather(airportCodes []string) (map[string]*WeatherData, error) {
-	results := make(map[string]*WeatherData)
+// GetMultipleAirportsWeather fetches weather for multiple airports in
+// parallel. Airports that failed are left out of the results and reported in
+// the failures map instead. opts apply to every airport.
+func (w *WeatherAPI) GetMultipleAirportsWeather(airportCodes []string, opts ...WeatherOption) (map[string]*WeatherData, map[string]error, error) {
+	return w.GetMultipleAirportsWeatherContext(context.Background(), airportCodes, opts...)
+}
+
+// GetMultipleAirportsWeatherContext fetches weather for multiple airports in
+// parallel, aborting the whole batch when ctx is done
+func (w *WeatherAPI) GetMultipleAirportsWeatherContext(ctx context.Context, airportCodes []string, opts ...WeatherOption) (map[string]*WeatherData, map[string]error, error) {
+	var (
+		mu       sync.Mutex
+		results  = make(map[string]*WeatherData)
+		failures = make(map[string]error)
+	)
+
+	codes := make(chan string)
+	workers := w.concurrency
+	if workers <= 0 {
+		workers = DefaultWeatherConcurrency
+	}
+	if workers > len(airportCodes) {
+		workers = len(airportCodes)
+	}
 
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for code := range codes {
+				weather, err := w.GetCurrentWeatherContext(ctx, code, opts...)
+				mu.Lock()
+				if err != nil {
+					failures[code] = err
+				} else {
+					results[code] = weather
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	seen := make(map[string]bool, len(airportCodes))
+feed:
 	for _, code := range airportCodes {
-		weather, err := w.GetCurrentWeather(code)
-		if err != nil {
-			// Continue with other airports even if one fails
-			results[code] = nil
+		if seen[code] {
 			continue
 		}
-		results[code] = weather
+		seen[code] = true
+		select {
+		case codes <- code:
+		case <-ctx.Done():
+			break feed
+		}
 	}
+	close(codes)
+	wg.Wait()
 
-	return results, nil
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	return results, failures, nil
 }
 
-// IsWeatherSuitableForFlight checks if weather conditions are suitable for flight operations
-func (w *WeatherAPI) IsWeatherSuitableForFlight(airportCode string) (bool, string, error) {
-	weather, err := w.GetCurrentWeather(airportCode)
+// SetConcurrency sets how many airports GetMultipleAirportsWeather fetches
+// at once. Zero or less restores DefaultWeatherConcurrency.
+func (w *WeatherAPI) SetConcurrency(n int) {
+	w.concurrency = n
+}
+
+// fillFromMETAR populates structured current weather fields the API left
+// empty from the raw METAR. Fields the API did provide are kept. It returns
+// the METAR's flight category, or "" when there is no usable METAR.
+func fillFromMETAR(weather *WeatherData) string {
+	current := &weather.CurrentWeather
+	if current.METAR == "" {
+		return ""
+	}
+	report, err := ParseMETAR(current.METAR)
 	if err != nil {
-		return false, "", err
+		log.Printf("Could not parse METAR for %s: %v", weather.AirportICAO, err)
+		return ""
 	}
 
-	// Basic weather suitability checks
-	reasons := []string{}
-
-	// Check visibility (minimum 3 miles for VFR)
-	if weather.CurrentWeather.Visibility.Miles < 3.0 {
-		reasons = append(reasons, "Low visibility")
+	if weather.AirportICAO == "" {
+		weather.AirportICAO = report.Station
 	}
-
-	// Check wind speed (assuming 35 knots is maximum for most aircraft)
-	if weather.CurrentWeather.Wind.Speed > 35.0 {
-		reasons = append(reasons, "High wind speed")
+	if weather.ObservedAt.IsZero() {
+		weather.ObservedAt = report.ObservationTime
+	}
+	if current.Temperature.Celsius == 0 && current.Temperature.Fahrenheit == 0 && report.TemperatureC != nil {
+		current.Temperature.Celsius = *report.TemperatureC
+		current.Temperature.Fahrenheit = *report.TemperatureC*9/5 + 32
+	}
+	if current.Wind.Speed == 0 && current.Wind.Direction == 0 && report.WindUnit != "" {
+		current.Wind.Direction = report.WindDirection
+		current.Wind.Speed = float64(report.WindSpeed)
+		current.Wind.Unit = report.WindUnit
+	}
+	if current.Wind.Gusts == 0 && report.WindGust > 0 && strings.EqualFold(current.Wind.Unit, report.WindUnit) {
+		current.Wind.Gusts = float64(report.WindGust)
+	}
+	if current.Visibility.Miles == 0 && current.Visibility.Meters == 0 && report.VisibilityMeters > 0 {
+		current.Visibility.Meters = report.VisibilityMeters
+		current.Visibility.Miles = report.VisibilityMeters / 1609.344
+	}
+	if current.Pressure.HPa == 0 && current.Pressure.InHg == 0 && report.AltimeterHPa != nil {
+		current.Pressure.HPa = *report.AltimeterHPa
+		current.Pressure.Millibar = *report.AltimeterHPa
+		current.Pressure.KPa = *report.AltimeterHPa / 10
+		current.Pressure.InHg = *report.AltimeterInHg
 	}
+	if current.Humidity == 0 && report.TemperatureC != nil && report.DewpointC != nil {
+		current.Humidity = relativeHumidity(*report.TemperatureC, *report.DewpointC)
+	}
+	if len(current.CloudCover) == 0 {
+		current.CloudCover = report.Clouds
+	}
+	if current.Conditions == "" {
+		current.Conditions = report.Conditions()
+	}
+	return report.FlightCategory()
+}
 
-	// Check weather category
-	if weather.CurrentWeather.WeatherCategory == "LIFR" || weather.CurrentWeather.WeatherCategory == "IFR" {
-		reasons = append(reasons, "Poor weather category")
+// reconcileFlightCategory sets WeatherCategory from the observation when the
+// API left it empty or it disagrees with the raw data. observed is the
+// METAR's category; without one the category is computed from the
+// visibility and cloud layers, provided the visibility is known.
+func reconcileFlightCategory(weather *WeatherData, observed string) {
+	current := &weather.CurrentWeather
+	if observed == "" {
+		if current.Visibility.Miles <= 0 {
+			return
+		}
+		observed, _ = ComputeFlightCategory(current.Visibility.Miles, current.CloudCover)
 	}
 
-	suitable := len(reasons) == 0
-	reasonStr := ""
-	if !suitable {
-		reasonStr = fmt.Sprintf("Unsuitable due to: %v", reasons)
-	} else {
-		reasonStr = "Weather conditions suitable for flight"
+	if current.WeatherCategory != "" && !strings.EqualFold(current.WeatherCategory, observed) {
+		log.Printf("Weather category %s for %s disagrees with observation, using %s",
+			current.WeatherCategory, weather.AirportICAO, observed)
 	}
+	current.WeatherCategory = observed
+}
 
-	return suitable, reasonStr, nil
+// relativeHumidity estimates relative humidity in percent from temperature
+// and dewpoint using the Magnus formula
+func relativeHumidity(tempC, dewpointC float64) float64 {
+	const b, c = 17.625, 243.04
+	rh := 100 * math.Exp(b*dewpointC/(c+dewpointC)-b*tempC/(c+tempC))
+	return math.Round(rh*10) / 10
+}
+
+// syntheticWeather logs why live weather is unavailable and returns mock
+// data flagged as synthetic in its place
+func (w *WeatherAPI) syntheticWeather(airportCode string, forecast bool, err error) *WeatherData {
+	log.Printf("Weather unavailable for %s, returning synthetic data: %v", airportCode, err)
+	if forecast {
+		return w.getMockWeatherDataWithForecast(airportCode)
+	}
+	return w.getMockWeatherData(airportCode)
 }
 
 // getMockWeatherData returns mock weather data for testing
@@ -261,6 +478,7 @@ func (w *WeatherAPI) getMockWeatherData(airportCode string) *WeatherData {
 			Wind struct {
 				Direction int     `json:"direction"`
 				Speed     float64 `json:"speed"`
+				Gusts     float64 `json:"gusts,omitempty"`
 				Unit      string  `json:"unit"`
 			} `json:"wind"`
 			Visibility struct {
@@ -289,6 +507,7 @@ func (w *WeatherAPI) getMockWeatherData(airportCode string) *WeatherData {
 			Wind: struct {
 				Direction int     `json:"direction"`
 				Speed     float64 `json:"speed"`
+				Gusts     float64 `json:"gusts,omitempty"`
 				Unit      string  `json:"unit"`
 			}{
 				Direction: 270,
@@ -327,6 +546,8 @@ func (w *WeatherAPI) getMockWeatherData(airportCode string) *WeatherData {
 		},
 		Forecast:    []ForecastData{},
 		LastUpdated: "2025-06-28T13:32:00Z",
+		Synthetic:   true,
+		Source:      WeatherSourceMock,
 	}
 }
 
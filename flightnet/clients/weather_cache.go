@@ -0,0 +1,164 @@
+package clients
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultWeatherCacheTTL is how long current weather is reused unless
+// SetCacheTTL is called. METARs are issued every 30 to 60 minutes.
+const DefaultWeatherCacheTTL = 10 * time.Minute
+
+// WeatherOption adjusts a single current weather request
+type WeatherOption func(*weatherOptions)
+
+// weatherOptions collects the options of a request
+type weatherOptions struct {
+	forceRefresh bool
+}
+
+// ForceRefresh skips the weather cache and fetches fresh data, which then
+// replaces the cached copy
+func ForceRefresh() WeatherOption {
+	return func(o *weatherOptions) {
+		o.forceRefresh = true
+	}
+}
+
+// weatherCacheEntry is cached current weather for one airport
+type weatherCacheEntry struct {
+	weather *WeatherData
+	expires time.Time
+}
+
+// weatherCache is an in-memory TTL cache of current weather keyed by
+// airport code
+type weatherCache struct {
+	mu      sync.Mutex
+	entries map[string]weatherCacheEntry
+	hits    uint64
+	misses  uint64
+}
+
+// newWeatherCache creates an empty cache
+func newWeatherCache() *weatherCache {
+	return &weatherCache{entries: make(map[string]weatherCacheEntry)}
+}
+
+// get returns a copy of the cached weather for key if it has not expired
+func (c *weatherCache) get(key string) (*WeatherData, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if !ok || !time.Now().Before(entry.expires) {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	atomic.AddUint64(&c.hits, 1)
+	return entry.weather.clone(), true
+}
+
+// put caches a copy of weather under key for ttl, dropping expired entries
+func (c *weatherCache) put(key string, weather *WeatherData, ttl time.Duration) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = weatherCacheEntry{weather: weather.clone(), expires: now.Add(ttl)}
+}
+
+// clear removes every entry
+func (c *weatherCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]weatherCacheEntry)
+}
+
+// stats returns the current counters
+func (c *weatherCache) stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:    atomic.LoadUint64(&c.hits),
+		Misses:  atomic.LoadUint64(&c.misses),
+		Entries: entries,
+	}
+}
+
+// clone returns a copy that shares no slices with weather, so callers
+// cannot modify cached data
+func (weather *WeatherData) clone() *WeatherData {
+	c := *weather
+	c.CurrentWeather.CloudCover = append([]CloudLayer(nil), weather.CurrentWeather.CloudCover...)
+	c.Forecast = append([]ForecastData(nil), weather.Forecast...)
+	if weather.Derived != nil {
+		derived := *weather.Derived
+		if weather.Derived.Omitted != nil {
+			derived.Omitted = make(map[string]string, len(weather.Derived.Omitted))
+			for metric, reason := range weather.Derived.Omitted {
+				derived.Omitted[metric] = reason
+			}
+		}
+		c.Derived = &derived
+	}
+	return &c
+}
+
+// SetCacheTTL sets how long current weather is cached per airport. Zero
+// restores DefaultWeatherCacheTTL and a negative TTL disables the cache.
+func (w *WeatherAPI) SetCacheTTL(ttl time.Duration) {
+	w.cacheTTL = ttl
+}
+
+// ClearCache drops all cached weather
+func (w *WeatherAPI) ClearCache() {
+	w.cache.clear()
+}
+
+// CacheStats returns weather cache hit and miss counters
+func (w *WeatherAPI) CacheStats() CacheStats {
+	return w.cache.stats()
+}
+
+// cachedWeather returns the cached current weather for airportCode, calling
+// fetch on a miss or when opts force a refresh. Synthetic data is never
+// cached so live data is picked up as soon as the APIs recover.
+func (w *WeatherAPI) cachedWeather(ctx context.Context, airportCode string, opts []WeatherOption, fetch func(context.Context) (*WeatherData, error)) (*WeatherData, error) {
+	var o weatherOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	ttl := w.cacheTTL
+	if ttl == 0 {
+		ttl = DefaultWeatherCacheTTL
+	}
+	if ttl < 0 {
+		return fetch(ctx)
+	}
+
+	key := strings.ToUpper(strings.TrimSpace(airportCode))
+	if !o.forceRefresh {
+		if weather, ok := w.cache.get(key); ok {
+			return weather, nil
+		}
+	}
+
+	weather, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !weather.Synthetic {
+		w.cache.put(key, weather, ttl)
+	}
+	return weather, nil
+}
@@ -0,0 +1,166 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// humidityTolerance is how far, in percentage points, a reported humidity may
+// differ from the one computed from temperature and dewpoint before it is
+// flagged
+const humidityTolerance = 5.0
+
+// DerivedMetrics are performance-relevant values computed from a weather
+// report and the airport's field elevation. A metric that cannot be computed
+// is left out and its reason recorded in Omitted.
+type DerivedMetrics struct {
+	FieldElevationFt   *float64 `json:"field_elevation_ft,omitempty"`
+	PressureAltitudeFt *float64 `json:"pressure_altitude_ft,omitempty"`
+	DensityAltitudeFt  *float64 `json:"density_altitude_ft,omitempty"`
+	DewpointC          *float64 `json:"dewpoint_c,omitempty"`
+	DewpointSpreadC    *float64 `json:"dewpoint_spread_c,omitempty"` // Temperature minus dewpoint; a small spread means fog or low cloud is likely
+	ComputedHumidity   *float64 `json:"computed_humidity,omitempty"` // Relative humidity from temperature and dewpoint
+	// HumidityMismatch is set when the reported humidity differs from
+	// ComputedHumidity by more than five percentage points
+	HumidityMismatch bool              `json:"humidity_mismatch,omitempty"`
+	Omitted          map[string]string `json:"omitted,omitempty"` // Metric name to the reason it is missing
+}
+
+// PressureAltitude returns the pressure altitude in feet of a field at
+// elevationFt with altimeter setting qnhHPa, using the standard atmosphere
+func PressureAltitude(elevationFt, qnhHPa float64) float64 {
+	return elevationFt + 145366.45*(1-math.Pow(qnhHPa/1013.25, 0.190284))
+}
+
+// DensityAltitude returns the density altitude in feet of a field at
+// elevationFt with altimeter setting qnhHPa and temperature tempC. It adds
+// 118.8 ft per degree above the standard temperature at the pressure
+// altitude, so standard conditions return the pressure altitude.
+func DensityAltitude(elevationFt, qnhHPa, tempC float64) float64 {
+	pressureAltitude := PressureAltitude(elevationFt, qnhHPa)
+	isaTempC := 15 - 1.98*pressureAltitude/1000
+	return pressureAltitude + 118.8*(tempC-isaTempC)
+}
+
+// GetDensityAltitude returns the current density altitude at an airport in feet
+func (w *WeatherAPI) GetDensityAltitude(airportCode string) (float64, error) {
+	return w.GetDensityAltitudeContext(context.Background(), airportCode)
+}
+
+// GetDensityAltitudeContext returns the current density altitude at an
+// airport in feet, aborting when ctx is done
+func (w *WeatherAPI) GetDensityAltitudeContext(ctx context.Context, airportCode string) (float64, error) {
+	weather, err := w.GetCurrentWeatherContext(ctx, airportCode)
+	if err != nil {
+		return 0, err
+	}
+	if weather.Derived == nil || weather.Derived.DensityAltitudeFt == nil {
+		reason := "no derived metrics"
+		if weather.Derived != nil {
+			reason = weather.Derived.Omitted["density_altitude_ft"]
+		}
+		return 0, fmt.Errorf("density altitude unavailable for %s: %s", airportCode, reason)
+	}
+	return *weather.Derived.DensityAltitudeFt, nil
+}
+
+// addDerivedMetrics looks up the airport's elevation and sets
+// weather.Derived. Only ctx ending is returned as an error; an unknown
+// elevation is recorded as the reason the altitudes are omitted.
+func (w *WeatherAPI) addDerivedMetrics(ctx context.Context, airportCode string, weather *WeatherData) error {
+	var elevation *float64
+	elevationErr := errors.New("synthetic weather has no airport")
+	if !weather.Synthetic {
+		ft, err := w.airports.GetElevationContext(ctx, airportCode)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			elevation = &ft
+		}
+		elevationErr = err
+	}
+	weather.Derived = deriveMetrics(weather, elevation, elevationErr)
+	return nil
+}
+
+// deriveMetrics computes the derived metrics of a weather report.
+// elevationFt is nil when the field elevation is unknown, with elevationErr
+// saying why.
+func deriveMetrics(weather *WeatherData, elevationFt *float64, elevationErr error) *DerivedMetrics {
+	current := &weather.CurrentWeather
+	derived := &DerivedMetrics{Omitted: map[string]string{}}
+	omit := func(metric, reason string) {
+		derived.Omitted[metric] = reason
+	}
+
+	// Temperature and pressure are zero when missing; 0 °C is a real
+	// reading, so a zero Fahrenheit value alongside tells them apart
+	tempC := current.Temperature.Celsius
+	tempKnown := tempC != 0 || current.Temperature.Fahrenheit != 0
+	qnhHPa := current.Pressure.HPa
+	if qnhHPa == 0 && current.Pressure.InHg > 0 {
+		qnhHPa = current.Pressure.InHg * 33.8639
+	}
+
+	switch {
+	case elevationFt == nil:
+		reason := "field elevation unknown"
+		if elevationErr != nil {
+			reason += ": " + elevationErr.Error()
+		}
+		omit("pressure_altitude_ft", reason)
+		omit("density_altitude_ft", reason)
+	case qnhHPa <= 0:
+		derived.FieldElevationFt = elevationFt
+		omit("pressure_altitude_ft", "altimeter setting not reported")
+		omit("density_altitude_ft", "altimeter setting not reported")
+	default:
+		derived.FieldElevationFt = elevationFt
+		pressureAltitude := math.Round(PressureAltitude(*elevationFt, qnhHPa))
+		derived.PressureAltitudeFt = &pressureAltitude
+		if tempKnown {
+			densityAltitude := math.Round(DensityAltitude(*elevationFt, qnhHPa, tempC))
+			derived.DensityAltitudeFt = &densityAltitude
+		} else {
+			omit("density_altitude_ft", "temperature not reported")
+		}
+	}
+
+	// The METAR's dewpoint is preferred; without one it is recovered from
+	// the reported humidity
+	var dewpoint *float64
+	if current.METAR != "" {
+		if report, err := ParseMETAR(current.METAR); err == nil && report.DewpointC != nil {
+			dewpoint = report.DewpointC
+		}
+	}
+	if dewpoint == nil && tempKnown && current.Humidity > 0 {
+		d := dewpointFromHumidity(tempC, current.Humidity)
+		dewpoint = &d
+	}
+	if dewpoint == nil || !tempKnown {
+		omit("dewpoint_spread_c", "temperature or dewpoint not reported")
+		omit("computed_humidity", "temperature or dewpoint not reported")
+	} else {
+		d := math.Round(*dewpoint*10) / 10
+		spread := math.Round((tempC-*dewpoint)*10) / 10
+		humidity := relativeHumidity(tempC, *dewpoint)
+		derived.DewpointC, derived.DewpointSpreadC, derived.ComputedHumidity = &d, &spread, &humidity
+		derived.HumidityMismatch = current.Humidity > 0 && math.Abs(current.Humidity-humidity) > humidityTolerance
+	}
+
+	if len(derived.Omitted) == 0 {
+		derived.Omitted = nil
+	}
+	return derived
+}
+
+// dewpointFromHumidity inverts the Magnus formula used by relativeHumidity
+func dewpointFromHumidity(tempC, humidity float64) float64 {
+	const b, c = 17.625, 243.04
+	gamma := math.Log(humidity/100) + b*tempC/(c+tempC)
+	return c * gamma / (b - gamma)
+}
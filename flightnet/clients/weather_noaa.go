@@ -0,0 +1,216 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Weather sources reported in WeatherData.Source
+const (
+	WeatherSourceAviationEdge = "aviation-edge"
+	WeatherSourceNOAA         = "noaa"
+	WeatherSourceMock         = "mock"
+)
+
+// noaaMETAR is one observation from the aviationweather.gov METAR endpoint
+// in JSON format
+type noaaMETAR struct {
+	ICAOID     string          `json:"icaoId"`
+	Name       string          `json:"name"`
+	Lat        float64         `json:"lat"`
+	Lon        float64         `json:"lon"`
+	ReportTime string          `json:"reportTime"`
+	ObsTime    int64           `json:"obsTime"` // Unix seconds
+	Temp       *float64        `json:"temp"`
+	Dewp       *float64        `json:"dewp"`
+	Wdir       json.RawMessage `json:"wdir"` // Degrees, or "VRB"
+	Wspd       float64         `json:"wspd"`
+	Wgst       float64         `json:"wgst"`
+	Visib      json.RawMessage `json:"visib"` // Statute miles, or "10+"
+	Altim      float64         `json:"altim"` // hPa
+	RawOb      string          `json:"rawOb"`
+	FltCat     string          `json:"fltCat"`
+	Clouds     []struct {
+		Cover string `json:"cover"`
+		Base  *int   `json:"base"`
+	} `json:"clouds"`
+}
+
+// noaaTAF is one forecast from the aviationweather.gov TAF endpoint in JSON
+// format
+type noaaTAF struct {
+	ICAOID        string `json:"icaoId"`
+	RawTAF        string `json:"rawTAF"`
+	ValidTimeFrom int64  `json:"validTimeFrom"` // Unix seconds
+	ValidTimeTo   int64  `json:"validTimeTo"`
+}
+
+// noaaStationID returns the ICAO identifier NOAA knows an airport by. ICAO
+// codes are used as given and IATA codes are looked up in the embedded
+// airport dataset.
+func noaaStationID(airportCode string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(airportCode))
+	if isAirportCode(code, 4) {
+		return code, nil
+	}
+	if airport, ok := fallbackAirports().byIATA[code]; ok && airport.ICAO != "" {
+		return airport.ICAO, nil
+	}
+	return "", fmt.Errorf("no ICAO code known for %s: %w", airportCode, ErrAirportNotFound)
+}
+
+// noaaWeather fetches the latest METAR for an airport from NOAA, and its TAF
+// when forecast is set
+func (w *WeatherAPI) noaaWeather(ctx context.Context, airportCode string, forecast bool) (*WeatherData, error) {
+	station, err := noaaStationID(airportCode)
+	if err != nil {
+		return nil, err
+	}
+	params := map[string]string{"ids": station, "format": "json"}
+
+	data, err := w.fetcher.GetContext(ctx, "noaa", "metar", params)
+	if err != nil {
+		return nil, fmt.Errorf("NOAA METAR request for %s failed: %w", station, err)
+	}
+	var metars []noaaMETAR
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &metars); err != nil {
+			return nil, fmt.Errorf("failed to parse NOAA METAR for %s: %w", station, err)
+		}
+	}
+	if len(metars) == 0 {
+		return nil, fmt.Errorf("NOAA has no METAR for %s", station)
+	}
+
+	weather := metars[0].weatherData()
+	if code := strings.ToUpper(strings.TrimSpace(airportCode)); len(code) == 3 {
+		weather.AirportIATA = code
+	} else if airport, ok := fallbackAirports().byICAO[station]; ok {
+		weather.AirportIATA = airport.IATA
+	}
+
+	if forecast {
+		data, err := w.fetcher.GetContext(ctx, "noaa", "taf", params)
+		if err != nil {
+			return nil, fmt.Errorf("NOAA TAF request for %s failed: %w", station, err)
+		}
+		var tafs []noaaTAF
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &tafs); err != nil {
+				return nil, fmt.Errorf("failed to parse NOAA TAF for %s: %w", station, err)
+			}
+		}
+		for _, taf := range tafs {
+			weather.Forecast = append(weather.Forecast, taf.forecastData())
+		}
+	}
+
+	reconcileFlightCategory(weather, fillFromMETAR(weather))
+	return weather, nil
+}
+
+// weatherData converts the observation to WeatherData. Anything left empty
+// is filled from the raw METAR afterwards by fillFromMETAR.
+func (m *noaaMETAR) weatherData() *WeatherData {
+	weather := &WeatherData{
+		AirportICAO: m.ICAOID,
+		AirportName: m.Name,
+		Latitude:    m.Lat,
+		Longitude:   m.Lon,
+		LastUpdated: m.ReportTime,
+		Source:      WeatherSourceNOAA,
+		Forecast:    []ForecastData{},
+	}
+	if m.ObsTime > 0 {
+		weather.ObservedAt = time.Unix(m.ObsTime, 0).UTC()
+	}
+	current := &weather.CurrentWeather
+	current.METAR = m.RawOb
+
+	if m.Temp != nil {
+		current.Temperature.Celsius = *m.Temp
+		current.Temperature.Fahrenheit = *m.Temp*9/5 + 32
+		if m.Dewp != nil {
+			current.Humidity = relativeHumidity(*m.Temp, *m.Dewp)
+		}
+	}
+
+	// A variable direction is left at zero, which is how the METAR parser
+	// reports VRB
+	var direction int
+	if json.Unmarshal(m.Wdir, &direction) == nil {
+		current.Wind.Direction = direction
+	}
+	current.Wind.Speed = m.Wspd
+	current.Wind.Gusts = m.Wgst
+	current.Wind.Unit = "KT"
+
+	if miles, ok := noaaVisibility(m.Visib); ok {
+		current.Visibility.Miles = miles
+		current.Visibility.Meters = math.Round(miles * 1609.344)
+	}
+
+	if m.Altim > 0 {
+		current.Pressure.HPa = m.Altim
+		current.Pressure.Millibar = m.Altim
+		current.Pressure.KPa = m.Altim / 10
+		current.Pressure.InHg = math.Round(m.Altim/33.8639*100) / 100
+	}
+
+	// The raw METAR's layers carry more detail, such as CB and vertical
+	// visibility, so the JSON layers are only used without one
+	if m.RawOb == "" {
+		for _, cloud := range m.Clouds {
+			layer := CloudLayer{Coverage: cloud.Cover, Description: cloudCoverage[cloud.Cover]}
+			if cloud.Base != nil {
+				layer.AltitudeFt = *cloud.Base
+				layer.AltitudeM = int(float64(layer.AltitudeFt) * 0.3048)
+			}
+			current.CloudCover = append(current.CloudCover, layer)
+		}
+	}
+	current.WeatherCategory = m.FltCat
+	return weather
+}
+
+// noaaVisibility decodes NOAA's visibility, a number of statute miles or a
+// string such as "10+" or "1/2"
+func noaaVisibility(raw json.RawMessage) (float64, bool) {
+	var miles float64
+	if json.Unmarshal(raw, &miles) == nil {
+		return miles, true
+	}
+	var text string
+	if json.Unmarshal(raw, &text) != nil {
+		return 0, false
+	}
+	text = strings.TrimSuffix(strings.TrimSpace(text), "+")
+	if num, den, ok := strings.Cut(text, "/"); ok {
+		n, err1 := strconv.ParseFloat(num, 64)
+		d, err2 := strconv.ParseFloat(den, 64)
+		if err1 != nil || err2 != nil || d == 0 {
+			return 0, false
+		}
+		return n / d, true
+	}
+	miles, err := strconv.ParseFloat(text, 64)
+	return miles, err == nil
+}
+
+// forecastData converts the TAF to ForecastData. Only the validity and the
+// raw text are set; use ParseTAF for the decoded conditions.
+func (t *noaaTAF) forecastData() ForecastData {
+	forecast := ForecastData{TAF: t.RawTAF}
+	if t.ValidTimeFrom > 0 {
+		forecast.ValidFrom = time.Unix(t.ValidTimeFrom, 0).UTC().Format(time.RFC3339)
+	}
+	if t.ValidTimeTo > 0 {
+		forecast.ValidTo = time.Unix(t.ValidTimeTo, 0).UTC().Format(time.RFC3339)
+	}
+	return forecast
+}
@@ -0,0 +1,289 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// TrendDirection is how conditions are forecast to change
+type TrendDirection string
+
+// Trend directions
+const (
+	TrendImproving     TrendDirection = "improving"
+	TrendDeteriorating TrendDirection = "deteriorating"
+	TrendStable        TrendDirection = "stable"
+	TrendUnknown       TrendDirection = "unknown"
+)
+
+// Trend horizon and tolerances
+const (
+	weatherTrendHorizon = 6 * time.Hour
+	// windTrendToleranceKt is the change in wind, gusts included, that
+	// counts as a trend
+	windTrendToleranceKt = 10
+)
+
+// DimensionTrend is the trend of one weather element
+type DimensionTrend struct {
+	Dimension string         `json:"dimension"` // visibility, ceiling or wind
+	Direction TrendDirection `json:"direction"`
+	Current   string         `json:"current,omitempty"`
+	Forecast  string         `json:"forecast,omitempty"` // The forecast value the direction is based on
+	At        time.Time      `json:"at"`                 // When the forecast value is reached, zero unless improving or deteriorating
+}
+
+// WeatherTrend compares the current observation at an airport with its
+// forecast for the next six hours
+type WeatherTrend struct {
+	Airport    string         `json:"airport"`
+	Overall    TrendDirection `json:"overall"`
+	Visibility DimensionTrend `json:"visibility"`
+	Ceiling    DimensionTrend `json:"ceiling"`
+	Wind       DimensionTrend `json:"wind"`
+	Summary    string         `json:"summary"`
+}
+
+// GetWeatherTrend compares the current METAR at an airport with its TAF and
+// reports whether conditions are improving, deteriorating or stable over the
+// next six hours. Without a usable METAR or TAF the trend is TrendUnknown.
+func (w *WeatherAPI) GetWeatherTrend(airportCode string) (*WeatherTrend, error) {
+	return w.GetWeatherTrendContext(context.Background(), airportCode)
+}
+
+// GetWeatherTrendContext compares the current METAR at an airport with its
+// TAF, aborting when ctx is done
+func (w *WeatherAPI) GetWeatherTrendContext(ctx context.Context, airportCode string) (*WeatherTrend, error) {
+	current, err := w.GetCurrentWeatherContext(ctx, airportCode)
+	if err != nil {
+		return nil, err
+	}
+	forecast, err := w.GetWeatherForecastContext(ctx, airportCode)
+	if err != nil {
+		return nil, err
+	}
+
+	var tafs []string
+	for _, f := range forecast.Forecast {
+		if f.TAF != "" {
+			tafs = append(tafs, f.TAF)
+		}
+	}
+	trend := weatherTrend(current.CurrentWeather.METAR, tafs, time.Now().UTC())
+	trend.Airport = airportCode
+	return trend, nil
+}
+
+// trendSample is one weather element's value; higher scores are better
+type trendSample struct {
+	score float64
+	text  string
+	known bool
+}
+
+// weatherTrend compares metar with the first TAF valid at now
+func weatherTrend(metar string, tafs []string, now time.Time) *WeatherTrend {
+	trend := &WeatherTrend{
+		Overall:    TrendUnknown,
+		Visibility: DimensionTrend{Dimension: "visibility", Direction: TrendUnknown},
+		Ceiling:    DimensionTrend{Dimension: "ceiling", Direction: TrendUnknown},
+		Wind:       DimensionTrend{Dimension: "wind", Direction: TrendUnknown},
+	}
+
+	report, err := parseMETARAt(metar, now)
+	if metar == "" || err != nil {
+		trend.Summary = "Trend unknown: no usable METAR"
+		return trend
+	}
+	var taf *TAFReport
+	for _, raw := range tafs {
+		if parsed, err := parseTAFAt(raw, now); err == nil && !now.Before(parsed.ValidFrom) && now.Before(parsed.ValidTo) {
+			taf = parsed
+			break
+		}
+	}
+	if taf == nil {
+		trend.Summary = "Trend unknown: no TAF valid now"
+		return trend
+	}
+
+	layers := report.Clouds
+	if report.VerticalVisibilityFt > 0 {
+		layers = append(layers[:len(layers):len(layers)], CloudLayer{Coverage: "VV", AltitudeFt: report.VerticalVisibilityFt})
+	}
+	currentVisibility := visibilitySample(report.VisibilityMeters, report.VisibilityMeters > 0)
+	// A METAR without cloud groups reports no ceiling
+	currentCeiling := ceilingSample(layers, true)
+	currentWind := windSample(report.WindSpeed, report.WindGust, report.WindUnit, report.WindUnit != "")
+
+	var visibility, ceiling, wind []timedSample
+	for _, t := range trendSampleTimes(taf, now, now.Add(weatherTrendHorizon)) {
+		conditions, err := taf.ConditionsAt(t)
+		if err != nil {
+			// Past the end of the TAF's validity
+			break
+		}
+		p := &conditions.Prevailing
+		periodLayers := p.Clouds
+		if p.VerticalVisibilityFt > 0 {
+			periodLayers = append(periodLayers[:len(periodLayers):len(periodLayers)], CloudLayer{Coverage: "VV", AltitudeFt: p.VerticalVisibilityFt})
+		}
+		visibility = append(visibility, timedSample{t, visibilitySample(p.VisibilityMeters, p.hasVisibility && p.VisibilityMeters > 0)})
+		ceiling = append(ceiling, timedSample{t, ceilingSample(periodLayers, p.hasClouds)})
+		wind = append(wind, timedSample{t, windSample(p.WindSpeed, p.WindGust, p.WindUnit, p.hasWind)})
+	}
+
+	trend.Visibility = dimensionTrend("visibility", currentVisibility, visibility, 1)
+	trend.Ceiling = dimensionTrend("ceiling", currentCeiling, ceiling, 1)
+	trend.Wind = dimensionTrend("wind", currentWind, wind, windTrendToleranceKt)
+	trend.Overall, trend.Summary = summarizeTrend(trend)
+	return trend
+}
+
+// trendSampleTimes returns the times between start and end at which the
+// TAF's prevailing conditions are compared: both ends and every change group
+// boundary, in order
+func trendSampleTimes(taf *TAFReport, start, end time.Time) []time.Time {
+	times := []time.Time{start, end}
+	for _, period := range taf.Periods {
+		switch period.Type {
+		case TAFFrom:
+			times = append(times, period.From)
+		case TAFBecmg:
+			// Prevailing conditions only take on the change once it is complete
+			times = append(times, period.To)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	var inRange []time.Time
+	for _, t := range times {
+		if t.Before(start) || t.After(end) || (len(inRange) > 0 && t.Equal(inRange[len(inRange)-1])) {
+			continue
+		}
+		inRange = append(inRange, t)
+	}
+	return inRange
+}
+
+// timedSample is a forecast value at a point in time
+type timedSample struct {
+	at time.Time
+	trendSample
+}
+
+// visibilitySample scores visibility by flight category band, so small
+// changes within a band do not count as a trend
+func visibilitySample(meters float64, known bool) trendSample {
+	miles := meters / 1609.344
+	band := 3.0
+	switch {
+	case miles < 1:
+		band = 0
+	case miles < 3:
+		band = 1
+	case miles <= 5:
+		band = 2
+	}
+	return trendSample{score: band, text: fmt.Sprintf("%.1f mi", miles), known: known}
+}
+
+// ceilingSample scores the ceiling by flight category band
+func ceilingSample(layers []CloudLayer, known bool) trendSample {
+	_, ceilingFt := ComputeFlightCategory(0, layers)
+	band := 3.0
+	switch {
+	case ceilingFt < 500:
+		band = 0
+	case ceilingFt < 1000:
+		band = 1
+	case ceilingFt <= 3000:
+		band = 2
+	}
+	text := fmt.Sprintf("%d ft", ceilingFt)
+	if ceilingFt == UnlimitedCeilingFt {
+		text = "unlimited"
+	}
+	return trendSample{score: band, text: text, known: known}
+}
+
+// windSample scores the wind by its strongest speed in knots, lower being better
+func windSample(speed, gust int, unit string, known bool) trendSample {
+	kt := knots(math.Max(float64(speed), float64(gust)), unit)
+	return trendSample{score: -kt, text: formatKnots(kt), known: known}
+}
+
+// dimensionTrend classifies one element. It deteriorates when any forecast
+// sample is worse than the current value by tolerance, and improves when the
+// forecast at the end of the horizon is better by tolerance.
+func dimensionTrend(dimension string, current trendSample, forecast []timedSample, tolerance float64) DimensionTrend {
+	trend := DimensionTrend{Dimension: dimension, Direction: TrendUnknown}
+	if !current.known {
+		return trend
+	}
+	trend.Current = current.text
+
+	var worst, last *timedSample
+	for i := range forecast {
+		sample := &forecast[i]
+		if !sample.known {
+			continue
+		}
+		if worst == nil || sample.score < worst.score {
+			worst = sample
+		}
+		last = sample
+	}
+	switch {
+	case worst == nil:
+		return trend
+	case worst.score <= current.score-tolerance:
+		trend.Direction, trend.Forecast, trend.At = TrendDeteriorating, worst.text, worst.at
+	case last.score >= current.score+tolerance:
+		// Report when the improvement sets in for the rest of the horizon
+		at := last.at
+		for i := len(forecast) - 1; i >= 0 && (!forecast[i].known || forecast[i].score >= current.score+tolerance); i-- {
+			if forecast[i].known {
+				at = forecast[i].at
+			}
+		}
+		trend.Direction, trend.Forecast, trend.At = TrendImproving, last.text, at
+	default:
+		trend.Direction, trend.Forecast = TrendStable, last.text
+	}
+	return trend
+}
+
+// summarizeTrend derives the overall direction and names the driving
+// factor. Any deterioration outweighs improvements, and the earliest change
+// drives the summary.
+func summarizeTrend(trend *WeatherTrend) (TrendDirection, string) {
+	dimensions := []DimensionTrend{trend.Visibility, trend.Ceiling, trend.Wind}
+	for _, direction := range []TrendDirection{TrendDeteriorating, TrendImproving} {
+		var driver *DimensionTrend
+		for i := range dimensions {
+			if dimensions[i].Direction == direction && (driver == nil || dimensions[i].At.Before(driver.At)) {
+				driver = &dimensions[i]
+			}
+		}
+		if driver == nil {
+			continue
+		}
+		verb := "improving"
+		if direction == TrendDeteriorating {
+			verb = "deteriorating"
+		}
+		return direction, fmt.Sprintf("Conditions %s, driven by %s going from %s to %s by %s",
+			verb, driver.Dimension, driver.Current, driver.Forecast, driver.At.UTC().Format("1504Z"))
+	}
+
+	for _, dimension := range dimensions {
+		if dimension.Direction == TrendStable {
+			return TrendStable, fmt.Sprintf("Conditions stable, no significant change forecast in the next %.0f hours", weatherTrendHorizon.Hours())
+		}
+	}
+	return TrendUnknown, "Trend unknown: neither the METAR nor the TAF reports comparable conditions"
+}
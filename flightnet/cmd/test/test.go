@@ -57,8 +57,10 @@ func main() {
 	}
 	fmt.Printf("Fetched %d future flights\n", len(futureFlights))
 
-	// Combine all data
+	// Combine all data, with when it was fetched so the dump can be
+	// backfilled into the snapshot history at its original time
 	combinedData := map[string]interface{}{
+		"timestamp":     time.Now().UTC().Format(time.RFC3339),
 		"aircraft":      aircraft,
 		"flights":       flights,
 		"futureFlights": futureFlights,
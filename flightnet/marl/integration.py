@@ -15,7 +15,7 @@ class EnhancedFlightOptimizer:
         env_data = self.go_client.get_flight_environment_data(route=route)
         
         constraints = {
-            "no_fly_zones": set(env_data.get("no_fly_zones", [])),
+            "no_fly_zones": set(env_data.get("no_fly_zone_codes", env_data.get("no_fly_zones", []))),
             "weather_restrictions": self._extract_weather_restrictions(env_data.get("weather", {})),
             "geopolitical_risks": self._extract_geopolitical_risks(env_data.get("geopolitical", {})),
             "sustainability_targets": self._extract_sustainability_targets(env_data.get("sustainability", {})),
@@ -0,0 +1,188 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DumpLists are the record lists of an aviation-edge dump, as cmd/test
+// writes it
+var DumpLists = []string{"aircraft", "flights", "futureFlights"}
+
+// dumpAircraft is an aviation-edge airplaneDatabase record
+type dumpAircraft struct {
+	AirplaneID         string `json:"airplaneId"`
+	NumberRegistration string `json:"numberRegistration"`
+	AirplaneIataType   string `json:"airplaneIataType"`
+	PlaneModel         string `json:"planeModel"`
+	HexIcaoAirplane    string `json:"hexIcaoAirplane"`
+	CodeIataAirline    string `json:"codeIataAirline"`
+	PlaneStatus        string `json:"planeStatus"`
+}
+
+// dumpAirport is the departure or arrival of an aviation-edge flight
+type dumpAirport struct {
+	IataCode      string `json:"iataCode"`
+	IcaoCode      string `json:"icaoCode"`
+	ScheduledTime string `json:"scheduledTime"`
+}
+
+// dumpFlight is an aviation-edge flights or timetable record
+type dumpFlight struct {
+	Geography *Position   `json:"geography"`
+	Departure dumpAirport `json:"departure"`
+	Arrival   dumpAirport `json:"arrival"`
+	Aircraft  struct {
+		RegNumber string `json:"regNumber"`
+	} `json:"aircraft"`
+	Flight struct {
+		Number     string `json:"number"`
+		IataNumber string `json:"iataNumber"`
+		IcaoNumber string `json:"icaoNumber"`
+	} `json:"flight"`
+	Airline struct {
+		Name     string `json:"name"`
+		IataCode string `json:"iataCode"`
+		IcaoCode string `json:"icaoCode"`
+	} `json:"airline"`
+	Status string `json:"status"`
+}
+
+// scheduledTimeLayouts are the forms aviation-edge gives scheduled times
+// in. Those without a zone are taken as UTC.
+var scheduledTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+}
+
+// DecodeDump converts an aviation-edge dump, an object of the lists in
+// DumpLists and the RFC 3339 time it was taken at, into a snapshot. The
+// snapshot's timestamp is zero when the dump has none. Records that do not
+// decode or identify nothing are left out and listed in problems; err is set
+// when the dump has no valid records.
+func DecodeDump(body []byte) (snapshot *Snapshot, problems []string, err error) {
+	var dump map[string]json.RawMessage
+	if err := json.Unmarshal(body, &dump); err != nil {
+		return nil, nil, fmt.Errorf("decoding dump: %w", err)
+	}
+
+	snapshot = &Snapshot{}
+	if raw, ok := dump["timestamp"]; ok {
+		var timestamp string
+		if err := json.Unmarshal(raw, &timestamp); err != nil {
+			return nil, nil, fmt.Errorf("timestamp %s is not a string", raw)
+		}
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err != nil {
+			return nil, nil, fmt.Errorf("timestamp %q is not an RFC 3339 time", timestamp)
+		}
+		snapshot.Timestamp = t.UTC()
+	}
+
+	for _, name := range DumpLists {
+		raw, ok := dump[name]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+		var records []json.RawMessage
+		if err := json.Unmarshal(raw, &records); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: not a list of records", name))
+			continue
+		}
+		for i, record := range records {
+			var err error
+			if name == "aircraft" {
+				err = snapshot.addAircraft(record)
+			} else {
+				err = snapshot.addFlight(record)
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s %d: %v", name, i, err))
+			}
+		}
+	}
+	if len(snapshot.Aircraft) == 0 && len(snapshot.Flights) == 0 {
+		return nil, problems, errors.New("no valid records")
+	}
+	return snapshot, problems, nil
+}
+
+// addAircraft adds an airplaneDatabase record to the snapshot
+func (s *Snapshot) addAircraft(record json.RawMessage) error {
+	var a dumpAircraft
+	if err := json.Unmarshal(record, &a); err != nil {
+		return err
+	}
+	if a.AirplaneID == "" && a.NumberRegistration == "" {
+		return errors.New("no airplaneId or numberRegistration")
+	}
+	s.Aircraft = append(s.Aircraft, Aircraft{
+		ID:           a.AirplaneID,
+		Registration: a.NumberRegistration,
+		Model:        a.PlaneModel,
+		TypeCode:     a.AirplaneIataType,
+		Airline:      a.CodeIataAirline,
+		ICAO24:       strings.ToLower(a.HexIcaoAirplane),
+		Status:       a.PlaneStatus,
+	})
+	return nil
+}
+
+// addFlight adds a flights or timetable record to the snapshot
+func (s *Snapshot) addFlight(record json.RawMessage) error {
+	var f dumpFlight
+	if err := json.Unmarshal(record, &f); err != nil {
+		return err
+	}
+	flight := Flight{
+		Number:      firstNonEmpty(f.Flight.IataNumber, f.Flight.IcaoNumber, f.Flight.Number),
+		Airline:     firstNonEmpty(f.Airline.Name, f.Airline.IataCode, f.Airline.IcaoCode),
+		Origin:      firstNonEmpty(f.Departure.IataCode, f.Departure.IcaoCode),
+		Destination: firstNonEmpty(f.Arrival.IataCode, f.Arrival.IcaoCode),
+		Status:      f.Status,
+		Aircraft:    f.Aircraft.RegNumber,
+	}
+	if flight.Number == "" {
+		return errors.New("no flight number")
+	}
+	var err error
+	if flight.DepartureTime, err = parseScheduledTime(f.Departure.ScheduledTime); err != nil {
+		return fmt.Errorf("departure: %w", err)
+	}
+	if flight.ArrivalTime, err = parseScheduledTime(f.Arrival.ScheduledTime); err != nil {
+		return fmt.Errorf("arrival: %w", err)
+	}
+	if f.Geography != nil && (f.Geography.Latitude != 0 || f.Geography.Longitude != 0) {
+		position := *f.Geography
+		flight.Position = &position
+	}
+	s.Flights = append(s.Flights, flight)
+	return nil
+}
+
+// parseScheduledTime parses an aviation-edge scheduled time, the zero time
+// when there is none
+func parseScheduledTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range scheduledTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("scheduled time %q is not a time", value)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeDump(t *testing.T) {
+	body := `{
+		"timestamp": "2024-03-01T12:00:00Z",
+		"aircraft": [
+			{"airplaneId": "1", "numberRegistration": "N123AA", "planeModel": "737", "hexIcaoAirplane": "A1B2C3"},
+			{"airplaneId": ""},
+			"not a record"
+		],
+		"flights": [
+			{
+				"geography": {"latitude": 51.5, "longitude": -20.1, "altitude": 11000},
+				"departure": {"iataCode": "JFK", "scheduledTime": "2024-03-01T10:00:00.000"},
+				"arrival": {"iataCode": "LHR", "scheduledTime": "2024-03-01T22:00:00"},
+				"aircraft": {"regNumber": "G-XWBA"},
+				"flight": {"iataNumber": "BA114", "icaoNumber": "BAW114"},
+				"status": "en-route"
+			},
+			{"flight": {"iataNumber": "BA116"}, "departure": {"scheduledTime": "soon"}}
+		],
+		"futureFlights": [
+			{"flight": {"icaoNumber": "BAW118"}, "departure": {"icaoCode": "KJFK"}, "arrival": {"icaoCode": "EGLL"}}
+		]
+	}`
+
+	snapshot, problems, err := DecodeDump([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC); !snapshot.Timestamp.Equal(want) {
+		t.Errorf("timestamp %s, want %s", snapshot.Timestamp, want)
+	}
+	if len(snapshot.Aircraft) != 1 || snapshot.Aircraft[0].ICAO24 != "a1b2c3" {
+		t.Errorf("aircraft %+v, want N123AA with its address lowercased", snapshot.Aircraft)
+	}
+	if len(snapshot.Flights) != 2 {
+		t.Fatalf("decoded %d flights, want 2: %+v", len(snapshot.Flights), snapshot.Flights)
+	}
+	f := snapshot.Flights[0]
+	if f.Number != "BA114" || f.Origin != "JFK" || f.Destination != "LHR" || f.Aircraft != "G-XWBA" {
+		t.Errorf("flight %+v, want BA114 JFK-LHR on G-XWBA", f)
+	}
+	if want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC); !f.DepartureTime.Equal(want) {
+		t.Errorf("departure %s, want %s", f.DepartureTime, want)
+	}
+	if f.Position == nil || f.Position.Altitude != 11000 {
+		t.Errorf("position %+v, want the tracked geography", f.Position)
+	}
+	if g := snapshot.Flights[1]; g.Number != "BAW118" || g.Origin != "KJFK" || g.Position != nil {
+		t.Errorf("future flight %+v, want BAW118 from KJFK without a position", g)
+	}
+
+	wantProblems := []string{"aircraft 1:", "aircraft 2:", "flights 1: departure:"}
+	if len(problems) != len(wantProblems) {
+		t.Fatalf("problems %q, want %d", problems, len(wantProblems))
+	}
+	for i, prefix := range wantProblems {
+		if !strings.HasPrefix(problems[i], prefix) {
+			t.Errorf("problem %q, want it to start %q", problems[i], prefix)
+		}
+	}
+}
+
+func TestDecodeDumpFailures(t *testing.T) {
+	tests := map[string]string{
+		"not JSON":        `{"aircraft": `,
+		"bad timestamp":   `{"timestamp": "yesterday", "aircraft": [{"airplaneId": "1"}]}`,
+		"no valid record": `{"aircraft": [{}], "flights": [{}]}`,
+	}
+	for name, body := range tests {
+		if _, _, err := DecodeDump([]byte(body)); err == nil {
+			t.Errorf("%s: decoded without an error", name)
+		}
+	}
+
+	snapshot, _, err := DecodeDump([]byte(`{"aircraft": [{"airplaneId": "1"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapshot.Timestamp.IsZero() {
+		t.Errorf("timestamp %s for a dump without one, want zero", snapshot.Timestamp)
+	}
+}
@@ -0,0 +1,43 @@
+// Package models holds the flight data FlightNet records over time, and
+// converts exported upstream data into it.
+package models
+
+import "time"
+
+// Position is where a tracked aircraft was
+type Position struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"` // Meters
+}
+
+// Aircraft is an airframe from an aircraft database
+type Aircraft struct {
+	ID           string `json:"id"`
+	Registration string `json:"registration"`
+	Model        string `json:"model"`
+	TypeCode     string `json:"type_code"` // IATA aircraft type
+	Airline      string `json:"airline"`   // IATA code of the operator
+	ICAO24       string `json:"icao24"`    // Transponder address
+	Status       string `json:"status"`
+}
+
+// Flight is a scheduled or tracked flight
+type Flight struct {
+	Number        string    `json:"number"` // IATA flight number, ICAO when there is none
+	Airline       string    `json:"airline"`
+	Origin        string    `json:"origin"` // IATA airport code, ICAO when there is none
+	Destination   string    `json:"destination"`
+	DepartureTime time.Time `json:"departure_time"` // Zero when not scheduled
+	ArrivalTime   time.Time `json:"arrival_time"`
+	Status        string    `json:"status"`
+	Aircraft      string    `json:"aircraft"`           // Registration
+	Position      *Position `json:"position,omitempty"` // Nil unless tracked
+}
+
+// Snapshot is the flight data exported at one time
+type Snapshot struct {
+	Timestamp time.Time  `json:"timestamp"` // Zero when the export did not record it
+	Aircraft  []Aircraft `json:"aircraft"`
+	Flights   []Flight   `json:"flights"`
+}